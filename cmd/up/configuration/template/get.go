@@ -0,0 +1,45 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package template
+
+import (
+	"context"
+
+	"github.com/pterm/pterm"
+	"github.com/upbound/up-sdk-go/service/configurations"
+	"github.com/upbound/up/internal/upbound"
+	"github.com/upbound/up/internal/upterm"
+)
+
+// getCmd gets a single configuration template on Upbound.
+type getCmd struct {
+	ID string `arg:"" required:"" help:"ID of the configuration template." predictor:"templates"`
+}
+
+// Run executes the get command.
+func (c *getCmd) Run(ctx context.Context, printer upterm.ObjectPrinter, p pterm.TextPrinter, cc *configurations.Client, upCtx *upbound.Context) error {
+	// Templates cannot be fetched individually.
+	templateList, err := cc.ListTemplates(ctx)
+	if err != nil {
+		return err
+	}
+	for _, t := range templateList.Templates {
+		if t.ID == c.ID {
+			return printer.Print(t, fieldNames, extractFields)
+		}
+	}
+	p.Printfln("Configuration template %s not found", c.ID)
+	return nil
+}
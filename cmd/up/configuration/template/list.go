@@ -23,8 +23,6 @@ import (
 	"github.com/upbound/up/internal/upterm"
 )
 
-var fieldNames = []string{"ID", "DESCRIPTION", "REPO"}
-
 // listCmd lists configuration templates on Upbound.
 type listCmd struct{}
 
@@ -40,9 +38,3 @@ func (c *listCmd) Run(ctx context.Context, printer upterm.ObjectPrinter, p pterm
 	}
 	return printer.Print(templateList.Templates, fieldNames, extractFields)
 }
-
-// extractFields helps render the console output by mapping the response to desired fields.
-func extractFields(obj any) []string {
-	o := obj.(configurations.ConfigurationTemplateReponse)
-	return []string{o.ID, o.Name, o.Repo}
-}
@@ -36,11 +36,20 @@ func (c *Cmd) AfterApply(kongCtx *kong.Context, upCtx *upbound.Context) error {
 }
 
 // Cmd contains commands for managing configuration templates
-// Today, there is only one option: List
+// Today, templates can only be listed and inspected.
 // The creation of new configuration templates is managed by Upbound,
 // not users.
 type Cmd struct {
 	List listCmd `cmd:"" help:"List the configuration templates."`
+	Get  getCmd  `cmd:"" help:"Get a configuration template."`
+}
+
+var fieldNames = []string{"ID", "DESCRIPTION", "REPO"}
+
+// extractFields helps render the console output by mapping the response to desired fields.
+func extractFields(obj any) []string {
+	o := obj.(configurations.ConfigurationTemplateReponse)
+	return []string{o.ID, o.Name, o.Repo}
 }
 
 func PredictTemplates() complete.Predictor {
@@ -16,6 +16,7 @@ package controlplane
 
 import (
 	"context"
+	"io"
 	"time"
 
 	"github.com/alecthomas/kong"
@@ -23,16 +24,25 @@ import (
 	"github.com/upbound/up/internal/upterm"
 	"k8s.io/apimachinery/pkg/util/duration"
 
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+
 	cp "github.com/upbound/up-sdk-go/service/controlplanes"
 	"github.com/upbound/up/cmd/up/controlplane/connector"
 	"github.com/upbound/up/cmd/up/controlplane/kubeconfig"
 	"github.com/upbound/up/cmd/up/controlplane/pkg"
 	"github.com/upbound/up/cmd/up/controlplane/pullsecret"
+	"github.com/upbound/up/internal/config"
 	"github.com/upbound/up/internal/controlplane"
 	"github.com/upbound/up/internal/feature"
 	"github.com/upbound/up/internal/upbound"
 )
 
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+)
+
 var (
 	cloudfieldNames = []string{"NAME", "CONFIGURATION", "UPDATED", "SYNCED", "READY", "MESSAGE", "AGE"}
 	spacefieldNames = []string{"GROUP", "NAME", "CROSSPLANE", "SYNCED", "READY", "MESSAGE", "AGE"}
@@ -94,8 +104,12 @@ type Cmd struct {
 	Disconnect disconnectCmd `cmd:"" help:"Disconnect kubectl from control plane."`
 	Create     createCmd     `cmd:"" help:"Create a managed control plane."`
 	Delete     deleteCmd     `cmd:"" help:"Delete a control plane."`
+	Copy       copyCmd       `cmd:"" help:"Copy the state of a control plane into another one in a Space."`
 	List       listCmd       `cmd:"" help:"List control planes for the account."`
 	Get        getCmd        `cmd:"" help:"Get a single control plane."`
+	Pause      pauseCmd      `cmd:"" help:"Pause the Crossplane instance of a control plane in a Space."`
+	Resume     resumeCmd     `cmd:"" help:"Resume the paused Crossplane instance of a control plane in a Space."`
+	Logs       logsCmd       `cmd:"" help:"Print the logs of the Crossplane instance of a control plane."`
 
 	Connector connector.Cmd `cmd:"" help:"Connect an App Cluster to a managed control plane."`
 
@@ -159,9 +173,52 @@ func formatAge(age *time.Duration) string {
 	return duration.HumanDuration(*age)
 }
 
-func tabularPrint(obj any, printer upterm.ObjectPrinter, upCtx *upbound.Context) error {
+// outputFormat returns the output format of the get and list commands, i.e.
+// output if -o/--output was passed, or the one matching the global --format
+// otherwise. Passing both with different formats is an error.
+func outputFormat(kongCtx *kong.Context, output string, printer upterm.ObjectPrinter) (string, error) {
+	format := outputTable
+	switch printer.Format {
+	case config.JSON:
+		format = outputJSON
+	case config.YAML:
+		format = outputYAML
+	case config.Default:
+	}
+	passed := false
+	for _, p := range kongCtx.Path {
+		if p.Flag != nil && !p.Resolved && p.Flag.Name == "output" {
+			passed = true
+		}
+	}
+	if !passed {
+		return format, nil
+	}
+	if printer.Format != "" && printer.Format != config.Default && output != format {
+		return "", errors.Errorf("--output=%s conflicts with --format=%s", output, printer.Format)
+	}
+	return output, nil
+}
+
+// tabularPrint prints the control plane response(s) in obj to w in the output
+// format, i.e. the full responses as JSON or YAML, or their display fields as
+// a table.
+func tabularPrint(obj any, output string, w io.Writer, printer upterm.ObjectPrinter, upCtx *upbound.Context) error {
+	switch output {
+	case outputJSON:
+		return printer.PrintJSON(obj, w)
+	case outputYAML:
+		return printer.PrintYAML(obj, w)
+	}
+	fieldNames, extractFields := tableFields(upCtx)
+	return printer.Print(obj, fieldNames, extractFields)
+}
+
+// tableFields returns the column names and the function extracting them from
+// a control plane response for the profile in upCtx.
+func tableFields(upCtx *upbound.Context) ([]string, func(any) []string) {
 	if upCtx.Profile.IsSpace() {
-		return printer.Print(obj, spacefieldNames, extractSpaceFields)
+		return spacefieldNames, extractSpaceFields
 	}
-	return printer.Print(obj, cloudfieldNames, extractCloudFields)
+	return cloudfieldNames, extractCloudFields
 }
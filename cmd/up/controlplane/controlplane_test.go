@@ -0,0 +1,91 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controlplane
+
+import (
+	"testing"
+
+	"github.com/alecthomas/kong"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/upbound/up/internal/config"
+	"github.com/upbound/up/internal/upterm"
+)
+
+func TestOutputFormat(t *testing.T) {
+	type args struct {
+		args   []string
+		format config.Format
+	}
+	type want struct {
+		output string
+		err    bool
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"Defaults": {
+			reason: "Without -o and --format, a table should be printed.",
+			args:   args{format: config.Default},
+			want:   want{output: outputTable},
+		},
+		"FromFormat": {
+			reason: "Without -o, the output format should be derived from --format.",
+			args:   args{format: config.YAML},
+			want:   want{output: outputYAML},
+		},
+		"FromOutput": {
+			reason: "Without --format, -o should be used as is.",
+			args:   args{args: []string{"-o", "json"}, format: config.Default},
+			want:   want{output: outputJSON},
+		},
+		"Matching": {
+			reason: "Passing both with the same format should be allowed.",
+			args:   args{args: []string{"--output=json"}, format: config.JSON},
+			want:   want{output: outputJSON},
+		},
+		"Conflicting": {
+			reason: "Passing both with different formats should be rejected.",
+			args:   args{args: []string{"-o", "table"}, format: config.JSON},
+			want:   want{err: true},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			// The output flag of the get and list commands.
+			cmd := &struct {
+				Output string `short:"o" enum:"table,json,yaml" default:"table"`
+			}{}
+			k, err := kong.New(cmd)
+			if err != nil {
+				t.Fatalf("kong.New(...): unexpected error: %v", err)
+			}
+			kongCtx, err := k.Parse(tc.args.args)
+			if err != nil {
+				t.Fatalf("Parse(...): unexpected error: %v", err)
+			}
+			got, err := outputFormat(kongCtx, cmd.Output, upterm.ObjectPrinter{Format: tc.args.format})
+			if (err != nil) != tc.want.err {
+				t.Fatalf("\n%s\noutputFormat(...): error = %v, want error %t", tc.reason, err, tc.want.err)
+			}
+			if diff := cmp.Diff(tc.want.output, got); diff != "" {
+				t.Errorf("\n%s\noutputFormat(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,226 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controlplane
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pterm/pterm"
+	"github.com/spf13/afero"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	appsv1 "k8s.io/client-go/kubernetes/typed/apps/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+
+	"github.com/upbound/up/cmd/up/controlplane/kubeconfig"
+	"github.com/upbound/up/internal/controlplane"
+	"github.com/upbound/up/internal/controlplane/space"
+	"github.com/upbound/up/internal/upbound"
+	"github.com/upbound/up/pkg/migration/crossplane"
+	"github.com/upbound/up/pkg/migration/exporter"
+	"github.com/upbound/up/pkg/migration/importer"
+)
+
+// copyDir is the directory the state is exported to in memory.
+const copyDir = "/xp-state"
+
+type ctpKubeConfigGetter interface {
+	GetKubeConfig(ctx context.Context, ctp types.NamespacedName) (*api.Config, error)
+}
+
+// copyCmd copies the state of a control plane into another one in a Space.
+type copyCmd struct {
+	Source string `required:"" help:"The control plane to copy the state of, as '<group>/<name>' or '<name>' for a control plane in the group of the current profile."`
+	Target string `required:"" help:"The control plane to copy the state into, as '<group>/<name>' or '<name>' for a control plane in the group of the current profile."`
+
+	IncludeNamespaces  []string `help:"A list of specific namespaces to copy. If not specified, all namespaces are copied."`
+	ExcludeNamespaces  []string `help:"A list of specific namespaces not to copy. Defaults to 'kube-system', 'kube-public', 'kube-node-lease', and 'local-path-storage'." default:"kube-system,kube-public,kube-node-lease,local-path-storage"`
+	UnpauseAfterImport bool     `help:"When set to true, unpauses the managed resources in the target control plane once they are imported. Defaults to false."`
+
+	Yes bool `help:"When set to true, copies the state even if preflight checks fail. Defaults to false."`
+
+	client ctpKubeConfigGetter
+	group  string
+}
+
+func (c *copyCmd) Help() string {
+	return `
+Copy the state of a control plane into another control plane in the same Space,
+e.g. to recreate a control plane in another group. The state is exported from
+the source control plane into memory and imported into the target control
+plane, like 'up alpha migration migrate' does. Resources are not removed from
+the source control plane.
+
+Examples:
+    up ctp copy --source=team-a/ctp --target=team-b/ctp --unpause-after-import
+        Copies the state of control plane 'ctp' in group 'team-a' into control
+        plane 'ctp' in group 'team-b', and unpauses the imported managed
+        resources.
+`
+}
+
+// AfterApply sets default values in command after assignment and validation.
+func (c *copyCmd) AfterApply(upCtx *upbound.Context) error {
+	if !upCtx.Profile.IsSpace() {
+		return fmt.Errorf("copy is not supported for Upbound Cloud profile %q", upCtx.ProfileName)
+	}
+	kubeconfig, ns, err := upCtx.Profile.GetSpaceKubeConfig()
+	if err != nil {
+		return err
+	}
+	c.group = ns
+
+	client, err := dynamic.NewForConfig(kubeconfig)
+	if err != nil {
+		return err
+	}
+	c.client = space.New(client)
+	return nil
+}
+
+// Run executes the copy command.
+func (c *copyCmd) Run(ctx context.Context, p pterm.TextPrinter, upCtx *upbound.Context) error {
+	source, err := parseControlPlane(c.Source, c.group)
+	if err != nil {
+		return errors.Wrap(err, "invalid source control plane")
+	}
+	target, err := parseControlPlane(c.Target, c.group)
+	if err != nil {
+		return errors.Wrap(err, "invalid target control plane")
+	}
+	if source == target {
+		return errors.New("source and target control plane must differ")
+	}
+	sourceCfg, err := restConfig(ctx, c.client, source, upCtx)
+	if err != nil {
+		return errors.Wrapf(err, "cannot get kubeconfig of source control plane %s", source)
+	}
+	targetCfg, err := restConfig(ctx, c.client, target, upCtx)
+	if err != nil {
+		return errors.Wrapf(err, "cannot get kubeconfig of target control plane %s", target)
+	}
+
+	fs := afero.NewMemMapFs()
+	e, err := exporter.NewControlPlaneStateExporterForConfig(sourceCfg, exporter.Options{
+		OutputArchive:         copyDir,
+		OutputFormat:          exporter.OutputFormatDirectory,
+		OutputFS:              fs,
+		IncludeNamespaces:     c.IncludeNamespaces,
+		ExcludeNamespaces:     c.ExcludeNamespaces,
+		IncludeExtraResources: []string{"namespaces", "configmaps", "secrets"},
+		// The state is not staged on disk.
+		SkipStorageCheck: true,
+	})
+	if err != nil {
+		return err
+	}
+	i, err := importer.NewControlPlaneStateImporterForConfig(targetCfg, importer.Options{
+		InputArchive:       copyDir,
+		InputFormat:        importer.InputFormatDirectory,
+		InputFS:            fs,
+		UnpauseAfterImport: c.UnpauseAfterImport,
+	})
+	if err != nil {
+		return err
+	}
+	defer i.Close() //nolint:errcheck // Nothing is spilled to disk.
+
+	// The target is checked before anything is exported, as far as possible
+	// without the export.
+	appsClient, err := appsv1.NewForConfig(sourceCfg)
+	if err != nil {
+		return err
+	}
+	xp, err := crossplane.CollectInfo(ctx, appsClient)
+	if err != nil {
+		return errors.Wrapf(err, "cannot get Crossplane info of source control plane %s", source)
+	}
+	if err := c.preflight(p, i.TargetPreflightChecks(ctx, xp), "copy"); err != nil {
+		return err
+	}
+	if err := c.preflight(p, e.PreflightChecks(ctx), "export"); err != nil {
+		return err
+	}
+	if err := e.Export(ctx); err != nil {
+		return errors.Wrapf(err, "cannot export source control plane %s", source)
+	}
+	if err := c.preflight(p, i.PreflightChecks(ctx), "import"); err != nil {
+		return err
+	}
+	if err := i.Import(ctx); err != nil {
+		return errors.Wrapf(err, "cannot import into target control plane %s", target)
+	}
+	p.Printfln("%s copied to %s", source, target)
+	return nil
+}
+
+// restConfig returns the REST config of the supplied control plane.
+func restConfig(ctx context.Context, client ctpKubeConfigGetter, ctp types.NamespacedName, upCtx *upbound.Context) (*rest.Config, error) {
+	cfg, err := client.GetKubeConfig(ctx, ctp)
+	if controlplane.IsNotFound(err) {
+		return nil, errors.Errorf("control plane %s not found", ctp)
+	}
+	if err != nil {
+		return nil, err
+	}
+	cfg, err = kubeconfig.ExtractControlPlaneContext(cfg, kubeconfig.ExpectedConnectionSecretContext(upCtx.Account, ctp.Name), ctp.String())
+	if err != nil {
+		return nil, err
+	}
+	rc, err := clientcmd.NewDefaultClientConfig(*cfg, &clientcmd.ConfigOverrides{}).ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	if upCtx.WrapTransport != nil {
+		rc.Wrap(upCtx.WrapTransport)
+	}
+	return rc, nil
+}
+
+// preflight prints the failed preflight checks of the operation, if any, and
+// returns an error unless they are to be ignored.
+func (c *copyCmd) preflight(p pterm.TextPrinter, errs []error, operation string) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	p.Printfln("Preflight checks of the %s failed:", operation)
+	for _, err := range errs {
+		p.Printfln("- %s", err)
+	}
+	if c.Yes {
+		return nil
+	}
+	return errors.Errorf("preflight checks must pass in order to proceed with the %s, use --yes to ignore them", operation)
+}
+
+// parseControlPlane parses a control plane reference of the form
+// '<group>/<name>' or '<name>', which refers to a control plane in the
+// default group.
+func parseControlPlane(s, defaultGroup string) (types.NamespacedName, error) {
+	group, name, ok := strings.Cut(s, "/")
+	if !ok {
+		group, name = defaultGroup, s
+	}
+	if group == "" || name == "" || strings.Contains(name, "/") {
+		return types.NamespacedName{}, errors.Errorf("%q is not of the form '<group>/<name>' or '<name>'", s)
+	}
+	return types.NamespacedName{Namespace: group, Name: name}, nil
+}
@@ -0,0 +1,74 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controlplane
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestParseControlPlane(t *testing.T) {
+	type want struct {
+		ctp types.NamespacedName
+		err bool
+	}
+	cases := map[string]struct {
+		reason string
+		s      string
+		want   want
+	}{
+		"GroupAndName": {
+			reason: "A control plane in another group should be parsed.",
+			s:      "team-a/ctp",
+			want: want{
+				ctp: types.NamespacedName{Namespace: "team-a", Name: "ctp"},
+			},
+		},
+		"Name": {
+			reason: "A control plane without a group should be in the default group.",
+			s:      "ctp",
+			want: want{
+				ctp: types.NamespacedName{Namespace: "default", Name: "ctp"},
+			},
+		},
+		"EmptyGroup": {
+			reason: "A control plane with an empty group should be rejected.",
+			s:      "/ctp",
+			want: want{
+				err: true,
+			},
+		},
+		"TooManySegments": {
+			reason: "A control plane with more than two segments should be rejected.",
+			s:      "team-a/ctp/extra",
+			want: want{
+				err: true,
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := parseControlPlane(tc.s, "default")
+			if (err != nil) != tc.want.err {
+				t.Fatalf("\n%s\nparseControlPlane(...): error = %v, want error %t", tc.reason, err, tc.want.err)
+			}
+			if diff := cmp.Diff(tc.want.ctp, got); diff != "" {
+				t.Errorf("\n%s\nparseControlPlane(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -16,6 +16,10 @@ package controlplane
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
 
 	"github.com/alecthomas/kong"
 	"github.com/pterm/pterm"
@@ -68,14 +72,24 @@ func (c *getCmd) AfterApply(kongCtx *kong.Context, upCtx *upbound.Context) error
 
 // getCmd gets a single control plane in an account on Upbound.
 type getCmd struct {
-	Name  string `arg:"" required:"" help:"Name of control plane." predictor:"ctps"`
-	Group string `short:"g" help:"The control plane group that the control plane is contained in. This defaults to the group specified in the current profile."`
+	Name   string `arg:"" required:"" help:"Name of control plane." predictor:"ctps"`
+	Group  string `short:"g" help:"The control plane group that the control plane is contained in. This defaults to the group specified in the current profile."`
+	Output string `short:"o" enum:"table,json,yaml" default:"table" help:"Output format, either 'table', or 'json' or 'yaml' to print the full control plane response. Defaults to the global --format."`
+
+	Watch         bool          `short:"w" help:"Keep polling the control plane and print its status whenever it is polled, until interrupted. With --output=json, one JSON object is printed per line and poll."`
+	WatchInterval time.Duration `default:"5s" help:"How often to poll the control plane with --watch."`
 
 	client ctpGetter
 }
 
 // Run executes the get command.
-func (c *getCmd) Run(ctx context.Context, printer upterm.ObjectPrinter, p pterm.TextPrinter, upCtx *upbound.Context) error {
+func (c *getCmd) Run(ctx context.Context, kongCtx *kong.Context, printer upterm.ObjectPrinter, p pterm.TextPrinter, upCtx *upbound.Context) error {
+	output, err := outputFormat(kongCtx, c.Output, printer)
+	if err != nil {
+		return err
+	}
+	c.Output = output
+
 	ctp, err := c.client.Get(ctx, types.NamespacedName{Name: c.Name, Namespace: c.Group})
 	if controlplane.IsNotFound(err) {
 		p.Printfln("Control plane %s not found", c.Name)
@@ -84,8 +98,83 @@ func (c *getCmd) Run(ctx context.Context, printer upterm.ObjectPrinter, p pterm.
 	if err != nil {
 		return err
 	}
+	if c.Watch {
+		return c.watch(ctx, ctp, kongCtx.Stdout, printer, p, upCtx)
+	}
+
+	return tabularPrint(ctp, c.Output, kongCtx.Stdout, printer, upCtx)
+}
+
+// watch prints ctp and then polls the control plane every watch interval,
+// printing it again, until ctx is done. Tables are updated in place, JSON and
+// YAML are appended to w.
+func (c *getCmd) watch(ctx context.Context, ctp *controlplane.Response, w io.Writer, printer upterm.ObjectPrinter, p pterm.TextPrinter, upCtx *upbound.Context) error {
+	if c.Output != outputJSON && c.Output != outputYAML {
+		fieldNames, extractFields := tableFields(upCtx)
+		render := func(ctp *controlplane.Response) (string, error) {
+			return printer.TablePrinter.WithHasHeader().WithData(pterm.TableData{fieldNames, extractFields(ctp)}).Srender()
+		}
+		s, err := render(ctp)
+		if err != nil {
+			return err
+		}
+		area, err := pterm.DefaultArea.Start(s)
+		if err != nil {
+			return err
+		}
+		defer area.Stop() //nolint:errcheck // Nothing to do about it.
+		return c.poll(ctx, func(ctp *controlplane.Response) error {
+			s, err := render(ctp)
+			if err != nil {
+				return err
+			}
+			area.Update(s)
+			return nil
+		}, p)
+	}
+
+	emit := func(ctp *controlplane.Response) error {
+		if c.Output == outputYAML {
+			if _, err := fmt.Fprintln(w, "---"); err != nil {
+				return err
+			}
+			return printer.PrintYAML(ctp, w)
+		}
+		// Indented JSON would break up the object across lines.
+		return json.NewEncoder(w).Encode(ctp)
+	}
+	if err := emit(ctp); err != nil {
+		return err
+	}
+	return c.poll(ctx, emit, p)
+}
 
-	return tabularPrint(ctp, printer, upCtx)
+// poll gets the control plane every watch interval and passes it to emit
+// until ctx is done or the control plane is gone.
+func (c *getCmd) poll(ctx context.Context, emit func(*controlplane.Response) error, p pterm.TextPrinter) error {
+	t := time.NewTicker(c.WatchInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-t.C:
+		}
+		ctp, err := c.client.Get(ctx, types.NamespacedName{Name: c.Name, Namespace: c.Group})
+		if ctx.Err() != nil {
+			return nil
+		}
+		if controlplane.IsNotFound(err) {
+			p.Printfln("Control plane %s not found", c.Name)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := emit(ctp); err != nil {
+			return err
+		}
+	}
 }
 
 // EmptyControlPlaneConfiguration returns an empty ControlPlaneConfiguration with default values.
@@ -0,0 +1,116 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controlplane
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pterm/pterm"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/upbound/up/internal/controlplane"
+	"github.com/upbound/up/internal/upterm"
+)
+
+// pollingGetter returns the ready states one after the other, and cancels
+// the watch, like an interrupt, once they are exhausted.
+type pollingGetter struct {
+	ready  []string
+	cancel context.CancelFunc
+}
+
+func (g *pollingGetter) Get(_ context.Context, name types.NamespacedName) (*controlplane.Response, error) {
+	if len(g.ready) == 0 {
+		g.cancel()
+		return nil, context.Canceled
+	}
+	r := &controlplane.Response{Group: name.Namespace, Name: name.Name, Ready: g.ready[0]}
+	g.ready = g.ready[1:]
+	return r, nil
+}
+
+func TestGetCmdWatch(t *testing.T) {
+	cases := map[string]struct {
+		output string
+		ready  []string
+		want   string
+	}{
+		"JSON": {
+			output: outputJSON,
+			ready:  []string{"False", "True"},
+			want: `{"ID":"","Group":"default","Name":"ctp","CrossplaneVersion":"","Synced":"","Ready":"Unknown","Message":"","Age":null,"Cfg":"","Updated":"","ConnName":""}
+{"ID":"","Group":"default","Name":"ctp","CrossplaneVersion":"","Synced":"","Ready":"False","Message":"","Age":null,"Cfg":"","Updated":"","ConnName":""}
+{"ID":"","Group":"default","Name":"ctp","CrossplaneVersion":"","Synced":"","Ready":"True","Message":"","Age":null,"Cfg":"","Updated":"","ConnName":""}
+`,
+		},
+		"YAML": {
+			output: outputYAML,
+			ready:  []string{"True"},
+			want: `---
+id: ""
+group: default
+name: ctp
+crossplaneversion: ""
+synced: ""
+ready: Unknown
+message: ""
+age: null
+cfg: ""
+updated: ""
+connname: ""
+
+---
+id: ""
+group: default
+name: ctp
+crossplaneversion: ""
+synced: ""
+ready: "True"
+message: ""
+age: null
+cfg: ""
+updated: ""
+connname: ""
+
+`,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			c := &getCmd{
+				Name:          "ctp",
+				Group:         "default",
+				Output:        tc.output,
+				Watch:         true,
+				WatchInterval: time.Millisecond,
+				client:        &pollingGetter{ready: tc.ready, cancel: cancel},
+			}
+			w := &bytes.Buffer{}
+			ctp := &controlplane.Response{Group: "default", Name: "ctp", Ready: "Unknown"}
+			if err := c.watch(ctx, ctp, w, upterm.DefaultObjPrinter, pterm.DefaultBasicText.WithWriter(w), nil); err != nil {
+				t.Fatalf("watch(...): unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.want, w.String()); diff != "" {
+				t.Errorf("\n%s\nwatch(...): -want, +got:\n%s", name, diff)
+			}
+		})
+	}
+}
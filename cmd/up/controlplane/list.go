@@ -38,6 +38,7 @@ type ctpLister interface {
 type listCmd struct {
 	Group     string `short:"g" help:"The control plane group that the control plane is contained in. This defaults to the group specified in the current profile."`
 	AllGroups bool   `short:"A" default:"false" help:"List control planes across all groups."`
+	Output    string `short:"o" enum:"table,json,yaml" default:"table" help:"Output format, either 'table', or 'json' or 'yaml' to print the full control plane responses. Defaults to the global --format."`
 
 	client ctpLister
 }
@@ -74,7 +75,13 @@ func (c *listCmd) AfterApply(kongCtx *kong.Context, upCtx *upbound.Context) erro
 }
 
 // Run executes the list command.
-func (c *listCmd) Run(ctx context.Context, printer upterm.ObjectPrinter, p pterm.TextPrinter, upCtx *upbound.Context) error {
+func (c *listCmd) Run(ctx context.Context, kongCtx *kong.Context, printer upterm.ObjectPrinter, p pterm.TextPrinter, upCtx *upbound.Context) error {
+	output, err := outputFormat(kongCtx, c.Output, printer)
+	if err != nil {
+		return err
+	}
+	c.Output = output
+
 	l, err := c.client.List(ctx, c.deriveGroup())
 	if controlplane.IsNotFound(err) {
 		p.Printfln("No Control planes found in %s group", c.deriveGroup())
@@ -89,7 +96,7 @@ func (c *listCmd) Run(ctx context.Context, printer upterm.ObjectPrinter, p pterm
 		return nil
 	}
 
-	return tabularPrint(l, printer, upCtx)
+	return tabularPrint(l, c.Output, kongCtx.Stdout, printer, upCtx)
 }
 
 func (c *listCmd) deriveGroup() string {
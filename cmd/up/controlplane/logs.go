@@ -0,0 +1,167 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controlplane
+
+import (
+	"context"
+	"io"
+	"math"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/alecthomas/kong"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+
+	"github.com/upbound/up/cmd/up/controlplane/kubeconfig"
+	"github.com/upbound/up/internal/upbound"
+	"github.com/upbound/up/pkg/migration/crossplane"
+)
+
+const (
+	crossplaneDeployment = "crossplane"
+	crossplaneContainer  = "crossplane"
+	uxpContainer         = "universal-crossplane"
+)
+
+// logsCmd prints the logs of the Crossplane instance of a control plane.
+type logsCmd struct {
+	kubeconfig.ConnectionSecretCmd
+
+	Follow bool          `short:"f" help:"Stream the logs until interrupted."`
+	Since  time.Duration `help:"Only print logs newer than a relative duration like 5s, 2m, or 3h. By default, all logs are printed."`
+	Tail   int64         `default:"-1" help:"Number of most recent lines to print. By default, all lines are printed."`
+}
+
+func (c *logsCmd) Help() string {
+	return `
+Print the logs of the Crossplane controller of a control plane, i.e. of the
+'crossplane' container of a pod of the 'crossplane' deployment.
+
+Examples:
+    up ctp logs ctp --tail=100 --follow
+        Prints the last 100 lines of the Crossplane logs of control plane
+        'ctp' and streams new lines until interrupted.
+`
+}
+
+// AfterApply sets default values in command after assignment and validation.
+func (c *logsCmd) AfterApply(kongCtx *kong.Context, upCtx *upbound.Context) error {
+	return c.ConnectionSecretCmd.AfterApply(kongCtx, upCtx)
+}
+
+// Run executes the logs command.
+func (c *logsCmd) Run(ctx context.Context, kongCtx *kong.Context, upCtx *upbound.Context, getter kubeconfig.ConnectionSecretGetter) error {
+	// Interrupts are handled for all commands, but the stream should also end
+	// cleanly when the command is terminated.
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGTERM)
+	defer stop()
+
+	ctp := types.NamespacedName{Namespace: c.Group, Name: c.Name}
+	cfg, err := restConfig(ctx, getter, ctp, upCtx)
+	if err != nil {
+		return errors.Wrapf(err, "cannot get kubeconfig of control plane %s", c.Name)
+	}
+	cs, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return err
+	}
+	return c.streamLogs(ctx, cs, kongCtx.Stdout)
+}
+
+// streamLogs copies the logs of the Crossplane container to w. When
+// following, it returns without error once ctx is done.
+func (c *logsCmd) streamLogs(ctx context.Context, client kubernetes.Interface, w io.Writer) error {
+	xp, err := crossplane.CollectInfo(ctx, client.AppsV1())
+	if err != nil {
+		return errors.Wrap(err, "cannot find Crossplane deployment")
+	}
+	if xp.Namespace == "" {
+		return errors.Errorf("Crossplane not found in control plane %s", c.Name)
+	}
+	d, err := client.AppsV1().Deployments(xp.Namespace).Get(ctx, crossplaneDeployment, metav1.GetOptions{})
+	if err != nil {
+		return errors.Wrap(err, "cannot get Crossplane deployment")
+	}
+	sel, err := metav1.LabelSelectorAsSelector(d.Spec.Selector)
+	if err != nil {
+		return errors.Wrap(err, "invalid selector of Crossplane deployment")
+	}
+	pods, err := client.CoreV1().Pods(xp.Namespace).List(ctx, metav1.ListOptions{LabelSelector: sel.String()})
+	if err != nil {
+		return errors.Wrap(err, "cannot list Crossplane pods")
+	}
+	pod, err := logsPod(pods.Items)
+	if err != nil {
+		return err
+	}
+
+	stream, err := client.CoreV1().Pods(xp.Namespace).GetLogs(pod.Name, c.logOptions(d)).Stream(ctx)
+	if err != nil {
+		return errors.Wrapf(err, "cannot get logs of pod %s", pod.Name)
+	}
+	defer stream.Close() //nolint:errcheck // Nothing is left to read.
+	_, err = io.Copy(w, stream)
+	if ctx.Err() != nil {
+		// The stream was interrupted on purpose.
+		return nil
+	}
+	return errors.Wrapf(err, "cannot read logs of pod %s", pod.Name)
+}
+
+// logOptions returns the options to get the logs of the Crossplane container
+// of deployment d with.
+func (c *logsCmd) logOptions(d *appsv1.Deployment) *corev1.PodLogOptions {
+	opts := &corev1.PodLogOptions{Container: logsContainer(d.Spec.Template.Spec.Containers), Follow: c.Follow}
+	if c.Since > 0 {
+		s := int64(math.Ceil(c.Since.Seconds()))
+		opts.SinceSeconds = &s
+	}
+	if c.Tail >= 0 {
+		tail := c.Tail
+		opts.TailLines = &tail
+	}
+	return opts
+}
+
+// logsContainer returns the name of the Crossplane container among
+// containers, which is named differently by Universal Crossplane.
+func logsContainer(containers []corev1.Container) string {
+	for _, c := range containers {
+		if c.Name == crossplaneContainer || c.Name == uxpContainer {
+			return c.Name
+		}
+	}
+	return crossplaneContainer
+}
+
+// logsPod returns the pod to get the logs of, preferring running ones.
+func logsPod(pods []corev1.Pod) (*corev1.Pod, error) {
+	if len(pods) == 0 {
+		return nil, errors.New("no Crossplane pods found")
+	}
+	for i := range pods {
+		if pods[i].Status.Phase == corev1.PodRunning {
+			return &pods[i], nil
+		}
+	}
+	return &pods[0], nil
+}
@@ -0,0 +1,128 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controlplane
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/utils/ptr"
+)
+
+func TestLogsCmdStreamLogs(t *testing.T) {
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "crossplane", Namespace: "upbound-system"},
+		Spec: appsv1.DeploymentSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "crossplane"}},
+		},
+	}
+	pod := func(name string, phase corev1.PodPhase) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "upbound-system", Labels: map[string]string{"app": "crossplane"}},
+			Status:     corev1.PodStatus{Phase: phase},
+		}
+	}
+
+	type want struct {
+		out string
+		err bool
+	}
+	cases := map[string]struct {
+		reason  string
+		objects []runtime.Object
+		want    want
+	}{
+		"Logs": {
+			reason:  "The logs of the Crossplane pod should be printed.",
+			objects: []runtime.Object{deployment, pod("crossplane-1", corev1.PodRunning)},
+			// The fake client returns fixed logs.
+			want: want{out: "fake logs"},
+		},
+		"NoCrossplane": {
+			reason: "An error should be returned if Crossplane is not installed.",
+			want:   want{err: true},
+		},
+		"NoPods": {
+			reason:  "An error should be returned if Crossplane has no pods.",
+			objects: []runtime.Object{deployment},
+			want:    want{err: true},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			c := &logsCmd{Tail: -1}
+			out := &bytes.Buffer{}
+			err := c.streamLogs(context.Background(), fake.NewSimpleClientset(tc.objects...), out)
+			if diff := cmp.Diff(tc.want.err, err != nil); diff != "" {
+				t.Errorf("\n%s\nstreamLogs(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.out, out.String()); diff != "" {
+				t.Errorf("\n%s\nstreamLogs(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestLogsCmdLogOptions(t *testing.T) {
+	containers := func(names ...string) *appsv1.Deployment {
+		d := &appsv1.Deployment{}
+		for _, n := range names {
+			d.Spec.Template.Spec.Containers = append(d.Spec.Template.Spec.Containers, corev1.Container{Name: n})
+		}
+		return d
+	}
+
+	cases := map[string]struct {
+		reason string
+		cmd    logsCmd
+		d      *appsv1.Deployment
+		want   *corev1.PodLogOptions
+	}{
+		"Defaults": {
+			reason: "All logs of the crossplane container should be requested by default.",
+			cmd:    logsCmd{Tail: -1},
+			d:      containers("crossplane"),
+			want:   &corev1.PodLogOptions{Container: "crossplane"},
+		},
+		"UniversalCrossplane": {
+			reason: "The logs of the Universal Crossplane container should be requested.",
+			cmd:    logsCmd{Tail: -1},
+			d:      containers("sidecar", "universal-crossplane"),
+			want:   &corev1.PodLogOptions{Container: "universal-crossplane"},
+		},
+		"FollowSinceTail": {
+			reason: "Following, a partial second, and a number of lines should be requested.",
+			cmd:    logsCmd{Follow: true, Since: 1500 * time.Millisecond, Tail: 10},
+			d:      containers("crossplane"),
+			want:   &corev1.PodLogOptions{Container: "crossplane", Follow: true, SinceSeconds: ptr.To[int64](2), TailLines: ptr.To[int64](10)},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := tc.cmd.logOptions(tc.d)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\nlogOptions(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controlplane
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/alecthomas/kong"
+	"github.com/pterm/pterm"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+
+	"github.com/upbound/up/internal/controlplane"
+	"github.com/upbound/up/internal/controlplane/space"
+	"github.com/upbound/up/internal/upbound"
+	"github.com/upbound/up/internal/upterm"
+)
+
+const defaultStateWaitInterval = 5 * time.Second
+
+type ctpStateSetter interface {
+	Get(ctx context.Context, ctp types.NamespacedName) (*controlplane.Response, error)
+	Pause(ctx context.Context, ctp types.NamespacedName) (*controlplane.Response, error)
+	Resume(ctx context.Context, ctp types.NamespacedName) (*controlplane.Response, error)
+}
+
+// stateCmd contains the flags and the client shared by the commands changing
+// the state of a control plane.
+type stateCmd struct {
+	Name  string `arg:"" required:"" help:"Name of control plane." predictor:"ctps"`
+	Group string `short:"g" help:"The control plane group that the control plane is contained in. This defaults to the group specified in the current profile."`
+
+	Wait        bool          `help:"Wait until the control plane reached the new state."`
+	WaitTimeout time.Duration `default:"5m" help:"How long to wait for the control plane to reach the new state with --wait."`
+
+	client       ctpStateSetter
+	waitInterval time.Duration
+}
+
+// afterApply builds the client of the space of the current profile. Only
+// control planes in Spaces can be paused.
+func (c *stateCmd) afterApply(kongCtx *kong.Context, upCtx *upbound.Context, operation string) error {
+	if !upCtx.Profile.IsSpace() {
+		return fmt.Errorf("%s is not supported for Upbound Cloud profile %q", operation, upCtx.ProfileName)
+	}
+	kubeconfig, ns, err := upCtx.Profile.GetSpaceKubeConfig()
+	if err != nil {
+		return err
+	}
+	if c.Group == "" {
+		c.Group = ns
+	}
+
+	client, err := dynamic.NewForConfig(kubeconfig)
+	if err != nil {
+		return err
+	}
+	c.client = space.New(client)
+	c.waitInterval = defaultStateWaitInterval
+
+	kongCtx.Bind(pterm.DefaultTable.WithWriter(kongCtx.Stdout).WithSeparator("   "))
+	return nil
+}
+
+// run changes the state of the control plane with set, optionally waits
+// until it is ready or not, and prints its status.
+func (c *stateCmd) run(ctx context.Context, set func(context.Context, types.NamespacedName) (*controlplane.Response, error), ready bool, kongCtx *kong.Context, printer upterm.ObjectPrinter, upCtx *upbound.Context) error {
+	name := types.NamespacedName{Name: c.Name, Namespace: c.Group}
+	ctp, err := set(ctx, name)
+	if controlplane.IsNotFound(err) {
+		return errors.Errorf("control plane %s not found", c.Name)
+	}
+	if err != nil {
+		return err
+	}
+	if c.Wait {
+		if ctp, err = c.wait(ctx, name, ready); err != nil {
+			return err
+		}
+	}
+	return tabularPrint(ctp, "table", kongCtx.Stdout, printer, upCtx)
+}
+
+// wait polls the control plane until it is ready, or until it is not ready if
+// ready is false, i.e. until it is running or paused, and returns it.
+func (c *stateCmd) wait(ctx context.Context, name types.NamespacedName, ready bool) (*controlplane.Response, error) {
+	state := "running"
+	if !ready {
+		state = "paused"
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.WaitTimeout)
+	defer cancel()
+	t := time.NewTicker(c.waitInterval)
+	defer t.Stop()
+	for {
+		ctp, err := c.client.Get(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		if (ctp.Ready == string(corev1.ConditionTrue)) == ready {
+			return ctp, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, errors.Errorf("timed out waiting for control plane %s to be %s", c.Name, state)
+		case <-t.C:
+		}
+	}
+}
+
+// pauseCmd pauses the Crossplane instance of a control plane in a Space.
+type pauseCmd struct {
+	stateCmd `embed:""`
+}
+
+// AfterApply sets default values in command after assignment and validation.
+func (c *pauseCmd) AfterApply(kongCtx *kong.Context, upCtx *upbound.Context) error {
+	return c.afterApply(kongCtx, upCtx, "pause")
+}
+
+// Run executes the pause command.
+func (c *pauseCmd) Run(ctx context.Context, kongCtx *kong.Context, printer upterm.ObjectPrinter, upCtx *upbound.Context) error {
+	return c.run(ctx, c.client.Pause, false, kongCtx, printer, upCtx)
+}
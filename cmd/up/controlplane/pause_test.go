@@ -0,0 +1,116 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controlplane
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/upbound/up/internal/controlplane"
+)
+
+// stateClient returns the ready states one after the other, and the last one
+// once they are exhausted.
+type stateClient struct {
+	ready []string
+	gets  int
+}
+
+func (c *stateClient) Get(_ context.Context, name types.NamespacedName) (*controlplane.Response, error) {
+	r := &controlplane.Response{Group: name.Namespace, Name: name.Name, Ready: c.ready[min(c.gets, len(c.ready)-1)]}
+	c.gets++
+	return r, nil
+}
+
+func (c *stateClient) Pause(ctx context.Context, name types.NamespacedName) (*controlplane.Response, error) {
+	return c.Get(ctx, name)
+}
+
+func (c *stateClient) Resume(ctx context.Context, name types.NamespacedName) (*controlplane.Response, error) {
+	return c.Get(ctx, name)
+}
+
+func TestStateCmdWait(t *testing.T) {
+	type args struct {
+		ready   []string
+		waitFor bool
+	}
+	type want struct {
+		gets int
+		err  bool
+	}
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"Running": {
+			reason: "Waiting for a resumed control plane should end once it is ready.",
+			args: args{
+				ready:   []string{"False", "Unknown", "True"},
+				waitFor: true,
+			},
+			want: want{
+				gets: 3,
+			},
+		},
+		"Paused": {
+			reason: "Waiting for a paused control plane should end once it is not ready.",
+			args: args{
+				ready:   []string{"True", "False"},
+				waitFor: false,
+			},
+			want: want{
+				gets: 2,
+			},
+		},
+		"Timeout": {
+			reason: "Waiting should fail if the control plane does not reach the state in time.",
+			args: args{
+				ready:   []string{"True"},
+				waitFor: false,
+			},
+			want: want{
+				err: true,
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			client := &stateClient{ready: tc.args.ready}
+			c := &stateCmd{
+				Name:         "ctp",
+				Group:        "default",
+				WaitTimeout:  50 * time.Millisecond,
+				client:       client,
+				waitInterval: time.Millisecond,
+			}
+			_, err := c.wait(context.Background(), types.NamespacedName{Name: c.Name, Namespace: c.Group}, tc.args.waitFor)
+			if (err != nil) != tc.want.err {
+				t.Fatalf("\n%s\nwait(...): error = %v, want error %t", tc.reason, err, tc.want.err)
+			}
+			if tc.want.err {
+				return
+			}
+			if diff := cmp.Diff(tc.want.gets, client.gets); diff != "" {
+				t.Errorf("\n%s\nwait(...): gets: -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
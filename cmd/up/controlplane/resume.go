@@ -0,0 +1,40 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controlplane
+
+import (
+	"context"
+
+	"github.com/alecthomas/kong"
+
+	"github.com/upbound/up/internal/upbound"
+	"github.com/upbound/up/internal/upterm"
+)
+
+// resumeCmd resumes the paused Crossplane instance of a control plane in a
+// Space.
+type resumeCmd struct {
+	stateCmd `embed:""`
+}
+
+// AfterApply sets default values in command after assignment and validation.
+func (c *resumeCmd) AfterApply(kongCtx *kong.Context, upCtx *upbound.Context) error {
+	return c.afterApply(kongCtx, upCtx, "resume")
+}
+
+// Run executes the resume command.
+func (c *resumeCmd) Run(ctx context.Context, kongCtx *kong.Context, printer upterm.ObjectPrinter, upCtx *upbound.Context) error {
+	return c.run(ctx, c.client.Resume, true, kongCtx, printer, upCtx)
+}
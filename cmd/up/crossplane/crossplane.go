@@ -0,0 +1,40 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package crossplane
+
+import (
+	"github.com/alecthomas/kong"
+	"k8s.io/client-go/rest"
+
+	"github.com/upbound/up/internal/kube"
+)
+
+// AfterApply constructs and binds the Kubernetes client configuration to any
+// subcommands that have Run() methods that receive it.
+func (c *Cmd) AfterApply(kongCtx *kong.Context) error {
+	cfg, err := kube.GetKubeConfig(c.Kubeconfig)
+	if err != nil {
+		return err
+	}
+	kongCtx.BindTo(cfg, (*rest.Config)(nil))
+	return nil
+}
+
+// Cmd contains commands for inspecting Crossplane.
+type Cmd struct {
+	Info infoCmd `cmd:"" help:"Display the version, namespace, distribution and feature flags of Crossplane."`
+
+	Kubeconfig string `type:"existingfile" help:"Override default kubeconfig path."`
+}
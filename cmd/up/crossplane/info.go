@@ -0,0 +1,74 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package crossplane
+
+import (
+	"context"
+	"strings"
+
+	"github.com/alecthomas/kong"
+	"github.com/pterm/pterm"
+	"k8s.io/client-go/kubernetes"
+	appsv1 "k8s.io/client-go/kubernetes/typed/apps/v1"
+	"k8s.io/client-go/rest"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+
+	"github.com/upbound/up/internal/upterm"
+	"github.com/upbound/up/pkg/migration/crossplane"
+	"github.com/upbound/up/pkg/migration/meta/v1alpha1"
+)
+
+const errNotFound = "Crossplane not found in any namespace"
+
+var fieldNames = []string{"VERSION", "NAMESPACE", "DISTRIBUTION", "FEATURE FLAGS"}
+
+// AfterApply sets default values in command after assignment and validation.
+func (c *infoCmd) AfterApply(kongCtx *kong.Context, cfg *rest.Config) error {
+	cs, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return err
+	}
+	c.client = cs.AppsV1()
+	kongCtx.Bind(pterm.DefaultTable.WithWriter(kongCtx.Stdout).WithSeparator("   "))
+	return nil
+}
+
+// infoCmd displays information about the Crossplane installation of a
+// cluster.
+type infoCmd struct {
+	client appsv1.DeploymentsGetter
+}
+
+// Run executes the info command.
+func (c *infoCmd) Run(ctx context.Context, printer upterm.ObjectPrinter) error {
+	info, err := crossplane.CollectInfo(ctx, c.client)
+	if err != nil {
+		return errors.Wrap(err, "cannot collect Crossplane information")
+	}
+	if info.Namespace == "" {
+		return errors.New(errNotFound)
+	}
+	return printer.Print(*info, fieldNames, extractFields)
+}
+
+func extractFields(obj any) []string {
+	i := obj.(v1alpha1.CrossplaneInfo)
+	flags := "<none>"
+	if len(i.FeatureFlags) > 0 {
+		flags = strings.Join(i.FeatureFlags, ",")
+	}
+	return []string{i.Version, i.Namespace, i.Distribution, flags}
+}
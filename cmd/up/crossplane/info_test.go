@@ -0,0 +1,109 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package crossplane
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/upbound/up/internal/upterm"
+	"github.com/upbound/up/pkg/migration/meta/v1alpha1"
+)
+
+func TestInfoCmdRun(t *testing.T) {
+	type want struct {
+		err error
+	}
+	cases := map[string]struct {
+		reason      string
+		deployments []appsv1.Deployment
+		want        want
+	}{
+		"NotFound": {
+			reason: "An error should be returned if no namespace has a Crossplane deployment.",
+			deployments: []appsv1.Deployment{{
+				ObjectMeta: metav1.ObjectMeta{Name: "provider-aws", Namespace: "crossplane-system"},
+			}},
+			want: want{
+				err: errors.New(errNotFound),
+			},
+		},
+		"Found": {
+			reason: "No error should be returned if a Crossplane deployment is found.",
+			deployments: []appsv1.Deployment{{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "crossplane",
+					Namespace: "upbound-system",
+					Labels:    map[string]string{"app.kubernetes.io/version": "v1.14.5-up.1"},
+				},
+			}},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			cs := fake.NewSimpleClientset()
+			for i := range tc.deployments {
+				if _, err := cs.AppsV1().Deployments(tc.deployments[i].Namespace).Create(context.Background(), &tc.deployments[i], metav1.CreateOptions{}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			c := &infoCmd{client: cs.AppsV1()}
+			err := c.Run(context.Background(), upterm.ObjectPrinter{Quiet: true})
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nRun(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestExtractFields(t *testing.T) {
+	cases := map[string]struct {
+		reason string
+		info   v1alpha1.CrossplaneInfo
+		want   []string
+	}{
+		"NoFeatureFlags": {
+			reason: "A placeholder should be shown if no feature flags are enabled.",
+			info:   v1alpha1.CrossplaneInfo{Version: "v1.14.5", Namespace: "crossplane-system", Distribution: v1alpha1.DistributionCrossplane},
+			want:   []string{"v1.14.5", "crossplane-system", v1alpha1.DistributionCrossplane, "<none>"},
+		},
+		"FeatureFlags": {
+			reason: "All enabled feature flags should be shown.",
+			info: v1alpha1.CrossplaneInfo{
+				Version:      "v1.14.5-up.1",
+				Namespace:    "upbound-system",
+				Distribution: v1alpha1.DistributionUniversalCrossplane,
+				FeatureFlags: []string{"--enable-usages", "--enable-realtime-compositions"},
+			},
+			want: []string{"v1.14.5-up.1", "upbound-system", v1alpha1.DistributionUniversalCrossplane, "--enable-usages,--enable-realtime-compositions"},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := extractFields(tc.info)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\nextractFields(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
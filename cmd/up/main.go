@@ -16,6 +16,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -29,6 +30,7 @@ import (
 	"github.com/upbound/up/cmd/up/configuration"
 	"github.com/upbound/up/cmd/up/configuration/template"
 	"github.com/upbound/up/cmd/up/controlplane"
+	"github.com/upbound/up/cmd/up/crossplane"
 	"github.com/upbound/up/cmd/up/login"
 	"github.com/upbound/up/cmd/up/migration"
 	"github.com/upbound/up/cmd/up/organization"
@@ -110,6 +112,7 @@ type cli struct {
 	Logout             login.LogoutCmd              `cmd:"" help:"Logout of Upbound."`
 	Configuration      configuration.Cmd            `cmd:"" name:"configuration" aliases:"cfg" help:"Interact with configurations."`
 	ControlPlane       controlplane.Cmd             `cmd:"" name:"controlplane" aliases:"ctp" help:"Interact with control planes of the current profile, both in Upbound and local Spaces."`
+	Crossplane         crossplane.Cmd               `cmd:"" help:"Inspect the Crossplane installation of a cluster."`
 	Space              space.Cmd                    `cmd:"" help:"Interact with local Spaces."`
 	Organization       organization.Cmd             `cmd:"" name:"organization" aliases:"org" help:"Interact with Upbound organizations."`
 	Profile            profile.Cmd                  `cmd:"" help:"Interact with Upbound profiles or local Spaces."`
@@ -173,6 +176,7 @@ func main() {
 		kongplete.WithPredictor("ctps", controlplane.PredictControlPlanes()),
 		kongplete.WithPredictor("repos", repository.PredictRepos()),
 		kongplete.WithPredictor("robots", robot.PredictRobots()),
+		kongplete.WithPredictor("teams", robot.PredictTeams()),
 		kongplete.WithPredictor("profiles", profile.PredictProfiles()),
 		kongplete.WithPredictor("configs", configuration.PredictConfigurations()),
 		kongplete.WithPredictor("templates", template.PredictTemplates()),
@@ -198,5 +202,19 @@ func main() {
 	}()
 
 	kongCtx.BindTo(ctx, (*context.Context)(nil))
-	kongCtx.FatalIfErrorf(kongCtx.Run())
+	err = kongCtx.Run()
+	// Commands may fail with specific exit codes, e.g. for scripts to tell
+	// failures apart.
+	var ec exitCoder
+	if errors.As(err, &ec) {
+		kongCtx.Errorf("%s", err)
+		kongCtx.Exit(ec.ExitCode())
+	}
+	kongCtx.FatalIfErrorf(err)
+}
+
+// exitCoder is an error with a specific exit code.
+type exitCoder interface {
+	error
+	ExitCode() int
 }
@@ -0,0 +1,69 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migration
+
+import (
+	"context"
+	"os"
+
+	"github.com/upbound/up/pkg/migration/importer"
+)
+
+type catCmd struct {
+	Archive       string `short:"a" help:"Specifies the file path of the archive to read the resource from, or '-' to read it from stdin. The default path is 'xp-state.tar.gz'." default:"xp-state.tar.gz"`
+	InputFormat   string `enum:"archive,directory" help:"The format of the export to read the resource from, either a gzip or zstd compressed tar 'archive' or a 'directory' of plain YAML files at the --archive path. Defaults to 'archive'." default:"archive"`
+	EncryptionKey string `env:"UP_MIGRATION_ENCRYPTION_KEY" help:"The base64 encoded 32 byte key to decrypt an archive encrypted by 'migration export --encryption-key' with. Archives that are not encrypted are read as is."`
+
+	Resource  string `required:"" help:"The type of the resource in \"resource.group\" format, e.g. 'buckets.s3.aws.upbound.io', or just 'resource' for core types, e.g. 'configmaps'."`
+	Name      string `required:"" help:"The name of the resource."`
+	Namespace string `short:"n" help:"The namespace of the resource. Omit it for cluster scoped resources."`
+}
+
+func (c *catCmd) Help() string {
+	return `
+Usage:
+    migration cat --resource=<resource.group> --name=<name> [--namespace=<namespace>] [options]
+
+The 'cat' command prints the YAML of a single resource of an exported control plane state, like 'kubectl get -o yaml'
+does for a live control plane. The output can be piped to 'kubectl apply -f -'.
+
+The control plane is not accessed.
+
+Examples:
+    migration cat --archive=my-export.tar.gz --resource=configmaps --namespace=default --name=settings
+        Prints the ConfigMap 'settings' in the namespace 'default' of the export in 'my-export.tar.gz'.
+
+    migration cat --resource=buckets.s3.aws.upbound.io --name=my-bucket | kubectl apply -f -
+        Applies the exported Bucket 'my-bucket' to the current cluster.
+`
+}
+
+func (c *catCmd) Run(ctx context.Context) error {
+	return withExitCode(c.run(ctx))
+}
+
+func (c *catCmd) run(ctx context.Context) error {
+	im := importer.NewControlPlaneStateImporter(nil, nil, nil, nil, importer.Options{
+		InputArchive:  c.Archive,
+		InputFormat:   importer.InputFormat(c.InputFormat),
+		EncryptionKey: c.EncryptionKey,
+	})
+	b, err := im.ReadResource(ctx, c.Resource, c.Namespace, c.Name)
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(b)
+	return err
+}
@@ -0,0 +1,76 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migration
+
+import (
+	"context"
+	"path"
+
+	"github.com/pterm/pterm"
+	"github.com/spf13/afero"
+	"github.com/upbound/up/pkg/migration/archiver"
+)
+
+type compareCmd struct {
+	From    string `required:"" type:"existingfile" help:"The file path of the older export archive."`
+	To      string `required:"" type:"existingfile" help:"The file path of the newer export archive."`
+	DiffDir string `type:"path" help:"A directory to write a unified YAML diff of every modified resource to, as '<type>/<namespace>/<name>.diff'. Cluster scoped resources are written to the '_cluster' namespace directory."`
+}
+
+func (c *compareCmd) Help() string {
+	return `
+Usage:
+    migration compare --from=<archive> --to=<archive> [options]
+
+The 'compare' command reports which resources were added, removed, or modified between two exported control plane
+states, e.g. the exports taken before and after a failed migration attempt. Resources are compared field by field,
+ignoring their resourceVersion, uid, and generation, which change without the resource being changed. Neither the
+archives nor a control plane are modified.
+
+Examples:
+    migration compare --from=first-export.tar.gz --to=second-export.tar.gz
+        Lists the resources that differ between both exports.
+
+    migration compare --from=first-export.tar.gz --to=second-export.tar.gz --diff-dir=diffs
+        Additionally writes a YAML diff of every modified resource to the 'diffs' directory.
+`
+}
+
+func (c *compareCmd) Run(ctx context.Context) error {
+	return withExitCode(c.run(ctx))
+}
+
+func (c *compareCmd) run(ctx context.Context) error {
+	changes, err := archiver.CompareArchives(ctx, c.From, c.To)
+	if err != nil {
+		return err
+	}
+	if len(changes) == 0 {
+		pterm.Success.Println("No resources changed.")
+		return nil
+	}
+
+	data := pterm.TableData{{"CHANGE", "GVR", "NAMESPACE", "NAME"}}
+	for _, ch := range changes {
+		data = append(data, []string{string(ch.Type), path.Join(ch.GVR.GroupVersion().String(), ch.GVR.Resource), ch.Namespace, ch.Name})
+	}
+	if err := pterm.DefaultTable.WithHasHeader().WithData(data).Render(); err != nil {
+		return err
+	}
+	if c.DiffDir == "" {
+		return nil
+	}
+	return archiver.WriteDiffs(afero.Afero{Fs: afero.NewOsFs()}, c.DiffDir, changes)
+}
@@ -0,0 +1,59 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migration
+
+import (
+	"github.com/upbound/up/pkg/migration"
+)
+
+// exitCodes are the exit codes of the migration commands failing with errors
+// of the respective codes, so that scripts can tell failures apart without
+// parsing error messages. Other errors exit with 1.
+var exitCodes = map[migration.ErrorCode]int{
+	migration.ErrCodePreflightFailed:     2,
+	migration.ErrCodeTimeout:             3,
+	migration.ErrCodeArchiveCorrupt:      4,
+	migration.ErrCodeResourceFetchFailed: 5,
+	migration.ErrCodeResourceApplyFailed: 6,
+}
+
+// exitError is an error the CLI exits with a specific exit code for.
+type exitError struct {
+	err  error
+	code int
+}
+
+func (e *exitError) Error() string {
+	return e.err.Error()
+}
+
+func (e *exitError) Unwrap() error {
+	return e.err
+}
+
+// ExitCode returns the exit code of the error.
+func (e *exitError) ExitCode() int {
+	return e.code
+}
+
+// withExitCode returns err with the exit code of its error code, if it has
+// one.
+func withExitCode(err error) error {
+	code, ok := exitCodes[migration.Code(err)]
+	if !ok {
+		return err
+	}
+	return &exitError{err: err, code: code}
+}
@@ -16,17 +16,18 @@ package migration
 
 import (
 	"context"
+	"os"
+	"time"
 
 	"github.com/pterm/pterm"
 	"github.com/upbound/up/pkg/migration"
+	"github.com/upbound/up/pkg/migration/archiver"
 	"github.com/upbound/up/pkg/migration/exporter"
-	apiextensionsclientset "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
-	"k8s.io/client-go/discovery"
-	"k8s.io/client-go/discovery/cached/memory"
-	"k8s.io/client-go/dynamic"
-	appsv1 "k8s.io/client-go/kubernetes/typed/apps/v1"
-	"k8s.io/client-go/restmapper"
+	"github.com/upbound/up/pkg/migration/transform"
 
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+
+	"github.com/upbound/up/internal/config"
 	"github.com/upbound/up/internal/input"
 )
 
@@ -35,7 +36,7 @@ which are stored as Kubernetes secrets. Additionally, some managed resources pro
 connection details exclusively during provisioning, and these details may not be
 reconstructable post-migration. Consequently, the exported archive will incorporate
 those secrets by default. To exclude secrets from the export, please use the
---excluded-resources flag.
+--exclude-resources flag, or --exclude-secrets to export them with their data redacted.
 
 IMPORTANT: The exported archive will contain secrets. Do you wish to proceed?`
 
@@ -44,14 +45,68 @@ type exportCmd struct {
 
 	Yes bool `help:"When set to true, automatically accepts any confirmation prompts that may appear during the export process." default:"false"`
 
-	Output string `short:"o" help:"Specifies the file path where the exported archive will be saved. Defaults to 'xp-state.tar.gz'." default:"xp-state.tar.gz"`
+	Output       string `short:"o" help:"Specifies the file path where the exported archive will be saved, or '-' to write it to stdout. Defaults to 'xp-state.tar.gz'." default:"xp-state.tar.gz"`
+	OutputFormat string `enum:"archive,directory" help:"The format of the export, either a compressed tar 'archive' or a 'directory' of plain YAML files at the --output path, which must not exist or be empty. Defaults to 'archive'." default:"archive"`
+
+	OutputSplitSize int64 `help:"The maximum size in bytes of the parts to split the archive into, e.g. 5000000000 to stay within the single object limit of S3. The parts are numbered by appending '.001', '.002' and so on to --output. Only supported for archives written to a local file. The archive is not split by default."`
+
+	S3Bucket   string `name:"s3-bucket" help:"The bucket of an S3-compatible object store to stream the archive to instead of writing it locally. The base name of --output is used as the object name. Credentials are read from the standard AWS environment variables and configuration files."`
+	S3Prefix   string `name:"s3-prefix" help:"The prefix of the archive object name in --s3-bucket, e.g. 'exports/prod'."`
+	S3Region   string `name:"s3-region" help:"The region of --s3-bucket. Defaults to the region of the AWS configuration."`
+	S3Endpoint string `name:"s3-endpoint" help:"The endpoint of an S3-compatible object store, like MinIO or GCS. Defaults to AWS S3."`
+
+	OutputOCIRef string `name:"output-oci-ref" help:"The reference of an OCI artifact in a container registry to push the archive to instead of writing it locally, e.g. 'registry.example.com/exports/prod:v1'. Credentials are read from the Docker configuration."`
+
+	CompressionAlgorithm string         `enum:"gzip,zstd" help:"The algorithm to compress the archive with, either 'gzip' or 'zstd'. The matching '.tar.gz' or '.tar.zst' extension is appended to --output if it has none. Defaults to 'gzip'." default:"gzip"`
+	CompressionLevel     int            `help:"The compression level of the chosen algorithm, i.e. 1-9 for gzip and 1-22 for zstd. Defaults to the default level of the algorithm."`
+	CompressLevelByType  map[string]int `name:"compress-level-by-type" help:"Compression levels for resources of specific kinds, e.g. 'Secret=9;ConfigMap=3'. The archive is compressed with the highest level of all exported kinds, or --compression-level if none of them are exported."`
+	EncryptionKey        string         `env:"UP_MIGRATION_ENCRYPTION_KEY" help:"A base64 encoded 32 byte key to encrypt the archive with using AES-256-GCM, e.g. generated with 'openssl rand -base64 32'. The same key is required to import or verify the archive."`
+
+	IncludeExtraResources    []string `help:"A list of extra resource types to include in the export in \"resource.group\" format in addition to all Crossplane resources. By default, it includes namespaces, configmaps, secrets." default:"namespaces,configmaps,secrets"`
+	ExcludeResources         []string `help:"A list of resource types to exclude from the export in \"resource.group\" format. No resources are excluded by default."`
+	IncludeWebhookConfigs    bool     `help:"When set to true, exports the mutating and validating webhook configurations, which providers often install without owning them by a CRD. Defaults to false." default:"false"`
+	IncludeNamespaces        []string `help:"A list of specific namespaces to include in the export. If not specified, all namespaces are included by default."`
+	ExcludeNamespaces        []string `help:"A list of specific namespaces to exclude from the export. Defaults to 'kube-system', 'kube-public', 'kube-node-lease', and 'local-path-storage'." default:"kube-system,kube-public,kube-node-lease,local-path-storage"`
+	ExcludeNamespacePattern  []string `help:"A glob pattern of namespaces to exclude from the export, e.g. 'kube-*' or 'team-?'. Can be repeated. Namespaces matching any pattern are excluded in addition to --exclude-namespaces." sep:"none"`
+	IncludeHelmRelease       []string `help:"The name of a Helm release whose resources to export, e.g. CRDs deliberately installed with Helm. Can be repeated. Resources installed with Helm are not exported by default. The release secret itself is not exported, only the resources the release installed." sep:"none"`
+	CompositionLabelSelector string   `help:"A label selector of CompositeResourceDefinitions, e.g. 'team=platform'. If set, the only Crossplane resources exported are the claims and composite resources of matching CompositeResourceDefinitions, e.g. to export the portion of a shared control plane that belongs to one team."`
+	FieldSelector            string   `help:"A field selector all exported resources must match, e.g. 'metadata.name!=default'. It applies to all exported types, so it must only use fields supported by all of them, like 'metadata.name' and 'metadata.namespace', unless the exported types are restricted accordingly."`
+	LabelSelector            string   `help:"A label selector all exported resources must match, e.g. 'app!=legacy'. Resources must match both selectors if --field-selector is set as well."`
+	MaxResourceSize          int64    `help:"The size in bytes of a resource serialized to JSON above which it is not exported, e.g. to skip ConfigMaps or Secrets with large embedded payloads. Skipped resources are listed in the export metadata. Defaults to no limit."`
+	MaxResources             int      `help:"The number of resources of all exported types above which the export fails before anything is written, e.g. to detect filters that accidentally include too much. Resources are counted regardless of namespace and selector filters. Defaults to no limit."`
+
+	PauseBeforeExport bool          `help:"When set to true, pauses all managed resources before starting the export process. This can help ensure a consistent state for the export. Defaults to false." default:"false"`
+	PauseTimeout      time.Duration `help:"How long to wait for all managed resources to acknowledge the pause when --pause-before-export is set, e.g. 5m. The export fails if any of them did not acknowledge it in time. Does not wait by default."`
+
+	RespectPriorityClasses bool `help:"When set to true, exports the resource types used in namespaces running higher priority pods first, according to their PriorityClasses. Defaults to false." default:"false"`
+
+	SegmentByNamespace bool `help:"When set to true, writes one archive per namespace and one named '_cluster' for cluster scoped resources to the --output path, which must be a directory that does not exist or is empty, so that namespaces can be imported independently. Defaults to false." default:"false"`
+
+	ExcludeSecrets bool `help:"When set to true, replaces the values of the data of exported Secrets with '<redacted>' and annotates them with 'migration.upbound.io/secrets-redacted: \"true\"', e.g. to share the structure of a control plane without its credentials. Defaults to false." default:"false"`
+
+	RewriteImages []string `sep:"none" help:"Rewrites the package images of exported Providers in \"from=to\" format, replacing the prefix 'from' with 'to', e.g. xpkg.upbound.io/upbound/=my-registry.corp/. The prefix may be a glob pattern, e.g. xpkg.upbound.io/*/. Can be repeated, the first matching rule is applied."`
+
+	ContentAddressable bool `help:"When set to true, stores each resource under the SHA-256 hash of its content along with a manifest, so that identical resources produce identical files across exports. Defaults to false." default:"false"`
+
+	ChangedSince time.Time `help:"Only exports resources created or modified since the given RFC 3339 timestamp, e.g. the time a previous export was started. The resulting differential archive can only be imported into a control plane that already received an export taken at or after this time. Deletions are not exported."`
+
+	ExportAuditHistory bool   `help:"When set to true, includes the recent mutations of every exported Crossplane resource, read from the audit log at --audit-log-path, in the archive for debugging. Defaults to false." default:"false"`
+	AuditLogPath       string `type:"existingfile" help:"Path to the Kubernetes audit log of the control plane, in JSON lines format. Required when --export-audit-history is set."`
+
+	TargetCrossplaneVersion string `help:"The Crossplane version of the control plane the export will be imported into, e.g. 1.14.5. If set, the export fails if the version of the source control plane cannot be migrated to it, and warns about migrations that require additional care."`
+
+	FetchMaxRetries   int           `help:"How often listing resources is retried when the control plane is temporarily unavailable or times out, e.g. during an etcd leader election. Not retried by default." default:"0"`
+	FetchRetryBackoff time.Duration `help:"The delay before the first retry of listing resources. It is doubled for every further retry." default:"1s"`
+
+	Parallelism int `help:"The number of resource types to export concurrently, at most 20. Defaults to 1." default:"1"`
 
-	IncludeExtraResources []string `help:"A list of extra resource types to include in the export in \"resource.group\" format in addition to all Crossplane resources. By default, it includes namespaces, configmaps, secrets." default:"namespaces,configmaps,secrets"`
-	ExcludeResources      []string `help:"A list of resource types to exclude from the export in \"resource.group\" format. No resources are excluded by default."`
-	IncludeNamespaces     []string `help:"A list of specific namespaces to include in the export. If not specified, all namespaces are included by default."`
-	ExcludeNamespaces     []string `help:"A list of specific namespaces to exclude from the export. Defaults to 'kube-system', 'kube-public', 'kube-node-lease', and 'local-path-storage'." default:"kube-system,kube-public,kube-node-lease,local-path-storage"`
+	Timeout time.Duration `help:"The maximum duration of the whole export process, e.g. 60m. No timeout by default."`
 
-	PauseBeforeExport bool `help:"When set to true, pauses all managed resources before starting the export process. This can help ensure a consistent state for the export. Defaults to false." default:"false"`
+	MigrationExpectedDuration time.Duration `help:"How long the whole migration is expected to take. Preflight checks warn about resources annotated to expire within this duration." default:"2h"`
+
+	SkipStorageCheck bool `help:"When set to true, skips the preflight check whether the temporary directory, or the --output directory with --output-format=directory, has enough space for the export, e.g. if the available space is not reported reliably. Defaults to false." default:"false"`
+
+	OTELEndpoint string `name:"otel-endpoint" help:"The OTLP gRPC endpoint to send traces of the export process to, either as host:port or as an http(s) URL. Tracing is disabled by default."`
 }
 
 func (c *exportCmd) Help() string {
@@ -64,6 +119,7 @@ into an archive file. This file can then be used for migration to Upbound Manage
 
 Use the available options to customize the export process, such as specifying the output file path, including or excluding
 specific resources and namespaces, and deciding whether to pause managed resources before exporting.
+Individual resources annotated with 'migration.upbound.io/skip: "true"' are not exported.
 
 Examples:
 	migration export --pause-before-export
@@ -72,6 +128,33 @@ Examples:
 	migration export --output=my-export.tar.gz
         Exports the control plane state to a specified file 'my-export.tar.gz'.
 
+    migration export --yes --output=- | aws s3 cp - s3://bucket/xp-state.tar.gz
+        Streams the exported control plane state to stdout, e.g. to upload it without creating a local file.
+
+    migration export --s3-bucket=my-bucket --s3-prefix=exports --s3-endpoint=https://minio.example.com
+        Streams the exported control plane state to 'exports/xp-state.tar.gz' in the bucket 'my-bucket' of a MinIO server.
+
+    migration export --output-oci-ref=registry.example.com/exports/prod:v1
+        Pushes the exported control plane state as an OCI artifact, to be imported with 'migration import --input-oci-ref'.
+
+    migration export --compression-algorithm=zstd --compression-level=19 --output=xp-state
+        Exports the control plane state to 'xp-state.tar.zst', compressed with zstd at a high compression level.
+
+    migration export --output-split-size=1000000000 --output=xp-state.tar.gz
+        Exports the control plane state to 'xp-state.tar.gz.001', 'xp-state.tar.gz.002' and so on, each at most 1 GB.
+
+    migration export --output-format=directory --output=xp-state
+        Exports the control plane state as plain YAML files to the directory 'xp-state', e.g. to inspect or version it.
+
+    migration export --changed-since=2024-03-01T12:00:00Z --output=xp-state-diff.tar.gz
+        Exports only the resources created or modified since the given time, to be imported on top of a previous export.
+
+    migration export --exclude-secrets --output=xp-structure.tar.gz
+        Exports the control plane state with the data of all Secrets redacted, e.g. to share it for troubleshooting.
+
+    migration export --exclude-namespace-pattern='kube-*' --exclude-namespace-pattern='team-?-dev'
+        Exports the control plane state, excluding all namespaces matching any of the patterns.
+
     migration export --include-extra-resources="customresource.group" --include-namespaces="crossplane-system,team-a,team-b"
         Exports the control plane state to a default file 'xp-state.tar.gz', with the additional resource specified and only using provided namespaces.
 `
@@ -83,52 +166,142 @@ func (c *exportCmd) BeforeApply() error {
 	return nil
 }
 
-func (c *exportCmd) Run(ctx context.Context, migCtx *migration.Context) error {
-	cfg := migCtx.Kubeconfig
+func (c *exportCmd) Run(ctx context.Context, migCtx *migration.Context, quiet config.QuietFlag) error {
+	return withExitCode(c.run(ctx, migCtx, quiet))
+}
+
+func (c *exportCmd) run(ctx context.Context, migCtx *migration.Context, quiet config.QuietFlag) error {
+	if c.ExportAuditHistory && c.AuditLogPath == "" {
+		return errors.New("--audit-log-path is required when --export-audit-history is set")
+	}
+
+	if c.Output == "-" && c.S3Bucket == "" && c.OutputOCIRef == "" {
+		// Keep stdout for the archive.
+		pterm.SetDefaultOutput(os.Stderr)
+	}
 
-	crdClient, err := apiextensionsclientset.NewForConfig(cfg)
+	o, err := c.options(bool(quiet))
 	if err != nil {
 		return err
 	}
-	dynamicClient, err := dynamic.NewForConfig(cfg)
+	e, err := exporter.NewControlPlaneStateExporterForConfig(migCtx.Kubeconfig, o)
 	if err != nil {
 		return err
 	}
-	discoveryClient, err := discovery.NewDiscoveryClientForConfig(cfg)
-	if err != nil {
+
+	if !c.confirmSecrets(e) {
+		return nil
+	}
+
+	if err := checkPreflight(e.PreflightChecks(ctx), c.Yes, "export"); err != nil {
 		return err
 	}
-	appsClient, err := appsv1.NewForConfig(cfg)
-	if err != nil {
+
+	if err = e.Export(ctx); err != nil {
 		return err
 	}
+	return nil
+}
 
-	mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(discoveryClient))
+// options returns the exporter options configured by the flags.
+// confirmSecrets asks whether to proceed with an export that would contain the
+// data of Secrets, and returns whether to proceed. It does not ask if Secrets
+// are not exported, or only with their data redacted.
+func (c *exportCmd) confirmSecrets(e *exporter.ControlPlaneStateExporter) bool {
+	if c.Yes || c.ExcludeSecrets || !e.IncludedExtraResource("secrets") {
+		return true
+	}
+	confirm := pterm.DefaultInteractiveConfirm
+	confirm.DefaultText = secretsWarning
+	confirm.DefaultValue = true
+	result, _ := confirm.Show()
+	pterm.Println() // Blank line
+	return result
+}
+
+func (c *exportCmd) options(quiet bool) (exporter.Options, error) {
+	var changedSince *time.Time
+	if !c.ChangedSince.IsZero() {
+		changedSince = &c.ChangedSince
+	}
+
+	imageRewrites, err := parseImageRewriteRules(c.RewriteImages)
+	if err != nil {
+		return exporter.Options{}, err
+	}
 
-	e := exporter.NewControlPlaneStateExporter(crdClient, dynamicClient, discoveryClient, appsClient, mapper, exporter.Options{
+	return exporter.Options{
 		OutputArchive: c.Output,
+		OutputFormat:  exporter.OutputFormat(c.OutputFormat),
+
+		S3Bucket:   c.S3Bucket,
+		S3Prefix:   c.S3Prefix,
+		S3Region:   c.S3Region,
+		S3Endpoint: c.S3Endpoint,
+
+		OutputOCIRef: c.OutputOCIRef,
 
-		IncludeNamespaces:     c.IncludeNamespaces,
-		ExcludeNamespaces:     c.ExcludeNamespaces,
-		IncludeExtraResources: c.IncludeExtraResources,
-		ExcludeResources:      c.ExcludeResources,
+		CompressionAlgorithm:   archiver.Compression(c.CompressionAlgorithm),
+		CompressionLevel:       c.CompressionLevel,
+		CompressionLevelByKind: c.CompressLevelByType,
+		EncryptionKey:          c.EncryptionKey,
+		SplitSizeBytes:         c.OutputSplitSize,
+
+		IncludeNamespaces:        c.IncludeNamespaces,
+		ExcludeNamespaces:        c.ExcludeNamespaces,
+		ExcludeNamespacePatterns: c.ExcludeNamespacePattern,
+		IncludeHelmReleases:      c.IncludeHelmRelease,
+		CompositionLabelSelector: c.CompositionLabelSelector,
+		MaxResourceSizeBytes:     c.MaxResourceSize,
+		MaxTotalResources:        c.MaxResources,
+		IncludeExtraResources:    c.IncludeExtraResources,
+		ExcludeResources:         c.ExcludeResources,
+		IncludeWebhookConfigs:    c.IncludeWebhookConfigs,
 
 		PauseBeforeExport: c.PauseBeforeExport,
-	})
-
-	if !c.Yes && e.IncludedExtraResource("secrets") {
-		confirm := pterm.DefaultInteractiveConfirm
-		confirm.DefaultText = secretsWarning
-		confirm.DefaultValue = true
-		result, _ := confirm.Show()
-		pterm.Println() // Blank line
-		if !result {
-			return nil
-		}
-	}
+		PauseTimeout:      c.PauseTimeout,
 
-	if err = e.Export(ctx); err != nil {
-		return err
+		RespectPriorityClasses: c.RespectPriorityClasses,
+
+		SegmentByNamespace: c.SegmentByNamespace,
+		ContentAddressable: c.ContentAddressable,
+		RedactSecrets:      c.ExcludeSecrets,
+		ImageRewriteRules:  imageRewrites,
+		ChangedSince:       changedSince,
+
+		ExportAuditHistory: c.ExportAuditHistory,
+		AuditLogPath:       c.AuditLogPath,
+
+		Fetch: exporter.FetchOptions{
+			MaxRetries:    c.FetchMaxRetries,
+			RetryBackoff:  c.FetchRetryBackoff,
+			FieldSelector: c.FieldSelector,
+			LabelSelector: c.LabelSelector,
+		},
+		Parallelism: c.Parallelism,
+		Quiet:       quiet,
+
+		TargetCrossplaneVersion: c.TargetCrossplaneVersion,
+
+		Timeout: c.Timeout,
+
+		MigrationExpectedDuration: c.MigrationExpectedDuration,
+		SkipStorageCheck:          c.SkipStorageCheck,
+
+		OTELEndpoint: c.OTELEndpoint,
+	}, nil
+}
+
+// parseImageRewriteRules parses the image rewrite rules of the
+// --rewrite-images flag.
+func parseImageRewriteRules(ss []string) ([]transform.ImageRewriteRule, error) {
+	rules := make([]transform.ImageRewriteRule, 0, len(ss))
+	for _, s := range ss {
+		r, err := transform.ParseImageRewriteRule(s)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, r)
 	}
-	return nil
+	return rules, nil
 }
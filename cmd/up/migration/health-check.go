@@ -0,0 +1,75 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migration
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/pterm/pterm"
+	"github.com/upbound/up/pkg/migration"
+	"github.com/upbound/up/pkg/migration/importer"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/restmapper"
+)
+
+type healthCheckCmd struct {
+	Timeout time.Duration `help:"How long to wait for all resources to become ready, e.g. 5m." default:"10m"`
+}
+
+func (c *healthCheckCmd) Help() string {
+	return `
+Usage:
+    migration health-check [options]
+
+The 'health-check' command checks whether the control plane is healthy after an import, without importing again. It waits
+until all CompositeResourceDefinitions are established and all packages and package revisions are installed and healthy,
+just like the import does, and then prints the number of ready resources of each kind.
+
+Examples:
+    migration health-check --timeout=5m
+        Waits up to 5 minutes for the control plane to become healthy.
+`
+}
+
+func (c *healthCheckCmd) Run(ctx context.Context, migCtx *migration.Context) error {
+	cfg := migCtx.Kubeconfig
+
+	dynamicClient, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		return err
+	}
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(cfg)
+	if err != nil {
+		return err
+	}
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(discoveryClient))
+
+	ctx, cancel := context.WithTimeout(ctx, c.Timeout)
+	defer cancel()
+	statuses, err := importer.NewHealthChecker(dynamicClient, mapper, importer.HealthChecks).Check(ctx)
+
+	data := pterm.TableData{{"GROUPKIND", "READY", "TOTAL"}}
+	for _, s := range statuses {
+		data = append(data, []string{s.GroupKind.String(), strconv.Itoa(s.Ready), strconv.Itoa(s.Total)})
+	}
+	if perr := pterm.DefaultTable.WithHasHeader().WithData(data).Render(); perr != nil {
+		return perr
+	}
+	return err
+}
@@ -16,19 +16,14 @@ package migration
 
 import (
 	"context"
-	"fmt"
 	"net/url"
 	"strings"
+	"time"
 
-	"github.com/pterm/pterm"
 	"github.com/upbound/up/pkg/migration"
 	"github.com/upbound/up/pkg/migration/importer"
-	"k8s.io/client-go/discovery"
-	"k8s.io/client-go/discovery/cached/memory"
-	"k8s.io/client-go/dynamic"
-	appsv1 "k8s.io/client-go/kubernetes/typed/apps/v1"
+	"github.com/upbound/up/pkg/migration/transform"
 	"k8s.io/client-go/rest"
-	"k8s.io/client-go/restmapper"
 
 	"github.com/crossplane/crossplane-runtime/pkg/errors"
 
@@ -39,9 +34,60 @@ type importCmd struct {
 	prompter input.Prompter
 	Yes      bool `help:"When set to true, automatically accepts any confirmation prompts that may appear during the import process." default:"false"`
 
-	Input string `short:"i" help:"Specifies the file path of the archive to be imported. The default path is 'xp-state.tar.gz'." default:"xp-state.tar.gz"`
+	Input         string `short:"i" help:"Specifies the file path of the archive to be imported, or '-' to read it from stdin, which requires --yes. Archives split by 'migration export --output-split-size' are read from all their parts if the path of their first part, ending with '.001', or the path they were split from is given. The default path is 'xp-state.tar.gz'." default:"xp-state.tar.gz"`
+	InputFormat   string `enum:"archive,directory" help:"The format of the export to be imported, either a gzip or zstd compressed tar 'archive', detected automatically, or a 'directory' of plain YAML files at the --input path, as created by 'migration export --output-format=directory'. Defaults to 'archive'." default:"archive"`
+	InputOCIRef   string `name:"input-oci-ref" help:"The reference of an OCI artifact in a container registry to pull the archive from instead of --input, e.g. 'registry.example.com/exports/prod:v1', as pushed by 'migration export --output-oci-ref'. Credentials are read from the Docker configuration."`
+	EncryptionKey string `env:"UP_MIGRATION_ENCRYPTION_KEY" help:"The base64 encoded 32 byte key to decrypt an archive encrypted by 'migration export --encryption-key' with. Archives that are not encrypted are read as is."`
+	MaxMemoryMB   int    `name:"max-memory-mb" help:"How many megabytes of the unarchived export to keep in memory. Files larger than 1 MB, and all files exceeding this limit, are written to a temporary directory instead, to import very large archives without running out of memory." default:"512"`
+
+	DryRun bool `help:"When set to true, validates that the archive can be imported by running the preflight checks and checking that the control plane serves the types of all exported resources, and prints how many resources of each type would be imported, without changing the control plane. Types provided by packages or CompositeResourceDefinitions that are not installed yet are reported as not served." default:"false"`
 
 	UnpauseAfterImport bool `help:"When set to true, automatically unpauses all managed resources that were paused during the import process. This helps in resuming normal operations post-import. Defaults to false, requiring manual unpausing of resources if needed." default:"false"`
+
+	ActivationBatchSize     int           `help:"The number of managed resources to unpause at a time with --unpause-after-import. Each batch must become ready before the next one is unpaused. All managed resources are unpaused at once by default."`
+	ActivationBatchInterval time.Duration `help:"How long to wait after a batch of managed resources became ready before unpausing the next one, e.g. 30s."`
+
+	WaitTimeout         time.Duration `help:"How long to wait for CompositeResourceDefinitions and packages to become ready before importing the resources depending on them." default:"10m"`
+	WaitPollInterval    time.Duration `help:"How often to check whether CompositeResourceDefinitions and packages are ready." default:"5s"`
+	WaitMaxPollInterval time.Duration `help:"If larger than --wait-poll-interval, the poll interval is doubled after every check up to this value, to reduce the load on slow API servers. The poll interval is constant by default."`
+
+	CheckRegistryReachability  bool   `help:"When set to true, preflight checks verify that the registries of all exported provider packages are reachable from within the target control plane by running a temporary Job. Only network reachability is checked, the registries are not authenticated against with the package pull secrets. Defaults to false." default:"false"`
+	RegistryCheckImage         string `help:"The image of the Job checking whether registries are reachable, which must have curl as entrypoint, e.g. a mirror in air-gapped environments. --rewrite-images rules are applied to it. Defaults to curlimages/curl:8.6.0."`
+	StrictProviderVersionCheck bool   `help:"When set to true, preflight checks verify that exported providers already installed in the target control plane have the exported versions. Defaults to false." default:"false"`
+
+	StructuredLogPath string `type:"path" help:"Path of a file to write the outcome of applying each resource to as JSON lines, e.g. to feed CI dashboards. The human readable output is not affected."`
+
+	RollbackOnFailure bool `help:"When set to true, deletes all resources created by the import if it fails, orphaning the external resources of managed resources. Resources that existed before the import are left untouched. Defaults to false." default:"false"`
+
+	Timeout time.Duration `help:"The maximum duration of the whole import process including preflight checks, e.g. 60m. No timeout by default."`
+
+	OTELEndpoint string `name:"otel-endpoint" help:"The OTLP gRPC endpoint to send traces of the import process to, either as host:port or as an http(s) URL. Tracing is disabled by default."`
+
+	ConflictStrategy string `enum:"overwrite,skip,fail" help:"How to handle resources that already exist in the target control plane: 'overwrite' applies the exported state on top of them, 'skip' leaves them untouched and reports them in the summary, and 'fail' aborts the import. Defaults to 'overwrite'." default:"overwrite"`
+
+	Incremental bool `help:"When set to true, skips resources that already exist in the target control plane with identical labels and content, e.g. to re-run an import after a partial failure without rewriting the imported resources. Resources that differ are imported according to --conflict-strategy. Defaults to false." default:"false"`
+
+	WebhookConflictStrategy string `enum:"overwrite,skip,fail" help:"How to handle webhook configurations that already exist in the target control plane, usually because a provider installed them. Accepts the same values as --conflict-strategy. Defaults to 'skip'." default:"skip"`
+
+	ExcludeResources     []string `help:"A list of resource types not to import in \"resource.group\" format, e.g. 'secrets' if they are managed by an external secret manager. No resources are excluded by default."`
+	ExcludeResourcesFile string   `type:"existingfile" help:"Path to a file listing additional resource types not to import in \"resource.group\" format, one per line. Lines starting with '#' are ignored."`
+
+	SkipRedactedSecrets bool `help:"When set to true, does not import Secrets whose data was redacted by 'migration export --exclude-secrets'. They are imported without data otherwise. Defaults to false." default:"false"`
+
+	AutoDetectFieldManager bool `help:"When set to true, resources that already exist in the target control plane are applied with their first existing field manager instead of the default one, avoiding field manager conflicts. Defaults to false." default:"false"`
+
+	PreserveUIDs bool `name:"preserve-uids" help:"When set to true, resources are imported with the UIDs they had in the exported control plane. The API server assigns new UIDs to created resources regardless, so the UIDs only guard against re-importing over resources that were recreated since the export, e.g. when restoring into the exported control plane. Requires --conflict-strategy=skip or fail. Defaults to false." default:"false"`
+
+	PreserveResourceVersion bool `help:"When set to true, resources are imported with the resourceVersions in the export, so that importing a resource fails if it changed since it was exported. Only safe if the target control plane shares the etcd of the exported one, e.g. when restoring into the control plane an export was taken from, as resourceVersions are etcd revisions. Exports of 'migration export' have no resourceVersions. Defaults to false." default:"false"`
+
+	NamespaceMapping map[string]string `help:"Imports the resources of exported namespaces into other namespaces, in \"exported=target\" format, e.g. 'team-a=team-a-prod;team-b=team-b-prod'. Exported Namespaces are renamed accordingly. Namespaces that are not mapped are imported as is."`
+
+	AdaptiveRateLimit bool `help:"When set to true, requests to the target control plane are slowed down once it starts throttling them, and sped up again as it recovers. Defaults to false." default:"false"`
+
+	ConvertDeprecatedAPIVersions bool `name:"convert-deprecated-api-versions" help:"When set to true, resources of native Kubernetes kinds exported with API versions removed in recent Kubernetes releases, e.g. extensions/v1beta1 Ingresses, are imported with their current API version. Only the API version is changed. Defaults to false." default:"false"`
+
+	RewriteEndpoint []string `sep:"none" help:"Rewrites an endpoint in the ProviderConfigs of a provider before importing them, in \"provider:old-url:new-url\" format, e.g. provider-aws:https://prod.example.com:https://staging.example.com. Can be repeated."`
+	RewriteImages   []string `sep:"none" help:"Rewrites the package images of Providers before importing them, in \"from=to\" format, replacing the prefix 'from' with 'to', e.g. xpkg.upbound.io/upbound/=my-registry.corp/. The prefix may be a glob pattern, e.g. xpkg.upbound.io/*/. Can be repeated, the first matching rule is applied."`
 }
 
 func (c *importCmd) Help() string {
@@ -54,12 +100,33 @@ The 'import' command imports a control plane state from an archive file into an
 By default, all managed resources will be paused during the import process for possible manual inspection/validation.
 You can use the --unpause-after-import flag to automatically unpause all managed resources after the import process completes.
 
+Resources annotated with 'migration.upbound.io/skip: "true"' in the export, e.g. by editing an export directory, are
+not imported. The annotation is kept in their files for traceability.
+
+Secrets whose data was redacted by 'migration export --exclude-secrets' are imported without data, to be filled in
+afterwards, or skipped with --skip-redacted-secrets. A warning is printed for each of them.
+
 Examples:
     migration import --input=my-export.tar.gz
         Imports the control plane state from 'my-export.tar.gz'.
 
+    migration import --dry-run --input=my-export.tar.gz
+        Validates that 'my-export.tar.gz' can be imported, without changing the control plane.
+
+    migration import --input=my-segments
+        Imports an export segmented by namespace from the 'my-segments' directory, cluster scoped resources first.
+
+    migration import --input-oci-ref=registry.example.com/exports/prod:v1
+        Imports the control plane state from an OCI artifact pushed by 'migration export --output-oci-ref'.
+
     migration import --unpause-after-import
         Imports and automatically unpauses managed resources after import.
+
+    migration import --unpause-after-import --activation-batch-size=50 --activation-batch-interval=1m
+        Imports and unpauses managed resources 50 at a time, waiting a minute after each batch became ready.
+
+    migration import --rewrite-endpoint=provider-aws:https://prod.example.com:https://staging.example.com
+        Imports and points the AWS ProviderConfigs at the staging endpoint instead of the production one.
 `
 }
 
@@ -69,59 +136,110 @@ func (c *importCmd) BeforeApply() error {
 	return nil
 }
 
-func (c *importCmd) Run(ctx context.Context, migCtx *migration.Context) error { //nolint:gocyclo // Just a lot of error handling.
+func (c *importCmd) Run(ctx context.Context, migCtx *migration.Context) error {
+	return withExitCode(c.run(ctx, migCtx))
+}
+
+func (c *importCmd) run(ctx context.Context, migCtx *migration.Context) error {
+	if c.Input == "-" && !c.Yes {
+		return errors.New("--yes is required when reading the archive from stdin, since confirmation prompts cannot be answered")
+	}
+
 	cfg := migCtx.Kubeconfig
 
 	if !isMCP(cfg) {
 		return errors.New("not a managed control plane, import not supported!")
 	}
 
-	dynamicClient, err := dynamic.NewForConfig(cfg)
+	o, err := c.options()
 	if err != nil {
 		return err
 	}
-	discoveryClient, err := discovery.NewDiscoveryClientForConfig(cfg)
+	i, err := importer.NewControlPlaneStateImporterForConfig(cfg, o)
 	if err != nil {
 		return err
 	}
-	mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(discoveryClient))
+	defer i.Close() //nolint:errcheck // Only temporary files are left behind.
 
-	appsClient, err := appsv1.NewForConfig(cfg)
-	if err != nil {
+	if c.DryRun {
+		// A dry run runs the preflight checks and reports their failures itself.
+		return i.Import(ctx)
+	}
+
+	if err := checkPreflight(i.PreflightChecks(ctx), c.Yes, "import"); err != nil {
 		return err
 	}
 
-	i := importer.NewControlPlaneStateImporter(dynamicClient, discoveryClient, appsClient, mapper, importer.Options{
-		InputArchive: c.Input,
+	if err = i.Import(ctx); err != nil {
+		return err
+	}
 
-		UnpauseAfterImport: c.UnpauseAfterImport,
-	})
+	return nil
+}
 
-	errs := i.PreflightChecks(ctx)
-	if len(errs) > 0 {
-		fmt.Println("Preflight checks failed:")
-		for _, err := range errs {
-			fmt.Println("- " + err.Error())
-		}
-		if !c.Yes {
-			pterm.Println() // Blank line
-			confirm := pterm.DefaultInteractiveConfirm
-			confirm.DefaultText = "Do you still want to proceed?"
-			confirm.DefaultValue = false
-			result, _ := confirm.Show()
-			pterm.Println() // Blank line
-			if !result {
-				pterm.Error.Println("Preflight checks must pass in order to proceed with the import.")
-				return nil
-			}
+// options returns the importer options configured by the flags.
+func (c *importCmd) options() (importer.Options, error) {
+	rewrites := make([]transform.EndpointRewrite, 0, len(c.RewriteEndpoint))
+	for _, s := range c.RewriteEndpoint {
+		rw, err := transform.ParseEndpointRewrite(s)
+		if err != nil {
+			return importer.Options{}, err
 		}
+		rewrites = append(rewrites, rw)
 	}
 
-	if err = i.Import(ctx); err != nil {
-		return err
+	imageRewrites, err := parseImageRewriteRules(c.RewriteImages)
+	if err != nil {
+		return importer.Options{}, err
 	}
 
-	return nil
+	var conversions transform.ConversionTable
+	if c.ConvertDeprecatedAPIVersions {
+		conversions = transform.DefaultConversionTable
+	}
+
+	return importer.Options{
+		InputArchive:  c.Input,
+		InputFormat:   importer.InputFormat(c.InputFormat),
+		InputOCIRef:   c.InputOCIRef,
+		EncryptionKey: c.EncryptionKey,
+		MaxMemoryMB:   c.MaxMemoryMB,
+
+		UnpauseAfterImport:      c.UnpauseAfterImport,
+		ActivationBatchSize:     c.ActivationBatchSize,
+		ActivationBatchInterval: c.ActivationBatchInterval,
+
+		WaitTimeout:         c.WaitTimeout,
+		WaitPollInterval:    c.WaitPollInterval,
+		WaitMaxPollInterval: c.WaitMaxPollInterval,
+		RollbackOnFailure:   c.RollbackOnFailure,
+		StructuredLogPath:   c.StructuredLogPath,
+
+		CheckRegistryReachability:  c.CheckRegistryReachability,
+		RegistryCheckImage:         c.RegistryCheckImage,
+		StrictProviderVersionCheck: c.StrictProviderVersionCheck,
+
+		Timeout: c.Timeout,
+
+		ConflictStrategy:        importer.ConflictStrategy(c.ConflictStrategy),
+		WebhookConflictStrategy: importer.ConflictStrategy(c.WebhookConflictStrategy),
+		IncrementalImport:       c.Incremental,
+		ExcludeResources:        c.ExcludeResources,
+		ExcludeResourcesFile:    c.ExcludeResourcesFile,
+		SkipRedactedSecrets:     c.SkipRedactedSecrets,
+		AutoDetectFieldManager:  c.AutoDetectFieldManager,
+		AdaptiveRateLimit:       c.AdaptiveRateLimit,
+		EndpointRewrites:        rewrites,
+		ImageRewriteRules:       imageRewrites,
+		APIVersionConversions:   conversions,
+		PreserveUIDs:            c.PreserveUIDs,
+		PreserveResourceVersion: c.PreserveResourceVersion,
+		NamespaceMapping:        c.NamespaceMapping,
+
+		DryRun: c.DryRun,
+
+		OTELEndpoint: c.OTELEndpoint,
+	}, nil
 }
 
 func isMCP(cfg *rest.Config) bool {
@@ -0,0 +1,98 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migration
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pterm/pterm"
+	"github.com/upbound/up/pkg/migration/importer"
+)
+
+type inspectCmd struct {
+	Archive       string `short:"a" help:"Specifies the file path of the archive to be inspected, or '-' to read it from stdin. The default path is 'xp-state.tar.gz'." default:"xp-state.tar.gz"`
+	InputFormat   string `enum:"archive,directory" help:"The format of the export to be inspected, either a gzip or zstd compressed tar 'archive' or a 'directory' of plain YAML files at the --archive path. Defaults to 'archive'." default:"archive"`
+	EncryptionKey string `env:"UP_MIGRATION_ENCRYPTION_KEY" help:"The base64 encoded 32 byte key to decrypt an archive encrypted by 'migration export --encryption-key' with. Archives that are not encrypted are read as is."`
+}
+
+func (c *inspectCmd) Help() string {
+	return `
+Usage:
+    migration inspect [options]
+
+The 'inspect' command prints a summary of an exported control plane state, to evaluate it before importing it. It
+reports the version and feature flags of the exported Crossplane instance, when the export was created, and the
+number of exported resources of each type along with its category:
+
+- base: Types imported before all others, e.g. packages, CompositeResourceDefinitions, and Secrets.
+- managed, composite, or claim: Crossplane managed resources, composite resources, and claims.
+
+The control plane is not accessed.
+
+Examples:
+    migration inspect --archive=my-export.tar.gz
+        Prints a summary of the export in 'my-export.tar.gz'.
+`
+}
+
+func (c *inspectCmd) Run(ctx context.Context) error {
+	return withExitCode(c.run(ctx))
+}
+
+func (c *inspectCmd) run(ctx context.Context) error {
+	im := importer.NewControlPlaneStateImporter(nil, nil, nil, nil, importer.Options{
+		InputArchive:  c.Archive,
+		InputFormat:   importer.InputFormat(c.InputFormat),
+		EncryptionKey: c.EncryptionKey,
+	})
+	summary, err := im.Inspect(ctx)
+	if err != nil {
+		return err
+	}
+
+	em := summary.Meta
+	data := pterm.TableData{
+		{"Crossplane version", orNone(em.Crossplane.Version, "unknown")},
+		{"Crossplane distribution", orNone(em.Crossplane.Distribution, "unknown")},
+		{"Feature flags", orNone(strings.Join(em.Crossplane.FeatureFlags, ", "), "none")},
+		{"Exported at", exportedAt(em.ExportedAt)},
+	}
+	if err := pterm.DefaultTable.WithData(data).Render(); err != nil {
+		return err
+	}
+
+	data = pterm.TableData{{"TYPE", "COUNT", "CATEGORY"}}
+	total := 0
+	for _, t := range summary.Types {
+		data = append(data, []string{t.GroupResource, strconv.Itoa(t.Count), t.Category})
+		total += t.Count
+	}
+	if err := pterm.DefaultTable.WithHasHeader().WithData(data).Render(); err != nil {
+		return err
+	}
+	pterm.Printfln("%d resources of %d types.", total, len(summary.Types))
+	return nil
+}
+
+// exportedAt formats the time an export was created at.
+func exportedAt(t time.Time) string {
+	if t.IsZero() {
+		return "(unknown)"
+	}
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,168 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migration
+
+import (
+	"context"
+
+	"github.com/pterm/pterm"
+	"github.com/spf13/afero"
+	"github.com/upbound/up/pkg/migration"
+	"github.com/upbound/up/pkg/migration/crossplane"
+	"github.com/upbound/up/pkg/migration/exporter"
+	"github.com/upbound/up/pkg/migration/importer"
+	appsv1 "k8s.io/client-go/kubernetes/typed/apps/v1"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+
+	"github.com/upbound/up/internal/config"
+	"github.com/upbound/up/internal/kube"
+)
+
+// migrateDir is the directory the state is exported to in memory.
+const migrateDir = "/xp-state"
+
+type migrateCmd struct {
+	SourceKubeconfig string `required:"" type:"existingfile" help:"The kubeconfig of the Crossplane or Universal Crossplane control plane to export."`
+	TargetKubeconfig string `required:"" type:"existingfile" help:"The kubeconfig of the Upbound managed control plane to import into."`
+
+	Export exportCmd `embed:"" prefix:"export-"`
+	Import importCmd `embed:"" prefix:"import-"`
+}
+
+func (c *migrateCmd) Help() string {
+	return `
+Usage:
+    migration migrate --source-kubeconfig=<path> --target-kubeconfig=<path> [options]
+
+The 'migrate' command exports the state of a Crossplane or Universal Crossplane control plane and imports it into an
+Upbound managed control plane in one go. The state is kept in memory and never written to disk. The preflight checks
+of the target control plane that do not need the exported state run before the export, to fail before pausing or
+exporting anything.
+
+All flags of the 'export' and 'import' commands are supported, prefixed with '--export-' and '--import-' respectively,
+e.g. '--export-pause-before-export' or '--import-unpause-after-import'. The flags selecting where the state is
+written to or read from, e.g. '--export-output' or '--import-input', have no effect, and exporting to an S3 bucket,
+an OCI artifact, or in segments is not supported.
+
+Examples:
+    migration migrate --source-kubeconfig=xp.yaml --target-kubeconfig=mcp.yaml --export-pause-before-export --import-unpause-after-import
+        Pauses the managed resources of the source control plane, migrates its state, and unpauses them in the
+        target control plane.
+`
+}
+
+func (c *migrateCmd) Run(ctx context.Context, quiet config.QuietFlag) error {
+	return withExitCode(c.run(ctx, quiet))
+}
+
+func (c *migrateCmd) run(ctx context.Context, quiet config.QuietFlag) error {
+	source, err := kube.GetKubeConfig(c.SourceKubeconfig)
+	if err != nil {
+		return errors.Wrap(err, "cannot load source kubeconfig")
+	}
+	target, err := kube.GetKubeConfig(c.TargetKubeconfig)
+	if err != nil {
+		return errors.Wrap(err, "cannot load target kubeconfig")
+	}
+	if !isMCP(target) {
+		return errors.New("target is not a managed control plane, import not supported!")
+	}
+
+	fs := afero.NewMemMapFs()
+	eo, err := c.Export.options(bool(quiet))
+	if err != nil {
+		return err
+	}
+	eo.OutputArchive = migrateDir
+	eo.OutputFormat = exporter.OutputFormatDirectory
+	eo.OutputFS = fs
+	// The state is not staged on disk.
+	eo.SkipStorageCheck = true
+	e, err := exporter.NewControlPlaneStateExporterForConfig(source, eo)
+	if err != nil {
+		return err
+	}
+
+	iopts, err := c.Import.options()
+	if err != nil {
+		return err
+	}
+	iopts.InputArchive = migrateDir
+	iopts.InputFormat = importer.InputFormatDirectory
+	iopts.InputOCIRef = ""
+	iopts.InputFS = fs
+	i, err := importer.NewControlPlaneStateImporterForConfig(target, iopts)
+	if err != nil {
+		return err
+	}
+
+	appsClient, err := appsv1.NewForConfig(source)
+	if err != nil {
+		return err
+	}
+	xp, err := crossplane.CollectInfo(ctx, appsClient)
+	if err != nil {
+		return errors.Wrap(err, "cannot get Crossplane info of the source control plane")
+	}
+	if err := checkPreflight(i.TargetPreflightChecks(ctx, xp), c.Import.Yes, "migration"); err != nil {
+		return err
+	}
+
+	if !c.Export.confirmSecrets(e) {
+		return nil
+	}
+	if err := checkPreflight(e.PreflightChecks(ctx), c.Export.Yes, "export"); err != nil {
+		return err
+	}
+	if err := e.Export(ctx); err != nil {
+		return errors.Wrap(err, "cannot export source control plane")
+	}
+
+	if c.Import.DryRun {
+		// A dry run runs the preflight checks and reports their failures itself.
+		return i.Import(ctx)
+	}
+	if err := checkPreflight(i.PreflightChecks(ctx), c.Import.Yes, "import"); err != nil {
+		return err
+	}
+	return errors.Wrap(i.Import(ctx), "cannot import into target control plane")
+}
+
+// checkPreflight prints the failed preflight checks, if any, and returns an
+// error unless the operation is to proceed, asking for confirmation unless
+// yes is set.
+func checkPreflight(errs []error, yes bool, operation string) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	pterm.Println("Preflight checks failed:")
+	for _, err := range errs {
+		pterm.Println("- " + err.Error())
+	}
+	if yes {
+		return nil
+	}
+	pterm.Println() // Blank line
+	confirm := pterm.DefaultInteractiveConfirm
+	confirm.DefaultText = "Do you still want to proceed?"
+	confirm.DefaultValue = false
+	result, _ := confirm.Show()
+	pterm.Println() // Blank line
+	if !result {
+		return migration.WithCode(errors.Errorf("preflight checks must pass in order to proceed with the %s", operation), migration.ErrCodePreflightFailed)
+	}
+	return nil
+}
@@ -21,9 +21,21 @@ import (
 	"github.com/upbound/up/internal/kube"
 )
 
+// offlineCommands are the subcommands that only read exports and do not
+// access a control plane, so they do not need a kubeconfig.
+var offlineCommands = map[string]bool{
+	"cat":     true,
+	"compare": true,
+	"inspect": true,
+	"verify":  true,
+}
+
 // AfterApply constructs and binds Upbound specific context to any subcommands
 // that have Run() methods that receive it.
 func (c *Cmd) AfterApply(kongCtx *kong.Context) error {
+	if n := kongCtx.Selected(); n != nil && offlineCommands[n.Name] {
+		return nil
+	}
 	cfg, err := kube.GetKubeConfig(c.Kubeconfig)
 	if err != nil {
 		return err
@@ -39,6 +51,24 @@ type Cmd struct {
 	Export exportCmd `cmd:"" help:"Export the current state of a Crossplane or Universal Crossplane control plane into an archive, preparing it for migration to Upbound Managed Control Planes."`
 	Import importCmd `cmd:"" help:"Import a previously exported control plane state into an Upbound managed control plane, completing the migration process."`
 
+	Migrate migrateCmd `cmd:"" help:"Export a Crossplane or Universal Crossplane control plane and import it into an Upbound managed control plane in one go, without writing an archive."`
+
+	Plan planCmd `cmd:"" help:"Report what would be exported from a Crossplane or Universal Crossplane control plane, without writing any files."`
+
+	Schedule scheduleCmd `cmd:"" help:"Create a CronJob in a Crossplane or Universal Crossplane control plane that periodically exports its state to an S3 bucket."`
+
+	Compare compareCmd `cmd:"" help:"Report the resources that were added, removed, or modified between two exported control plane states."`
+
+	Inspect inspectCmd `cmd:"" help:"Print a summary of an exported control plane state, without accessing a control plane."`
+
+	Cat catCmd `cmd:"" help:"Print the YAML of a single resource of an exported control plane state, without accessing a control plane."`
+
+	Verify verifyCmd `cmd:"" help:"Verify the checksums of the files of an exported control plane state, without accessing a control plane."`
+
+	HealthCheck healthCheckCmd `cmd:"" help:"Check whether the packages and CompositeResourceDefinitions of an imported control plane are ready."`
+
+	MoveNamespace moveNamespaceCmd `cmd:"" help:"Move Crossplane claims and the Secrets they use from one namespace to another of the same control plane."`
+
 	Kubeconfig string `type:"existingfile" help:"Override default kubeconfig path."`
 }
 
@@ -0,0 +1,72 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migration
+
+import (
+	"context"
+
+	"github.com/pterm/pterm"
+	"github.com/upbound/up/pkg/migration"
+	"github.com/upbound/up/pkg/migration/namespacemove"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+)
+
+type moveNamespaceCmd struct {
+	From string `required:"" help:"The namespace to move the Crossplane resources from."`
+	To   string `required:"" help:"The namespace to move the Crossplane resources to. It is created if it does not exist."`
+
+	DeleteOriginals bool `help:"When set to true, deletes the moved resources from the --from namespace, without deleting the resources composed or created for them. Defaults to false, leaving copies in both namespaces." default:"false"`
+}
+
+func (c *moveNamespaceCmd) Help() string {
+	return `
+Usage:
+    migration move-namespace --from=<namespace> --to=<namespace> [options]
+
+The 'move-namespace' command moves Crossplane resources between namespaces of the same control plane. It copies all claims
+in the --from namespace to the --to namespace, together with the Secrets referenced by them and by cluster scoped Crossplane
+resources, e.g. connection secrets and provider credentials, and updates the references of composite resources, managed
+resources and ProviderConfigs to point to the --to namespace.
+
+Examples:
+    migration move-namespace --from=team-a --to=platform-team-a --delete-originals
+        Moves the claims of 'team-a' and the Secrets they use to 'platform-team-a'.
+`
+}
+
+func (c *moveNamespaceCmd) Run(ctx context.Context, migCtx *migration.Context) error {
+	cfg := migCtx.Kubeconfig
+
+	dynamicClient, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		return err
+	}
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(cfg)
+	if err != nil {
+		return err
+	}
+
+	var opts []namespacemove.Option
+	if c.DeleteOriginals {
+		opts = append(opts, namespacemove.WithDeleteOriginals())
+	}
+	if err := namespacemove.NewMover(dynamicClient, discoveryClient, opts...).MoveResources(ctx, c.From, c.To); err != nil {
+		return err
+	}
+
+	pterm.Printf("Successfully moved Crossplane resources from namespace %q to %q!\n", c.From, c.To)
+	return nil
+}
@@ -0,0 +1,102 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migration
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/pterm/pterm"
+	"github.com/upbound/up/pkg/migration"
+	"github.com/upbound/up/pkg/migration/exporter"
+	apiextensionsclientset "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/restmapper"
+)
+
+type planCmd struct {
+	IncludeExtraResources    []string `help:"A list of extra resource types to include in the export in \"resource.group\" format in addition to all Crossplane resources. By default, it includes namespaces, configmaps, secrets." default:"namespaces,configmaps,secrets"`
+	ExcludeResources         []string `help:"A list of resource types to exclude from the export in \"resource.group\" format. No resources are excluded by default."`
+	IncludeWebhookConfigs    bool     `help:"When set to true, exports the mutating and validating webhook configurations, which providers often install without owning them by a CRD. Defaults to false." default:"false"`
+	IncludeNamespaces        []string `help:"A list of specific namespaces to include in the export. If not specified, all namespaces are included by default."`
+	ExcludeNamespaces        []string `help:"A list of specific namespaces to exclude from the export. Defaults to 'kube-system', 'kube-public', 'kube-node-lease', and 'local-path-storage'." default:"kube-system,kube-public,kube-node-lease,local-path-storage"`
+	ExcludeNamespacePattern  []string `help:"A glob pattern of namespaces to exclude from the export, e.g. 'kube-*' or 'team-?'. Can be repeated. Namespaces matching any pattern are excluded in addition to --exclude-namespaces." sep:"none"`
+	IncludeHelmRelease       []string `help:"The name of a Helm release whose resources to export, e.g. CRDs deliberately installed with Helm. Can be repeated. Resources installed with Helm are not exported by default." sep:"none"`
+	CompositionLabelSelector string   `help:"A label selector of CompositeResourceDefinitions, e.g. 'team=platform'. If set, the only Crossplane resources exported are the claims and composite resources of matching CompositeResourceDefinitions, e.g. to export the portion of a shared control plane that belongs to one team."`
+	FieldSelector            string   `help:"A field selector all exported resources must match, e.g. 'metadata.name!=default'. It applies to all exported types, so it must only use fields supported by all of them, like 'metadata.name' and 'metadata.namespace', unless the exported types are restricted accordingly."`
+	LabelSelector            string   `help:"A label selector all exported resources must match, e.g. 'app!=legacy'. Resources must match both selectors if --field-selector is set as well."`
+	MaxResourceSize          int64    `help:"The size in bytes of a resource serialized to JSON above which it is not exported. Defaults to no limit."`
+}
+
+func (c *planCmd) Help() string {
+	return `
+Usage:
+    migration plan [options]
+
+The 'plan' command reports what 'migration export' would export with the same options, without writing any files or
+modifying the control plane. For each resource type to export, it prints the number of its resources, how many of them
+would be exported, and how many would be skipped, e.g. because they are in an excluded namespace or installed with Helm.
+
+Examples:
+    migration plan --exclude-namespace-pattern='team-*'
+        Reports what would be exported if all namespaces starting with 'team-' are excluded.
+`
+}
+
+func (c *planCmd) Run(ctx context.Context, migCtx *migration.Context) error {
+	cfg := migCtx.Kubeconfig
+
+	crdClient, err := apiextensionsclientset.NewForConfig(cfg)
+	if err != nil {
+		return err
+	}
+	dynamicClient, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		return err
+	}
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(cfg)
+	if err != nil {
+		return err
+	}
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(discoveryClient))
+
+	e := exporter.NewControlPlaneStateExporter(crdClient, dynamicClient, discoveryClient, nil, mapper, exporter.Options{
+		IncludeNamespaces:        c.IncludeNamespaces,
+		ExcludeNamespaces:        c.ExcludeNamespaces,
+		ExcludeNamespacePatterns: c.ExcludeNamespacePattern,
+		IncludeHelmReleases:      c.IncludeHelmRelease,
+		CompositionLabelSelector: c.CompositionLabelSelector,
+		MaxResourceSizeBytes:     c.MaxResourceSize,
+		IncludeExtraResources:    c.IncludeExtraResources,
+		ExcludeResources:         c.ExcludeResources,
+		IncludeWebhookConfigs:    c.IncludeWebhookConfigs,
+		Fetch: exporter.FetchOptions{
+			FieldSelector: c.FieldSelector,
+			LabelSelector: c.LabelSelector,
+		},
+	})
+	plans, err := e.Plan(ctx)
+	if err != nil {
+		return err
+	}
+
+	data := pterm.TableData{{"TYPE", "TOTAL", "EXPORTED", "SKIPPED"}}
+	for _, p := range plans {
+		data = append(data, []string{p.Type, strconv.Itoa(p.Total), strconv.Itoa(p.Exported), strconv.Itoa(p.Skipped)})
+	}
+	return pterm.DefaultTable.WithHasHeader().WithData(data).Render()
+}
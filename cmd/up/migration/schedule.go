@@ -0,0 +1,302 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migration
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/alecthomas/kong"
+	"github.com/pterm/pterm"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	applybatchv1 "k8s.io/client-go/applyconfigurations/batch/v1"
+	applycorev1 "k8s.io/client-go/applyconfigurations/core/v1"
+	applyrbacv1 "k8s.io/client-go/applyconfigurations/rbac/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+
+	"github.com/upbound/up/internal/config"
+	"github.com/upbound/up/pkg/migration"
+	"github.com/upbound/up/pkg/migration/exporter"
+)
+
+const (
+	scheduleFieldManager = "up-migration-schedule"
+	encryptionKeyEnv     = "UP_MIGRATION_ENCRYPTION_KEY"
+	encryptionKeyKey     = "key"
+)
+
+// exportFlagGroup is the group of the export flags of the schedule command,
+// which are passed on to the scheduled export. It is the group of
+// scheduleCmd.Export.
+var exportFlagGroup = func() string {
+	f, _ := reflect.TypeOf(scheduleCmd{}).FieldByName("Export")
+	return f.Tag.Get("group")
+}()
+
+// skippedExportFlags are the export flags that are set by the schedule
+// command itself rather than passed on.
+var skippedExportFlags = map[string]bool{
+	"yes":            true,
+	"s3-bucket":      true,
+	"s3-prefix":      true,
+	"encryption-key": true,
+}
+
+// scheduleCmd creates a CronJob that periodically exports the state of the
+// control plane to an S3 bucket.
+type scheduleCmd struct {
+	Cron          string `required:"" help:"The schedule of the export in cron format, e.g. '0 2 * * *' for every day at 2 AM."`
+	ArchiveBucket string `required:"" help:"The S3 bucket and optional prefix to stream the archives to, e.g. 's3://my-bucket/exports/'. Every export overwrites the archive of the previous one unless the bucket is versioned."`
+	Image         string `required:"" help:"The image to run the export with. It must contain the up CLI on its PATH."`
+
+	Name      string `default:"up-migration-export" help:"The name of the CronJob and of the ServiceAccount, ClusterRole, and ClusterRoleBinding the export runs with."`
+	Namespace string `short:"n" default:"default" help:"The namespace of the CronJob and its ServiceAccount."`
+
+	CredentialsSecret   string `help:"The name of a Secret in --namespace whose keys are set as environment variables of the export, e.g. 'AWS_ACCESS_KEY_ID' and 'AWS_SECRET_ACCESS_KEY' to access the bucket."`
+	EncryptionKeySecret string `help:"The name of a Secret in --namespace with the key to encrypt the archives with under its 'key' key, instead of passing --encryption-key, which would be stored in the CronJob."`
+
+	Export exportCmd `embed:"" group:"Export options"`
+}
+
+func (c *scheduleCmd) Help() string {
+	return `
+Usage:
+    migration schedule --cron=<schedule> --archive-bucket=<url> --image=<image> [export options]
+
+The 'schedule' command creates a CronJob in the control plane that runs 'migration export' on the given schedule and
+streams the archives to an S3 bucket, rather than exporting the control plane right away. All options of the export
+command are supported and passed on to the scheduled exports. The exports run with a ServiceAccount bound to a
+ClusterRole that allows reading the types that would be exported at the time of scheduling. Run the command again to
+update the schedule, the options, or the ClusterRole after new types were installed.
+
+Examples:
+    migration schedule --cron="0 2 * * *" --archive-bucket=s3://my-bucket/exports/ --image=registry.example.com/up:v0.28.0 \
+        --credentials-secret=aws-credentials --pause-before-export
+        Exports the control plane state every day at 2 AM to 'exports/xp-state.tar.gz' in the bucket 'my-bucket',
+        pausing all managed resources first, with the AWS credentials in the Secret 'aws-credentials'.
+`
+}
+
+// Run executes the schedule command.
+func (c *scheduleCmd) Run(ctx context.Context, kongCtx *kong.Context, p pterm.TextPrinter, migCtx *migration.Context, quiet config.QuietFlag) error {
+	bucket, prefix, err := parseArchiveBucket(c.ArchiveBucket)
+	if err != nil {
+		return err
+	}
+	if err := c.validate(kongCtx); err != nil {
+		return err
+	}
+	args := append(exportArgs(kongCtx), "--s3-bucket="+bucket)
+	if prefix != "" {
+		args = append(args, "--s3-prefix="+prefix)
+	}
+
+	eo, err := c.Export.options(bool(quiet))
+	if err != nil {
+		return err
+	}
+	e, err := exporter.NewControlPlaneStateExporterForConfig(migCtx.Kubeconfig, eo)
+	if err != nil {
+		return err
+	}
+	gvrs, err := e.ListExportableResources(ctx)
+	if err != nil {
+		return errors.Wrap(err, "cannot get types to export")
+	}
+
+	cs, err := kubernetes.NewForConfig(migCtx.Kubeconfig)
+	if err != nil {
+		return err
+	}
+	opts := v1.ApplyOptions{FieldManager: scheduleFieldManager, Force: true}
+	labels := map[string]string{"app.kubernetes.io/managed-by": "up", "app.kubernetes.io/name": c.Name}
+	if _, err := cs.CoreV1().ServiceAccounts(c.Namespace).Apply(ctx, applycorev1.ServiceAccount(c.Name, c.Namespace).WithLabels(labels), opts); err != nil {
+		return errors.Wrap(err, "cannot apply ServiceAccount")
+	}
+	role := applyrbacv1.ClusterRole(c.Name).WithLabels(labels).WithRules(exportRules(gvrs, c.Export.PauseBeforeExport, c.Export.RespectPriorityClasses)...)
+	if _, err := cs.RbacV1().ClusterRoles().Apply(ctx, role, opts); err != nil {
+		return errors.Wrap(err, "cannot apply ClusterRole")
+	}
+	binding := applyrbacv1.ClusterRoleBinding(c.Name).WithLabels(labels).
+		WithRoleRef(applyrbacv1.RoleRef().WithAPIGroup("rbac.authorization.k8s.io").WithKind("ClusterRole").WithName(c.Name)).
+		WithSubjects(applyrbacv1.Subject().WithKind("ServiceAccount").WithName(c.Name).WithNamespace(c.Namespace))
+	if _, err := cs.RbacV1().ClusterRoleBindings().Apply(ctx, binding, opts); err != nil {
+		return errors.Wrap(err, "cannot apply ClusterRoleBinding")
+	}
+	if _, err := cs.BatchV1().CronJobs(c.Namespace).Apply(ctx, c.cronJob(labels, args), opts); err != nil {
+		return errors.Wrap(err, "cannot apply CronJob")
+	}
+
+	p.Printfln("Scheduled the export %q with CronJob %s/%s", c.Cron, c.Namespace, c.Name)
+	return nil
+}
+
+// validate returns an error if export flags are set that the schedule command
+// sets itself or that must not be stored in the CronJob.
+func (c *scheduleCmd) validate(kongCtx *kong.Context) error {
+	if c.Export.S3Bucket != "" || c.Export.S3Prefix != "" {
+		return errors.New("--s3-bucket and --s3-prefix cannot be used with --archive-bucket")
+	}
+	if flagPassed(kongCtx, "encryption-key") {
+		return errors.New("the encryption key would be stored in the CronJob, use --encryption-key-secret instead")
+	}
+	// A key set through the environment is not passed on, the scheduled
+	// export reads its key from --encryption-key-secret.
+	c.Export.EncryptionKey = ""
+	return nil
+}
+
+// flagPassed returns whether the flag with the supplied name was passed on the
+// command line, rather than set through its environment variable or default.
+func flagPassed(kongCtx *kong.Context, name string) bool {
+	for _, p := range kongCtx.Path {
+		if p.Flag != nil && !p.Resolved && p.Flag.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// cronJob returns the CronJob running the export with the supplied arguments.
+func (c *scheduleCmd) cronJob(labels map[string]string, args []string) *applybatchv1.CronJobApplyConfiguration {
+	container := applycorev1.Container().
+		WithName("export").
+		WithImage(c.Image).
+		WithCommand("up", "alpha", "migration", "export").
+		WithArgs(args...)
+	if c.CredentialsSecret != "" {
+		container.WithEnvFrom(applycorev1.EnvFromSource().WithSecretRef(applycorev1.SecretEnvSource().WithName(c.CredentialsSecret)))
+	}
+	if c.EncryptionKeySecret != "" {
+		container.WithEnv(applycorev1.EnvVar().WithName(encryptionKeyEnv).WithValueFrom(
+			applycorev1.EnvVarSource().WithSecretKeyRef(applycorev1.SecretKeySelector().WithName(c.EncryptionKeySecret).WithKey(encryptionKeyKey))))
+	}
+
+	return applybatchv1.CronJob(c.Name, c.Namespace).WithLabels(labels).WithSpec(applybatchv1.CronJobSpec().
+		WithSchedule(c.Cron).
+		// An export must not overwrite the archive of one still running.
+		WithConcurrencyPolicy(batchv1.ForbidConcurrent).
+		WithJobTemplate(applybatchv1.JobTemplateSpec().WithLabels(labels).WithSpec(applybatchv1.JobSpec().
+			WithBackoffLimit(0).
+			WithTemplate(applycorev1.PodTemplateSpec().WithLabels(labels).WithSpec(applycorev1.PodSpec().
+				WithServiceAccountName(c.Name).
+				WithRestartPolicy(corev1.RestartPolicyNever).
+				WithContainers(container))))))
+}
+
+// parseArchiveBucket returns the bucket and prefix of an S3 URL like
+// "s3://my-bucket/exports/".
+func parseArchiveBucket(s string) (string, string, error) {
+	u, err := url.Parse(s)
+	if err != nil || u.Scheme != "s3" || u.Host == "" {
+		return "", "", errors.Errorf("archive bucket %q is not of the form 's3://<bucket>/<prefix>'", s)
+	}
+	return u.Host, strings.Trim(u.Path, "/"), nil
+}
+
+// exportArgs returns the arguments that pass the export flags with non-zero
+// values in kongCtx on to the scheduled export, which must not prompt.
+func exportArgs(kongCtx *kong.Context) []string {
+	args := []string{"--yes"}
+	for _, f := range kongCtx.Flags() {
+		if f.Group == nil || f.Group.Key != exportFlagGroup || skippedExportFlags[f.Name] {
+			continue
+		}
+		if !f.Target.IsValid() || f.Target.IsZero() {
+			continue
+		}
+		args = append(args, flagArgs(f.Name, f.Target)...)
+	}
+	return args
+}
+
+// flagArgs returns the arguments that set the flag with the supplied name to
+// the non-zero value v. Slices and maps are passed as one argument per
+// element, which kong accumulates.
+func flagArgs(name string, v reflect.Value) []string {
+	switch x := v.Interface().(type) {
+	case bool:
+		return []string{"--" + name}
+	case time.Time:
+		return []string{fmt.Sprintf("--%s=%s", name, x.Format(time.RFC3339))}
+	case time.Duration:
+		return []string{fmt.Sprintf("--%s=%s", name, x)}
+	}
+	switch v.Kind() { //nolint:exhaustive // Other kinds are formatted as is.
+	case reflect.Slice:
+		args := make([]string, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			args = append(args, fmt.Sprintf("--%s=%v", name, v.Index(i).Interface()))
+		}
+		return args
+	case reflect.Map:
+		args := make([]string, 0, v.Len())
+		for _, k := range v.MapKeys() {
+			args = append(args, fmt.Sprintf("--%s=%v=%v", name, k.Interface(), v.MapIndex(k).Interface()))
+		}
+		sort.Strings(args)
+		return args
+	}
+	return []string{fmt.Sprintf("--%s=%v", name, v.Interface())}
+}
+
+// exportRules returns the rules of the ClusterRole of the scheduled export,
+// which reads the supplied types, the CRDs, and the Crossplane deployment.
+// The exported types are updated as well to pause managed resources before
+// the export.
+func exportRules(gvrs []schema.GroupVersionResource, pause, priorityClasses bool) []*applyrbacv1.PolicyRuleApplyConfiguration {
+	read := []string{"get", "list"}
+	verbs := read
+	if pause {
+		verbs = []string{"get", "list", "update", "patch"}
+	}
+	resources := map[string][]string{}
+	for _, gvr := range gvrs {
+		resources[gvr.Group] = append(resources[gvr.Group], gvr.Resource)
+	}
+	groups := make([]string, 0, len(resources))
+	for g := range resources {
+		groups = append(groups, g)
+	}
+	sort.Strings(groups)
+	rules := make([]*applyrbacv1.PolicyRuleApplyConfiguration, 0, len(groups)+4)
+	for _, g := range groups {
+		rs := resources[g]
+		sort.Strings(rs)
+		rules = append(rules, applyrbacv1.PolicyRule().WithAPIGroups(g).WithResources(rs...).WithVerbs(verbs...))
+	}
+
+	rules = append(rules,
+		applyrbacv1.PolicyRule().WithAPIGroups("apiextensions.k8s.io").WithResources("customresourcedefinitions").WithVerbs(read...),
+		applyrbacv1.PolicyRule().WithAPIGroups("apps").WithResources("deployments").WithVerbs(read...),
+	)
+	if priorityClasses {
+		rules = append(rules,
+			applyrbacv1.PolicyRule().WithAPIGroups("").WithResources("pods").WithVerbs(read...),
+			applyrbacv1.PolicyRule().WithAPIGroups("scheduling.k8s.io").WithResources("priorityclasses").WithVerbs(read...),
+		)
+	}
+	return rules
+}
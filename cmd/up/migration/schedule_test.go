@@ -0,0 +1,305 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migration
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/alecthomas/kong"
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	applyrbacv1 "k8s.io/client-go/applyconfigurations/rbac/v1"
+)
+
+// parseSchedule parses the arguments of the schedule command.
+func parseSchedule(t *testing.T, args ...string) (*scheduleCmd, *kong.Context) {
+	t.Helper()
+	cli := struct {
+		Schedule scheduleCmd `cmd:""`
+	}{}
+	k, err := kong.New(&cli)
+	if err != nil {
+		t.Fatalf("kong.New(...): unexpected error: %v", err)
+	}
+	required := []string{"schedule", "--cron=0 2 * * *", "--archive-bucket=s3://bucket/exports", "--image=up:latest"}
+	kongCtx, err := k.Parse(append(required, args...))
+	if err != nil {
+		t.Fatalf("Parse(...): unexpected error: %v", err)
+	}
+	return &cli.Schedule, kongCtx
+}
+
+func TestFlagArgs(t *testing.T) {
+	cases := map[string]struct {
+		reason string
+		name   string
+		value  any
+		want   []string
+	}{
+		"Bool": {
+			reason: "Bool flags should be passed without a value.",
+			name:   "pause-before-export",
+			value:  true,
+			want:   []string{"--pause-before-export"},
+		},
+		"Time": {
+			reason: "Times should be passed in RFC 3339 format.",
+			name:   "changed-since",
+			value:  time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+			want:   []string{"--changed-since=2024-01-02T03:04:05Z"},
+		},
+		"Duration": {
+			reason: "Durations should be passed in Go duration format.",
+			name:   "timeout",
+			value:  90 * time.Minute,
+			want:   []string{"--timeout=1h30m0s"},
+		},
+		"Slice": {
+			reason: "Slices should be passed as one argument per element, in order.",
+			name:   "exclude-namespaces",
+			value:  []string{"kube-system", "team-a"},
+			want:   []string{"--exclude-namespaces=kube-system", "--exclude-namespaces=team-a"},
+		},
+		"Map": {
+			reason: "Maps should be passed as one sorted argument per entry.",
+			name:   "compress-level-by-type",
+			value:  map[string]int{"Secret": 9, "ConfigMap": 3},
+			want:   []string{"--compress-level-by-type=ConfigMap=3", "--compress-level-by-type=Secret=9"},
+		},
+		"String": {
+			reason: "Other values should be passed as is.",
+			name:   "output",
+			value:  "state.tar.gz",
+			want:   []string{"--output=state.tar.gz"},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := flagArgs(tc.name, reflect.ValueOf(tc.value))
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\nflagArgs(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestExportArgs(t *testing.T) {
+	cases := map[string]struct {
+		reason string
+		args   []string
+		want   []string
+	}{
+		"ExportFlags": {
+			reason: "Export flags with non-zero values, including defaults, should be passed on, and flags of the schedule command itself should not.",
+			args:   []string{"--name=nightly", "--pause-before-export", "--exclude-namespaces=kube-system", "--fetch-retry-backoff=2s"},
+			want: []string{
+				"--yes",
+				"--output=xp-state.tar.gz",
+				"--output-format=archive",
+				"--compression-algorithm=gzip",
+				"--include-extra-resources=namespaces",
+				"--include-extra-resources=configmaps",
+				"--include-extra-resources=secrets",
+				"--exclude-namespaces=kube-system",
+				"--pause-before-export",
+				"--fetch-retry-backoff=2s",
+				"--parallelism=1",
+				"--migration-expected-duration=2h0m0s",
+			},
+		},
+		"SkippedFlags": {
+			reason: "Flags set by the schedule command itself should not be passed on.",
+			args:   []string{"--yes", "--s3-prefix=other"},
+			want: []string{
+				"--yes",
+				"--output=xp-state.tar.gz",
+				"--output-format=archive",
+				"--compression-algorithm=gzip",
+				"--include-extra-resources=namespaces",
+				"--include-extra-resources=configmaps",
+				"--include-extra-resources=secrets",
+				"--exclude-namespaces=kube-system",
+				"--exclude-namespaces=kube-public",
+				"--exclude-namespaces=kube-node-lease",
+				"--exclude-namespaces=local-path-storage",
+				"--fetch-retry-backoff=1s",
+				"--parallelism=1",
+				"--migration-expected-duration=2h0m0s",
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, kongCtx := parseSchedule(t, tc.args...)
+			got := exportArgs(kongCtx)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\nexportArgs(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestScheduleCmdValidate(t *testing.T) {
+	cases := map[string]struct {
+		reason string
+		env    string
+		args   []string
+		err    bool
+	}{
+		"EncryptionKeyFlag": {
+			reason: "An encryption key passed as a flag would be stored in the CronJob.",
+			args:   []string{"--encryption-key=c2VjcmV0"},
+			err:    true,
+		},
+		"EncryptionKeyEnv": {
+			reason: "An encryption key set through the environment should be ignored.",
+			env:    "c2VjcmV0",
+		},
+		"S3Bucket": {
+			reason: "The bucket is set through --archive-bucket.",
+			args:   []string{"--s3-bucket=other"},
+			err:    true,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if tc.env != "" {
+				t.Setenv(encryptionKeyEnv, tc.env)
+			}
+			c, kongCtx := parseSchedule(t, tc.args...)
+			err := c.validate(kongCtx)
+			if (err != nil) != tc.err {
+				t.Fatalf("\n%s\nvalidate(...): error = %v, want error %t", tc.reason, err, tc.err)
+			}
+			if err == nil && c.Export.EncryptionKey != "" {
+				t.Errorf("\n%s\nvalidate(...): encryption key was not cleared", tc.reason)
+			}
+		})
+	}
+}
+
+func TestParseArchiveBucket(t *testing.T) {
+	type want struct {
+		bucket string
+		prefix string
+		err    bool
+	}
+
+	cases := map[string]struct {
+		reason string
+		url    string
+		want   want
+	}{
+		"BucketOnly": {
+			reason: "A bucket without a path should have no prefix.",
+			url:    "s3://my-bucket",
+			want:   want{bucket: "my-bucket"},
+		},
+		"Prefix": {
+			reason: "The path should be the prefix without surrounding slashes.",
+			url:    "s3://my-bucket/exports/prod/",
+			want:   want{bucket: "my-bucket", prefix: "exports/prod"},
+		},
+		"WrongScheme": {
+			reason: "Only S3 URLs are supported.",
+			url:    "gs://my-bucket/exports",
+			want:   want{err: true},
+		},
+		"NoBucket": {
+			reason: "A bucket is required.",
+			url:    "s3:///exports",
+			want:   want{err: true},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			bucket, prefix, err := parseArchiveBucket(tc.url)
+			if (err != nil) != tc.want.err {
+				t.Fatalf("\n%s\nparseArchiveBucket(...): error = %v, want error %t", tc.reason, err, tc.want.err)
+			}
+			if diff := cmp.Diff(tc.want.bucket, bucket); diff != "" {
+				t.Errorf("\n%s\nparseArchiveBucket(...): -want, +got:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.prefix, prefix); diff != "" {
+				t.Errorf("\n%s\nparseArchiveBucket(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestExportRules(t *testing.T) {
+	gvrs := []schema.GroupVersionResource{
+		{Group: "example.org", Version: "v1", Resource: "things"},
+		{Version: "v1", Resource: "secrets"},
+		{Group: "example.org", Version: "v1", Resource: "buckets"},
+	}
+	rule := func(group string, verbs []string, resources ...string) *applyrbacv1.PolicyRuleApplyConfiguration {
+		return applyrbacv1.PolicyRule().WithAPIGroups(group).WithResources(resources...).WithVerbs(verbs...)
+	}
+	read := []string{"get", "list"}
+
+	type args struct {
+		pause           bool
+		priorityClasses bool
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   []*applyrbacv1.PolicyRuleApplyConfiguration
+	}{
+		"ReadOnly": {
+			reason: "The exported types should be readable, grouped and sorted by API group.",
+			want: []*applyrbacv1.PolicyRuleApplyConfiguration{
+				rule("", read, "secrets"),
+				rule("example.org", read, "buckets", "things"),
+				rule("apiextensions.k8s.io", read, "customresourcedefinitions"),
+				rule("apps", read, "deployments"),
+			},
+		},
+		"Pause": {
+			reason: "The exported types should be updatable to pause them.",
+			args:   args{pause: true},
+			want: []*applyrbacv1.PolicyRuleApplyConfiguration{
+				rule("", []string{"get", "list", "update", "patch"}, "secrets"),
+				rule("example.org", []string{"get", "list", "update", "patch"}, "buckets", "things"),
+				rule("apiextensions.k8s.io", read, "customresourcedefinitions"),
+				rule("apps", read, "deployments"),
+			},
+		},
+		"PriorityClasses": {
+			reason: "Pods and PriorityClasses should be readable to order the types by priority.",
+			args:   args{priorityClasses: true},
+			want: []*applyrbacv1.PolicyRuleApplyConfiguration{
+				rule("", read, "secrets"),
+				rule("example.org", read, "buckets", "things"),
+				rule("apiextensions.k8s.io", read, "customresourcedefinitions"),
+				rule("apps", read, "deployments"),
+				rule("", read, "pods"),
+				rule("scheduling.k8s.io", read, "priorityclasses"),
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := exportRules(gvrs, tc.args.pause, tc.args.priorityClasses)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\nexportRules(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
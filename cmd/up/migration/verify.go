@@ -0,0 +1,84 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migration
+
+import (
+	"context"
+
+	"github.com/pterm/pterm"
+	"github.com/upbound/up/pkg/migration"
+	"github.com/upbound/up/pkg/migration/importer"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+)
+
+type verifyCmd struct {
+	Input         string `short:"i" help:"Specifies the file path of the archive to be verified, or '-' to read it from stdin. The default path is 'xp-state.tar.gz'." default:"xp-state.tar.gz"`
+	InputFormat   string `enum:"archive,directory" help:"The format of the export to be verified, either a gzip or zstd compressed tar 'archive' or a 'directory' of plain YAML files at the --input path. Defaults to 'archive'." default:"archive"`
+	EncryptionKey string `env:"UP_MIGRATION_ENCRYPTION_KEY" help:"The base64 encoded 32 byte key to decrypt an archive encrypted by 'migration export --encryption-key' with. Archives that are not encrypted are read as is."`
+}
+
+func (c *verifyCmd) Help() string {
+	return `
+Usage:
+    migration verify [options]
+
+The 'verify' command checks that an exported control plane state was not corrupted, e.g. while being stored in or
+downloaded from object storage. It computes the SHA-256 checksum of every exported file and compares it to the
+checksum manifest the export embeds. Files that do not match are reported with both checksums, and the command
+fails if there are any. The control plane is not accessed.
+
+Examples:
+    migration verify --input=my-export.tar.gz
+        Verifies the checksums of the files in 'my-export.tar.gz'.
+`
+}
+
+func (c *verifyCmd) Run(ctx context.Context) error {
+	return withExitCode(c.run(ctx))
+}
+
+func (c *verifyCmd) run(ctx context.Context) error {
+	im := importer.NewControlPlaneStateImporter(nil, nil, nil, nil, importer.Options{
+		InputArchive:  c.Input,
+		InputFormat:   importer.InputFormat(c.InputFormat),
+		EncryptionKey: c.EncryptionKey,
+	})
+	mismatches, err := im.Verify(ctx)
+	if err != nil {
+		return err
+	}
+	if len(mismatches) == 0 {
+		pterm.Success.Println("All checksums match.")
+		return nil
+	}
+
+	data := pterm.TableData{{"FILE", "EXPECTED", "ACTUAL"}}
+	for _, m := range mismatches {
+		data = append(data, []string{m.Path, orNone(m.Expected, "not listed"), orNone(m.Actual, "missing")})
+	}
+	if err := pterm.DefaultTable.WithHasHeader().WithData(data).Render(); err != nil {
+		return err
+	}
+	return migration.WithCode(errors.Errorf("%d files do not match their checksums", len(mismatches)), migration.ErrCodeArchiveCorrupt)
+}
+
+// orNone returns s, or none if s is empty.
+func orNone(s, none string) string {
+	if s == "" {
+		return "(" + none + ")"
+	}
+	return s
+}
@@ -24,15 +24,20 @@ import (
 
 // createCmd creates an organization on Upbound.
 type createCmd struct {
-	Name string `arg:"" required:"" help:"Name of organization."`
+	Name        string `arg:"" required:"" help:"Name of organization."`
+	DisplayName string `help:"Display name of organization. Defaults to its name."`
 }
 
 // Run executes the create command.
 func (c *createCmd) Run(ctx context.Context, p pterm.TextPrinter, oc *organizations.Client) error {
-	if err := oc.Create(ctx, &organizations.OrganizationCreateParameters{
-		Name: c.Name,
+	displayName := c.DisplayName
+	if displayName == "" {
 		// NOTE(hasheddan): we default display name to the same as name.
-		DisplayName: c.Name,
+		displayName = c.Name
+	}
+	if err := oc.Create(ctx, &organizations.OrganizationCreateParameters{
+		Name:        c.Name,
+		DisplayName: displayName,
 	}); err != nil {
 		return err
 	}
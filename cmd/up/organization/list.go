@@ -23,6 +23,8 @@ import (
 	"github.com/upbound/up-sdk-go/service/organizations"
 	"github.com/upbound/up/internal/upterm"
 
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+
 	"github.com/upbound/up/internal/upbound"
 )
 
@@ -33,9 +35,23 @@ func (c *listCmd) AfterApply(kongCtx *kong.Context, upCtx *upbound.Context) erro
 }
 
 // listCmd lists organizations on Upbound.
-type listCmd struct{}
+type listCmd struct {
+	Limit int `help:"The maximum number of organizations to list per page. All organizations are listed by default."`
+	Page  int `default:"1" help:"The page of organizations to list with --limit, starting at 1."`
+}
 
-var fieldNames = []string{"ID", "NAME", "ROLE"}
+var fieldNames = []string{"ID", "NAME", "DISPLAY NAME", "ROLE"}
+
+// Validate validates the pagination flags.
+func (c *listCmd) Validate() error {
+	if c.Limit < 0 {
+		return errors.New("--limit must not be negative")
+	}
+	if c.Page < 1 {
+		return errors.New("--page must be at least 1")
+	}
+	return nil
+}
 
 // Run executes the list command.
 func (c *listCmd) Run(printer upterm.ObjectPrinter, p pterm.TextPrinter, oc *organizations.Client, upCtx *upbound.Context) error {
@@ -47,10 +63,36 @@ func (c *listCmd) Run(printer upterm.ObjectPrinter, p pterm.TextPrinter, oc *org
 		p.Printfln("No organizations found.")
 		return nil
 	}
-	return printer.Print(orgs, fieldNames, extractFields)
+	page := paginate(orgs, c.Page, c.Limit)
+	if len(page) == 0 {
+		p.Printfln("No organizations found on page %d.", c.Page)
+		return nil
+	}
+	return printer.Print(page, fieldNames, extractFields)
+}
+
+// paginate returns the page of orgs with at most limit organizations. The
+// API does not support pagination, so all organizations are listed and then
+// paginated. A limit of 0 returns all organizations on the first page.
+func paginate(orgs []organizations.Organization, page, limit int) []organizations.Organization {
+	if limit == 0 {
+		if page > 1 {
+			return nil
+		}
+		return orgs
+	}
+	start := (page - 1) * limit
+	if start >= len(orgs) {
+		return nil
+	}
+	end := start + limit
+	if end > len(orgs) {
+		end = len(orgs)
+	}
+	return orgs[start:end]
 }
 
 func extractFields(obj any) []string {
 	o := obj.(organizations.Organization)
-	return []string{strconv.Itoa(int(o.ID)), o.Name, string(o.Role)}
+	return []string{strconv.Itoa(int(o.ID)), o.Name, o.DisplayName, string(o.Role)}
 }
@@ -0,0 +1,69 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package organization
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/upbound/up-sdk-go/service/organizations"
+)
+
+func TestPaginate(t *testing.T) {
+	orgs := []organizations.Organization{{ID: 1}, {ID: 2}, {ID: 3}}
+
+	type args struct {
+		page  int
+		limit int
+	}
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   []organizations.Organization
+	}{
+		"NoLimit": {
+			reason: "All organizations should be returned without a limit.",
+			args:   args{page: 1},
+			want:   orgs,
+		},
+		"NoLimitLaterPage": {
+			reason: "All organizations are on the first page without a limit.",
+			args:   args{page: 2},
+		},
+		"FirstPage": {
+			reason: "The first page should contain the first organizations.",
+			args:   args{page: 1, limit: 2},
+			want:   orgs[:2],
+		},
+		"LastPage": {
+			reason: "The last page should contain the remaining organizations.",
+			args:   args{page: 2, limit: 2},
+			want:   orgs[2:],
+		},
+		"PastLastPage": {
+			reason: "Pages after the last one should be empty.",
+			args:   args{page: 3, limit: 2},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := paginate(orgs, tc.args.page, tc.args.limit)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\npaginate(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -43,11 +43,11 @@ func (c *deleteCmd) BeforeApply() error {
 
 // AfterApply accepts user input by default to confirm the delete operation.
 func (c *deleteCmd) AfterApply(p pterm.TextPrinter, upCtx *upbound.Context) error {
-	if c.Force {
+	if c.Confirm || c.Force {
 		return nil
 	}
 
-	confirm, err := c.prompter.Prompt("Are you sure you want to delete this robot? [y/n]", false)
+	confirm, err := c.prompter.Prompt(fmt.Sprintf("Are you sure you want to delete robot '%s'? [y/N]", c.Name), false)
 	if err != nil {
 		return err
 	}
@@ -66,7 +66,8 @@ type deleteCmd struct {
 
 	Name string `arg:"" required:"" help:"Name of robot." predictor:"robots"`
 
-	Force bool `help:"Force delete robot even if conflicts exist." default:"false"`
+	Confirm bool `short:"y" help:"Delete the robot without asking for confirmation." default:"false"`
+	Force   bool `help:"Force delete robot even if conflicts exist." default:"false"`
 }
 
 // Run executes the delete command.
@@ -0,0 +1,87 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package robot
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pterm/pterm"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/upbound/up/internal/upbound"
+)
+
+// answer is a prompter recording its prompts and answering them.
+type answer struct {
+	answer  string
+	prompts []string
+}
+
+func (a *answer) Prompt(label string, _ bool) (string, error) {
+	a.prompts = append(a.prompts, label)
+	return a.answer, nil
+}
+
+func TestDeleteAfterApply(t *testing.T) {
+	prompt := "Are you sure you want to delete robot 'cool-robot'? [y/N]"
+
+	type want struct {
+		prompts []string
+		err     error
+	}
+	cases := map[string]struct {
+		reason  string
+		confirm bool
+		answer  string
+		want    want
+	}{
+		"Confirmed": {
+			reason:  "The user should not be asked if the deletion was confirmed by flag.",
+			confirm: true,
+		},
+		"Yes": {
+			reason: "The robot should be deleted if the user agrees.",
+			answer: "y",
+			want: want{
+				prompts: []string{prompt},
+			},
+		},
+		"No": {
+			reason: "The robot should not be deleted by default.",
+			want: want{
+				prompts: []string{prompt},
+				err:     errors.New("operation canceled"),
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			a := &answer{answer: tc.answer}
+			c := &deleteCmd{Name: "cool-robot", Confirm: tc.confirm, prompter: a}
+
+			err := c.AfterApply(pterm.DefaultBasicText.WithWriter(&bytes.Buffer{}), &upbound.Context{Account: "cool-org"})
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nAfterApply(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.prompts, a.prompts); diff != "" {
+				t.Errorf("\n%s\nAfterApply(...): -want prompts, +got prompts:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -16,8 +16,10 @@ package robot
 
 import (
 	"context"
+	"time"
 
 	"github.com/alecthomas/kong"
+	"github.com/google/uuid"
 	"github.com/pterm/pterm"
 	"github.com/upbound/up/internal/upterm"
 
@@ -29,6 +31,17 @@ import (
 	"github.com/upbound/up/internal/upbound"
 )
 
+var teamFieldNames = []string{"TEAM", "ID"}
+
+// robotDetails is a robot along with the teams it is a member of.
+type robotDetails struct {
+	ID          uuid.UUID `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"createdAt"`
+	Teams       []team    `json:"teams"`
+}
+
 // AfterApply sets default values in command after assignment and validation.
 func (c *getCmd) AfterApply(kongCtx *kong.Context, upCtx *upbound.Context) error {
 	kongCtx.Bind(pterm.DefaultTable.WithWriter(kongCtx.Stdout).WithSeparator("   "))
@@ -37,17 +50,43 @@ func (c *getCmd) AfterApply(kongCtx *kong.Context, upCtx *upbound.Context) error
 
 // getCmd gets a single robot in an account on Upbound.
 type getCmd struct {
-	Name string `arg:"" required:"" help:"Name of robot." predictor:"robots"`
+	Name   string `arg:"" required:"" help:"Name of robot." predictor:"robots"`
+	Output string `short:"o" enum:"table,json,yaml" default:"table" help:"Output format, either 'table', or 'json' or 'yaml' to print the robot along with its teams."`
 }
 
 // Run executes the get robot command.
-func (c *getCmd) Run(ctx context.Context, printer upterm.ObjectPrinter, ac *accounts.Client, oc *organizations.Client, upCtx *upbound.Context) error {
-	a, err := ac.Get(ctx, upCtx.Account)
+func (c *getCmd) Run(ctx context.Context, kongCtx *kong.Context, printer upterm.ObjectPrinter, p pterm.TextPrinter, ac *accounts.Client, oc *organizations.Client, upCtx *upbound.Context) error {
+	r, err := c.get(ctx, ac, oc, upCtx)
 	if err != nil {
 		return err
 	}
+	switch c.Output {
+	case "json":
+		return printer.PrintJSON(r, kongCtx.Stdout)
+	case "yaml":
+		return printer.PrintYAML(r, kongCtx.Stdout)
+	}
+
+	robot := organizations.Robot{ID: r.ID, Name: r.Name, Description: r.Description, CreatedAt: r.CreatedAt}
+	if err := printer.Print(robot, fieldNames, extractFields); err != nil {
+		return err
+	}
+	p.Println()
+	if len(r.Teams) == 0 {
+		p.Println("Robot is not a member of any team")
+		return nil
+	}
+	return printer.Print(r.Teams, teamFieldNames, extractTeamFields)
+}
+
+// get returns the robot and the teams it is a member of.
+func (c *getCmd) get(ctx context.Context, ac *accounts.Client, oc *organizations.Client, upCtx *upbound.Context) (*robotDetails, error) {
+	a, err := ac.Get(ctx, upCtx.Account)
+	if err != nil {
+		return nil, err
+	}
 	if a.Account.Type != accounts.AccountOrganization {
-		return errors.New(errUserAccount)
+		return nil, errors.New(errUserAccount)
 	}
 
 	// The get command accepts a name, but the get API call takes an ID
@@ -57,13 +96,45 @@ func (c *getCmd) Run(ctx context.Context, printer upterm.ObjectPrinter, ac *acco
 	// the list command.
 	rs, err := oc.ListRobots(ctx, a.Organization.ID)
 	if err != nil {
-		return err
+		return nil, err
 	}
-
-	for _, r := range rs {
-		if r.Name == c.Name {
-			return printer.Print(r, fieldNames, extractFields)
+	var robot *organizations.Robot
+	for i := range rs {
+		if rs[i].Name == c.Name {
+			robot = &rs[i]
+			break
 		}
 	}
-	return errors.New("no robot named \"" + c.Name + "\"")
+	if robot == nil {
+		return nil, errors.New("no robot named \"" + c.Name + "\"")
+	}
+
+	r := &robotDetails{
+		ID:          robot.ID,
+		Name:        robot.Name,
+		Description: robot.Description,
+		CreatedAt:   robot.CreatedAt,
+		Teams:       []team{},
+	}
+	if len(robot.TeamIDs) == 0 {
+		return r, nil
+	}
+	ts, err := listTeams(ctx, oc, a.Organization.ID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot list teams in %s", upCtx.Account)
+	}
+	names := make(map[uuid.UUID]string, len(ts))
+	for _, t := range ts {
+		names[t.ID] = t.Name
+	}
+	for _, id := range robot.TeamIDs {
+		// Teams the user cannot see are listed without a name.
+		r.Teams = append(r.Teams, team{ID: id, Name: names[id]})
+	}
+	return r, nil
+}
+
+func extractTeamFields(obj any) []string {
+	t := obj.(team)
+	return []string{t.Name, t.ID.String()}
 }
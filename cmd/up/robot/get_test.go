@@ -0,0 +1,90 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package robot
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/upbound/up-sdk-go/service/accounts"
+	"github.com/upbound/up-sdk-go/service/organizations"
+
+	"github.com/upbound/up/internal/upbound"
+)
+
+func TestGet(t *testing.T) {
+	id := uuid.MustParse("5a6f0a0e-8b3c-4c8e-9a43-4c8d29d7f1a2")
+	hidden := uuid.MustParse("9f5e3d4c-6a7b-4c8d-ae9f-1a2b3c4d5e6f")
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	type want struct {
+		robot *robotDetails
+		err   error
+	}
+	cases := map[string]struct {
+		reason string
+		robots []organizations.Robot
+		want   want
+	}{
+		"NoTeams": {
+			reason: "A robot that is not a member of any team should have no teams.",
+			robots: []organizations.Robot{{ID: id, Name: "cool-robot", Description: "cool", CreatedAt: created}},
+			want: want{
+				robot: &robotDetails{ID: id, Name: "cool-robot", Description: "cool", CreatedAt: created, Teams: []team{}},
+			},
+		},
+		"Teams": {
+			reason: "The teams of a robot should be named, unless they are not visible.",
+			robots: []organizations.Robot{
+				{Name: "other-robot", TeamIDs: []uuid.UUID{mockTeams[1].ID}},
+				{ID: id, Name: "cool-robot", CreatedAt: created, TeamIDs: []uuid.UUID{mockTeams[0].ID, hidden}},
+			},
+			want: want{
+				robot: &robotDetails{ID: id, Name: "cool-robot", CreatedAt: created, Teams: []team{
+					mockTeams[0],
+					{ID: hidden},
+				}},
+			},
+		},
+		"NotFound": {
+			reason: "A robot that does not exist should be reported.",
+			robots: []organizations.Robot{{Name: "other-robot"}},
+			want: want{
+				err: errors.New("no robot named \"cool-robot\""),
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			cfg := mockAPI(tc.robots, nil, map[string]any{})
+			c := &getCmd{Name: "cool-robot"}
+
+			got, err := c.get(context.Background(), accounts.NewClient(cfg), organizations.NewClient(cfg), &upbound.Context{Account: "cool-org"})
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nget(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.robot, got); diff != "" {
+				t.Errorf("\n%s\nget(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
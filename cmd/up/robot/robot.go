@@ -52,53 +52,87 @@ func (c *Cmd) AfterApply(kongCtx *kong.Context) error {
 
 func PredictRobots() complete.Predictor {
 	return complete.PredictFunc(func(a complete.Args) (prediction []string) {
-		upCtx, err := upbound.NewFromFlags(upbound.Flags{})
-		if err != nil {
+		oc, orgID, ok := completionOrg(context.Background())
+		if !ok {
 			return nil
 		}
-		cfg, err := upCtx.BuildSDKConfig()
+		rs, err := oc.ListRobots(context.Background(), orgID)
 		if err != nil {
 			return nil
 		}
-
-		ac := accounts.NewClient(cfg)
-		if ac == nil {
+		if len(rs) == 0 {
 			return nil
 		}
-
-		oc := organizations.NewClient(cfg)
-		if oc == nil {
-			return nil
+		data := make([]string, len(rs))
+		for i, r := range rs {
+			data[i] = r.Name
 		}
+		return data
+	})
+}
 
-		account, err := ac.Get(context.Background(), upCtx.Account)
-		if err != nil {
-			return nil
-		}
-		if account.Account.Type != accounts.AccountOrganization {
+func PredictTeams() complete.Predictor {
+	return complete.PredictFunc(func(a complete.Args) (prediction []string) {
+		oc, orgID, ok := completionOrg(context.Background())
+		if !ok {
 			return nil
 		}
-		rs, err := oc.ListRobots(context.Background(), account.Organization.ID)
+		ts, err := listTeams(context.Background(), oc, orgID)
 		if err != nil {
 			return nil
 		}
-		if len(rs) == 0 {
+		if len(ts) == 0 {
 			return nil
 		}
-		data := make([]string, len(rs))
-		for i, r := range rs {
-			data[i] = r.Name
+		data := make([]string, len(ts))
+		for i, t := range ts {
+			data[i] = t.Name
 		}
 		return data
 	})
 }
 
+// completionOrg returns an organizations client and the ID of the
+// organization of the current profile to complete robot and team names with,
+// or false if the account of the profile is not an organization.
+func completionOrg(ctx context.Context) (*organizations.Client, uint, bool) {
+	upCtx, err := upbound.NewFromFlags(upbound.Flags{})
+	if err != nil {
+		return nil, 0, false
+	}
+	cfg, err := upCtx.BuildSDKConfig()
+	if err != nil {
+		return nil, 0, false
+	}
+
+	ac := accounts.NewClient(cfg)
+	if ac == nil {
+		return nil, 0, false
+	}
+
+	oc := organizations.NewClient(cfg)
+	if oc == nil {
+		return nil, 0, false
+	}
+
+	account, err := ac.Get(ctx, upCtx.Account)
+	if err != nil {
+		return nil, 0, false
+	}
+	if account.Account.Type != accounts.AccountOrganization {
+		return nil, 0, false
+	}
+	return oc, account.Organization.ID, true
+}
+
 // Cmd contains commands for interacting with robots.
 type Cmd struct {
 	Create createCmd `cmd:"" help:"Create a robot."`
 	Delete deleteCmd `cmd:"" help:"Delete a robot."`
 	List   listCmd   `cmd:"" help:"List robots for the account."`
 	Get    getCmd    `cmd:"" help:"Get a robot for the account."`
+	Update updateCmd `cmd:"" help:"Update the description of a robot."`
+	Team   teamCmd   `cmd:"" help:"Manage the team memberships of robots."`
 	Token  token.Cmd `cmd:"" help:"Interact with robot tokens."`
 
 	// Common Upbound API configuration
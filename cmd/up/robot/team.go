@@ -0,0 +1,193 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package robot
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+
+	"github.com/google/uuid"
+	"github.com/pterm/pterm"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+
+	"github.com/upbound/up-sdk-go/service/accounts"
+	"github.com/upbound/up-sdk-go/service/organizations"
+	"github.com/upbound/up-sdk-go/service/robots"
+
+	"github.com/upbound/up/internal/input"
+	"github.com/upbound/up/internal/upbound"
+)
+
+const (
+	orgsPath = "v1/organizations"
+
+	errMultipleTeamFmt = "found multiple teams with name %s in %s"
+	errFindTeamFmt     = "could not find team %s in %s"
+)
+
+// team is a team of an organization on Upbound.
+type team struct {
+	ID   uuid.UUID `json:"id"`
+	Name string    `json:"name"`
+}
+
+// listTeams lists the teams of the organization. The SDK does not support
+// teams yet.
+func listTeams(ctx context.Context, oc *organizations.Client, orgID uint) ([]team, error) {
+	req, err := oc.Client.NewRequest(ctx, http.MethodGet, orgsPath, fmt.Sprintf("%d/teams", orgID), nil)
+	if err != nil {
+		return nil, err
+	}
+	ts := []team{}
+	if err := oc.Client.Do(req, &ts); err != nil {
+		return nil, err
+	}
+	return ts, nil
+}
+
+// findTeam returns the team with the supplied name, which must be unique.
+func findTeam(ts []team, name, account string) (*team, error) {
+	var t *team
+	for i := range ts {
+		if ts[i].Name != name {
+			continue
+		}
+		if t != nil {
+			return nil, errors.Errorf(errMultipleTeamFmt, name, account)
+		}
+		t = &ts[i]
+	}
+	if t == nil {
+		return nil, errors.Errorf(errFindTeamFmt, name, account)
+	}
+	return t, nil
+}
+
+// robotTeamsRequest is the JSON:API request adding a robot to, or removing it
+// from, teams.
+type robotTeamsRequest struct {
+	Data []robotTeamsData `json:"data"`
+}
+
+type robotTeamsData struct {
+	Type string    `json:"type"`
+	ID   uuid.UUID `json:"id"`
+}
+
+// teamCmd contains commands for managing the team memberships of robots.
+type teamCmd struct {
+	Add    teamAddCmd    `cmd:"" help:"Add a robot to a team."`
+	Remove teamRemoveCmd `cmd:"" help:"Remove a robot from a team."`
+}
+
+// teamAddCmd adds a robot to a team on Upbound.
+type teamAddCmd struct {
+	Robot string `required:"" help:"Name of robot." predictor:"robots"`
+	Team  string `required:"" help:"Name of team." predictor:"teams"`
+}
+
+// Run executes the team add command.
+func (c *teamAddCmd) Run(ctx context.Context, p pterm.TextPrinter, ac *accounts.Client, oc *organizations.Client, rc *robots.Client, upCtx *upbound.Context) error {
+	if err := updateMembership(ctx, http.MethodPost, c.Robot, c.Team, ac, oc, rc, upCtx); err != nil {
+		return errors.Wrapf(err, "cannot add robot %s to team %s", c.Robot, c.Team)
+	}
+	p.Printfln("%s/%s added to team %s", upCtx.Account, c.Robot, c.Team)
+	return nil
+}
+
+// BeforeApply sets default values for the team remove command, before
+// assignment and validation.
+func (c *teamRemoveCmd) BeforeApply() error {
+	c.prompter = input.NewPrompter()
+	return nil
+}
+
+// AfterApply accepts user input by default to confirm the remove operation.
+func (c *teamRemoveCmd) AfterApply() error {
+	if c.Confirm {
+		return nil
+	}
+
+	confirm, err := c.prompter.Prompt(fmt.Sprintf("Are you sure you want to remove robot '%s' from team '%s'? [y/N]", c.Robot, c.Team), false)
+	if err != nil {
+		return err
+	}
+
+	if input.InputYes(confirm) {
+		return nil
+	}
+
+	return fmt.Errorf("operation canceled")
+}
+
+// teamRemoveCmd removes a robot from a team on Upbound.
+type teamRemoveCmd struct {
+	prompter input.Prompter
+
+	Robot string `required:"" help:"Name of robot." predictor:"robots"`
+	Team  string `required:"" help:"Name of team." predictor:"teams"`
+
+	Confirm bool `short:"y" help:"Remove the robot from the team without asking for confirmation." default:"false"`
+}
+
+// Run executes the team remove command.
+func (c *teamRemoveCmd) Run(ctx context.Context, p pterm.TextPrinter, ac *accounts.Client, oc *organizations.Client, rc *robots.Client, upCtx *upbound.Context) error {
+	if err := updateMembership(ctx, http.MethodDelete, c.Robot, c.Team, ac, oc, rc, upCtx); err != nil {
+		return errors.Wrapf(err, "cannot remove robot %s from team %s", c.Robot, c.Team)
+	}
+	p.Printfln("%s/%s removed from team %s", upCtx.Account, c.Robot, c.Team)
+	return nil
+}
+
+// updateMembership adds the named robot to the named team with method POST,
+// or removes it with method DELETE. The SDK does not support team
+// memberships yet.
+func updateMembership(ctx context.Context, method, robotName, teamName string, ac *accounts.Client, oc *organizations.Client, rc *robots.Client, upCtx *upbound.Context) error {
+	a, err := ac.Get(ctx, upCtx.Account)
+	if err != nil {
+		return err
+	}
+	if a.Account.Type != accounts.AccountOrganization {
+		return errors.New(errUserAccount)
+	}
+
+	rs, err := oc.ListRobots(ctx, a.Organization.ID)
+	if err != nil {
+		return err
+	}
+	robot, err := findRobot(rs, robotName, upCtx.Account)
+	if err != nil {
+		return err
+	}
+	ts, err := listTeams(ctx, oc, a.Organization.ID)
+	if err != nil {
+		return errors.Wrapf(err, "cannot list teams in %s", upCtx.Account)
+	}
+	t, err := findTeam(ts, teamName, upCtx.Account)
+	if err != nil {
+		return err
+	}
+
+	req, err := rc.Client.NewRequest(ctx, method, robotsPath, path.Join(robot.ID.String(), "relationships", "teams"), &robotTeamsRequest{
+		Data: []robotTeamsData{{Type: "teams", ID: t.ID}},
+	})
+	if err != nil {
+		return err
+	}
+	return rc.Client.Do(req, nil)
+}
@@ -0,0 +1,152 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package robot
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/upbound/up-sdk-go/fake"
+	"github.com/upbound/up-sdk-go/service/accounts"
+	"github.com/upbound/up-sdk-go/service/organizations"
+	"github.com/upbound/up-sdk-go/service/robots"
+
+	"github.com/upbound/up/internal/upbound"
+)
+
+func TestUpdateMembership(t *testing.T) {
+	errBoom := errors.New("boom")
+	id := uuid.MustParse("5a6f0a0e-8b3c-4c8e-9a43-4c8d29d7f1a2")
+	robot := organizations.Robot{ID: id, Name: "cool-robot"}
+	membership := func(method string) map[string]any {
+		return map[string]any{
+			method + " " + id.String() + "/relationships/teams": &robotTeamsRequest{
+				Data: []robotTeamsData{{Type: "teams", ID: mockTeams[0].ID}},
+			},
+		}
+	}
+
+	type args struct {
+		method    string
+		team      string
+		robots    []organizations.Robot
+		updateErr error
+	}
+	type want struct {
+		requests map[string]any
+		err      error
+	}
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"Added": {
+			reason: "The robot should be added to the team.",
+			args: args{
+				method: http.MethodPost,
+				team:   "cool-team",
+				robots: []organizations.Robot{{Name: "other-robot"}, robot},
+			},
+			want: want{
+				requests: membership(http.MethodPost),
+			},
+		},
+		"Removed": {
+			reason: "The robot should be removed from the team.",
+			args: args{
+				method: http.MethodDelete,
+				team:   "cool-team",
+				robots: []organizations.Robot{robot},
+			},
+			want: want{
+				requests: membership(http.MethodDelete),
+			},
+		},
+		"RobotNotFound": {
+			reason: "The membership of a robot that does not exist should not be changed.",
+			args: args{
+				method: http.MethodPost,
+				team:   "cool-team",
+				robots: []organizations.Robot{{Name: "other-robot"}},
+			},
+			want: want{
+				requests: map[string]any{},
+				err:      errors.Errorf(errFindRobotFmt, "cool-robot", "cool-org"),
+			},
+		},
+		"TeamNotFound": {
+			reason: "The membership of a team that does not exist should not be changed.",
+			args: args{
+				method: http.MethodPost,
+				team:   "missing-team",
+				robots: []organizations.Robot{robot},
+			},
+			want: want{
+				requests: map[string]any{},
+				err:      errors.Errorf(errFindTeamFmt, "missing-team", "cool-org"),
+			},
+		},
+		"UpdateFailed": {
+			reason: "Errors changing the membership should be returned.",
+			args: args{
+				method:    http.MethodPost,
+				team:      "cool-team",
+				robots:    []organizations.Robot{robot},
+				updateErr: errBoom,
+			},
+			want: want{
+				requests: membership(http.MethodPost),
+				err:      errBoom,
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			cfg := mockAPI(tc.args.robots, nil, map[string]any{})
+			// Record the membership requests served by mockAPI otherwise.
+			mc := cfg.Client.(*fake.MockClient)
+			newRequest, do := mc.MockNewRequest, mc.MockDo
+			requests := map[string]any{}
+			mc.MockNewRequest = func(ctx context.Context, method, prefix, urlPath string, body interface{}) (*http.Request, error) {
+				if method == http.MethodPost || method == http.MethodDelete {
+					requests[method+" "+urlPath] = body
+				}
+				return newRequest(ctx, method, prefix, urlPath, body)
+			}
+			mc.MockDo = func(req *http.Request, obj interface{}) error {
+				if req.Method == http.MethodPost || req.Method == http.MethodDelete {
+					return tc.args.updateErr
+				}
+				return do(req, obj)
+			}
+
+			err := updateMembership(context.Background(), tc.args.method, "cool-robot", tc.args.team, accounts.NewClient(cfg), organizations.NewClient(cfg), robots.NewClient(cfg), &upbound.Context{Account: "cool-org"})
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nupdateMembership(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.requests, requests); diff != "" {
+				t.Errorf("\n%s\nupdateMembership(...): -want requests, +got requests:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -18,44 +18,104 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"os"
 	"path/filepath"
+	"time"
 
+	"github.com/alecthomas/kong"
 	"github.com/crossplane/crossplane-runtime/pkg/errors"
 	"github.com/google/uuid"
 	"github.com/pterm/pterm"
 
 	"github.com/upbound/up-sdk-go/service/accounts"
 	"github.com/upbound/up-sdk-go/service/organizations"
-	"github.com/upbound/up-sdk-go/service/robots"
 	"github.com/upbound/up-sdk-go/service/tokens"
 
 	"github.com/upbound/up/internal/upbound"
+	"github.com/upbound/up/internal/upterm"
 )
 
+const tokensPath = "v1/tokens"
+
+var createdFieldNames = []string{"ACCESS ID", "TOKEN", "EXPIRES"}
+
+// tokenCreateRequest is the JSON:API request creating a token. Unlike the
+// SDK's request, it supports an expiration time.
+type tokenCreateRequest struct {
+	Data tokenCreateData `json:"data"`
+}
+
+type tokenCreateData struct {
+	Type          string                    `json:"type"`
+	Attributes    tokenCreateAttributes     `json:"attributes"`
+	Relationships tokens.TokenRelationships `json:"relationships"`
+}
+
+type tokenCreateAttributes struct {
+	Name      string     `json:"name"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+// createdToken is a token that was just created. Its secret can only be
+// retrieved once.
+type createdToken struct {
+	AccessID  string     `json:"accessId"`
+	Token     string     `json:"token"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+// AfterApply sets default values in command after assignment and validation.
+func (c *createCmd) AfterApply(kongCtx *kong.Context) error {
+	kongCtx.Bind(pterm.DefaultTable.WithWriter(kongCtx.Stdout).WithSeparator("   "))
+	return nil
+}
+
 // createCmd creates a robot on Upbound.
 type createCmd struct {
-	RobotName string `arg:"" required:"" help:"Name of robot."`
+	RobotName string `arg:"" required:"" help:"Name of robot." predictor:"robots"`
 	TokenName string `arg:"" required:"" help:"Name of token."`
 
-	Output string `type:"path" short:"o" required:"" help:"Path to write JSON file containing access ID and token."`
+	ExpiresIn time.Duration `help:"How long the token is valid after its creation, e.g. 720h. Tokens do not expire by default."`
+	Output    string        `type:"path" short:"o" help:"Path to write JSON file containing access ID and token. The token is printed if not set or '-'."`
 }
 
 // Run executes the create command.
-func (c *createCmd) Run(ctx context.Context, p pterm.TextPrinter, ac *accounts.Client, oc *organizations.Client, rc *robots.Client, tc *tokens.Client, upCtx *upbound.Context) error { //nolint:gocyclo
-	a, err := ac.Get(ctx, upCtx.Account)
+func (c *createCmd) Run(ctx context.Context, printer upterm.ObjectPrinter, p pterm.TextPrinter, ac *accounts.Client, oc *organizations.Client, tc *tokens.Client, upCtx *upbound.Context) error {
+	t, err := c.create(ctx, ac, oc, tc, upCtx)
 	if err != nil {
 		return err
 	}
-	if a.Account.Type != accounts.AccountOrganization {
-		return errors.New(errUserAccount)
+	p.Printfln("%s/%s/%s created", upCtx.Account, c.RobotName, c.TokenName)
+
+	if c.Output == "" || c.Output == "-" {
+		pterm.Warning.Println("Store this token securely. It cannot be retrieved again.")
+		return printer.Print(*t, createdFieldNames, extractCreatedFields)
 	}
-	rs, err := oc.ListRobots(ctx, a.Organization.ID)
+
+	f, err := os.OpenFile(filepath.Clean(c.Output), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
 	if err != nil {
 		return err
 	}
-	if len(rs) == 0 {
-		return errors.Errorf(errFindRobotFmt, c.RobotName, upCtx.Account)
+	defer f.Close() //nolint:errcheck,gosec
+	return json.NewEncoder(f).Encode(&upbound.TokenFile{
+		AccessID: t.AccessID,
+		Token:    t.Token,
+	})
+}
+
+// create creates the token for the robot and returns it.
+func (c *createCmd) create(ctx context.Context, ac *accounts.Client, oc *organizations.Client, tc *tokens.Client, upCtx *upbound.Context) (*createdToken, error) {
+	a, err := ac.Get(ctx, upCtx.Account)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot get account %s", upCtx.Account)
+	}
+	if a.Account.Type != accounts.AccountOrganization {
+		return nil, errors.New(errUserAccount)
+	}
+	rs, err := oc.ListRobots(ctx, a.Organization.ID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot list robots in %s", upCtx.Account)
 	}
 	// TODO(hasheddan): because this API does not guarantee name uniqueness, we
 	// must guarantee that exactly one robot exists in the specified account
@@ -66,53 +126,54 @@ func (c *createCmd) Run(ctx context.Context, p pterm.TextPrinter, ac *accounts.C
 	for _, r := range rs {
 		if r.Name == c.RobotName {
 			if found {
-				return errors.Errorf(errMultipleRobotFmt, c.RobotName, upCtx.Account)
+				return nil, errors.Errorf(errMultipleRobotFmt, c.RobotName, upCtx.Account)
 			}
 			id = r.ID
 			found = true
 		}
 	}
 	if !found {
-		return errors.Errorf(errFindRobotFmt, c.RobotName, upCtx.Account)
+		return nil, errors.Errorf(errFindRobotFmt+"; run 'up robot list' to see the robots", c.RobotName, upCtx.Account)
 	}
-	res, err := tc.Create(ctx, &tokens.TokenCreateParameters{
-		Attributes: tokens.TokenAttributes{
-			Name: c.TokenName,
-		},
-		Relationships: tokens.TokenRelationships{
-			Owner: tokens.TokenOwner{
-				Data: tokens.TokenOwnerData{
-					Type: tokens.TokenOwnerRobot,
-					ID:   id.String(),
+
+	attrs := tokenCreateAttributes{Name: c.TokenName}
+	if c.ExpiresIn > 0 {
+		exp := time.Now().Add(c.ExpiresIn).UTC().Truncate(time.Second)
+		attrs.ExpiresAt = &exp
+	}
+	req, err := tc.Client.NewRequest(ctx, http.MethodPost, tokensPath, "", &tokenCreateRequest{
+		Data: tokenCreateData{
+			Type:       "tokens",
+			Attributes: attrs,
+			Relationships: tokens.TokenRelationships{
+				Owner: tokens.TokenOwner{
+					Data: tokens.TokenOwnerData{
+						Type: tokens.TokenOwnerRobot,
+						ID:   id.String(),
+					},
 				},
 			},
 		},
 	})
 	if err != nil {
-		return err
+		return nil, err
 	}
-	p.Printfln("%s/%s/%s created", upCtx.Account, c.RobotName, c.TokenName)
-	if c.Output == "" {
-		p.Printfln("Refusing to emit sensitive output. Please specify output location.")
-		return nil
+	res := &tokens.TokenResponse{}
+	if err := tc.Client.Do(req, res); err != nil {
+		return nil, errors.Wrapf(err, "cannot create token %s for robot %s in %s", c.TokenName, c.RobotName, upCtx.Account)
 	}
+	return &createdToken{
+		AccessID:  res.ID.String(),
+		Token:     fmt.Sprint(res.DataSet.Meta["jwt"]),
+		ExpiresAt: attrs.ExpiresAt,
+	}, nil
+}
 
-	access := res.ID.String()
-	token := fmt.Sprint(res.DataSet.Meta["jwt"])
-	if c.Output == "-" {
-		pterm.Println()
-		p.Printfln(pterm.LightMagenta("Access ID: ") + access)
-		p.Printfln(pterm.LightMagenta("Token: ") + token)
-		return nil
+func extractCreatedFields(obj any) []string {
+	t := obj.(createdToken)
+	exp := "never"
+	if t.ExpiresAt != nil {
+		exp = t.ExpiresAt.Format(time.RFC3339)
 	}
-
-	f, err := os.OpenFile(filepath.Clean(c.Output), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
-	if err != nil {
-		return err
-	}
-	defer f.Close() //nolint:errcheck,gosec
-	return json.NewEncoder(f).Encode(&upbound.TokenFile{
-		AccessID: access,
-		Token:    token,
-	})
+	return []string{t.AccessID, t.Token, exp}
 }
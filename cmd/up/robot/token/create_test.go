@@ -0,0 +1,192 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package token
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/google/uuid"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/upbound/up-sdk-go"
+	"github.com/upbound/up-sdk-go/fake"
+	"github.com/upbound/up-sdk-go/service/accounts"
+	"github.com/upbound/up-sdk-go/service/common"
+	"github.com/upbound/up-sdk-go/service/organizations"
+	"github.com/upbound/up-sdk-go/service/tokens"
+
+	"github.com/upbound/up/internal/upbound"
+)
+
+// mockAPI returns an SDK config whose client serves an account of the
+// supplied type with the supplied robots, and records the bodies of token
+// creations.
+func mockAPI(typ accounts.Type, rs []organizations.Robot, createErr error, creates *[]any) *up.Config {
+	return &up.Config{Client: &fake.MockClient{
+		MockNewRequest: func(ctx context.Context, method, prefix, urlPath string, body interface{}) (*http.Request, error) {
+			req, err := http.NewRequestWithContext(ctx, method, "https://api.upbound.io/"+path.Join(prefix, urlPath), nil)
+			if method == http.MethodPost {
+				*creates = append(*creates, body)
+			}
+			return req, err
+		},
+		MockDo: func(req *http.Request, obj interface{}) error {
+			switch {
+			case req.Method == http.MethodGet && req.URL.Path == "/v1/accounts/cool-org":
+				return respond(obj, accounts.AccountResponse{
+					Account:      accounts.Account{Type: typ},
+					Organization: &organizations.Organization{ID: 1},
+				})
+			case req.Method == http.MethodGet && req.URL.Path == "/v1/organizations/1/robots":
+				return respond(obj, rs)
+			case req.Method == http.MethodPost && req.URL.Path == "/v1/tokens":
+				if createErr != nil {
+					return createErr
+				}
+				return respond(obj, tokens.TokenResponse{DataSet: common.DataSet{
+					ID:   uuid.MustParse("0c1d6e8a-2b8e-4f1e-a7a0-1d9f3c4a5b6c"),
+					Meta: common.Meta{"jwt": "secret"},
+				}})
+			}
+			return errors.Errorf("unexpected request %s %s", req.Method, req.URL.Path)
+		},
+	}}
+}
+
+// respond decodes the supplied response into obj, like the SDK client does.
+func respond(obj any, res any) error {
+	b, err := json.Marshal(res)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, obj)
+}
+
+func TestCreate(t *testing.T) {
+	errBoom := errors.New("boom")
+	id := uuid.MustParse("5a6f0a0e-8b3c-4c8e-9a43-4c8d29d7f1a2")
+	robot := organizations.Robot{ID: id, Name: "cool-robot"}
+	expiresAt := time.Now().Add(time.Hour).UTC()
+
+	request := func(exp *time.Time) *tokenCreateRequest {
+		return &tokenCreateRequest{Data: tokenCreateData{
+			Type:       "tokens",
+			Attributes: tokenCreateAttributes{Name: "cool-token", ExpiresAt: exp},
+			Relationships: tokens.TokenRelationships{Owner: tokens.TokenOwner{Data: tokens.TokenOwnerData{
+				Type: tokens.TokenOwnerRobot,
+				ID:   id.String(),
+			}}},
+		}}
+	}
+
+	type args struct {
+		accountType accounts.Type
+		robots      []organizations.Robot
+		expiresIn   time.Duration
+		createErr   error
+	}
+	type want struct {
+		token   *createdToken
+		creates []any
+		err     error
+	}
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"Created": {
+			reason: "A token that does not expire should be created for the robot.",
+			args: args{
+				accountType: accounts.AccountOrganization,
+				robots:      []organizations.Robot{{Name: "other-robot"}, robot},
+			},
+			want: want{
+				token:   &createdToken{AccessID: "0c1d6e8a-2b8e-4f1e-a7a0-1d9f3c4a5b6c", Token: "secret"},
+				creates: []any{request(nil)},
+			},
+		},
+		"Expiring": {
+			reason: "A token should expire after the supplied duration.",
+			args: args{
+				accountType: accounts.AccountOrganization,
+				robots:      []organizations.Robot{robot},
+				expiresIn:   time.Hour,
+			},
+			want: want{
+				token:   &createdToken{AccessID: "0c1d6e8a-2b8e-4f1e-a7a0-1d9f3c4a5b6c", Token: "secret", ExpiresAt: &expiresAt},
+				creates: []any{request(&expiresAt)},
+			},
+		},
+		"UserAccount": {
+			reason: "Robots are not supported for user accounts.",
+			args: args{
+				accountType: accounts.AccountUser,
+			},
+			want: want{
+				err: errors.New(errUserAccount),
+			},
+		},
+		"RobotNotFound": {
+			reason: "No token should be created for a robot that does not exist.",
+			args: args{
+				accountType: accounts.AccountOrganization,
+				robots:      []organizations.Robot{{Name: "other-robot"}},
+			},
+			want: want{
+				err: errors.Errorf(errFindRobotFmt+"; run 'up robot list' to see the robots", "cool-robot", "cool-org"),
+			},
+		},
+		"CreateFailed": {
+			reason: "Errors creating the token should be returned.",
+			args: args{
+				accountType: accounts.AccountOrganization,
+				robots:      []organizations.Robot{robot},
+				createErr:   errBoom,
+			},
+			want: want{
+				creates: []any{request(nil)},
+				err:     errors.Wrapf(errBoom, "cannot create token %s for robot %s in %s", "cool-token", "cool-robot", "cool-org"),
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			var creates []any
+			cfg := mockAPI(tc.args.accountType, tc.args.robots, tc.args.createErr, &creates)
+			c := &createCmd{RobotName: "cool-robot", TokenName: "cool-token", ExpiresIn: tc.args.expiresIn}
+
+			got, err := c.create(context.Background(), accounts.NewClient(cfg), organizations.NewClient(cfg), tokens.NewClient(cfg), &upbound.Context{Account: "cool-org"})
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\ncreate(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.token, got, cmpopts.EquateApproxTime(time.Minute)); diff != "" {
+				t.Errorf("\n%s\ncreate(...): -want, +got:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.creates, creates, cmpopts.EquateApproxTime(time.Minute)); diff != "" {
+				t.Errorf("\n%s\ncreate(...): -want creates, +got creates:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -60,7 +60,7 @@ func (c *deleteCmd) AfterApply(p pterm.TextPrinter, upCtx *upbound.Context) erro
 type deleteCmd struct {
 	prompter input.Prompter
 
-	RobotName string `arg:"" required:"" help:"Name of robot."`
+	RobotName string `arg:"" required:"" help:"Name of robot." predictor:"robots"`
 	TokenName string `arg:"" required:"" help:"Name of token."`
 
 	Force bool `help:"Force delete token even if conflicts exist." default:"false"`
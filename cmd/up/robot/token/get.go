@@ -42,7 +42,7 @@ func (c *getCmd) AfterApply(kongCtx *kong.Context, upCtx *upbound.Context) error
 
 // getCmd deletes a robot token on Upbound.
 type getCmd struct {
-	RobotName string `arg:"" required:"" help:"Name of robot."`
+	RobotName string `arg:"" required:"" help:"Name of robot." predictor:"robots"`
 	TokenName string `arg:"" required:"" help:"Name of token."`
 }
 
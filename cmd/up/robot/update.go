@@ -0,0 +1,134 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package robot
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/alecthomas/kong"
+	"github.com/google/uuid"
+	"github.com/pterm/pterm"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+
+	"github.com/upbound/up-sdk-go/service/accounts"
+	"github.com/upbound/up-sdk-go/service/organizations"
+	"github.com/upbound/up-sdk-go/service/robots"
+
+	"github.com/upbound/up/internal/upbound"
+	"github.com/upbound/up/internal/upterm"
+)
+
+const robotsPath = "v2/robots"
+
+// robotUpdateRequest is the JSON:API request updating the attributes of a
+// robot.
+type robotUpdateRequest struct {
+	Data robotUpdateData `json:"data"`
+}
+
+type robotUpdateData struct {
+	Type       string                `json:"type"`
+	ID         uuid.UUID             `json:"id"`
+	Attributes robotUpdateAttributes `json:"attributes"`
+}
+
+type robotUpdateAttributes struct {
+	Description string `json:"description"`
+}
+
+// AfterApply sets default values in command after assignment and validation.
+func (c *updateCmd) AfterApply(kongCtx *kong.Context) error {
+	kongCtx.Bind(pterm.DefaultTable.WithWriter(kongCtx.Stdout).WithSeparator("   "))
+	return nil
+}
+
+// updateCmd updates the description of a robot on Upbound.
+type updateCmd struct {
+	Name        string `required:"" help:"Name of robot." predictor:"robots"`
+	Description string `required:"" help:"New description of robot."`
+}
+
+// Run executes the update command.
+func (c *updateCmd) Run(ctx context.Context, printer upterm.ObjectPrinter, ac *accounts.Client, oc *organizations.Client, rc *robots.Client, upCtx *upbound.Context) error {
+	r, err := c.update(ctx, ac, oc, rc, upCtx)
+	if err != nil {
+		return err
+	}
+	return printer.Print(*r, fieldNames, extractFields)
+}
+
+// update updates the description of the robot and returns the updated robot.
+func (c *updateCmd) update(ctx context.Context, ac *accounts.Client, oc *organizations.Client, rc *robots.Client, upCtx *upbound.Context) (*organizations.Robot, error) {
+	a, err := ac.Get(ctx, upCtx.Account)
+	if err != nil {
+		return nil, err
+	}
+	if a.Account.Type != accounts.AccountOrganization {
+		return nil, errors.New(errUserAccount)
+	}
+
+	rs, err := oc.ListRobots(ctx, a.Organization.ID)
+	if err != nil {
+		return nil, err
+	}
+	robot, err := findRobot(rs, c.Name, upCtx.Account)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := rc.Client.NewRequest(ctx, http.MethodPatch, robotsPath, robot.ID.String(), &robotUpdateRequest{
+		Data: robotUpdateData{
+			Type:       "robots",
+			ID:         robot.ID,
+			Attributes: robotUpdateAttributes{Description: c.Description},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	res := &robots.RobotResponse{}
+	if err := rc.Client.Do(req, res); err != nil {
+		return nil, errors.Wrapf(err, "cannot update robot %s in %s", c.Name, upCtx.Account)
+	}
+
+	updated := *robot
+	updated.Description = c.Description
+	if d, ok := res.AttributeSet["description"].(string); ok {
+		updated.Description = d
+	}
+	return &updated, nil
+}
+
+// findRobot returns the robot with the supplied name. The API does not
+// guarantee name uniqueness, so we must make sure that exactly one robot with
+// the name exists before modifying it.
+func findRobot(rs []organizations.Robot, name, account string) (*organizations.Robot, error) {
+	var robot *organizations.Robot
+	for i := range rs {
+		if rs[i].Name != name {
+			continue
+		}
+		if robot != nil {
+			return nil, errors.Errorf(errMultipleRobotFmt, name, account)
+		}
+		robot = &rs[i]
+	}
+	if robot == nil {
+		return nil, errors.Errorf(errFindRobotFmt, name, account)
+	}
+	return robot, nil
+}
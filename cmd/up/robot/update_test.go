@@ -0,0 +1,179 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package robot
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"path"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/upbound/up-sdk-go"
+	"github.com/upbound/up-sdk-go/fake"
+	"github.com/upbound/up-sdk-go/service/accounts"
+	"github.com/upbound/up-sdk-go/service/common"
+	"github.com/upbound/up-sdk-go/service/organizations"
+	"github.com/upbound/up-sdk-go/service/robots"
+
+	"github.com/upbound/up/internal/upbound"
+)
+
+// mockTeams are the teams of the organization served by mockAPI.
+var mockTeams = []team{
+	{ID: uuid.MustParse("7d3c1b2a-4e5f-4a6b-8c7d-9e0f1a2b3c4d"), Name: "cool-team"},
+	{ID: uuid.MustParse("8e4d2c3b-5f6a-4b7c-9d8e-0f1a2b3c4d5e"), Name: "other-team"},
+}
+
+// mockAPI returns an SDK config whose client serves an organization with the
+// supplied robots and mockTeams, and records the bodies of robot updates.
+func mockAPI(rs []organizations.Robot, updateErr error, updates map[string]any) *up.Config {
+	return &up.Config{Client: &fake.MockClient{
+		MockNewRequest: func(ctx context.Context, method, prefix, urlPath string, body interface{}) (*http.Request, error) {
+			req, err := http.NewRequestWithContext(ctx, method, "https://api.upbound.io/"+path.Join(prefix, urlPath), nil)
+			if method == http.MethodPatch {
+				updates[urlPath] = body
+			}
+			return req, err
+		},
+		MockDo: func(req *http.Request, obj interface{}) error {
+			switch {
+			case req.Method == http.MethodGet && req.URL.Path == "/v1/accounts/cool-org":
+				return respond(obj, accounts.AccountResponse{
+					Account:      accounts.Account{Type: accounts.AccountOrganization},
+					Organization: &organizations.Organization{ID: 1},
+				})
+			case req.Method == http.MethodGet && req.URL.Path == "/v1/organizations/1/robots":
+				return respond(obj, rs)
+			case req.Method == http.MethodGet && req.URL.Path == "/v1/organizations/1/teams":
+				return respond(obj, mockTeams)
+			case req.Method == http.MethodPatch:
+				if updateErr != nil {
+					return updateErr
+				}
+				u := updates[path.Base(req.URL.Path)].(*robotUpdateRequest)
+				return respond(obj, robots.RobotResponse{DataSet: common.DataSet{
+					ID:           u.Data.ID,
+					AttributeSet: common.AttributeSet{"description": u.Data.Attributes.Description},
+				}})
+			}
+			return errors.Errorf("unexpected request %s %s", req.Method, req.URL.Path)
+		},
+	}}
+}
+
+// respond decodes the supplied response into obj, like the SDK client does.
+func respond(obj any, res any) error {
+	b, err := json.Marshal(res)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, obj)
+}
+
+func TestUpdate(t *testing.T) {
+	errBoom := errors.New("boom")
+	id := uuid.MustParse("5a6f0a0e-8b3c-4c8e-9a43-4c8d29d7f1a2")
+	robot := organizations.Robot{ID: id, Name: "cool-robot", Description: "old"}
+
+	type args struct {
+		robots    []organizations.Robot
+		updateErr error
+	}
+	type want struct {
+		robot   *organizations.Robot
+		updates map[string]any
+		err     error
+	}
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"Updated": {
+			reason: "The description of the robot should be updated and the updated robot returned.",
+			args: args{
+				robots: []organizations.Robot{{Name: "other-robot"}, robot},
+			},
+			want: want{
+				robot: &organizations.Robot{ID: id, Name: "cool-robot", Description: "new"},
+				updates: map[string]any{id.String(): &robotUpdateRequest{Data: robotUpdateData{
+					Type:       "robots",
+					ID:         id,
+					Attributes: robotUpdateAttributes{Description: "new"},
+				}}},
+			},
+		},
+		"NotFound": {
+			reason: "A robot that does not exist should not be updated.",
+			args: args{
+				robots: []organizations.Robot{{Name: "other-robot"}},
+			},
+			want: want{
+				updates: map[string]any{},
+				err:     errors.Errorf(errFindRobotFmt, "cool-robot", "cool-org"),
+			},
+		},
+		"Ambiguous": {
+			reason: "A robot should not be updated if its name is ambiguous.",
+			args: args{
+				robots: []organizations.Robot{robot, robot},
+			},
+			want: want{
+				updates: map[string]any{},
+				err:     errors.Errorf(errMultipleRobotFmt, "cool-robot", "cool-org"),
+			},
+		},
+		"UpdateFailed": {
+			reason: "Errors updating the robot should be returned.",
+			args: args{
+				robots:    []organizations.Robot{robot},
+				updateErr: errBoom,
+			},
+			want: want{
+				updates: map[string]any{id.String(): &robotUpdateRequest{Data: robotUpdateData{
+					Type:       "robots",
+					ID:         id,
+					Attributes: robotUpdateAttributes{Description: "new"},
+				}}},
+				err: errors.Wrapf(errBoom, "cannot update robot %s in %s", "cool-robot", "cool-org"),
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			updates := map[string]any{}
+			cfg := mockAPI(tc.args.robots, tc.args.updateErr, updates)
+			c := &updateCmd{Name: "cool-robot", Description: "new"}
+
+			got, err := c.update(context.Background(), accounts.NewClient(cfg), organizations.NewClient(cfg), robots.NewClient(cfg), &upbound.Context{Account: "cool-org"})
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nupdate(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.robot, got); diff != "" {
+				t.Errorf("\n%s\nupdate(...): -want, +got:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.updates, updates); diff != "" {
+				t.Errorf("\n%s\nupdate(...): -want updates, +got updates:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -16,11 +16,13 @@ package space
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 
 	corev1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
@@ -112,6 +114,36 @@ func (c *Client) Delete(ctx context.Context, ctp types.NamespacedName) error {
 	return err
 }
 
+// Pause the Crossplane instance of the ControlPlane corresponding to the given
+// ControlPlane name.
+func (c *Client) Pause(ctx context.Context, ctp types.NamespacedName) (*controlplane.Response, error) {
+	return c.setState(ctx, ctp, resources.ControlPlaneStatePaused)
+}
+
+// Resume the Crossplane instance of the ControlPlane corresponding to the
+// given ControlPlane name.
+func (c *Client) Resume(ctx context.Context, ctp types.NamespacedName) (*controlplane.Response, error) {
+	return c.setState(ctx, ctp, resources.ControlPlaneStateRunning)
+}
+
+func (c *Client) setState(ctx context.Context, ctp types.NamespacedName, state string) (*controlplane.Response, error) {
+	patch := &resources.ControlPlane{Unstructured: unstructured.Unstructured{Object: map[string]any{}}}
+	patch.SetCrossplaneState(state)
+	b, err := json.Marshal(patch.Object)
+	if err != nil {
+		return nil, err
+	}
+	u, err := c.c.Resource(resource).Namespace(ctp.Namespace).Patch(ctx, ctp.Name, types.MergePatchType, b, metav1.PatchOptions{})
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			return nil, controlplane.NewNotFound(err)
+		}
+		return nil, err
+	}
+
+	return convert(&resources.ControlPlane{Unstructured: *u}), nil
+}
+
 // GetKubeConfig for the given Control Plane.
 func (c *Client) GetKubeConfig(ctx context.Context, ctp types.NamespacedName) (*api.Config, error) {
 	// get the control plane
@@ -21,6 +21,7 @@ import (
 
 	"github.com/google/go-cmp/cmp"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -512,3 +513,82 @@ func TestConvert(t *testing.T) {
 		})
 	}
 }
+
+func TestPauseResume(t *testing.T) {
+	ctp1 := &resources.ControlPlane{}
+	ctp1.SetName("ctp1")
+	ctp1.SetNamespace("default")
+
+	type args struct {
+		client dynamic.Interface
+		name   string
+		pause  bool
+	}
+	type want struct {
+		state string
+		err   error
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"ErrorControlPlaneNotFound": {
+			reason: "If the control plane does not exist, a not found error is returned.",
+			args: args{
+				client: fake.NewSimpleDynamicClient(scheme),
+				name:   "ctp-dne",
+				pause:  true,
+			},
+			want: want{
+				err: controlplane.NewNotFound(errors.New(`controlplanes.spaces.upbound.io "ctp-dne" not found`)),
+			},
+		},
+		"Pause": {
+			reason: "Pausing a control plane should set its Crossplane state to Paused.",
+			args: args{
+				client: fake.NewSimpleDynamicClient(scheme, ctp1.GetUnstructured()),
+				name:   "ctp1",
+				pause:  true,
+			},
+			want: want{
+				state: resources.ControlPlaneStatePaused,
+			},
+		},
+		"Resume": {
+			reason: "Resuming a control plane should set its Crossplane state to Running.",
+			args: args{
+				client: fake.NewSimpleDynamicClient(scheme, ctp1.GetUnstructured()),
+				name:   "ctp1",
+			},
+			want: want{
+				state: resources.ControlPlaneStateRunning,
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			c := New(tc.args.client)
+			set := c.Resume
+			if tc.args.pause {
+				set = c.Pause
+			}
+			_, err := set(context.Background(), types.NamespacedName{Name: tc.args.name, Namespace: "default"})
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nPause/Resume(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if err != nil {
+				return
+			}
+			u, err := tc.args.client.Resource(resource).Namespace("default").Get(context.Background(), tc.args.name, metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("cannot get control plane: %v", err)
+			}
+			got := (&resources.ControlPlane{Unstructured: *u}).GetCrossplaneState()
+			if diff := cmp.Diff(tc.want.state, got); diff != "" {
+				t.Errorf("\n%s\nPause/Resume(...): -want state, +got state:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -34,6 +34,15 @@ var (
 	}
 )
 
+const (
+	// ControlPlaneStateRunning is the Crossplane state of a running
+	// ControlPlane.
+	ControlPlaneStateRunning = "Running"
+	// ControlPlaneStatePaused is the Crossplane state of a paused
+	// ControlPlane.
+	ControlPlaneStatePaused = "Paused"
+)
+
 // ControlPlane represents the ControlPlane CustomResource and extends an
 // unstructured.Unstructured.
 type ControlPlane struct {
@@ -97,6 +106,18 @@ func (c *ControlPlane) GetCrossplaneVersion() string {
 	return out
 }
 
+// GetCrossplaneState returns the desired state of Crossplane, i.e. Running or
+// Paused. It is empty if the state was never set, which means Running.
+func (c *ControlPlane) GetCrossplaneState() string {
+	out, _ := fieldpath.Pave(c.Object).GetString("spec.crossplane.state")
+	return out
+}
+
+// SetCrossplaneState sets the desired state of Crossplane.
+func (c *ControlPlane) SetCrossplaneState(state string) {
+	_ = fieldpath.Pave(c.Object).SetValue("spec.crossplane.state", state)
+}
+
 func (c *ControlPlane) GetMessage() string {
 	var ann map[string]string
 	if err := fieldpath.Pave(c.Object).GetValueInto("metadata.annotations", &ann); err != nil {
@@ -17,6 +17,8 @@ package upterm
 import (
 	"encoding/json"
 	"fmt"
+	"io"
+	"os"
 	"reflect"
 
 	"github.com/pterm/pterm"
@@ -72,29 +74,39 @@ func (p *ObjectPrinter) Print(obj any, fieldNames []string, extractFields func(a
 	// Step 3: Print the object with the appropriate formatting.
 	switch p.Format { //nolint:exhaustive
 	case config.JSON:
-		return printJSON(obj)
+		return p.PrintJSON(obj, os.Stdout)
 	case config.YAML:
-		return printYAML(obj)
+		return p.PrintYAML(obj, os.Stdout)
 	default:
 		return p.printDefault(obj, fieldNames, extractFields)
 	}
 }
 
-func printJSON(obj any) error {
+// PrintJSON writes all fields of a single object or an array/slice of objects
+// as indented JSON to w, unless the printer is quiet.
+func (p *ObjectPrinter) PrintJSON(obj any, w io.Writer) error {
+	if p.Quiet {
+		return nil
+	}
 	js, err := json.MarshalIndent(obj, "", "    ")
 	if err != nil {
 		return err
 	}
-	_, err = fmt.Println(string(js))
+	_, err = fmt.Fprintln(w, string(js))
 	return err
 }
 
-func printYAML(obj any) error {
+// PrintYAML writes all fields of a single object or an array/slice of objects
+// as YAML to w, unless the printer is quiet.
+func (p *ObjectPrinter) PrintYAML(obj any, w io.Writer) error {
+	if p.Quiet {
+		return nil
+	}
 	ys, err := yaml.Marshal(obj)
 	if err != nil {
 		return err
 	}
-	_, err = fmt.Println(string(ys))
+	_, err = fmt.Fprintln(w, string(ys))
 	return err
 }
 
@@ -0,0 +1,95 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package upterm
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/upbound/up/internal/config"
+)
+
+type printed struct {
+	Name  string
+	Ready string
+}
+
+func TestObjectPrinterPrintJSON(t *testing.T) {
+	cases := map[string]struct {
+		quiet bool
+		obj   any
+		want  string
+	}{
+		"Object": {
+			obj:  printed{Name: "ctp", Ready: "True"},
+			want: "{\n    \"Name\": \"ctp\",\n    \"Ready\": \"True\"\n}\n",
+		},
+		"List": {
+			obj:  []printed{{Name: "ctp"}},
+			want: "[\n    {\n        \"Name\": \"ctp\",\n        \"Ready\": \"\"\n    }\n]\n",
+		},
+		"Quiet": {
+			quiet: true,
+			obj:   printed{Name: "ctp"},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			p := &ObjectPrinter{Quiet: config.QuietFlag(tc.quiet)}
+			w := &bytes.Buffer{}
+			if err := p.PrintJSON(tc.obj, w); err != nil {
+				t.Fatalf("PrintJSON(...): unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.want, w.String()); diff != "" {
+				t.Errorf("\n%s\nPrintJSON(...): -want, +got:\n%s", name, diff)
+			}
+		})
+	}
+}
+
+func TestObjectPrinterPrintYAML(t *testing.T) {
+	cases := map[string]struct {
+		quiet bool
+		obj   any
+		want  string
+	}{
+		"Object": {
+			obj:  printed{Name: "ctp", Ready: "True"},
+			want: "name: ctp\nready: \"True\"\n\n",
+		},
+		"List": {
+			obj:  []printed{{Name: "ctp"}},
+			want: "- name: ctp\n  ready: \"\"\n\n",
+		},
+		"Quiet": {
+			quiet: true,
+			obj:   printed{Name: "ctp"},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			p := &ObjectPrinter{Quiet: config.QuietFlag(tc.quiet)}
+			w := &bytes.Buffer{}
+			if err := p.PrintYAML(tc.obj, w); err != nil {
+				t.Fatalf("PrintYAML(...): unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.want, w.String()); diff != "" {
+				t.Errorf("\n%s\nPrintYAML(...): -want, +got:\n%s", name, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,332 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package archiver reads and writes control plane state export archives.
+package archiver
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/spf13/afero"
+
+	"github.com/upbound/up/pkg/migration"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+)
+
+// Compression is the compression algorithm of an archive.
+type Compression string
+
+const (
+	// CompressionGzip compresses archives with gzip.
+	CompressionGzip Compression = "gzip"
+	// CompressionZstd compresses archives with Zstandard.
+	CompressionZstd Compression = "zstd"
+)
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// Extension returns the file extension of tar archives compressed with c.
+func Extension(c Compression) string {
+	if c == CompressionZstd {
+		return ".tar.zst"
+	}
+	return ".tar.gz"
+}
+
+type archiveOptions struct {
+	compression Compression
+	level       int
+	key         []byte
+	// rand is where the nonce of encrypted archives is read from.
+	rand io.Reader
+}
+
+// An ArchiveOption configures how an archive is written.
+type ArchiveOption func(*archiveOptions)
+
+// WithCompression compresses the archive with the supplied algorithm at the
+// supplied level. A level of zero selects the default level of the
+// algorithm. Archives are compressed with gzip by default.
+func WithCompression(c Compression, level int) ArchiveOption {
+	return func(o *archiveOptions) {
+		o.compression = c
+		o.level = level
+	}
+}
+
+// WithEncryption encrypts the compressed archive with AES-256-GCM using the
+// supplied 32 byte key. Encrypted archives are recognized by Unarchive and
+// decrypted with the key supplied through WithDecryptionKey.
+func WithEncryption(key []byte) ArchiveOption {
+	return func(o *archiveOptions) {
+		o.key = key
+	}
+}
+
+type unarchiveOptions struct {
+	key []byte
+}
+
+// An UnarchiveOption configures how an archive is read.
+type UnarchiveOption func(*unarchiveOptions)
+
+// WithDecryptionKey decrypts encrypted archives with the supplied 32 byte
+// key. Archives that are not encrypted are read as is.
+func WithDecryptionKey(key []byte) UnarchiveOption {
+	return func(o *unarchiveOptions) {
+		o.key = key
+	}
+}
+
+// compressor returns a writer compressing to w according to o.
+func (o archiveOptions) compressor(w io.Writer) (io.WriteCloser, error) {
+	switch o.compression {
+	case CompressionGzip, "":
+		if o.level == 0 {
+			return gzip.NewWriter(w), nil
+		}
+		gw, err := gzip.NewWriterLevel(w, o.level)
+		return gw, errors.Wrapf(err, "invalid gzip compression level %d", o.level)
+	case CompressionZstd:
+		var opts []zstd.EOption
+		if o.level != 0 {
+			opts = append(opts, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(o.level)))
+		}
+		zw, err := zstd.NewWriter(w, opts...)
+		return zw, errors.Wrap(err, "cannot create zstd writer")
+	default:
+		return nil, errors.Errorf("unknown compression algorithm %q", o.compression)
+	}
+}
+
+// decompressor returns a reader decompressing r, detecting the compression
+// algorithm from the magic bytes at the start of r.
+func decompressor(r io.Reader, o unarchiveOptions) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(len(encryptedMagic))
+	if err != nil && err != io.EOF {
+		return nil, errors.Wrap(err, "cannot read archive header")
+	}
+	if bytes.HasPrefix(magic, encryptedMagic) {
+		if o.key == nil {
+			return nil, errors.New("archive is encrypted, a decryption key is required")
+		}
+		if _, err := br.Discard(len(encryptedMagic)); err != nil {
+			return nil, errors.Wrap(err, "cannot read archive header")
+		}
+		dr, err := newDecryptReader(br, o.key)
+		if err != nil {
+			return nil, err
+		}
+		return decompressor(dr, unarchiveOptions{})
+	}
+	if bytes.HasPrefix(magic, zstdMagic) {
+		zr, err := zstd.NewReader(br)
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot create zstd reader")
+		}
+		return zr.IOReadCloser(), nil
+	}
+	// Default to gzip, which all archives were compressed with before other
+	// algorithms were supported.
+	gr, err := gzip.NewReader(br)
+	return gr, errors.Wrap(err, "cannot create gzip reader")
+}
+
+// Archive writes all files below dir in fs to w as a compressed tar archive.
+// File names in the archive are relative to dir.
+func Archive(ctx context.Context, fs afero.Afero, dir string, w io.Writer, opts ...ArchiveOption) error { //nolint:gocyclo // Mostly error handling.
+	o := archiveOptions{compression: CompressionGzip, rand: rand.Reader}
+	for _, fn := range opts {
+		fn(&o)
+	}
+	ew := io.WriteCloser(nopWriteCloser{w})
+	if o.key != nil {
+		var err error
+		if ew, err = newEncryptWriter(w, o.key, o.rand); err != nil {
+			return err
+		}
+	}
+	cw, err := o.compressor(ew)
+	if err != nil {
+		return err
+	}
+	tw := tar.NewWriter(cw)
+
+	err = fs.Walk(dir, func(file string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+
+		// Skip if it is a directory
+		if fi.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, file)
+		if err != nil {
+			return err
+		}
+
+		header, err := tar.FileInfoHeader(fi, fi.Name())
+		if err != nil {
+			return err
+		}
+		// Keep the path relative to the root of the export, so that the
+		// directory structure is preserved.
+		header.Name = filepath.ToSlash(rel)
+
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+
+		f, err := fs.Open(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close() //nolint:errcheck // Read only.
+
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return errors.Wrap(err, "cannot add files to archive")
+	}
+
+	if err := tw.Close(); err != nil {
+		return errors.Wrap(err, "cannot close tar writer")
+	}
+	if err := cw.Close(); err != nil {
+		return errors.Wrapf(err, "cannot close %s writer", o.compression)
+	}
+	return errors.Wrap(ew.Close(), "cannot close encryption writer")
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+// Unarchive extracts the compressed tar archive read from r into the root of
+// fs. Both gzip and zstd compressed archives are supported, as well as
+// encrypted ones if a decryption key is supplied.
+func Unarchive(ctx context.Context, r io.Reader, fs afero.Afero, opts ...UnarchiveOption) error {
+	o := unarchiveOptions{}
+	for _, fn := range opts {
+		fn(&o)
+	}
+	dr, err := decompressor(r, o)
+	if err != nil {
+		return migration.WithCode(err, migration.ErrCodeArchiveCorrupt)
+	}
+	defer dr.Close() //nolint:errcheck // Read only.
+
+	tr := tar.NewReader(dr)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break // End of archive
+		}
+		if err != nil {
+			return migration.WithCode(errors.Wrap(err, "cannot read archive"), migration.ErrCodeArchiveCorrupt)
+		}
+
+		name := filepath.Clean(filepath.FromSlash(hdr.Name))
+		if !filepath.IsLocal(name) {
+			return migration.WithCode(errors.Errorf("invalid file name %q in archive", hdr.Name), migration.ErrCodeArchiveCorrupt)
+		}
+
+		if hdr.FileInfo().IsDir() {
+			if err = fs.MkdirAll(name, 0700); err != nil {
+				return errors.Wrapf(err, "cannot create directory %q", name)
+			}
+			continue
+		}
+
+		if err = fs.MkdirAll(filepath.Dir(name), 0700); err != nil {
+			return errors.Wrapf(err, "cannot create directory for file %q", name)
+		}
+		if err = writeFile(fs, name, tr); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ArchiveFile writes all files below dir in fs to a new archive at path.
+func ArchiveFile(ctx context.Context, fs afero.Afero, dir string, path string, opts ...ArchiveOption) error {
+	out, err := fs.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return errors.Wrapf(err, "cannot create archive %q", path)
+	}
+	defer out.Close() //nolint:errcheck // Closed explicitly below.
+
+	// Apply the appropriate permissions in case the file already existed.
+	if err = fs.Chmod(path, 0600); err != nil {
+		return errors.Wrapf(err, "cannot set permissions of archive %q", path)
+	}
+
+	if err = Archive(ctx, fs, dir, out, opts...); err != nil {
+		return err
+	}
+	return errors.Wrapf(out.Close(), "cannot close archive %q", path)
+}
+
+// UnarchiveFile extracts the archive at path on the local file system into fs.
+// Split archives are extracted from all their parts if path is their first
+// part, e.g. "xp-state.tar.gz.001", or the path they were split from.
+func UnarchiveFile(ctx context.Context, path string, fs afero.Afero, opts ...UnarchiveOption) error {
+	f, err := openArchive(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close() //nolint:errcheck // Read only.
+
+	return Unarchive(ctx, f, fs, opts...)
+}
+
+func writeFile(fs afero.Afero, name string, r io.Reader) error {
+	nf, err := fs.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return errors.Wrapf(err, "cannot create file %q", name)
+	}
+	defer nf.Close() //nolint:errcheck // Closed explicitly below.
+
+	if _, err := io.Copy(nf, r); err != nil { //nolint:gosec // Archives are produced by the exporter.
+		return errors.Wrapf(err, "cannot write file %q", name)
+	}
+	return errors.Wrapf(nf.Close(), "cannot close file %q", name)
+}
@@ -0,0 +1,125 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package archiver
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/afero"
+)
+
+func TestArchiveRoundTrip(t *testing.T) {
+	type args struct {
+		opts []ArchiveOption
+	}
+	type want struct {
+		magic []byte
+		err   bool
+	}
+
+	cases := map[string]struct {
+		args args
+		want want
+	}{
+		"DefaultGzip": {
+			args: args{},
+			want: want{magic: gzipMagic},
+		},
+		"GzipLevel": {
+			args: args{opts: []ArchiveOption{WithCompression(CompressionGzip, 9)}},
+			want: want{magic: gzipMagic},
+		},
+		"InvalidGzipLevel": {
+			args: args{opts: []ArchiveOption{WithCompression(CompressionGzip, 42)}},
+			want: want{err: true},
+		},
+		"Zstd": {
+			args: args{opts: []ArchiveOption{WithCompression(CompressionZstd, 0)}},
+			want: want{magic: zstdMagic},
+		},
+		"ZstdLevel": {
+			args: args{opts: []ArchiveOption{WithCompression(CompressionZstd, 19)}},
+			want: want{magic: zstdMagic},
+		},
+		"UnknownAlgorithm": {
+			args: args{opts: []ArchiveOption{WithCompression("lz4", 0)}},
+			want: want{err: true},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			files := map[string]string{
+				"export.yaml":                     "version: v1alpha1\n",
+				"namespaces/cluster/default.yaml": "kind: Namespace\n",
+			}
+			src := afero.Afero{Fs: afero.NewMemMapFs()}
+			for f, c := range files {
+				if err := src.WriteFile(f, []byte(c), 0600); err != nil {
+					t.Fatalf("cannot write file %q: %v", f, err)
+				}
+			}
+
+			buf := &bytes.Buffer{}
+			err := Archive(context.Background(), src, "", buf, tc.args.opts...)
+			if tc.want.err {
+				if err == nil {
+					t.Fatal("Archive(...): expected error, got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Archive(...): unexpected error: %v", err)
+			}
+			if !bytes.HasPrefix(buf.Bytes(), tc.want.magic) {
+				t.Errorf("Archive(...): archive starts with %x, want %x", buf.Bytes()[:4], tc.want.magic)
+			}
+
+			dst := afero.Afero{Fs: afero.NewMemMapFs()}
+			if err := Unarchive(context.Background(), buf, dst); err != nil {
+				t.Fatalf("Unarchive(...): unexpected error: %v", err)
+			}
+			for f, c := range files {
+				got, err := dst.ReadFile(f)
+				if err != nil {
+					t.Fatalf("cannot read unarchived file %q: %v", f, err)
+				}
+				if diff := cmp.Diff(c, string(got)); diff != "" {
+					t.Errorf("Unarchive(...): %s: -want, +got:\n%s", f, diff)
+				}
+			}
+		})
+	}
+}
+
+func TestExtension(t *testing.T) {
+	cases := map[string]struct {
+		c    Compression
+		want string
+	}{
+		"Default": {want: ".tar.gz"},
+		"Gzip":    {c: CompressionGzip, want: ".tar.gz"},
+		"Zstd":    {c: CompressionZstd, want: ".tar.zst"},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if got := Extension(tc.c); got != tc.want {
+				t.Errorf("Extension(%q) = %q, want %q", tc.c, got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package archiver
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/afero"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+)
+
+// ChecksumsFile is the name of the manifest with the SHA-256 checksums of all
+// other files of an export, in the format of sha256sum.
+const ChecksumsFile = "checksums.sha256"
+
+// ChecksumMismatch is a file whose checksum does not match the checksum
+// manifest.
+type ChecksumMismatch struct {
+	// Path of the file, relative to the root of the export.
+	Path string
+	// Expected is the checksum in the manifest, empty if the file is not
+	// listed.
+	Expected string
+	// Actual is the checksum of the file, empty if the file is missing.
+	Actual string
+}
+
+// WriteChecksums writes a checksum manifest of all files in dir to dir.
+func WriteChecksums(fs afero.Afero, dir string) error {
+	sums, err := checksums(fs, dir)
+	if err != nil {
+		return err
+	}
+	paths := make([]string, 0, len(sums))
+	for p := range sums {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	b := &bytes.Buffer{}
+	for _, p := range paths {
+		fmt.Fprintf(b, "%s  %s\n", sums[p], p)
+	}
+	return errors.Wrap(fs.WriteFile(filepath.Join(dir, ChecksumsFile), b.Bytes(), 0600), "cannot write checksum manifest")
+}
+
+// VerifyChecksums compares the checksums of all files in dir to the checksum
+// manifest in dir, and returns all files that do not match, are missing or
+// are not listed, sorted by path.
+func VerifyChecksums(fs afero.Afero, dir string) ([]ChecksumMismatch, error) {
+	want, err := readChecksums(fs, filepath.Join(dir, ChecksumsFile))
+	if err != nil {
+		return nil, err
+	}
+	got, err := checksums(fs, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var mismatches []ChecksumMismatch
+	for p, sum := range want {
+		if got[p] != sum {
+			mismatches = append(mismatches, ChecksumMismatch{Path: p, Expected: sum, Actual: got[p]})
+		}
+	}
+	for p, sum := range got {
+		if _, ok := want[p]; !ok {
+			mismatches = append(mismatches, ChecksumMismatch{Path: p, Actual: sum})
+		}
+	}
+	sort.Slice(mismatches, func(i, j int) bool {
+		return mismatches[i].Path < mismatches[j].Path
+	})
+	return mismatches, nil
+}
+
+// checksums returns the hex encoded SHA-256 checksums of all files in dir
+// except the checksum manifest, keyed by their slash separated path relative
+// to dir.
+func checksums(fs afero.Afero, dir string) (map[string]string, error) {
+	sums := map[string]string{}
+	err := fs.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel == ChecksumsFile {
+			return nil
+		}
+		b, err := fs.ReadFile(path)
+		if err != nil {
+			return errors.Wrapf(err, "cannot read %q", rel)
+		}
+		sum := sha256.Sum256(b)
+		sums[rel] = hex.EncodeToString(sum[:])
+		return nil
+	})
+	return sums, errors.Wrap(err, "cannot compute checksums")
+}
+
+// readChecksums reads the checksum manifest at path.
+func readChecksums(fs afero.Afero, path string) (map[string]string, error) {
+	b, err := fs.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot read checksum manifest")
+	}
+	sums := map[string]string{}
+	s := bufio.NewScanner(bytes.NewReader(b))
+	for s.Scan() {
+		if s.Text() == "" {
+			continue
+		}
+		sum, p, ok := strings.Cut(s.Text(), "  ")
+		if !ok {
+			return nil, errors.Errorf("invalid line in checksum manifest: %q", s.Text())
+		}
+		sums[p] = sum
+	}
+	return sums, errors.Wrap(s.Err(), "cannot read checksum manifest")
+}
@@ -0,0 +1,123 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package archiver
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/afero"
+)
+
+func TestVerifyChecksums(t *testing.T) {
+	const (
+		// SHA-256 of "kind: ConfigMap".
+		configMapSum = "033792b59cbccfc2b5b25b845d8d551f8c7dbdae67e823a5d8ca9af515e32d1b"
+		// SHA-256 of "corrupted".
+		corruptedSum = "3dbb3963d11aa418de8b61f846c3dbd5af43b40d252842adb823f90936fe6920"
+		// SHA-256 of "version: v1alpha1".
+		exportMetaSum = "a70cefa7c1d0994eefd697feefea07b2441d21de53704d9cbd81947b70fc09c3"
+	)
+
+	type args struct {
+		// modify changes the exported state after its checksums were
+		// written.
+		modify func(fs afero.Afero) error
+	}
+	type want struct {
+		manifest   string
+		mismatches []ChecksumMismatch
+	}
+
+	cases := map[string]struct {
+		args args
+		want want
+	}{
+		"Unchanged": {
+			args: args{
+				modify: func(_ afero.Afero) error { return nil },
+			},
+			want: want{
+				manifest: configMapSum + "  configmaps/namespaces/default/config.yaml\n" + exportMetaSum + "  export.yaml\n",
+			},
+		},
+		"Corrupted": {
+			args: args{
+				modify: func(fs afero.Afero) error {
+					return fs.WriteFile("state/configmaps/namespaces/default/config.yaml", []byte("corrupted"), 0600)
+				},
+			},
+			want: want{
+				mismatches: []ChecksumMismatch{{
+					Path:     "configmaps/namespaces/default/config.yaml",
+					Expected: configMapSum,
+					Actual:   corruptedSum,
+				}},
+			},
+		},
+		"MissingAndUnlisted": {
+			args: args{
+				modify: func(fs afero.Afero) error {
+					if err := fs.Remove("state/export.yaml"); err != nil {
+						return err
+					}
+					return fs.WriteFile("state/secrets/namespaces/default/secret.yaml", []byte("corrupted"), 0600)
+				},
+			},
+			want: want{
+				mismatches: []ChecksumMismatch{
+					{Path: "export.yaml", Expected: exportMetaSum},
+					{Path: "secrets/namespaces/default/secret.yaml", Actual: corruptedSum},
+				},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			fs := afero.Afero{Fs: afero.NewMemMapFs()}
+			for p, c := range map[string]string{
+				"state/export.yaml": "version: v1alpha1",
+				"state/configmaps/namespaces/default/config.yaml": "kind: ConfigMap",
+			} {
+				if err := fs.WriteFile(p, []byte(c), 0600); err != nil {
+					t.Fatalf("cannot write %q: %v", p, err)
+				}
+			}
+			if err := WriteChecksums(fs, "state"); err != nil {
+				t.Fatalf("WriteChecksums(...): unexpected error: %v", err)
+			}
+			if tc.want.manifest != "" {
+				b, err := fs.ReadFile("state/" + ChecksumsFile)
+				if err != nil {
+					t.Fatalf("cannot read checksum manifest: %v", err)
+				}
+				if diff := cmp.Diff(tc.want.manifest, string(b)); diff != "" {
+					t.Errorf("\n%s\nWriteChecksums(...): -want manifest, +got manifest:\n%s", name, diff)
+				}
+			}
+			if err := tc.args.modify(fs); err != nil {
+				t.Fatalf("cannot modify exported state: %v", err)
+			}
+
+			mismatches, err := VerifyChecksums(fs, "state")
+			if err != nil {
+				t.Fatalf("VerifyChecksums(...): unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.want.mismatches, mismatches); diff != "" {
+				t.Errorf("\n%s\nVerifyChecksums(...): -want, +got:\n%s", name, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,203 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package archiver
+
+import (
+	"context"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/spf13/afero"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/yaml"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+)
+
+// ChangeType is how a resource changed between two exports.
+type ChangeType string
+
+const (
+	// ChangeAdded resources are only part of the newer export.
+	ChangeAdded ChangeType = "added"
+	// ChangeRemoved resources are only part of the older export.
+	ChangeRemoved ChangeType = "removed"
+	// ChangeModified resources are part of both exports with different
+	// content.
+	ChangeModified ChangeType = "modified"
+)
+
+// ResourceChange is a resource that differs between two exports.
+type ResourceChange struct {
+	Type ChangeType
+	// GVR is the type of the resource, with the version it was exported
+	// with.
+	GVR       schema.GroupVersionResource
+	Namespace string
+	Name      string
+	// Diff is the unified diff of the YAML of a modified resource, without
+	// the fields that are ignored when comparing.
+	Diff string
+}
+
+// ignoredFields are the metadata fields ignored when comparing resources,
+// since they change without the resource being changed.
+var ignoredFields = []string{"resourceVersion", "uid", "generation"}
+
+// resourceKey identifies a resource within an export.
+type resourceKey struct {
+	groupResource string
+	namespace     string
+	name          string
+}
+
+// CompareArchives returns the resources that were added, removed, or modified
+// in the export archive at toPath compared to the one at fromPath, ordered by
+// type, namespace and name.
+func CompareArchives(ctx context.Context, fromPath, toPath string) ([]ResourceChange, error) {
+	from, err := unarchivedResources(ctx, fromPath)
+	if err != nil {
+		return nil, err
+	}
+	to, err := unarchivedResources(ctx, toPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []ResourceChange
+	for k, f := range from {
+		t, ok := to[k]
+		switch {
+		case !ok:
+			changes = append(changes, newResourceChange(ChangeRemoved, k, f))
+		case !reflect.DeepEqual(f.Object, t.Object):
+			c := newResourceChange(ChangeModified, k, t)
+			if c.Diff, err = yamlDiff(k, f, t); err != nil {
+				return nil, err
+			}
+			changes = append(changes, c)
+		}
+	}
+	for k, t := range to {
+		if _, ok := from[k]; !ok {
+			changes = append(changes, newResourceChange(ChangeAdded, k, t))
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		a, b := changes[i], changes[j]
+		if a.GVR.GroupResource() != b.GVR.GroupResource() {
+			return a.GVR.GroupResource().String() < b.GVR.GroupResource().String()
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+	return changes, nil
+}
+
+// WriteDiffs writes the diff of every modified resource to
+// <dir>/<group resource>/<namespace or "_cluster">/<name>.diff in fs.
+func WriteDiffs(fs afero.Afero, dir string, changes []ResourceChange) error {
+	for _, c := range changes {
+		if c.Type != ChangeModified {
+			continue
+		}
+		scope := "_cluster"
+		if c.Namespace != "" {
+			scope = c.Namespace
+		}
+		p := filepath.Join(dir, c.GVR.GroupResource().String(), scope, c.Name+".diff")
+		if err := fs.MkdirAll(filepath.Dir(p), 0700); err != nil {
+			return errors.Wrapf(err, "cannot create directory for %q", p)
+		}
+		if err := fs.WriteFile(p, []byte(c.Diff), 0600); err != nil {
+			return errors.Wrapf(err, "cannot write diff %q", p)
+		}
+	}
+	return nil
+}
+
+func newResourceChange(t ChangeType, k resourceKey, u unstructured.Unstructured) ResourceChange {
+	gvr := schema.ParseGroupResource(k.groupResource).WithVersion(u.GroupVersionKind().Version)
+	return ResourceChange{Type: t, GVR: gvr, Namespace: k.namespace, Name: k.name}
+}
+
+// unarchivedResources extracts the export archive at path into memory and
+// returns its resources, without the ignored fields.
+func unarchivedResources(ctx context.Context, p string) (map[resourceKey]unstructured.Unstructured, error) {
+	fs := afero.Afero{Fs: afero.NewMemMapFs()}
+	if err := UnarchiveFile(ctx, p, fs); err != nil {
+		return nil, errors.Wrapf(err, "cannot unarchive %q", p)
+	}
+
+	resources := map[resourceKey]unstructured.Unstructured{}
+	err := fs.Walk(".", func(f string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		// Resources are stored at <group resource>/cluster/..., at
+		// <group resource>/namespaces/..., or at <group resource>/objects/...
+		// in content addressable exports.
+		parts := strings.Split(filepath.ToSlash(f), "/")
+		if fi.IsDir() || len(parts) < 3 || path.Ext(f) != ".yaml" {
+			return nil
+		}
+		if parts[1] != "cluster" && parts[1] != "namespaces" && parts[1] != "objects" {
+			return nil
+		}
+		b, err := fs.ReadFile(f)
+		if err != nil {
+			return errors.Wrapf(err, "cannot read %q from %q", f, p)
+		}
+		u := unstructured.Unstructured{}
+		if err := yaml.Unmarshal(b, &u.Object); err != nil {
+			return errors.Wrapf(err, "cannot unmarshal %q from %q", f, p)
+		}
+		for _, field := range ignoredFields {
+			unstructured.RemoveNestedField(u.Object, "metadata", field)
+		}
+		resources[resourceKey{groupResource: parts[0], namespace: u.GetNamespace(), name: u.GetName()}] = u
+		return nil
+	})
+	return resources, errors.Wrapf(err, "cannot read resources of %q", p)
+}
+
+// yamlDiff returns the unified diff of the YAML of a resource in two exports.
+func yamlDiff(k resourceKey, from, to unstructured.Unstructured) (string, error) {
+	fb, err := yaml.Marshal(from.Object)
+	if err != nil {
+		return "", errors.Wrap(err, "cannot marshal resource to yaml")
+	}
+	tb, err := yaml.Marshal(to.Object)
+	if err != nil {
+		return "", errors.Wrap(err, "cannot marshal resource to yaml")
+	}
+	name := path.Join(k.groupResource, k.namespace, k.name)
+	d, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(fb)),
+		B:        difflib.SplitLines(string(tb)),
+		FromFile: "a/" + name + ".yaml",
+		ToFile:   "b/" + name + ".yaml",
+		Context:  3,
+	})
+	return d, errors.Wrapf(err, "cannot diff %q", name)
+}
@@ -0,0 +1,81 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package archiver
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/spf13/afero"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestCompareArchives(t *testing.T) {
+	dir := t.TempDir()
+	from, to := filepath.Join(dir, "from.tar.gz"), filepath.Join(dir, "to.tar.gz")
+	writeArchive(t, from, map[string]string{
+		"export.yaml": "version: v1alpha1\n",
+		"configmaps/namespaces/default/unchanged.yaml": "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: unchanged\n  namespace: default\n  resourceVersion: \"1\"\n  uid: a\n",
+		"configmaps/namespaces/default/removed.yaml":   "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: removed\n  namespace: default\n",
+		"things.example.org/cluster/a.yaml":            "apiVersion: example.org/v1\nkind: Thing\nmetadata:\n  name: a\n  generation: 1\nspec:\n  size: small\n",
+		"things.example.org/metadata.yaml":             "categories:\n- managed\n",
+	})
+	writeArchive(t, to, map[string]string{
+		"export.yaml": "version: v1alpha1\nformatVersion: v1.2.0\n",
+		"configmaps/namespaces/default/unchanged.yaml": "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: unchanged\n  namespace: default\n  resourceVersion: \"2\"\n  uid: b\n",
+		"configmaps/namespaces/team/added.yaml":        "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: added\n  namespace: team\n",
+		"things.example.org/cluster/a.yaml":            "apiVersion: example.org/v1\nkind: Thing\nmetadata:\n  name: a\n  generation: 2\nspec:\n  size: large\n",
+		"things.example.org/metadata.yaml":             "categories:\n- managed\n- aws\n",
+	})
+
+	got, err := CompareArchives(context.Background(), from, to)
+	if err != nil {
+		t.Fatalf("CompareArchives(...): unexpected error: %v", err)
+	}
+	configMaps := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	things := schema.GroupVersionResource{Group: "example.org", Version: "v1", Resource: "things"}
+	want := []ResourceChange{
+		{Type: ChangeRemoved, GVR: configMaps, Namespace: "default", Name: "removed"},
+		{Type: ChangeAdded, GVR: configMaps, Namespace: "team", Name: "added"},
+		{Type: ChangeModified, GVR: things, Name: "a"},
+	}
+	if diff := cmp.Diff(want, got, cmpopts.IgnoreFields(ResourceChange{}, "Diff")); diff != "" {
+		t.Errorf("CompareArchives(...): -want, +got:\n%s", diff)
+	}
+
+	d := got[2].Diff
+	if !strings.Contains(d, "-  size: small\n") || !strings.Contains(d, "+  size: large\n") || strings.Contains(d, "generation") {
+		t.Errorf("CompareArchives(...): unexpected diff of modified resource:\n%s", d)
+	}
+
+	fs := afero.Afero{Fs: afero.NewMemMapFs()}
+	if err := WriteDiffs(fs, "diffs", got); err != nil {
+		t.Fatalf("WriteDiffs(...): unexpected error: %v", err)
+	}
+	b, err := fs.ReadFile(filepath.Join("diffs", "things.example.org", "_cluster", "a.diff"))
+	if err != nil {
+		t.Fatalf("WriteDiffs(...): cannot read diff: %v", err)
+	}
+	if diff := cmp.Diff(d, string(b)); diff != "" {
+		t.Errorf("WriteDiffs(...): -want, +got:\n%s", diff)
+	}
+	if ok, _ := fs.Exists(filepath.Join("diffs", "configmaps", "team", "added.diff")); ok {
+		t.Errorf("WriteDiffs(...): wrote a diff for an added resource")
+	}
+}
@@ -0,0 +1,203 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package archiver
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+)
+
+const (
+	// EncryptionKeySize is the size of AES-256 keys in bytes.
+	EncryptionKeySize = 32
+
+	nonceSize = 12
+	// chunkSize is the maximum size of the plaintext sealed at a time, so
+	// that archives can be encrypted and decrypted without holding them in
+	// memory.
+	chunkSize = 64 * 1024
+)
+
+// encryptedMagic is the header of encrypted archives. It is followed by the
+// random base nonce and a sequence of sealed chunks, each prefixed with its
+// length as a 32 bit big endian integer.
+var encryptedMagic = []byte("UPXPENC1")
+
+var (
+	// Chunks are authenticated with whether they are the last one, so that
+	// a truncated archive cannot be decrypted.
+	chunkAAD = []byte{0}
+	lastAAD  = []byte{1}
+)
+
+// ParseEncryptionKey decodes a base64 encoded AES-256 key.
+func ParseEncryptionKey(s string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot decode encryption key")
+	}
+	if len(key) != EncryptionKeySize {
+		return nil, errors.Errorf("encryption key must be %d bytes, got %d", EncryptionKeySize, len(key))
+	}
+	return key, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot create AES cipher")
+	}
+	gcm, err := cipher.NewGCM(block)
+	return gcm, errors.Wrap(err, "cannot create AES-GCM cipher")
+}
+
+// chunkNonce returns the nonce of the i-th chunk, which is the base nonce
+// with its last 8 bytes XORed with i.
+func chunkNonce(base []byte, i uint64) []byte {
+	n := make([]byte, nonceSize)
+	copy(n, base)
+	c := binary.BigEndian.Uint64(n[4:])
+	binary.BigEndian.PutUint64(n[4:], c^i)
+	return n
+}
+
+// encryptWriter seals everything written to it with AES-256-GCM in chunks
+// of chunkSize.
+type encryptWriter struct {
+	w     io.Writer
+	gcm   cipher.AEAD
+	nonce []byte
+	buf   []byte
+	n     uint64
+}
+
+// newEncryptWriter returns a writer encrypting to w with key. The base nonce
+// is read from rnd and written to w after the magic header.
+func newEncryptWriter(w io.Writer, key []byte, rnd io.Reader) (io.WriteCloser, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, nonceSize)
+	if _, err := io.ReadFull(rnd, nonce); err != nil {
+		return nil, errors.Wrap(err, "cannot generate nonce")
+	}
+	if _, err := w.Write(append(append([]byte{}, encryptedMagic...), nonce...)); err != nil {
+		return nil, errors.Wrap(err, "cannot write encryption header")
+	}
+	return &encryptWriter{w: w, gcm: gcm, nonce: nonce, buf: make([]byte, 0, chunkSize)}, nil
+}
+
+func (e *encryptWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		// A full chunk is only sealed once more data is written, so that
+		// the last chunk is known when the writer is closed.
+		if len(e.buf) == chunkSize {
+			if err := e.seal(chunkAAD); err != nil {
+				return written, err
+			}
+		}
+		n := copy(e.buf[len(e.buf):chunkSize], p)
+		e.buf = e.buf[:len(e.buf)+n]
+		p = p[n:]
+		written += n
+	}
+	return written, nil
+}
+
+// Close seals the last chunk. It does not close the underlying writer.
+func (e *encryptWriter) Close() error {
+	return e.seal(lastAAD)
+}
+
+func (e *encryptWriter) seal(aad []byte) error {
+	out := e.gcm.Seal(make([]byte, 4, 4+len(e.buf)+e.gcm.Overhead()), chunkNonce(e.nonce, e.n), e.buf, aad)
+	binary.BigEndian.PutUint32(out, uint32(len(out)-4)) //nolint:gosec // Chunks are at most chunkSize plus the overhead.
+	e.n++
+	e.buf = e.buf[:0]
+	_, err := e.w.Write(out)
+	return errors.Wrap(err, "cannot write encrypted chunk")
+}
+
+// decryptReader opens the chunks sealed by an encryptWriter.
+type decryptReader struct {
+	r     io.Reader
+	gcm   cipher.AEAD
+	nonce []byte
+	buf   []byte
+	n     uint64
+	last  bool
+}
+
+// newDecryptReader returns a reader decrypting r with key. The magic header
+// must already have been read from r.
+func newDecryptReader(r io.Reader, key []byte) (io.Reader, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, nonceSize)
+	if _, err := io.ReadFull(r, nonce); err != nil {
+		return nil, errors.Wrap(err, "cannot read nonce")
+	}
+	return &decryptReader{r: r, gcm: gcm, nonce: nonce}, nil
+}
+
+func (d *decryptReader) Read(p []byte) (int, error) {
+	for len(d.buf) == 0 {
+		if d.last {
+			return 0, io.EOF
+		}
+		if err := d.open(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, d.buf)
+	d.buf = d.buf[n:]
+	return n, nil
+}
+
+func (d *decryptReader) open() error {
+	var size [4]byte
+	if _, err := io.ReadFull(d.r, size[:]); err != nil {
+		return errors.Wrap(err, "cannot read encrypted chunk")
+	}
+	l := binary.BigEndian.Uint32(size[:])
+	if l > chunkSize+uint32(d.gcm.Overhead()) { //nolint:gosec // The overhead is 16 bytes.
+		return errors.Errorf("encrypted chunk of %d bytes exceeds the maximum size", l)
+	}
+	sealed := make([]byte, l)
+	if _, err := io.ReadFull(d.r, sealed); err != nil {
+		return errors.Wrap(err, "cannot read encrypted chunk")
+	}
+	nonce := chunkNonce(d.nonce, d.n)
+	b, err := d.gcm.Open(nil, nonce, sealed, chunkAAD)
+	if err != nil {
+		// Only the last chunk is sealed with lastAAD.
+		if b, err = d.gcm.Open(nil, nonce, sealed, lastAAD); err != nil {
+			return errors.New("cannot decrypt archive, the key is wrong or the archive is corrupted")
+		}
+		d.last = true
+	}
+	d.n++
+	d.buf = b
+	return nil
+}
@@ -0,0 +1,192 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package archiver
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/afero"
+)
+
+func testKey(seed byte) []byte {
+	key := make([]byte, EncryptionKeySize)
+	for i := range key {
+		key[i] = seed + byte(i)
+	}
+	return key
+}
+
+func TestEncryptWriter(t *testing.T) {
+	// The key is 0x00..0x1f and the nonce twelve 0xa5 bytes.
+	want := "55505850454e4331" + // magic
+		"a5a5a5a5a5a5a5a5a5a5a5a5" + // nonce
+		"00000023" + // chunk length
+		"4228f2d440f344a958c0c28ccd030451ec6ed203927198bb35ce8d028399d556a8b194"
+
+	b := &bytes.Buffer{}
+	w, err := newEncryptWriter(b, testKey(0), bytes.NewReader(bytes.Repeat([]byte{0xa5}, nonceSize)))
+	if err != nil {
+		t.Fatalf("newEncryptWriter(...): unexpected error: %v", err)
+	}
+	if _, err := w.Write([]byte("control plane state")); err != nil {
+		t.Fatalf("Write(...): unexpected error: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close(): unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(want, hex.EncodeToString(b.Bytes())); diff != "" {
+		t.Errorf("\nnewEncryptWriter(...): -want, +got:\n%s", diff)
+	}
+}
+
+func TestDecryptReader(t *testing.T) {
+	plain := bytes.Repeat([]byte("0123456789abcdef"), chunkSize/8+3) // a little over two chunks
+
+	encrypt := func(t *testing.T, p []byte) []byte {
+		t.Helper()
+		b := &bytes.Buffer{}
+		w, err := newEncryptWriter(b, testKey(0), bytes.NewReader(bytes.Repeat([]byte{0xa5}, nonceSize)))
+		if err != nil {
+			t.Fatalf("newEncryptWriter(...): unexpected error: %v", err)
+		}
+		if _, err := w.Write(p); err != nil {
+			t.Fatalf("Write(...): unexpected error: %v", err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("Close(): unexpected error: %v", err)
+		}
+		return b.Bytes()[len(encryptedMagic):]
+	}
+
+	type args struct {
+		key    []byte
+		mutate func([]byte) []byte
+	}
+	type want struct {
+		plain []byte
+		err   bool
+	}
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"RoundTrip": {
+			reason: "Data written in several chunks should be decrypted as is.",
+			args:   args{key: testKey(0)},
+			want:   want{plain: plain},
+		},
+		"WrongKey": {
+			reason: "Decrypting with a different key should fail.",
+			args:   args{key: testKey(1)},
+			want:   want{err: true},
+		},
+		"Tampered": {
+			reason: "Decrypting modified data should fail.",
+			args: args{key: testKey(0), mutate: func(b []byte) []byte {
+				b[nonceSize+10] ^= 0xff
+				return b
+			}},
+			want: want{err: true},
+		},
+		"Truncated": {
+			reason: "Decrypting data without its last chunk should fail.",
+			args: args{key: testKey(0), mutate: func(b []byte) []byte {
+				return b[:nonceSize+4+chunkSize+16]
+			}},
+			want: want{err: true},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			b := encrypt(t, plain)
+			if tc.args.mutate != nil {
+				b = tc.args.mutate(b)
+			}
+			r, err := newDecryptReader(bytes.NewReader(b), tc.args.key)
+			if err != nil {
+				t.Fatalf("newDecryptReader(...): unexpected error: %v", err)
+			}
+			got, err := io.ReadAll(r)
+			if tc.want.err {
+				if err == nil {
+					t.Fatalf("\n%s\nRead(...): expected error, got none", tc.reason)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("\n%s\nRead(...): unexpected error: %v", tc.reason, err)
+			}
+			if !bytes.Equal(tc.want.plain, got) {
+				t.Errorf("\n%s\nRead(...): got %d bytes that differ from the %d written", tc.reason, len(got), len(tc.want.plain))
+			}
+		})
+	}
+}
+
+func TestEncryptedArchiveRoundTrip(t *testing.T) {
+	src := afero.Afero{Fs: afero.NewMemMapFs()}
+	if err := src.WriteFile("export.yaml", []byte("version: v1alpha1\n"), 0600); err != nil {
+		t.Fatalf("cannot write file: %v", err)
+	}
+	buf := &bytes.Buffer{}
+	if err := Archive(context.Background(), src, "", buf, WithEncryption(testKey(0))); err != nil {
+		t.Fatalf("Archive(...): unexpected error: %v", err)
+	}
+	if !bytes.HasPrefix(buf.Bytes(), encryptedMagic) {
+		t.Fatalf("Archive(...): archive starts with %x, want %x", buf.Bytes()[:len(encryptedMagic)], encryptedMagic)
+	}
+
+	if err := Unarchive(context.Background(), bytes.NewReader(buf.Bytes()), afero.Afero{Fs: afero.NewMemMapFs()}); err == nil {
+		t.Error("Unarchive(...): expected error without a decryption key, got none")
+	}
+
+	dst := afero.Afero{Fs: afero.NewMemMapFs()}
+	if err := Unarchive(context.Background(), bytes.NewReader(buf.Bytes()), dst, WithDecryptionKey(testKey(0))); err != nil {
+		t.Fatalf("Unarchive(...): unexpected error: %v", err)
+	}
+	got, err := dst.ReadFile("export.yaml")
+	if err != nil {
+		t.Fatalf("cannot read unarchived file: %v", err)
+	}
+	if diff := cmp.Diff("version: v1alpha1\n", string(got)); diff != "" {
+		t.Errorf("\nUnarchive(...): -want, +got:\n%s", diff)
+	}
+}
+
+func TestParseEncryptionKey(t *testing.T) {
+	cases := map[string]struct {
+		key     string
+		wantErr bool
+	}{
+		"Valid":     {key: base64.StdEncoding.EncodeToString(testKey(0))},
+		"NotBase64": {key: "not base64!", wantErr: true},
+		"TooShort":  {key: base64.StdEncoding.EncodeToString(testKey(0)[:16]), wantErr: true},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseEncryptionKey(tc.key)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("ParseEncryptionKey(%q): error %v, want error %t", tc.key, err, tc.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,238 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package archiver
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/afero"
+	"sigs.k8s.io/yaml"
+
+	"github.com/upbound/up/pkg/migration/meta/v1alpha1"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+)
+
+const (
+	exportMetaFile = "export.yaml"
+	typeMetaFile   = "metadata.yaml"
+	manifestFile   = "manifest.yaml"
+	// orderFile is the order manifest of a group resource, see
+	// exporter.ResourceOrderFile.
+	orderFile = "order.yaml"
+)
+
+// source is an unarchived input of a merge.
+type source struct {
+	path string
+	fs   afero.Afero
+	meta *v1alpha1.ExportMeta
+}
+
+// MergeArchives combines the export archives at archivePaths into a single
+// archive at outputPath. If the same resource is part of more than one
+// archive, the one of the most recent export wins, as exported resources have
+// no resourceVersions to compare. Exports taken at the same time are resolved
+// in favor of the later archive in archivePaths. The resource counts in
+// export.yaml are recalculated for the merged archive.
+func MergeArchives(ctx context.Context, archivePaths []string, outputPath string) error { //nolint:gocyclo // Mostly sequential steps.
+	if len(archivePaths) == 0 {
+		return errors.New("no archives to merge")
+	}
+
+	sources := make([]*source, 0, len(archivePaths))
+	for _, p := range archivePaths {
+		s := &source{path: p, fs: afero.Afero{Fs: afero.NewMemMapFs()}}
+		if err := UnarchiveFile(ctx, p, s.fs); err != nil {
+			return errors.Wrapf(err, "cannot unarchive %q", p)
+		}
+		b, err := s.fs.ReadFile(exportMetaFile)
+		if err != nil {
+			return errors.Wrapf(err, "cannot read export metadata of %q", p)
+		}
+		s.meta = &v1alpha1.ExportMeta{}
+		if err = yaml.Unmarshal(b, s.meta); err != nil {
+			return errors.Wrapf(err, "cannot unmarshal export metadata of %q", p)
+		}
+		sources = append(sources, s)
+	}
+	// Merge the most recent export last, so that its files win.
+	sort.SliceStable(sources, func(i, j int) bool {
+		return sources[i].meta.ExportedAt.Before(sources[j].meta.ExportedAt)
+	})
+
+	out := afero.Afero{Fs: afero.NewMemMapFs()}
+	// merged keeps track of the files already merged.
+	merged := map[string]struct{}{}
+	for _, s := range sources {
+		err := s.fs.Walk(".", func(path string, fi os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if fi.IsDir() || path == exportMetaFile || path == ChecksumsFile {
+				// Recreated for the merged state below.
+				return nil
+			}
+			b, err := s.fs.ReadFile(path)
+			if err != nil {
+				return errors.Wrapf(err, "cannot read %q from %q", path, s.path)
+			}
+			if _, ok := merged[path]; ok && filepath.Base(path) == manifestFile {
+				// Content addressable exports, keep the entries of both.
+				if b, err = mergeManifests(out, path, b); err != nil {
+					return err
+				}
+			}
+			if err = out.MkdirAll(filepath.Dir(path), 0700); err != nil {
+				return errors.Wrapf(err, "cannot create directory for %q", path)
+			}
+			if err = out.WriteFile(path, b, 0600); err != nil {
+				return errors.Wrapf(err, "cannot write %q", path)
+			}
+			merged[path] = struct{}{}
+			return nil
+		})
+		if err != nil {
+			return errors.Wrapf(err, "cannot merge %q", s.path)
+		}
+	}
+
+	em, err := mergedMeta(out, sources)
+	if err != nil {
+		return errors.Wrap(err, "cannot build merged export metadata")
+	}
+	b, err := yaml.Marshal(em)
+	if err != nil {
+		return errors.Wrap(err, "cannot marshal export metadata to yaml")
+	}
+	if err = out.WriteFile(exportMetaFile, b, 0600); err != nil {
+		return errors.Wrap(err, "cannot write export metadata")
+	}
+	if err = WriteChecksums(out, "."); err != nil {
+		return err
+	}
+
+	osFs := afero.Afero{Fs: afero.NewOsFs()}
+	f, err := osFs.OpenFile(outputPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return errors.Wrapf(err, "cannot create archive %q", outputPath)
+	}
+	defer f.Close() //nolint:errcheck // Closed explicitly below.
+	if err = Archive(ctx, out, ".", f); err != nil {
+		return errors.Wrap(err, "cannot archive merged state")
+	}
+	return errors.Wrapf(f.Close(), "cannot close archive %q", outputPath)
+}
+
+// mergeManifests merges the content manifest b into the one already merged at
+// path. Entries of b win over existing ones.
+func mergeManifests(out afero.Afero, path string, b []byte) ([]byte, error) {
+	cur, err := out.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot read merged %q", path)
+	}
+	m := &v1alpha1.ContentManifest{}
+	if err = yaml.Unmarshal(cur, m); err != nil {
+		return nil, errors.Wrapf(err, "cannot unmarshal manifest %q", path)
+	}
+	n := &v1alpha1.ContentManifest{}
+	if err = yaml.Unmarshal(b, n); err != nil {
+		return nil, errors.Wrapf(err, "cannot unmarshal manifest %q", path)
+	}
+	if m.Resources == nil {
+		m.Resources = map[string]string{}
+	}
+	for id, h := range n.Resources {
+		m.Resources[id] = h
+	}
+	return yaml.Marshal(m)
+}
+
+func mergedMeta(out afero.Afero, sources []*source) (*v1alpha1.ExportMeta, error) {
+	// Start from the most recent export, which also reflects the most recent
+	// Crossplane installation. Sources are sorted by export time.
+	latest := sources[len(sources)-1]
+	custom := map[string]struct{}{}
+	for _, s := range sources {
+		for gr := range s.meta.Stats.CustomResources {
+			custom[gr] = struct{}{}
+		}
+	}
+	em := *latest.meta
+	em.Stats = v1alpha1.ExportStats{
+		NativeResources: map[string]int{},
+		CustomResources: map[string]int{},
+	}
+
+	grs, err := out.ReadDir(".")
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot list group resources")
+	}
+	for _, gr := range grs {
+		if !gr.IsDir() {
+			continue
+		}
+		count, err := countResources(out, gr.Name())
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot count %q resources", gr.Name())
+		}
+		if _, ok := custom[gr.Name()]; ok {
+			em.Stats.CustomResources[gr.Name()] = count
+		} else {
+			// Native resources are counted by their plural name only.
+			em.Stats.NativeResources[strings.SplitN(gr.Name(), ".", 2)[0]] = count
+		}
+		em.Stats.Total += count
+	}
+	return &em, nil
+}
+
+// countResources returns the number of merged resources of the group
+// resource gr. The objects of content addressable exports may no longer be
+// referenced by the merged manifest, so its entries are counted instead.
+func countResources(out afero.Afero, gr string) (int, error) {
+	mf := filepath.Join(gr, manifestFile)
+	if ok, _ := out.Exists(mf); ok {
+		b, err := out.ReadFile(mf)
+		if err != nil {
+			return 0, errors.Wrapf(err, "cannot read manifest %q", mf)
+		}
+		m := &v1alpha1.ContentManifest{}
+		if err = yaml.Unmarshal(b, m); err != nil {
+			return 0, errors.Wrapf(err, "cannot unmarshal manifest %q", mf)
+		}
+		return len(m.Resources), nil
+	}
+
+	count := 0
+	err := out.Walk(gr, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fi.IsDir() || filepath.Ext(path) != ".yaml" {
+			return nil
+		}
+		if rel, _ := filepath.Rel(gr, path); rel == typeMetaFile || rel == orderFile {
+			return nil
+		}
+		count++
+		return nil
+	})
+	return count, err
+}
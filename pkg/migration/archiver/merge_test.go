@@ -0,0 +1,230 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package archiver
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/afero"
+	"sigs.k8s.io/yaml"
+
+	"github.com/upbound/up/pkg/migration/meta/v1alpha1"
+)
+
+func writeArchive(t *testing.T, path string, files map[string]string) {
+	t.Helper()
+	fs := afero.Afero{Fs: afero.NewMemMapFs()}
+	for f, c := range files {
+		if err := fs.WriteFile(f, []byte(c), 0600); err != nil {
+			t.Fatalf("cannot write file %q: %v", f, err)
+		}
+	}
+	osFs := afero.Afero{Fs: afero.NewOsFs()}
+	f, err := osFs.Create(path)
+	if err != nil {
+		t.Fatalf("cannot create archive %q: %v", path, err)
+	}
+	defer f.Close() //nolint:errcheck // Test.
+	if err := Archive(context.Background(), fs, ".", f); err != nil {
+		t.Fatalf("cannot write archive %q: %v", path, err)
+	}
+}
+
+func TestMergeArchives(t *testing.T) {
+	type args struct {
+		archives []map[string]string
+	}
+	type want struct {
+		files map[string]string
+		stats v1alpha1.ExportStats
+	}
+
+	cases := map[string]struct {
+		args args
+		want want
+	}{
+		"OverlappingAndDisjoint": {
+			args: args{
+				archives: []map[string]string{
+					{
+						"export.yaml": `
+version: v1alpha1
+exportedAt: "2024-01-01T00:00:00Z"
+stats:
+  total: 2
+  nativeResources:
+    secrets: 1
+  customResources:
+    things.example.org: 1
+`,
+						"secrets/namespaces/default/creds.yaml": "kind: Secret\nmetadata:\n  name: creds\n  namespace: default\n",
+						"things.example.org/cluster/a.yaml":     "kind: Thing\nmetadata:\n  name: a\nspec:\n  from: first\n",
+					},
+					{
+						"export.yaml": `
+version: v1alpha1
+exportedAt: "2024-01-02T00:00:00Z"
+stats:
+  total: 2
+  customResources:
+    things.example.org: 2
+`,
+						"things.example.org/cluster/a.yaml": "kind: Thing\nmetadata:\n  name: a\nspec:\n  from: second\n",
+						"things.example.org/cluster/b.yaml": "kind: Thing\nmetadata:\n  name: b\n",
+					},
+				},
+			},
+			want: want{
+				files: map[string]string{
+					"secrets/namespaces/default/creds.yaml": "kind: Secret\nmetadata:\n  name: creds\n  namespace: default\n",
+					"things.example.org/cluster/a.yaml":     "kind: Thing\nmetadata:\n  name: a\nspec:\n  from: second\n",
+					"things.example.org/cluster/b.yaml":     "kind: Thing\nmetadata:\n  name: b\n",
+				},
+				stats: v1alpha1.ExportStats{
+					Total:           3,
+					NativeResources: map[string]int{"secrets": 1},
+					CustomResources: map[string]int{"things.example.org": 2},
+				},
+			},
+		},
+		"NewestExportWinsRegardlessOfOrder": {
+			args: args{
+				archives: []map[string]string{
+					{
+						"export.yaml":                       "version: v1alpha1\nexportedAt: \"2024-01-02T00:00:00Z\"\n",
+						"things.example.org/cluster/a.yaml": "kind: Thing\nmetadata:\n  name: a\nspec:\n  from: first\n",
+					},
+					{
+						"export.yaml":                       "version: v1alpha1\nexportedAt: \"2024-01-01T00:00:00Z\"\n",
+						"things.example.org/cluster/a.yaml": "kind: Thing\nmetadata:\n  name: a\nspec:\n  from: second\n",
+					},
+				},
+			},
+			want: want{
+				files: map[string]string{
+					"things.example.org/cluster/a.yaml": "kind: Thing\nmetadata:\n  name: a\nspec:\n  from: first\n",
+				},
+				stats: v1alpha1.ExportStats{
+					Total:           1,
+					NativeResources: map[string]int{"things": 1},
+				},
+			},
+		},
+		"OrderManifestNotCounted": {
+			args: args{
+				archives: []map[string]string{
+					{
+						"export.yaml":                       "version: v1alpha1\nexportedAt: \"2024-01-01T00:00:00Z\"\nstats:\n  customResources:\n    things.example.org: 2\n",
+						"things.example.org/metadata.yaml":  "withStatusSubresource: true\n",
+						"things.example.org/order.yaml":     "resources:\n- cluster/b\n- cluster/a\n",
+						"things.example.org/cluster/a.yaml": "kind: Thing\nmetadata:\n  name: a\n",
+						"things.example.org/cluster/b.yaml": "kind: Thing\nmetadata:\n  name: b\n",
+					},
+				},
+			},
+			want: want{
+				files: map[string]string{
+					"things.example.org/order.yaml": "resources:\n- cluster/b\n- cluster/a\n",
+				},
+				stats: v1alpha1.ExportStats{
+					Total:           2,
+					CustomResources: map[string]int{"things.example.org": 2},
+				},
+			},
+		},
+		"ContentAddressableCountsManifestEntries": {
+			args: args{
+				archives: []map[string]string{
+					{
+						"export.yaml": `
+version: v1alpha1
+exportedAt: "2024-01-01T00:00:00Z"
+stats:
+  customResources:
+    things.example.org: 2
+`,
+						"things.example.org/manifest.yaml":   "resources:\n  cluster/a: h1\n  cluster/b: h2\n",
+						"things.example.org/objects/h1.yaml": "kind: Thing\nmetadata:\n  name: a\n",
+						"things.example.org/objects/h2.yaml": "kind: Thing\nmetadata:\n  name: b\n",
+					},
+					{
+						"export.yaml": `
+version: v1alpha1
+exportedAt: "2024-01-02T00:00:00Z"
+stats:
+  customResources:
+    things.example.org: 1
+`,
+						"things.example.org/manifest.yaml":   "resources:\n  cluster/a: h3\n",
+						"things.example.org/objects/h3.yaml": "kind: Thing\nmetadata:\n  name: a\nspec:\n  from: second\n",
+					},
+				},
+			},
+			want: want{
+				files: map[string]string{
+					"things.example.org/manifest.yaml": "resources:\n  cluster/a: h3\n  cluster/b: h2\n",
+				},
+				stats: v1alpha1.ExportStats{
+					Total:           2,
+					CustomResources: map[string]int{"things.example.org": 2},
+				},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			paths := make([]string, 0, len(tc.args.archives))
+			for i, files := range tc.args.archives {
+				p := filepath.Join(dir, string(rune('a'+i))+".tar.gz")
+				writeArchive(t, p, files)
+				paths = append(paths, p)
+			}
+
+			out := filepath.Join(dir, "merged.tar.gz")
+			if err := MergeArchives(context.Background(), paths, out); err != nil {
+				t.Fatalf("MergeArchives() unexpected error: %v", err)
+			}
+
+			fs := afero.Afero{Fs: afero.NewMemMapFs()}
+			if err := UnarchiveFile(context.Background(), out, fs); err != nil {
+				t.Fatalf("cannot unarchive merged archive: %v", err)
+			}
+			for f, want := range tc.want.files {
+				got, err := fs.ReadFile(f)
+				if err != nil {
+					t.Fatalf("cannot read %q from merged archive: %v", f, err)
+				}
+				if diff := cmp.Diff(want, string(got)); diff != "" {
+					t.Errorf("%q mismatch (-want +got):\n%s", f, diff)
+				}
+			}
+			b, err := fs.ReadFile("export.yaml")
+			if err != nil {
+				t.Fatalf("cannot read export metadata: %v", err)
+			}
+			em := &v1alpha1.ExportMeta{}
+			if err := yaml.Unmarshal(b, em); err != nil {
+				t.Fatalf("cannot unmarshal export metadata: %v", err)
+			}
+			if diff := cmp.Diff(tc.want.stats, em.Stats); diff != "" {
+				t.Errorf("export stats mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
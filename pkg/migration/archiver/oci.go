@@ -0,0 +1,81 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package archiver
+
+import (
+	"context"
+	"io"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+)
+
+const (
+	// OCIArtifactType is the media type of the config of an OCI artifact
+	// holding an export archive.
+	OCIArtifactType types.MediaType = "application/vnd.upbound.migration.export.config.v1+json"
+
+	ociLayerMediaTypePrefix = "application/vnd.upbound.migration.export.v1.tar+"
+)
+
+// PushOCI pushes the archive compressed with alg to the OCI reference ref,
+// as the single layer of an OCI artifact. Credentials are read from the
+// Docker configuration.
+func PushOCI(ctx context.Context, ref string, archive []byte, alg Compression) error {
+	r, err := name.ParseReference(ref)
+	if err != nil {
+		return errors.Wrapf(err, "cannot parse OCI reference %q", ref)
+	}
+	layer := static.NewLayer(archive, types.MediaType(ociLayerMediaTypePrefix+string(alg)))
+	img, err := mutate.Append(empty.Image, mutate.Addendum{Layer: layer})
+	if err != nil {
+		return errors.Wrap(err, "cannot build OCI artifact")
+	}
+	img = mutate.ConfigMediaType(mutate.MediaType(img, types.OCIManifestSchema1), OCIArtifactType)
+
+	err = remote.Write(r, img, remote.WithContext(ctx), remote.WithAuthFromKeychain(authn.DefaultKeychain))
+	return errors.Wrapf(err, "cannot push archive to %q", ref)
+}
+
+// PullOCI returns the archive stored in the OCI artifact at ref by PushOCI.
+// Credentials are read from the Docker configuration.
+func PullOCI(ctx context.Context, ref string) (io.ReadCloser, error) {
+	r, err := name.ParseReference(ref)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot parse OCI reference %q", ref)
+	}
+	img, err := remote.Image(r, remote.WithContext(ctx), remote.WithAuthFromKeychain(authn.DefaultKeychain))
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot pull %q", ref)
+	}
+	layers, err := img.Layers()
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot get layers of %q", ref)
+	}
+	if len(layers) != 1 {
+		return nil, errors.Errorf("%q is not an export archive: want a single layer, got %d", ref, len(layers))
+	}
+	// The layer is the archive as is, which is decompressed when it is
+	// unarchived.
+	rc, err := layers[0].Compressed()
+	return rc, errors.Wrapf(err, "cannot read archive layer of %q", ref)
+}
@@ -0,0 +1,97 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package archiver
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/spf13/afero"
+)
+
+// newTestRegistry returns the host of an in-memory container registry, which
+// is served over plain HTTP as it is on the loopback interface.
+func newTestRegistry(t *testing.T) string {
+	t.Helper()
+	s := httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(s.Close)
+	return strings.TrimPrefix(s.URL, "http://")
+}
+
+func TestOCIRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	for _, alg := range []Compression{CompressionGzip, CompressionZstd} {
+		t.Run(string(alg), func(t *testing.T) {
+			src := afero.Afero{Fs: afero.NewMemMapFs()}
+			if err := src.WriteFile("export.yaml", []byte("version: v1alpha1\n"), 0600); err != nil {
+				t.Fatalf("cannot write export metadata: %v", err)
+			}
+			b := &bytes.Buffer{}
+			if err := Archive(ctx, src, ".", b, WithCompression(alg, 0)); err != nil {
+				t.Fatalf("Archive(...): %v", err)
+			}
+
+			ref := newTestRegistry(t) + "/exports/prod:v1"
+			if err := PushOCI(ctx, ref, b.Bytes(), alg); err != nil {
+				t.Fatalf("PushOCI(...): %v", err)
+			}
+			rc, err := PullOCI(ctx, ref)
+			if err != nil {
+				t.Fatalf("PullOCI(...): %v", err)
+			}
+			defer rc.Close() //nolint:errcheck // Read only.
+
+			dst := afero.Afero{Fs: afero.NewMemMapFs()}
+			if err := Unarchive(ctx, rc, dst); err != nil {
+				t.Fatalf("Unarchive(...): %v", err)
+			}
+			got, err := dst.ReadFile("export.yaml")
+			if err != nil {
+				t.Fatalf("cannot read export metadata: %v", err)
+			}
+			if diff := cmp.Diff("version: v1alpha1\n", string(got)); diff != "" {
+				t.Errorf("PullOCI(...): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestPullOCINotAnExport(t *testing.T) {
+	img, err := random.Image(64, 2)
+	if err != nil {
+		t.Fatalf("cannot create image: %v", err)
+	}
+	ref := newTestRegistry(t) + "/images/random:v1"
+	r, err := name.ParseReference(ref)
+	if err != nil {
+		t.Fatalf("cannot parse reference: %v", err)
+	}
+	if err := remote.Write(r, img); err != nil {
+		t.Fatalf("cannot push image: %v", err)
+	}
+	if _, err := PullOCI(context.Background(), ref); err == nil {
+		t.Errorf("PullOCI(...): want error for an image with multiple layers, got none")
+	}
+}
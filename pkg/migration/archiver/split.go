@@ -0,0 +1,180 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package archiver
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/afero"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+)
+
+// firstPartSuffix is the suffix of the first part of a split archive.
+const firstPartSuffix = ".001"
+
+// PartPath returns the path of the i-th part, starting at 1, of the split
+// archive at path, e.g. "xp-state.tar.gz.002".
+func PartPath(path string, i int) string {
+	return fmt.Sprintf("%s.%03d", path, i)
+}
+
+// splitWriter writes to numbered part files, switching to a new one whenever
+// the current one reaches the maximum size.
+type splitWriter struct {
+	fs   afero.Afero
+	path string
+	size int64
+
+	parts   int
+	written int64
+	current afero.File
+}
+
+func newSplitWriter(fs afero.Afero, path string, size int64) *splitWriter {
+	return &splitWriter{fs: fs, path: path, size: size}
+}
+
+func (w *splitWriter) Write(p []byte) (int, error) {
+	n := 0
+	for len(p) > 0 {
+		if w.current == nil || w.written == w.size {
+			if err := w.next(); err != nil {
+				return n, err
+			}
+		}
+		chunk := p
+		if rest := w.size - w.written; int64(len(chunk)) > rest {
+			chunk = chunk[:rest]
+		}
+		m, err := w.current.Write(chunk)
+		n += m
+		w.written += int64(m)
+		if err != nil {
+			return n, errors.Wrapf(err, "cannot write archive part %q", w.current.Name())
+		}
+		p = p[m:]
+	}
+	return n, nil
+}
+
+// next closes the current part, if any, and creates the next one.
+func (w *splitWriter) next() error {
+	if err := w.Close(); err != nil {
+		return err
+	}
+	w.parts++
+	path := PartPath(w.path, w.parts)
+	f, err := w.fs.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return errors.Wrapf(err, "cannot create archive part %q", path)
+	}
+	// Apply the appropriate permissions in case the file already existed.
+	if err = w.fs.Chmod(path, 0600); err != nil {
+		_ = f.Close()
+		return errors.Wrapf(err, "cannot set permissions of archive part %q", path)
+	}
+	w.current, w.written = f, 0
+	return nil
+}
+
+// Close closes the current part.
+func (w *splitWriter) Close() error {
+	if w.current == nil {
+		return nil
+	}
+	f := w.current
+	w.current = nil
+	return errors.Wrapf(f.Close(), "cannot close archive part %q", f.Name())
+}
+
+// ArchiveSplitFiles writes all files below dir in fs to a new archive split
+// into numbered parts of at most size bytes next to path, e.g.
+// "xp-state.tar.gz.001" and "xp-state.tar.gz.002". It returns the number of
+// parts written.
+func ArchiveSplitFiles(ctx context.Context, fs afero.Afero, dir string, path string, size int64, opts ...ArchiveOption) (int, error) {
+	if size <= 0 {
+		return 0, errors.New("the size of archive parts must be positive")
+	}
+	w := newSplitWriter(fs, path, size)
+	if err := Archive(ctx, fs, dir, w, opts...); err != nil {
+		_ = w.Close()
+		return w.parts, err
+	}
+	return w.parts, w.Close()
+}
+
+// openArchive opens the archive at path on the local file system. If path
+// is the first part of a split archive, or does not exist but a first part
+// next to it does, all parts are read in order as if they were one file.
+func openArchive(path string) (io.ReadCloser, error) {
+	path = filepath.Clean(path)
+	base := strings.TrimSuffix(path, firstPartSuffix)
+	if base == path {
+		if _, err := os.Stat(path); err == nil || !os.IsNotExist(err) {
+			f, err := os.Open(path)
+			return f, errors.Wrap(err, "cannot open input archive")
+		}
+		if _, err := os.Stat(PartPath(path, 1)); err != nil {
+			// Report the missing archive rather than its missing first part.
+			_, err := os.Open(path)
+			return nil, errors.Wrap(err, "cannot open input archive")
+		}
+	}
+
+	var files []*os.File
+	for i := 1; ; i++ {
+		f, err := os.Open(PartPath(base, i))
+		if os.IsNotExist(err) && i > 1 {
+			break
+		}
+		if err != nil {
+			_ = closeAll(files)
+			return nil, errors.Wrapf(err, "cannot open input archive part %d", i)
+		}
+		files = append(files, f)
+	}
+	readers := make([]io.Reader, len(files))
+	for i, f := range files {
+		readers[i] = f
+	}
+	return &partsReader{Reader: io.MultiReader(readers...), files: files}, nil
+}
+
+// partsReader reads the concatenation of the parts of a split archive.
+type partsReader struct {
+	io.Reader
+	files []*os.File
+}
+
+// Close closes all parts.
+func (r *partsReader) Close() error {
+	return closeAll(r.files)
+}
+
+func closeAll(files []*os.File) error {
+	var err error
+	for _, f := range files {
+		if cerr := f.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
@@ -0,0 +1,90 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package archiver
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/afero"
+)
+
+func TestArchiveSplitFilesRoundTrip(t *testing.T) {
+	const size = 64
+
+	cases := map[string]struct {
+		reason string
+		input  func(path string) string
+	}{
+		"ArchivePath": {
+			reason: "A split archive should be read from the path it was split from.",
+			input:  func(path string) string { return path },
+		},
+		"FirstPart": {
+			reason: "A split archive should be read from its first part.",
+			input:  func(path string) string { return PartPath(path, 1) },
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			files := map[string]string{
+				"export.yaml":                     "version: v1alpha1\n",
+				"namespaces/cluster/default.yaml": "kind: Namespace\n",
+			}
+			src := afero.Afero{Fs: afero.NewMemMapFs()}
+			for f, c := range files {
+				if err := src.WriteFile(filepath.Join("/export", f), []byte(c), 0600); err != nil {
+					t.Fatalf("cannot write file %q: %v", f, err)
+				}
+			}
+
+			// Split archives are read from the local file system.
+			out := afero.Afero{Fs: afero.NewOsFs()}
+			path := filepath.Join(t.TempDir(), "xp-state.tar.gz")
+			parts, err := ArchiveSplitFiles(context.Background(), afero.Afero{Fs: afero.NewCopyOnWriteFs(src.Fs, out.Fs)}, "/export", path, size)
+			if err != nil {
+				t.Fatalf("\n%s\nArchiveSplitFiles(...): unexpected error: %v", tc.reason, err)
+			}
+			if parts < 2 {
+				t.Fatalf("\n%s\nArchiveSplitFiles(...): wrote %d parts, want several", tc.reason, parts)
+			}
+			for i := 1; i <= parts; i++ {
+				fi, err := out.Stat(PartPath(path, i))
+				if err != nil {
+					t.Fatalf("cannot stat part %d: %v", i, err)
+				}
+				if fi.Size() > size {
+					t.Errorf("\n%s\nArchiveSplitFiles(...): part %d has %d bytes, want at most %d", tc.reason, i, fi.Size(), size)
+				}
+			}
+
+			dst := afero.Afero{Fs: afero.NewMemMapFs()}
+			if err := UnarchiveFile(context.Background(), tc.input(path), dst); err != nil {
+				t.Fatalf("\n%s\nUnarchiveFile(...): unexpected error: %v", tc.reason, err)
+			}
+			for f, c := range files {
+				got, err := dst.ReadFile(f)
+				if err != nil {
+					t.Fatalf("cannot read unarchived file %q: %v", f, err)
+				}
+				if diff := cmp.Diff(c, string(got)); diff != "" {
+					t.Errorf("\n%s\nUnarchiveFile(...): %s: -want, +got:\n%s", tc.reason, f, diff)
+				}
+			}
+		})
+	}
+}
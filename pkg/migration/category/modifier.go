@@ -17,8 +17,10 @@ package category
 import (
 	"context"
 
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/dynamic"
@@ -28,6 +30,18 @@ import (
 	"github.com/crossplane/crossplane-runtime/pkg/resource"
 )
 
+const (
+	// CategoryObserved is the pseudo category of the types whose CRD is
+	// labeled with LabelObserved, e.g. the observed composites and claims of
+	// Composition Functions. They are not in any API category.
+	CategoryObserved = "observed"
+	// LabelObserved marks a CRD as a type of CategoryObserved if set to
+	// "true".
+	LabelObserved = "observed.upbound.io"
+)
+
+var crdGVR = apiextensionsv1.SchemeGroupVersion.WithResource("customresourcedefinitions")
+
 type Modifier interface {
 	ModifyResources(ctx context.Context, category string, modify func(*unstructured.Unstructured) error) (int, error)
 }
@@ -44,47 +58,89 @@ func NewAPICategoryModifier(dyn dynamic.Interface, dis discovery.DiscoveryInterf
 	}
 }
 
-func (a *APICategoryModifier) ModifyResources(ctx context.Context, category string, modify func(*unstructured.Unstructured) error) (int, error) { // nolint:gocyclo // Hard to refactor without losing readability.
+func (a *APICategoryModifier) ModifyResources(ctx context.Context, category string, modify func(*unstructured.Unstructured) error) (int, error) {
+	gvrs, err := a.resourcesIn(ctx, category)
+	if err != nil {
+		return 0, err
+	}
 	count := 0
+	for _, gvr := range gvrs {
+		ul, err := a.dynamicClient.Resource(gvr).Namespace("").List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return 0, errors.Wrapf(err, "cannot list resources %s", gvr.Resource)
+		}
+		for _, item := range ul.Items {
+			if err = retry.OnError(retry.DefaultRetry, resource.IsAPIError, func() error {
+				u, err := a.dynamicClient.Resource(gvr).Namespace(item.GetNamespace()).Get(ctx, item.GetName(), metav1.GetOptions{})
+				if err != nil {
+					return err
+				}
+				if err = modify(u); err != nil {
+					return err
+				}
+				_, err = a.dynamicClient.Resource(gvr).Namespace(u.GetNamespace()).Update(ctx, u, metav1.UpdateOptions{})
+				if err != nil {
+					return err
+				}
+				return nil
+			}); err != nil {
+				return 0, errors.Wrapf(err, "cannot modify resource %s/%s", item.GetKind(), item.GetName())
+			}
+			count++
+		}
+	}
+	return count, nil
+}
+
+// resourcesIn returns the types of the supplied category.
+func (a *APICategoryModifier) resourcesIn(ctx context.Context, category string) ([]schema.GroupVersionResource, error) {
+	if category == CategoryObserved {
+		return a.observedResources(ctx)
+	}
 	apiLists, err := a.discoveryClient.ServerPreferredResources()
 	if err != nil {
-		return 0, errors.Wrap(err, "cannot get server preferred resources")
+		return nil, errors.Wrap(err, "cannot get server preferred resources")
 	}
+	var gvrs []schema.GroupVersionResource
 	for _, al := range apiLists {
 		for _, r := range al.APIResources {
 			if contains(r.Categories, category) {
-				gvr := schema.GroupVersionResource{
+				gvrs = append(gvrs, schema.GroupVersionResource{
 					Group:    r.Group,
 					Version:  r.Version,
 					Resource: r.Name,
-				}
-				ul, err := a.dynamicClient.Resource(gvr).Namespace("").List(ctx, metav1.ListOptions{})
-				if err != nil {
-					return 0, errors.Wrapf(err, "cannot list resources %s", r.Name)
-				}
-				for _, item := range ul.Items {
-					if err = retry.OnError(retry.DefaultRetry, resource.IsAPIError, func() error {
-						u, err := a.dynamicClient.Resource(gvr).Namespace(item.GetNamespace()).Get(ctx, item.GetName(), metav1.GetOptions{})
-						if err != nil {
-							return err
-						}
-						if err = modify(u); err != nil {
-							return err
-						}
-						_, err = a.dynamicClient.Resource(gvr).Namespace(u.GetNamespace()).Update(ctx, u, metav1.UpdateOptions{})
-						if err != nil {
-							return err
-						}
-						return nil
-					}); err != nil {
-						return 0, errors.Wrapf(err, "cannot modify resource %s/%s", item.GetKind(), item.GetName())
-					}
-					count++
-				}
+				})
 			}
 		}
 	}
-	return count, nil
+	return gvrs, nil
+}
+
+// observedResources returns the types whose CRD is labeled with LabelObserved,
+// with their storage version.
+func (a *APICategoryModifier) observedResources(ctx context.Context) ([]schema.GroupVersionResource, error) {
+	ul, err := a.dynamicClient.Resource(crdGVR).List(ctx, metav1.ListOptions{LabelSelector: LabelObserved + "=true"})
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot list observed CRDs")
+	}
+	gvrs := make([]schema.GroupVersionResource, 0, len(ul.Items))
+	for _, u := range ul.Items {
+		crd := &apiextensionsv1.CustomResourceDefinition{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, crd); err != nil {
+			return nil, errors.Wrapf(err, "cannot convert CRD %q", u.GetName())
+		}
+		for _, v := range crd.Spec.Versions {
+			if v.Storage {
+				gvrs = append(gvrs, schema.GroupVersionResource{
+					Group:    crd.Spec.Group,
+					Version:  v.Name,
+					Resource: crd.Spec.Names.Plural,
+				})
+				break
+			}
+		}
+	}
+	return gvrs, nil
 }
 
 func contains(slice []string, item string) bool {
@@ -0,0 +1,92 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package category
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	xpmeta "github.com/crossplane/crossplane-runtime/pkg/meta"
+)
+
+func TestAPICategoryModifierObserved(t *testing.T) {
+	observed := schema.GroupVersionResource{Group: "example.org", Version: "v1", Resource: "observedcomposites"}
+
+	crd := func(name string, labels map[string]string) runtime.Object {
+		u := &unstructured.Unstructured{Object: map[string]any{
+			"spec": map[string]any{
+				"group": "example.org",
+				"names": map[string]any{"plural": name, "kind": "Thing"},
+				"versions": []any{
+					map[string]any{"name": "v1alpha1", "served": true, "storage": false},
+					map[string]any{"name": "v1", "served": true, "storage": true},
+				},
+			},
+		}}
+		u.SetAPIVersion("apiextensions.k8s.io/v1")
+		u.SetKind("CustomResourceDefinition")
+		u.SetName(name + ".example.org")
+		u.SetLabels(labels)
+		return u
+	}
+	resource := func(name string) runtime.Object {
+		u := &unstructured.Unstructured{}
+		u.SetAPIVersion("example.org/v1")
+		u.SetKind("ObservedComposite")
+		u.SetName(name)
+		xpmeta.AddAnnotations(u, map[string]string{"crossplane.io/paused": "true"})
+		return u
+	}
+
+	dyn := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		crdGVR:   "CustomResourceDefinitionList",
+		observed: "ObservedCompositeList",
+	},
+		crd("observedcomposites", map[string]string{LabelObserved: "true"}),
+		crd("others", nil),
+		resource("a"),
+		resource("b"),
+	)
+
+	m := NewAPICategoryModifier(dyn, kubefake.NewSimpleClientset().Discovery())
+	n, err := m.ModifyResources(context.Background(), CategoryObserved, func(u *unstructured.Unstructured) error {
+		xpmeta.RemoveAnnotations(u, "crossplane.io/paused")
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ModifyResources(...): unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(2, n); diff != "" {
+		t.Errorf("ModifyResources(...): -want, +got:\n%s", diff)
+	}
+
+	ul, err := dyn.Resource(observed).List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("cannot list observed resources: %v", err)
+	}
+	for _, u := range ul.Items {
+		if _, paused := u.GetAnnotations()["crossplane.io/paused"]; paused {
+			t.Errorf("ModifyResources(...): %s is still paused", u.GetName())
+		}
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package crossplane
+
+import (
+	"k8s.io/apimachinery/pkg/util/version"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+)
+
+// Compatibility of migrating between two Crossplane versions.
+type Compatibility string
+
+const (
+	// Compatible versions can be migrated between.
+	Compatible Compatibility = "Compatible"
+	// CompatibleWithWarnings versions can be migrated between, but the
+	// migration requires additional care.
+	CompatibleWithWarnings Compatibility = "CompatibleWithWarnings"
+	// Incompatible versions cannot be migrated between.
+	Incompatible Compatibility = "Incompatible"
+)
+
+// A CompatibilityRule determines the compatibility of the source and target
+// versions of a migration it matches.
+type CompatibilityRule struct {
+	// Matches returns true if the rule applies to a migration from source to
+	// target.
+	Matches func(source, target *version.Version) bool
+	// Result is the compatibility of the matching versions.
+	Result Compatibility
+	// Reason explains the result.
+	Reason string
+}
+
+// A CompatibilityMatrix determines the compatibility of migrating between
+// two Crossplane versions by its first matching rule. Versions that match no
+// rule are compatible.
+type CompatibilityMatrix []CompatibilityRule
+
+// DefaultCompatibilityMatrix allows migrating between equal versions, warns
+// about upgrading during a migration, and rejects downgrades and migrations
+// across major versions.
+var DefaultCompatibilityMatrix = CompatibilityMatrix{
+	{
+		Matches: func(source, target *version.Version) bool {
+			return source.Major() != target.Major()
+		},
+		Result: Incompatible,
+		Reason: "migrating across major versions is not supported",
+	},
+	{
+		Matches: func(source, target *version.Version) bool {
+			return release(target).LessThan(release(source))
+		},
+		Result: Incompatible,
+		Reason: "the target version is older than the source version, and may not support all exported resources",
+	},
+	{
+		Matches: func(source, target *version.Version) bool {
+			return release(source).LessThan(release(target))
+		},
+		Result: CompatibleWithWarnings,
+		Reason: "the target version is newer than the source version, import preflight checks will report the version mismatch",
+	},
+}
+
+// CompatibilityResult is the result of a compatibility check.
+type CompatibilityResult struct {
+	Source string
+	Target string
+	Result Compatibility
+	Reason string
+}
+
+// Check returns the compatibility of migrating from the source to the target
+// version according to the matrix.
+func (m CompatibilityMatrix) Check(source, target string) (CompatibilityResult, error) {
+	sv, err := version.ParseSemantic(source)
+	if err != nil {
+		return CompatibilityResult{}, errors.Wrapf(err, "cannot parse source Crossplane version %q", source)
+	}
+	tv, err := version.ParseSemantic(target)
+	if err != nil {
+		return CompatibilityResult{}, errors.Wrapf(err, "cannot parse target Crossplane version %q", target)
+	}
+	for _, r := range m {
+		if r.Matches(sv, tv) {
+			return CompatibilityResult{Source: source, Target: target, Result: r.Result, Reason: r.Reason}, nil
+		}
+	}
+	return CompatibilityResult{Source: source, Target: target, Result: Compatible}, nil
+}
+
+// release returns the major, minor and patch version of v, ignoring pre-release
+// and build metadata like the "-up.1" suffix of Universal Crossplane.
+func release(v *version.Version) *version.Version {
+	return version.MajorMinor(v.Major(), v.Minor()).WithPatch(v.Patch())
+}
@@ -0,0 +1,73 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package crossplane
+
+import (
+	"testing"
+)
+
+func TestCompatibilityMatrixCheck(t *testing.T) {
+	type want struct {
+		result Compatibility
+		err    bool
+	}
+	cases := map[string]struct {
+		source string
+		target string
+		want   want
+	}{
+		"Equal": {
+			source: "1.14.5",
+			target: "v1.14.5",
+			want:   want{result: Compatible},
+		},
+		"EqualUniversalCrossplane": {
+			source: "1.14.5-up.1",
+			target: "1.14.5",
+			want:   want{result: Compatible},
+		},
+		"Upgrade": {
+			source: "1.14.5",
+			target: "1.15.0",
+			want:   want{result: CompatibleWithWarnings},
+		},
+		"Downgrade": {
+			source: "1.14.5",
+			target: "1.13.2",
+			want:   want{result: Incompatible},
+		},
+		"MajorVersion": {
+			source: "1.14.5",
+			target: "2.0.0",
+			want:   want{result: Incompatible},
+		},
+		"InvalidTarget": {
+			source: "1.14.5",
+			target: "latest",
+			want:   want{err: true},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := DefaultCompatibilityMatrix.Check(tc.source, tc.target)
+			if (err != nil) != tc.want.err {
+				t.Fatalf("Check(%q, %q): error = %v, want error %t", tc.source, tc.target, err, tc.want.err)
+			}
+			if got.Result != tc.want.result {
+				t.Errorf("Check(%q, %q) = %q, want %q", tc.source, tc.target, got.Result, tc.want.result)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package crossplane
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/util/version"
+
+	"github.com/upbound/up/pkg/migration/meta/v1alpha1"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+)
+
+// DistributionMismatch is returned if the source and target control planes of
+// a migration run different distributions of Crossplane, e.g. Upbound
+// Universal Crossplane and open source Crossplane. Universal Crossplane may
+// support features open source Crossplane does not.
+type DistributionMismatch struct {
+	Source string
+	Target string
+}
+
+func (e *DistributionMismatch) Error() string {
+	return fmt.Sprintf("Crossplane distribution %q of the target control plane does not match exported distribution %q", e.Target, e.Source)
+}
+
+// CheckInstances checks that the Crossplane instance of the target control
+// plane of a migration matches the source. Universal Crossplane versions must
+// match exactly, including the "-up" suffix. If the distributions differ, a
+// DistributionMismatch is returned in addition to mismatching versions, which
+// are then compared without the suffix.
+func CheckInstances(source, target *v1alpha1.CrossplaneInfo) []error {
+	var errs []error
+	sameDistribution := source.IsUniversalCrossplane() == target.IsUniversalCrossplane()
+	if !sameDistribution {
+		errs = append(errs, &DistributionMismatch{Source: distribution(source), Target: distribution(target)})
+	}
+	if !versionsMatch(source.Version, target.Version, sameDistribution) {
+		errs = append(errs, errors.Errorf("Crossplane version %q does not match exported version %q", target.Version, source.Version))
+	}
+	return errs
+}
+
+func distribution(xp *v1alpha1.CrossplaneInfo) string {
+	if xp.IsUniversalCrossplane() {
+		return v1alpha1.DistributionUniversalCrossplane
+	}
+	return v1alpha1.DistributionCrossplane
+}
+
+func versionsMatch(source, target string, exact bool) bool {
+	if source == target || exact {
+		return source == target
+	}
+	sv, err := version.ParseSemantic(source)
+	if err != nil {
+		return false
+	}
+	tv, err := version.ParseSemantic(target)
+	if err != nil {
+		return false
+	}
+	return release(sv).String() == release(tv).String()
+}
@@ -0,0 +1,91 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package crossplane
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/upbound/up/pkg/migration/meta/v1alpha1"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+)
+
+func TestCheckInstances(t *testing.T) {
+	oss := func(v string) *v1alpha1.CrossplaneInfo {
+		return &v1alpha1.CrossplaneInfo{Distribution: v1alpha1.DistributionCrossplane, Version: v}
+	}
+	uxp := func(v string) *v1alpha1.CrossplaneInfo {
+		return &v1alpha1.CrossplaneInfo{Distribution: v1alpha1.DistributionUniversalCrossplane, Version: v}
+	}
+
+	cases := map[string]struct {
+		source *v1alpha1.CrossplaneInfo
+		target *v1alpha1.CrossplaneInfo
+		want   []error
+	}{
+		"Crossplane": {
+			source: oss("1.14.5"),
+			target: oss("1.14.5"),
+		},
+		"CrossplaneVersionMismatch": {
+			source: oss("1.14.5"),
+			target: oss("1.15.0"),
+			want: []error{
+				errors.New(`Crossplane version "1.15.0" does not match exported version "1.14.5"`),
+			},
+		},
+		"UniversalCrossplane": {
+			source: uxp("1.14.5-up.1"),
+			target: uxp("1.14.5-up.1"),
+		},
+		"UniversalCrossplaneVersionMismatch": {
+			source: uxp("1.14.5-up.1"),
+			target: uxp("1.14.5-up.2"),
+			want: []error{
+				errors.New(`Crossplane version "1.14.5-up.2" does not match exported version "1.14.5-up.1"`),
+			},
+		},
+		"UniversalCrossplaneToCrossplane": {
+			source: uxp("1.14.5-up.1"),
+			target: oss("1.14.5"),
+			want: []error{
+				&DistributionMismatch{Source: v1alpha1.DistributionUniversalCrossplane, Target: v1alpha1.DistributionCrossplane},
+			},
+		},
+		"CrossplaneToUniversalCrossplane": {
+			source: oss("1.14.5"),
+			target: uxp("1.15.0-up.1"),
+			want: []error{
+				&DistributionMismatch{Source: v1alpha1.DistributionCrossplane, Target: v1alpha1.DistributionUniversalCrossplane},
+				errors.New(`Crossplane version "1.15.0-up.1" does not match exported version "1.14.5"`),
+			},
+		},
+		"LegacyUniversalCrossplaneExport": {
+			source: &v1alpha1.CrossplaneInfo{Distribution: "my-release", Version: "1.14.5-up.1"},
+			target: uxp("1.14.5-up.1"),
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := CheckInstances(tc.source, tc.target)
+			if diff := cmp.Diff(tc.want, got, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nCheckInstances(...): -want, +got:\n%s", name, diff)
+			}
+		})
+	}
+}
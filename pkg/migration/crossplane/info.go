@@ -16,17 +16,54 @@ package crossplane
 
 import (
 	"context"
+	"sort"
 	"strings"
 
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
 	appsv1 "k8s.io/client-go/kubernetes/typed/apps/v1"
 
 	"github.com/upbound/up/pkg/migration/meta/v1alpha1"
 
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
 	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
 )
 
-func CollectInfo(ctx context.Context, appsClient appsv1.DeploymentsGetter) (*v1alpha1.CrossplaneInfo, error) {
+// DefaultRegistry is the registry Crossplane pulls packages without a
+// registry from, unless configured otherwise.
+const DefaultRegistry = "xpkg.upbound.io"
+
+// providersGVR is the resource of the installed providers.
+var providersGVR = schema.GroupVersionResource{Group: "pkg.crossplane.io", Version: "v1", Resource: "providers"}
+
+// InfoOption configures what CollectInfo collects.
+type InfoOption func(*infoOptions)
+
+type infoOptions struct {
+	dynamicClient dynamic.Interface
+}
+
+// WithProviders makes CollectInfo also collect the installed providers with
+// the supplied client.
+func WithProviders(client dynamic.Interface) InfoOption {
+	return func(o *infoOptions) {
+		o.dynamicClient = client
+	}
+}
+
+// CollectInfo returns the information about the Crossplane instance of the
+// control plane, or an empty CrossplaneInfo if Crossplane is not installed.
+func CollectInfo(ctx context.Context, appsClient appsv1.DeploymentsGetter, opts ...InfoOption) (*v1alpha1.CrossplaneInfo, error) {
+	o := &infoOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
 	dl, err := appsClient.Deployments("").List(ctx, v1.ListOptions{})
 	if err != nil {
 		return nil, errors.Wrap(err, "cannot list deployments to find Crossplane deployment")
@@ -38,20 +75,108 @@ func CollectInfo(ctx context.Context, appsClient appsv1.DeploymentsGetter) (*v1a
 			xp.Namespace = d.Namespace
 			if d.Labels != nil {
 				xp.Version = d.Labels["app.kubernetes.io/version"]
-				xp.Distribution = d.Labels["app.kubernetes.io/instance"]
+			}
+			// The instance label is the name of the Helm release, which is
+			// only a hint for the distribution.
+			xp.Distribution = v1alpha1.DistributionCrossplane
+			if d.Labels["app.kubernetes.io/instance"] == v1alpha1.DistributionUniversalCrossplane || strings.Contains(xp.Version, "-up.") {
+				xp.Distribution = v1alpha1.DistributionUniversalCrossplane
 			}
 			for _, c := range d.Spec.Template.Spec.Containers {
+				if c.Name == "universal-crossplane" {
+					xp.Distribution = v1alpha1.DistributionUniversalCrossplane
+				}
 				if c.Name == "crossplane" || c.Name == "universal-crossplane" {
 					for _, a := range c.Args {
 						if strings.HasPrefix(a, "--enable") {
 							xp.FeatureFlags = append(xp.FeatureFlags, a)
 						}
 					}
+					xp.Registry = registry(c)
 					break
 				}
 			}
 			break
 		}
 	}
+	// Providers can only be installed by Crossplane.
+	if o.dynamicClient != nil && xp.Namespace != "" {
+		if xp.Providers, err = collectProviders(ctx, o.dynamicClient); err != nil {
+			return nil, err
+		}
+	}
 	return &xp, nil
 }
+
+// registry returns the default registry configured for the Crossplane
+// container c, either by flag or by environment variable, or an empty string
+// if it is not configured.
+func registry(c corev1.Container) string {
+	for i, a := range c.Args {
+		for _, f := range []string{"--registry", "-r"} {
+			if v, ok := strings.CutPrefix(a, f+"="); ok {
+				return v
+			}
+			if a == f && i+1 < len(c.Args) {
+				return c.Args[i+1]
+			}
+		}
+	}
+	for _, e := range c.Env {
+		if e.Name == "REGISTRY" {
+			return e.Value
+		}
+	}
+	return ""
+}
+
+// collectProviders returns the installed providers sorted by name, or none if
+// providers are not supported by the control plane.
+func collectProviders(ctx context.Context, client dynamic.Interface) ([]v1alpha1.ProviderInfo, error) {
+	l, err := client.Resource(providersGVR).List(ctx, v1.ListOptions{})
+	if kerrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot list providers")
+	}
+	providers := make([]v1alpha1.ProviderInfo, 0, len(l.Items))
+	for _, p := range l.Items {
+		pkg, _ := fieldpath.Pave(p.Object).GetString("spec.package")
+		providers = append(providers, v1alpha1.ProviderInfo{
+			Name:    p.GetName(),
+			Version: packageVersion(pkg),
+			Healthy: isHealthy(p),
+		})
+	}
+	sort.Slice(providers, func(i, j int) bool {
+		return providers[i].Name < providers[j].Name
+	})
+	return providers, nil
+}
+
+// packageVersion returns the digest or tag of the package reference pkg, or
+// an empty string if it has neither.
+func packageVersion(pkg string) string {
+	if _, digest, ok := strings.Cut(pkg, "@"); ok {
+		return digest
+	}
+	repo := pkg[strings.LastIndex(pkg, "/")+1:]
+	if _, tag, ok := strings.Cut(repo, ":"); ok {
+		return tag
+	}
+	return ""
+}
+
+func isHealthy(p unstructured.Unstructured) bool {
+	var conditions []xpv1.Condition
+	if err := fieldpath.Pave(p.Object).GetValueInto("status.conditions", &conditions); err != nil {
+		return false
+	}
+	for _, c := range conditions {
+		if c.Type == "Healthy" {
+			return c.Status == "True"
+		}
+	}
+	return false
+}
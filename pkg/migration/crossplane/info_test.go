@@ -0,0 +1,181 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package crossplane
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/upbound/up/pkg/migration/meta/v1alpha1"
+)
+
+func TestCollectInfo(t *testing.T) {
+	deployment := func(instance, version, container string) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      "crossplane",
+				Namespace: "crossplane-system",
+				Labels: map[string]string{
+					"app.kubernetes.io/instance": instance,
+					"app.kubernetes.io/version":  version,
+				},
+			},
+			Spec: appsv1.DeploymentSpec{
+				Template: corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{{Name: container, Args: []string{"core", "start", "--enable-usages"}}},
+					},
+				},
+			},
+		}
+	}
+
+	cases := map[string]struct {
+		deployment *appsv1.Deployment
+		want       *v1alpha1.CrossplaneInfo
+	}{
+		"NotInstalled": {
+			want: &v1alpha1.CrossplaneInfo{},
+		},
+		"Crossplane": {
+			deployment: deployment("crossplane", "1.14.5", "crossplane"),
+			want: &v1alpha1.CrossplaneInfo{
+				Distribution: v1alpha1.DistributionCrossplane,
+				Namespace:    "crossplane-system",
+				Version:      "1.14.5",
+				FeatureFlags: []string{"--enable-usages"},
+			},
+		},
+		"UniversalCrossplane": {
+			deployment: deployment("universal-crossplane", "1.14.5-up.1", "universal-crossplane"),
+			want: &v1alpha1.CrossplaneInfo{
+				Distribution: v1alpha1.DistributionUniversalCrossplane,
+				Namespace:    "crossplane-system",
+				Version:      "1.14.5-up.1",
+				FeatureFlags: []string{"--enable-usages"},
+			},
+		},
+		"CustomRegistry": {
+			deployment: func() *appsv1.Deployment {
+				d := deployment("crossplane", "1.14.5", "crossplane")
+				d.Spec.Template.Spec.Containers[0].Args = []string{"core", "start", "--registry=registry.example.org"}
+				return d
+			}(),
+			want: &v1alpha1.CrossplaneInfo{
+				Distribution: v1alpha1.DistributionCrossplane,
+				Namespace:    "crossplane-system",
+				Version:      "1.14.5",
+				Registry:     "registry.example.org",
+			},
+		},
+		"UniversalCrossplaneCustomRelease": {
+			deployment: deployment("uxp", "1.14.5-up.1", "crossplane"),
+			want: &v1alpha1.CrossplaneInfo{
+				Distribution: v1alpha1.DistributionUniversalCrossplane,
+				Namespace:    "crossplane-system",
+				Version:      "1.14.5-up.1",
+				FeatureFlags: []string{"--enable-usages"},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			cs := kubefake.NewSimpleClientset()
+			if tc.deployment != nil {
+				cs = kubefake.NewSimpleClientset(tc.deployment)
+			}
+			got, err := CollectInfo(context.Background(), cs.AppsV1())
+			if err != nil {
+				t.Fatalf("\n%s\nCollectInfo(...): %v", name, err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\nCollectInfo(...): -want, +got:\n%s", name, diff)
+			}
+		})
+	}
+}
+
+func TestCollectInfoProviders(t *testing.T) {
+	provider := func(name, pkg string, healthy string) *unstructured.Unstructured {
+		p := &unstructured.Unstructured{Object: map[string]any{
+			"spec": map[string]any{"package": pkg},
+		}}
+		p.SetAPIVersion("pkg.crossplane.io/v1")
+		p.SetKind("Provider")
+		p.SetName(name)
+		if healthy != "" {
+			p.Object["status"] = map[string]any{
+				"conditions": []any{map[string]any{"type": "Healthy", "status": healthy, "reason": "HealthyPackageRevision"}},
+			}
+		}
+		return p
+	}
+	deployment := &appsv1.Deployment{
+		ObjectMeta: v1.ObjectMeta{Name: "crossplane", Namespace: "crossplane-system"},
+	}
+	listKinds := map[schema.GroupVersionResource]string{providersGVR: "ProviderList"}
+
+	cases := map[string]struct {
+		reason     string
+		deployment *appsv1.Deployment
+		providers  []runtime.Object
+		want       []v1alpha1.ProviderInfo
+	}{
+		"NotInstalled": {
+			reason:    "Providers should not be collected if Crossplane is not installed.",
+			providers: []runtime.Object{provider("provider-aws", "xpkg.upbound.io/upbound/provider-aws:v1.0.0", "True")},
+		},
+		"Providers": {
+			reason:     "The versions and health of the installed providers should be collected sorted by name.",
+			deployment: deployment,
+			providers: []runtime.Object{
+				provider("provider-helm", "localhost:5000/crossplane/provider-helm@sha256:abc", "False"),
+				provider("provider-aws", "xpkg.upbound.io/upbound/provider-aws:v1.0.0", "True"),
+				provider("provider-local", "localhost:5000/provider-local", ""),
+			},
+			want: []v1alpha1.ProviderInfo{
+				{Name: "provider-aws", Version: "v1.0.0", Healthy: true},
+				{Name: "provider-helm", Version: "sha256:abc"},
+				{Name: "provider-local"},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			cs := kubefake.NewSimpleClientset()
+			if tc.deployment != nil {
+				cs = kubefake.NewSimpleClientset(tc.deployment)
+			}
+			dyn := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds, tc.providers...)
+			got, err := CollectInfo(context.Background(), cs.AppsV1(), WithProviders(dyn))
+			if err != nil {
+				t.Fatalf("\n%s\nCollectInfo(...): %v", tc.reason, err)
+			}
+			if diff := cmp.Diff(tc.want, got.Providers); diff != "" {
+				t.Errorf("\n%s\nCollectInfo(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
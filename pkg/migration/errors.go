@@ -0,0 +1,75 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migration
+
+import (
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+)
+
+// ErrorCode classifies migration errors, e.g. to handle them in scripts
+// without parsing the error message.
+type ErrorCode string
+
+const (
+	// ErrCodePreflightFailed is the code of errors caused by failed
+	// preflight checks.
+	ErrCodePreflightFailed ErrorCode = "PreflightFailed"
+	// ErrCodeArchiveCorrupt is the code of errors caused by an export
+	// archive that cannot be read or does not match its checksums.
+	ErrCodeArchiveCorrupt ErrorCode = "ArchiveCorrupt"
+	// ErrCodeResourceFetchFailed is the code of errors caused by resources
+	// that cannot be fetched from the exported control plane.
+	ErrCodeResourceFetchFailed ErrorCode = "ResourceFetchFailed"
+	// ErrCodeResourceApplyFailed is the code of errors caused by resources
+	// that cannot be applied to the target control plane.
+	ErrCodeResourceApplyFailed ErrorCode = "ResourceApplyFailed"
+	// ErrCodeTimeout is the code of errors caused by exceeding the global
+	// timeout of an export or import.
+	ErrCodeTimeout ErrorCode = "Timeout"
+)
+
+// MigrationError is an error with an ErrorCode.
+type MigrationError struct {
+	Code  ErrorCode
+	Cause error
+}
+
+// Error returns the message of the cause.
+func (e *MigrationError) Error() string {
+	return e.Cause.Error()
+}
+
+// Unwrap returns the cause.
+func (e *MigrationError) Unwrap() error {
+	return e.Cause
+}
+
+// WithCode returns err with the supplied code, or nil if err is nil.
+func WithCode(err error, code ErrorCode) error {
+	if err == nil {
+		return nil
+	}
+	return &MigrationError{Code: code, Cause: err}
+}
+
+// Code returns the code of the outermost MigrationError in the chain of err,
+// or an empty code if there is none.
+func Code(err error) ErrorCode {
+	var merr *MigrationError
+	if errors.As(err, &merr) {
+		return merr.Code
+	}
+	return ""
+}
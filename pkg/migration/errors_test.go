@@ -0,0 +1,66 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migration
+
+import (
+	"testing"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCode(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	cases := map[string]struct {
+		reason string
+		err    error
+		want   ErrorCode
+	}{
+		"Nil": {
+			reason: "A nil error should have no code.",
+			err:    WithCode(nil, ErrCodeTimeout),
+			want:   "",
+		},
+		"NoCode": {
+			reason: "An error without a code should have no code.",
+			err:    errBoom,
+			want:   "",
+		},
+		"Code": {
+			reason: "An error with a code should return it.",
+			err:    WithCode(errBoom, ErrCodeArchiveCorrupt),
+			want:   ErrCodeArchiveCorrupt,
+		},
+		"Wrapped": {
+			reason: "The code should be found through wrapped errors.",
+			err:    errors.Wrap(WithCode(errBoom, ErrCodeResourceApplyFailed), "cannot import"),
+			want:   ErrCodeResourceApplyFailed,
+		},
+		"Outermost": {
+			reason: "The code of the outermost coded error should win.",
+			err:    WithCode(errors.Wrap(WithCode(errBoom, ErrCodeResourceApplyFailed), "cannot import"), ErrCodeTimeout),
+			want:   ErrCodeTimeout,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := Code(tc.err)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\nCode(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/afero"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/yaml"
+
+	"github.com/upbound/up/pkg/migration/meta/v1alpha1"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+)
+
+const (
+	// AuditHistoryFile is the name of the file in the root of the export that
+	// contains the audit history of the exported resources.
+	AuditHistoryFile = "audit-history.yaml"
+
+	defaultAuditHistoryLimit = 10
+	maxAuditLineSize         = 4 * 1024 * 1024
+)
+
+// auditEvent is the subset of an audit.k8s.io/v1 Event that we need to build
+// the mutation history.
+type auditEvent struct {
+	Stage string `json:"stage"`
+	Verb  string `json:"verb"`
+	User  struct {
+		Username string `json:"username"`
+	} `json:"user"`
+	ObjectRef *struct {
+		Resource    string `json:"resource"`
+		Namespace   string `json:"namespace"`
+		Name        string `json:"name"`
+		APIGroup    string `json:"apiGroup"`
+		Subresource string `json:"subresource"`
+	} `json:"objectRef"`
+	ResponseStatus *struct {
+		Code int32 `json:"code"`
+	} `json:"responseStatus"`
+	StageTimestamp time.Time `json:"stageTimestamp"`
+}
+
+// AuditLogExporter extracts the mutation history of exported resources from a
+// Kubernetes audit log and stores it in the export.
+type AuditLogExporter struct {
+	fs      afero.Afero
+	root    string
+	logFS   afero.Afero
+	logPath string
+	limit   int
+}
+
+// NewAuditLogExporter returns a new AuditLogExporter reading the audit log at
+// the given path, in the JSON lines format of the Kubernetes log backend. The
+// audit log is read from the local file system, regardless of the file system
+// the export is written to, e.g. when exporting to memory.
+func NewAuditLogExporter(fs afero.Afero, root string, logPath string) *AuditLogExporter {
+	return &AuditLogExporter{
+		fs:      fs,
+		root:    root,
+		logFS:   afero.Afero{Fs: afero.NewOsFs()},
+		logPath: logPath,
+		limit:   defaultAuditHistoryLimit,
+	}
+}
+
+// ExportAuditHistory writes the last mutations of every resource of the given
+// group resources found in the audit log to the export.
+func (e *AuditLogExporter) ExportAuditHistory(_ context.Context, groupResources []string) error {
+	f, err := e.logFS.Open(e.logPath)
+	if err != nil {
+		return errors.Wrapf(err, "cannot open audit log %q", e.logPath)
+	}
+	defer f.Close() //nolint:errcheck // Read only.
+
+	h, err := ParseAuditLog(f, groupResources, e.limit)
+	if err != nil {
+		return errors.Wrapf(err, "cannot parse audit log %q", e.logPath)
+	}
+
+	b, err := yaml.Marshal(h)
+	if err != nil {
+		return errors.Wrap(err, "cannot marshal audit history to yaml")
+	}
+	if err = e.fs.WriteFile(filepath.Join(e.root, AuditHistoryFile), b, 0600); err != nil {
+		return errors.Wrap(err, "cannot write audit history")
+	}
+	return nil
+}
+
+// ParseAuditLog reads audit events from r and returns the last limit
+// successful mutations of every resource of the given group resources.
+func ParseAuditLog(r io.Reader, groupResources []string, limit int) (*v1alpha1.AuditHistory, error) {
+	grs := make(map[string]struct{}, len(groupResources))
+	for _, gr := range groupResources {
+		grs[gr] = struct{}{}
+	}
+
+	h := &v1alpha1.AuditHistory{Resources: map[string][]v1alpha1.Mutation{}}
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 0, 64*1024), maxAuditLineSize)
+	for s.Scan() {
+		if len(s.Bytes()) == 0 {
+			continue
+		}
+		ev := auditEvent{}
+		if err := json.Unmarshal(s.Bytes(), &ev); err != nil {
+			return nil, errors.Wrap(err, "cannot unmarshal audit event")
+		}
+		if !isMutation(ev) {
+			continue
+		}
+		gr := schema.GroupResource{Group: ev.ObjectRef.APIGroup, Resource: ev.ObjectRef.Resource}.String()
+		if _, ok := grs[gr]; !ok {
+			continue
+		}
+
+		key := filepath.Join(gr, "cluster", ev.ObjectRef.Name)
+		if ev.ObjectRef.Namespace != "" {
+			key = filepath.Join(gr, "namespaces", ev.ObjectRef.Namespace, ev.ObjectRef.Name)
+		}
+		m := append(h.Resources[key], v1alpha1.Mutation{
+			Timestamp:   ev.StageTimestamp,
+			Verb:        ev.Verb,
+			User:        ev.User.Username,
+			Subresource: ev.ObjectRef.Subresource,
+			Code:        ev.ResponseStatus.Code,
+		})
+		if len(m) > limit {
+			m = m[len(m)-limit:]
+		}
+		h.Resources[key] = m
+	}
+	if err := s.Err(); err != nil {
+		return nil, errors.Wrap(err, "cannot read audit log")
+	}
+	return h, nil
+}
+
+func isMutation(ev auditEvent) bool {
+	if ev.Stage != "ResponseComplete" || ev.ObjectRef == nil || ev.ObjectRef.Name == "" {
+		return false
+	}
+	if ev.ResponseStatus == nil || ev.ResponseStatus.Code < 200 || ev.ResponseStatus.Code >= 300 {
+		return false
+	}
+	switch ev.Verb {
+	case "create", "update", "patch", "delete":
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/afero"
+	"sigs.k8s.io/yaml"
+
+	"github.com/upbound/up/pkg/migration/meta/v1alpha1"
+)
+
+const sampleAuditLog = `
+{"kind":"Event","apiVersion":"audit.k8s.io/v1","level":"Metadata","stage":"ResponseComplete","verb":"create","user":{"username":"alice"},"objectRef":{"resource":"compositions","name":"xdb","apiGroup":"apiextensions.crossplane.io","apiVersion":"v1"},"responseStatus":{"code":201},"stageTimestamp":"2024-01-01T10:00:00.000000Z"}
+{"kind":"Event","apiVersion":"audit.k8s.io/v1","level":"Metadata","stage":"ResponseStarted","verb":"watch","user":{"username":"crossplane"},"objectRef":{"resource":"compositions","apiGroup":"apiextensions.crossplane.io","apiVersion":"v1"},"stageTimestamp":"2024-01-01T10:00:01.000000Z"}
+{"kind":"Event","apiVersion":"audit.k8s.io/v1","level":"Metadata","stage":"ResponseComplete","verb":"get","user":{"username":"alice"},"objectRef":{"resource":"compositions","name":"xdb","apiGroup":"apiextensions.crossplane.io","apiVersion":"v1"},"responseStatus":{"code":200},"stageTimestamp":"2024-01-01T10:00:02.000000Z"}
+{"kind":"Event","apiVersion":"audit.k8s.io/v1","level":"Metadata","stage":"ResponseComplete","verb":"patch","user":{"username":"bob"},"objectRef":{"resource":"compositions","name":"xdb","apiGroup":"apiextensions.crossplane.io","apiVersion":"v1"},"responseStatus":{"code":409},"stageTimestamp":"2024-01-01T10:00:03.000000Z"}
+{"kind":"Event","apiVersion":"audit.k8s.io/v1","level":"Metadata","stage":"ResponseComplete","verb":"update","user":{"username":"bob"},"objectRef":{"resource":"compositions","name":"xdb","apiGroup":"apiextensions.crossplane.io","apiVersion":"v1"},"responseStatus":{"code":200},"stageTimestamp":"2024-01-01T10:00:04.000000Z"}
+{"kind":"Event","apiVersion":"audit.k8s.io/v1","level":"Metadata","stage":"ResponseComplete","verb":"update","user":{"username":"system:serviceaccount:crossplane-system:crossplane"},"objectRef":{"resource":"compositions","name":"xdb","apiGroup":"apiextensions.crossplane.io","apiVersion":"v1","subresource":"status"},"responseStatus":{"code":200},"stageTimestamp":"2024-01-01T10:00:05.000000Z"}
+{"kind":"Event","apiVersion":"audit.k8s.io/v1","level":"Metadata","stage":"ResponseComplete","verb":"update","user":{"username":"alice"},"objectRef":{"resource":"deployments","namespace":"default","name":"app","apiGroup":"apps","apiVersion":"v1"},"responseStatus":{"code":200},"stageTimestamp":"2024-01-01T10:00:06.000000Z"}
+{"kind":"Event","apiVersion":"audit.k8s.io/v1","level":"Metadata","stage":"ResponseComplete","verb":"patch","user":{"username":"carol"},"objectRef":{"resource":"databases","namespace":"team-a","name":"db","apiGroup":"example.org","apiVersion":"v1"},"responseStatus":{"code":200},"stageTimestamp":"2024-01-01T10:00:07.000000Z"}
+`
+
+func TestParseAuditLog(t *testing.T) {
+	ts := func(s string) time.Time {
+		t, _ := time.Parse(time.RFC3339, s)
+		return t
+	}
+
+	type args struct {
+		groupResources []string
+		limit          int
+	}
+	type want struct {
+		history *v1alpha1.AuditHistory
+	}
+
+	cases := map[string]struct {
+		args args
+		want want
+	}{
+		"ExtractMutations": {
+			args: args{
+				groupResources: []string{"compositions.apiextensions.crossplane.io", "databases.example.org"},
+				limit:          10,
+			},
+			want: want{
+				history: &v1alpha1.AuditHistory{Resources: map[string][]v1alpha1.Mutation{
+					"compositions.apiextensions.crossplane.io/cluster/xdb": {
+						{Timestamp: ts("2024-01-01T10:00:00Z"), Verb: "create", User: "alice", Code: 201},
+						{Timestamp: ts("2024-01-01T10:00:04Z"), Verb: "update", User: "bob", Code: 200},
+						{Timestamp: ts("2024-01-01T10:00:05Z"), Verb: "update", User: "system:serviceaccount:crossplane-system:crossplane", Subresource: "status", Code: 200},
+					},
+					"databases.example.org/namespaces/team-a/db": {
+						{Timestamp: ts("2024-01-01T10:00:07Z"), Verb: "patch", User: "carol", Code: 200},
+					},
+				}},
+			},
+		},
+		"KeepLastN": {
+			args: args{
+				groupResources: []string{"compositions.apiextensions.crossplane.io"},
+				limit:          1,
+			},
+			want: want{
+				history: &v1alpha1.AuditHistory{Resources: map[string][]v1alpha1.Mutation{
+					"compositions.apiextensions.crossplane.io/cluster/xdb": {
+						{Timestamp: ts("2024-01-01T10:00:05Z"), Verb: "update", User: "system:serviceaccount:crossplane-system:crossplane", Subresource: "status", Code: 200},
+					},
+				}},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := ParseAuditLog(strings.NewReader(sampleAuditLog), tc.args.groupResources, tc.args.limit)
+			if err != nil {
+				t.Fatalf("ParseAuditLog() unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.want.history, got); diff != "" {
+				t.Errorf("ParseAuditLog() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAuditLogExporterExportAuditHistory(t *testing.T) {
+	// The audit log is on disk, while the export is written to memory, as
+	// when migrating or copying a control plane.
+	logPath := filepath.Join(t.TempDir(), "audit.log")
+	if err := os.WriteFile(logPath, []byte(sampleAuditLog), 0600); err != nil {
+		t.Fatalf("cannot write audit log: %v", err)
+	}
+	fs := afero.Afero{Fs: afero.NewMemMapFs()}
+
+	e := NewAuditLogExporter(fs, "export", logPath)
+	if err := e.ExportAuditHistory(context.Background(), []string{"databases.example.org"}); err != nil {
+		t.Fatalf("ExportAuditHistory(...): unexpected error: %v", err)
+	}
+
+	b, err := fs.ReadFile(filepath.Join("export", AuditHistoryFile))
+	if err != nil {
+		t.Fatalf("cannot read audit history: %v", err)
+	}
+	got := &v1alpha1.AuditHistory{}
+	if err := yaml.Unmarshal(b, got); err != nil {
+		t.Fatalf("cannot unmarshal audit history: %v", err)
+	}
+	want := &v1alpha1.AuditHistory{Resources: map[string][]v1alpha1.Mutation{
+		"databases.example.org/namespaces/team-a/db": {
+			{Timestamp: time.Date(2024, 1, 1, 10, 0, 7, 0, time.UTC), Verb: "patch", User: "carol", Code: 200},
+		},
+	}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ExportAuditHistory(...): -want, +got:\n%s", diff)
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"sync"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// KindCompressionLevels selects the compression level of an export based on
+// the kinds of the exported resources. Resources are compressed together in
+// a single archive rather than file by file, so the highest level configured
+// for any exported kind is used.
+type KindCompressionLevels struct {
+	levels map[string]int
+
+	mu       sync.Mutex
+	level    int
+	observed bool
+}
+
+// NewKindCompressionLevels returns a KindCompressionLevels with the supplied
+// levels per kind, falling back to the default level if no resource of any
+// of these kinds is exported.
+func NewKindCompressionLevels(levels map[string]int, def int) *KindCompressionLevels {
+	return &KindCompressionLevels{
+		levels: levels,
+		level:  def,
+	}
+}
+
+// ObserveResources raises the compression level to the level of the kinds of
+// the supplied resources, if they are higher.
+func (k *KindCompressionLevels) ObserveResources(_ string, resources []unstructured.Unstructured) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	for i := range resources {
+		l, ok := k.levels[resources[i].GetKind()]
+		if !ok {
+			continue
+		}
+		if !k.observed || l > k.level {
+			k.level = l
+			k.observed = true
+		}
+	}
+}
+
+// Level returns the compression level to use for the observed resources.
+func (k *KindCompressionLevels) Level() int {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.level
+}
@@ -0,0 +1,96 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestKindCompressionLevels(t *testing.T) {
+	ofKind := func(kinds ...string) []unstructured.Unstructured {
+		us := make([]unstructured.Unstructured, 0, len(kinds))
+		for _, k := range kinds {
+			u := unstructured.Unstructured{}
+			u.SetKind(k)
+			us = append(us, u)
+		}
+		return us
+	}
+
+	type args struct {
+		levels map[string]int
+		def    int
+		// observed are the kinds of the resources observed per call.
+		observed [][]unstructured.Unstructured
+	}
+	type want struct {
+		level int
+	}
+
+	cases := map[string]struct {
+		args args
+		want want
+	}{
+		"NoKindExported": {
+			args: args{
+				levels:   map[string]int{"Secret": 9},
+				def:      6,
+				observed: [][]unstructured.Unstructured{ofKind("ConfigMap")},
+			},
+			want: want{
+				level: 6,
+			},
+		},
+		"SingleKind": {
+			args: args{
+				levels:   map[string]int{"Secret": 9, "ConfigMap": 3},
+				def:      6,
+				observed: [][]unstructured.Unstructured{ofKind("ConfigMap", "Composition")},
+			},
+			want: want{
+				// A configured level applies even if lower than the
+				// default.
+				level: 3,
+			},
+		},
+		"HighestKind": {
+			args: args{
+				levels: map[string]int{"Secret": 9, "ConfigMap": 3, "Composition": 5},
+				def:    1,
+				observed: [][]unstructured.Unstructured{
+					ofKind("ConfigMap"),
+					ofKind("Secret", "Secret"),
+					ofKind("Composition"),
+				},
+			},
+			want: want{
+				level: 9,
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			k := NewKindCompressionLevels(tc.args.levels, tc.args.def)
+			for _, us := range tc.args.observed {
+				k.ObserveResources("", us)
+			}
+			if got := k.Level(); got != tc.want.level {
+				t.Errorf("\n%s\nLevel(): want %d, got %d", name, tc.want.level, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"context"
+	"fmt"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+)
+
+// ConversionWebhookValidator checks that the conversion webhooks of CRDs with
+// multiple served versions are healthy. Resources of a type are stored in a
+// single version and converted by the webhook when read in any other version,
+// so an unhealthy webhook may leave exported resources in a non-standard
+// state.
+type ConversionWebhookValidator struct {
+	dynamicClient dynamic.Interface
+}
+
+// NewConversionWebhookValidator returns a new ConversionWebhookValidator.
+func NewConversionWebhookValidator(dyn dynamic.Interface) *ConversionWebhookValidator {
+	return &ConversionWebhookValidator{
+		dynamicClient: dyn,
+	}
+}
+
+// Validate reads a resource of every CRD with a conversion webhook in all of
+// its served versions, and returns a warning for every version that cannot be
+// read.
+func (v *ConversionWebhookValidator) Validate(ctx context.Context, crds []apiextensionsv1.CustomResourceDefinition) []error {
+	var errs []error
+	for _, crd := range crds {
+		if crd.Spec.Conversion == nil || crd.Spec.Conversion.Strategy != apiextensionsv1.WebhookConverter {
+			continue
+		}
+		served := make([]string, 0, len(crd.Spec.Versions))
+		for _, vr := range crd.Spec.Versions {
+			if vr.Served {
+				served = append(served, vr.Name)
+			}
+		}
+		if len(served) < 2 {
+			continue
+		}
+		for _, version := range served {
+			gvr := schema.GroupVersionResource{Group: crd.Spec.Group, Version: version, Resource: crd.Spec.Names.Plural}
+			if _, err := v.dynamicClient.Resource(gvr).List(ctx, v1.ListOptions{Limit: 1}); err != nil {
+				errs = append(errs, errors.Wrapf(err, "Conversion webhook of %q seems unhealthy, cannot read version %q, exported resources may be in a non-standard state", crd.GetName(), version))
+			}
+		}
+	}
+	return errs
+}
+
+// ConversionWarnings returns a warning for every CRD whose resources may not
+// be exported in the version they are stored in, i.e. CRDs with more than one
+// storage version, which is invalid but may be left by an in-progress version
+// migration, and CRDs converted by a webhook.
+func ConversionWarnings(crds []apiextensionsv1.CustomResourceDefinition) []string {
+	var warnings []string
+	for _, crd := range crds {
+		var storage []string
+		for _, vr := range crd.Spec.Versions {
+			if vr.Storage {
+				storage = append(storage, vr.Name)
+			}
+		}
+		if len(storage) > 1 {
+			warnings = append(warnings, fmt.Sprintf("CRD %q has multiple storage versions %v, resources are exported in version %q", crd.GetName(), storage, storage[len(storage)-1]))
+		}
+		if crd.Spec.Conversion != nil && crd.Spec.Conversion.Strategy == apiextensionsv1.WebhookConverter {
+			warnings = append(warnings, fmt.Sprintf("CRD %q is converted by a webhook, which must be available in the target control plane", crd.GetName()))
+		}
+	}
+	return warnings
+}
@@ -0,0 +1,158 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+)
+
+func TestConversionWebhookValidatorValidate(t *testing.T) {
+	errBoom := errors.New(`conversion webhook for s3.aws.upbound.io/v1beta1, Kind=Bucket failed: Post "https://provider-aws-s3.crossplane-system.svc:9443/convert": connection refused`)
+
+	crd := func(strategy apiextensionsv1.ConversionStrategyType, versions ...string) apiextensionsv1.CustomResourceDefinition {
+		c := apiextensionsv1.CustomResourceDefinition{}
+		c.SetName("buckets.s3.aws.upbound.io")
+		c.Spec.Group = "s3.aws.upbound.io"
+		c.Spec.Names.Plural = "buckets"
+		c.Spec.Conversion = &apiextensionsv1.CustomResourceConversion{Strategy: strategy}
+		for i, v := range versions {
+			c.Spec.Versions = append(c.Spec.Versions, apiextensionsv1.CustomResourceDefinitionVersion{Name: v, Served: true, Storage: i == 0})
+		}
+		return c
+	}
+
+	type args struct {
+		crd apiextensionsv1.CustomResourceDefinition
+		// unhealthy are the versions the conversion webhook fails to
+		// convert to.
+		unhealthy []string
+	}
+	type want struct {
+		errs []error
+	}
+
+	cases := map[string]struct {
+		args args
+		want want
+	}{
+		"Healthy": {
+			args: args{
+				crd: crd(apiextensionsv1.WebhookConverter, "v1beta1", "v1beta2"),
+			},
+			want: want{},
+		},
+		"Unhealthy": {
+			args: args{
+				crd:       crd(apiextensionsv1.WebhookConverter, "v1beta1", "v1beta2"),
+				unhealthy: []string{"v1beta2"},
+			},
+			want: want{
+				errs: []error{
+					errors.Wrap(errBoom, `Conversion webhook of "buckets.s3.aws.upbound.io" seems unhealthy, cannot read version "v1beta2", exported resources may be in a non-standard state`),
+				},
+			},
+		},
+		"NoWebhook": {
+			args: args{
+				crd:       crd(apiextensionsv1.NoneConverter, "v1beta1", "v1beta2"),
+				unhealthy: []string{"v1beta1", "v1beta2"},
+			},
+			want: want{},
+		},
+		"SingleVersion": {
+			args: args{
+				crd:       crd(apiextensionsv1.WebhookConverter, "v1beta1"),
+				unhealthy: []string{"v1beta1"},
+			},
+			want: want{},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			dyn := fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+				{Group: "s3.aws.upbound.io", Version: "v1beta1", Resource: "buckets"}: "BucketList",
+				{Group: "s3.aws.upbound.io", Version: "v1beta2", Resource: "buckets"}: "BucketList",
+			})
+			// The API server calls the conversion webhook when reading
+			// resources in a version other than the storage version.
+			dyn.PrependReactor("list", "buckets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				for _, v := range tc.args.unhealthy {
+					if action.GetResource().Version == v {
+						return true, nil, errBoom
+					}
+				}
+				return false, nil, nil
+			})
+
+			errs := NewConversionWebhookValidator(dyn).Validate(context.Background(), []apiextensionsv1.CustomResourceDefinition{tc.args.crd})
+			if diff := cmp.Diff(tc.want.errs, errs, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nValidate(...): -want, +got:\n%s", name, diff)
+			}
+		})
+	}
+}
+
+func TestConversionWarnings(t *testing.T) {
+	crd := func(name string, conversion apiextensionsv1.ConversionStrategyType, storage ...bool) apiextensionsv1.CustomResourceDefinition {
+		c := apiextensionsv1.CustomResourceDefinition{}
+		c.SetName(name)
+		c.Spec.Conversion = &apiextensionsv1.CustomResourceConversion{Strategy: conversion}
+		for i, s := range storage {
+			c.Spec.Versions = append(c.Spec.Versions, apiextensionsv1.CustomResourceDefinitionVersion{Name: fmt.Sprintf("v%d", i+1), Served: true, Storage: s})
+		}
+		return c
+	}
+
+	cases := map[string]struct {
+		reason string
+		crds   []apiextensionsv1.CustomResourceDefinition
+		want   []string
+	}{
+		"NoConversion": {
+			reason: "CRDs with a single storage version and no webhook should not be warned about.",
+			crds:   []apiextensionsv1.CustomResourceDefinition{crd("buckets.s3.aws.upbound.io", apiextensionsv1.NoneConverter, false, true)},
+		},
+		"MultipleStorageVersions": {
+			reason: "CRDs with multiple storage versions should be warned about.",
+			crds:   []apiextensionsv1.CustomResourceDefinition{crd("buckets.s3.aws.upbound.io", apiextensionsv1.NoneConverter, true, true)},
+			want:   []string{`CRD "buckets.s3.aws.upbound.io" has multiple storage versions [v1 v2], resources are exported in version "v2"`},
+		},
+		"Webhook": {
+			reason: "CRDs converted by a webhook should be warned about.",
+			crds:   []apiextensionsv1.CustomResourceDefinition{crd("buckets.s3.aws.upbound.io", apiextensionsv1.WebhookConverter, false, true)},
+			want:   []string{`CRD "buckets.s3.aws.upbound.io" is converted by a webhook, which must be available in the target control plane`},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := ConversionWarnings(tc.crds)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\nConversionWarnings(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
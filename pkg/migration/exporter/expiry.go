@@ -0,0 +1,99 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+)
+
+// defaultMigrationExpectedDuration is used if no expected migration duration
+// is configured.
+const defaultMigrationExpectedDuration = 2 * time.Hour
+
+// expiryAnnotations are the known names of annotations holding the time a
+// resource expires at, without an optional prefix.
+var expiryAnnotations = map[string]struct{}{
+	"expiry":          {},
+	"expiry-date":     {},
+	"expiry-time":     {},
+	"expires":         {},
+	"expires-at":      {},
+	"expiration":      {},
+	"expiration-date": {},
+	"expiration-time": {},
+}
+
+// ExpiryAnnotationChecker checks for resources expiring before a migration
+// is expected to complete.
+type ExpiryAnnotationChecker struct {
+	within time.Duration
+	now    func() time.Time
+}
+
+// NewExpiryAnnotationChecker returns a new ExpiryAnnotationChecker for
+// migrations expected to take the supplied duration.
+func NewExpiryAnnotationChecker(within time.Duration) *ExpiryAnnotationChecker {
+	return &ExpiryAnnotationChecker{
+		within: within,
+		now:    time.Now,
+	}
+}
+
+// Check returns an error for every resource with a known expiry annotation
+// holding an RFC 3339 timestamp within the expected migration duration.
+// Resources that already expired are reported as well.
+func (c *ExpiryAnnotationChecker) Check(resources []unstructured.Unstructured) []error {
+	var errs []error
+	for _, r := range resources {
+		errs = append(errs, c.CheckResource(r)...)
+	}
+	return errs
+}
+
+// CheckResource returns an error for every known expiry annotation of the
+// supplied resource holding an RFC 3339 timestamp within the expected
+// migration duration.
+func (c *ExpiryAnnotationChecker) CheckResource(r unstructured.Unstructured) []error {
+	deadline := c.now().Add(c.within)
+
+	var errs []error
+	for k, v := range r.GetAnnotations() {
+		name := k
+		if i := strings.LastIndex(k, "/"); i >= 0 {
+			name = k[i+1:]
+		}
+		if _, ok := expiryAnnotations[strings.ToLower(name)]; !ok {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil || t.After(deadline) {
+			continue
+		}
+		errs = append(errs, errors.Errorf("%s %q expires at %s according to annotation %q, before the migration is expected to complete within %s", r.GetKind(), resourceName(r), t.Format(time.RFC3339), k, c.within))
+	}
+	return errs
+}
+
+func resourceName(r unstructured.Unstructured) string {
+	if r.GetNamespace() == "" {
+		return r.GetName()
+	}
+	return r.GetNamespace() + "/" + r.GetName()
+}
@@ -0,0 +1,115 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+)
+
+func TestExpiryAnnotationCheckerCheck(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	resource := func(namespace, name string, annotations map[string]string) unstructured.Unstructured {
+		u := unstructured.Unstructured{}
+		u.SetKind("Secret")
+		u.SetNamespace(namespace)
+		u.SetName(name)
+		u.SetAnnotations(annotations)
+		return u
+	}
+
+	type args struct {
+		resources []unstructured.Unstructured
+	}
+	type want struct {
+		errs []error
+	}
+
+	cases := map[string]struct {
+		args args
+		want want
+	}{
+		"ExpiresWithinMigration": {
+			args: args{
+				resources: []unstructured.Unstructured{
+					resource("default", "token", map[string]string{"expiry": "2024-03-01T13:00:00Z"}),
+				},
+			},
+			want: want{
+				errs: []error{
+					errors.New(`Secret "default/token" expires at 2024-03-01T13:00:00Z according to annotation "expiry", before the migration is expected to complete within 2h0m0s`),
+				},
+			},
+		},
+		"PrefixedAnnotation": {
+			args: args{
+				resources: []unstructured.Unstructured{
+					resource("", "cert", map[string]string{"example.org/expires-at": "2024-03-01T12:30:00+00:00"}),
+				},
+			},
+			want: want{
+				errs: []error{
+					errors.New(`Secret "cert" expires at 2024-03-01T12:30:00Z according to annotation "example.org/expires-at", before the migration is expected to complete within 2h0m0s`),
+				},
+			},
+		},
+		"AlreadyExpired": {
+			args: args{
+				resources: []unstructured.Unstructured{
+					resource("default", "old", map[string]string{"expiration": "2024-01-01T00:00:00Z"}),
+				},
+			},
+			want: want{
+				errs: []error{
+					errors.New(`Secret "default/old" expires at 2024-01-01T00:00:00Z according to annotation "expiration", before the migration is expected to complete within 2h0m0s`),
+				},
+			},
+		},
+		"ExpiresAfterMigration": {
+			args: args{
+				resources: []unstructured.Unstructured{
+					resource("default", "token", map[string]string{"expiry": "2024-03-01T14:00:01Z"}),
+				},
+			},
+			want: want{},
+		},
+		"UnknownAnnotationOrFormat": {
+			args: args{
+				resources: []unstructured.Unstructured{
+					resource("default", "a", map[string]string{"created": "2024-03-01T13:00:00Z"}),
+					resource("default", "b", map[string]string{"expiry": "tomorrow"}),
+				},
+			},
+			want: want{},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			c := NewExpiryAnnotationChecker(2 * time.Hour)
+			c.now = func() time.Time { return now }
+
+			errs := c.Check(tc.args.resources)
+			if diff := cmp.Diff(tc.want.errs, errs, test.EquateErrors()); diff != "" {
+				t.Errorf("Check() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
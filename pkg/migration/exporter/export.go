@@ -15,16 +15,18 @@
 package exporter
 
 import (
-	"archive/tar"
-	"compress/gzip"
 	"context"
 	"io"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/pterm/pterm"
 	"github.com/spf13/afero"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/sync/errgroup"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	apiextensionsclientset "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
 	"k8s.io/apimachinery/pkg/api/meta"
@@ -32,33 +34,232 @@ import (
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
 	"k8s.io/client-go/dynamic"
 	appsv1 "k8s.io/client-go/kubernetes/typed/apps/v1"
+	"k8s.io/client-go/metadata"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
 
+	"github.com/upbound/up/pkg/migration"
+	"github.com/upbound/up/pkg/migration/archiver"
 	"github.com/upbound/up/pkg/migration/category"
+	"github.com/upbound/up/pkg/migration/crossplane"
 	"github.com/upbound/up/pkg/migration/meta/v1alpha1"
+	"github.com/upbound/up/pkg/migration/telemetry"
+	"github.com/upbound/up/pkg/migration/transform"
 
 	"github.com/crossplane/crossplane-runtime/pkg/errors"
 	xpmeta "github.com/crossplane/crossplane-runtime/pkg/meta"
 )
 
+// webhookConfigResources are the webhook configuration types exported if
+// Options.IncludeWebhookConfigs is set.
+var webhookConfigResources = []string{
+	"mutatingwebhookconfigurations.admissionregistration.k8s.io",
+	"validatingwebhookconfigurations.admissionregistration.k8s.io",
+}
+
+const (
+	errGlobalTimeout = "global timeout exceeded"
+
+	// telemetryShutdownTimeout bounds flushing pending spans after export.
+	telemetryShutdownTimeout = 10 * time.Second
+)
+
+// MaxParallelism is the maximum number of resource types exported
+// concurrently.
+const MaxParallelism = 20
+
+// OutputFormat is the format of an export.
+type OutputFormat string
+
+const (
+	// OutputFormatArchive exports to a compressed tar archive.
+	OutputFormatArchive OutputFormat = "archive"
+	// OutputFormatDirectory exports to a directory of plain YAML files.
+	OutputFormatDirectory OutputFormat = "directory"
+)
+
 // Options for the exporter.
 type Options struct {
-	// OutputArchive is the path to the archive file to be created.
+	// OutputArchive is the path to the archive file to be created. If empty or
+	// "-", the archive is written to OutputWriter instead. It is the path to
+	// the directory to be created if OutputFormat is OutputFormatDirectory.
 	OutputArchive string // default: xp-state.tar.gz
+	// OutputFormat is the format of the export.
+	OutputFormat OutputFormat // default: archive
+	// OutputWriter is where the archive is written to if OutputArchive is
+	// empty or "-".
+	OutputWriter io.Writer // default: os.Stdout
+	// OutputFS is the file system the export is written to. It is only
+	// supported with OutputFormatDirectory, e.g. to export to memory and
+	// import the directory without archiving it.
+	OutputFS afero.Fs // default: the local file system
+
+	// S3Bucket is the bucket of an S3-compatible object store the archive is
+	// streamed to instead of OutputArchive or OutputWriter. The base name of
+	// OutputArchive is used as the object name.
+	S3Bucket string // default: none
+	// S3Prefix is prepended to the name of the archive object.
+	S3Prefix string // default: none
+	// S3Region is the region of the S3 bucket.
+	S3Region string // default: from the AWS configuration
+	// S3Endpoint is the endpoint of an S3-compatible object store, like
+	// MinIO or GCS.
+	S3Endpoint string // default: AWS S3
+
+	// OutputOCIRef is the reference of an OCI artifact in a container
+	// registry, e.g. "registry.example.com/exports/prod:v1", the archive is
+	// pushed to as a single layer instead of writing it locally.
+	OutputOCIRef string // default: none
+
+	// CompressionAlgorithm is the algorithm the archive is compressed with,
+	// either "gzip" or "zstd". The matching extension is appended to
+	// OutputArchive if it has none.
+	CompressionAlgorithm archiver.Compression // default: gzip
+	// CompressionLevel is the algorithm specific compression level. Zero
+	// selects the default level of the algorithm.
+	CompressionLevel int // default: 0
+	// CompressionLevelByKind are the compression levels for resources of
+	// specific kinds, e.g. "Secret". The archive is compressed with the
+	// highest level of all exported kinds, or CompressionLevel if none of
+	// them are exported.
+	CompressionLevelByKind map[string]int // default: none
+	// EncryptionKey is a base64 encoded 32 byte key the archive is encrypted
+	// with using AES-256-GCM after being compressed. It must be supplied to
+	// import or verify the archive.
+	EncryptionKey string // default: none
+	// SplitSizeBytes splits the archive written to OutputArchive into
+	// numbered parts of at most this size, e.g. "xp-state.tar.gz.001", to
+	// stay within the upload size limits of object stores or CI artifact
+	// stores. The archive is not split if zero.
+	SplitSizeBytes int64 // default: 0
+
+	// SegmentByNamespace splits the export into one archive per namespace,
+	// named after the namespace, and one named "_cluster" for the cluster
+	// scoped resources, so that namespaces can be imported independently.
+	// OutputArchive is the directory the archives and a segment manifest are
+	// written to.
+	SegmentByNamespace bool // default: false
 
 	// Namespaces to include in the export. If not specified, all namespaces are included.
 	IncludeNamespaces []string // default: none
 	// Namespaces to exclude from the export.
 	ExcludeNamespaces []string // default: except kube-system, kube-public, kube-node-lease, local-path-storage
+	// ExcludeNamespacePatterns are glob patterns of namespaces to exclude
+	// from the export in path.Match syntax, e.g. "kube-*".
+	ExcludeNamespacePatterns []string // default: none
+	// IncludeHelmReleases are the names of Helm releases whose resources are
+	// exported, e.g. CRDs deliberately installed with Helm. Resources
+	// installed with Helm are not exported otherwise. The release secrets of
+	// these releases are not exported, only the resources they installed, as
+	// identified by their "meta.helm.sh/release-name" annotation.
+	IncludeHelmReleases []string // default: none
+
+	// MaxResourceSizeBytes is the size of a resource serialized to JSON above
+	// which it is not exported, e.g. to skip ConfigMaps with large embedded
+	// payloads. The skipped resources are listed in the export metadata.
+	// Zero means unlimited.
+	MaxResourceSizeBytes int64 // default: 0
+	// MaxTotalResources is the number of resources of all types to export
+	// above which the export fails before anything is written, e.g. to
+	// detect filters that accidentally include too much. The resources are
+	// counted regardless of namespace and selector filters. Zero means
+	// unlimited.
+	MaxTotalResources int // default: 0
+
+	// CompositionLabelSelector restricts the exported Crossplane types to the
+	// claims and composite resources defined by CompositeResourceDefinitions
+	// matching this label selector, e.g. "team=platform". All other Crossplane
+	// types, including packages, managed resources and compositions, are not
+	// exported if set. Extra resources are not affected.
+	CompositionLabelSelector string // default: none
 
 	// Extra resource types to include in the export.
 	IncludeExtraResources []string // default: namespaces, configmaps, secrets ( + all Crossplane resources)
 	// Resource types to exclude from the export.
 	ExcludeResources []string // default: none
+	// IncludeWebhookConfigs exports the mutating and validating webhook
+	// configurations, which providers often install without owning them by
+	// a CRD.
+	IncludeWebhookConfigs bool // default: false
 
 	// PauseBeforeExport pauses all managed resources before starting the export process.
 	PauseBeforeExport bool // default: false
+	// PauseTimeout is how long to wait for all managed resources to
+	// acknowledge the pause if PauseBeforeExport is set. The export does not
+	// wait if zero.
+	PauseTimeout time.Duration // default: none
+
+	// RespectPriorityClasses exports the types with resources in namespaces
+	// running higher priority pods first.
+	RespectPriorityClasses bool // default: false
+
+	// ContentAddressable stores each resource under a path derived from the
+	// SHA-256 hash of its content, together with a manifest mapping resource
+	// identities to hashes.
+	ContentAddressable bool // default: false
+
+	// RedactSecrets replaces the values of the data of exported Secrets with
+	// a placeholder, e.g. to share the structure of a control plane without
+	// its credentials. Redacted Secrets are annotated accordingly.
+	RedactSecrets bool // default: false
+
+	// ImageRewriteRules rewrite the package images of exported Providers,
+	// e.g. to install them from a different registry in the target control
+	// plane. The first matching rule is applied.
+	ImageRewriteRules []transform.ImageRewriteRule // default: none
+
+	// ChangedSince only exports resources created or modified at or after
+	// the given time, producing a differential export to be imported on top
+	// of a prior one. Deletions are not recorded.
+	ChangedSince *time.Time // default: none
+
+	// ExportAuditHistory includes the recent mutations of every exported
+	// Crossplane resource, read from the audit log at AuditLogPath.
+	ExportAuditHistory bool // default: false
+	// AuditLogPath is the path to the Kubernetes audit log of the control plane.
+	AuditLogPath string // default: none
+
+	// Fetch configures how resources are fetched from the control plane,
+	// e.g. how often transient errors are retried.
+	Fetch FetchOptions // default: no retries
+
+	// Parallelism is the number of resource types exported concurrently.
+	// Types are still started in order, e.g. by priority, but may complete
+	// out of order.
+	Parallelism int // default: 1, max: MaxParallelism
+
+	// TargetCrossplaneVersion is the Crossplane version of the control plane
+	// the export will be imported into. If set, the export fails if it is
+	// incompatible with the version of the source control plane.
+	TargetCrossplaneVersion string // default: none
+
+	// Quiet suppresses all progress output, e.g. for use in scripts.
+	Quiet bool // default: false
+	// ProgressPrinter reports the progress of the export, instead of
+	// progress bars. It is ignored if Quiet is set.
+	ProgressPrinter ProgressPrinter // default: progress bars
+
+	// Timeout is the global deadline for the export. Zero means no deadline.
+	Timeout time.Duration // default: none
+
+	// MigrationExpectedDuration is how long the whole migration is expected
+	// to take. Preflight checks warn about resources expiring within it.
+	MigrationExpectedDuration time.Duration // default: 2h
+
+	// SkipStorageCheck skips the preflight check whether the filesystem the
+	// export is staged on has enough space, e.g. if the available space is
+	// not reported reliably.
+	SkipStorageCheck bool // default: false
+
+	// OTELEndpoint is the OTLP gRPC endpoint to export traces of the export
+	// to. Tracing is disabled if empty.
+	OTELEndpoint string // default: none
+	// TracerProvider to trace the export with, instead of configuring one for
+	// OTELEndpoint.
+	TracerProvider trace.TracerProvider // default: none
 }
 
 // ControlPlaneStateExporter exports the state of a Crossplane control plane.
@@ -69,78 +270,273 @@ type ControlPlaneStateExporter struct {
 	appsClient      appsv1.AppsV1Interface
 	resourceMapper  meta.RESTMapper
 
+	// persistLocks are shared by the persisters of concurrently exported
+	// types.
+	persistLocks *pathLocks
+
+	// throttles counts the requests of the dynamic client throttled by the
+	// API server, if it records them.
+	throttles *ThrottleSummary
+
+	// metadataClient lists the metadata of resources, e.g. to find the
+	// namespaces of the resources of a type.
+	metadataClient metadata.Interface
+	// typePriorities are the priorities of the exported CRDs, computed once
+	// if types are exported by priority.
+	typePriorities map[string]int64
+
 	options Options
 }
 
+// ControlPlaneStateExporterOption configures a ControlPlaneStateExporter.
+type ControlPlaneStateExporterOption func(*ControlPlaneStateExporter)
+
+// WithMetadataClient configures the client the exporter lists the metadata of
+// resources with. It is required to export types by priority.
+func WithMetadataClient(c metadata.Interface) ControlPlaneStateExporterOption {
+	return func(e *ControlPlaneStateExporter) {
+		e.metadataClient = c
+	}
+}
+
+// WithThrottleSummary configures the exporter to print the supplied summary
+// of throttled requests after the export, if any were throttled. The dynamic
+// client must record them, e.g. by wrapping its transport with
+// ThrottleSummary.WrapTransport.
+func WithThrottleSummary(s *ThrottleSummary) ControlPlaneStateExporterOption {
+	return func(e *ControlPlaneStateExporter) {
+		e.throttles = s
+	}
+}
+
+// NewControlPlaneStateExporterForConfig returns a new
+// ControlPlaneStateExporter exporting the control plane the supplied REST
+// config connects to.
+func NewControlPlaneStateExporterForConfig(cfg *rest.Config, opts Options) (*ControlPlaneStateExporter, error) {
+	crdClient, err := apiextensionsclientset.NewForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	// Only the dynamic client, which fetches the exported resources, records
+	// and retries throttled requests.
+	throttles := NewThrottleSummary()
+	dcfg := rest.CopyConfig(cfg)
+	dcfg.Wrap(throttles.WrapTransport)
+	dynamicClient, err := dynamic.NewForConfig(dcfg)
+	if err != nil {
+		return nil, err
+	}
+	metadataClient, err := metadata.NewForConfig(dcfg)
+	if err != nil {
+		return nil, err
+	}
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	appsClient, err := appsv1.NewForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(discoveryClient))
+
+	return NewControlPlaneStateExporter(crdClient, dynamicClient, discoveryClient, appsClient, mapper, opts, WithThrottleSummary(throttles), WithMetadataClient(metadataClient)), nil
+}
+
 // NewControlPlaneStateExporter returns a new ControlPlaneStateExporter.
-func NewControlPlaneStateExporter(crdClient apiextensionsclientset.Interface, dynamicClient dynamic.Interface, discoveryClient discovery.DiscoveryInterface, appsClient appsv1.AppsV1Interface, mapper meta.RESTMapper, opts Options) *ControlPlaneStateExporter {
-	return &ControlPlaneStateExporter{
+func NewControlPlaneStateExporter(crdClient apiextensionsclientset.Interface, dynamicClient dynamic.Interface, discoveryClient discovery.DiscoveryInterface, appsClient appsv1.AppsV1Interface, mapper meta.RESTMapper, opts Options, eopts ...ControlPlaneStateExporterOption) *ControlPlaneStateExporter {
+	e := &ControlPlaneStateExporter{
 		crdClient:       crdClient,
 		dynamicClient:   dynamicClient,
 		discoveryClient: discoveryClient,
 		appsClient:      appsClient,
 		resourceMapper:  mapper,
 
+		persistLocks: newPathLocks(),
+
 		options: opts,
 	}
+	for _, o := range eopts {
+		o(e)
+	}
+	return e
 }
 
 // Export exports the state of the control plane.
-func (e *ControlPlaneStateExporter) Export(ctx context.Context) error { // nolint:gocyclo // This is the high level export command, so it's expected to be a bit complex.
+func (e *ControlPlaneStateExporter) Export(ctx context.Context) (err error) {
+	tp := e.options.TracerProvider
+	if tp == nil && e.options.OTELEndpoint != "" {
+		p, err := telemetry.NewTracerProvider(ctx, e.options.OTELEndpoint, telemetry.ResourceAttributes(ctx, e.dynamicClient, e.appsClient, e.options.OutputArchive)...)
+		if err != nil {
+			return errors.Wrap(err, "cannot configure telemetry")
+		}
+		defer func() {
+			sctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), telemetryShutdownTimeout)
+			defer cancel()
+			_ = p.Shutdown(sctx)
+		}()
+		tp = p
+	}
+	if tp != nil {
+		var span trace.Span
+		ctx, span = tp.Tracer(telemetry.TracerName).Start(ctx, "Export")
+		defer func() { telemetry.EndSpan(span, err) }()
+	}
+
+	if e.options.Timeout <= 0 {
+		return e.export(ctx)
+	}
+	ctx, cancel := context.WithTimeout(ctx, e.options.Timeout)
+	defer cancel()
+	err = e.export(ctx)
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return migration.WithCode(errors.Wrap(err, errGlobalTimeout), migration.ErrCodeTimeout)
+	}
+	return err
+}
+
+func (e *ControlPlaneStateExporter) export(ctx context.Context) error { // nolint:gocyclo // This is the high level export command, so it's expected to be a bit complex.
+
+	if err := validateNamespacePatterns(e.options.ExcludeNamespacePatterns); err != nil {
+		return err
+	}
+	if e.options.EncryptionKey != "" {
+		if e.options.OutputFormat == OutputFormatDirectory {
+			return errors.New("cannot encrypt an export to a directory")
+		}
+		if _, err := archiver.ParseEncryptionKey(e.options.EncryptionKey); err != nil {
+			return err
+		}
+	}
+
+	if e.options.TargetCrossplaneVersion != "" {
+		if err := e.checkTargetVersion(ctx); err != nil {
+			return err
+		}
+	}
+	if e.options.MaxTotalResources > 0 {
+		if err := e.checkTotalResources(ctx); err != nil {
+			return err
+		}
+	}
 
 	// TODO(turkenh): Check if we can use `afero.NewMemMapFs()` just like import and avoid the need for a temporary directory.
 	fs := afero.Afero{Fs: afero.NewOsFs()}
-	// We are using a temporary directory to store the exported state before
-	// archiving it. This temporary directory will be deleted after the archive
-	// is created.
-	tmpDir, err := fs.TempDir("", "up")
-	if err != nil {
-		return errors.Wrap(err, "cannot create temporary directory")
+	if e.options.OutputFS != nil {
+		if e.options.OutputFormat != OutputFormatDirectory {
+			return errors.New("an output file system is only supported when exporting to a directory")
+		}
+		fs.Fs = e.options.OutputFS
+	}
+	if e.options.SplitSizeBytes > 0 {
+		if err := validateSplitOptions(e.options); err != nil {
+			return err
+		}
+	}
+	if e.options.SegmentByNamespace {
+		if err := validateSegmentOptions(e.options); err != nil {
+			return err
+		}
+		if err := prepareOutputDirectory(fs, e.options.OutputArchive); err != nil {
+			return err
+		}
+	}
+	var dir string
+	if e.options.OutputFormat == OutputFormatDirectory {
+		// We are storing the exported state directly in the output directory,
+		// which is kept as is.
+		if e.options.S3Bucket != "" {
+			return errors.New("cannot export a directory to an S3 bucket")
+		}
+		if e.options.OutputOCIRef != "" {
+			return errors.New("cannot export a directory to an OCI artifact")
+		}
+		dir = e.options.OutputArchive
+		if err := prepareOutputDirectory(fs, dir); err != nil {
+			return err
+		}
+	} else {
+		// We are using a temporary directory to store the exported state before
+		// archiving it. This temporary directory will be deleted after the archive
+		// is created.
+		tmpDir, err := fs.TempDir("", "up")
+		if err != nil {
+			return errors.Wrap(err, "cannot create temporary directory")
+		}
+		defer func() {
+			_ = fs.RemoveAll(tmpDir)
+		}()
+		dir = tmpDir
 	}
-	defer func() {
-		_ = fs.RemoveAll(tmpDir)
-	}()
 
 	if e.options.PauseBeforeExport {
 		cm := category.NewAPICategoryModifier(e.dynamicClient, e.discoveryClient)
 
 		// Modify all managed resources to add the "crossplane.io/paused: true" annotation.
-		_, err := cm.ModifyResources(ctx, "managed", func(u *unstructured.Unstructured) error {
+		pctx, span := telemetry.StartSpan(ctx, "PauseManagedResources")
+		_, err := cm.ModifyResources(pctx, "managed", func(u *unstructured.Unstructured) error {
 			xpmeta.AddAnnotations(u, map[string]string{"crossplane.io/paused": "true"})
 			return nil
 		})
+		telemetry.EndSpan(span, err)
 		if err != nil {
 			return errors.Wrap(err, "cannot pause managed resources")
 		}
+
+		// Wait for the controllers of the managed resources to stop
+		// reconciling them.
+		if e.options.PauseTimeout > 0 {
+			wctx, span := telemetry.StartSpan(ctx, "WaitForPausedManagedResources")
+			err = NewPauseWaiter(e.dynamicClient, e.discoveryClient).Wait(wctx, e.options.PauseTimeout)
+			telemetry.EndSpan(span, err)
+			if err != nil {
+				return errors.Wrap(err, "cannot pause managed resources")
+			}
+		}
 	}
 
-	// Scan the control plane for types to export.
-	crdList, err := fetchAllCRDs(ctx, e.crdClient)
+	// Scan the control plane for types to export. The GVRs of the exported
+	// CRDs come first, followed by those of the native resources.
+	exportList, gvrs, err := e.exportableResources(ctx)
 	if err != nil {
-		return errors.Wrap(err, "cannot fetch CRDs")
-	}
-	exportList := make([]apiextensionsv1.CustomResourceDefinition, 0, len(crdList))
-	for _, crd := range crdList {
-		// We only want to export the following types:
-		// - Crossplane Core CRDs - Has suffix ".crossplane.io".
-		// - CRDs owned by Crossplane packages - Has owner reference to a Crossplane package.
-		// - CRDs owned by a CompositeResourceDefinition - Has owner reference to a CompositeResourceDefinition.
-		// - Included extra resources - Specified by the user.
-		if !e.shouldExport(crd) {
-			// Ignore CRDs that we don't want to export.
-			continue
-		}
-		exportList = append(exportList, crd)
+		return err
 	}
+	nativeGVRs := gvrs[len(exportList):]
 	//////////////////////
 
-	// Export Crossplane resources.
+	// Record which exported types depend on which other types, based on the
+	// CRDs and the owner references of the exported resources.
+	graph := NewDependencyGraphBuilder(e.resourceMapper)
+	observers := []ResourceObserver{graph}
+	// Owners are imported before the resources they own.
+	sorter := NewTopologicalExportSorter()
+	levels := NewKindCompressionLevels(e.options.CompressionLevelByKind, e.options.CompressionLevel)
+	if len(e.options.CompressionLevelByKind) > 0 {
+		observers = append(observers, levels)
+	}
+
+	// Export Crossplane resources, fanning out one type per worker.
+	parallelism := e.options.Parallelism
+	if parallelism < 1 {
+		parallelism = 1
+	}
+	if parallelism > MaxParallelism {
+		return errors.Errorf("parallelism must not exceed %d", MaxParallelism)
+	}
+	progress := e.progressPrinter()
+	progress.StartPhase("Exporting Crossplane resources", len(exportList))
+	var countsMu sync.Mutex
 	crCounts := make(map[string]int, len(exportList))
-	for _, crd := range exportList {
-		gvr, err := e.customResourceGVR(crd)
-		if err != nil {
-			return errors.Wrapf(err, "cannot get GVR for %q", crd.GetName())
-		}
+	// oversized are the resources skipped for exceeding the maximum size.
+	var oversized []string
+	// durations are the export durations per group resource.
+	durations := make(map[string]time.Duration, len(exportList))
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(parallelism)
+	for i, crd := range exportList {
+		graph.AddCRD(crd)
+		gvr := gvrs[i]
 
 		sub := false
 		for _, vr := range crd.Spec.Versions {
@@ -151,20 +547,38 @@ func (e *ControlPlaneStateExporter) Export(ctx context.Context) error { // nolin
 				break
 			}
 		}
+		fetcher := NewUnstructuredFetcher(e.dynamicClient, e.options)
 		exporter := NewUnstructuredExporter(
-			NewUnstructuredFetcher(e.dynamicClient, e.options),
-			NewFileSystemPersister(fs, tmpDir, &v1alpha1.TypeMeta{
-				Categories:            crd.Spec.Names.Categories,
+			fetcher,
+			NewFileSystemPersister(fs, dir, &v1alpha1.TypeMeta{
+				Categories:            typeCategories(crd),
 				WithStatusSubresource: sub,
-			}))
+			}, e.persisterOptions()...),
+			WithResourceObservers(observers...),
+			WithResourceSorter(sorter))
 
-		// ExportResource will fetch all resources of the given GVR and store them in the
-		// well-known directory structure.
-		count, err := exporter.ExportResources(ctx, gvr)
-		if err != nil {
-			return errors.Wrapf(err, "cannot export resources for %q", crd.GetName())
-		}
-		crCounts[gvr.GroupResource().String()] = count
+		name := crd.GetName()
+		g.Go(func() error {
+			// ExportResource will fetch all resources of the given GVR and store them in the
+			// well-known directory structure.
+			start := time.Now()
+			count, err := exporter.ExportResources(gctx, gvr)
+			if err != nil {
+				return errors.Wrapf(err, "cannot export resources for %q", name)
+			}
+			progress.TypeExported(gvr.GroupResource().String(), count)
+			countsMu.Lock()
+			defer countsMu.Unlock()
+			crCounts[gvr.GroupResource().String()] = count
+			durations[gvr.GroupResource().String()] = time.Since(start)
+			oversized = append(oversized, fetcher.OversizedResources()...)
+			return nil
+		})
+	}
+	err = g.Wait()
+	progress.StopPhase()
+	if err != nil {
+		return err
 	}
 
 	total := 0
@@ -174,49 +588,395 @@ func (e *ControlPlaneStateExporter) Export(ctx context.Context) error { // nolin
 	//////////////////////
 
 	// Export native resources.
-	nativeCounts := make(map[string]int, len(e.options.IncludeExtraResources))
+	nativeCounts := make(map[string]int, len(nativeGVRs))
+	progress.StartPhase("Exporting native resources", len(nativeGVRs))
+	defer progress.StopPhase()
 
 	// In addition to the Crossplane resources, we also need to export some native resources. These are
 	// defaulted as "namespaces", "configmaps" and "secrets". However, the user can also specify additional
 	// resources to include or exclude the default ones.
-	for r := range e.extraResources() {
-		gvr, err := e.resourceMapper.ResourceFor(schema.ParseGroupResource(r).WithVersion(""))
-		if err != nil {
-			return errors.Wrapf(err, "cannot get GVR for %q", r)
-		}
+	for _, gvr := range nativeGVRs {
+		fetcher := NewUnstructuredFetcher(e.dynamicClient, e.options)
 		exporter := NewUnstructuredExporter(
-			NewUnstructuredFetcher(e.dynamicClient, e.options),
-			NewFileSystemPersister(fs, tmpDir, nil))
+			fetcher,
+			NewFileSystemPersister(fs, dir, nil, e.persisterOptions()...),
+			WithResourceObservers(observers...),
+			WithResourceSorter(sorter))
 
+		start := time.Now()
 		count, err := exporter.ExportResources(ctx, gvr)
 		if err != nil {
-			return errors.Wrapf(err, "cannot export resources for %q", r)
+			return errors.Wrapf(err, "cannot export resources for %q", gvr.GroupResource())
 		}
 		nativeCounts[gvr.Resource] = count
+		durations[gvr.GroupResource().String()] = time.Since(start)
+		oversized = append(oversized, fetcher.OversizedResources()...)
+		progress.TypeExported(gvr.GroupResource().String(), count)
 	}
+	progress.StopPhase()
 	total = 0
 	for _, count := range nativeCounts {
 		total += count
 	}
 	//////////////////////
 
+	// Export the mutation history of the exported Crossplane resources, if requested.
+	if e.options.ExportAuditHistory {
+		grs := make([]string, 0, len(crCounts))
+		for gr := range crCounts {
+			grs = append(grs, gr)
+		}
+		ae := NewAuditLogExporter(fs, dir, e.options.AuditLogPath)
+		actx, span := telemetry.StartSpan(ctx, "ExportAuditHistory")
+		err = ae.ExportAuditHistory(actx, grs)
+		telemetry.EndSpan(span, err)
+		if err != nil {
+			return errors.Wrap(err, "cannot export audit history")
+		}
+	}
+	//////////////////////
+
+	// Export the dependency graph of the exported types.
+	if err = graph.Persist(fs, dir); err != nil {
+		return errors.Wrap(err, "cannot export dependency graph")
+	}
+	//////////////////////
+
 	// Export a top level metadata file. This file contains details like when the export was done,
 	// the version and feature flags of Crossplane and number of resources exported per type.
 	// This metadata file is used during import to determine if the import is compatible with the
 	// current Crossplane version and feature flags and also enables manual inspection the exported state.
-	me := NewPersistentMetadataExporter(e.appsClient, fs, tmpDir)
-	if err = me.ExportMetadata(ctx, e.options, nativeCounts, crCounts); err != nil {
+	me := NewPersistentMetadataExporter(e.appsClient, e.dynamicClient, fs, dir)
+	mctx, span := telemetry.StartSpan(ctx, "ExportMetadata")
+	err = me.ExportMetadata(mctx, e.options, nativeCounts, crCounts, durations, oversized, ConversionWarnings(exportList))
+	telemetry.EndSpan(span, err)
+	if err != nil {
 		return errors.Wrap(err, "cannot write export metadata")
 	}
 	//////////////////////
 
-	// Archive the exported state.
-	if err = e.archive(ctx, fs, tmpDir); err != nil {
-		return errors.Wrap(err, "cannot archive exported state")
+	// Archive the exported state, unless exporting to a directory.
+	switch {
+	case e.options.SegmentByNamespace:
+		actx, span := telemetry.StartSpan(ctx, "ArchiveSegments")
+		err = e.archiveSegments(actx, fs, dir, levels.Level())
+		telemetry.EndSpan(span, err)
+		if err != nil {
+			return errors.Wrap(err, "cannot archive exported state")
+		}
+	case e.options.OutputFormat != OutputFormatDirectory:
+		actx, span := telemetry.StartSpan(ctx, "Archive")
+		err = e.archive(actx, fs, dir, levels.Level())
+		telemetry.EndSpan(span, err)
+		if err != nil {
+			return errors.Wrap(err, "cannot archive exported state")
+		}
 	}
 	//////////////////////
 
-	pterm.Println("\nSuccessfully exported control plane state!")
+	if !e.options.Quiet {
+		if e.throttles != nil {
+			if err := e.throttles.Print(); err != nil {
+				return errors.Wrap(err, "cannot print throttle summary")
+			}
+		}
+		pterm.Println("\nSuccessfully exported control plane state!")
+	}
+	return nil
+}
+
+// PreflightChecks checks whether the control plane state can be exported and
+// migrated. All detected problems are returned.
+func (e *ControlPlaneStateExporter) PreflightChecks(ctx context.Context) []error { //nolint:gocyclo // Just a list of checks.
+	var errs []error
+	if err := e.checkOutputWritable(); err != nil {
+		errs = append(errs, err)
+	}
+	if _, err := e.discoveryClient.ServerVersion(); err != nil {
+		// All further checks would fail the same way.
+		return append(errs, errors.Wrap(err, "Cannot reach the API server"))
+	}
+	if xp, err := crossplane.CollectInfo(ctx, e.appsClient); err != nil {
+		errs = append(errs, errors.Wrap(err, "Cannot get Crossplane info"))
+	} else if xp.Namespace == "" {
+		errs = append(errs, errors.New("Cannot find the Crossplane deployment"))
+	}
+	if e.options.PauseBeforeExport {
+		managed, err := NewPauseWaiter(e.dynamicClient, e.discoveryClient).managedGVRs()
+		if err != nil {
+			errs = append(errs, errors.Wrap(err, "Cannot get managed resource types"))
+		} else {
+			errs = append(errs, NewRBACPreflightChecker(e.dynamicClient).CheckPause(ctx, managed)...)
+		}
+	}
+
+	within := e.options.MigrationExpectedDuration
+	if within <= 0 {
+		within = defaultMigrationExpectedDuration
+	}
+	expiry := NewExpiryAnnotationChecker(within)
+
+	crds, err := e.exportedCRDs(ctx)
+	if err != nil {
+		return append(errs, errors.Wrap(err, "Cannot get types to export"))
+	}
+	gvrs, err := e.exportedGVRs(crds)
+	if err != nil {
+		return append(errs, errors.Wrap(err, "Cannot get types to export"))
+	}
+	fetcher := NewUnstructuredFetcher(e.dynamicClient, e.options)
+
+	// Types the exporter is not allowed to read are reported once, instead of
+	// also failing to fetch them below.
+	readable, rerrs := NewRBACPreflightChecker(e.dynamicClient).Check(ctx, gvrs)
+	errs = append(errs, rerrs...)
+	errs = append(errs, NewConversionWebhookValidator(e.dynamicClient).Validate(ctx, crds)...)
+	total, cerrs := checkResources(ctx, fetcher, readable, expiry)
+	errs = append(errs, cerrs...)
+	if !e.options.SkipStorageCheck {
+		if err := NewStorageCapacityChecker(e.stagingDir()).Check(total); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// checkResources streams the resources of the supplied types, checking each
+// for expiry annotations as it is fetched, so that no type is kept in memory
+// as a whole. It returns the number of resources and the errors of the check.
+func checkResources(ctx context.Context, fetcher ResourceFetcher, gvrs []schema.GroupVersionResource, expiry *ExpiryAnnotationChecker) (int, []error) {
+	var errs []error
+	total := 0
+	for _, gvr := range gvrs {
+		err := fetcher.StreamResources(ctx, gvr, func(r unstructured.Unstructured) error {
+			total++
+			errs = append(errs, expiry.CheckResource(r)...)
+			return nil
+		})
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "Cannot fetch %q resources", gvr.GroupResource()))
+		}
+	}
+	return total, errs
+}
+
+// ListExportableResources returns the GVRs of all types that would be exported,
+// i.e. the custom resources of the exported CRDs followed by the native
+// resources, without fetching or writing any resources, e.g. to grant read
+// access to exactly these types.
+func (e *ControlPlaneStateExporter) ListExportableResources(ctx context.Context) ([]schema.GroupVersionResource, error) {
+	_, gvrs, err := e.exportableResources(ctx)
+	return gvrs, err
+}
+
+// exportableResources returns the CRDs of the types to export, ordered by
+// priority if requested, and the GVRs of all types to export. The GVRs of the
+// CRDs come first, in the same order.
+func (e *ControlPlaneStateExporter) exportableResources(ctx context.Context) ([]apiextensionsv1.CustomResourceDefinition, []schema.GroupVersionResource, error) {
+	crds, err := e.exportedCRDs(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	if e.options.RespectPriorityClasses {
+		if err = e.sortByPriority(ctx, crds); err != nil {
+			return nil, nil, errors.Wrap(err, "cannot sort types by priority")
+		}
+	}
+	gvrs, err := e.exportedGVRs(crds)
+	if err != nil {
+		return nil, nil, err
+	}
+	return crds, gvrs, nil
+}
+
+// exportedGVRs returns the GVRs of all types to export, i.e. the exported CRDs
+// and extra resources.
+func (e *ControlPlaneStateExporter) exportedGVRs(crds []apiextensionsv1.CustomResourceDefinition) ([]schema.GroupVersionResource, error) {
+	gvrs := make([]schema.GroupVersionResource, 0, len(crds)+len(e.options.IncludeExtraResources))
+	for _, crd := range crds {
+		gvr, err := e.customResourceGVR(crd)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot get GVR for %q", crd.GetName())
+		}
+		gvrs = append(gvrs, gvr)
+	}
+	for r := range e.extraResources() {
+		gvr, err := e.resourceMapper.ResourceFor(schema.ParseGroupResource(r).WithVersion(""))
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot get GVR for %q", r)
+		}
+		gvrs = append(gvrs, gvr)
+	}
+	return gvrs, nil
+}
+
+// exportedCRDs returns the CRDs of the types to export.
+func (e *ControlPlaneStateExporter) exportedCRDs(ctx context.Context) ([]apiextensionsv1.CustomResourceDefinition, error) {
+	fctx, span := telemetry.StartSpan(ctx, "FetchCRDs")
+	crdList, err := fetchAllCRDs(fctx, e.crdClient)
+	telemetry.EndSpan(span, err)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot fetch CRDs")
+	}
+	xrds, err := e.selectedXRDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	exportList := make([]apiextensionsv1.CustomResourceDefinition, 0, len(crdList))
+	for _, crd := range crdList {
+		// We only want to export the following types:
+		// - Crossplane Core CRDs - Has suffix ".crossplane.io".
+		// - CRDs owned by Crossplane packages - Has owner reference to a Crossplane package.
+		// - CRDs owned by a CompositeResourceDefinition - Has owner reference to a CompositeResourceDefinition.
+		// - Included extra resources - Specified by the user.
+		if !e.shouldExport(crd, xrds) {
+			// Ignore CRDs that we don't want to export.
+			continue
+		}
+		exportList = append(exportList, crd)
+	}
+	return exportList, nil
+}
+
+// checkTargetVersion checks that the Crossplane version of the source control
+// plane can be migrated to the target version. It warns about versions that
+// are compatible with warnings.
+func (e *ControlPlaneStateExporter) checkTargetVersion(ctx context.Context) error {
+	xp, err := crossplane.CollectInfo(ctx, e.appsClient)
+	if err != nil {
+		return errors.Wrap(err, "cannot get Crossplane info")
+	}
+	if xp.Version == "" {
+		return errors.New("cannot determine the Crossplane version of the source control plane")
+	}
+	res, err := crossplane.DefaultCompatibilityMatrix.Check(xp.Version, e.options.TargetCrossplaneVersion)
+	if err != nil {
+		return err
+	}
+	switch res.Result {
+	case crossplane.Incompatible:
+		return errors.Errorf("cannot migrate from Crossplane %s to %s: %s", res.Source, res.Target, res.Reason)
+	case crossplane.CompatibleWithWarnings:
+		pterm.Warning.Printfln("Migrating from Crossplane %s to %s: %s", res.Source, res.Target, res.Reason)
+	case crossplane.Compatible:
+	}
+	return nil
+}
+
+// checkTotalResources returns an error if the control plane has more
+// resources of the types to export than the maximum.
+func (e *ControlPlaneStateExporter) checkTotalResources(ctx context.Context) error {
+	gvrs, err := e.ListExportableResources(ctx)
+	if err != nil {
+		return errors.Wrap(err, "cannot get types to export")
+	}
+	total := int64(0)
+	for _, gvr := range gvrs {
+		// The API server reports the number of remaining items, so that
+		// counting needs a single item per type only.
+		l, err := e.dynamicClient.Resource(gvr).List(ctx, v1.ListOptions{Limit: 1})
+		if err != nil {
+			return errors.Wrapf(err, "cannot count %q resources", gvr.GroupResource())
+		}
+		total += int64(len(l.Items))
+		if l.GetRemainingItemCount() != nil {
+			total += *l.GetRemainingItemCount()
+		}
+	}
+	if total > int64(e.options.MaxTotalResources) {
+		return errors.Errorf("control plane has %d resources to export, which exceeds the maximum of %d", total, e.options.MaxTotalResources)
+	}
+	return nil
+}
+
+// archive archives the exported state in dir to the S3 bucket, the output
+// archive file or writer, compressed with the supplied level.
+func (e *ControlPlaneStateExporter) archive(ctx context.Context, fs afero.Afero, dir string, level int) error {
+	alg, opts, err := e.archiveOptions(level)
+	if err != nil {
+		return err
+	}
+
+	// The checksums are embedded in the archive, so that it can be verified
+	// after being downloaded.
+	if err := archiver.WriteChecksums(fs, dir); err != nil {
+		return err
+	}
+
+	if e.options.S3Bucket != "" && e.options.OutputOCIRef != "" {
+		return errors.New("cannot export to both an S3 bucket and an OCI artifact")
+	}
+	if e.options.S3Bucket != "" {
+		return e.uploadToS3(ctx, fs, dir, alg, opts...)
+	}
+	if e.options.OutputOCIRef != "" {
+		return e.pushToOCI(ctx, fs, dir, alg, opts...)
+	}
+	if path := e.options.OutputArchive; path != "" && path != "-" {
+		if filepath.Ext(path) == "" {
+			path += archiver.Extension(alg)
+		}
+		if e.options.SplitSizeBytes > 0 {
+			_, err := archiver.ArchiveSplitFiles(ctx, fs, dir, path, e.options.SplitSizeBytes, opts...)
+			return err
+		}
+		return archiver.ArchiveFile(ctx, fs, dir, path, opts...)
+	}
+	w := e.options.OutputWriter
+	if w == nil {
+		w = os.Stdout
+	}
+	return archiver.Archive(ctx, fs, dir, w, opts...)
+}
+
+// validateSplitOptions checks that the archive is written to a local file, as
+// only those can be split.
+func validateSplitOptions(o Options) error {
+	switch {
+	case o.OutputFormat == OutputFormatDirectory || o.SegmentByNamespace:
+		return errors.New("only archives can be split, not directories or segmented exports")
+	case o.S3Bucket != "" || o.OutputOCIRef != "":
+		return errors.New("archives uploaded to S3 or pushed as OCI artifacts cannot be split")
+	case o.OutputArchive == "" || o.OutputArchive == "-":
+		return errors.New("archives written to stdout cannot be split")
+	}
+	return nil
+}
+
+// archiveOptions returns the compression algorithm and the options of the
+// archives written with the supplied compression level.
+func (e *ControlPlaneStateExporter) archiveOptions(level int) (archiver.Compression, []archiver.ArchiveOption, error) {
+	alg := e.options.CompressionAlgorithm
+	if alg == "" {
+		alg = archiver.CompressionGzip
+	}
+	opts := []archiver.ArchiveOption{archiver.WithCompression(alg, level)}
+	if e.options.EncryptionKey != "" {
+		key, err := archiver.ParseEncryptionKey(e.options.EncryptionKey)
+		if err != nil {
+			return "", nil, err
+		}
+		opts = append(opts, archiver.WithEncryption(key))
+	}
+	return alg, opts, nil
+}
+
+// prepareOutputDirectory creates the output directory, which must either not
+// exist or be empty.
+func prepareOutputDirectory(fs afero.Afero, dir string) error {
+	if dir == "" || dir == "-" {
+		return errors.New("an output directory is required to export to a directory")
+	}
+	if err := fs.MkdirAll(dir, 0700); err != nil {
+		return errors.Wrapf(err, "cannot create output directory %q", dir)
+	}
+	empty, err := fs.IsEmpty(dir)
+	if err != nil {
+		return errors.Wrapf(err, "cannot read output directory %q", dir)
+	}
+	if !empty {
+		return errors.Errorf("output directory %q is not empty", dir)
+	}
 	return nil
 }
 
@@ -230,7 +990,17 @@ func (e *ControlPlaneStateExporter) IncludedExtraResource(gr string) bool {
 	return false
 }
 
-func (e *ControlPlaneStateExporter) shouldExport(in apiextensionsv1.CustomResourceDefinition) bool {
+func (e *ControlPlaneStateExporter) shouldExport(in apiextensionsv1.CustomResourceDefinition, xrds map[string]struct{}) bool {
+	if xrds != nil {
+		// Only types owned by a selected CompositeResourceDefinition.
+		for _, ref := range in.GetOwnerReferences() {
+			if _, ok := xrds[ref.Name]; ok && ref.APIVersion == "apiextensions.crossplane.io/v1" && ref.Kind == "CompositeResourceDefinition" {
+				return true
+			}
+		}
+		return false
+	}
+
 	for _, ref := range in.GetOwnerReferences() {
 		// Types owned by a Crossplane package.
 		if ref.APIVersion == "pkg.crossplane.io/v1" {
@@ -255,11 +1025,51 @@ func (e *ControlPlaneStateExporter) shouldExport(in apiextensionsv1.CustomResour
 	return e.IncludedExtraResource(in.GetName())
 }
 
+func (e *ControlPlaneStateExporter) progressPrinter() ProgressPrinter {
+	switch {
+	case e.options.Quiet:
+		return NopProgressPrinter{}
+	case e.options.ProgressPrinter != nil:
+		return e.options.ProgressPrinter
+	default:
+		return NewProgressBarPrinter()
+	}
+}
+
+// typeCategories returns the categories of the supplied CRD, including
+// category.CategoryObserved if it is labeled accordingly, so that the importer
+// can pause its resources.
+func typeCategories(crd apiextensionsv1.CustomResourceDefinition) []string {
+	if crd.GetLabels()[category.LabelObserved] != "true" {
+		return crd.Spec.Names.Categories
+	}
+	return append(append([]string(nil), crd.Spec.Names.Categories...), category.CategoryObserved)
+}
+
+func (e *ControlPlaneStateExporter) persisterOptions() []PersisterOption {
+	opts := []PersisterOption{withPathLocks(e.persistLocks)}
+	if e.options.ContentAddressable {
+		opts = append(opts, WithContentAddressable())
+	}
+	if e.options.RedactSecrets {
+		opts = append(opts, WithRedactedSecrets())
+	}
+	if len(e.options.ImageRewriteRules) > 0 {
+		opts = append(opts, WithImageRewrites(e.options.ImageRewriteRules))
+	}
+	return opts
+}
+
 func (e *ControlPlaneStateExporter) extraResources() map[string]struct{} {
 	extra := make(map[string]struct{}, len(e.options.IncludeExtraResources))
 	for _, r := range e.options.IncludeExtraResources {
 		extra[r] = struct{}{}
 	}
+	if e.options.IncludeWebhookConfigs {
+		for _, r := range webhookConfigResources {
+			extra[r] = struct{}{}
+		}
+	}
 
 	for _, r := range e.options.ExcludeResources {
 		delete(extra, r)
@@ -287,76 +1097,6 @@ func (e *ControlPlaneStateExporter) customResourceGVR(in apiextensionsv1.CustomR
 	return rm.Resource, nil
 }
 
-func (e *ControlPlaneStateExporter) archive(ctx context.Context, fs afero.Afero, dir string) error {
-	// Create the output file
-	out, err := fs.Create(e.options.OutputArchive)
-	if err != nil {
-		return err
-	}
-	defer out.Close()
-
-	// Apply the appropriate permissions to the output file
-	if err = fs.Chmod(e.options.OutputArchive, 0600); err != nil {
-		return err
-	}
-
-	// Create a new gzip writer
-	gw := gzip.NewWriter(out)
-	defer gw.Close()
-
-	// Create a new tar writer
-	tw := tar.NewWriter(gw)
-	defer tw.Close()
-
-	// Walk the directory and add each file to the tar archive
-	err = filepath.Walk(dir, func(file string, fi os.FileInfo, err error) error {
-		if ctx.Done() != nil {
-			return ctx.Err()
-		}
-
-		if err != nil {
-			return err
-		}
-
-		// Skip if it is a directory
-		if fi.IsDir() {
-			return nil
-		}
-
-		// Open the file
-		f, err := os.Open(file)
-		if err != nil {
-			return err
-		}
-		defer f.Close()
-
-		// Create a new tar header
-		header, err := tar.FileInfoHeader(fi, fi.Name())
-		if err != nil {
-			return err
-		}
-
-		// Write the header to the tar archive
-		if err := tw.WriteHeader(header); err != nil {
-			return err
-		}
-
-		// Copy the file data to the tar archive
-		if _, err := io.Copy(tw, f); err != nil {
-			return err
-		}
-
-		return nil
-	})
-
-	// Return any errors encountered while creating the archive
-	if err != nil {
-		return err
-	}
-
-	return nil
-}
-
 func fetchAllCRDs(ctx context.Context, kube apiextensionsclientset.Interface) ([]apiextensionsv1.CustomResourceDefinition, error) {
 	var crds []apiextensionsv1.CustomResourceDefinition
 
@@ -0,0 +1,829 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/afero"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	appsv1 "k8s.io/api/apps/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apiextensionsfake "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/fake"
+	"k8s.io/apimachinery/pkg/api/meta"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/upbound/up/pkg/migration/archiver"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+)
+
+func TestControlPlaneStateExporterTimeout(t *testing.T) {
+	type args struct {
+		timeout time.Duration
+	}
+	type want struct {
+		timeout bool
+	}
+
+	cases := map[string]struct {
+		args args
+		want want
+	}{
+		"NoTimeout": {
+			args: args{},
+			want: want{},
+		},
+		"TimeoutExceeded": {
+			args: args{
+				timeout: time.Nanosecond,
+			},
+			want: want{
+				timeout: true,
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			kube := kubefake.NewSimpleClientset()
+			e := NewControlPlaneStateExporter(
+				apiextensionsfake.NewSimpleClientset(),
+				dynamicfake.NewSimpleDynamicClient(runtime.NewScheme()),
+				kube.Discovery(),
+				kube.AppsV1(),
+				meta.NewDefaultRESTMapper(nil),
+				Options{
+					OutputArchive: filepath.Join(t.TempDir(), "xp-state.tar.gz"),
+					Timeout:       tc.args.timeout,
+				})
+
+			err := e.Export(context.Background())
+			if !tc.want.timeout {
+				if err != nil {
+					t.Fatalf("Export() unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.HasPrefix(err.Error(), errGlobalTimeout) {
+				t.Errorf("Export() error = %v, want %q", err, errGlobalTimeout)
+			}
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("Export() error = %v, want wrapped %v", err, context.DeadlineExceeded)
+			}
+		})
+	}
+}
+
+func TestControlPlaneStateExporterTracing(t *testing.T) {
+	ns := &unstructured.Unstructured{}
+	ns.SetAPIVersion("v1")
+	ns.SetKind("Namespace")
+	ns.SetName("default")
+
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{{Version: "v1"}})
+	mapper.Add(ns.GroupVersionKind(), meta.RESTScopeRoot)
+
+	kube := kubefake.NewSimpleClientset()
+	spans := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(spans))
+
+	e := NewControlPlaneStateExporter(
+		apiextensionsfake.NewSimpleClientset(),
+		dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), ns),
+		kube.Discovery(),
+		kube.AppsV1(),
+		mapper,
+		Options{
+			OutputArchive:         filepath.Join(t.TempDir(), "xp-state.tar.gz"),
+			IncludeExtraResources: []string{"namespaces"},
+			TracerProvider:        tp,
+		})
+	if err := e.Export(context.Background()); err != nil {
+		t.Fatalf("Export() unexpected error: %v", err)
+	}
+
+	got := spans.GetSpans()
+	names := make([]string, 0, len(got))
+	for _, s := range got {
+		names = append(names, s.Name)
+	}
+	want := []string{"FetchCRDs", "FetchResources", "PersistResources", "ExportMetadata", "Archive", "Export"}
+	if diff := cmp.Diff(want, names); diff != "" {
+		t.Errorf("spans mismatch (-want +got):\n%s", diff)
+	}
+
+	root := got[len(got)-1].SpanContext.SpanID()
+	for _, s := range got[:len(got)-1] {
+		if s.Parent.SpanID() != root {
+			t.Errorf("span %q is not a child of the %q span", s.Name, "Export")
+		}
+	}
+}
+
+func TestShouldExport(t *testing.T) {
+	crd := func(name string, owners ...v1.OwnerReference) apiextensionsv1.CustomResourceDefinition {
+		c := apiextensionsv1.CustomResourceDefinition{}
+		c.SetName(name)
+		c.SetOwnerReferences(owners)
+		return c
+	}
+
+	xrdOwner := func(name string) v1.OwnerReference {
+		return v1.OwnerReference{APIVersion: "apiextensions.crossplane.io/v1", Kind: "CompositeResourceDefinition", Name: name}
+	}
+	selected := map[string]struct{}{"xdatabases.example.org": {}}
+
+	cases := map[string]struct {
+		crd  apiextensionsv1.CustomResourceDefinition
+		xrds map[string]struct{}
+		want bool
+	}{
+		"EnvironmentConfigs": {
+			crd:  crd("environmentconfigs.apiextensions.crossplane.io"),
+			want: true,
+		},
+		"Compositions": {
+			crd:  crd("compositions.apiextensions.crossplane.io"),
+			want: true,
+		},
+		"ImageConfigs": {
+			crd:  crd("imageconfigs.pkg.crossplane.io"),
+			want: true,
+		},
+		"OwnedByProvider": {
+			crd:  crd("buckets.s3.aws.upbound.io", v1.OwnerReference{APIVersion: "pkg.crossplane.io/v1", Kind: "Provider"}),
+			want: true,
+		},
+		"OwnedByXRD": {
+			crd:  crd("xdatabases.example.org", v1.OwnerReference{APIVersion: "apiextensions.crossplane.io/v1", Kind: "CompositeResourceDefinition"}),
+			want: true,
+		},
+		"Unrelated": {
+			crd:  crd("certificates.cert-manager.io"),
+			want: false,
+		},
+		"OwnedBySelectedXRD": {
+			crd:  crd("xdatabases.example.org", xrdOwner("xdatabases.example.org")),
+			xrds: selected,
+			want: true,
+		},
+		"OwnedByUnselectedXRD": {
+			crd:  crd("xnetworks.example.org", xrdOwner("xnetworks.example.org")),
+			xrds: selected,
+			want: false,
+		},
+		"NotOwnedBySelectedXRD": {
+			crd:  crd("compositions.apiextensions.crossplane.io"),
+			xrds: selected,
+			want: false,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			e := NewControlPlaneStateExporter(nil, nil, nil, nil, nil, Options{})
+			if got := e.shouldExport(tc.crd, tc.xrds); got != tc.want {
+				t.Errorf("shouldExport(%q) = %t, want %t", tc.crd.GetName(), got, tc.want)
+			}
+		})
+	}
+}
+
+func TestControlPlaneStateExporterStream(t *testing.T) {
+	cases := map[string]struct {
+		output string
+	}{
+		"Dash": {
+			output: "-",
+		},
+		"Empty": {
+			output: "",
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			kube := kubefake.NewSimpleClientset()
+			out := &bytes.Buffer{}
+			e := NewControlPlaneStateExporter(
+				apiextensionsfake.NewSimpleClientset(),
+				dynamicfake.NewSimpleDynamicClient(runtime.NewScheme()),
+				kube.Discovery(),
+				kube.AppsV1(),
+				meta.NewDefaultRESTMapper(nil),
+				Options{
+					OutputArchive: tc.output,
+					OutputWriter:  out,
+				})
+			if err := e.Export(context.Background()); err != nil {
+				t.Fatalf("Export() unexpected error: %v", err)
+			}
+
+			fs := afero.Afero{Fs: afero.NewMemMapFs()}
+			if err := archiver.Unarchive(context.Background(), out, fs); err != nil {
+				t.Fatalf("cannot unarchive streamed export: %v", err)
+			}
+			if ok, _ := fs.Exists("export.yaml"); !ok {
+				t.Errorf("streamed export does not contain export metadata")
+			}
+			if ok, _ := fs.Exists(DependencyGraphFile); !ok {
+				t.Errorf("streamed export does not contain the dependency graph")
+			}
+		})
+	}
+}
+
+func TestControlPlaneStateExporterCompression(t *testing.T) {
+	cases := map[string]struct {
+		output      string
+		compression archiver.Compression
+		want        string
+	}{
+		"DefaultExtension": {
+			output: "xp-state",
+			want:   "xp-state.tar.gz",
+		},
+		"ZstdExtension": {
+			output:      "xp-state",
+			compression: archiver.CompressionZstd,
+			want:        "xp-state.tar.zst",
+		},
+		"KeepExtension": {
+			output:      "xp-state.tar.gz",
+			compression: archiver.CompressionZstd,
+			want:        "xp-state.tar.gz",
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			kube := kubefake.NewSimpleClientset()
+			e := NewControlPlaneStateExporter(
+				apiextensionsfake.NewSimpleClientset(),
+				dynamicfake.NewSimpleDynamicClient(runtime.NewScheme()),
+				kube.Discovery(),
+				kube.AppsV1(),
+				meta.NewDefaultRESTMapper(nil),
+				Options{
+					OutputArchive:        filepath.Join(dir, tc.output),
+					CompressionAlgorithm: tc.compression,
+				})
+			if err := e.Export(context.Background()); err != nil {
+				t.Fatalf("Export() unexpected error: %v", err)
+			}
+
+			fs := afero.Afero{Fs: afero.NewMemMapFs()}
+			if err := archiver.UnarchiveFile(context.Background(), filepath.Join(dir, tc.want), fs); err != nil {
+				t.Fatalf("cannot unarchive export %q: %v", tc.want, err)
+			}
+			if ok, _ := fs.Exists("export.yaml"); !ok {
+				t.Errorf("export does not contain export metadata")
+			}
+		})
+	}
+}
+
+// syntheticExporter returns an exporter of a control plane with the supplied
+// number of Crossplane types with one resource each, whose list calls take
+// the supplied latency.
+func syntheticExporter(t testing.TB, types int, latency time.Duration, opts Options) *ControlPlaneStateExporter {
+	t.Helper()
+	crds := make([]runtime.Object, 0, types)
+	objs := make([]runtime.Object, 0, types)
+	listKinds := make(map[schema.GroupVersionResource]string, types)
+	mapper := meta.NewDefaultRESTMapper(nil)
+	for i := 0; i < types; i++ {
+		kind := fmt.Sprintf("Type%d", i)
+		plural := strings.ToLower(kind) + "s"
+		crd := &apiextensionsv1.CustomResourceDefinition{
+			ObjectMeta: v1.ObjectMeta{Name: plural + ".example.crossplane.io"},
+			Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+				Group: "example.crossplane.io",
+				Names: apiextensionsv1.CustomResourceDefinitionNames{Kind: kind, Plural: plural},
+				Scope: apiextensionsv1.ClusterScoped,
+				Versions: []apiextensionsv1.CustomResourceDefinitionVersion{
+					{Name: "v1", Served: true, Storage: true},
+				},
+			},
+		}
+		crds = append(crds, crd)
+
+		gvk := schema.GroupVersionKind{Group: "example.crossplane.io", Version: "v1", Kind: kind}
+		mapper.Add(gvk, meta.RESTScopeRoot)
+		listKinds[gvk.GroupVersion().WithResource(plural)] = kind + "List"
+
+		u := &unstructured.Unstructured{}
+		u.SetGroupVersionKind(gvk)
+		u.SetName("example")
+		objs = append(objs, u)
+	}
+
+	// The reactors of the fake client run under a global lock, so the
+	// latency is added outside of it.
+	dyn := &slowDynamicClient{
+		Interface: dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds, objs...),
+		latency:   latency,
+	}
+
+	kube := kubefake.NewSimpleClientset()
+	return NewControlPlaneStateExporter(apiextensionsfake.NewSimpleClientset(crds...), dyn, kube.Discovery(), kube.AppsV1(), mapper, opts)
+}
+
+// slowDynamicClient is a dynamic client whose list calls take latency.
+type slowDynamicClient struct {
+	dynamic.Interface
+	latency time.Duration
+}
+
+func (c *slowDynamicClient) Resource(gvr schema.GroupVersionResource) dynamic.NamespaceableResourceInterface {
+	return &slowResourceClient{NamespaceableResourceInterface: c.Interface.Resource(gvr), latency: c.latency}
+}
+
+type slowResourceClient struct {
+	dynamic.NamespaceableResourceInterface
+	latency time.Duration
+}
+
+func (c *slowResourceClient) List(ctx context.Context, opts v1.ListOptions) (*unstructured.UnstructuredList, error) {
+	time.Sleep(c.latency)
+	return c.NamespaceableResourceInterface.List(ctx, opts)
+}
+
+func TestControlPlaneStateExporterParallelism(t *testing.T) {
+	cases := map[string]struct {
+		parallelism int
+		err         bool
+	}{
+		"Serial": {
+			parallelism: 1,
+		},
+		"Parallel": {
+			parallelism: 10,
+		},
+		"TooParallel": {
+			parallelism: MaxParallelism + 1,
+			err:         true,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "xp-state")
+			e := syntheticExporter(t, 30, 0, Options{
+				OutputArchive: dir,
+				OutputFormat:  OutputFormatDirectory,
+				Parallelism:   tc.parallelism,
+			})
+			err := e.Export(context.Background())
+			if tc.err {
+				if err == nil {
+					t.Fatal("Export() expected error, got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Export() unexpected error: %v", err)
+			}
+			for i := 0; i < 30; i++ {
+				f := filepath.Join(dir, fmt.Sprintf("type%ds.example.crossplane.io", i), "cluster", "example.yaml")
+				if _, err := os.Stat(f); err != nil {
+					t.Errorf("resource of type %d was not exported: %v", i, err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkControlPlaneStateExporterParallelism exports 30 types, whose list
+// calls take 10ms each, e.g. as against a remote API server. Exporting them
+// with 10 workers is expected to be several times faster than serially.
+func BenchmarkControlPlaneStateExporterParallelism(b *testing.B) {
+	for _, p := range []int{1, 5, 10, 20} {
+		b.Run(fmt.Sprintf("Parallelism%d", p), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				e := syntheticExporter(b, 30, 10*time.Millisecond, Options{
+					OutputArchive:         filepath.Join(b.TempDir(), "xp-state.tar.gz"),
+					IncludeExtraResources: []string{},
+					Parallelism:           p,
+				})
+				if err := e.Export(context.Background()); err != nil {
+					b.Fatalf("Export() unexpected error: %v", err)
+				}
+			}
+		})
+	}
+}
+
+// progressRecorder records the reported progress of an export.
+type progressRecorder struct {
+	mu     sync.Mutex
+	phase  string
+	phases map[string]int
+	types  map[string]map[string]int
+}
+
+func (r *progressRecorder) StartPhase(title string, types int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.phase = title
+	r.phases[title] = types
+	r.types[title] = map[string]int{}
+}
+
+func (r *progressRecorder) TypeExported(groupResource string, count int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.types[r.phase][groupResource] = count
+}
+
+func (r *progressRecorder) StopPhase() {}
+
+func TestControlPlaneStateExporterProgress(t *testing.T) {
+	type want struct {
+		phases map[string]int
+		types  map[string]map[string]int
+	}
+	cases := map[string]struct {
+		quiet bool
+		want  want
+	}{
+		"Progress": {
+			want: want{
+				phases: map[string]int{
+					"Exporting Crossplane resources": 2,
+					"Exporting native resources":     0,
+				},
+				types: map[string]map[string]int{
+					"Exporting Crossplane resources": {
+						"type0s.example.crossplane.io": 1,
+						"type1s.example.crossplane.io": 1,
+					},
+					"Exporting native resources": {},
+				},
+			},
+		},
+		"Quiet": {
+			quiet: true,
+			want: want{
+				phases: map[string]int{},
+				types:  map[string]map[string]int{},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			r := &progressRecorder{phases: map[string]int{}, types: map[string]map[string]int{}}
+			e := syntheticExporter(t, 2, 0, Options{
+				OutputArchive:         filepath.Join(t.TempDir(), "xp-state.tar.gz"),
+				IncludeExtraResources: []string{},
+				Parallelism:           2,
+				Quiet:                 tc.quiet,
+				ProgressPrinter:       r,
+			})
+			if err := e.Export(context.Background()); err != nil {
+				t.Fatalf("Export() unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.want.phases, r.phases); diff != "" {
+				t.Errorf("phases: -want, +got:\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.want.types, r.types); diff != "" {
+				t.Errorf("types: -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestControlPlaneStateExporterTargetCrossplaneVersion(t *testing.T) {
+	xp := &appsv1.Deployment{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "crossplane",
+			Namespace: "crossplane-system",
+			Labels:    map[string]string{"app.kubernetes.io/version": "1.14.5"},
+		},
+	}
+
+	cases := map[string]struct {
+		target string
+		err    bool
+	}{
+		"Compatible": {
+			target: "1.14.5",
+		},
+		"CompatibleWithWarnings": {
+			target: "1.15.0",
+		},
+		"Incompatible": {
+			target: "1.13.0",
+			err:    true,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			out := filepath.Join(t.TempDir(), "xp-state.tar.gz")
+			kube := kubefake.NewSimpleClientset(xp)
+			e := NewControlPlaneStateExporter(
+				apiextensionsfake.NewSimpleClientset(),
+				dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+					{Group: "pkg.crossplane.io", Version: "v1", Resource: "providers"}: "ProviderList",
+				}),
+				kube.Discovery(),
+				kube.AppsV1(),
+				meta.NewDefaultRESTMapper(nil),
+				Options{
+					OutputArchive:           out,
+					IncludeExtraResources:   []string{},
+					TargetCrossplaneVersion: tc.target,
+				})
+			err := e.Export(context.Background())
+			if (err != nil) != tc.err {
+				t.Fatalf("Export() error = %v, want error %t", err, tc.err)
+			}
+			_, serr := os.Stat(out)
+			if exported := serr == nil; exported == tc.err {
+				t.Errorf("Export() exported = %t, want %t", exported, !tc.err)
+			}
+		})
+	}
+}
+
+func TestControlPlaneStateExporterPreflightChecks(t *testing.T) {
+	xp := &appsv1.Deployment{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "crossplane",
+			Namespace: "crossplane-system",
+		},
+	}
+	buckets := schema.GroupVersionResource{Group: "s3.aws.upbound.io", Version: "v1beta1", Resource: "buckets"}
+
+	type args struct {
+		objects []runtime.Object
+		// notWritable makes the output path unwritable.
+		notWritable bool
+		pause       bool
+		// denied are the verbs denied on all types.
+		denied []string
+	}
+	type want struct {
+		// errs are substrings of the returned errors.
+		errs []string
+	}
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"AllPassed": {
+			reason: "No errors should be returned if all checks pass.",
+			args: args{
+				objects: []runtime.Object{xp},
+				pause:   true,
+			},
+		},
+		"AllFailed": {
+			reason: "All failed checks should be reported.",
+			args: args{
+				notWritable: true,
+				pause:       true,
+				denied:      []string{"update"},
+			},
+			want: want{
+				errs: []string{
+					"Cannot write the export to",
+					"Cannot find the Crossplane deployment",
+					`Missing permission to update "buckets.s3.aws.upbound.io"`,
+				},
+			},
+		},
+		"PauseNotChecked": {
+			reason: "Permissions to pause should not be checked without pausing.",
+			args: args{
+				objects: []runtime.Object{xp},
+				denied:  []string{"update"},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			out := filepath.Join(t.TempDir(), "xp-state.tar.gz")
+			if tc.args.notWritable {
+				if err := os.WriteFile(out, nil, 0o600); err != nil {
+					t.Fatal(err)
+				}
+				out = filepath.Join(out, "xp-state.tar.gz")
+			}
+			kube := kubefake.NewSimpleClientset(tc.args.objects...)
+			dis := &preferredDiscovery{
+				DiscoveryInterface: kube.Discovery(),
+				resources: []*v1.APIResourceList{{
+					GroupVersion: buckets.GroupVersion().String(),
+					APIResources: []v1.APIResource{{Name: "buckets", Kind: "Bucket", Categories: []string{"crossplane", "managed"}}},
+				}},
+			}
+			dyn := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+			dyn.PrependReactor("create", "selfsubjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				u := action.(k8stesting.CreateAction).GetObject().(*unstructured.Unstructured).DeepCopy()
+				verb, _, _ := unstructured.NestedString(u.Object, "spec", "resourceAttributes", "verb")
+				allowed := true
+				for _, v := range tc.args.denied {
+					if v == verb {
+						allowed = false
+					}
+				}
+				_ = unstructured.SetNestedField(u.Object, allowed, "status", "allowed")
+				return true, u, nil
+			})
+			e := NewControlPlaneStateExporter(
+				apiextensionsfake.NewSimpleClientset(),
+				dyn,
+				dis,
+				kube.AppsV1(),
+				meta.NewDefaultRESTMapper(nil),
+				Options{
+					OutputArchive:         out,
+					IncludeExtraResources: []string{},
+					PauseBeforeExport:     tc.args.pause,
+					SkipStorageCheck:      true,
+				})
+
+			errs := e.PreflightChecks(context.Background())
+			got := make([]string, len(errs))
+			for i, err := range errs {
+				got[i] = err.Error()
+			}
+			if len(got) != len(tc.want.errs) {
+				t.Fatalf("\n%s\nPreflightChecks(...): want errors %q, got %q", tc.reason, tc.want.errs, got)
+			}
+			for i := range got {
+				if !strings.Contains(got[i], tc.want.errs[i]) {
+					t.Errorf("\n%s\nPreflightChecks(...): want error %q, got %q", tc.reason, tc.want.errs[i], got[i])
+				}
+			}
+		})
+	}
+}
+
+func TestControlPlaneStateExporterListExportableResources(t *testing.T) {
+	crd := func(group, kind, plural string, owners ...v1.OwnerReference) runtime.Object {
+		return &apiextensionsv1.CustomResourceDefinition{
+			ObjectMeta: v1.ObjectMeta{Name: plural + "." + group, OwnerReferences: owners},
+			Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+				Group: group,
+				Names: apiextensionsv1.CustomResourceDefinitionNames{Kind: kind, Plural: plural},
+				Versions: []apiextensionsv1.CustomResourceDefinitionVersion{
+					{Name: "v1beta1", Served: true},
+					{Name: "v1", Served: true, Storage: true},
+				},
+			},
+		}
+	}
+	buckets := schema.GroupVersionResource{Group: "s3.aws.upbound.io", Version: "v1", Resource: "buckets"}
+	widgets := schema.GroupVersionResource{Group: "example.org", Version: "v1", Resource: "widgets"}
+	configMaps := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(buckets.GroupVersion().WithKind("Bucket"), meta.RESTScopeRoot)
+	mapper.Add(widgets.GroupVersion().WithKind("Widget"), meta.RESTScopeNamespace)
+	mapper.Add(configMaps.GroupVersion().WithKind("ConfigMap"), meta.RESTScopeNamespace)
+
+	kube := kubefake.NewSimpleClientset()
+	e := NewControlPlaneStateExporter(
+		apiextensionsfake.NewSimpleClientset(
+			crd("s3.aws.upbound.io", "Bucket", "buckets", v1.OwnerReference{APIVersion: "pkg.crossplane.io/v1", Kind: "Provider", Name: "provider-aws-s3"}),
+			crd("example.org", "Widget", "widgets"),
+		),
+		dynamicfake.NewSimpleDynamicClient(runtime.NewScheme()),
+		kube.Discovery(),
+		kube.AppsV1(),
+		mapper,
+		Options{
+			IncludeExtraResources: []string{"configmaps"},
+		})
+
+	got, err := e.ListExportableResources(context.Background())
+	if err != nil {
+		t.Fatalf("ListExportableResources(...): unexpected error: %v", err)
+	}
+	want := []schema.GroupVersionResource{buckets, configMaps}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ListExportableResources(...): -want, +got:\n%s", diff)
+	}
+}
+
+func TestControlPlaneStateExporterMaxTotalResources(t *testing.T) {
+	configMaps := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(configMaps.GroupVersion().WithKind("ConfigMap"), meta.RESTScopeNamespace)
+	cm := func(name string) runtime.Object {
+		u := &unstructured.Unstructured{}
+		u.SetAPIVersion("v1")
+		u.SetKind("ConfigMap")
+		u.SetNamespace("default")
+		u.SetName(name)
+		return u
+	}
+
+	cases := map[string]struct {
+		reason string
+		max    int
+		err    bool
+	}{
+		"Unlimited": {
+			reason: "The export should not be limited by default.",
+		},
+		"WithinMaximum": {
+			reason: "The export should succeed if the resources do not exceed the maximum.",
+			max:    2,
+		},
+		"ExceedsMaximum": {
+			reason: "The export should fail without writing anything if the resources exceed the maximum.",
+			max:    1,
+			err:    true,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "state")
+			kube := kubefake.NewSimpleClientset()
+			e := NewControlPlaneStateExporter(
+				apiextensionsfake.NewSimpleClientset(),
+				dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), cm("a"), cm("b")),
+				kube.Discovery(),
+				kube.AppsV1(),
+				mapper,
+				Options{
+					OutputArchive:         dir,
+					OutputFormat:          OutputFormatDirectory,
+					IncludeExtraResources: []string{"configmaps"},
+					MaxTotalResources:     tc.max,
+				})
+			err := e.Export(context.Background())
+			if diff := cmp.Diff(tc.err, err != nil); diff != "" {
+				t.Fatalf("\n%s\nExport(...): -want error, +got error:\n%s\n%v", tc.reason, diff, err)
+			}
+			_, statErr := os.Stat(dir)
+			if diff := cmp.Diff(!tc.err, statErr == nil); diff != "" {
+				t.Errorf("\n%s\nExport(...): -want written, +got written:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+// streamOnlyFetcher fails the test if a type is fetched as a whole, rather
+// than streamed.
+type streamOnlyFetcher struct {
+	staticFetcher
+	t *testing.T
+}
+
+func (f streamOnlyFetcher) FetchResources(_ context.Context, gvr schema.GroupVersionResource) ([]unstructured.Unstructured, error) {
+	f.t.Errorf("FetchResources(%q): resources should be streamed instead of loaded at once", gvr.GroupResource())
+	return nil, nil
+}
+
+func TestCheckResources(t *testing.T) {
+	expiring := unstructured.Unstructured{}
+	expiring.SetKind("Secret")
+	expiring.SetNamespace("default")
+	expiring.SetName("token")
+	expiring.SetAnnotations(map[string]string{"expires": time.Now().Add(time.Hour).Format(time.RFC3339)})
+	other := unstructured.Unstructured{}
+	other.SetKind("Secret")
+	other.SetNamespace("default")
+	other.SetName("other")
+
+	gvrs := []schema.GroupVersionResource{{Version: "v1", Resource: "secrets"}, {Version: "v1", Resource: "configmaps"}}
+	f := streamOnlyFetcher{staticFetcher: staticFetcher{expiring, other}, t: t}
+	total, errs := checkResources(context.Background(), f, gvrs, NewExpiryAnnotationChecker(2*time.Hour))
+	if diff := cmp.Diff(4, total); diff != "" {
+		t.Errorf("checkResources(...): -want, +got:\n%s", diff)
+	}
+	if diff := cmp.Diff(2, len(errs)); diff != "" {
+		t.Errorf("checkResources(...): -want, +got:\n%s", diff)
+	}
+}
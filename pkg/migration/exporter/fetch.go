@@ -16,23 +16,54 @@ package exporter
 
 import (
 	"context"
+	"path"
 	"strings"
+	"time"
 
+	"github.com/pterm/pterm"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/metadata"
+
+	"github.com/upbound/up/pkg/migration"
+	"github.com/upbound/up/pkg/migration/meta/v1alpha1"
 
 	"github.com/crossplane/crossplane-runtime/pkg/errors"
 	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
 )
 
 const (
-	defaultPageSize = 500
+	defaultPageSize     = 500
+	defaultRetryBackoff = time.Second
 )
 
+// FetchOptions configures how resources are fetched from the control plane.
+type FetchOptions struct {
+	// MaxRetries is how often listing a page of resources is retried after a
+	// transient error, i.e. the API server being unavailable or timing out.
+	MaxRetries int // default: 0
+	// RetryBackoff is the delay before the first retry. It is doubled for
+	// every further retry.
+	RetryBackoff time.Duration // default: 1s
+	// FieldSelector restricts the fetched resources of all types to those
+	// matching it, e.g. "metadata.name!=default". The fields are filtered by
+	// the API server, which rejects fields not supported by a type.
+	FieldSelector string // default: none
+	// LabelSelector restricts the fetched resources of all types to those
+	// matching it. Resources must match both selectors if both are set.
+	LabelSelector string // default: none
+}
+
 type ResourceFetcher interface {
 	FetchResources(ctx context.Context, gvr schema.GroupVersionResource) ([]unstructured.Unstructured, error)
+	// StreamResources calls fn with every fetched resource as soon as it is
+	// fetched, so that they need not be kept in memory. It stops at the
+	// first error returned by fn and returns it.
+	StreamResources(ctx context.Context, gvr schema.GroupVersionResource, fn func(unstructured.Unstructured) error) error
 }
 
 type UnstructuredFetcher struct {
@@ -41,6 +72,27 @@ type UnstructuredFetcher struct {
 
 	includedNamespaces map[string]struct{}
 	excludedNamespaces map[string]struct{}
+	// excludedNamespacePatterns are path.Match patterns of excluded
+	// namespaces.
+	excludedNamespacePatterns []string
+	// includedHelmReleases are the Helm releases whose resources are
+	// exported despite being managed by Helm.
+	includedHelmReleases map[string]struct{}
+
+	changedSince *time.Time
+
+	maxRetries   int
+	retryBackoff time.Duration
+
+	fieldSelector string
+	labelSelector string
+
+	// maxResourceSize is the size in bytes above which resources are not
+	// exported. Zero means unlimited.
+	maxResourceSize int64
+	// oversized are the resources that were not exported because they
+	// exceeded maxResourceSize.
+	oversized []string
 }
 
 func NewUnstructuredFetcher(kube dynamic.Interface, opts Options) *UnstructuredFetcher {
@@ -52,40 +104,248 @@ func NewUnstructuredFetcher(kube dynamic.Interface, opts Options) *UnstructuredF
 	for _, ns := range opts.ExcludeNamespaces {
 		exc[ns] = struct{}{}
 	}
+	rel := make(map[string]struct{}, len(opts.IncludeHelmReleases))
+	for _, r := range opts.IncludeHelmReleases {
+		rel[r] = struct{}{}
+	}
+	retryBackoff := opts.Fetch.RetryBackoff
+	if retryBackoff <= 0 {
+		retryBackoff = defaultRetryBackoff
+	}
 
 	return &UnstructuredFetcher{
 		kube:     kube,
 		pageSize: defaultPageSize,
 
-		includedNamespaces: inc,
-		excludedNamespaces: exc,
+		includedNamespaces:        inc,
+		excludedNamespaces:        exc,
+		excludedNamespacePatterns: opts.ExcludeNamespacePatterns,
+		includedHelmReleases:      rel,
+
+		changedSince: opts.ChangedSince,
+
+		maxRetries:   opts.Fetch.MaxRetries,
+		retryBackoff: retryBackoff,
+
+		fieldSelector: opts.Fetch.FieldSelector,
+		labelSelector: opts.Fetch.LabelSelector,
+
+		maxResourceSize: opts.MaxResourceSizeBytes,
 	}
 }
 
+// validateNamespacePatterns returns an error if any of the patterns is not a
+// valid path.Match pattern.
+func validateNamespacePatterns(patterns []string) error {
+	for _, p := range patterns {
+		if _, err := path.Match(p, ""); err != nil {
+			return errors.Wrapf(err, "invalid namespace pattern %q", p)
+		}
+	}
+	return nil
+}
+
 func (e *UnstructuredFetcher) FetchResources(ctx context.Context, gvr schema.GroupVersionResource) ([]unstructured.Unstructured, error) {
 	var resources []unstructured.Unstructured
+	err := e.StreamResources(ctx, gvr, func(r unstructured.Unstructured) error {
+		resources = append(resources, r)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resources, nil
+}
+
+// StreamResources calls fn with every resource of the supplied type that is
+// to be exported, page by page. Errors returned by fn are returned as is.
+func (e *UnstructuredFetcher) StreamResources(ctx context.Context, gvr schema.GroupVersionResource, fn func(unstructured.Unstructured) error) error {
+	var fnErr error
+	err := e.listAll(ctx, gvr, func(r unstructured.Unstructured) error {
+		if e.changedSince != nil && !changedSince(r, *e.changedSince) {
+			return nil
+		}
+		if e.shouldSkip(r) {
+			return nil
+		}
+		if size := resourceSize(r); e.tooLarge(size) {
+			id := path.Join(gvr.GroupResource().String(), r.GetNamespace(), r.GetName())
+			pterm.Warning.Printfln("Skipping %q of %d bytes, which exceeds the maximum resource size of %d bytes", id, size, e.maxResourceSize)
+			e.oversized = append(e.oversized, id)
+			return nil
+		}
+		fnErr = fn(r)
+		return fnErr
+	})
+	if fnErr != nil {
+		return fnErr
+	}
+	return migration.WithCode(err, migration.ErrCodeResourceFetchFailed)
+}
+
+// OversizedResources returns the resources that were not fetched because
+// they exceeded the maximum resource size, as
+// "<groupResource>/<namespace>/<name>", or "<groupResource>/<name>" for
+// cluster scoped resources.
+func (e *UnstructuredFetcher) OversizedResources() []string {
+	return e.oversized
+}
+
+// resourceSize returns the size of the resource serialized to JSON.
+func resourceSize(r unstructured.Unstructured) int64 {
+	b, err := r.MarshalJSON()
+	if err != nil {
+		// Resources read from the API server can always be serialized.
+		return 0
+	}
+	return int64(len(b))
+}
+
+func (e *UnstructuredFetcher) tooLarge(size int64) bool {
+	return e.maxResourceSize > 0 && size > e.maxResourceSize
+}
+
+// ResourceCounts are the numbers of resources of a type.
+type ResourceCounts struct {
+	// Total is the number of all resources of the type.
+	Total int
+	// Exported is the number of resources that would be exported.
+	Exported int
+	// Skipped is the number of resources skipped by the export rules, e.g.
+	// because they are in an excluded namespace, installed with Helm, or
+	// too large.
+	Skipped int
+}
+
+// CountResources counts the resources of the supplied type, and how many of
+// them would be exported or skipped, without keeping them.
+func (e *UnstructuredFetcher) CountResources(ctx context.Context, gvr schema.GroupVersionResource) (ResourceCounts, error) {
+	c := ResourceCounts{}
+	err := e.listAll(ctx, gvr, func(r unstructured.Unstructured) error {
+		c.Total++
+		switch {
+		case e.shouldSkip(r), e.tooLarge(resourceSize(r)):
+			c.Skipped++
+		case e.changedSince == nil || changedSince(r, *e.changedSince):
+			c.Exported++
+		}
+		return nil
+	})
+	return c, err
+}
 
+// listAll calls fn with every resource of the supplied type, listing them
+// page by page, until fn returns an error.
+func (e *UnstructuredFetcher) listAll(ctx context.Context, gvr schema.GroupVersionResource, fn func(r unstructured.Unstructured) error) error {
+	var l *unstructured.UnstructuredList
+	list := func(ctx context.Context, opts v1.ListOptions) (v1.ListInterface, error) {
+		var err error
+		l, err = e.kube.Resource(gvr).List(ctx, opts)
+		return l, err
+	}
+	return e.paginate(ctx, gvr.GroupResource(), list, func() error {
+		for _, r := range l.Items {
+			if err := fn(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// listMetadata calls fn with the metadata of every resource of the supplied
+// type, listing them page by page with the metadata client, until fn returns
+// an error. Only the metadata of the resources is transferred.
+func (e *UnstructuredFetcher) listMetadata(ctx context.Context, client metadata.Interface, gvr schema.GroupVersionResource, fn func(r v1.PartialObjectMetadata) error) error {
+	var l *v1.PartialObjectMetadataList
+	list := func(ctx context.Context, opts v1.ListOptions) (v1.ListInterface, error) {
+		var err error
+		l, err = client.Resource(gvr).List(ctx, opts)
+		return l, err
+	}
+	return e.paginate(ctx, gvr.GroupResource(), list, func() error {
+		for _, r := range l.Items {
+			if err := fn(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// paginate lists the resources of the supplied type page by page with list,
+// and calls page after every listed page, until page returns an error.
+func (e *UnstructuredFetcher) paginate(ctx context.Context, gr schema.GroupResource, list func(ctx context.Context, opts v1.ListOptions) (v1.ListInterface, error), page func() error) error {
 	continueToken := ""
 	for {
-		l, err := e.kube.Resource(gvr).List(ctx, v1.ListOptions{
-			Limit:    e.pageSize,
-			Continue: continueToken,
+		l, err := e.list(ctx, gr, list, v1.ListOptions{
+			Limit:         e.pageSize,
+			Continue:      continueToken,
+			FieldSelector: e.fieldSelector,
+			LabelSelector: e.labelSelector,
 		})
 		if err != nil {
-			return nil, errors.Wrapf(err, "cannot list %q resources", gvr.GroupResource())
+			return errors.Wrapf(err, "cannot list %q resources", gr)
 		}
-		for _, r := range l.Items {
-			if !e.shouldSkip(r) {
-				resources = append(resources, r)
-			}
+		if err := page(); err != nil {
+			return err
 		}
 		continueToken = l.GetContinue()
 		if continueToken == "" {
-			break
+			return nil
 		}
 	}
+}
 
-	return resources, nil
+// list lists a page of resources, retrying transient errors with exponential
+// backoff up to the configured number of retries.
+func (e *UnstructuredFetcher) list(ctx context.Context, gr schema.GroupResource, list func(ctx context.Context, opts v1.ListOptions) (v1.ListInterface, error), opts v1.ListOptions) (v1.ListInterface, error) {
+	var l v1.ListInterface
+	var lastErr error
+	retries := 0
+	delay := e.retryBackoff
+	backoff := wait.Backoff{Duration: e.retryBackoff, Factor: 2, Steps: e.maxRetries + 1}
+	err := wait.ExponentialBackoffWithContext(ctx, backoff, func(ctx context.Context) (bool, error) {
+		var err error
+		l, err = list(ctx, opts)
+		if err == nil {
+			return true, nil
+		}
+		if !isTransient(err) {
+			return false, err
+		}
+		lastErr = err
+		if retries < e.maxRetries {
+			retries++
+			pterm.Debug.Printfln("Retrying to list %q resources in %s (retry %d of %d): %v", gr, delay, retries, e.maxRetries, err)
+			delay *= 2
+		}
+		return false, nil
+	})
+	if wait.Interrupted(err) && lastErr != nil {
+		// All retries failed.
+		return nil, lastErr
+	}
+	return l, err
+}
+
+// isTransient returns true if listing resources may succeed if retried.
+func isTransient(err error) bool {
+	return kerrors.IsServiceUnavailable(err) || kerrors.IsServerTimeout(err)
+}
+
+// changedSince returns true if the resource was created or any of its fields
+// was last modified at or after the cutoff.
+func changedSince(r unstructured.Unstructured, cutoff time.Time) bool {
+	if !r.GetCreationTimestamp().Time.Before(cutoff) {
+		return true
+	}
+	for _, mf := range r.GetManagedFields() {
+		if mf.Time != nil && !mf.Time.Time.Before(cutoff) {
+			return true
+		}
+	}
+	return false
 }
 
 func (e *UnstructuredFetcher) namespaceInScope(namespace string) bool {
@@ -99,6 +359,13 @@ func (e *UnstructuredFetcher) namespaceInScope(namespace string) bool {
 		return false
 	}
 
+	for _, p := range e.excludedNamespacePatterns {
+		// Patterns are validated before exporting.
+		if ok, _ := path.Match(p, namespace); ok {
+			return false
+		}
+	}
+
 	return true
 }
 
@@ -111,12 +378,20 @@ func (e *UnstructuredFetcher) shouldSkip(r unstructured.Unstructured) bool { //
 		return true
 	}
 
+	if r.GetAnnotations()[v1alpha1.AnnotationSkip] == "true" {
+		// Explicitly marked as not to be migrated.
+		return true
+	}
+
 	if r.GetKind() == "ConfigMap" && r.GetName() == "kube-root-ca.crt" {
 		// This is cluster-specific and should not be exported.
 		return true
 	}
 
-	if r.GetLabels() != nil && r.GetLabels()["app.kubernetes.io/managed-by"] == "Helm" {
+	// Resources of included Helm releases are exported, even though they
+	// are managed by Helm.
+	_, included := e.includedHelmReleases[r.GetAnnotations()["meta.helm.sh/release-name"]]
+	if !included && r.GetLabels() != nil && r.GetLabels()["app.kubernetes.io/managed-by"] == "Helm" {
 		// We don't want to export Helm resources. They need to be installed
 		// to the target cluster again using Helm.
 		// A typical example is the TLS secrets for Crossplane.
@@ -15,17 +15,31 @@
 package exporter
 
 import (
+	"context"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
 )
 
 func TestUnstructuredFetcherShouldSkip(t *testing.T) {
 	type args struct {
-		includedNamespaces map[string]struct{}
-		excludedNamespaces map[string]struct{}
-		r                  unstructured.Unstructured
+		includedNamespaces        map[string]struct{}
+		excludedNamespaces        map[string]struct{}
+		excludedNamespacePatterns []string
+		includedHelmReleases      map[string]struct{}
+		r                         unstructured.Unstructured
 	}
 	type want struct {
 		skip bool
@@ -35,6 +49,44 @@ func TestUnstructuredFetcherShouldSkip(t *testing.T) {
 		args args
 		want want
 	}{
+		"SkipAnnotated": {
+			args: args{
+				r: unstructured.Unstructured{
+					Object: map[string]interface{}{
+						"kind": "ConfigMap",
+						"metadata": map[string]interface{}{
+							"name":      "scratch",
+							"namespace": "default",
+							"annotations": map[string]interface{}{
+								"migration.upbound.io/skip": "true",
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				skip: true,
+			},
+		},
+		"DontSkipAnnotatedFalse": {
+			args: args{
+				r: unstructured.Unstructured{
+					Object: map[string]interface{}{
+						"kind": "ConfigMap",
+						"metadata": map[string]interface{}{
+							"name":      "scratch",
+							"namespace": "default",
+							"annotations": map[string]interface{}{
+								"migration.upbound.io/skip": "false",
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				skip: false,
+			},
+		},
 		"SkipNonIncludedNamespaces": {
 			args: args{
 				includedNamespaces: map[string]struct{}{
@@ -107,6 +159,55 @@ func TestUnstructuredFetcherShouldSkip(t *testing.T) {
 				skip: false,
 			},
 		},
+		"SkipNamespacesMatchingExcludedPattern": {
+			args: args{
+				excludedNamespacePatterns: []string{"kube-*"},
+				r: unstructured.Unstructured{
+					Object: map[string]interface{}{
+						"kind": "Namespace",
+						"metadata": map[string]interface{}{
+							"name": "kube-system",
+						},
+					},
+				},
+			},
+			want: want{
+				skip: true,
+			},
+		},
+		"SkipNamespacedResourceMatchingExcludedPattern": {
+			args: args{
+				excludedNamespacePatterns: []string{"team-?", "kube-*"},
+				r: unstructured.Unstructured{
+					Object: map[string]interface{}{
+						"kind": "ConfigMap",
+						"metadata": map[string]interface{}{
+							"name":      "config",
+							"namespace": "kube-public",
+						},
+					},
+				},
+			},
+			want: want{
+				skip: true,
+			},
+		},
+		"DontSkipIfNotMatchingExcludedPattern": {
+			args: args{
+				excludedNamespacePatterns: []string{"kube-*"},
+				r: unstructured.Unstructured{
+					Object: map[string]interface{}{
+						"kind": "Namespace",
+						"metadata": map[string]interface{}{
+							"name": "crossplane-system",
+						},
+					},
+				},
+			},
+			want: want{
+				skip: false,
+			},
+		},
 
 		"SkipNonIncludedNamespacedResource": {
 			args: args{
@@ -215,6 +316,78 @@ func TestUnstructuredFetcherShouldSkip(t *testing.T) {
 			},
 		},
 
+		"DontSkipIncludedHelmRelease": {
+			args: args{
+				includedHelmReleases: map[string]struct{}{
+					"platform-crds": {},
+				},
+				r: unstructured.Unstructured{
+					Object: map[string]interface{}{
+						"kind": "CustomResourceDefinition",
+						"metadata": map[string]interface{}{
+							"labels": map[string]interface{}{
+								"app.kubernetes.io/managed-by": "Helm",
+							},
+							"annotations": map[string]interface{}{
+								"meta.helm.sh/release-name": "platform-crds",
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				skip: false,
+			},
+		},
+
+		"SkipHelmManagedOfOtherRelease": {
+			args: args{
+				includedHelmReleases: map[string]struct{}{
+					"platform-crds": {},
+				},
+				r: unstructured.Unstructured{
+					Object: map[string]interface{}{
+						"kind": "CustomResourceDefinition",
+						"metadata": map[string]interface{}{
+							"labels": map[string]interface{}{
+								"app.kubernetes.io/managed-by": "Helm",
+							},
+							"annotations": map[string]interface{}{
+								"meta.helm.sh/release-name": "crossplane",
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				skip: true,
+			},
+		},
+
+		"SkipHelmSecretOfIncludedRelease": {
+			args: args{
+				includedHelmReleases: map[string]struct{}{
+					"platform-crds": {},
+				},
+				r: unstructured.Unstructured{
+					Object: map[string]interface{}{
+						"kind": "Secret",
+						"type": "helm.sh/release.v1",
+						"metadata": map[string]interface{}{
+							"name": "sh.helm.release.v1.platform-crds.v1",
+							"labels": map[string]interface{}{
+								"name":  "platform-crds",
+								"owner": "helm",
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				skip: true,
+			},
+		},
+
 		"SkipHelmSecret": {
 			args: args{
 				r: unstructured.Unstructured{
@@ -279,8 +452,10 @@ func TestUnstructuredFetcherShouldSkip(t *testing.T) {
 	for name, tc := range cases {
 		t.Run(name, func(t *testing.T) {
 			e := &UnstructuredFetcher{
-				includedNamespaces: tc.args.includedNamespaces,
-				excludedNamespaces: tc.args.excludedNamespaces,
+				includedNamespaces:        tc.args.includedNamespaces,
+				excludedNamespaces:        tc.args.excludedNamespaces,
+				excludedNamespacePatterns: tc.args.excludedNamespacePatterns,
+				includedHelmReleases:      tc.args.includedHelmReleases,
 			}
 			if diff := cmp.Diff(e.shouldSkip(tc.args.r), tc.want.skip); diff != "" {
 				t.Errorf("shouldSkip() mismatch (-want +got):\n%s", diff)
@@ -288,3 +463,382 @@ func TestUnstructuredFetcherShouldSkip(t *testing.T) {
 		})
 	}
 }
+
+func TestUnstructuredFetcherChangedSince(t *testing.T) {
+	cutoff := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	configMap := func(name string, created time.Time, modified ...time.Time) runtime.Object {
+		u := &unstructured.Unstructured{}
+		u.SetAPIVersion("v1")
+		u.SetKind("ConfigMap")
+		u.SetNamespace("default")
+		u.SetName(name)
+		u.SetCreationTimestamp(metav1.NewTime(created))
+		mfs := make([]metav1.ManagedFieldsEntry, 0, len(modified))
+		for _, m := range modified {
+			mt := metav1.NewTime(m)
+			mfs = append(mfs, metav1.ManagedFieldsEntry{Manager: "test", Time: &mt})
+		}
+		u.SetManagedFields(mfs)
+		return u
+	}
+	objects := []runtime.Object{
+		configMap("unchanged", cutoff.Add(-2*time.Hour), cutoff.Add(-time.Hour)),
+		configMap("never-modified", cutoff.Add(-time.Hour)),
+		configMap("created-after", cutoff.Add(time.Minute)),
+		configMap("created-at", cutoff),
+		configMap("modified-after", cutoff.Add(-time.Hour), cutoff.Add(-30*time.Minute), cutoff.Add(time.Second)),
+	}
+	gvr := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+
+	type args struct {
+		changedSince *time.Time
+	}
+	type want struct {
+		names []string
+	}
+
+	cases := map[string]struct {
+		args args
+		want want
+	}{
+		"Full": {
+			args: args{},
+			want: want{
+				names: []string{"created-after", "created-at", "modified-after", "never-modified", "unchanged"},
+			},
+		},
+		"Differential": {
+			args: args{
+				changedSince: &cutoff,
+			},
+			want: want{
+				names: []string{"created-after", "created-at", "modified-after"},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			dyn := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{gvr: "ConfigMapList"}, objects...)
+			resources, err := NewUnstructuredFetcher(dyn, Options{ChangedSince: tc.args.changedSince}).FetchResources(context.Background(), gvr)
+			if err != nil {
+				t.Fatalf("FetchResources(...): unexpected error: %v", err)
+			}
+			names := make([]string, 0, len(resources))
+			for _, r := range resources {
+				names = append(names, r.GetName())
+			}
+			if diff := cmp.Diff(tc.want.names, names); diff != "" {
+				t.Errorf("\n%s\nFetchResources(...): -want, +got:\n%s", name, diff)
+			}
+		})
+	}
+}
+
+func TestUnstructuredFetcherMaxResourceSize(t *testing.T) {
+	configMap := func(name string, data string) runtime.Object {
+		u := &unstructured.Unstructured{}
+		u.SetAPIVersion("v1")
+		u.SetKind("ConfigMap")
+		u.SetNamespace("default")
+		u.SetName(name)
+		_ = unstructured.SetNestedField(u.Object, data, "data", "payload")
+		return u
+	}
+	objects := []runtime.Object{
+		configMap("small", "x"),
+		configMap("large", strings.Repeat("x", 1024)),
+	}
+	gvr := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+
+	type args struct {
+		maxSize int64
+	}
+	type want struct {
+		names     []string
+		oversized []string
+	}
+
+	cases := map[string]struct {
+		args args
+		want want
+	}{
+		"Unlimited": {
+			args: args{},
+			want: want{
+				names: []string{"large", "small"},
+			},
+		},
+		"Limited": {
+			args: args{
+				maxSize: 512,
+			},
+			want: want{
+				names:     []string{"small"},
+				oversized: []string{"configmaps/default/large"},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			dyn := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{gvr: "ConfigMapList"}, objects...)
+			f := NewUnstructuredFetcher(dyn, Options{MaxResourceSizeBytes: tc.args.maxSize})
+			resources, err := f.FetchResources(context.Background(), gvr)
+			if err != nil {
+				t.Fatalf("FetchResources(...): unexpected error: %v", err)
+			}
+			names := make([]string, 0, len(resources))
+			for _, r := range resources {
+				names = append(names, r.GetName())
+			}
+			if diff := cmp.Diff(tc.want.names, names); diff != "" {
+				t.Errorf("\n%s\nFetchResources(...): -want, +got:\n%s", name, diff)
+			}
+			if diff := cmp.Diff(tc.want.oversized, f.OversizedResources()); diff != "" {
+				t.Errorf("\n%s\nOversizedResources(): -want, +got:\n%s", name, diff)
+			}
+		})
+	}
+}
+
+func TestValidateNamespacePatterns(t *testing.T) {
+	cases := map[string]struct {
+		patterns []string
+		wantErr  bool
+	}{
+		"Valid": {
+			patterns: []string{"kube-*", "team-?", "tenant-[a-c]"},
+		},
+		"Invalid": {
+			patterns: []string{"kube-*", "tenant-["},
+			wantErr:  true,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := validateNamespacePatterns(tc.patterns)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("\n%s\nvalidateNamespacePatterns(...): want error %t, got %v", name, tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestUnstructuredFetcherRetries(t *testing.T) {
+	gvr := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	cm := &unstructured.Unstructured{}
+	cm.SetAPIVersion("v1")
+	cm.SetKind("ConfigMap")
+	cm.SetNamespace("default")
+	cm.SetName("settings")
+
+	type args struct {
+		failures   int
+		err        error
+		maxRetries int
+	}
+	type want struct {
+		calls int
+		names []string
+		err   bool
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"RecoveredFromUnavailable": {
+			reason: "Listing should be retried while the API server is unavailable.",
+			args: args{
+				failures:   2,
+				err:        kerrors.NewServiceUnavailable("etcd leader election"),
+				maxRetries: 3,
+			},
+			want: want{
+				calls: 3,
+				names: []string{"settings"},
+			},
+		},
+		"RecoveredFromTimeout": {
+			reason: "Listing should be retried when the API server times out.",
+			args: args{
+				failures:   1,
+				err:        kerrors.NewServerTimeout(gvr.GroupResource(), "list", 1),
+				maxRetries: 1,
+			},
+			want: want{
+				calls: 2,
+				names: []string{"settings"},
+			},
+		},
+		"RetriesExhausted": {
+			reason: "The last transient error should be returned once all retries failed.",
+			args: args{
+				failures:   3,
+				err:        kerrors.NewServiceUnavailable("etcd leader election"),
+				maxRetries: 2,
+			},
+			want: want{
+				calls: 3,
+				err:   true,
+			},
+		},
+		"NotTransient": {
+			reason: "Errors that are not transient should not be retried.",
+			args: args{
+				failures:   1,
+				err:        kerrors.NewForbidden(gvr.GroupResource(), "", nil),
+				maxRetries: 3,
+			},
+			want: want{
+				calls: 1,
+				err:   true,
+			},
+		},
+		"NoRetries": {
+			reason: "Transient errors should not be retried by default.",
+			args: args{
+				failures: 1,
+				err:      kerrors.NewServiceUnavailable("etcd leader election"),
+			},
+			want: want{
+				calls: 1,
+				err:   true,
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			dyn := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{gvr: "ConfigMapList"}, cm.DeepCopy())
+			calls := 0
+			dyn.PrependReactor("list", "configmaps", func(_ k8stesting.Action) (bool, runtime.Object, error) {
+				calls++
+				if calls <= tc.args.failures {
+					return true, nil, tc.args.err
+				}
+				return false, nil, nil
+			})
+
+			f := NewUnstructuredFetcher(dyn, Options{Fetch: FetchOptions{MaxRetries: tc.args.maxRetries, RetryBackoff: time.Millisecond}})
+			resources, err := f.FetchResources(context.Background(), gvr)
+			if (err != nil) != tc.want.err {
+				t.Fatalf("\n%s\nFetchResources(...): want error %t, got %v", tc.reason, tc.want.err, err)
+			}
+			var names []string
+			for _, r := range resources {
+				names = append(names, r.GetName())
+			}
+			if diff := cmp.Diff(tc.want.names, names); diff != "" {
+				t.Errorf("\n%s\nFetchResources(...): -want, +got:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.calls, calls); diff != "" {
+				t.Errorf("\n%s\nFetchResources(...): list calls: -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestUnstructuredFetcherSelectors(t *testing.T) {
+	gvr := schema.GroupVersionResource{Version: "v1", Resource: "secrets"}
+
+	type want struct {
+		fields string
+		labels string
+	}
+	cases := map[string]struct {
+		reason string
+		opts   FetchOptions
+		want   want
+	}{
+		"None": {
+			reason: "All resources should be listed without selectors.",
+			want: want{
+				fields: "",
+				labels: "",
+			},
+		},
+		"FieldSelector": {
+			reason: "The field selector should be forwarded to the API server.",
+			opts:   FetchOptions{FieldSelector: "type=kubernetes.io/tls"},
+			want: want{
+				fields: "type=kubernetes.io/tls",
+			},
+		},
+		"BothSelectors": {
+			reason: "Both selectors should be forwarded, so that resources must match both.",
+			opts:   FetchOptions{FieldSelector: "type=kubernetes.io/tls", LabelSelector: "app=web"},
+			want: want{
+				fields: "type=kubernetes.io/tls",
+				labels: "app=web",
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			dyn := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{gvr: "SecretList"})
+			var got want
+			dyn.PrependReactor("list", "secrets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				r := action.(k8stesting.ListAction).GetListRestrictions()
+				got = want{fields: r.Fields.String(), labels: r.Labels.String()}
+				return false, nil, nil
+			})
+
+			f := NewUnstructuredFetcher(dyn, Options{Fetch: tc.opts})
+			if _, err := f.FetchResources(context.Background(), gvr); err != nil {
+				t.Fatalf("\n%s\nFetchResources(...): unexpected error: %v", tc.reason, err)
+			}
+			if diff := cmp.Diff(tc.want, got, cmp.AllowUnexported(want{})); diff != "" {
+				t.Errorf("\n%s\nFetchResources(...): list restrictions: -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestUnstructuredFetcherStreamResources(t *testing.T) {
+	gvr := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	configMap := func(name string) runtime.Object {
+		u := &unstructured.Unstructured{}
+		u.SetAPIVersion("v1")
+		u.SetKind("ConfigMap")
+		u.SetNamespace("default")
+		u.SetName(name)
+		return u
+	}
+	errBoom := errors.New("boom")
+
+	type want struct {
+		names []string
+		err   error
+	}
+	cases := map[string]struct {
+		reason string
+		fnErr  error
+		want   want
+	}{
+		"AllResources": {
+			reason: "Every resource should be passed to the function.",
+			want:   want{names: []string{"a", "b", "c"}},
+		},
+		"FunctionError": {
+			reason: "Streaming should stop at the first error of the function, which should be returned as is.",
+			fnErr:  errBoom,
+			want:   want{names: []string{"a"}, err: errBoom},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			dyn := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{gvr: "ConfigMapList"}, configMap("a"), configMap("b"), configMap("c"))
+			var names []string
+			err := NewUnstructuredFetcher(dyn, Options{}).StreamResources(context.Background(), gvr, func(r unstructured.Unstructured) error {
+				names = append(names, r.GetName())
+				return tc.fnErr
+			})
+			if diff := cmp.Diff(tc.want.err, err, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nStreamResources(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.names, names); diff != "" {
+				t.Errorf("\n%s\nStreamResources(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,226 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/spf13/afero"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/upbound/up/pkg/migration/meta/v1alpha1"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+)
+
+// DependencyGraphFile is the name of the file holding the dependency graph of
+// the exported types, at the root of the export.
+const DependencyGraphFile = "dependency-graph.json"
+
+const (
+	compositionsGR                 = "compositions.apiextensions.crossplane.io"
+	compositeResourceDefinitionsGR = "compositeresourcedefinitions.apiextensions.crossplane.io"
+)
+
+// Reasons for dependencies between types.
+const (
+	ReasonProviderConfig              = "ProviderConfig"
+	ReasonComposition                 = "Composition"
+	ReasonCompositeResource           = "CompositeResource"
+	ReasonCompositeResourceDefinition = "CompositeResourceDefinition"
+	ReasonPackage                     = "Package"
+	ReasonOwnerReference              = "OwnerReference"
+)
+
+// ResourceObserver observes the resources fetched for export, before cluster
+// specific data is removed from them.
+type ResourceObserver interface {
+	ObserveResources(groupResource string, resources []unstructured.Unstructured)
+}
+
+// exportedType is a type known to the DependencyGraphBuilder.
+type exportedType struct {
+	group      string
+	resource   string
+	categories []string
+	// xrd is the name of the CompositeResourceDefinition owning the type.
+	xrd string
+}
+
+// DependencyGraphBuilder builds the dependency graph of the exported types
+// from their CRDs and the owner references of their resources.
+type DependencyGraphBuilder struct {
+	mapper meta.RESTMapper
+
+	mu    sync.Mutex
+	types map[string]exportedType
+	deps  map[v1alpha1.Dependency]struct{}
+}
+
+// NewDependencyGraphBuilder returns a new DependencyGraphBuilder.
+func NewDependencyGraphBuilder(mapper meta.RESTMapper) *DependencyGraphBuilder {
+	return &DependencyGraphBuilder{
+		mapper: mapper,
+		types:  map[string]exportedType{},
+		deps:   map[v1alpha1.Dependency]struct{}{},
+	}
+}
+
+// AddCRD adds the type defined by crd to the graph, together with the
+// dependencies implied by its owners.
+func (b *DependencyGraphBuilder) AddCRD(crd apiextensionsv1.CustomResourceDefinition) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	gr := crd.GetName()
+	t := exportedType{
+		group:      crd.Spec.Group,
+		resource:   crd.Spec.Names.Plural,
+		categories: crd.Spec.Names.Categories,
+	}
+	for _, ref := range crd.GetOwnerReferences() {
+		switch {
+		case ref.APIVersion == "apiextensions.crossplane.io/v1" && ref.Kind == "CompositeResourceDefinition":
+			t.xrd = ref.Name
+			b.addDependency(gr, compositeResourceDefinitionsGR, ReasonCompositeResourceDefinition)
+		case strings.HasPrefix(ref.APIVersion, "pkg.crossplane.io/"):
+			b.addDependency(gr, strings.ToLower(ref.Kind)+"s.pkg.crossplane.io", ReasonPackage)
+		}
+	}
+	b.types[gr] = t
+}
+
+// ObserveResources adds the type of the resources to the graph, together with
+// dependencies on the types of their owners.
+func (b *DependencyGraphBuilder) ObserveResources(groupResource string, resources []unstructured.Unstructured) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.types[groupResource]; !ok {
+		gr := schema.ParseGroupResource(groupResource)
+		b.types[groupResource] = exportedType{group: gr.Group, resource: gr.Resource}
+	}
+	for _, r := range resources {
+		for _, ref := range r.GetOwnerReferences() {
+			gv, err := schema.ParseGroupVersion(ref.APIVersion)
+			if err != nil {
+				continue
+			}
+			rm, err := b.mapper.RESTMapping(schema.GroupKind{Group: gv.Group, Kind: ref.Kind}, gv.Version)
+			if err != nil {
+				// The owner type is not known to the cluster (anymore).
+				continue
+			}
+			if owner := rm.Resource.GroupResource().String(); owner != groupResource {
+				b.addDependency(groupResource, owner, ReasonOwnerReference)
+			}
+		}
+	}
+}
+
+// Build returns the dependency graph of all added types. Dependencies implied
+// by categories are only added if the depended on types were added as well.
+func (b *DependencyGraphBuilder) Build() *v1alpha1.DependencyGraph {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	deps := make(map[v1alpha1.Dependency]struct{}, len(b.deps))
+	for d := range b.deps {
+		deps[d] = struct{}{}
+	}
+	add := func(from, to, reason string) {
+		deps[v1alpha1.Dependency{From: from, To: to, Reason: reason}] = struct{}{}
+	}
+
+	for gr, t := range b.types {
+		switch {
+		case contains(t.categories, "managed"):
+			// Managed resources depend on the ProviderConfigs of their
+			// provider, e.g. s3.aws.upbound.io on aws.upbound.io.
+			for pgr, pt := range b.types {
+				if pt.resource == "providerconfigs" && (t.group == pt.group || strings.HasSuffix(t.group, "."+pt.group)) {
+					add(gr, pgr, ReasonProviderConfig)
+				}
+			}
+		case contains(t.categories, "composite"):
+			if _, ok := b.types[compositionsGR]; ok {
+				add(gr, compositionsGR, ReasonComposition)
+			}
+		case contains(t.categories, "claim"):
+			// Claims depend on the composite resource type defined by the
+			// same CompositeResourceDefinition.
+			for cgr, ct := range b.types {
+				if ct.xrd != "" && ct.xrd == t.xrd && contains(ct.categories, "composite") {
+					add(gr, cgr, ReasonCompositeResource)
+				}
+			}
+		}
+	}
+
+	g := &v1alpha1.DependencyGraph{
+		Types:        make([]string, 0, len(b.types)),
+		Dependencies: make([]v1alpha1.Dependency, 0, len(deps)),
+	}
+	for gr := range b.types {
+		g.Types = append(g.Types, gr)
+	}
+	sort.Strings(g.Types)
+	for d := range deps {
+		g.Dependencies = append(g.Dependencies, d)
+	}
+	sort.Slice(g.Dependencies, func(i, j int) bool {
+		a, b := g.Dependencies[i], g.Dependencies[j]
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		if a.To != b.To {
+			return a.To < b.To
+		}
+		return a.Reason < b.Reason
+	})
+	return g
+}
+
+// Persist writes the dependency graph to the root of the export.
+func (b *DependencyGraphBuilder) Persist(fs afero.Afero, root string) error {
+	j, err := json.MarshalIndent(b.Build(), "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "cannot marshal dependency graph to json")
+	}
+	return errors.Wrap(fs.WriteFile(filepath.Join(root, DependencyGraphFile), j, 0600), "cannot write dependency graph")
+}
+
+func (b *DependencyGraphBuilder) addDependency(from, to, reason string) {
+	if from == to {
+		return
+	}
+	b.deps[v1alpha1.Dependency{From: from, To: to, Reason: reason}] = struct{}{}
+}
+
+func contains(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,151 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/afero"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/upbound/up/pkg/migration/meta/v1alpha1"
+)
+
+func TestDependencyGraphBuilder(t *testing.T) {
+	crd := func(group, plural string, categories []string, owners ...metav1.OwnerReference) apiextensionsv1.CustomResourceDefinition {
+		c := apiextensionsv1.CustomResourceDefinition{}
+		c.SetName(plural + "." + group)
+		c.SetOwnerReferences(owners)
+		c.Spec.Group = group
+		c.Spec.Names.Plural = plural
+		c.Spec.Names.Categories = categories
+		return c
+	}
+	xrd := metav1.OwnerReference{APIVersion: "apiextensions.crossplane.io/v1", Kind: "CompositeResourceDefinition", Name: "xclusters.example.org"}
+	provider := metav1.OwnerReference{APIVersion: "pkg.crossplane.io/v1", Kind: "Provider", Name: "provider-aws-s3"}
+
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Group: "example.org", Version: "v1alpha1", Kind: "XCluster"}, meta.RESTScopeRoot)
+
+	type args struct {
+		crds     []apiextensionsv1.CustomResourceDefinition
+		observed map[string][]unstructured.Unstructured
+	}
+	type want struct {
+		graph *v1alpha1.DependencyGraph
+	}
+
+	cases := map[string]struct {
+		args args
+		want want
+	}{
+		"CategoriesAndOwners": {
+			args: args{
+				crds: []apiextensionsv1.CustomResourceDefinition{
+					crd("apiextensions.crossplane.io", "compositions", nil),
+					crd("aws.upbound.io", "providerconfigs", nil, provider),
+					crd("s3.aws.upbound.io", "buckets", []string{"crossplane", "managed", "aws"}, provider),
+					crd("example.org", "xclusters", []string{"composite"}, xrd),
+					crd("example.org", "clusters", []string{"claim"}, xrd),
+				},
+			},
+			want: want{
+				graph: &v1alpha1.DependencyGraph{
+					Types: []string{
+						"buckets.s3.aws.upbound.io",
+						"clusters.example.org",
+						"compositions.apiextensions.crossplane.io",
+						"providerconfigs.aws.upbound.io",
+						"xclusters.example.org",
+					},
+					Dependencies: []v1alpha1.Dependency{
+						{From: "buckets.s3.aws.upbound.io", To: "providerconfigs.aws.upbound.io", Reason: ReasonProviderConfig},
+						{From: "buckets.s3.aws.upbound.io", To: "providers.pkg.crossplane.io", Reason: ReasonPackage},
+						{From: "clusters.example.org", To: "compositeresourcedefinitions.apiextensions.crossplane.io", Reason: ReasonCompositeResourceDefinition},
+						{From: "clusters.example.org", To: "xclusters.example.org", Reason: ReasonCompositeResource},
+						{From: "providerconfigs.aws.upbound.io", To: "providers.pkg.crossplane.io", Reason: ReasonPackage},
+						{From: "xclusters.example.org", To: "compositeresourcedefinitions.apiextensions.crossplane.io", Reason: ReasonCompositeResourceDefinition},
+						{From: "xclusters.example.org", To: "compositions.apiextensions.crossplane.io", Reason: ReasonComposition},
+					},
+				},
+			},
+		},
+		"OwnerReferences": {
+			args: args{
+				observed: map[string][]unstructured.Unstructured{
+					"secrets": {ownedBy("example.org/v1alpha1", "XCluster"), ownedBy("example.org/v1alpha1", "Unknown")},
+				},
+			},
+			want: want{
+				graph: &v1alpha1.DependencyGraph{
+					Types: []string{"secrets"},
+					Dependencies: []v1alpha1.Dependency{
+						{From: "secrets", To: "xclusters.example.org", Reason: ReasonOwnerReference},
+					},
+				},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			b := NewDependencyGraphBuilder(mapper)
+			for _, c := range tc.args.crds {
+				b.AddCRD(c)
+			}
+			for gr, rs := range tc.args.observed {
+				b.ObserveResources(gr, rs)
+			}
+			if diff := cmp.Diff(tc.want.graph, b.Build()); diff != "" {
+				t.Errorf("\n%s\nBuild(...): -want, +got:\n%s", name, diff)
+			}
+		})
+	}
+}
+
+func TestDependencyGraphBuilderPersist(t *testing.T) {
+	b := NewDependencyGraphBuilder(meta.NewDefaultRESTMapper(nil))
+	c := apiextensionsv1.CustomResourceDefinition{}
+	c.SetName("compositions.apiextensions.crossplane.io")
+	b.AddCRD(c)
+
+	fs := afero.Afero{Fs: afero.NewMemMapFs()}
+	if err := b.Persist(fs, "export"); err != nil {
+		t.Fatalf("Persist(...): unexpected error: %v", err)
+	}
+	j, err := fs.ReadFile("export/" + DependencyGraphFile)
+	if err != nil {
+		t.Fatalf("cannot read dependency graph: %v", err)
+	}
+	got := &v1alpha1.DependencyGraph{}
+	if err := json.Unmarshal(j, got); err != nil {
+		t.Fatalf("cannot unmarshal dependency graph: %v", err)
+	}
+	want := &v1alpha1.DependencyGraph{Types: []string{"compositions.apiextensions.crossplane.io"}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Persist(...): -want, +got:\n%s", diff)
+	}
+}
+
+func ownedBy(apiVersion, kind string) unstructured.Unstructured {
+	u := unstructured.Unstructured{}
+	u.SetOwnerReferences([]metav1.OwnerReference{{APIVersion: apiVersion, Kind: kind, Name: "owner"}})
+	return u
+}
@@ -17,13 +17,16 @@ package exporter
 import (
 	"context"
 	"path/filepath"
+	"sort"
 	"time"
 
 	"github.com/spf13/afero"
+	"k8s.io/client-go/dynamic"
 	appsv1 "k8s.io/client-go/kubernetes/typed/apps/v1"
 	"sigs.k8s.io/yaml"
 
 	"github.com/upbound/up/pkg/migration/crossplane"
+	"github.com/upbound/up/pkg/migration/meta"
 	"github.com/upbound/up/pkg/migration/meta/v1alpha1"
 
 	"github.com/crossplane/crossplane-runtime/pkg/errors"
@@ -34,21 +37,27 @@ type MetadataExporter interface {
 }
 
 type PersistentMetadataExporter struct {
-	appsClient appsv1.AppsV1Interface
-	fs         afero.Afero
-	root       string
+	appsClient    appsv1.AppsV1Interface
+	dynamicClient dynamic.Interface
+	fs            afero.Afero
+	root          string
 }
 
-func NewPersistentMetadataExporter(apps appsv1.AppsV1Interface, fs afero.Afero, root string) *PersistentMetadataExporter {
+func NewPersistentMetadataExporter(apps appsv1.AppsV1Interface, dynamicClient dynamic.Interface, fs afero.Afero, root string) *PersistentMetadataExporter {
 	return &PersistentMetadataExporter{
-		appsClient: apps,
-		fs:         fs,
-		root:       root,
+		appsClient:    apps,
+		dynamicClient: dynamicClient,
+		fs:            fs,
+		root:          root,
 	}
 }
 
-func (e *PersistentMetadataExporter) ExportMetadata(ctx context.Context, opts Options, native map[string]int, custom map[string]int) error {
-	xp, err := crossplane.CollectInfo(ctx, e.appsClient)
+// ExportMetadata writes the export metadata with the supplied numbers of
+// exported native and custom resources, export durations per type, the
+// resources skipped for exceeding the maximum resource size, and warnings for
+// the importer.
+func (e *PersistentMetadataExporter) ExportMetadata(ctx context.Context, opts Options, native map[string]int, custom map[string]int, durations map[string]time.Duration, oversized, warnings []string) error {
+	xp, err := crossplane.CollectInfo(ctx, e.appsClient, crossplane.WithProviders(e.dynamicClient))
 	if err != nil {
 		return errors.Wrap(err, "cannot get Crossplane info")
 	}
@@ -60,15 +69,33 @@ func (e *PersistentMetadataExporter) ExportMetadata(ctx context.Context, opts Op
 	for _, v := range custom {
 		total += v
 	}
+	var typeDurations map[string]string
+	if len(durations) > 0 {
+		typeDurations = make(map[string]string, len(durations))
+		for gr, d := range durations {
+			typeDurations[gr] = d.Round(time.Millisecond).String()
+		}
+	}
+	// Types are exported in parallel.
+	sort.Strings(oversized)
 	em := &v1alpha1.ExportMeta{
-		Version:    "v1alpha1",
-		ExportedAt: time.Now(),
+		Version:       "v1alpha1",
+		FormatVersion: meta.CurrentFormatVersion,
+		ExportedAt:    time.Now(),
+		ChangedSince:  opts.ChangedSince,
 		Options: v1alpha1.ExportOptions{
-			IncludedNamespaces:     opts.IncludeNamespaces,
-			ExcludedNamespaces:     opts.ExcludeNamespaces,
-			IncludedExtraResources: opts.IncludeExtraResources,
-			ExcludedResources:      opts.ExcludeResources,
-			PausedBeforeExport:     opts.PauseBeforeExport,
+			IncludedNamespaces:        opts.IncludeNamespaces,
+			ExcludedNamespaces:        opts.ExcludeNamespaces,
+			ExcludedNamespacePatterns: opts.ExcludeNamespacePatterns,
+			IncludedHelmReleases:      opts.IncludeHelmReleases,
+			CompositionLabelSelector:  opts.CompositionLabelSelector,
+			FieldSelector:             opts.Fetch.FieldSelector,
+			LabelSelector:             opts.Fetch.LabelSelector,
+			IncludedExtraResources:    opts.IncludeExtraResources,
+			ExcludedResources:         opts.ExcludeResources,
+			PausedBeforeExport:        opts.PauseBeforeExport,
+			ContentAddressable:        opts.ContentAddressable,
+			SegmentedByNamespace:      opts.SegmentByNamespace,
 		},
 		Crossplane: *xp,
 		Stats: v1alpha1.ExportStats{
@@ -76,6 +103,9 @@ func (e *PersistentMetadataExporter) ExportMetadata(ctx context.Context, opts Op
 			NativeResources: native,
 			CustomResources: custom,
 		},
+		ResourceTypeDurations: typeDurations,
+		OversizedResources:    oversized,
+		Warnings:              warnings,
 	}
 	b, err := yaml.Marshal(&em)
 	if err != nil {
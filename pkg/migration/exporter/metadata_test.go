@@ -0,0 +1,114 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/afero"
+	apiextensionsfake "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/fake"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"sigs.k8s.io/yaml"
+
+	"github.com/upbound/up/pkg/migration/meta/v1alpha1"
+)
+
+func readExportMeta(t *testing.T, fs afero.Afero, dir string) *v1alpha1.ExportMeta {
+	t.Helper()
+	b, err := fs.ReadFile(filepath.Join(dir, "export.yaml"))
+	if err != nil {
+		t.Fatalf("cannot read export metadata: %v", err)
+	}
+	em := &v1alpha1.ExportMeta{}
+	if err := yaml.Unmarshal(b, em); err != nil {
+		t.Fatalf("cannot unmarshal export metadata: %v", err)
+	}
+	return em
+}
+
+func TestPersistentMetadataExporterResourceTypeDurations(t *testing.T) {
+	cases := map[string]struct {
+		durations map[string]time.Duration
+		want      map[string]string
+	}{
+		"None": {},
+		"Rounded": {
+			durations: map[string]time.Duration{
+				"configmaps":                  1500*time.Millisecond + 42*time.Microsecond,
+				"buckets.s3.aws.upbound.io":   2 * time.Minute,
+				"providers.pkg.crossplane.io": 0,
+			},
+			want: map[string]string{
+				"configmaps":                  "1.5s",
+				"buckets.s3.aws.upbound.io":   "2m0s",
+				"providers.pkg.crossplane.io": "0s",
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			fs := afero.Afero{Fs: afero.NewMemMapFs()}
+			e := NewPersistentMetadataExporter(kubefake.NewSimpleClientset().AppsV1(), dynamicfake.NewSimpleDynamicClient(runtime.NewScheme()), fs, "state")
+			if err := e.ExportMetadata(context.Background(), Options{}, nil, nil, tc.durations, nil, nil); err != nil {
+				t.Fatalf("\n%s\nExportMetadata(...): %v", name, err)
+			}
+			if diff := cmp.Diff(tc.want, readExportMeta(t, fs, "state").ResourceTypeDurations); diff != "" {
+				t.Errorf("\n%s\nExportMetadata(...): -want, +got:\n%s", name, diff)
+			}
+		})
+	}
+}
+
+func TestControlPlaneStateExporterResourceTypeDurations(t *testing.T) {
+	core := schema.GroupVersion{Version: "v1"}
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{core})
+	mapper.Add(core.WithKind("ConfigMap"), meta.RESTScopeNamespace)
+	cm := &unstructured.Unstructured{}
+	cm.SetAPIVersion("v1")
+	cm.SetKind("ConfigMap")
+	cm.SetNamespace("default")
+	cm.SetName("config")
+
+	dir := filepath.Join(t.TempDir(), "state")
+	kube := kubefake.NewSimpleClientset()
+	e := NewControlPlaneStateExporter(
+		apiextensionsfake.NewSimpleClientset(),
+		dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), cm),
+		kube.Discovery(),
+		kube.AppsV1(),
+		mapper,
+		Options{
+			OutputArchive:         dir,
+			OutputFormat:          OutputFormatDirectory,
+			IncludeExtraResources: []string{"configmaps"},
+		})
+	if err := e.Export(context.Background()); err != nil {
+		t.Fatalf("Export() unexpected error: %v", err)
+	}
+
+	got := readExportMeta(t, afero.Afero{Fs: afero.NewOsFs()}, dir).ResourceTypeDurations
+	if _, err := time.ParseDuration(got["configmaps"]); err != nil {
+		t.Errorf("Export(): want the export duration of configmaps, got %v: %v", got, err)
+	}
+}
@@ -0,0 +1,35 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/spf13/afero"
+
+	"github.com/upbound/up/pkg/migration/archiver"
+)
+
+// pushToOCI pushes the archive of the exported state in dir to the configured
+// OCI reference, without storing it locally. The archive is buffered in
+// memory, as the digest of the layer must be known before it is uploaded.
+func (e *ControlPlaneStateExporter) pushToOCI(ctx context.Context, fs afero.Afero, dir string, alg archiver.Compression, opts ...archiver.ArchiveOption) error {
+	b := &bytes.Buffer{}
+	if err := archiver.Archive(ctx, fs, dir, b, opts...); err != nil {
+		return err
+	}
+	return archiver.PushOCI(ctx, e.options.OutputOCIRef, b.Bytes(), alg)
+}
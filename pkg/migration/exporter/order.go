@@ -0,0 +1,138 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"path/filepath"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/yaml"
+
+	"github.com/upbound/up/pkg/migration/meta/v1alpha1"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+)
+
+// ResourceOrderFile is the name of the file holding the order the resources of
+// a group resource should be imported in, if it matters.
+const ResourceOrderFile = "order.yaml"
+
+// ResourceSorter orders the fetched resources of a type before cluster
+// specific data is removed from them.
+type ResourceSorter interface {
+	// SortResources returns the resources in the order they should be
+	// imported, and whether the order matters.
+	SortResources(resources []unstructured.Unstructured) ([]unstructured.Unstructured, bool)
+}
+
+// OrderPersister persists the order resources of a type should be imported
+// in.
+type OrderPersister interface {
+	PersistOrder(groupResource string, resources []unstructured.Unstructured) error
+}
+
+// TopologicalExportSorter sorts resources so that owners come before the
+// resources they own, based on their owner references.
+type TopologicalExportSorter struct{}
+
+// NewTopologicalExportSorter returns a new TopologicalExportSorter.
+func NewTopologicalExportSorter() *TopologicalExportSorter {
+	return &TopologicalExportSorter{}
+}
+
+// SortResources returns the resources with every owner before the resources
+// it owns, keeping the original order otherwise. Only owners among the
+// supplied resources are considered, and the order matters if there is at
+// least one of them. Resources in an ownership cycle are kept in their
+// original order.
+func (s *TopologicalExportSorter) SortResources(resources []unstructured.Unstructured) ([]unstructured.Unstructured, bool) {
+	byUID := make(map[types.UID]int, len(resources))
+	for i := range resources {
+		if uid := resources[i].GetUID(); uid != "" {
+			byUID[uid] = i
+		}
+	}
+
+	owned := false
+	owners := make([][]int, len(resources))
+	for i := range resources {
+		for _, ref := range resources[i].GetOwnerReferences() {
+			if j, ok := byUID[ref.UID]; ok && j != i {
+				owners[i] = append(owners[i], j)
+				owned = true
+			}
+		}
+	}
+	if !owned {
+		return resources, false
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make([]int, len(resources))
+	sorted := make([]unstructured.Unstructured, 0, len(resources))
+	var visit func(i int)
+	visit = func(i int) {
+		if state[i] != unvisited {
+			// Either already sorted, or part of a cycle.
+			return
+		}
+		state[i] = visiting
+		for _, j := range owners[i] {
+			visit(j)
+		}
+		state[i] = visited
+		sorted = append(sorted, resources[i])
+	}
+	for i := range resources {
+		visit(i)
+	}
+	return sorted, true
+}
+
+// PersistOrder writes the identities of the supplied resources in their order
+// to the order manifest of the group resource, after the ones of previously
+// persisted resources.
+func (p *FileSystemPersister) PersistOrder(groupResource string, resources []unstructured.Unstructured) error {
+	defer p.locks.lock(p.pathFor(groupResource))()
+
+	order := &v1alpha1.ResourceOrder{}
+	of := p.pathFor(groupResource, ResourceOrderFile)
+	if ok, _ := p.fs.Exists(of); ok {
+		b, err := p.fs.ReadFile(of)
+		if err != nil {
+			return errors.Wrapf(err, "cannot read order manifest %q", of)
+		}
+		if err = yaml.Unmarshal(b, order); err != nil {
+			return errors.Wrapf(err, "cannot unmarshal order manifest %q", of)
+		}
+	}
+	for i := range resources {
+		order.Resources = append(order.Resources, filepath.ToSlash(ResourceIdentity(resources[i])))
+	}
+
+	b, err := yaml.Marshal(order)
+	if err != nil {
+		return errors.Wrap(err, "cannot marshal order manifest to yaml")
+	}
+	if err = p.fs.MkdirAll(p.pathFor(groupResource), 0700); err != nil {
+		return errors.Wrapf(err, "cannot create directory resource group %q", groupResource)
+	}
+	return errors.Wrapf(p.fs.WriteFile(of, b, 0600), "cannot write order manifest to %q", of)
+}
@@ -0,0 +1,136 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/afero"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/yaml"
+
+	"github.com/upbound/up/pkg/migration/meta/v1alpha1"
+)
+
+// thing returns a cluster scoped resource with the supplied name, using the
+// name as its UID, owned by the supplied owners.
+func thing(name string, owners ...string) unstructured.Unstructured {
+	u := unstructured.Unstructured{}
+	u.SetAPIVersion("example.org/v1")
+	u.SetKind("Thing")
+	u.SetName(name)
+	u.SetUID(types.UID(name))
+	refs := make([]metav1.OwnerReference, 0, len(owners))
+	for _, o := range owners {
+		refs = append(refs, metav1.OwnerReference{APIVersion: "example.org/v1", Kind: "Thing", Name: o, UID: types.UID(o)})
+	}
+	u.SetOwnerReferences(refs)
+	return u
+}
+
+func TestTopologicalExportSorterSortResources(t *testing.T) {
+	type want struct {
+		names   []string
+		ordered bool
+	}
+	cases := map[string]struct {
+		reason    string
+		resources []unstructured.Unstructured
+		want      want
+	}{
+		"OwnershipChain": {
+			reason:    "An owner should be sorted before the resource it owns.",
+			resources: []unstructured.Unstructured{thing("child", "parent"), thing("parent")},
+			want: want{
+				names:   []string{"parent", "child"},
+				ordered: true,
+			},
+		},
+		"NoOwners": {
+			reason:    "Resources without owners among them should keep their order.",
+			resources: []unstructured.Unstructured{thing("b"), thing("a", "elsewhere")},
+			want: want{
+				names: []string{"b", "a"},
+			},
+		},
+		"Cycle": {
+			reason:    "Resources in an ownership cycle should all be kept.",
+			resources: []unstructured.Unstructured{thing("a", "b"), thing("b", "a"), thing("c")},
+			want: want{
+				names:   []string{"b", "a", "c"},
+				ordered: true,
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			sorted, ordered := NewTopologicalExportSorter().SortResources(tc.resources)
+			names := make([]string, 0, len(sorted))
+			for _, u := range sorted {
+				names = append(names, u.GetName())
+			}
+			if diff := cmp.Diff(tc.want, want{names: names, ordered: ordered}, cmp.AllowUnexported(want{})); diff != "" {
+				t.Errorf("\n%s\nSortResources(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+// staticFetcher fetches the same resources for every type.
+type staticFetcher []unstructured.Unstructured
+
+func (f staticFetcher) FetchResources(_ context.Context, _ schema.GroupVersionResource) ([]unstructured.Unstructured, error) {
+	return f, nil
+}
+
+func (f staticFetcher) StreamResources(_ context.Context, _ schema.GroupVersionResource, fn func(unstructured.Unstructured) error) error {
+	for _, r := range f {
+		if err := fn(*r.DeepCopy()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestUnstructuredExporterPersistsOrder(t *testing.T) {
+	fs := afero.Afero{Fs: afero.NewMemMapFs()}
+	e := NewUnstructuredExporter(
+		staticFetcher{thing("child", "parent"), thing("parent")},
+		NewFileSystemPersister(fs, "export", nil),
+		WithResourceSorter(NewTopologicalExportSorter()))
+
+	if _, err := e.ExportResources(context.Background(), schema.GroupVersionResource{Group: "example.org", Version: "v1", Resource: "things"}); err != nil {
+		t.Fatalf("ExportResources(...): unexpected error: %v", err)
+	}
+
+	b, err := fs.ReadFile(filepath.Join("export", "things.example.org", ResourceOrderFile))
+	if err != nil {
+		t.Fatalf("cannot read order manifest: %v", err)
+	}
+	got := &v1alpha1.ResourceOrder{}
+	if err := yaml.Unmarshal(b, got); err != nil {
+		t.Fatalf("cannot unmarshal order manifest: %v", err)
+	}
+	want := &v1alpha1.ResourceOrder{Resources: []string{"cluster/parent", "cluster/child"}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ExportResources(...): order manifest: -want, +got:\n%s", diff)
+	}
+}
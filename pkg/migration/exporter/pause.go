@@ -0,0 +1,150 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
+)
+
+const (
+	// pausePollInterval is how often managed resources are checked for
+	// having acknowledged the pause.
+	pausePollInterval = 2 * time.Second
+	// maxReportedUnpaused is the number of managed resources that did not
+	// acknowledge the pause listed in the timeout error.
+	maxReportedUnpaused = 10
+)
+
+// PauseWaiter waits for managed resources to acknowledge that they were
+// paused, i.e. for their Synced condition to have the ReconcilePaused reason.
+type PauseWaiter struct {
+	dynamicClient   dynamic.Interface
+	discoveryClient discovery.DiscoveryInterface
+	pollInterval    time.Duration
+}
+
+// NewPauseWaiter returns a new PauseWaiter.
+func NewPauseWaiter(dyn dynamic.Interface, dis discovery.DiscoveryInterface) *PauseWaiter {
+	return &PauseWaiter{
+		dynamicClient:   dyn,
+		discoveryClient: dis,
+		pollInterval:    pausePollInterval,
+	}
+}
+
+// Wait returns once all managed resources acknowledged the pause, or an error
+// listing the ones that did not if the timeout elapses first.
+func (w *PauseWaiter) Wait(ctx context.Context, timeout time.Duration) error {
+	gvrs, err := w.managedGVRs()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	for {
+		unpaused, err := w.unpaused(ctx, gvrs)
+		if err != nil {
+			return err
+		}
+		if len(unpaused) == 0 {
+			return nil
+		}
+
+		t := time.NewTimer(w.pollInterval)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return errors.Errorf("timeout waiting for %d managed resources to acknowledge the pause: %s", len(unpaused), summarize(unpaused, maxReportedUnpaused))
+		case <-t.C:
+		}
+	}
+}
+
+// managedGVRs returns the preferred versions of all managed resource types.
+func (w *PauseWaiter) managedGVRs() ([]schema.GroupVersionResource, error) {
+	apiLists, err := w.discoveryClient.ServerPreferredResources()
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot get server preferred resources")
+	}
+	var gvrs []schema.GroupVersionResource
+	for _, al := range apiLists {
+		gv, err := schema.ParseGroupVersion(al.GroupVersion)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot parse group version %q", al.GroupVersion)
+		}
+		for _, r := range al.APIResources {
+			if contains(r.Categories, "managed") {
+				gvrs = append(gvrs, gv.WithResource(r.Name))
+			}
+		}
+	}
+	return gvrs, nil
+}
+
+// unpaused returns the managed resources that did not acknowledge the pause
+// yet, as "<kind>/<name>", sorted.
+func (w *PauseWaiter) unpaused(ctx context.Context, gvrs []schema.GroupVersionResource) ([]string, error) {
+	var unpaused []string
+	for _, gvr := range gvrs {
+		l, err := w.dynamicClient.Resource(gvr).List(ctx, v1.ListOptions{})
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot list %q", gvr.GroupResource())
+		}
+		for i := range l.Items {
+			ok, err := pauseAcknowledged(&l.Items[i])
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				unpaused = append(unpaused, fmt.Sprintf("%s/%s", l.Items[i].GetKind(), l.Items[i].GetName()))
+			}
+		}
+	}
+	sort.Strings(unpaused)
+	return unpaused, nil
+}
+
+// pauseAcknowledged returns true if the Synced condition of the managed
+// resource has the ReconcilePaused reason.
+func pauseAcknowledged(r *unstructured.Unstructured) (bool, error) {
+	status := xpv1.ConditionedStatus{}
+	if err := fieldpath.Pave(r.Object).GetValueInto("status", &status); err != nil && !fieldpath.IsNotFound(err) {
+		return false, errors.Wrapf(err, "cannot get status of %q %q", r.GetKind(), r.GetName())
+	}
+	return status.GetCondition(xpv1.TypeSynced).Reason == xpv1.ReasonReconcilePaused, nil
+}
+
+// summarize joins the first n items, followed by how many were left out.
+func summarize(items []string, n int) string {
+	if len(items) <= n {
+		return strings.Join(items, ", ")
+	}
+	return fmt.Sprintf("%s, and %d more", strings.Join(items[:n], ", "), len(items)-n)
+}
@@ -0,0 +1,113 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+)
+
+// preferredDiscovery serves the supplied preferred resources, which the fake
+// discovery client does not support.
+type preferredDiscovery struct {
+	discovery.DiscoveryInterface
+	resources []*v1.APIResourceList
+}
+
+func (d *preferredDiscovery) ServerPreferredResources() ([]*v1.APIResourceList, error) {
+	return d.resources, nil
+}
+
+func TestPauseWaiterWait(t *testing.T) {
+	buckets := schema.GroupVersionResource{Group: "s3.aws.upbound.io", Version: "v1beta1", Resource: "buckets"}
+	bucket := func(name, reason string) runtime.Object {
+		u := &unstructured.Unstructured{}
+		u.SetAPIVersion("s3.aws.upbound.io/v1beta1")
+		u.SetKind("Bucket")
+		u.SetName(name)
+		if reason != "" {
+			u.Object["status"] = map[string]interface{}{
+				"conditions": []interface{}{
+					map[string]interface{}{"type": "Synced", "status": "False", "reason": reason},
+				},
+			}
+		}
+		return u
+	}
+
+	type want struct {
+		err string
+	}
+	cases := map[string]struct {
+		reason  string
+		objects []runtime.Object
+		want    want
+	}{
+		"AllPaused": {
+			reason:  "Waiting should succeed once all managed resources acknowledged the pause.",
+			objects: []runtime.Object{bucket("a", "ReconcilePaused"), bucket("b", "ReconcilePaused")},
+		},
+		"NotPaused": {
+			reason:  "The managed resources that did not acknowledge the pause should be listed.",
+			objects: []runtime.Object{bucket("a", "ReconcilePaused"), bucket("b", "ReconcileSuccess"), bucket("c", "")},
+			want: want{
+				err: "timeout waiting for 2 managed resources to acknowledge the pause: Bucket/b, Bucket/c",
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			dyn := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{buckets: "BucketList"}, tc.objects...)
+			dis := &preferredDiscovery{
+				DiscoveryInterface: kubefake.NewSimpleClientset().Discovery(),
+				resources: []*v1.APIResourceList{{
+					GroupVersion: buckets.GroupVersion().String(),
+					APIResources: []v1.APIResource{{Name: "buckets", Kind: "Bucket", Categories: []string{"crossplane", "managed", "aws"}}},
+				}},
+			}
+			w := NewPauseWaiter(dyn, dis)
+			w.pollInterval = time.Millisecond
+
+			err := w.Wait(context.Background(), 20*time.Millisecond)
+			got := ""
+			if err != nil {
+				got = err.Error()
+			}
+			if !strings.Contains(got, tc.want.err) || (tc.want.err == "") != (err == nil) {
+				t.Errorf("\n%s\nWait(...): want error %q, got %v", tc.reason, tc.want.err, err)
+			}
+		})
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	items := []string{"a", "b", "c"}
+	if got, want := summarize(items, 3), "a, b, c"; got != want {
+		t.Errorf("summarize(...): want %q, got %q", want, got)
+	}
+	if got, want := summarize(items, 2), "a, b, and 1 more"; got != want {
+		t.Errorf("summarize(...): want %q, got %q", want, got)
+	}
+}
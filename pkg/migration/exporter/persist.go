@@ -16,34 +16,115 @@ package exporter
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"path/filepath"
+	"sync"
 
 	"github.com/spf13/afero"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"sigs.k8s.io/yaml"
 
 	"github.com/upbound/up/pkg/migration/meta/v1alpha1"
+	"github.com/upbound/up/pkg/migration/transform"
 
 	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	xpmeta "github.com/crossplane/crossplane-runtime/pkg/meta"
 )
 
 type ResourcePersister interface {
 	PersistResources(ctx context.Context, groupResource string, resources []unstructured.Unstructured) error
 }
 
+// redactedValue replaces the values of the data of redacted Secrets.
+const redactedValue = "<redacted>"
+
 type FileSystemPersister struct {
 	fs   afero.Afero
 	root string
 
 	meta *v1alpha1.TypeMeta
+
+	contentAddressable bool
+	redactSecrets      bool
+	imageRewrites      []transform.ImageRewriteRule
+
+	locks *pathLocks
+}
+
+// pathLocks serializes writes to the files below a path, e.g. of the
+// persisters of concurrently exported types sharing a group resource.
+type pathLocks struct {
+	mu    sync.Mutex
+	paths map[string]*sync.Mutex
+}
+
+func newPathLocks() *pathLocks {
+	return &pathLocks{paths: make(map[string]*sync.Mutex)}
+}
+
+// lock locks the supplied path and returns a function unlocking it.
+func (l *pathLocks) lock(path string) func() {
+	l.mu.Lock()
+	m, ok := l.paths[path]
+	if !ok {
+		m = &sync.Mutex{}
+		l.paths[path] = m
+	}
+	l.mu.Unlock()
+
+	m.Lock()
+	return m.Unlock
+}
+
+// PersisterOption configures a FileSystemPersister.
+type PersisterOption func(*FileSystemPersister)
+
+// withPathLocks configures the persister to share the supplied locks with
+// other persisters writing to the same root.
+func withPathLocks(l *pathLocks) PersisterOption {
+	return func(p *FileSystemPersister) {
+		p.locks = l
+	}
+}
+
+// WithContentAddressable configures the persister to store resources under
+// the SHA-256 hash of their content and to write a manifest mapping resource
+// identities to hashes.
+func WithContentAddressable() PersisterOption {
+	return func(p *FileSystemPersister) {
+		p.contentAddressable = true
+	}
 }
 
-func NewFileSystemPersister(fs afero.Afero, root string, m *v1alpha1.TypeMeta) *FileSystemPersister {
-	return &FileSystemPersister{
+// WithRedactedSecrets configures the persister to replace the values of the
+// data of Secrets with a placeholder and to annotate them accordingly.
+func WithRedactedSecrets() PersisterOption {
+	return func(p *FileSystemPersister) {
+		p.redactSecrets = true
+	}
+}
+
+// WithImageRewrites configures the persister to rewrite the package images of
+// Providers according to the supplied rules.
+func WithImageRewrites(rules []transform.ImageRewriteRule) PersisterOption {
+	return func(p *FileSystemPersister) {
+		p.imageRewrites = rules
+	}
+}
+
+func NewFileSystemPersister(fs afero.Afero, root string, m *v1alpha1.TypeMeta, opts ...PersisterOption) *FileSystemPersister {
+	p := &FileSystemPersister{
 		fs:   fs,
 		root: root,
 		meta: m,
+
+		locks: newPathLocks(),
 	}
+	for _, o := range opts {
+		o(p)
+	}
+	return p
 }
 
 func (p *FileSystemPersister) pathFor(dirs ...string) string {
@@ -56,6 +137,10 @@ func (p *FileSystemPersister) PersistResources(_ context.Context, groupResource
 		return nil
 	}
 
+	// Writes to the files of a group resource, e.g. a content manifest being
+	// read, updated and written back, must not interleave.
+	defer p.locks.lock(p.pathFor(groupResource))()
+
 	if err := p.fs.MkdirAll(p.pathFor(groupResource), 0700); err != nil {
 		return errors.Wrapf(err, "cannot create directory resource group %q", groupResource)
 	}
@@ -73,6 +158,21 @@ func (p *FileSystemPersister) PersistResources(_ context.Context, groupResource
 		}
 	}
 
+	if p.redactSecrets {
+		resources = redactSecrets(resources)
+	}
+
+	if len(p.imageRewrites) > 0 {
+		var err error
+		if resources, err = rewriteImages(resources, p.imageRewrites); err != nil {
+			return err
+		}
+	}
+
+	if p.contentAddressable {
+		return p.persistContentAddressable(groupResource, resources)
+	}
+
 	for i := range resources {
 		fileDirPath := p.pathFor(groupResource, "cluster")
 		if resources[i].GetNamespace() != "" {
@@ -97,3 +197,97 @@ func (p *FileSystemPersister) PersistResources(_ context.Context, groupResource
 
 	return nil
 }
+
+func (p *FileSystemPersister) persistContentAddressable(groupResource string, resources []unstructured.Unstructured) error {
+	objDir := p.pathFor(groupResource, "objects")
+	if err := p.fs.MkdirAll(objDir, 0700); err != nil {
+		return errors.Wrapf(err, "cannot create objects directory for resource group %q", groupResource)
+	}
+
+	manifest := &v1alpha1.ContentManifest{Resources: make(map[string]string, len(resources))}
+	mf := p.pathFor(groupResource, "manifest.yaml")
+	if ok, _ := p.fs.Exists(mf); ok {
+		b, err := p.fs.ReadFile(mf)
+		if err != nil {
+			return errors.Wrapf(err, "cannot read manifest %q", mf)
+		}
+		if err = yaml.Unmarshal(b, manifest); err != nil {
+			return errors.Wrapf(err, "cannot unmarshal manifest %q", mf)
+		}
+		if manifest.Resources == nil {
+			manifest.Resources = make(map[string]string, len(resources))
+		}
+	}
+
+	for i := range resources {
+		// sigs.k8s.io/yaml marshals through JSON, which sorts map keys, so the
+		// output is normalized and identical resources hash the same.
+		b, err := yaml.Marshal(&resources[i])
+		if err != nil {
+			return errors.Wrap(err, "cannot marshal resource to yaml")
+		}
+		sum := sha256.Sum256(b)
+		h := hex.EncodeToString(sum[:])
+
+		f := filepath.Join(objDir, h+".yaml")
+		if err = p.fs.WriteFile(f, b, 0600); err != nil {
+			return errors.Wrapf(err, "cannot write resource to %q", f)
+		}
+		manifest.Resources[ResourceIdentity(resources[i])] = h
+	}
+
+	b, err := yaml.Marshal(manifest)
+	if err != nil {
+		return errors.Wrap(err, "cannot marshal manifest to yaml")
+	}
+	if err = p.fs.WriteFile(mf, b, 0600); err != nil {
+		return errors.Wrapf(err, "cannot write manifest to %q", mf)
+	}
+	return nil
+}
+
+// redactSecrets returns the resources with the values of the data of Secrets
+// replaced with a placeholder. The supplied resources are not modified.
+func redactSecrets(resources []unstructured.Unstructured) []unstructured.Unstructured {
+	redacted := make([]unstructured.Unstructured, len(resources))
+	for i := range resources {
+		gvk := resources[i].GroupVersionKind()
+		if gvk.Group != "" || gvk.Kind != "Secret" {
+			redacted[i] = resources[i]
+			continue
+		}
+		u := resources[i].DeepCopy()
+		if data, ok := u.Object["data"].(map[string]any); ok {
+			for k := range data {
+				data[k] = redactedValue
+			}
+		}
+		xpmeta.AddAnnotations(u, map[string]string{v1alpha1.AnnotationSecretsRedacted: "true"})
+		redacted[i] = *u
+	}
+	return redacted
+}
+
+// rewriteImages returns the resources with the package images of Providers
+// rewritten according to rules. The supplied resources are not modified.
+func rewriteImages(resources []unstructured.Unstructured, rules []transform.ImageRewriteRule) ([]unstructured.Unstructured, error) {
+	rw := transform.NewImageRewriter(rules)
+	rewritten := make([]unstructured.Unstructured, len(resources))
+	for i := range resources {
+		u := resources[i].DeepCopy()
+		if err := rw.Transform(u); err != nil {
+			return nil, err
+		}
+		rewritten[i] = *u
+	}
+	return rewritten, nil
+}
+
+// ResourceIdentity returns the identity of a resource within its group
+// resource, matching the path it would be stored at in a regular export.
+func ResourceIdentity(u unstructured.Unstructured) string {
+	if u.GetNamespace() != "" {
+		return filepath.Join("namespaces", u.GetNamespace(), u.GetName())
+	}
+	return filepath.Join("cluster", u.GetName())
+}
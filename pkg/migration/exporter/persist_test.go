@@ -0,0 +1,211 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/afero"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+
+	"github.com/upbound/up/pkg/migration/meta/v1alpha1"
+	"github.com/upbound/up/pkg/migration/transform"
+)
+
+func TestFileSystemPersisterContentAddressable(t *testing.T) {
+	thing := func(ns, name string) unstructured.Unstructured {
+		u := unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "example.org/v1",
+			"kind":       "Thing",
+			"spec": map[string]interface{}{
+				"a": "b",
+			},
+		}}
+		u.SetNamespace(ns)
+		u.SetName(name)
+		return u
+	}
+
+	type args struct {
+		runs [][]unstructured.Unstructured
+	}
+	type want struct {
+		objects   int
+		manifests int
+	}
+
+	cases := map[string]struct {
+		args args
+		want want
+	}{
+		"SameResourceAcrossRuns": {
+			args: args{
+				runs: [][]unstructured.Unstructured{
+					{thing("default", "a")},
+					{thing("default", "a")},
+				},
+			},
+			want: want{
+				objects:   1,
+				manifests: 1,
+			},
+		},
+		"DifferentResources": {
+			args: args{
+				runs: [][]unstructured.Unstructured{
+					{thing("default", "a"), thing("", "b")},
+				},
+			},
+			want: want{
+				objects:   2,
+				manifests: 2,
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			fs := afero.Afero{Fs: afero.NewMemMapFs()}
+			var hashes []string
+			for _, run := range tc.args.runs {
+				p := NewFileSystemPersister(fs, "/export", nil, WithContentAddressable())
+				if err := p.PersistResources(context.Background(), "things.example.org", run); err != nil {
+					t.Fatalf("PersistResources() unexpected error: %v", err)
+				}
+
+				b, err := fs.ReadFile("/export/things.example.org/manifest.yaml")
+				if err != nil {
+					t.Fatalf("cannot read manifest: %v", err)
+				}
+				m := &v1alpha1.ContentManifest{}
+				if err := yaml.Unmarshal(b, m); err != nil {
+					t.Fatalf("cannot unmarshal manifest: %v", err)
+				}
+				if diff := cmp.Diff(tc.want.manifests, len(m.Resources)); diff != "" {
+					t.Errorf("manifest entries mismatch (-want +got):\n%s", diff)
+				}
+				hashes = append(hashes, m.Resources["namespaces/default/a"])
+			}
+			for _, h := range hashes[1:] {
+				if diff := cmp.Diff(hashes[0], h); diff != "" {
+					t.Errorf("hash mismatch across runs (-want +got):\n%s", diff)
+				}
+			}
+
+			objs, err := fs.ReadDir("/export/things.example.org/objects")
+			if err != nil {
+				t.Fatalf("cannot read objects directory: %v", err)
+			}
+			if diff := cmp.Diff(tc.want.objects, len(objs)); diff != "" {
+				t.Errorf("object files mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFileSystemPersisterRedactedSecrets(t *testing.T) {
+	secret := unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"metadata": map[string]interface{}{
+			"name":      "creds",
+			"namespace": "default",
+		},
+		"data": map[string]interface{}{
+			"password": "c2VjcmV0",
+		},
+	}}
+	want := map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"metadata": map[string]interface{}{
+			"name":      "creds",
+			"namespace": "default",
+			"annotations": map[string]interface{}{
+				v1alpha1.AnnotationSecretsRedacted: "true",
+			},
+		},
+		"data": map[string]interface{}{
+			"password": redactedValue,
+		},
+	}
+
+	fs := afero.Afero{Fs: afero.NewMemMapFs()}
+	p := NewFileSystemPersister(fs, "/export", nil, WithRedactedSecrets())
+	if err := p.PersistResources(context.Background(), "secrets", []unstructured.Unstructured{secret}); err != nil {
+		t.Fatalf("PersistResources() unexpected error: %v", err)
+	}
+
+	b, err := fs.ReadFile("/export/secrets/namespaces/default/creds.yaml")
+	if err != nil {
+		t.Fatalf("cannot read secret: %v", err)
+	}
+	got := map[string]interface{}{}
+	if err := yaml.Unmarshal(b, &got); err != nil {
+		t.Fatalf("cannot unmarshal secret: %v", err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("persisted secret mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff("c2VjcmV0", secret.Object["data"].(map[string]interface{})["password"]); diff != "" {
+		t.Errorf("supplied secret was modified (-want +got):\n%s", diff)
+	}
+}
+
+func TestFileSystemPersisterImageRewrites(t *testing.T) {
+	provider := unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "pkg.crossplane.io/v1",
+		"kind":       "Provider",
+		"metadata": map[string]interface{}{
+			"name": "provider-aws",
+		},
+		"spec": map[string]interface{}{
+			"package": "xpkg.upbound.io/upbound/provider-aws:v0.43.0",
+		},
+	}}
+	want := map[string]interface{}{
+		"apiVersion": "pkg.crossplane.io/v1",
+		"kind":       "Provider",
+		"metadata": map[string]interface{}{
+			"name": "provider-aws",
+		},
+		"spec": map[string]interface{}{
+			"package": "my-registry.corp/provider-aws:v0.43.0",
+		},
+	}
+
+	fs := afero.Afero{Fs: afero.NewMemMapFs()}
+	p := NewFileSystemPersister(fs, "/export", nil, WithImageRewrites([]transform.ImageRewriteRule{{From: "xpkg.upbound.io/*/", To: "my-registry.corp/"}}))
+	if err := p.PersistResources(context.Background(), "providers.pkg.crossplane.io", []unstructured.Unstructured{provider}); err != nil {
+		t.Fatalf("PersistResources() unexpected error: %v", err)
+	}
+
+	b, err := fs.ReadFile("/export/providers.pkg.crossplane.io/cluster/provider-aws.yaml")
+	if err != nil {
+		t.Fatalf("cannot read provider: %v", err)
+	}
+	got := map[string]interface{}{}
+	if err := yaml.Unmarshal(b, &got); err != nil {
+		t.Fatalf("cannot unmarshal provider: %v", err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("persisted provider mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff("xpkg.upbound.io/upbound/provider-aws:v0.43.0", provider.Object["spec"].(map[string]interface{})["package"]); diff != "" {
+		t.Errorf("supplied provider was modified (-want +got):\n%s", diff)
+	}
+}
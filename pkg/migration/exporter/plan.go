@@ -0,0 +1,55 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"context"
+	"sort"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+)
+
+// TypePlan is what would be exported of a single type.
+type TypePlan struct {
+	// Type is the group resource of the type, e.g. "configmaps".
+	Type string
+	ResourceCounts
+}
+
+// Plan returns what would be exported of every type to export, ordered by
+// type, without writing anything or modifying the control plane.
+func (e *ControlPlaneStateExporter) Plan(ctx context.Context) ([]TypePlan, error) {
+	if err := validateNamespacePatterns(e.options.ExcludeNamespacePatterns); err != nil {
+		return nil, err
+	}
+	gvrs, err := e.ListExportableResources(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot get types to export")
+	}
+
+	fetcher := NewUnstructuredFetcher(e.dynamicClient, e.options)
+	plans := make([]TypePlan, 0, len(gvrs))
+	for _, gvr := range gvrs {
+		c, err := fetcher.CountResources(ctx, gvr)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot count %q resources", gvr.GroupResource())
+		}
+		plans = append(plans, TypePlan{Type: gvr.GroupResource().String(), ResourceCounts: c})
+	}
+	sort.Slice(plans, func(i, j int) bool {
+		return plans[i].Type < plans[j].Type
+	})
+	return plans, nil
+}
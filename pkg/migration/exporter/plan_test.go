@@ -0,0 +1,78 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	apiextensionsfake "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/fake"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestControlPlaneStateExporterPlan(t *testing.T) {
+	configMap := func(namespace, name string, labels map[string]string) runtime.Object {
+		u := &unstructured.Unstructured{}
+		u.SetAPIVersion("v1")
+		u.SetKind("ConfigMap")
+		u.SetNamespace(namespace)
+		u.SetName(name)
+		u.SetLabels(labels)
+		return u
+	}
+	objs := []runtime.Object{
+		configMap("default", "settings", nil),
+		configMap("default", "kube-root-ca.crt", nil),
+		configMap("default", "chart-values", map[string]string{"app.kubernetes.io/managed-by": "Helm"}),
+		configMap("kube-system", "coredns", nil),
+	}
+	gvr := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(gvr.GroupVersion().WithKind("ConfigMap"), meta.RESTScopeNamespace)
+
+	output := filepath.Join(t.TempDir(), "xp-state.tar.gz")
+	kube := kubefake.NewSimpleClientset()
+	e := NewControlPlaneStateExporter(
+		apiextensionsfake.NewSimpleClientset(),
+		dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{gvr: "ConfigMapList"}, objs...),
+		kube.Discovery(),
+		kube.AppsV1(),
+		mapper,
+		Options{
+			OutputArchive:         output,
+			IncludeExtraResources: []string{"configmaps"},
+			ExcludeNamespaces:     []string{"kube-system"},
+		})
+
+	got, err := e.Plan(context.Background())
+	if err != nil {
+		t.Fatalf("Plan(): unexpected error: %v", err)
+	}
+	want := []TypePlan{{Type: "configmaps", ResourceCounts: ResourceCounts{Total: 4, Exported: 1, Skipped: 3}}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Plan(): -want, +got:\n%s", diff)
+	}
+	if _, err := os.Stat(output); !os.IsNotExist(err) {
+		t.Errorf("Plan(): wrote %q", output)
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"context"
+	"sort"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
+)
+
+var (
+	priorityClassesGVR = schema.GroupVersionResource{Group: "scheduling.k8s.io", Version: "v1", Resource: "priorityclasses"}
+	podsGVR            = schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+)
+
+// namespacePriorities returns the priority of every namespace running pods,
+// which is the highest priority of its pods, together with the priority of
+// the global default PriorityClass. Pods without a resolved priority get the
+// priority of their PriorityClass. Only the priorities are kept while paging
+// through the pods.
+func namespacePriorities(ctx context.Context, f *UnstructuredFetcher) (map[string]int64, int64, error) {
+	values := map[string]int64{}
+	var def int64
+	err := f.listAll(ctx, priorityClassesGVR, func(c unstructured.Unstructured) error {
+		p := fieldpath.Pave(c.Object)
+		v, err := p.GetInteger("value")
+		if err != nil {
+			return errors.Wrapf(err, "cannot get value of priority class %q", c.GetName())
+		}
+		values[c.GetName()] = v
+		if g, _ := p.GetBool("globalDefault"); g {
+			def = v
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "cannot list priority classes")
+	}
+
+	priorities := map[string]int64{}
+	err = f.listAll(ctx, podsGVR, func(pod unstructured.Unstructured) error {
+		p := fieldpath.Pave(pod.Object)
+		v, err := p.GetInteger("spec.priority")
+		if err != nil {
+			v = def
+			if c, _ := p.GetString("spec.priorityClassName"); c != "" {
+				v = values[c]
+			}
+		}
+		if cur, ok := priorities[pod.GetNamespace()]; !ok || v > cur {
+			priorities[pod.GetNamespace()] = v
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "cannot list pods")
+	}
+	return priorities, def, nil
+}
+
+// sortByPriority sorts crds such that types with resources in namespaces of
+// higher priority come first. The priority of a type is the highest priority
+// of the namespaces its resources are in. Cluster scoped types and types
+// without resources have the priority of the global default PriorityClass.
+// The order of types with the same priority is kept. The priorities are
+// computed once per exporter.
+func (e *ControlPlaneStateExporter) sortByPriority(ctx context.Context, crds []apiextensionsv1.CustomResourceDefinition) error {
+	if e.metadataClient == nil {
+		return errors.New("cannot list resources by priority without a metadata client")
+	}
+	if e.typePriorities == nil {
+		e.typePriorities = map[string]int64{}
+	}
+	// Paging and retries are those of the fetcher, but without the selectors
+	// of the exported resources.
+	f := NewUnstructuredFetcher(e.dynamicClient, Options{Fetch: FetchOptions{
+		MaxRetries:   e.options.Fetch.MaxRetries,
+		RetryBackoff: e.options.Fetch.RetryBackoff,
+	}})
+
+	var nsPriorities map[string]int64
+	var def int64
+	for _, crd := range crds {
+		if _, ok := e.typePriorities[crd.GetName()]; ok {
+			continue
+		}
+		if nsPriorities == nil {
+			var err error
+			if nsPriorities, def, err = namespacePriorities(ctx, f); err != nil {
+				return err
+			}
+		}
+		v, err := e.typePriority(ctx, f, crd, nsPriorities, def)
+		if err != nil {
+			return err
+		}
+		e.typePriorities[crd.GetName()] = v
+	}
+
+	sort.SliceStable(crds, func(i, j int) bool {
+		return e.typePriorities[crds[i].GetName()] > e.typePriorities[crds[j].GetName()]
+	})
+	return nil
+}
+
+// typePriority returns the highest priority of the namespaces the resources
+// of crd are in, or def if it is higher. Only the metadata of the resources
+// is listed.
+func (e *ControlPlaneStateExporter) typePriority(ctx context.Context, f *UnstructuredFetcher, crd apiextensionsv1.CustomResourceDefinition, nsPriorities map[string]int64, def int64) (int64, error) {
+	if crd.Spec.Scope != apiextensionsv1.NamespaceScoped {
+		return def, nil
+	}
+	gvr, err := e.customResourceGVR(crd)
+	if err != nil {
+		return 0, errors.Wrapf(err, "cannot get GVR for %q", crd.GetName())
+	}
+	v := def
+	err = f.listMetadata(ctx, e.metadataClient, gvr, func(r v1.PartialObjectMetadata) error {
+		if p, ok := nsPriorities[r.GetNamespace()]; ok && p > v {
+			v = p
+		}
+		return nil
+	})
+	return v, errors.Wrapf(err, "cannot list %q resources", crd.GetName())
+}
@@ -0,0 +1,148 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+	metadatafake "k8s.io/client-go/metadata/fake"
+)
+
+func TestSortByPriority(t *testing.T) {
+	gv := schema.GroupVersion{Group: "example.org", Version: "v1"}
+	crd := func(kind, plural string, scope apiextensionsv1.ResourceScope) apiextensionsv1.CustomResourceDefinition {
+		c := apiextensionsv1.CustomResourceDefinition{}
+		c.SetName(plural + "." + gv.Group)
+		c.Spec.Group = gv.Group
+		c.Spec.Scope = scope
+		c.Spec.Names.Kind = kind
+		c.Spec.Names.Plural = plural
+		c.Spec.Versions = []apiextensionsv1.CustomResourceDefinitionVersion{{Name: gv.Version, Storage: true}}
+		return c
+	}
+	partial := func(kind, namespace, name string) *v1.PartialObjectMetadata {
+		m := &v1.PartialObjectMetadata{}
+		m.SetGroupVersionKind(gv.WithKind(kind))
+		m.SetNamespace(namespace)
+		m.SetName(name)
+		return m
+	}
+	object := func(gvk schema.GroupVersionKind, namespace, name string, fields map[string]any) *unstructured.Unstructured {
+		u := &unstructured.Unstructured{Object: fields}
+		if u.Object == nil {
+			u.Object = map[string]any{}
+		}
+		u.SetGroupVersionKind(gvk)
+		u.SetNamespace(namespace)
+		u.SetName(name)
+		return u
+	}
+	priorityClass := schema.GroupVersionKind{Group: "scheduling.k8s.io", Version: "v1", Kind: "PriorityClass"}
+	pod := schema.GroupVersionKind{Version: "v1", Kind: "Pod"}
+
+	type args struct {
+		crds    []apiextensionsv1.CustomResourceDefinition
+		objects []runtime.Object
+		// resources are the custom resources, listed by their metadata.
+		resources []runtime.Object
+	}
+	type want struct {
+		order []string
+		// lists is the number of metadata lists, which happen only once
+		// per type.
+		lists int
+	}
+
+	cases := map[string]struct {
+		args args
+		want want
+	}{
+		"HighestNamespacePriorityFirst": {
+			args: args{
+				crds: []apiextensionsv1.CustomResourceDefinition{
+					crd("Bucket", "buckets", apiextensionsv1.NamespaceScoped),
+					crd("ClusterThing", "clusterthings", apiextensionsv1.ClusterScoped),
+					crd("Empty", "empties", apiextensionsv1.NamespaceScoped),
+					crd("Database", "databases", apiextensionsv1.NamespaceScoped),
+				},
+				objects: []runtime.Object{
+					object(priorityClass, "", "high", map[string]any{"value": int64(1000)}),
+					object(priorityClass, "", "low", map[string]any{"value": int64(10), "globalDefault": true}),
+					object(pod, "critical", "api", map[string]any{"spec": map[string]any{"priorityClassName": "high"}}),
+					object(pod, "batch", "job", map[string]any{"spec": map[string]any{"priority": int64(5)}}),
+				},
+				resources: []runtime.Object{
+					partial("Bucket", "batch", "logs"),
+					partial("Database", "batch", "reports"),
+					partial("Database", "critical", "orders"),
+				},
+			},
+			want: want{
+				// Ties keep their order. Buckets in the "batch" namespace do not
+				// fall below the global default priority.
+				order: []string{"databases.example.org", "buckets.example.org", "clusterthings.example.org", "empties.example.org"},
+				lists: 3,
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{gv})
+			listKinds := map[schema.GroupVersionResource]string{
+				priorityClassesGVR: "PriorityClassList",
+				podsGVR:            "PodList",
+			}
+			for _, c := range tc.args.crds {
+				scope := meta.RESTScopeNamespace
+				if c.Spec.Scope == apiextensionsv1.ClusterScoped {
+					scope = meta.RESTScopeRoot
+				}
+				mapper.Add(gv.WithKind(c.Spec.Names.Kind), scope)
+			}
+			scheme := runtime.NewScheme()
+			if err := v1.AddMetaToScheme(scheme); err != nil {
+				t.Fatalf("cannot add meta types to scheme: %v", err)
+			}
+			mc := metadatafake.NewSimpleMetadataClient(scheme, tc.args.resources...)
+			e := NewControlPlaneStateExporter(nil, fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds, tc.args.objects...), nil, nil, mapper, Options{}, WithMetadataClient(mc))
+
+			// Sorting again must not list the resources again.
+			for i := 0; i < 2; i++ {
+				if err := e.sortByPriority(context.Background(), tc.args.crds); err != nil {
+					t.Fatalf("sortByPriority() unexpected error: %v", err)
+				}
+			}
+			got := make([]string, 0, len(tc.args.crds))
+			for _, c := range tc.args.crds {
+				got = append(got, c.GetName())
+			}
+			if diff := cmp.Diff(tc.want.order, got); diff != "" {
+				t.Errorf("sortByPriority() order mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.want.lists, len(mc.Actions())); diff != "" {
+				t.Errorf("sortByPriority() metadata lists mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/pterm/pterm"
+)
+
+// A ProgressPrinter reports the progress of the phases of an export, e.g.
+// exporting the Crossplane or the native resource types.
+type ProgressPrinter interface {
+	// StartPhase starts a phase exporting the supplied number of types.
+	StartPhase(title string, types int)
+	// TypeExported records that the count resources of the supplied group
+	// resource were exported. It may be called concurrently.
+	TypeExported(groupResource string, count int)
+	// StopPhase stops the current phase.
+	StopPhase()
+}
+
+// NopProgressPrinter does not report any progress.
+type NopProgressPrinter struct{}
+
+// StartPhase does nothing.
+func (NopProgressPrinter) StartPhase(string, int) {}
+
+// TypeExported does nothing.
+func (NopProgressPrinter) TypeExported(string, int) {}
+
+// StopPhase does nothing.
+func (NopProgressPrinter) StopPhase() {}
+
+// ProgressBarPrinter reports the progress of each phase as a progress bar,
+// advancing by one for each exported type.
+type ProgressBarPrinter struct {
+	mu    sync.Mutex
+	title string
+	bar   *pterm.ProgressbarPrinter
+}
+
+// NewProgressBarPrinter returns a new ProgressBarPrinter.
+func NewProgressBarPrinter() *ProgressBarPrinter {
+	return &ProgressBarPrinter{}
+}
+
+// StartPhase starts a progress bar for the phase.
+func (p *ProgressBarPrinter) StartPhase(title string, types int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if types == 0 {
+		return
+	}
+	p.title = title
+	// Progress is best effort, so errors rendering it are ignored.
+	p.bar, _ = pterm.DefaultProgressbar.WithTotal(types).WithTitle(title).WithRemoveWhenDone(false).Start()
+}
+
+// TypeExported advances the progress bar of the current phase.
+func (p *ProgressBarPrinter) TypeExported(groupResource string, count int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.bar == nil {
+		return
+	}
+	p.bar.UpdateTitle(fmt.Sprintf("%s (%s: %d)", p.title, groupResource, count))
+	p.bar.Increment()
+}
+
+// StopPhase stops the progress bar of the current phase.
+func (p *ProgressBarPrinter) StopPhase() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.bar == nil {
+		return
+	}
+	p.bar.UpdateTitle(p.title)
+	_, _ = p.bar.Stop()
+	p.bar = nil
+}
@@ -0,0 +1,132 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"context"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+)
+
+// exportVerbs are the verbs the exporter needs on every exported type.
+var exportVerbs = []string{"list", "get"}
+
+// pauseVerbs are the verbs the exporter needs on every managed resource type
+// to pause the managed resources, which are updated with the pause
+// annotation.
+var pauseVerbs = []string{"update"}
+
+var selfSubjectAccessReviewsGVR = authorizationv1.SchemeGroupVersion.WithResource("selfsubjectaccessreviews")
+
+// RBACPreflightChecker checks that the exporter is allowed to read all
+// exported types across all namespaces, so that an export does not fail
+// midway due to missing permissions.
+type RBACPreflightChecker struct {
+	dynamicClient dynamic.Interface
+}
+
+// NewRBACPreflightChecker returns a new RBACPreflightChecker.
+func NewRBACPreflightChecker(dyn dynamic.Interface) *RBACPreflightChecker {
+	return &RBACPreflightChecker{
+		dynamicClient: dyn,
+	}
+}
+
+// Check reviews the access of the exporter to the supplied types. It returns
+// the types all required verbs are allowed on, and an error for every verb
+// that is denied or cannot be reviewed.
+func (c *RBACPreflightChecker) Check(ctx context.Context, gvrs []schema.GroupVersionResource) ([]schema.GroupVersionResource, []error) {
+	return c.check(ctx, gvrs, exportVerbs)
+}
+
+// CheckPause reviews the access of the exporter to the supplied managed
+// resource types required to pause them before the export. It returns an
+// error for every verb that is denied or cannot be reviewed.
+func (c *RBACPreflightChecker) CheckPause(ctx context.Context, gvrs []schema.GroupVersionResource) []error {
+	_, errs := c.check(ctx, gvrs, pauseVerbs)
+	return errs
+}
+
+// check returns the types all of the verbs are allowed on, and an error for
+// every verb that is denied or cannot be reviewed.
+func (c *RBACPreflightChecker) check(ctx context.Context, gvrs []schema.GroupVersionResource, verbs []string) ([]schema.GroupVersionResource, []error) {
+	allowed := make([]schema.GroupVersionResource, 0, len(gvrs))
+	var errs []error
+	for _, gvr := range gvrs {
+		ok := true
+		for _, verb := range verbs {
+			status, err := c.review(ctx, gvr, verb)
+			if err != nil {
+				errs = append(errs, errors.Wrapf(err, "Cannot review permission to %s %q", verb, gvr.GroupResource()))
+				ok = false
+				continue
+			}
+			if !status.Allowed {
+				errs = append(errs, deniedError(gvr, verb, status))
+				ok = false
+			}
+		}
+		if ok {
+			allowed = append(allowed, gvr)
+		}
+	}
+	return allowed, errs
+}
+
+// review creates a SelfSubjectAccessReview for the verb on the type in all
+// namespaces. The dynamic client is used as it is the only client the
+// exporter has for arbitrary API groups.
+func (c *RBACPreflightChecker) review(ctx context.Context, gvr schema.GroupVersionResource, verb string) (authorizationv1.SubjectAccessReviewStatus, error) {
+	ssar := &authorizationv1.SelfSubjectAccessReview{
+		TypeMeta: v1.TypeMeta{
+			APIVersion: authorizationv1.SchemeGroupVersion.String(),
+			Kind:       "SelfSubjectAccessReview",
+		},
+		Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Verb:     verb,
+				Group:    gvr.Group,
+				Version:  gvr.Version,
+				Resource: gvr.Resource,
+			},
+		},
+	}
+	obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(ssar)
+	if err != nil {
+		return authorizationv1.SubjectAccessReviewStatus{}, errors.Wrap(err, "cannot convert SelfSubjectAccessReview")
+	}
+	res, err := c.dynamicClient.Resource(selfSubjectAccessReviewsGVR).Create(ctx, &unstructured.Unstructured{Object: obj}, v1.CreateOptions{})
+	if err != nil {
+		return authorizationv1.SubjectAccessReviewStatus{}, errors.Wrap(err, "cannot create SelfSubjectAccessReview")
+	}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(res.Object, ssar); err != nil {
+		return authorizationv1.SubjectAccessReviewStatus{}, errors.Wrap(err, "cannot convert SelfSubjectAccessReview")
+	}
+	return ssar.Status, nil
+}
+
+func deniedError(gvr schema.GroupVersionResource, verb string, status authorizationv1.SubjectAccessReviewStatus) error {
+	if status.Reason != "" {
+		return errors.Errorf("Missing permission to %s %q in all namespaces: %s", verb, gvr.GroupResource(), status.Reason)
+	}
+	return errors.Errorf("Missing permission to %s %q in all namespaces", verb, gvr.GroupResource())
+}
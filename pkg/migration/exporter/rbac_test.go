@@ -0,0 +1,139 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+)
+
+func TestRBACPreflightCheckerCheck(t *testing.T) {
+	errBoom := errors.New("boom")
+	buckets := schema.GroupVersionResource{Group: "s3.aws.upbound.io", Version: "v1beta1", Resource: "buckets"}
+	secrets := schema.GroupVersionResource{Version: "v1", Resource: "secrets"}
+
+	type args struct {
+		gvrs []schema.GroupVersionResource
+		// denied maps resources to the verbs denied on them.
+		denied map[string][]string
+		reason string
+		err    error
+	}
+	type want struct {
+		allowed []schema.GroupVersionResource
+		errs    []error
+	}
+
+	cases := map[string]struct {
+		args args
+		want want
+	}{
+		"AllAllowed": {
+			args: args{
+				gvrs: []schema.GroupVersionResource{buckets, secrets},
+			},
+			want: want{
+				allowed: []schema.GroupVersionResource{buckets, secrets},
+			},
+		},
+		"MissingPermissions": {
+			args: args{
+				gvrs: []schema.GroupVersionResource{buckets, secrets},
+				denied: map[string][]string{
+					"secrets": {"list", "get"},
+				},
+			},
+			want: want{
+				allowed: []schema.GroupVersionResource{buckets},
+				errs: []error{
+					errors.New(`Missing permission to list "secrets" in all namespaces`),
+					errors.New(`Missing permission to get "secrets" in all namespaces`),
+				},
+			},
+		},
+		"MissingPermissionWithReason": {
+			args: args{
+				gvrs: []schema.GroupVersionResource{buckets},
+				denied: map[string][]string{
+					"buckets": {"get"},
+				},
+				reason: "no RBAC policy matched",
+			},
+			want: want{
+				allowed: []schema.GroupVersionResource{},
+				errs: []error{
+					errors.New(`Missing permission to get "buckets.s3.aws.upbound.io" in all namespaces: no RBAC policy matched`),
+				},
+			},
+		},
+		"ReviewFailed": {
+			args: args{
+				gvrs: []schema.GroupVersionResource{buckets},
+				err:  errBoom,
+			},
+			want: want{
+				allowed: []schema.GroupVersionResource{},
+				errs: []error{
+					errors.Wrap(errors.Wrap(errBoom, "cannot create SelfSubjectAccessReview"), `Cannot review permission to list "buckets.s3.aws.upbound.io"`),
+					errors.Wrap(errors.Wrap(errBoom, "cannot create SelfSubjectAccessReview"), `Cannot review permission to get "buckets.s3.aws.upbound.io"`),
+				},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			dyn := fake.NewSimpleDynamicClient(runtime.NewScheme())
+			// The API server answers SelfSubjectAccessReviews in the
+			// status of the created review.
+			dyn.PrependReactor("create", "selfsubjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				if tc.args.err != nil {
+					return true, nil, tc.args.err
+				}
+				u := action.(k8stesting.CreateAction).GetObject().(*unstructured.Unstructured).DeepCopy()
+				verb, _, _ := unstructured.NestedString(u.Object, "spec", "resourceAttributes", "verb")
+				resource, _, _ := unstructured.NestedString(u.Object, "spec", "resourceAttributes", "resource")
+				allowed := true
+				for _, v := range tc.args.denied[resource] {
+					if v == verb {
+						allowed = false
+					}
+				}
+				_ = unstructured.SetNestedField(u.Object, allowed, "status", "allowed")
+				if !allowed && tc.args.reason != "" {
+					_ = unstructured.SetNestedField(u.Object, tc.args.reason, "status", "reason")
+				}
+				return true, u, nil
+			})
+
+			allowed, errs := NewRBACPreflightChecker(dyn).Check(context.Background(), tc.args.gvrs)
+			if diff := cmp.Diff(tc.want.allowed, allowed); diff != "" {
+				t.Errorf("\n%s\nCheck(...): allowed: -want, +got:\n%s", name, diff)
+			}
+			if diff := cmp.Diff(tc.want.errs, errs, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nCheck(...): errs: -want, +got:\n%s", name, diff)
+			}
+		})
+	}
+}
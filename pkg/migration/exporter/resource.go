@@ -21,10 +21,15 @@ import (
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 
+	"github.com/upbound/up/pkg/migration/telemetry"
+
 	"github.com/crossplane/crossplane-runtime/pkg/errors"
 	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
 )
 
+// persistBatchSize is the number of resources persisted at once.
+const persistBatchSize = 500
+
 type ResourceExporter interface {
 	ExportResources(ctx context.Context, gvr schema.GroupVersionResource) (count int, err error)
 }
@@ -32,39 +37,126 @@ type ResourceExporter interface {
 type UnstructuredExporter struct {
 	fetcher   ResourceFetcher
 	persister ResourcePersister
+	observers []ResourceObserver
+	sorter    ResourceSorter
 }
 
-func NewUnstructuredExporter(f ResourceFetcher, p ResourcePersister) *UnstructuredExporter {
-	return &UnstructuredExporter{
+// UnstructuredExporterOption configures an UnstructuredExporter.
+type UnstructuredExporterOption func(*UnstructuredExporter)
+
+// WithResourceObservers passes the fetched resources to the supplied
+// observers before cluster specific data is removed from them.
+func WithResourceObservers(o ...ResourceObserver) UnstructuredExporterOption {
+	return func(e *UnstructuredExporter) {
+		e.observers = append(e.observers, o...)
+	}
+}
+
+// WithResourceSorter orders the fetched resources with the supplied sorter.
+// The order is persisted if it matters and the persister supports it.
+func WithResourceSorter(s ResourceSorter) UnstructuredExporterOption {
+	return func(e *UnstructuredExporter) {
+		e.sorter = s
+	}
+}
+
+func NewUnstructuredExporter(f ResourceFetcher, p ResourcePersister, opts ...UnstructuredExporterOption) *UnstructuredExporter {
+	e := &UnstructuredExporter{
 		fetcher:   f,
 		persister: p,
 	}
+	for _, o := range opts {
+		o(e)
+	}
+	return e
 }
 
+// ExportResources streams the resources of the supplied type from the fetcher
+// to the persister in batches, so that only a batch of them is kept in memory
+// at a time, and returns how many were exported.
 func (e *UnstructuredExporter) ExportResources(ctx context.Context, gvr schema.GroupVersionResource) (int, error) {
-	resources, err := e.fetcher.FetchResources(ctx, gvr)
-	if err != nil {
-		return 0, errors.Wrap(err, "cannot fetch resources")
+	gr := gvr.GroupResource().String()
+	attr := telemetry.GroupResourceKey.String(gr)
+
+	count := 0
+	batch := make([]unstructured.Unstructured, 0, persistBatchSize)
+	persist := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		pctx, span := telemetry.StartSpan(ctx, "PersistResources", attr)
+		err := e.persister.PersistResources(pctx, gr, batch)
+		telemetry.EndSpan(span, err)
+		if err != nil {
+			return errors.Wrap(err, "cannot persist resources")
+		}
+		count += len(batch)
+		batch = batch[:0]
+		return nil
 	}
 
-	for i := range resources {
-		if err := cleanupClusterSpecificData(&resources[i]); err != nil {
-			return 0, errors.Wrap(err, "cannot cleanup cluster specific data")
+	// Only what is needed to sort the resources is kept of all of them.
+	var keys []unstructured.Unstructured
+	var persistErr error
+	fctx, span := telemetry.StartSpan(ctx, "FetchResources", attr)
+	err := e.fetcher.StreamResources(fctx, gvr, func(r unstructured.Unstructured) error {
+		for _, o := range e.observers {
+			o.ObserveResources(gr, []unstructured.Unstructured{r})
+		}
+		if e.sorter != nil {
+			keys = append(keys, orderKey(r))
 		}
+		if err := cleanupClusterSpecificData(&r); err != nil {
+			persistErr = errors.Wrap(err, "cannot cleanup cluster specific data")
+			return persistErr
+		}
+		batch = append(batch, r)
+		if len(batch) < persistBatchSize {
+			return nil
+		}
+		persistErr = persist()
+		return persistErr
+	})
+	telemetry.EndSpan(span, err)
+	if persistErr != nil {
+		return 0, persistErr
+	}
+	if err != nil {
+		return 0, errors.Wrap(err, "cannot fetch resources")
+	}
+	if err := persist(); err != nil {
+		return 0, err
 	}
 
-	if err = e.persister.PersistResources(ctx, gvr.GroupResource().String(), resources); err != nil {
-		return 0, errors.Wrap(err, "cannot persist resources")
+	if e.sorter == nil {
+		return count, nil
 	}
+	keys, ordered := e.sorter.SortResources(keys)
+	if op, ok := e.persister.(OrderPersister); ok && ordered {
+		if err := op.PersistOrder(gr, keys); err != nil {
+			return 0, errors.Wrap(err, "cannot persist resource order")
+		}
+	}
+	return count, nil
+}
 
-	return len(resources), nil
+// orderKey returns a copy of the resource with only the metadata needed to
+// sort it and to persist its order.
+func orderKey(r unstructured.Unstructured) unstructured.Unstructured {
+	k := unstructured.Unstructured{Object: map[string]any{}}
+	k.SetNamespace(r.GetNamespace())
+	k.SetName(r.GetName())
+	k.SetUID(r.GetUID())
+	k.SetOwnerReferences(r.GetOwnerReferences())
+	return k
 }
 
 func cleanupClusterSpecificData(u *unstructured.Unstructured) error {
 	paved := fieldpath.Pave(u.Object)
 
 	// Remove cluster specific data. Similar to Velero: https://github.com/vmware-tanzu/velero/blob/a81e049d362557c311cf8615c2c9c8bf77edf969/pkg/restore/restore.go#L2045
-	for _, f := range []string{"generateName", "selfLink", "uid", "resourceVersion", "generation", "creationTimestamp", "ownerReferences", "managedFields"} {
+	// The UID is kept, so that it can be preserved on import if requested.
+	for _, f := range []string{"generateName", "selfLink", "resourceVersion", "generation", "creationTimestamp", "ownerReferences", "managedFields"} {
 		err := paved.DeleteField(fmt.Sprintf("metadata.%s", f))
 		if err != nil {
 			return errors.Wrapf(err, "cannot delete %q field", f)
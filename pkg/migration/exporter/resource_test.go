@@ -0,0 +1,103 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+)
+
+// batchRecorder records the sizes of the persisted batches.
+type batchRecorder struct {
+	batches []int
+	err     error
+}
+
+func (p *batchRecorder) PersistResources(_ context.Context, _ string, resources []unstructured.Unstructured) error {
+	p.batches = append(p.batches, len(resources))
+	for _, r := range resources {
+		if len(r.GetOwnerReferences()) > 0 {
+			return errors.New("owner references were not removed")
+		}
+	}
+	return p.err
+}
+
+func TestUnstructuredExporterExportResources(t *testing.T) {
+	things := func(n int) staticFetcher {
+		f := make(staticFetcher, n)
+		for i := range f {
+			f[i] = thing(fmt.Sprintf("thing-%d", i), "owner")
+		}
+		return f
+	}
+
+	type want struct {
+		count   int
+		batches []int
+		err     bool
+	}
+	cases := map[string]struct {
+		reason    string
+		fetcher   staticFetcher
+		persister *batchRecorder
+		want      want
+	}{
+		"None": {
+			reason:    "Nothing should be persisted if there are no resources.",
+			persister: &batchRecorder{},
+		},
+		"Batches": {
+			reason:    "The resources should be persisted in batches without cluster specific data.",
+			fetcher:   things(2*persistBatchSize + 1),
+			persister: &batchRecorder{},
+			want: want{
+				count:   2*persistBatchSize + 1,
+				batches: []int{persistBatchSize, persistBatchSize, 1},
+			},
+		},
+		"PersistError": {
+			reason:    "Fetching should stop at the first batch that cannot be persisted.",
+			fetcher:   things(2*persistBatchSize + 1),
+			persister: &batchRecorder{err: errors.New("boom")},
+			want: want{
+				batches: []int{persistBatchSize},
+				err:     true,
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			e := NewUnstructuredExporter(tc.fetcher, tc.persister)
+			count, err := e.ExportResources(context.Background(), schema.GroupVersionResource{Group: "example.org", Version: "v1", Resource: "things"})
+			if diff := cmp.Diff(tc.want.err, err != nil); diff != "" {
+				t.Fatalf("\n%s\nExportResources(...): -want error, +got error:\n%s\n%v", tc.reason, diff, err)
+			}
+			if diff := cmp.Diff(tc.want.count, count); diff != "" {
+				t.Errorf("\n%s\nExportResources(...): -want count, +got count:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.batches, tc.persister.batches); diff != "" {
+				t.Errorf("\n%s\nExportResources(...): -want batches, +got batches:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"context"
+	"io"
+	"path"
+	"path/filepath"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"github.com/spf13/afero"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+
+	"github.com/upbound/up/pkg/migration/archiver"
+)
+
+const defaultArchiveName = "xp-state"
+
+// s3Key returns the key of the archive object in the S3 bucket, which is the
+// base name of the output archive below the configured prefix.
+func (e *ControlPlaneStateExporter) s3Key(ext string) string {
+	name := defaultArchiveName
+	if o := e.options.OutputArchive; o != "" && o != "-" {
+		name = filepath.Base(o)
+	}
+	if filepath.Ext(name) == "" {
+		name += ext
+	}
+	return path.Join(e.options.S3Prefix, name)
+}
+
+// uploadToS3 streams the archive of the exported state in dir to the
+// configured S3 bucket, without storing it locally.
+func (e *ControlPlaneStateExporter) uploadToS3(ctx context.Context, fs afero.Afero, dir string, alg archiver.Compression, opts ...archiver.ArchiveOption) error {
+	cfg := aws.NewConfig()
+	if e.options.S3Region != "" {
+		cfg = cfg.WithRegion(e.options.S3Region)
+	}
+	if e.options.S3Endpoint != "" {
+		// S3-compatible stores like MinIO generally do not support virtual
+		// hosted-style bucket addressing.
+		cfg = cfg.WithEndpoint(e.options.S3Endpoint).WithS3ForcePathStyle(true)
+	}
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Config:            *cfg,
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return errors.Wrap(err, "cannot create AWS session")
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(archiver.Archive(ctx, fs, dir, pw, opts...))
+	}()
+
+	key := e.s3Key(archiver.Extension(alg))
+	_, err = s3manager.NewUploader(sess).UploadWithContext(ctx, &s3manager.UploadInput{
+		Bucket: aws.String(e.options.S3Bucket),
+		Key:    aws.String(key),
+		Body:   pr,
+	})
+	// Unblock the archiver if the upload stopped reading early.
+	_ = pr.CloseWithError(err)
+	return errors.Wrapf(err, "cannot upload archive to s3://%s/%s", e.options.S3Bucket, key)
+}
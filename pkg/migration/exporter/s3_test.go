@@ -0,0 +1,130 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/afero"
+	apiextensionsfake "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/fake"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/upbound/up/pkg/migration/archiver"
+)
+
+// s3Mock is a minimal S3-compatible server storing the objects put into it.
+type s3Mock struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func (s *s3Mock) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		w.WriteHeader(http.StatusNotImplemented)
+		return
+	}
+	b, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.objects[r.URL.Path] = b
+	w.WriteHeader(http.StatusOK)
+}
+
+func TestControlPlaneStateExporterS3(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "access")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(t.TempDir(), "config"))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(t.TempDir(), "credentials"))
+
+	type args struct {
+		output      string
+		prefix      string
+		compression archiver.Compression
+	}
+	cases := map[string]struct {
+		args args
+		want string
+	}{
+		"DefaultName": {
+			args: args{},
+			want: "/bucket/xp-state.tar.gz",
+		},
+		"OutputName": {
+			args: args{output: "/tmp/my-export.tar.gz"},
+			want: "/bucket/my-export.tar.gz",
+		},
+		"PrefixAndZstd": {
+			args: args{output: "xp-state", prefix: "exports/prod", compression: archiver.CompressionZstd},
+			want: "/bucket/exports/prod/xp-state.tar.zst",
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			mock := &s3Mock{objects: map[string][]byte{}}
+			srv := httptest.NewServer(mock)
+			defer srv.Close()
+
+			kube := kubefake.NewSimpleClientset()
+			e := NewControlPlaneStateExporter(
+				apiextensionsfake.NewSimpleClientset(),
+				dynamicfake.NewSimpleDynamicClient(runtime.NewScheme()),
+				kube.Discovery(),
+				kube.AppsV1(),
+				meta.NewDefaultRESTMapper(nil),
+				Options{
+					OutputArchive:        tc.args.output,
+					S3Bucket:             "bucket",
+					S3Prefix:             tc.args.prefix,
+					S3Region:             "us-east-1",
+					S3Endpoint:           srv.URL,
+					CompressionAlgorithm: tc.args.compression,
+				})
+			if err := e.Export(context.Background()); err != nil {
+				t.Fatalf("Export() unexpected error: %v", err)
+			}
+
+			keys := make([]string, 0, len(mock.objects))
+			for k := range mock.objects {
+				keys = append(keys, k)
+			}
+			if diff := cmp.Diff([]string{tc.want}, keys); diff != "" {
+				t.Fatalf("uploaded objects: -want, +got:\n%s", diff)
+			}
+
+			fs := afero.Afero{Fs: afero.NewMemMapFs()}
+			if err := archiver.Unarchive(context.Background(), bytes.NewReader(mock.objects[tc.want]), fs); err != nil {
+				t.Fatalf("cannot unarchive uploaded export: %v", err)
+			}
+			if ok, _ := fs.Exists("export.yaml"); !ok {
+				t.Errorf("uploaded export does not contain export metadata")
+			}
+		})
+	}
+}
@@ -0,0 +1,263 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/afero"
+	"sigs.k8s.io/yaml"
+
+	"github.com/upbound/up/pkg/migration/archiver"
+	"github.com/upbound/up/pkg/migration/meta/v1alpha1"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+)
+
+const (
+	segmentManifestFile = "segments.yaml"
+	// clusterSegment is the name of the segment with the cluster scoped
+	// resources. Namespace names cannot start with an underscore.
+	clusterSegment = "_cluster"
+)
+
+// validateSegmentOptions returns an error if the options cannot be combined
+// with segmenting the export by namespace.
+func validateSegmentOptions(opts Options) error {
+	switch {
+	case opts.OutputFormat == OutputFormatDirectory:
+		return errors.New("cannot segment an export to a directory")
+	case opts.S3Bucket != "":
+		return errors.New("cannot upload a segmented export to an S3 bucket")
+	case opts.OutputOCIRef != "":
+		return errors.New("cannot push a segmented export to an OCI artifact")
+	case opts.ContentAddressable:
+		return errors.New("cannot segment a content addressable export")
+	case opts.OutputArchive == "" || opts.OutputArchive == "-":
+		return errors.New("an output directory is required to segment an export")
+	}
+	return nil
+}
+
+// archiveSegments splits the exported state in dir into one archive per
+// namespace and one for the cluster scoped resources, and writes them with a
+// segment manifest to the output directory. All segments are compressed with
+// the supplied level.
+func (e *ControlPlaneStateExporter) archiveSegments(ctx context.Context, fs afero.Afero, dir string, level int) error {
+	alg, opts, err := e.archiveOptions(level)
+	if err != nil {
+		return err
+	}
+
+	segDir, err := fs.TempDir("", "up-segments")
+	if err != nil {
+		return errors.Wrap(err, "cannot create temporary directory")
+	}
+	defer func() {
+		_ = fs.RemoveAll(segDir)
+	}()
+	namespaces, err := splitSegments(fs, dir, segDir)
+	if err != nil {
+		return errors.Wrap(err, "cannot split exported state into segments")
+	}
+
+	m := &v1alpha1.SegmentManifest{Segments: make([]v1alpha1.Segment, 0, len(namespaces)+1)}
+	for _, name := range append([]string{clusterSegment}, namespaces...) {
+		s := v1alpha1.Segment{Archive: name + archiver.Extension(alg)}
+		if name != clusterSegment {
+			s.Namespace = name
+		}
+		if err := archiver.WriteChecksums(fs, filepath.Join(segDir, name)); err != nil {
+			return errors.Wrapf(err, "cannot write checksums of segment %q", name)
+		}
+		if err := archiver.ArchiveFile(ctx, fs, filepath.Join(segDir, name), filepath.Join(e.options.OutputArchive, s.Archive), opts...); err != nil {
+			return errors.Wrapf(err, "cannot archive segment %q", name)
+		}
+		m.Segments = append(m.Segments, s)
+	}
+
+	b, err := yaml.Marshal(m)
+	if err != nil {
+		return errors.Wrap(err, "cannot marshal segment manifest")
+	}
+	return errors.Wrap(fs.WriteFile(filepath.Join(e.options.OutputArchive, segmentManifestFile), b, 0600), "cannot write segment manifest")
+}
+
+// splitSegments copies the exported state in dir to one directory per segment
+// in segDir, and returns the namespaces with a segment in lexicographic
+// order. Every segment gets the top level metadata files, and the metadata and
+// order manifests of the types it has resources of. Namespaces are stored in their own segment,
+// so that it can be imported on its own.
+func splitSegments(fs afero.Afero, dir, segDir string) ([]string, error) { //nolint:gocyclo // Walking the export structure is easier to follow in one place.
+	infos, err := fs.ReadDir(dir)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot read exported state")
+	}
+	cluster := filepath.Join(segDir, clusterSegment)
+	if err := fs.MkdirAll(cluster, 0700); err != nil {
+		return nil, errors.Wrapf(err, "cannot create segment %q", clusterSegment)
+	}
+
+	namespaces := map[string]struct{}{}
+	var shared []string
+	for _, info := range infos {
+		if !info.IsDir() {
+			shared = append(shared, info.Name())
+			continue
+		}
+		gr := info.Name()
+		typeMeta := filepath.Join(dir, gr, "metadata.yaml")
+		hasTypeMeta, err := fs.Exists(typeMeta)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot read metadata of %q", gr)
+		}
+		// addToSegment copies src to the path rel of the group resource in
+		// the named segment, together with the metadata of the type.
+		addToSegment := func(segment, src, rel string) error {
+			if segment != clusterSegment {
+				namespaces[segment] = struct{}{}
+			}
+			if hasTypeMeta {
+				if err := copyTree(fs, typeMeta, filepath.Join(segDir, segment, gr, "metadata.yaml")); err != nil {
+					return err
+				}
+			}
+			return copyTree(fs, src, filepath.Join(segDir, segment, gr, rel))
+		}
+
+		// Types without resources are kept in the cluster scoped segment.
+		if hasTypeMeta {
+			if err := addToSegment(clusterSegment, typeMeta, "metadata.yaml"); err != nil {
+				return nil, err
+			}
+		}
+		scoped, err := fs.ReadDir(filepath.Join(dir, gr, "cluster"))
+		if err != nil && !os.IsNotExist(err) {
+			return nil, errors.Wrapf(err, "cannot read cluster scoped %q", gr)
+		}
+		for _, f := range scoped {
+			segment := clusterSegment
+			if gr == "namespaces" {
+				segment = strings.TrimSuffix(f.Name(), ".yaml")
+			}
+			if err := addToSegment(segment, filepath.Join(dir, gr, "cluster", f.Name()), filepath.Join("cluster", f.Name())); err != nil {
+				return nil, err
+			}
+		}
+		nss, err := fs.ReadDir(filepath.Join(dir, gr, "namespaces"))
+		if err != nil && !os.IsNotExist(err) {
+			return nil, errors.Wrapf(err, "cannot read namespaced %q", gr)
+		}
+		for _, ns := range nss {
+			if err := addToSegment(ns.Name(), filepath.Join(dir, gr, "namespaces", ns.Name()), filepath.Join("namespaces", ns.Name())); err != nil {
+				return nil, err
+			}
+		}
+		if err := splitOrder(fs, dir, segDir, gr); err != nil {
+			return nil, err
+		}
+	}
+
+	sorted := make([]string, 0, len(namespaces))
+	for ns := range namespaces {
+		sorted = append(sorted, ns)
+	}
+	sort.Strings(sorted)
+	for _, f := range shared {
+		for _, segment := range append([]string{clusterSegment}, sorted...) {
+			if err := copyTree(fs, filepath.Join(dir, f), filepath.Join(segDir, segment, f)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return sorted, nil
+}
+
+// splitOrder writes the order manifest of the group resource gr in dir, if
+// it has one, to every segment in segDir with resources of gr, limited to the
+// resources of that segment.
+func splitOrder(fs afero.Afero, dir, segDir, gr string) error {
+	of := filepath.Join(dir, gr, ResourceOrderFile)
+	if ok, _ := fs.Exists(of); !ok {
+		return nil
+	}
+	b, err := fs.ReadFile(of)
+	if err != nil {
+		return errors.Wrapf(err, "cannot read order manifest %q", of)
+	}
+	order := &v1alpha1.ResourceOrder{}
+	if err := yaml.Unmarshal(b, order); err != nil {
+		return errors.Wrapf(err, "cannot unmarshal order manifest %q", of)
+	}
+
+	segments := map[string]*v1alpha1.ResourceOrder{}
+	for _, id := range order.Resources {
+		parts := strings.Split(id, "/")
+		segment := clusterSegment
+		switch {
+		case parts[0] == "namespaces" && len(parts) == 3:
+			segment = parts[1]
+		case gr == "namespaces" && len(parts) == 2:
+			// Namespaces are stored in their own segment.
+			segment = parts[1]
+		}
+		if segments[segment] == nil {
+			segments[segment] = &v1alpha1.ResourceOrder{}
+		}
+		segments[segment].Resources = append(segments[segment].Resources, id)
+	}
+	for segment, o := range segments {
+		b, err := yaml.Marshal(o)
+		if err != nil {
+			return errors.Wrap(err, "cannot marshal order manifest")
+		}
+		if err := fs.MkdirAll(filepath.Join(segDir, segment, gr), 0700); err != nil {
+			return errors.Wrapf(err, "cannot create %q of segment %q", gr, segment)
+		}
+		if err := fs.WriteFile(filepath.Join(segDir, segment, gr, ResourceOrderFile), b, 0600); err != nil {
+			return errors.Wrapf(err, "cannot write order manifest of segment %q", segment)
+		}
+	}
+	return nil
+}
+
+// copyTree copies the file or directory src to dst.
+func copyTree(fs afero.Afero, src, dst string) error {
+	return errors.Wrapf(fs.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if info.IsDir() {
+			return fs.MkdirAll(target, 0700)
+		}
+		if err := fs.MkdirAll(filepath.Dir(target), 0700); err != nil {
+			return err
+		}
+		b, err := fs.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return fs.WriteFile(target, b, 0600)
+	}), "cannot copy %q", src)
+}
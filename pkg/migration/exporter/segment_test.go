@@ -0,0 +1,89 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/afero"
+)
+
+func TestSplitSegments(t *testing.T) {
+	type want struct {
+		namespaces []string
+		files      map[string]string
+	}
+
+	cases := map[string]struct {
+		reason string
+		files  map[string]string
+		want   want
+	}{
+		"OrderManifestPerSegment": {
+			reason: "Every segment should get the order manifest of its types, limited to its resources.",
+			files: map[string]string{
+				"export.yaml":                              "version: v1alpha1\n",
+				"things.example.org/metadata.yaml":         "withStatusSubresource: true\n",
+				"things.example.org/cluster/global.yaml":   "kind: Thing\n",
+				"things.example.org/namespaces/a/one.yaml": "kind: Thing\n",
+				"things.example.org/namespaces/a/two.yaml": "kind: Thing\n",
+				"things.example.org/namespaces/b/one.yaml": "kind: Thing\n",
+				"things.example.org/order.yaml":            "resources:\n- namespaces/a/two\n- cluster/global\n- namespaces/b/one\n- namespaces/a/one\n",
+				"namespaces/cluster/a.yaml":                "kind: Namespace\n",
+				"namespaces/order.yaml":                    "resources:\n- cluster/a\n",
+			},
+			want: want{
+				namespaces: []string{"a", "b"},
+				files: map[string]string{
+					"_cluster/export.yaml":                   "version: v1alpha1\n",
+					"_cluster/things.example.org/order.yaml": "resources:\n- cluster/global\n",
+					"a/things.example.org/order.yaml":        "resources:\n- namespaces/a/two\n- namespaces/a/one\n",
+					"a/namespaces/order.yaml":                "resources:\n- cluster/a\n",
+					"b/things.example.org/order.yaml":        "resources:\n- namespaces/b/one\n",
+					"b/things.example.org/metadata.yaml":     "withStatusSubresource: true\n",
+				},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			fs := afero.Afero{Fs: afero.NewMemMapFs()}
+			for f, content := range tc.files {
+				if err := fs.WriteFile(filepath.Join("state", f), []byte(content), 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			namespaces, err := splitSegments(fs, "state", "segments")
+			if err != nil {
+				t.Fatalf("\n%s\nsplitSegments(...): unexpected error: %v", tc.reason, err)
+			}
+			if diff := cmp.Diff(tc.want.namespaces, namespaces); diff != "" {
+				t.Errorf("\n%s\nsplitSegments(...): -want, +got:\n%s", tc.reason, diff)
+			}
+			for f, want := range tc.want.files {
+				got, err := fs.ReadFile(filepath.Join("segments", f))
+				if err != nil {
+					t.Errorf("\n%s\nsplitSegments(...): cannot read %q: %v", tc.reason, f, err)
+					continue
+				}
+				if diff := cmp.Diff(want, string(got)); diff != "" {
+					t.Errorf("\n%s\nsplitSegments(...): %q: -want, +got:\n%s", tc.reason, f, diff)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"context"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+)
+
+var xrdGVR = schema.GroupVersionResource{Group: "apiextensions.crossplane.io", Version: "v1", Resource: "compositeresourcedefinitions"}
+
+// selectedXRDs returns the names of the CompositeResourceDefinitions matching
+// the composition label selector, or nil if there is no selector.
+func (e *ControlPlaneStateExporter) selectedXRDs(ctx context.Context) (map[string]struct{}, error) {
+	if e.options.CompositionLabelSelector == "" {
+		return nil, nil
+	}
+	sel, err := labels.Parse(e.options.CompositionLabelSelector)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid composition label selector %q", e.options.CompositionLabelSelector)
+	}
+
+	xrds := map[string]struct{}{}
+	continueToken := ""
+	for {
+		l, err := e.dynamicClient.Resource(xrdGVR).List(ctx, v1.ListOptions{
+			LabelSelector: sel.String(),
+			Limit:         defaultPageSize,
+			Continue:      continueToken,
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot list CompositeResourceDefinitions")
+		}
+		for _, xrd := range l.Items {
+			xrds[xrd.GetName()] = struct{}{}
+		}
+		continueToken = l.GetContinue()
+		if continueToken == "" {
+			return xrds, nil
+		}
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+func TestControlPlaneStateExporterSelectedXRDs(t *testing.T) {
+	xrd := func(name string, labels map[string]string) runtime.Object {
+		u := &unstructured.Unstructured{}
+		u.SetAPIVersion("apiextensions.crossplane.io/v1")
+		u.SetKind("CompositeResourceDefinition")
+		u.SetName(name)
+		u.SetLabels(labels)
+		return u
+	}
+	objs := []runtime.Object{
+		xrd("xdatabases.example.org", map[string]string{"team": "platform"}),
+		xrd("xnetworks.example.org", map[string]string{"team": "network"}),
+		xrd("xbuckets.example.org", nil),
+	}
+
+	type want struct {
+		xrds map[string]struct{}
+		err  bool
+	}
+	cases := map[string]struct {
+		reason   string
+		selector string
+		want     want
+	}{
+		"NoSelector": {
+			reason: "No CompositeResourceDefinitions should be selected without a selector.",
+		},
+		"Equality": {
+			reason:   "Only CompositeResourceDefinitions with matching labels should be selected.",
+			selector: "team=platform",
+			want: want{
+				xrds: map[string]struct{}{"xdatabases.example.org": {}},
+			},
+		},
+		"Set": {
+			reason:   "Set based selectors should be supported.",
+			selector: "team in (platform,network)",
+			want: want{
+				xrds: map[string]struct{}{"xdatabases.example.org": {}, "xnetworks.example.org": {}},
+			},
+		},
+		"NoMatch": {
+			reason:   "An empty selection should be returned if no CompositeResourceDefinition matches.",
+			selector: "team=data",
+			want: want{
+				xrds: map[string]struct{}{},
+			},
+		},
+		"Invalid": {
+			reason:   "An invalid selector should be reported.",
+			selector: "team in (platform",
+			want: want{
+				err: true,
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			dyn := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{xrdGVR: "CompositeResourceDefinitionList"}, objs...)
+			e := NewControlPlaneStateExporter(nil, dyn, nil, nil, nil, Options{CompositionLabelSelector: tc.selector})
+			got, err := e.selectedXRDs(context.Background())
+			if (err != nil) != tc.want.err {
+				t.Fatalf("\n%s\nselectedXRDs(...): want error %t, got %v", tc.reason, tc.want.err, err)
+			}
+			if diff := cmp.Diff(tc.want.xrds, got); diff != "" {
+				t.Errorf("\n%s\nselectedXRDs(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"os"
+	"path/filepath"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+)
+
+const (
+	// defaultBytesPerResource is the estimated size of an exported resource,
+	// including the overhead of its file in the export directory.
+	defaultBytesPerResource = 8 << 10
+	// maxStorageUsage is the share of the available space an export may be
+	// projected to use.
+	maxStorageUsage = 0.8
+)
+
+// StorageCapacityChecker checks whether the filesystem an export is staged on
+// has enough space for it, so that an export does not fail midway when the
+// disk runs full.
+type StorageCapacityChecker struct {
+	dir              string
+	bytesPerResource uint64
+	availableBytes   func(dir string) (uint64, error)
+}
+
+// NewStorageCapacityChecker returns a new StorageCapacityChecker for exports
+// staged in dir.
+func NewStorageCapacityChecker(dir string) *StorageCapacityChecker {
+	return &StorageCapacityChecker{
+		dir:              dir,
+		bytesPerResource: defaultBytesPerResource,
+		availableBytes:   availableBytes,
+	}
+}
+
+// Check returns an error if exporting the supplied number of resources is
+// projected to use more than 80% of the available space.
+func (c *StorageCapacityChecker) Check(resources int) error {
+	dir, err := existingAncestor(c.dir)
+	if err != nil {
+		return errors.Wrapf(err, "Cannot determine available space for %q", c.dir)
+	}
+	available, err := c.availableBytes(dir)
+	if err != nil {
+		return errors.Wrapf(err, "Cannot determine available space for %q", c.dir)
+	}
+	estimated := uint64(resources) * c.bytesPerResource
+	if float64(estimated) <= maxStorageUsage*float64(available) {
+		return nil
+	}
+	return errors.Errorf("Exporting %d resources is estimated to take %s, which exceeds %.0f%% of the %s available in %q", resources, quantity(estimated), maxStorageUsage*100, quantity(available), c.dir)
+}
+
+// stagingDir returns the directory the exported state is written to before it
+// is archived.
+func (e *ControlPlaneStateExporter) stagingDir() string {
+	if e.options.OutputFormat == OutputFormatDirectory {
+		return e.options.OutputArchive
+	}
+	return os.TempDir()
+}
+
+// checkOutputWritable returns an error if the export cannot be written to the
+// local output path, e.g. because its directory is read only. Exports
+// written elsewhere are not checked.
+func (e *ControlPlaneStateExporter) checkOutputWritable() error {
+	o := e.options
+	if o.OutputArchive == "" || o.OutputArchive == "-" || o.OutputFS != nil || o.S3Bucket != "" || o.OutputOCIRef != "" {
+		return nil
+	}
+	dir := filepath.Dir(o.OutputArchive)
+	if o.OutputFormat == OutputFormatDirectory || o.SegmentByNamespace {
+		dir = o.OutputArchive
+	}
+	existing, err := existingAncestor(dir)
+	if err != nil {
+		return errors.Wrapf(err, "Cannot check whether %q is writable", o.OutputArchive)
+	}
+	f, err := os.CreateTemp(existing, ".up-migration-")
+	if err != nil {
+		return errors.Wrapf(err, "Cannot write the export to %q", o.OutputArchive)
+	}
+	_ = f.Close()
+	return errors.Wrapf(os.Remove(f.Name()), "Cannot remove %q", f.Name())
+}
+
+// existingAncestor returns the closest existing directory of path, as the
+// output directory of an export may not exist yet.
+func existingAncestor(path string) (string, error) {
+	p, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	for {
+		_, err := os.Stat(p)
+		if err == nil {
+			return p, nil
+		}
+		if !os.IsNotExist(err) || filepath.Dir(p) == p {
+			return "", err
+		}
+		p = filepath.Dir(p)
+	}
+}
+
+func quantity(bytes uint64) string {
+	q := resource.NewQuantity(int64(bytes), resource.BinarySI) //nolint:gosec // Sizes of local filesystems fit into an int64.
+	return q.String()
+}
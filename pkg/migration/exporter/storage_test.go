@@ -0,0 +1,98 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+)
+
+func TestStorageCapacityCheckerCheck(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	type args struct {
+		resources int
+		available uint64
+		err       error
+	}
+	cases := map[string]struct {
+		args args
+		want error
+	}{
+		"Enough": {
+			args: args{
+				resources: 100,
+				available: 1 << 20,
+			},
+		},
+		"AtThreshold": {
+			args: args{
+				resources: 80,
+				available: 100 * defaultBytesPerResource,
+			},
+		},
+		"TooLittle": {
+			args: args{
+				resources: 81,
+				available: 100 * defaultBytesPerResource,
+			},
+			want: errors.New(`Exporting 81 resources is estimated to take 648Ki, which exceeds 80% of the 800Ki available in "."`),
+		},
+		"Unknown": {
+			args: args{
+				err: errBoom,
+			},
+			want: errors.Wrapf(errBoom, "Cannot determine available space for %q", "."),
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			c := NewStorageCapacityChecker(".")
+			c.availableBytes = func(_ string) (uint64, error) {
+				return tc.args.available, tc.args.err
+			}
+			err := c.Check(tc.args.resources)
+			if diff := cmp.Diff(tc.want, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nCheck(...): -want error, +got error:\n%s", name, diff)
+			}
+		})
+	}
+}
+
+func TestExistingAncestor(t *testing.T) {
+	dir := t.TempDir()
+	got, err := existingAncestor(filepath.Join(dir, "does", "not", "exist"))
+	if err != nil {
+		t.Fatalf("existingAncestor(...): %v", err)
+	}
+	if diff := cmp.Diff(dir, got); diff != "" {
+		t.Errorf("existingAncestor(...): -want, +got:\n%s", diff)
+	}
+}
+
+func TestAvailableBytes(t *testing.T) {
+	got, err := availableBytes(t.TempDir())
+	if err != nil {
+		t.Fatalf("availableBytes(...): %v", err)
+	}
+	if got == 0 {
+		t.Errorf("availableBytes(...): want available space, got none")
+	}
+}
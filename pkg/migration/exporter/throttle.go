@@ -0,0 +1,212 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pterm/pterm"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	// maxThrottleRetries is how often a throttled request is retried before
+	// the throttled response is returned to the client.
+	maxThrottleRetries = 5
+	// defaultRetryAfter is the delay before retrying a throttled request
+	// whose response suggests no delay.
+	defaultRetryAfter = time.Second
+	// maxRetryAfter caps the delay suggested by the API server.
+	maxRetryAfter = 30 * time.Second
+)
+
+// ThrottleSummary counts the requests the API server throttled with HTTP 429
+// responses, per type.
+type ThrottleSummary struct {
+	mu     sync.Mutex
+	counts map[schema.GroupVersionResource]int
+}
+
+// NewThrottleSummary returns a new, empty ThrottleSummary.
+func NewThrottleSummary() *ThrottleSummary {
+	return &ThrottleSummary{
+		counts: make(map[schema.GroupVersionResource]int),
+	}
+}
+
+// Record counts a throttled request for the supplied type.
+func (s *ThrottleSummary) Record(gvr schema.GroupVersionResource) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.counts[gvr]++
+}
+
+// Counts returns the number of throttled requests per type.
+func (s *ThrottleSummary) Counts() map[schema.GroupVersionResource]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	counts := make(map[schema.GroupVersionResource]int, len(s.counts))
+	for gvr, n := range s.counts {
+		counts[gvr] = n
+	}
+	return counts
+}
+
+// Total returns the number of throttled requests of all types.
+func (s *ThrottleSummary) Total() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	total := 0
+	for _, n := range s.counts {
+		total += n
+	}
+	return total
+}
+
+// Print prints a table of the throttled requests per type, if any.
+func (s *ThrottleSummary) Print() error {
+	total := s.Total()
+	if total == 0 {
+		return nil
+	}
+	pterm.Printf("\nThe API server throttled %d requests:\n", total)
+	return pterm.DefaultTable.WithHasHeader().WithData(s.table()).Render()
+}
+
+// table returns the rows of the throttle summary, sorted by type. Requests
+// not addressing a type, e.g. discovery requests, are reported as "other".
+func (s *ThrottleSummary) table() [][]string {
+	counts := s.Counts()
+	gvrs := make([]schema.GroupVersionResource, 0, len(counts))
+	for gvr := range counts {
+		gvrs = append(gvrs, gvr)
+	}
+	sort.Slice(gvrs, func(i, j int) bool {
+		return gvrs[i].String() < gvrs[j].String()
+	})
+
+	data := [][]string{{"TYPE", "VERSION", "THROTTLED"}}
+	for _, gvr := range gvrs {
+		name := gvr.GroupResource().String()
+		if gvr.Empty() {
+			name = "other"
+		}
+		data = append(data, []string{name, gvr.Version, strconv.Itoa(counts[gvr])})
+	}
+	return data
+}
+
+// WrapTransport returns a transport recording throttled requests in the
+// summary and retrying them after the delay suggested by their Retry-After
+// header. It can be used as the wrapper of a rest.Config.
+func (s *ThrottleSummary) WrapTransport(rt http.RoundTripper) http.RoundTripper {
+	return &throttlingTransport{
+		next:    rt,
+		summary: s,
+		sleep:   sleep,
+	}
+}
+
+// throttlingTransport retries requests throttled by the API server.
+type throttlingTransport struct {
+	next    http.RoundTripper
+	summary *ThrottleSummary
+
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// RoundTrip sends the request, retrying it while it is throttled, up to
+// maxThrottleRetries times. Requests whose body cannot be replayed are not
+// retried.
+func (t *throttlingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for i := 0; ; i++ {
+		resp, err := t.next.RoundTrip(req)
+		if err != nil || resp.StatusCode != http.StatusTooManyRequests {
+			return resp, err
+		}
+		t.summary.Record(gvrFromPath(req.URL.Path))
+		if i >= maxThrottleRetries || (req.Body != nil && req.Body != http.NoBody && req.GetBody == nil) {
+			return resp, nil
+		}
+
+		d := retryAfter(resp.Header.Get("Retry-After"))
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+		if err := t.sleep(req.Context(), d); err != nil {
+			return nil, err
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+}
+
+// retryAfter returns the delay of a Retry-After header in seconds, or
+// defaultRetryAfter if it has none.
+func retryAfter(h string) time.Duration {
+	s, err := strconv.Atoi(strings.TrimSpace(h))
+	if err != nil || s <= 0 {
+		return defaultRetryAfter
+	}
+	if d := time.Duration(s) * time.Second; d < maxRetryAfter {
+		return d
+	}
+	return maxRetryAfter
+}
+
+// gvrFromPath returns the type addressed by an API server request path, e.g.
+// /apis/pkg.crossplane.io/v1/providers or /api/v1/namespaces/default/secrets.
+// The empty type is returned for other paths, e.g. of discovery requests.
+func gvrFromPath(p string) schema.GroupVersionResource {
+	parts := strings.Split(strings.Trim(p, "/"), "/")
+	var gvr schema.GroupVersionResource
+	switch {
+	case len(parts) >= 3 && parts[0] == "api":
+		gvr.Version, parts = parts[1], parts[2:]
+	case len(parts) >= 4 && parts[0] == "apis":
+		gvr.Group, gvr.Version, parts = parts[1], parts[2], parts[3:]
+	default:
+		return schema.GroupVersionResource{}
+	}
+	if len(parts) >= 3 && parts[0] == "namespaces" {
+		parts = parts[2:]
+	}
+	gvr.Resource = parts[0]
+	return gvr
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,186 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// roundTripFn is an http.RoundTripper calling itself.
+type roundTripFn func(*http.Request) (*http.Response, error)
+
+func (fn roundTripFn) RoundTrip(req *http.Request) (*http.Response, error) {
+	return fn(req)
+}
+
+func TestThrottlingTransportRoundTrip(t *testing.T) {
+	providers := schema.GroupVersionResource{Group: "pkg.crossplane.io", Version: "v1", Resource: "providers"}
+	response := func(status int, retryAfter string) *http.Response {
+		h := http.Header{}
+		if retryAfter != "" {
+			h.Set("Retry-After", retryAfter)
+		}
+		return &http.Response{StatusCode: status, Header: h, Body: io.NopCloser(strings.NewReader(""))}
+	}
+
+	type want struct {
+		status int
+		calls  int
+		delays []time.Duration
+		counts map[schema.GroupVersionResource]int
+	}
+	cases := map[string]struct {
+		reason    string
+		responses []*http.Response
+		want      want
+	}{
+		"NotThrottled": {
+			reason:    "Requests that are not throttled should neither be retried nor recorded.",
+			responses: []*http.Response{response(http.StatusOK, "")},
+			want: want{
+				status: http.StatusOK,
+				calls:  1,
+				counts: map[schema.GroupVersionResource]int{},
+			},
+		},
+		"RetryAfter": {
+			reason:    "Throttled requests should be recorded and retried after the suggested delay.",
+			responses: []*http.Response{response(http.StatusTooManyRequests, "3"), response(http.StatusTooManyRequests, ""), response(http.StatusOK, "")},
+			want: want{
+				status: http.StatusOK,
+				calls:  3,
+				delays: []time.Duration{3 * time.Second, defaultRetryAfter},
+				counts: map[schema.GroupVersionResource]int{providers: 2},
+			},
+		},
+		"RetriesExhausted": {
+			reason: "The throttled response should be returned once the retries are exhausted.",
+			responses: []*http.Response{
+				response(http.StatusTooManyRequests, "1"), response(http.StatusTooManyRequests, "1"), response(http.StatusTooManyRequests, "1"),
+				response(http.StatusTooManyRequests, "1"), response(http.StatusTooManyRequests, "1"), response(http.StatusTooManyRequests, "1"),
+			},
+			want: want{
+				status: http.StatusTooManyRequests,
+				calls:  maxThrottleRetries + 1,
+				delays: []time.Duration{time.Second, time.Second, time.Second, time.Second, time.Second},
+				counts: map[schema.GroupVersionResource]int{providers: maxThrottleRetries + 1},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			calls := 0
+			next := roundTripFn(func(_ *http.Request) (*http.Response, error) {
+				resp := tc.responses[calls]
+				calls++
+				return resp, nil
+			})
+			var delays []time.Duration
+			s := NewThrottleSummary()
+			rt := s.WrapTransport(next).(*throttlingTransport)
+			rt.sleep = func(_ context.Context, d time.Duration) error {
+				delays = append(delays, d)
+				return nil
+			}
+
+			req, _ := http.NewRequest(http.MethodGet, "https://example.com/apis/pkg.crossplane.io/v1/providers", nil)
+			resp, err := rt.RoundTrip(req)
+			if err != nil {
+				t.Fatalf("\n%s\nRoundTrip(...): unexpected error: %v", tc.reason, err)
+			}
+			if diff := cmp.Diff(tc.want.status, resp.StatusCode); diff != "" {
+				t.Errorf("\n%s\nRoundTrip(...): -want status, +got status:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.calls, calls); diff != "" {
+				t.Errorf("\n%s\nRoundTrip(...): -want calls, +got calls:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.delays, delays); diff != "" {
+				t.Errorf("\n%s\nRoundTrip(...): -want delays, +got delays:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.counts, s.Counts()); diff != "" {
+				t.Errorf("\n%s\nCounts(): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestGVRFromPath(t *testing.T) {
+	cases := map[string]struct {
+		reason string
+		path   string
+		want   schema.GroupVersionResource
+	}{
+		"Core": {
+			reason: "The type of core resources should be returned.",
+			path:   "/api/v1/configmaps",
+			want:   schema.GroupVersionResource{Version: "v1", Resource: "configmaps"},
+		},
+		"Namespaced": {
+			reason: "The type of namespaced resources should be returned.",
+			path:   "/api/v1/namespaces/default/secrets/creds",
+			want:   schema.GroupVersionResource{Version: "v1", Resource: "secrets"},
+		},
+		"Namespaces": {
+			reason: "Namespaces themselves should be returned as such.",
+			path:   "/api/v1/namespaces/default",
+			want:   schema.GroupVersionResource{Version: "v1", Resource: "namespaces"},
+		},
+		"Group": {
+			reason: "The type of resources of API groups should be returned.",
+			path:   "/apis/pkg.crossplane.io/v1/providers",
+			want:   schema.GroupVersionResource{Group: "pkg.crossplane.io", Version: "v1", Resource: "providers"},
+		},
+		"Discovery": {
+			reason: "The empty type should be returned for discovery requests.",
+			path:   "/apis/pkg.crossplane.io/v1",
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.want, gvrFromPath(tc.path)); diff != "" {
+				t.Errorf("\n%s\ngvrFromPath(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestThrottleSummaryTable(t *testing.T) {
+	s := NewThrottleSummary()
+	s.Record(schema.GroupVersionResource{Group: "pkg.crossplane.io", Version: "v1", Resource: "providers"})
+	s.Record(schema.GroupVersionResource{Version: "v1", Resource: "secrets"})
+	s.Record(schema.GroupVersionResource{Version: "v1", Resource: "secrets"})
+	s.Record(schema.GroupVersionResource{})
+
+	want := [][]string{
+		{"TYPE", "VERSION", "THROTTLED"},
+		{"other", "", "1"},
+		{"secrets", "v1", "2"},
+		{"providers.pkg.crossplane.io", "v1", "1"},
+	}
+	if diff := cmp.Diff(want, s.table()); diff != "" {
+		t.Errorf("table(): -want, +got:\n%s", diff)
+	}
+	if diff := cmp.Diff(4, s.Total()); diff != "" {
+		t.Errorf("Total(): -want, +got:\n%s", diff)
+	}
+}
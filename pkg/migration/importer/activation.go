@@ -0,0 +1,187 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package importer
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/pterm/pterm"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/util/retry"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+)
+
+const (
+	annotationPaused = "crossplane.io/paused"
+
+	defaultActivationPollInterval = 5 * time.Second
+	// defaultActivationReadyTimeout bounds waiting for a single batch to
+	// become ready.
+	defaultActivationReadyTimeout = 10 * time.Minute
+)
+
+// unpausePatch removes the paused annotation.
+var unpausePatch = []byte(`{"metadata":{"annotations":{"` + annotationPaused + `":null}}}`)
+
+// activationRef references a paused managed resource.
+type activationRef struct {
+	gvr       schema.GroupVersionResource
+	namespace string
+	name      string
+}
+
+// BatchActivator unpauses paused managed resources in batches, waiting for
+// each batch to become ready before unpausing the next one, so that providers
+// are not overwhelmed by reconciling all imported resources at once.
+type BatchActivator struct {
+	dynamicClient   dynamic.Interface
+	discoveryClient discovery.DiscoveryInterface
+
+	batchSize    int
+	interval     time.Duration
+	pollInterval time.Duration
+	readyTimeout time.Duration
+}
+
+// NewBatchActivator returns a BatchActivator unpausing batchSize managed
+// resources at a time, waiting interval between a batch becoming ready and
+// unpausing the next one.
+func NewBatchActivator(dyn dynamic.Interface, dis discovery.DiscoveryInterface, batchSize int, interval time.Duration) *BatchActivator {
+	return &BatchActivator{
+		dynamicClient:   dyn,
+		discoveryClient: dis,
+		batchSize:       batchSize,
+		interval:        interval,
+		pollInterval:    defaultActivationPollInterval,
+		readyTimeout:    defaultActivationReadyTimeout,
+	}
+}
+
+// Activate unpauses all paused managed resources in batches and returns how
+// many were unpaused. It fails if a batch does not become ready in time,
+// leaving the remaining resources paused.
+func (a *BatchActivator) Activate(ctx context.Context) (int, error) {
+	if a.batchSize < 1 {
+		return 0, errors.New("activation batch size must be positive")
+	}
+	refs, err := a.pausedManagedResources(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	activated := 0
+	for start := 0; start < len(refs); start += a.batchSize {
+		if start > 0 && a.interval > 0 {
+			select {
+			case <-ctx.Done():
+				return activated, errors.Wrap(ctx.Err(), "cannot wait for next activation batch")
+			case <-time.After(a.interval):
+			}
+		}
+		batch := refs[start:min(start+a.batchSize, len(refs))]
+		for _, r := range batch {
+			if err := a.unpause(ctx, r); err != nil {
+				return activated, err
+			}
+			activated++
+		}
+		pterm.Info.Printfln("Unpaused %d of %d managed resources, waiting for them to become ready", activated, len(refs))
+		if err := a.waitForReady(ctx, batch); err != nil {
+			return activated, err
+		}
+	}
+	return activated, nil
+}
+
+// pausedManagedResources returns all managed resources with the paused
+// annotation.
+func (a *BatchActivator) pausedManagedResources(ctx context.Context) ([]activationRef, error) {
+	apiLists, err := a.discoveryClient.ServerPreferredResources()
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot get server preferred resources")
+	}
+	var refs []activationRef
+	for _, al := range apiLists {
+		gv, err := schema.ParseGroupVersion(al.GroupVersion)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot parse group version %q", al.GroupVersion)
+		}
+		for _, r := range al.APIResources {
+			if !contains(r.Categories, "managed") {
+				continue
+			}
+			gvr := gv.WithResource(r.Name)
+			l, err := a.dynamicClient.Resource(gvr).List(ctx, v1.ListOptions{})
+			if err != nil {
+				return nil, errors.Wrapf(err, "cannot list %q", gvr.GroupResource())
+			}
+			// Resources of a type are activated in a predictable order.
+			sort.Slice(l.Items, func(i, j int) bool {
+				return namespacedName(l.Items[i].GetNamespace(), l.Items[i].GetName()) < namespacedName(l.Items[j].GetNamespace(), l.Items[j].GetName())
+			})
+			for _, u := range l.Items {
+				if u.GetAnnotations()[annotationPaused] == "true" {
+					refs = append(refs, activationRef{gvr: gvr, namespace: u.GetNamespace(), name: u.GetName()})
+				}
+			}
+		}
+	}
+	return refs, nil
+}
+
+func (a *BatchActivator) unpause(ctx context.Context, r activationRef) error {
+	err := retry.OnError(retry.DefaultRetry, resource.IsAPIError, func() error {
+		_, err := a.dynamicClient.Resource(r.gvr).Namespace(r.namespace).Patch(ctx, r.name, types.MergePatchType, unpausePatch, v1.PatchOptions{})
+		return err
+	})
+	return errors.Wrapf(err, "cannot unpause %s %q", r.gvr.GroupResource(), namespacedName(r.namespace, r.name))
+}
+
+// waitForReady waits until all resources of the batch are ready.
+func (a *BatchActivator) waitForReady(ctx context.Context, batch []activationRef) error {
+	pending := batch
+	err := wait.PollUntilContextTimeout(ctx, a.pollInterval, a.readyTimeout, true, func(ctx context.Context) (bool, error) {
+		var notReady []activationRef
+		for _, r := range pending {
+			u, err := a.dynamicClient.Resource(r.gvr).Namespace(r.namespace).Get(ctx, r.name, v1.GetOptions{})
+			if err != nil {
+				return false, errors.Wrapf(err, "cannot get %s %q", r.gvr.GroupResource(), namespacedName(r.namespace, r.name))
+			}
+			ready, err := conditionsMet(u, []xpv1.ConditionType{xpv1.TypeReady})
+			if err != nil {
+				return false, err
+			}
+			if !ready {
+				notReady = append(notReady, r)
+			}
+		}
+		pending = notReady
+		return len(pending) == 0, nil
+	})
+	if err != nil {
+		return errors.Wrapf(err, "%d of %d managed resources of the batch did not become ready", len(pending), len(batch))
+	}
+	return nil
+}
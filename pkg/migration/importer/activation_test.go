@@ -0,0 +1,188 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package importer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic/fake"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// preferredDiscovery serves the supplied preferred resources, which the fake
+// discovery client does not support.
+type preferredDiscovery struct {
+	discovery.DiscoveryInterface
+	resources []*v1.APIResourceList
+}
+
+func (d *preferredDiscovery) ServerPreferredResources() ([]*v1.APIResourceList, error) {
+	return d.resources, nil
+}
+
+// activationEvent is a managed resource being unpaused or observed ready.
+type activationEvent struct {
+	event string
+	name  string
+	at    time.Time
+}
+
+func TestBatchActivatorActivate(t *testing.T) {
+	buckets := schema.GroupVersionResource{Group: "s3.aws.upbound.io", Version: "v1beta1", Resource: "buckets"}
+	bucket := func(name string, paused bool) runtime.Object {
+		u := &unstructured.Unstructured{}
+		u.SetAPIVersion("s3.aws.upbound.io/v1beta1")
+		u.SetKind("Bucket")
+		u.SetName(name)
+		if paused {
+			u.SetAnnotations(map[string]string{"crossplane.io/paused": "true"})
+		}
+		return u
+	}
+
+	type args struct {
+		batchSize int
+		interval  time.Duration
+		// readyAfter is how many times a resource is read after it was
+		// unpaused before it is ready. Resources never become ready if
+		// negative.
+		readyAfter int
+	}
+	type want struct {
+		events    []string
+		activated int
+		err       bool
+	}
+
+	cases := map[string]struct {
+		args args
+		want want
+	}{
+		"Batches": {
+			args: args{
+				batchSize:  2,
+				interval:   20 * time.Millisecond,
+				readyAfter: 2,
+			},
+			want: want{
+				events: []string{
+					"unpause a", "unpause b", "ready a", "ready b",
+					"unpause c", "unpause d", "ready c", "ready d",
+					"unpause e", "ready e",
+				},
+				activated: 5,
+			},
+		},
+		"SingleBatch": {
+			args: args{
+				batchSize: 10,
+			},
+			want: want{
+				events: []string{
+					"unpause a", "unpause b", "unpause c", "unpause d", "unpause e",
+					"ready a", "ready b", "ready c", "ready d", "ready e",
+				},
+				activated: 5,
+			},
+		},
+		"NotReady": {
+			args: args{
+				batchSize:  2,
+				readyAfter: -1,
+			},
+			want: want{
+				events:    []string{"unpause a", "unpause b"},
+				activated: 2,
+				err:       true,
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			dyn := fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{buckets: "BucketList"},
+				bucket("c", true), bucket("a", true), bucket("e", true), bucket("b", true), bucket("d", true), bucket("active", false))
+
+			var events []activationEvent
+			reads := map[string]int{}
+			dyn.PrependReactor("patch", "buckets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				events = append(events, activationEvent{event: "unpause", name: action.(k8stesting.PatchAction).GetName(), at: time.Now()})
+				return false, nil, nil
+			})
+			dyn.PrependReactor("get", "buckets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				n := action.(k8stesting.GetAction).GetName()
+				obj, err := dyn.Tracker().Get(buckets, "", n)
+				if err != nil {
+					return true, nil, err
+				}
+				u := obj.(*unstructured.Unstructured).DeepCopy()
+				if _, paused := u.GetAnnotations()["crossplane.io/paused"]; paused {
+					return true, u, nil
+				}
+				reads[n]++
+				if tc.args.readyAfter >= 0 && reads[n] >= tc.args.readyAfter {
+					_ = unstructured.SetNestedSlice(u.Object, []any{map[string]any{"type": "Ready", "status": "True"}}, "status", "conditions")
+					if reads[n] == max(tc.args.readyAfter, 1) {
+						events = append(events, activationEvent{event: "ready", name: n, at: time.Now()})
+					}
+				}
+				return true, u, nil
+			})
+
+			dis := &preferredDiscovery{
+				DiscoveryInterface: kubefake.NewSimpleClientset().Discovery(),
+				resources: []*v1.APIResourceList{{
+					GroupVersion: "s3.aws.upbound.io/v1beta1",
+					APIResources: []v1.APIResource{{Name: "buckets", Kind: "Bucket", Categories: []string{"crossplane", "managed"}}},
+				}},
+			}
+			a := NewBatchActivator(dyn, dis, tc.args.batchSize, tc.args.interval)
+			a.pollInterval = time.Millisecond
+			a.readyTimeout = 50 * time.Millisecond
+
+			activated, err := a.Activate(context.Background())
+			if (err != nil) != tc.want.err {
+				t.Fatalf("\n%s\nActivate(...): want error %t, got %v", name, tc.want.err, err)
+			}
+			if activated != tc.want.activated {
+				t.Errorf("\n%s\nActivate(...): want %d activated, got %d", name, tc.want.activated, activated)
+			}
+			got := make([]string, 0, len(events))
+			for _, e := range events {
+				got = append(got, e.event+" "+e.name)
+			}
+			if diff := cmp.Diff(tc.want.events, got); diff != "" {
+				t.Errorf("\n%s\nActivate(...): -want events, +got events:\n%s", name, diff)
+			}
+			// The next batch is unpaused only after the interval passed
+			// since the previous batch became ready.
+			for i := 1; i < len(events); i++ {
+				if events[i-1].event == "ready" && events[i].event == "unpause" {
+					if d := events[i].at.Sub(events[i-1].at); d < tc.args.interval {
+						t.Errorf("\n%s\nActivate(...): %q unpaused %s after the previous batch became ready, want at least %s", name, events[i].name, d, tc.args.interval)
+					}
+				}
+			}
+		})
+	}
+}
@@ -16,13 +16,22 @@ package importer
 
 import (
 	"context"
+	"io"
+	"reflect"
+	"sync"
 
+	"github.com/pterm/pterm"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/util/retry"
 
+	"github.com/upbound/up/pkg/migration"
+
 	"github.com/crossplane/crossplane-runtime/pkg/errors"
 	"github.com/crossplane/crossplane-runtime/pkg/resource"
 )
@@ -32,51 +41,382 @@ type ResourceApplier interface {
 	ModifyResources(ctx context.Context, resources []unstructured.Unstructured, modify func(*unstructured.Unstructured) error) error
 }
 
+// defaultFieldManager is the field manager resources are applied with.
+const defaultFieldManager = "up-controlplane-migrator"
+
 type UnstructuredResourceApplier struct {
 	dynamicClient  dynamic.Interface
 	resourceMapper meta.RESTMapper
+
+	autoDetectFieldManager bool
+	limiter                *AdaptiveRateLimiter
+	journal                *ApplyJournal
+	log                    *structuredLog
+	conflicts              ConflictStrategy
+	resourceConflicts      map[schema.GroupResource]ConflictStrategy
+	incremental            bool
+
+	mu        sync.Mutex
+	skipped   int
+	unchanged int
+}
+
+// pendingStatus is the exported status of an applied resource, which is
+// restored once all resources of its type are applied.
+type pendingStatus struct {
+	gvr       schema.GroupVersionResource
+	kind      string
+	namespace string
+	name      string
+	status    any
+	manager   string
+}
+
+// ConflictStrategy determines how resources that already exist in the target
+// cluster are imported.
+type ConflictStrategy string
+
+const (
+	// ConflictStrategyOverwrite applies resources regardless of whether they
+	// exist.
+	ConflictStrategyOverwrite ConflictStrategy = "overwrite"
+	// ConflictStrategySkip leaves existing resources untouched.
+	ConflictStrategySkip ConflictStrategy = "skip"
+	// ConflictStrategyFail fails the import if a resource exists.
+	ConflictStrategyFail ConflictStrategy = "fail"
+)
+
+// ApplierOption configures an UnstructuredResourceApplier.
+type ApplierOption func(*UnstructuredResourceApplier)
+
+// WithAutoDetectFieldManager configures the applier to apply resources that
+// already exist in the target cluster with their first existing field manager,
+// rather than the default one, to avoid field manager conflicts.
+func WithAutoDetectFieldManager() ApplierOption {
+	return func(a *UnstructuredResourceApplier) {
+		a.autoDetectFieldManager = true
+	}
+}
+
+// WithRateLimiter configures the applier to delay requests to the target
+// cluster according to the supplied rate limiter.
+func WithRateLimiter(l *AdaptiveRateLimiter) ApplierOption {
+	return func(a *UnstructuredResourceApplier) {
+		a.limiter = l
+	}
+}
+
+// WithJournal configures the applier to record the resources it creates in
+// the supplied journal. This requires checking whether each resource exists
+// before applying it.
+func WithJournal(j *ApplyJournal) ApplierOption {
+	return func(a *UnstructuredResourceApplier) {
+		a.journal = j
+	}
+}
+
+// WithConflictStrategy configures how the applier handles resources that
+// already exist in the target cluster. This requires checking whether each
+// resource exists before applying it, unless existing resources are
+// overwritten.
+func WithConflictStrategy(s ConflictStrategy) ApplierOption {
+	return func(a *UnstructuredResourceApplier) {
+		a.conflicts = s
+	}
+}
+
+// WithResourceConflictStrategy configures how the applier handles resources
+// of the supplied type that already exist in the target cluster, overriding
+// the conflict strategy configured with WithConflictStrategy.
+func WithResourceConflictStrategy(gr schema.GroupResource, s ConflictStrategy) ApplierOption {
+	return func(a *UnstructuredResourceApplier) {
+		if a.resourceConflicts == nil {
+			a.resourceConflicts = make(map[schema.GroupResource]ConflictStrategy)
+		}
+		a.resourceConflicts[gr] = s
+	}
 }
 
-func NewUnstructuredResourceApplier(dynamicClient dynamic.Interface, resourceMapper meta.RESTMapper) *UnstructuredResourceApplier {
-	return &UnstructuredResourceApplier{
+// WithIncremental configures the applier to skip resources that already exist
+// in the target cluster with identical content, e.g. when re-running an import
+// after a partial failure. Existing resources that differ are handled
+// according to the conflict strategy. This requires checking whether each
+// resource exists before applying it.
+func WithIncremental() ApplierOption {
+	return func(a *UnstructuredResourceApplier) {
+		a.incremental = true
+	}
+}
+
+// WithStructuredLog configures the applier to write the outcome of applying
+// each resource to w as a JSON line.
+func WithStructuredLog(w io.Writer) ApplierOption {
+	return func(a *UnstructuredResourceApplier) {
+		a.log = newStructuredLog(w)
+	}
+}
+
+func NewUnstructuredResourceApplier(dynamicClient dynamic.Interface, resourceMapper meta.RESTMapper, opts ...ApplierOption) *UnstructuredResourceApplier {
+	a := &UnstructuredResourceApplier{
 		dynamicClient:  dynamicClient,
 		resourceMapper: resourceMapper,
 	}
+	for _, o := range opts {
+		o(a)
+	}
+	return a
 }
 
+// ApplyResources applies the supplied resources. If applyStatus is true, the
+// exported status of the resources is restored after all of them are applied.
 func (a *UnstructuredResourceApplier) ApplyResources(ctx context.Context, resources []unstructured.Unstructured, applyStatus bool) error {
+	var pending []pendingStatus
 	for i := range resources {
+		// The resource is unknown if its type cannot be mapped.
+		gvr := resources[i].GroupVersionKind().GroupVersion().WithResource("")
+		skipped, unchanged := false, false
+		var status *pendingStatus
 		err := retry.OnError(retry.DefaultRetry, resource.IsAPIError, func() error {
 			rm, err := a.resourceMapper.RESTMapping(resources[i].GroupVersionKind().GroupKind(), resources[i].GroupVersionKind().Version)
 			if err != nil {
 				return err
 			}
+			gvr = rm.Resource
 
-			rs := resources[i].DeepCopy()
-			_, err = a.dynamicClient.Resource(rm.Resource).Namespace(resources[i].GetNamespace()).Apply(ctx, resources[i].GetName(), &resources[i], v1.ApplyOptions{
-				FieldManager: "up-controlplane-migrator",
-				Force:        true,
-			})
+			ri := a.dynamicClient.Resource(rm.Resource).Namespace(resources[i].GetNamespace())
+			manager, statusManager, err := a.fieldManagers(ctx, ri, resources[i].GetName())
 			if err != nil {
 				return err
 			}
-			if !applyStatus {
+
+			conflicts := a.conflictStrategy(rm.Resource.GroupResource())
+			live, err := a.existing(ctx, ri, resources[i].GetName(), conflicts)
+			if err != nil {
+				return err
+			}
+			existed := live != nil
+			if existed && a.incremental && identical(live, &resources[i]) {
+				unchanged = true
 				return nil
 			}
-			_, err = a.dynamicClient.Resource(rm.Resource).Namespace(resources[i].GetNamespace()).ApplyStatus(ctx, rs.GetName(), rs, v1.ApplyOptions{
-				FieldManager: "up-controlplane-migrator",
-				Force:        true,
+			if existed {
+				switch conflicts {
+				case ConflictStrategySkip:
+					skipped = true
+					return nil
+				case ConflictStrategyFail:
+					// Wrapped so that it is not retried like other API errors.
+					return errors.Wrap(kerrors.NewAlreadyExists(rm.Resource.GroupResource(), resources[i].GetName()), "conflict strategy is fail")
+				case ConflictStrategyOverwrite, "":
+				}
+			}
+
+			rs := resources[i].DeepCopy()
+			err = a.call(ctx, func() error {
+				_, err := ri.Apply(ctx, resources[i].GetName(), &resources[i], v1.ApplyOptions{
+					FieldManager: manager,
+					Force:        true,
+				})
+				return err
 			})
 			if err != nil {
 				return err
 			}
+			if a.journal != nil && !existed {
+				a.journal.Record(JournalEntry{GVR: rm.Resource, Namespace: resources[i].GetNamespace(), Name: resources[i].GetName()})
+			}
+			if s, ok := rs.Object["status"]; ok && applyStatus {
+				status = &pendingStatus{gvr: rm.Resource, kind: rs.GetKind(), namespace: rs.GetNamespace(), name: rs.GetName(), status: s, manager: statusManager}
+			}
 			return nil
 		})
+		if skipped {
+			a.skip(gvr, &resources[i])
+			continue
+		}
+		if unchanged {
+			a.skipUnchanged(gvr, &resources[i])
+			continue
+		}
+		a.log.record(LogOpApply, gvr, resources[i].GetNamespace(), resources[i].GetName(), err)
 		if err != nil {
-			return errors.Wrapf(err, "cannot apply resource %s/%s", resources[i].GetKind(), resources[i].GetName())
+			return migration.WithCode(errors.Wrapf(err, "cannot apply resource %s/%s", resources[i].GetKind(), resources[i].GetName()), migration.ErrCodeResourceApplyFailed)
+		}
+		if status != nil {
+			pending = append(pending, *status)
 		}
 	}
-	return nil
+	_, err := a.restoreStatus(ctx, pending)
+	return err
+}
+
+// restoreStatus restores the exported status of applied resources through
+// their status subresource, as the API server ignores the status of resources
+// applied through the main resource. It is a second pass over the resources of
+// a type, so that the status is restored once all of them exist. It returns
+// the number of resources whose status was restored.
+func (a *UnstructuredResourceApplier) restoreStatus(ctx context.Context, pending []pendingStatus) (int, error) {
+	for i, p := range pending {
+		ri := a.dynamicClient.Resource(p.gvr).Namespace(p.namespace)
+		err := retry.OnError(retry.DefaultRetry, resource.IsAPIError, func() error {
+			var live *unstructured.Unstructured
+			err := a.call(ctx, func() error {
+				var err error
+				live, err = ri.Get(ctx, p.name, v1.GetOptions{})
+				return err
+			})
+			if err != nil {
+				return err
+			}
+			live.Object["status"] = p.status
+			return a.call(ctx, func() error {
+				_, err := ri.UpdateStatus(ctx, live, v1.UpdateOptions{FieldManager: p.manager})
+				return err
+			})
+		})
+		a.log.record(LogOpRestoreStatus, p.gvr, p.namespace, p.name, err)
+		if err != nil {
+			return i, migration.WithCode(errors.Wrapf(err, "cannot restore status of resource %s/%s", p.kind, p.name), migration.ErrCodeResourceApplyFailed)
+		}
+	}
+	return len(pending), nil
+}
+
+// call calls fn, delaying it according to the rate limiter, if any.
+func (a *UnstructuredResourceApplier) call(ctx context.Context, fn func() error) error {
+	if a.limiter == nil {
+		return fn()
+	}
+	return a.limiter.Do(ctx, fn)
+}
+
+// skip records that the supplied existing resource was skipped.
+func (a *UnstructuredResourceApplier) skip(gvr schema.GroupVersionResource, u *unstructured.Unstructured) {
+	pterm.Info.Printfln("Skipping %s %q as it already exists", gvr.GroupResource(), namespacedName(u.GetNamespace(), u.GetName()))
+	a.log.recordResult(LogOpApply, gvr, u.GetNamespace(), u.GetName(), LogResultSkipped, nil)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.skipped++
+}
+
+// skipUnchanged records that the supplied existing resource was skipped as it
+// is identical already.
+func (a *UnstructuredResourceApplier) skipUnchanged(gvr schema.GroupVersionResource, u *unstructured.Unstructured) {
+	a.log.recordResult(LogOpApply, gvr, u.GetNamespace(), u.GetName(), LogResultUnchanged, nil)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.unchanged++
+}
+
+// Unchanged returns the number of existing resources that were skipped as
+// they were identical already.
+func (a *UnstructuredResourceApplier) Unchanged() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.unchanged
+}
+
+// Skipped returns the number of existing resources that were skipped
+// according to the conflict strategy.
+func (a *UnstructuredResourceApplier) Skipped() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.skipped
+}
+
+// conflictStrategy returns the conflict strategy for resources of the supplied
+// type.
+func (a *UnstructuredResourceApplier) conflictStrategy(gr schema.GroupResource) ConflictStrategy {
+	if s, ok := a.resourceConflicts[gr]; ok {
+		return s
+	}
+	return a.conflicts
+}
+
+// existing returns the resource with the supplied name if it already exists,
+// or nil. It is only checked if the journal, the conflict strategy or an
+// incremental import need to know.
+func (a *UnstructuredResourceApplier) existing(ctx context.Context, ri dynamic.ResourceInterface, name string, conflicts ConflictStrategy) (*unstructured.Unstructured, error) {
+	if a.journal == nil && !a.incremental && (conflicts == ConflictStrategyOverwrite || conflicts == "") {
+		return nil, nil
+	}
+	var live *unstructured.Unstructured
+	err := a.call(ctx, func() error {
+		var err error
+		live, err = ri.Get(ctx, name, v1.GetOptions{})
+		return err
+	})
+	if kerrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return live, nil
+}
+
+// identical returns true if the live resource has the same labels and content
+// as the supplied one, ignoring all other metadata and the status. The
+// content are all other top level fields, e.g. the spec, or the data of
+// ConfigMaps and Secrets.
+func identical(live, u *unstructured.Unstructured) bool {
+	if !reflect.DeepEqual(live.GetLabels(), u.GetLabels()) {
+		return false
+	}
+	content := func(u *unstructured.Unstructured) map[string]any {
+		c := make(map[string]any, len(u.Object))
+		for k, v := range u.Object {
+			switch k {
+			case "apiVersion", "kind", "metadata", "status":
+				continue
+			}
+			c[k] = v
+		}
+		return c
+	}
+	return reflect.DeepEqual(content(live), content(u))
+}
+
+// fieldManagers returns the field managers to apply the resource with the
+// supplied name and its status with.
+func (a *UnstructuredResourceApplier) fieldManagers(ctx context.Context, ri dynamic.ResourceInterface, name string) (string, string, error) {
+	if !a.autoDetectFieldManager {
+		return defaultFieldManager, defaultFieldManager, nil
+	}
+	var live *unstructured.Unstructured
+	err := a.call(ctx, func() error {
+		var err error
+		live, err = ri.Get(ctx, name, v1.GetOptions{})
+		return err
+	})
+	if kerrors.IsNotFound(err) {
+		return defaultFieldManager, defaultFieldManager, nil
+	}
+	if err != nil {
+		return "", "", err
+	}
+
+	manager, statusManager := "", ""
+	for _, mf := range live.GetManagedFields() {
+		if mf.Subresource == "status" {
+			if statusManager == "" {
+				statusManager = mf.Manager
+			}
+			continue
+		}
+		if manager == "" {
+			manager = mf.Manager
+		}
+	}
+	if manager == "" {
+		manager = defaultFieldManager
+	}
+	if statusManager == "" {
+		statusManager = manager
+	}
+	return manager, statusManager, nil
 }
 
 func (a *UnstructuredResourceApplier) ModifyResources(ctx context.Context, resources []unstructured.Unstructured, modify func(*unstructured.Unstructured) error) error {
@@ -86,7 +426,12 @@ func (a *UnstructuredResourceApplier) ModifyResources(ctx context.Context, resou
 			if err != nil {
 				return err
 			}
-			u, err := a.dynamicClient.Resource(rm.Resource).Namespace(resources[i].GetNamespace()).Get(ctx, resources[i].GetName(), v1.GetOptions{})
+			var u *unstructured.Unstructured
+			err = a.call(ctx, func() error {
+				var err error
+				u, err = a.dynamicClient.Resource(rm.Resource).Namespace(resources[i].GetNamespace()).Get(ctx, resources[i].GetName(), v1.GetOptions{})
+				return err
+			})
 			if err != nil {
 				return err
 			}
@@ -94,14 +439,17 @@ func (a *UnstructuredResourceApplier) ModifyResources(ctx context.Context, resou
 				return err
 			}
 
-			_, err = a.dynamicClient.Resource(rm.Resource).Namespace(resources[i].GetNamespace()).Update(ctx, u, v1.UpdateOptions{})
+			err = a.call(ctx, func() error {
+				_, err := a.dynamicClient.Resource(rm.Resource).Namespace(resources[i].GetNamespace()).Update(ctx, u, v1.UpdateOptions{})
+				return err
+			})
 			if err != nil {
 				return err
 			}
 			return nil
 		})
 		if err != nil {
-			return errors.Wrapf(err, "cannot apply resource %s/%s", resources[i].GetKind(), resources[i].GetName())
+			return migration.WithCode(errors.Wrapf(err, "cannot apply resource %s/%s", resources[i].GetKind(), resources[i].GetName()), migration.ErrCodeResourceApplyFailed)
 		}
 	}
 	return nil
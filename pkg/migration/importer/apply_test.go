@@ -0,0 +1,415 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package importer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/api/meta"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/fake"
+)
+
+// applyRecorder records the field managers and objects of apply calls instead
+// of applying, which the fake dynamic client does not support.
+type applyRecorder struct {
+	dynamic.Interface
+	managers []string
+	applied  []string
+}
+
+func (r *applyRecorder) Resource(gvr schema.GroupVersionResource) dynamic.NamespaceableResourceInterface {
+	return &recordingResource{NamespaceableResourceInterface: r.Interface.Resource(gvr), recorder: r}
+}
+
+type recordingResource struct {
+	dynamic.NamespaceableResourceInterface
+	recorder *applyRecorder
+}
+
+func (r *recordingResource) Namespace(ns string) dynamic.ResourceInterface {
+	return &recordingNamespacedResource{ResourceInterface: r.NamespaceableResourceInterface.Namespace(ns), recorder: r.recorder}
+}
+
+type recordingNamespacedResource struct {
+	dynamic.ResourceInterface
+	recorder *applyRecorder
+}
+
+func (r *recordingNamespacedResource) Apply(_ context.Context, _ string, obj *unstructured.Unstructured, opts v1.ApplyOptions, subresources ...string) (*unstructured.Unstructured, error) {
+	m := opts.FieldManager
+	if len(subresources) > 0 {
+		m = subresources[0] + ":" + m
+	}
+	r.recorder.managers = append(r.recorder.managers, m)
+	if len(subresources) == 0 {
+		r.recorder.applied = append(r.recorder.applied, obj.GetKind()+"/"+obj.GetName())
+	}
+	return obj, nil
+}
+
+func (r *recordingNamespacedResource) UpdateStatus(_ context.Context, obj *unstructured.Unstructured, opts v1.UpdateOptions) (*unstructured.Unstructured, error) {
+	r.recorder.managers = append(r.recorder.managers, "status:"+opts.FieldManager)
+	return obj, nil
+}
+
+func TestUnstructuredResourceApplierFieldManager(t *testing.T) {
+	gvk := schema.GroupVersionKind{Group: "example.org", Version: "v1", Kind: "Thing"}
+	thing := func(name string, managers ...v1.ManagedFieldsEntry) *unstructured.Unstructured {
+		u := &unstructured.Unstructured{}
+		u.SetGroupVersionKind(gvk)
+		u.SetNamespace("default")
+		u.SetName(name)
+		u.SetManagedFields(managers)
+		return u
+	}
+
+	type args struct {
+		opts        []ApplierOption
+		live        []runtime.Object
+		applyStatus bool
+	}
+	type want struct {
+		managers []string
+	}
+
+	cases := map[string]struct {
+		args args
+		want want
+	}{
+		"DefaultManager": {
+			args: args{
+				live: []runtime.Object{thing("a", v1.ManagedFieldsEntry{Manager: "kubectl"})},
+			},
+			want: want{
+				managers: []string{"up-controlplane-migrator"},
+			},
+		},
+		"AutoDetectExistingManager": {
+			args: args{
+				opts: []ApplierOption{WithAutoDetectFieldManager()},
+				live: []runtime.Object{thing("a",
+					v1.ManagedFieldsEntry{Manager: "crossplane", Subresource: "status"},
+					v1.ManagedFieldsEntry{Manager: "kubectl"},
+					v1.ManagedFieldsEntry{Manager: "argocd-controller"},
+				)},
+				applyStatus: true,
+			},
+			want: want{
+				managers: []string{"kubectl", "status:crossplane"},
+			},
+		},
+		"AutoDetectNotFound": {
+			args: args{
+				opts: []ApplierOption{WithAutoDetectFieldManager()},
+			},
+			want: want{
+				managers: []string{"up-controlplane-migrator"},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{gvk.GroupVersion()})
+			mapper.Add(gvk, meta.RESTScopeNamespace)
+			rec := &applyRecorder{Interface: fake.NewSimpleDynamicClient(runtime.NewScheme(), tc.args.live...)}
+
+			a := NewUnstructuredResourceApplier(rec, mapper, tc.args.opts...)
+			u := thing("a")
+			u.Object["status"] = map[string]any{"ready": true}
+			if err := a.ApplyResources(context.Background(), []unstructured.Unstructured{*u}, tc.args.applyStatus); err != nil {
+				t.Fatalf("ApplyResources() unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.want.managers, rec.managers); diff != "" {
+				t.Errorf("field managers mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestUnstructuredResourceApplierConflictStrategy(t *testing.T) {
+	gvk := schema.GroupVersionKind{Group: "example.org", Version: "v1", Kind: "Thing"}
+	thing := func(name string) *unstructured.Unstructured {
+		u := &unstructured.Unstructured{}
+		u.SetGroupVersionKind(gvk)
+		u.SetNamespace("default")
+		u.SetName(name)
+		return u
+	}
+
+	type want struct {
+		applied []string
+		skipped int
+		results []string
+		err     bool
+	}
+	cases := map[string]struct {
+		strategy ConflictStrategy
+		// resourceStrategy overrides strategy for things, if set.
+		resourceStrategy ConflictStrategy
+		want             want
+	}{
+		"Default": {
+			want: want{
+				applied: []string{"Thing/existing", "Thing/new"},
+				results: []string{LogResultOK, LogResultOK},
+			},
+		},
+		"Overwrite": {
+			strategy: ConflictStrategyOverwrite,
+			want: want{
+				applied: []string{"Thing/existing", "Thing/new"},
+				results: []string{LogResultOK, LogResultOK},
+			},
+		},
+		"Skip": {
+			strategy: ConflictStrategySkip,
+			want: want{
+				applied: []string{"Thing/new"},
+				skipped: 1,
+				results: []string{LogResultSkipped, LogResultOK},
+			},
+		},
+		"Fail": {
+			strategy: ConflictStrategyFail,
+			want: want{
+				results: []string{LogResultError},
+				err:     true,
+			},
+		},
+		"ResourceSkip": {
+			strategy:         ConflictStrategyOverwrite,
+			resourceStrategy: ConflictStrategySkip,
+			want: want{
+				applied: []string{"Thing/new"},
+				skipped: 1,
+				results: []string{LogResultSkipped, LogResultOK},
+			},
+		},
+		"ResourceOverwrite": {
+			strategy:         ConflictStrategyFail,
+			resourceStrategy: ConflictStrategyOverwrite,
+			want: want{
+				applied: []string{"Thing/existing", "Thing/new"},
+				results: []string{LogResultOK, LogResultOK},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{gvk.GroupVersion()})
+			mapper.Add(gvk, meta.RESTScopeNamespace)
+			rec := &applyRecorder{Interface: fake.NewSimpleDynamicClient(runtime.NewScheme(), thing("existing"))}
+
+			buf := &bytes.Buffer{}
+			opts := []ApplierOption{WithConflictStrategy(tc.strategy), WithStructuredLog(buf)}
+			if tc.resourceStrategy != "" {
+				opts = append(opts, WithResourceConflictStrategy(schema.GroupResource{Group: gvk.Group, Resource: "things"}, tc.resourceStrategy))
+			}
+			a := NewUnstructuredResourceApplier(rec, mapper, opts...)
+			err := a.ApplyResources(context.Background(), []unstructured.Unstructured{*thing("existing"), *thing("new")}, false)
+			if (err != nil) != tc.want.err {
+				t.Fatalf("ApplyResources(...): error = %v, want error %t", err, tc.want.err)
+			}
+			if diff := cmp.Diff(tc.want.applied, rec.applied); diff != "" {
+				t.Errorf("ApplyResources(...): applied: -want, +got:\n%s", diff)
+			}
+			if got := a.Skipped(); got != tc.want.skipped {
+				t.Errorf("Skipped() = %d, want %d", got, tc.want.skipped)
+			}
+
+			var results []string
+			dec := json.NewDecoder(buf)
+			for dec.More() {
+				e := LogEntry{}
+				if err := dec.Decode(&e); err != nil {
+					t.Fatalf("cannot decode log entry: %v", err)
+				}
+				results = append(results, e.Result)
+			}
+			if diff := cmp.Diff(tc.want.results, results); diff != "" {
+				t.Errorf("ApplyResources(...): logged results: -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestUnstructuredResourceApplierIncremental(t *testing.T) {
+	gvk := schema.GroupVersionKind{Group: "example.org", Version: "v1", Kind: "Thing"}
+	thing := func(name, size string, labels map[string]string) *unstructured.Unstructured {
+		u := &unstructured.Unstructured{Object: map[string]any{"spec": map[string]any{"size": size}}}
+		u.SetGroupVersionKind(gvk)
+		u.SetNamespace("default")
+		u.SetName(name)
+		u.SetLabels(labels)
+		return u
+	}
+
+	type want struct {
+		applied   []string
+		skipped   int
+		unchanged int
+	}
+	cases := map[string]struct {
+		reason   string
+		strategy ConflictStrategy
+		live     *unstructured.Unstructured
+		want     want
+	}{
+		"Identical": {
+			reason: "A resource that exists with the same spec and labels should not be applied.",
+			live: func() *unstructured.Unstructured {
+				u := thing("a", "small", map[string]string{"team": "a"})
+				u.SetResourceVersion("42")
+				u.SetAnnotations(map[string]string{"example.org/ignored": "true"})
+				return u
+			}(),
+			want: want{
+				unchanged: 1,
+			},
+		},
+		"DifferentSpec": {
+			reason: "A resource that exists with a different spec should be applied.",
+			live:   thing("a", "large", map[string]string{"team": "a"}),
+			want: want{
+				applied: []string{"Thing/a"},
+			},
+		},
+		"DifferentLabels": {
+			reason: "A resource that exists with different labels should be applied.",
+			live:   thing("a", "small", nil),
+			want: want{
+				applied: []string{"Thing/a"},
+			},
+		},
+		"DifferentSkipped": {
+			reason:   "A resource that exists but differs should be handled according to the conflict strategy.",
+			strategy: ConflictStrategySkip,
+			live:     thing("a", "large", map[string]string{"team": "a"}),
+			want: want{
+				skipped: 1,
+			},
+		},
+		"Missing": {
+			reason: "A resource that does not exist should be applied.",
+			live:   thing("b", "small", map[string]string{"team": "a"}),
+			want: want{
+				applied: []string{"Thing/a"},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{gvk.GroupVersion()})
+			mapper.Add(gvk, meta.RESTScopeNamespace)
+			rec := &applyRecorder{Interface: fake.NewSimpleDynamicClient(runtime.NewScheme(), tc.live)}
+
+			a := NewUnstructuredResourceApplier(rec, mapper, WithIncremental(), WithConflictStrategy(tc.strategy))
+			if err := a.ApplyResources(context.Background(), []unstructured.Unstructured{*thing("a", "small", map[string]string{"team": "a"})}, false); err != nil {
+				t.Fatalf("\n%s\nApplyResources(...): unexpected error: %v", tc.reason, err)
+			}
+			if diff := cmp.Diff(tc.want.applied, rec.applied); diff != "" {
+				t.Errorf("\n%s\nApplyResources(...): applied: -want, +got:\n%s", tc.reason, diff)
+			}
+			if got := a.Skipped(); got != tc.want.skipped {
+				t.Errorf("\n%s\nSkipped() = %d, want %d", tc.reason, got, tc.want.skipped)
+			}
+			if got := a.Unchanged(); got != tc.want.unchanged {
+				t.Errorf("\n%s\nUnchanged() = %d, want %d", tc.reason, got, tc.want.unchanged)
+			}
+		})
+	}
+}
+
+func TestUnstructuredResourceApplierRestoreStatus(t *testing.T) {
+	gvk := schema.GroupVersionKind{Group: "example.org", Version: "v1", Kind: "Thing"}
+	thing := func(status map[string]any) *unstructured.Unstructured {
+		u := &unstructured.Unstructured{}
+		u.SetGroupVersionKind(gvk)
+		u.SetNamespace("default")
+		u.SetName("a")
+		if status != nil {
+			u.Object["status"] = status
+		}
+		return u
+	}
+
+	type args struct {
+		resource    *unstructured.Unstructured
+		applyStatus bool
+	}
+	type want struct {
+		managers []string
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"RestoreStatus": {
+			reason: "The status of applied resources should be restored through the status subresource.",
+			args: args{
+				resource:    thing(map[string]any{"atProvider": map[string]any{"id": "abc"}}),
+				applyStatus: true,
+			},
+			want: want{
+				managers: []string{"up-controlplane-migrator", "status:up-controlplane-migrator"},
+			},
+		},
+		"NoStatusSubresource": {
+			reason: "The status of types without a status subresource should not be restored.",
+			args: args{
+				resource: thing(map[string]any{"atProvider": map[string]any{"id": "abc"}}),
+			},
+			want: want{
+				managers: []string{"up-controlplane-migrator"},
+			},
+		},
+		"NoStatus": {
+			reason: "Resources without a status should not be updated again.",
+			args: args{
+				resource:    thing(nil),
+				applyStatus: true,
+			},
+			want: want{
+				managers: []string{"up-controlplane-migrator"},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{gvk.GroupVersion()})
+			mapper.Add(gvk, meta.RESTScopeNamespace)
+			rec := &applyRecorder{Interface: fake.NewSimpleDynamicClient(runtime.NewScheme(), thing(nil))}
+
+			a := NewUnstructuredResourceApplier(rec, mapper)
+			if err := a.ApplyResources(context.Background(), []unstructured.Unstructured{*tc.args.resource}, tc.args.applyStatus); err != nil {
+				t.Fatalf("\n%s\nApplyResources(...): unexpected error: %v", tc.reason, err)
+			}
+			if diff := cmp.Diff(tc.want.managers, rec.managers); diff != "" {
+				t.Errorf("\n%s\nApplyResources(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package importer
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/upbound/up/pkg/migration/meta/v1alpha1"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+)
+
+// AnnotationLastImportedExport records when the last export imported into a
+// control plane was taken, on the namespace Crossplane runs in.
+const AnnotationLastImportedExport = "migration.upbound.io/last-imported-export"
+
+var namespacesGVR = schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}
+
+// ImportHistory records the exports imported into a control plane, so that
+// differential exports are only imported on top of the exports they are based
+// on.
+type ImportHistory struct {
+	dynamicClient dynamic.Interface
+	namespace     string
+}
+
+// NewImportHistory returns the ImportHistory of the control plane with
+// Crossplane running in the supplied namespace.
+func NewImportHistory(dynamicClient dynamic.Interface, namespace string) *ImportHistory {
+	return &ImportHistory{
+		dynamicClient: dynamicClient,
+		namespace:     namespace,
+	}
+}
+
+// LastImportedExport returns when the last export imported into the control
+// plane was taken, or nil if none was imported.
+func (h *ImportHistory) LastImportedExport(ctx context.Context) (*time.Time, error) {
+	if h.namespace == "" {
+		return nil, nil
+	}
+	ns, err := h.dynamicClient.Resource(namespacesGVR).Get(ctx, h.namespace, v1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot get namespace %q", h.namespace)
+	}
+	v, ok := ns.GetAnnotations()[AnnotationLastImportedExport]
+	if !ok {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot parse annotation %q of namespace %q", AnnotationLastImportedExport, h.namespace)
+	}
+	return &t, nil
+}
+
+// RecordImportedExport records that the export described by em was imported
+// into the control plane.
+func (h *ImportHistory) RecordImportedExport(ctx context.Context, em *v1alpha1.ExportMeta) error {
+	if h.namespace == "" {
+		return nil
+	}
+	p, err := json.Marshal(map[string]any{
+		"metadata": map[string]any{
+			"annotations": map[string]string{
+				AnnotationLastImportedExport: em.ExportedAt.UTC().Format(time.RFC3339Nano),
+			},
+		},
+	})
+	if err != nil {
+		return errors.Wrap(err, "cannot marshal namespace patch")
+	}
+	_, err = h.dynamicClient.Resource(namespacesGVR).Patch(ctx, h.namespace, types.MergePatchType, p, v1.PatchOptions{})
+	return errors.Wrapf(err, "cannot annotate namespace %q", h.namespace)
+}
+
+// CheckDifferential returns an error if the export described by em is a
+// differential export that is not based on an export already imported into
+// the control plane.
+func (h *ImportHistory) CheckDifferential(ctx context.Context, em *v1alpha1.ExportMeta) error {
+	if em.ChangedSince == nil {
+		return nil
+	}
+	last, err := h.LastImportedExport(ctx)
+	if err != nil {
+		return err
+	}
+	if last == nil {
+		return errors.Errorf("export only contains resources changed since %s, but no prior export was imported into the target control plane", em.ChangedSince.Format(time.RFC3339Nano))
+	}
+	if last.Before(*em.ChangedSince) {
+		return errors.Errorf("export only contains resources changed since %s, but the last export imported into the target control plane was taken at %s", em.ChangedSince.Format(time.RFC3339Nano), last.Format(time.RFC3339Nano))
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package importer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+
+	"github.com/upbound/up/pkg/migration/meta/v1alpha1"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+)
+
+func TestImportHistoryCheckDifferential(t *testing.T) {
+	cutoff := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	namespace := func(annotations map[string]string) *unstructured.Unstructured {
+		u := &unstructured.Unstructured{}
+		u.SetAPIVersion("v1")
+		u.SetKind("Namespace")
+		u.SetName("crossplane-system")
+		u.SetAnnotations(annotations)
+		return u
+	}
+
+	type args struct {
+		ns *unstructured.Unstructured
+		em *v1alpha1.ExportMeta
+	}
+	type want struct {
+		err error
+	}
+
+	cases := map[string]struct {
+		args args
+		want want
+	}{
+		"FullExport": {
+			args: args{
+				ns: namespace(nil),
+				em: &v1alpha1.ExportMeta{},
+			},
+			want: want{},
+		},
+		"NoPriorImport": {
+			args: args{
+				ns: namespace(nil),
+				em: &v1alpha1.ExportMeta{ChangedSince: &cutoff},
+			},
+			want: want{
+				err: errors.New("export only contains resources changed since 2024-03-01T12:00:00Z, but no prior export was imported into the target control plane"),
+			},
+		},
+		"PriorImportTooOld": {
+			args: args{
+				ns: namespace(map[string]string{AnnotationLastImportedExport: "2024-03-01T11:00:00Z"}),
+				em: &v1alpha1.ExportMeta{ChangedSince: &cutoff},
+			},
+			want: want{
+				err: errors.New("export only contains resources changed since 2024-03-01T12:00:00Z, but the last export imported into the target control plane was taken at 2024-03-01T11:00:00Z"),
+			},
+		},
+		"PriorImport": {
+			args: args{
+				ns: namespace(map[string]string{AnnotationLastImportedExport: "2024-03-01T12:30:00Z"}),
+				em: &v1alpha1.ExportMeta{ChangedSince: &cutoff},
+			},
+			want: want{},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			h := NewImportHistory(dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), tc.args.ns), "crossplane-system")
+			err := h.CheckDifferential(context.Background(), tc.args.em)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nCheckDifferential(...): -want err, +got err:\n%s", name, diff)
+			}
+		})
+	}
+}
+
+func TestImportHistoryRecordImportedExport(t *testing.T) {
+	ns := &unstructured.Unstructured{}
+	ns.SetAPIVersion("v1")
+	ns.SetKind("Namespace")
+	ns.SetName("crossplane-system")
+	h := NewImportHistory(dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), ns), "crossplane-system")
+
+	exportedAt := time.Date(2024, 3, 1, 12, 0, 0, 500, time.UTC)
+	if err := h.RecordImportedExport(context.Background(), &v1alpha1.ExportMeta{ExportedAt: exportedAt}); err != nil {
+		t.Fatalf("RecordImportedExport(...): unexpected error: %v", err)
+	}
+	got, err := h.LastImportedExport(context.Background())
+	if err != nil {
+		t.Fatalf("LastImportedExport(...): unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(&exportedAt, got); diff != "" {
+		t.Errorf("LastImportedExport(...): -want, +got:\n%s", diff)
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package importer
+
+import (
+	"context"
+	"sort"
+	"strconv"
+
+	"github.com/pterm/pterm"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+)
+
+// DryRunResourceApplier checks that the target control plane serves the types
+// of resources instead of applying them, and records the resources it would
+// have applied or modified.
+type DryRunResourceApplier struct {
+	resourceMapper meta.RESTMapper
+
+	Applied  []unstructured.Unstructured
+	Modified []unstructured.Unstructured
+}
+
+// NewDryRunResourceApplier returns a new DryRunResourceApplier.
+func NewDryRunResourceApplier(resourceMapper meta.RESTMapper) *DryRunResourceApplier {
+	return &DryRunResourceApplier{
+		resourceMapper: resourceMapper,
+	}
+}
+
+func (a *DryRunResourceApplier) ApplyResources(_ context.Context, resources []unstructured.Unstructured, _ bool) error {
+	for i := range resources {
+		gvk := resources[i].GroupVersionKind()
+		if _, err := a.resourceMapper.RESTMapping(gvk.GroupKind(), gvk.Version); err != nil {
+			return errors.Wrapf(err, "cannot apply resource %s/%s", resources[i].GetKind(), resources[i].GetName())
+		}
+		a.Applied = append(a.Applied, resources[i])
+	}
+	return nil
+}
+
+func (a *DryRunResourceApplier) ModifyResources(_ context.Context, resources []unstructured.Unstructured, _ func(*unstructured.Unstructured) error) error {
+	a.Modified = append(a.Modified, resources...)
+	return nil
+}
+
+// dryRun runs the preflight checks and imports all exported resources with a
+// DryRunResourceApplier, then prints how many resources of each type would be
+// imported. It returns all detected problems.
+func (im *ControlPlaneStateImporter) dryRun(ctx context.Context) error {
+	errs := im.preflightChecks(ctx)
+	if im.fs == nil {
+		// Preflight checks could not read the exported state.
+		return errors.Join(errs...)
+	}
+
+	r := NewPausingResourceImporter(NewFileSystemReader(*im.fs), NewDryRunResourceApplier(im.resourceMapper), im.resourceImporterOptions()...)
+	excluded, err := im.excludedResources()
+	if err != nil {
+		return err
+	}
+	grs, err := im.fs.ReadDir("/")
+	if err != nil {
+		return errors.Wrap(err, "cannot list group resources")
+	}
+	counts := make(map[string]int, len(grs))
+	var skipped []string
+	for _, info := range grs {
+		if isMetadataFile(info.Name()) {
+			continue
+		}
+		if !info.IsDir() {
+			errs = append(errs, errors.Errorf("unexpected file %q in root directory of exported state", info.Name()))
+			continue
+		}
+		if isExcludedResource(info.Name(), excluded) {
+			skipped = append(skipped, info.Name())
+			continue
+		}
+		count, err := r.ImportResources(ctx, info.Name(), true)
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "cannot import %q resources", info.Name()))
+			continue
+		}
+		counts[info.Name()] = count
+	}
+
+	if err := printDryRunSummary(counts, skipped); err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		return errors.Wrap(errors.Join(errs...), "dry run failed")
+	}
+	pterm.Println("\nDry run succeeded, no changes were made to the control plane.")
+	return nil
+}
+
+func printDryRunSummary(counts map[string]int, skipped []string) error {
+	data, total := dryRunSummary(counts, skipped)
+	pterm.Printf("%d resources would be imported:\n", total)
+	return pterm.DefaultTable.WithHasHeader().WithData(data).Render()
+}
+
+// dryRunSummary returns a table of the number of resources of each type that
+// would be imported, with excluded types marked as skipped, and the total
+// number of resources that would be imported.
+func dryRunSummary(counts map[string]int, skipped []string) (pterm.TableData, int) {
+	rows := make(map[string]string, len(counts)+len(skipped))
+	total := 0
+	for gr, count := range counts {
+		rows[gr] = strconv.Itoa(count)
+		total += count
+	}
+	for _, gr := range skipped {
+		rows[gr] = LogResultSkipped
+	}
+	grs := make([]string, 0, len(rows))
+	for gr := range rows {
+		grs = append(grs, gr)
+	}
+	sort.Strings(grs)
+
+	data := pterm.TableData{{"TYPE", "RESOURCES"}}
+	for _, gr := range grs {
+		data = append(data, []string{gr, rows[gr]})
+	}
+	return data, total
+}
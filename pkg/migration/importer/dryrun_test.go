@@ -0,0 +1,170 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package importer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/afero"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+const configMapYAML = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+  namespace: default
+data:
+  key: value
+`
+
+func exportedState(t *testing.T, files map[string]string) afero.Afero {
+	t.Helper()
+	fs := afero.Afero{Fs: afero.NewMemMapFs()}
+	for p, c := range files {
+		if err := fs.WriteFile(p, []byte(c), 0600); err != nil {
+			t.Fatalf("cannot write %q: %v", p, err)
+		}
+	}
+	return fs
+}
+
+func TestPausingResourceImporterDryRun(t *testing.T) {
+	core := schema.GroupVersion{Version: "v1"}
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{core})
+	mapper.Add(core.WithKind("ConfigMap"), meta.RESTScopeNamespace)
+
+	fs := exportedState(t, map[string]string{
+		"configmaps/namespaces/default/config.yaml": configMapYAML,
+	})
+
+	type want struct {
+		count  int
+		writes []string
+	}
+
+	cases := map[string]struct {
+		dryRun bool
+		want   want
+	}{
+		"Apply": {
+			want: want{
+				count:  1,
+				writes: []string{"patch"},
+			},
+		},
+		"DryRun": {
+			dryRun: true,
+			want: want{
+				count: 1,
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			dyn := fake.NewSimpleDynamicClient(runtime.NewScheme())
+			dyn.PrependReactor("patch", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				return true, &unstructured.Unstructured{}, nil
+			})
+
+			var a ResourceApplier = NewUnstructuredResourceApplier(dyn, mapper)
+			if tc.dryRun {
+				a = NewDryRunResourceApplier(mapper)
+			}
+			count, err := NewPausingResourceImporter(NewFileSystemReader(fs), a).ImportResources(context.Background(), "configmaps", false)
+			if err != nil {
+				t.Fatalf("ImportResources(...): unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.want.count, count); diff != "" {
+				t.Errorf("\n%s\nImportResources(...): -want count, +got count:\n%s", name, diff)
+			}
+
+			var writes []string
+			for _, action := range dyn.Actions() {
+				if action.GetVerb() != "get" && action.GetVerb() != "list" {
+					writes = append(writes, action.GetVerb())
+				}
+			}
+			if diff := cmp.Diff(tc.want.writes, writes); diff != "" {
+				t.Errorf("\n%s\nImportResources(...): -want writes, +got writes:\n%s", name, diff)
+			}
+		})
+	}
+}
+
+func TestControlPlaneStateImporterDryRun(t *testing.T) {
+	core := schema.GroupVersion{Version: "v1"}
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{core})
+	mapper.Add(core.WithKind("ConfigMap"), meta.RESTScopeNamespace)
+
+	type want struct {
+		err bool
+	}
+
+	cases := map[string]struct {
+		files map[string]string
+		want  want
+	}{
+		"Importable": {
+			files: map[string]string{
+				"export.yaml": "version: v1alpha1\n",
+				"configmaps/namespaces/default/config.yaml": configMapYAML,
+			},
+		},
+		"UnservedType": {
+			files: map[string]string{
+				"export.yaml": "version: v1alpha1\n",
+				"configmaps/namespaces/default/config.yaml": configMapYAML,
+				"things.example.org/cluster/thing.yaml":     "apiVersion: example.org/v1\nkind: Thing\nmetadata:\n  name: thing\n",
+				"things.example.org/metadata.yaml":          "categories: [managed]\n",
+			},
+			want: want{
+				err: true,
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			fs := exportedState(t, tc.files)
+			dyn := fake.NewSimpleDynamicClient(runtime.NewScheme())
+			reviewAccess(dyn, nil)
+			kube := kubefake.NewSimpleClientset()
+			im := NewControlPlaneStateImporter(dyn, kube.Discovery(), kube.AppsV1(), resettableMapper{DefaultRESTMapper: mapper}, Options{DryRun: true})
+			im.fs = &fs
+
+			err := im.Import(context.Background())
+			if (err != nil) != tc.want.err {
+				t.Errorf("\n%s\nImport(...): want error %t, got %v", name, tc.want.err, err)
+			}
+			var calls []k8stesting.Action
+			for _, action := range dyn.Actions() {
+				if !isAccessReview(action) {
+					calls = append(calls, action)
+				}
+			}
+			if len(calls) > 0 {
+				t.Errorf("\n%s\nImport(...): unexpected calls to the control plane: %v", name, calls)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+)
+
+// excludedResources returns the group resources excluded from the import,
+// i.e. ExcludeResources and those listed in ExcludeResourcesFile. Empty lines
+// and lines starting with "#" in the file are ignored.
+func (im *ControlPlaneStateImporter) excludedResources() ([]string, error) {
+	excluded := append([]string(nil), im.options.ExcludeResources...)
+	if im.options.ExcludeResourcesFile == "" {
+		return excluded, nil
+	}
+	b, err := os.ReadFile(filepath.Clean(im.options.ExcludeResourcesFile))
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot read excluded resources file")
+	}
+	s := bufio.NewScanner(bytes.NewReader(b))
+	for s.Scan() {
+		gr := strings.TrimSpace(s.Text())
+		if gr == "" || strings.HasPrefix(gr, "#") {
+			continue
+		}
+		excluded = append(excluded, gr)
+	}
+	return excluded, errors.Wrap(s.Err(), "cannot read excluded resources file")
+}
+
+// isExcludedResource returns true if the group resource gr is excluded.
+func isExcludedResource(gr string, excluded []string) bool {
+	for _, e := range excluded {
+		if e == gr {
+			return true
+		}
+	}
+	return false
+}
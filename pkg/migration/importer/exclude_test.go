@@ -0,0 +1,151 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package importer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pterm/pterm"
+	apiextensionsfake "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/fake"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/upbound/up/pkg/migration/exporter"
+)
+
+func TestExcludedResources(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "exclude.txt")
+	if err := os.WriteFile(file, []byte("# Managed by external-secrets.\nsecrets\n\n  things.example.org  \n"), 0600); err != nil {
+		t.Fatalf("cannot write excluded resources file: %v", err)
+	}
+
+	type want struct {
+		excluded []string
+		err      bool
+	}
+	cases := map[string]struct {
+		opts Options
+		want want
+	}{
+		"None": {},
+		"Options": {
+			opts: Options{ExcludeResources: []string{"configmaps"}},
+			want: want{excluded: []string{"configmaps"}},
+		},
+		"Merged": {
+			opts: Options{ExcludeResources: []string{"configmaps"}, ExcludeResourcesFile: file},
+			want: want{excluded: []string{"configmaps", "secrets", "things.example.org"}},
+		},
+		"MissingFile": {
+			opts: Options{ExcludeResourcesFile: filepath.Join(t.TempDir(), "missing.txt")},
+			want: want{err: true},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			im := &ControlPlaneStateImporter{options: tc.opts}
+			got, err := im.excludedResources()
+			if (err != nil) != tc.want.err {
+				t.Fatalf("\n%s\nexcludedResources(): want error %t, got %v", name, tc.want.err, err)
+			}
+			if diff := cmp.Diff(tc.want.excluded, got); diff != "" {
+				t.Errorf("\n%s\nexcludedResources(): -want, +got:\n%s", name, diff)
+			}
+		})
+	}
+}
+
+func TestDryRunSummary(t *testing.T) {
+	data, total := dryRunSummary(map[string]int{"namespaces": 2, "configmaps": 3}, []string{"secrets"})
+	want := pterm.TableData{
+		{"TYPE", "RESOURCES"},
+		{"configmaps", "3"},
+		{"namespaces", "2"},
+		{"secrets", "skipped"},
+	}
+	if diff := cmp.Diff(want, data); diff != "" {
+		t.Errorf("dryRunSummary(...): -want table, +got table:\n%s", diff)
+	}
+	if total != 5 {
+		t.Errorf("dryRunSummary(...): want total 5, got %d", total)
+	}
+}
+
+func TestControlPlaneStateImporterExcludeResources(t *testing.T) {
+	mapper, listKinds := roundTripMapper(t)
+
+	dir := filepath.Join(t.TempDir(), "state")
+	kube := kubefake.NewSimpleClientset()
+	e := exporter.NewControlPlaneStateExporter(
+		apiextensionsfake.NewSimpleClientset(),
+		dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), namespaceAndConfigMap("default")...),
+		kube.Discovery(),
+		kube.AppsV1(),
+		mapper,
+		exporter.Options{
+			OutputArchive:         dir,
+			OutputFormat:          exporter.OutputFormatDirectory,
+			IncludeExtraResources: []string{"namespaces", "configmaps"},
+		})
+	if err := e.Export(context.Background()); err != nil {
+		t.Fatalf("Export() unexpected error: %v", err)
+	}
+
+	cases := map[string]struct {
+		exclude []string
+		want    []string
+	}{
+		"None": {
+			want: []string{"Namespace/default", "ConfigMap/config"},
+		},
+		// Namespaces are a base resource, imported before all other types.
+		"BaseResource": {
+			exclude: []string{"namespaces"},
+			want:    []string{"ConfigMap/config"},
+		},
+		"Remaining": {
+			exclude: []string{"configmaps"},
+			want:    []string{"Namespace/default"},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			dyn := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds)
+			reviewAccess(dyn, nil)
+			target := &applyRecorder{Interface: dyn}
+			im := NewControlPlaneStateImporter(
+				target,
+				kube.Discovery(),
+				kube.AppsV1(),
+				resettableMapper{DefaultRESTMapper: mapper},
+				Options{
+					InputArchive:     dir,
+					InputFormat:      InputFormatDirectory,
+					ExcludeResources: tc.exclude,
+				})
+			if err := im.Import(context.Background()); err != nil {
+				t.Fatalf("\n%s\nImport() unexpected error: %v", name, err)
+			}
+			if diff := cmp.Diff(tc.want, target.applied); diff != "" {
+				t.Errorf("\n%s\nImport(): -want applied, +got applied:\n%s", name, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package importer
+
+import (
+	"context"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
+)
+
+// pipelineStep is the subset of a Composition pipeline step that references a
+// composition function.
+type pipelineStep struct {
+	Step        string `json:"step"`
+	FunctionRef struct {
+		Name string `json:"name"`
+	} `json:"functionRef"`
+}
+
+// CompositionFunctionValidator validates that all composition functions
+// referenced by the exported Compositions will be available after import.
+type CompositionFunctionValidator struct {
+	reader         ResourceReader
+	dynamicClient  dynamic.Interface
+	resourceMapper meta.RESTMapper
+}
+
+// NewCompositionFunctionValidator returns a new CompositionFunctionValidator.
+func NewCompositionFunctionValidator(r ResourceReader, dynamicClient dynamic.Interface, mapper meta.RESTMapper) *CompositionFunctionValidator {
+	return &CompositionFunctionValidator{
+		reader:         r,
+		dynamicClient:  dynamicClient,
+		resourceMapper: mapper,
+	}
+}
+
+// Validate returns an error for every function referenced by an exported
+// Composition pipeline that is neither installed in the target control plane
+// nor part of the export.
+func (v *CompositionFunctionValidator) Validate(ctx context.Context) []error {
+	comps, _, err := v.reader.ReadResources("compositions.apiextensions.crossplane.io")
+	if err != nil {
+		return []error{errors.Wrap(err, "Cannot read exported Compositions")}
+	}
+
+	// Composition name(s) per referenced function.
+	refs := map[string][]string{}
+	for _, c := range comps {
+		var steps []pipelineStep
+		if err := fieldpath.Pave(c.Object).GetValueInto("spec.pipeline", &steps); err != nil {
+			if fieldpath.IsNotFound(err) {
+				continue
+			}
+			return []error{errors.Wrapf(err, "Cannot get pipeline of Composition %q", c.GetName())}
+		}
+		for _, s := range steps {
+			if s.FunctionRef.Name != "" {
+				refs[s.FunctionRef.Name] = append(refs[s.FunctionRef.Name], c.GetName())
+			}
+		}
+	}
+	if len(refs) == 0 {
+		return nil
+	}
+
+	available := map[string]struct{}{}
+	exported, _, err := v.reader.ReadResources("functions.pkg.crossplane.io")
+	if err != nil {
+		return []error{errors.Wrap(err, "Cannot read exported Functions")}
+	}
+	for _, f := range exported {
+		available[f.GetName()] = struct{}{}
+	}
+	installed, err := v.installedFunctions(ctx)
+	if err != nil {
+		return []error{errors.Wrap(err, "Cannot list Functions in the target control plane")}
+	}
+	for _, f := range installed {
+		available[f] = struct{}{}
+	}
+
+	names := make([]string, 0, len(refs))
+	for n := range refs {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	var errs []error
+	for _, n := range names {
+		if _, ok := available[n]; !ok {
+			errs = append(errs, errors.Errorf("Function %q referenced by Composition(s) %q is not installed in the target control plane and not part of the export.", n, refs[n]))
+		}
+	}
+	return errs
+}
+
+func (v *CompositionFunctionValidator) installedFunctions(ctx context.Context) ([]string, error) {
+	rm, err := v.resourceMapper.RESTMapping(schema.GroupKind{Group: "pkg.crossplane.io", Kind: "Function"})
+	if meta.IsNoMatchError(err) {
+		// Functions are not supported by the target control plane.
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot get REST mapping for Functions")
+	}
+	l, err := v.dynamicClient.Resource(rm.Resource).List(ctx, v1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(l.Items))
+	for _, f := range l.Items {
+		names = append(names, f.GetName())
+	}
+	return names, nil
+}
@@ -0,0 +1,130 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package importer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/afero"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+)
+
+const compositionWithPipeline = `
+apiVersion: apiextensions.crossplane.io/v1
+kind: Composition
+metadata:
+  name: xdb
+spec:
+  mode: Pipeline
+  pipeline:
+  - step: patch-and-transform
+    functionRef:
+      name: function-patch-and-transform
+  - step: auto-ready
+    functionRef:
+      name: function-auto-ready
+`
+
+func TestCompositionFunctionValidator(t *testing.T) {
+	functionGVK := schema.GroupVersionKind{Group: "pkg.crossplane.io", Version: "v1beta1", Kind: "Function"}
+	function := func(name string) runtime.Object {
+		u := &unstructured.Unstructured{}
+		u.SetGroupVersionKind(functionGVK)
+		u.SetName(name)
+		return u
+	}
+
+	type args struct {
+		files     map[string]string
+		installed []runtime.Object
+	}
+	type want struct {
+		errs []error
+	}
+
+	cases := map[string]struct {
+		args args
+		want want
+	}{
+		"AllInstalled": {
+			args: args{
+				files: map[string]string{
+					"compositions.apiextensions.crossplane.io/cluster/xdb.yaml": compositionWithPipeline,
+				},
+				installed: []runtime.Object{function("function-patch-and-transform"), function("function-auto-ready")},
+			},
+			want: want{},
+		},
+		"ExportedFunction": {
+			args: args{
+				files: map[string]string{
+					"compositions.apiextensions.crossplane.io/cluster/xdb.yaml": compositionWithPipeline,
+					"functions.pkg.crossplane.io/cluster/function-auto-ready.yaml": `
+apiVersion: pkg.crossplane.io/v1beta1
+kind: Function
+metadata:
+  name: function-auto-ready
+`,
+				},
+				installed: []runtime.Object{function("function-patch-and-transform")},
+			},
+			want: want{},
+		},
+		"MissingFunction": {
+			args: args{
+				files: map[string]string{
+					"compositions.apiextensions.crossplane.io/cluster/xdb.yaml": compositionWithPipeline,
+				},
+				installed: []runtime.Object{function("function-patch-and-transform")},
+			},
+			want: want{
+				errs: []error{
+					errors.New(`Function "function-auto-ready" referenced by Composition(s) ["xdb"] is not installed in the target control plane and not part of the export.`),
+				},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			fs := afero.Afero{Fs: afero.NewMemMapFs()}
+			for f, c := range tc.args.files {
+				if err := fs.WriteFile(f, []byte(c), 0600); err != nil {
+					t.Fatalf("cannot write file %q: %v", f, err)
+				}
+			}
+			gvr := functionGVK.GroupVersion().WithResource("functions")
+			dyn := fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+				gvr: "FunctionList",
+			}, tc.args.installed...)
+			mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{functionGVK.GroupVersion()})
+			mapper.Add(functionGVK, meta.RESTScopeRoot)
+
+			v := NewCompositionFunctionValidator(NewFileSystemReader(fs), dyn, mapper)
+			errs := v.Validate(context.Background())
+			if diff := cmp.Diff(tc.want.errs, errs, test.EquateErrors()); diff != "" {
+				t.Errorf("Validate() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,177 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package importer
+
+import (
+	"context"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
+)
+
+const defaultHealthCheckInterval = 5 * time.Second
+
+// A HealthCheck checks that all resources of a kind satisfy the conditions.
+type HealthCheck struct {
+	GroupKind  schema.GroupKind
+	Conditions []xpv1.ConditionType
+}
+
+// HealthChecks are the checks the importer waits for after importing the
+// CompositeResourceDefinitions and packages.
+var HealthChecks = []HealthCheck{
+	{GroupKind: schema.GroupKind{Group: "apiextensions.crossplane.io", Kind: "CompositeResourceDefinition"}, Conditions: []xpv1.ConditionType{"Established"}},
+	{GroupKind: schema.GroupKind{Group: "pkg.crossplane.io", Kind: "Provider"}, Conditions: []xpv1.ConditionType{"Installed", "Healthy"}},
+	{GroupKind: schema.GroupKind{Group: "pkg.crossplane.io", Kind: "Function"}, Conditions: []xpv1.ConditionType{"Installed", "Healthy"}},
+	{GroupKind: schema.GroupKind{Group: "pkg.crossplane.io", Kind: "Configuration"}, Conditions: []xpv1.ConditionType{"Installed", "Healthy"}},
+	{GroupKind: schema.GroupKind{Group: "pkg.crossplane.io", Kind: "ProviderRevision"}, Conditions: []xpv1.ConditionType{"Healthy"}},
+	{GroupKind: schema.GroupKind{Group: "pkg.crossplane.io", Kind: "FunctionRevision"}, Conditions: []xpv1.ConditionType{"Healthy"}},
+	{GroupKind: schema.GroupKind{Group: "pkg.crossplane.io", Kind: "ConfigurationRevision"}, Conditions: []xpv1.ConditionType{"Healthy"}},
+}
+
+// HealthStatus is the number of ready resources of a kind.
+type HealthStatus struct {
+	GroupKind schema.GroupKind
+	Ready     int
+	Total     int
+}
+
+// HealthChecker checks the health of an imported control plane.
+type HealthChecker struct {
+	dynamicClient  dynamic.Interface
+	resourceMapper meta.RESTMapper
+
+	checks   []HealthCheck
+	interval time.Duration
+}
+
+// NewHealthChecker returns a HealthChecker running the supplied checks.
+func NewHealthChecker(dynamicClient dynamic.Interface, mapper meta.RESTMapper, checks []HealthCheck) *HealthChecker {
+	return &HealthChecker{
+		dynamicClient:  dynamicClient,
+		resourceMapper: mapper,
+		checks:         checks,
+		interval:       defaultHealthCheckInterval,
+	}
+}
+
+// Check waits until all resources of all checked kinds satisfy their
+// conditions, or until ctx is done. It returns the last observed status of
+// every checked kind, and an error if any resource is not ready. Kinds not
+// served by the control plane are reported without resources.
+func (h *HealthChecker) Check(ctx context.Context) ([]HealthStatus, error) {
+	statuses := make([]HealthStatus, len(h.checks))
+	for i, c := range h.checks {
+		statuses[i].GroupKind = c.GroupKind
+	}
+
+	var err error
+	healthy := false
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		err = nil
+		for i, c := range h.checks {
+			ready, total, cerr := h.readiness(ctx, c)
+			if cerr != nil {
+				err = cerr
+				return
+			}
+			statuses[i].Ready, statuses[i].Total = ready, total
+		}
+		for _, s := range statuses {
+			if s.Ready < s.Total {
+				return
+			}
+		}
+		healthy = true
+		cancel()
+	}, h.interval)
+
+	if healthy {
+		return statuses, nil
+	}
+	if err != nil {
+		return statuses, err
+	}
+	for _, s := range statuses {
+		if s.Ready < s.Total {
+			return statuses, errors.Errorf("%d of %d %q are not ready", s.Total-s.Ready, s.Total, s.GroupKind)
+		}
+	}
+	return statuses, errors.New("health check was cancelled")
+}
+
+func (h *HealthChecker) readiness(ctx context.Context, c HealthCheck) (int, int, error) {
+	rm, err := h.resourceMapper.RESTMapping(c.GroupKind)
+	if meta.IsNoMatchError(err) {
+		return 0, 0, nil
+	}
+	if err != nil {
+		return 0, 0, errors.Wrapf(err, "cannot get REST mapping for %q", c.GroupKind)
+	}
+	return countReady(ctx, h.dynamicClient, rm.Resource, c.Conditions)
+}
+
+// countReady returns how many of the resources of the supplied GVR satisfy
+// all conditions, and how many resources there are.
+func countReady(ctx context.Context, dyn dynamic.Interface, gvr schema.GroupVersionResource, conditions []xpv1.ConditionType) (int, int, error) {
+	unready, total, err := listUnready(ctx, dyn, gvr, conditions)
+	return total - len(unready), total, err
+}
+
+// listUnready returns the names of the resources of the supplied type that do
+// not satisfy all conditions, and the number of all resources of the type.
+func listUnready(ctx context.Context, dyn dynamic.Interface, gvr schema.GroupVersionResource, conditions []xpv1.ConditionType) ([]string, int, error) {
+	resourceList, err := dyn.Resource(gvr).List(ctx, v1.ListOptions{})
+	if err != nil {
+		return nil, 0, errors.Wrapf(err, "cannot list %q", gvr.GroupResource())
+	}
+	var unready []string
+	for i := range resourceList.Items {
+		met, err := conditionsMet(&resourceList.Items[i], conditions)
+		if err != nil {
+			return nil, 0, err
+		}
+		if !met {
+			unready = append(unready, resourceList.Items[i].GetName())
+		}
+	}
+	return unready, len(resourceList.Items), nil
+}
+
+// conditionsMet returns true if all conditions of the resource are true.
+func conditionsMet(r *unstructured.Unstructured, conditions []xpv1.ConditionType) (bool, error) {
+	status := xpv1.ConditionedStatus{}
+	if err := fieldpath.Pave(r.Object).GetValueInto("status", &status); err != nil && !fieldpath.IsNotFound(err) {
+		return false, errors.Wrapf(err, "cannot get status of %q %q", r.GetKind(), r.GetName())
+	}
+	for _, c := range conditions {
+		if status.GetCondition(c).Status != corev1.ConditionTrue {
+			return false, nil
+		}
+	}
+	return true, nil
+}
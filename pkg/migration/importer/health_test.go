@@ -0,0 +1,125 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package importer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+)
+
+func TestHealthCheckerCheck(t *testing.T) {
+	providers := schema.GroupVersion{Group: "pkg.crossplane.io", Version: "v1"}
+	xrds := schema.GroupVersion{Group: "apiextensions.crossplane.io", Version: "v1"}
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{providers, xrds})
+	mapper.Add(providers.WithKind("Provider"), meta.RESTScopeRoot)
+	mapper.Add(xrds.WithKind("CompositeResourceDefinition"), meta.RESTScopeRoot)
+	listKinds := map[schema.GroupVersionResource]string{
+		providers.WithResource("providers"):               "ProviderList",
+		xrds.WithResource("compositeresourcedefinitions"): "CompositeResourceDefinitionList",
+	}
+
+	resource := func(gv schema.GroupVersion, kind, name string, conditions ...string) runtime.Object {
+		u := &unstructured.Unstructured{}
+		u.SetAPIVersion(gv.String())
+		u.SetKind(kind)
+		u.SetName(name)
+		cs := make([]any, 0, len(conditions))
+		for _, c := range conditions {
+			cs = append(cs, map[string]any{"type": c, "status": "True"})
+		}
+		u.Object["status"] = map[string]any{"conditions": cs}
+		return u
+	}
+	checks := []HealthCheck{
+		{GroupKind: schema.GroupKind{Group: "apiextensions.crossplane.io", Kind: "CompositeResourceDefinition"}, Conditions: HealthChecks[0].Conditions},
+		{GroupKind: schema.GroupKind{Group: "pkg.crossplane.io", Kind: "Provider"}, Conditions: HealthChecks[1].Conditions},
+		{GroupKind: schema.GroupKind{Group: "pkg.crossplane.io", Kind: "Function"}, Conditions: HealthChecks[2].Conditions},
+	}
+
+	type args struct {
+		objects []runtime.Object
+	}
+	type want struct {
+		statuses []HealthStatus
+		err      error
+	}
+
+	cases := map[string]struct {
+		args args
+		want want
+	}{
+		"Healthy": {
+			args: args{
+				objects: []runtime.Object{
+					resource(xrds, "CompositeResourceDefinition", "xclusters.example.org", "Established"),
+					resource(providers, "Provider", "provider-aws", "Installed", "Healthy"),
+					resource(providers, "Provider", "provider-gcp", "Healthy", "Installed"),
+				},
+			},
+			want: want{
+				statuses: []HealthStatus{
+					{GroupKind: checks[0].GroupKind, Ready: 1, Total: 1},
+					{GroupKind: checks[1].GroupKind, Ready: 2, Total: 2},
+					{GroupKind: checks[2].GroupKind},
+				},
+			},
+		},
+		"Unhealthy": {
+			args: args{
+				objects: []runtime.Object{
+					resource(xrds, "CompositeResourceDefinition", "xclusters.example.org", "Established"),
+					resource(providers, "Provider", "provider-aws", "Installed", "Healthy"),
+					resource(providers, "Provider", "provider-gcp", "Installed"),
+					resource(providers, "Provider", "provider-azure"),
+				},
+			},
+			want: want{
+				statuses: []HealthStatus{
+					{GroupKind: checks[0].GroupKind, Ready: 1, Total: 1},
+					{GroupKind: checks[1].GroupKind, Ready: 1, Total: 3},
+					{GroupKind: checks[2].GroupKind},
+				},
+				err: errors.New(`2 of 3 "Provider.pkg.crossplane.io" are not ready`),
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			h := NewHealthChecker(dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds, tc.args.objects...), mapper, checks)
+			h.interval = 10 * time.Millisecond
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+
+			statuses, err := h.Check(ctx)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nCheck(...): -want err, +got err:\n%s", name, diff)
+			}
+			if diff := cmp.Diff(tc.want.statuses, statuses); diff != "" {
+				t.Errorf("\n%s\nCheck(...): -want, +got:\n%s", name, diff)
+			}
+		})
+	}
+}
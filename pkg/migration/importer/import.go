@@ -15,31 +15,36 @@
 package importer
 
 import (
-	"archive/tar"
-	"compress/gzip"
 	"context"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/pterm/pterm"
 	"github.com/spf13/afero"
+	"go.opentelemetry.io/otel/trace"
 	"gopkg.in/yaml.v3"
-	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
-	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
-	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
 	"k8s.io/client-go/dynamic"
 	appsv1 "k8s.io/client-go/kubernetes/typed/apps/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
 
+	"github.com/upbound/up/pkg/migration"
+	"github.com/upbound/up/pkg/migration/archiver"
 	"github.com/upbound/up/pkg/migration/category"
 	"github.com/upbound/up/pkg/migration/crossplane"
+	migrationmeta "github.com/upbound/up/pkg/migration/meta"
 	"github.com/upbound/up/pkg/migration/meta/v1alpha1"
+	"github.com/upbound/up/pkg/migration/telemetry"
+	"github.com/upbound/up/pkg/migration/transform"
 
 	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
 	"github.com/crossplane/crossplane-runtime/pkg/errors"
@@ -47,6 +52,19 @@ import (
 	xpmeta "github.com/crossplane/crossplane-runtime/pkg/meta"
 )
 
+const (
+	errGlobalTimeout = "global timeout exceeded"
+
+	// telemetryShutdownTimeout bounds flushing pending spans after import.
+	telemetryShutdownTimeout = 10 * time.Second
+	// rollbackTimeout bounds deleting the resources created by a failed
+	// import.
+	rollbackTimeout = 5 * time.Minute
+
+	defaultWaitTimeout      = 10 * time.Minute
+	defaultWaitPollInterval = 5 * time.Second
+)
+
 var (
 	baseResources = []string{
 		// Core Kubernetes resources
@@ -59,23 +77,189 @@ var (
 		"controllerconfigs.pkg.crossplane.io",
 		"deploymentruntimeconfigs.pkg.crossplane.io",
 		"storeconfigs.secrets.crossplane.io",
+		// ImageConfigs are imported before packages, so that their pull
+		// configuration applies when the packages are installed.
+		"imageconfigs.pkg.crossplane.io",
 		// Compositions
 		"compositionrevisions.apiextensions.crossplane.io",
 		"compositions.apiextensions.crossplane.io",
 		"compositeresourcedefinitions.apiextensions.crossplane.io",
+		"environmentconfigs.apiextensions.crossplane.io",
 		// Packages
 		"providers.pkg.crossplane.io",
 		"functions.pkg.crossplane.io",
 		"configurations.pkg.crossplane.io",
+
+		// Webhook configurations
+		// They are imported last, so that webhooks that are not served yet
+		// do not reject the base resources above.
+		"mutatingwebhookconfigurations.admissionregistration.k8s.io",
+		"validatingwebhookconfigurations.admissionregistration.k8s.io",
+	}
+
+	// webhookConfigResources are the base resources imported according to
+	// Options.WebhookConflictStrategy.
+	webhookConfigResources = []string{
+		"mutatingwebhookconfigurations.admissionregistration.k8s.io",
+		"validatingwebhookconfigurations.admissionregistration.k8s.io",
 	}
 )
 
+// InputFormat is the format of an export to be imported.
+type InputFormat string
+
+const (
+	// InputFormatArchive imports a gzip or zstd compressed tar archive.
+	InputFormatArchive InputFormat = "archive"
+	// InputFormatDirectory imports a directory of plain YAML files.
+	InputFormatDirectory InputFormat = "directory"
+)
+
 // Options are the options for the import command.
 type Options struct {
-	// InputArchive is the path to the archive to be imported.
+	// InputArchive is the path to the archive to be imported. If "-", the
+	// archive is read from InputReader instead. It is the path to the
+	// directory to be imported if InputFormat is InputFormatDirectory. A
+	// directory with a segment manifest is imported as an export segmented
+	// by namespace, regardless of InputFormat.
 	InputArchive string // default: xp-state.tar.gz
+	// InputFormat is the format of the export to be imported.
+	InputFormat InputFormat // default: archive
+	// InputReader is where the archive is read from if InputArchive is "-".
+	InputReader io.Reader // default: os.Stdin
+	// InputFS is the file system the directory at InputArchive is read from
+	// if InputFormat is InputFormatDirectory, e.g. a directory exported to
+	// memory.
+	InputFS afero.Fs // default: the local file system
+	// InputOCIRef is the reference of an OCI artifact in a container
+	// registry the archive is pulled from instead of InputArchive, e.g.
+	// "registry.example.com/exports/prod:v1".
+	InputOCIRef string // default: none
+	// EncryptionKey is the base64 encoded 32 byte key an encrypted archive
+	// is decrypted with. Archives that are not encrypted are imported as is.
+	EncryptionKey string // default: none
+	// MaxMemoryMB is how many megabytes of the unarchived export are kept in
+	// memory. Files larger than 1 MB, and all files exceeding this limit, are
+	// spilled to a temporary directory on disk instead.
+	MaxMemoryMB int // default: 512
 	// UnpauseAfterImport indicates whether to unpause all managed resources after import.
 	UnpauseAfterImport bool // default: false
+	// ActivationBatchSize is the number of managed resources unpaused at a
+	// time after import, if UnpauseAfterImport is set. Each batch must
+	// become ready before the next one is unpaused. All managed resources
+	// are unpaused at once if zero.
+	ActivationBatchSize int // default: 0
+	// ActivationBatchInterval is how long to wait after a batch of managed
+	// resources became ready before unpausing the next one.
+	ActivationBatchInterval time.Duration // default: 0
+	// WaitTimeout is how long to wait for CompositeResourceDefinitions and
+	// packages to become ready, per kind.
+	WaitTimeout time.Duration // default: 10m
+	// WaitPollInterval is how often to check whether CompositeResourceDefinitions
+	// and packages are ready.
+	WaitPollInterval time.Duration // default: 5s
+	// WaitMaxPollInterval caps the poll interval, which is doubled after
+	// every check up to it, so that extended waits do not put unnecessary
+	// load on the API server. The interval is constant if not set.
+	WaitMaxPollInterval time.Duration // default: none
+
+	// CheckRegistryReachability indicates whether preflight checks should
+	// verify that the registries of all exported provider packages are
+	// reachable from within the target control plane. Only network
+	// reachability is checked, the registries are not authenticated against.
+	CheckRegistryReachability bool // default: false
+	// RegistryCheckImage is the image of the Job checking whether a registry
+	// is reachable. It must have curl as entrypoint. ImageRewriteRules are
+	// applied to it.
+	RegistryCheckImage string // default: curlimages/curl:8.6.0
+	// StrictProviderVersionCheck indicates whether preflight checks should
+	// verify that the exported providers already installed in the target
+	// control plane have the exported versions. Providers that are not
+	// installed are imported with their exported versions.
+	StrictProviderVersionCheck bool // default: false
+	// Timeout is the global deadline for the preflight checks and the import,
+	// measured from the start of whichever runs first. Zero means no deadline.
+	Timeout time.Duration // default: none
+	// AutoDetectFieldManager applies resources that already exist in the
+	// target control plane with their first existing field manager.
+	AutoDetectFieldManager bool // default: false
+	// AdaptiveRateLimit delays requests to the target control plane once it
+	// starts throttling them.
+	AdaptiveRateLimit bool // default: false
+	// EndpointRewrites are applied to ProviderConfigs before they are
+	// imported.
+	EndpointRewrites []transform.EndpointRewrite // default: none
+	// ImageRewriteRules rewrite the package images of Providers before they
+	// are imported, e.g. to install them from a mirror registry. The first
+	// matching rule is applied.
+	ImageRewriteRules []transform.ImageRewriteRule // default: none
+	// APIVersionConversions converts the deprecated API versions of exported
+	// resources to their current API versions before they are imported.
+	APIVersionConversions transform.ConversionTable // default: none
+	// ConflictStrategy determines how resources that already exist in the
+	// target control plane are imported.
+	ConflictStrategy ConflictStrategy // default: overwrite
+	// IncrementalImport skips resources that already exist in the target
+	// control plane with identical labels and content, e.g. when re-running
+	// an import after a partial failure. Existing resources that differ are
+	// imported according to ConflictStrategy.
+	IncrementalImport bool // default: false
+	// WebhookConflictStrategy determines how webhook configurations that
+	// already exist in the target control plane are imported. They are
+	// skipped by default, as they are usually managed by the providers.
+	WebhookConflictStrategy ConflictStrategy // default: skip
+	// ExcludeResources are the group resources not to import, e.g.
+	// "secrets" if they are managed by an external secret manager.
+	ExcludeResources []string // default: none
+	// ExcludeResourcesFile is the path of a file listing additional group
+	// resources not to import, one per line.
+	ExcludeResourcesFile string // default: none
+	// SkipRedactedSecrets does not import Secrets whose data was redacted
+	// during the export. They are imported without data otherwise.
+	SkipRedactedSecrets bool // default: false
+	// StructuredLogPath is the path of a file to write the outcome of
+	// applying each resource to, as JSON lines.
+	StructuredLogPath string // default: none
+	// RollbackOnFailure deletes all resources created by the import if it
+	// fails. Resources that existed before the import are left untouched.
+	RollbackOnFailure bool // default: false
+	// AmbiguousGVRResolution resolves exported types whose group resource
+	// matches types in several API groups of the target control plane.
+	// Resources are imported with the API version of their export if nil.
+	AmbiguousGVRResolution AmbiguousGVRResolution // default: none
+	// PreserveUIDs applies resources with the UIDs they had in the exported
+	// control plane. The API server assigns new UIDs to created resources
+	// regardless, so a UID only acts as a precondition when re-applying a
+	// resource to the control plane it was exported from: applying it fails
+	// if the existing resource has a different UID. It cannot be combined
+	// with the overwrite conflict strategy. Exports of format versions before
+	// v1.2.0 have no UIDs to preserve.
+	PreserveUIDs bool // default: false
+	// PreserveResourceVersion applies resources with the resourceVersions
+	// of their export, so that applying a resource fails if it changed since
+	// it was exported. This is only safe if the target control plane shares
+	// the etcd of the exported one, e.g. when restoring an export into the
+	// control plane it was taken from, as resourceVersions are etcd
+	// revisions. They are removed otherwise. Exports of 'migration export'
+	// have no resourceVersions to preserve.
+	PreserveResourceVersion bool // default: false
+	// NamespaceMapping maps exported namespaces to the namespaces their
+	// resources are imported into, e.g. "team-a" to "team-a-prod". Exported
+	// Namespaces are renamed accordingly. Namespaces that are not mapped are
+	// imported as is.
+	NamespaceMapping map[string]string // default: none
+
+	// DryRun validates that the export can be imported, by running the
+	// preflight checks and checking that the target control plane serves the
+	// types of all exported resources, without writing to it.
+	DryRun bool // default: false
+
+	// OTELEndpoint is the OTLP gRPC endpoint to export traces of the import
+	// to. Tracing is disabled if empty.
+	OTELEndpoint string // default: none
+	// TracerProvider to trace the import with, instead of configuring one for
+	// OTELEndpoint.
+	TracerProvider trace.TracerProvider // default: none
 }
 
 // ControlPlaneStateImporter is the importer for control plane state.
@@ -86,10 +270,44 @@ type ControlPlaneStateImporter struct {
 	resourceMapper  meta.ResettableRESTMapper
 
 	fs *afero.Afero
+	// spill is the file system the archive was unarchived to, if any.
+	spill *spillFs
+	// deadline is the global deadline shared by preflight checks and import.
+	deadline time.Time
 
 	options Options
 }
 
+// NewControlPlaneStateImporterForConfig creates a new importer for control
+// plane state importing into the control plane the supplied REST config
+// connects to.
+func NewControlPlaneStateImporterForConfig(cfg *rest.Config, opts Options) (*ControlPlaneStateImporter, error) {
+	dynamicClient, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(discoveryClient))
+
+	appsClient, err := appsv1.NewForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewControlPlaneStateImporter(dynamicClient, discoveryClient, appsClient, mapper, opts), nil
+}
+
+// validate returns an error if options are set that cannot be combined.
+func (o Options) validate() error {
+	if o.PreserveUIDs && (o.ConflictStrategy == "" || o.ConflictStrategy == ConflictStrategyOverwrite) {
+		return errors.New("cannot preserve UIDs with the overwrite conflict strategy, as existing resources with different UIDs cannot be overwritten")
+	}
+	return nil
+}
+
 // NewControlPlaneStateImporter creates a new importer for control plane state.
 func NewControlPlaneStateImporter(dynamicClient dynamic.Interface, discoveryClient discovery.DiscoveryInterface, appsClient appsv1.AppsV1Interface, mapper meta.ResettableRESTMapper, opts Options) *ControlPlaneStateImporter {
 	return &ControlPlaneStateImporter{
@@ -102,34 +320,172 @@ func NewControlPlaneStateImporter(dynamicClient dynamic.Interface, discoveryClie
 }
 
 // Import imports the control plane state.
-func (im *ControlPlaneStateImporter) Import(ctx context.Context) error { // nolint:gocyclo // This is the high level import command, so it's expected to be a bit complex.
-	// Reading state from the archive
+func (im *ControlPlaneStateImporter) Import(ctx context.Context) (err error) {
+	if err := im.options.validate(); err != nil {
+		return err
+	}
+	tp := im.options.TracerProvider
+	if tp == nil && im.options.OTELEndpoint != "" {
+		p, err := telemetry.NewTracerProvider(ctx, im.options.OTELEndpoint, telemetry.ResourceAttributes(ctx, im.dynamicClient, im.appsClient, im.options.InputArchive)...)
+		if err != nil {
+			return errors.Wrap(err, "cannot configure telemetry")
+		}
+		defer func() {
+			sctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), telemetryShutdownTimeout)
+			defer cancel()
+			_ = p.Shutdown(sctx)
+		}()
+		tp = p
+	}
+	if tp != nil {
+		var span trace.Span
+		ctx, span = tp.Tracer(telemetry.TracerName).Start(ctx, "Import")
+		defer func() { telemetry.EndSpan(span, err) }()
+	}
 
-	// If preflight checks were already done, which unarchives to get the `export.yaml`, we don't need to do it again.
-	if im.fs == nil {
-		// We export the archive to a memory map file system. Assuming the archive is not too big
-		// (a bunch of yaml files, this should be fine).
-		im.fs = &afero.Afero{Fs: afero.NewMemMapFs()}
+	defer im.Close() //nolint:errcheck // Only temporary files are left behind.
+	ctx, cancel := im.withDeadline(ctx)
+	defer cancel()
+	segments, err := im.segments()
+	if err != nil {
+		return err
+	}
+	if len(segments) > 0 {
+		return im.timeoutError(ctx, im.importSegments(ctx, segments))
+	}
+	if im.options.DryRun {
+		return im.timeoutError(ctx, im.dryRun(ctx))
+	}
+	return im.timeoutError(ctx, im.importState(ctx))
+}
 
-		if err := im.unarchive(ctx, *im.fs); err != nil {
-			return errors.Wrap(err, "cannot unarchive export archive")
-		}
+func (im *ControlPlaneStateImporter) importState(ctx context.Context) (err error) {
+	applier, journal, closeLog, err := im.newApplier()
+	if err != nil {
+		return err
+	}
+	defer closeLog()
+	if journal != nil {
+		defer func() {
+			if err != nil {
+				err = im.rollback(ctx, journal, err)
+			}
+		}()
 	}
 
-	//////////////////////////////////////////
+	history, em, err := im.prepare(ctx)
+	if err != nil {
+		return err
+	}
+	skipped, err := im.importResources(ctx, applier)
+	if err != nil {
+		return err
+	}
+	return im.finalize(ctx, applier, history, em, skipped)
+}
 
+// newApplier returns the applier of an import, the journal of the resources
+// it creates if the import is rolled back on failure, and a function closing
+// the structured log, if any.
+func (im *ControlPlaneStateImporter) newApplier() (*UnstructuredResourceApplier, *ApplyJournal, func(), error) {
 	// Pausing resource importer will import all resources.
 	// It will import all Claims, Composites and Managed resource with the `crossplane.io/paused` annotation set to `true`.
-	r := NewPausingResourceImporter(NewFileSystemReader(*im.fs), NewUnstructuredResourceApplier(im.dynamicClient, im.resourceMapper))
+	var aopts []ApplierOption
+	if im.options.AutoDetectFieldManager {
+		aopts = append(aopts, WithAutoDetectFieldManager())
+	}
+	if im.options.AdaptiveRateLimit {
+		aopts = append(aopts, WithRateLimiter(NewAdaptiveRateLimiter()))
+	}
+	closeLog := func() {}
+	if im.options.StructuredLogPath != "" {
+		f, err := os.OpenFile(filepath.Clean(im.options.StructuredLogPath), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+		if err != nil {
+			return nil, nil, nil, errors.Wrap(err, "cannot open structured log")
+		}
+		closeLog = func() {
+			_ = f.Close() // Write errors are ignored, see structuredLog.
+		}
+		aopts = append(aopts, WithStructuredLog(f))
+	}
+	var journal *ApplyJournal
+	if im.options.RollbackOnFailure {
+		journal = NewApplyJournal()
+		aopts = append(aopts, WithJournal(journal))
+	}
+	if im.options.ConflictStrategy != "" {
+		aopts = append(aopts, WithConflictStrategy(im.options.ConflictStrategy))
+	}
+	if im.options.IncrementalImport {
+		aopts = append(aopts, WithIncremental())
+	}
+	webhookConflicts := im.options.WebhookConflictStrategy
+	if webhookConflicts == "" {
+		webhookConflicts = ConflictStrategySkip
+	}
+	for _, gr := range webhookConfigResources {
+		aopts = append(aopts, WithResourceConflictStrategy(schema.ParseGroupResource(gr), webhookConflicts))
+	}
+	return NewUnstructuredResourceApplier(im.dynamicClient, im.resourceMapper, aopts...), journal, closeLog, nil
+}
 
+// prepare reads the exported state and checks whether it can be imported on
+// top of the previous imports. It returns the import history of the target
+// control plane and the export metadata.
+func (im *ControlPlaneStateImporter) prepare(ctx context.Context) (*ImportHistory, *v1alpha1.ExportMeta, error) {
+	// If preflight checks were already done, which unarchives to get the `export.yaml`, we don't need to do it again.
+	if im.fs == nil {
+		uctx, span := telemetry.StartSpan(ctx, "Unarchive")
+		err := im.open(uctx)
+		telemetry.EndSpan(span, err)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if err := im.migrateFormat(); err != nil {
+		return nil, nil, err
+	}
+	em, err := im.exportMeta()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "cannot read export metadata")
+	}
+	xp, err := crossplane.CollectInfo(ctx, im.appsClient)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "cannot get Crossplane info")
+	}
+	history := NewImportHistory(im.dynamicClient, xp.Namespace)
+	if err = history.CheckDifferential(ctx, em); err != nil {
+		return nil, nil, errors.Wrap(err, "cannot import differential export")
+	}
+	return history, em, nil
+}
+
+// importResources applies the exported resources with the supplied applier,
+// Claims, Composites and Managed resources paused. It returns the excluded
+// types that were skipped.
+func (im *ControlPlaneStateImporter) importResources(ctx context.Context, applier ResourceApplier) ([]string, error) { // nolint:gocyclo // This is the high level import command, so it's expected to be a bit complex.
+	r := NewPausingResourceImporter(NewFileSystemReader(*im.fs), applier, im.resourceImporterOptions()...)
+
+	excluded, err := im.excludedResources()
+	if err != nil {
+		return nil, err
+	}
+	var skipped []string
 	// Import base resources which are defined with the `baseResources` variable.
 	// They could be considered as the custom or native resources that do not depend on any packages (e.g. Managed Resources) or XRDs (e.g. Claims/Composites).
 	// They are imported first to make sure that all the resources that depend on them can be imported at a later stage.
 	baseCounts := make(map[string]int, len(baseResources))
 	for _, gr := range baseResources {
+		if isExcludedResource(gr, excluded) {
+			if ok, _ := im.fs.DirExists(gr); ok {
+				skipped = append(skipped, gr)
+			}
+			continue
+		}
 		count, err := r.ImportResources(ctx, gr, false)
 		if err != nil {
-			return errors.Wrapf(err, "cannot import %q resources", gr)
+			return nil, errors.Wrapf(err, "cannot import %q resources", gr)
 		}
 		baseCounts[gr] = count
 	}
@@ -140,9 +496,10 @@ func (im *ControlPlaneStateImporter) Import(ctx context.Context) error { // noli
 	//////////////////////////////////////////
 
 	// Wait for all XRDs and Packages to be ready before importing the resources that depend on them.
+	policy := im.waitPolicy()
 
-	if err := im.waitForConditions(ctx, schema.GroupKind{Group: "apiextensions.crossplane.io", Kind: "CompositeResourceDefinition"}, []xpv1.ConditionType{"Established"}); err != nil {
-		return errors.Wrap(err, "there are unhealthy CompositeResourceDefinitions")
+	if err := waitForConditions(ctx, os.Stdout, im.dynamicClient, im.resourceMapper, schema.GroupKind{Group: "apiextensions.crossplane.io", Kind: "CompositeResourceDefinition"}, []xpv1.ConditionType{"Established"}, policy); err != nil {
+		return nil, errors.Wrap(err, "there are unhealthy CompositeResourceDefinitions")
 	}
 
 	for _, k := range []schema.GroupKind{
@@ -150,8 +507,8 @@ func (im *ControlPlaneStateImporter) Import(ctx context.Context) error { // noli
 		{Group: "pkg.crossplane.io", Kind: "Function"},
 		{Group: "pkg.crossplane.io", Kind: "Configuration"},
 	} {
-		if err := im.waitForConditions(ctx, k, []xpv1.ConditionType{"Installed", "Healthy"}); err != nil {
-			return errors.Wrapf(err, "there are unhealthy %qs", k.Kind)
+		if err := waitForConditions(ctx, os.Stdout, im.dynamicClient, im.resourceMapper, k, []xpv1.ConditionType{"Installed", "Healthy"}, policy); err != nil {
+			return nil, errors.Wrapf(err, "there are unhealthy %qs", k.Kind)
 		}
 	}
 
@@ -164,8 +521,8 @@ func (im *ControlPlaneStateImporter) Import(ctx context.Context) error { // noli
 		{Group: "pkg.crossplane.io", Kind: "FunctionRevision"},
 		{Group: "pkg.crossplane.io", Kind: "ConfigurationRevision"},
 	} {
-		if err := im.waitForConditions(ctx, k, []xpv1.ConditionType{"Healthy"}); err != nil {
-			return errors.Wrapf(err, "there are unhealthy %qs", k.Kind)
+		if err := waitForConditions(ctx, os.Stdout, im.dynamicClient, im.resourceMapper, k, []xpv1.ConditionType{"Healthy"}, policy); err != nil {
+			return nil, errors.Wrapf(err, "there are unhealthy %qs", k.Kind)
 		}
 	}
 
@@ -177,16 +534,16 @@ func (im *ControlPlaneStateImporter) Import(ctx context.Context) error { // noli
 	// Import remaining resources other than the base resources.
 	grs, err := im.fs.ReadDir("/")
 	if err != nil {
-		return errors.Wrap(err, "cannot list group resources")
+		return nil, errors.Wrap(err, "cannot list group resources")
 	}
 	remainingCounts := make(map[string]int, len(grs))
 	for _, info := range grs {
-		if info.Name() == "export.yaml" {
-			// This is the top level export metadata file, so nothing to import.
+		if isMetadataFile(info.Name()) {
+			// These are top level metadata files, so nothing to import.
 			continue
 		}
 		if !info.IsDir() {
-			return errors.Errorf("unexpected file %q in root directory of exported state", info.Name())
+			return nil, errors.Errorf("unexpected file %q in root directory of exported state", info.Name())
 		}
 
 		if isBaseResource(info.Name()) {
@@ -194,9 +551,14 @@ func (im *ControlPlaneStateImporter) Import(ctx context.Context) error { // noli
 			continue
 		}
 
+		if isExcludedResource(info.Name(), excluded) {
+			skipped = append(skipped, info.Name())
+			continue
+		}
+
 		count, err := r.ImportResources(ctx, info.Name(), true)
 		if err != nil {
-			return errors.Wrapf(err, "cannot import %q resources", info.Name())
+			return nil, errors.Wrapf(err, "cannot import %q resources", info.Name())
 		}
 		remainingCounts[info.Name()] = count
 	}
@@ -205,79 +567,312 @@ func (im *ControlPlaneStateImporter) Import(ctx context.Context) error { // noli
 		total += count
 	}
 
-	//////////////////////////////////////////
+	return skipped, nil
+}
 
-	// At this stage, all the resources are imported, but Claims/Composites and Managed resources are paused.
-	// In the finalization step, we will unpause Claims and Composites but not Managed resources (i.e. not activate the control plane yet).
+// finalize unpauses the imported Claims, Composites and observed resources,
+// and Managed resources if requested, once all resources are imported, and
+// records the import in the history.
+func (im *ControlPlaneStateImporter) finalize(ctx context.Context, applier *UnstructuredResourceApplier, history *ImportHistory, em *v1alpha1.ExportMeta, skipped []string) error {
+	// At this stage, all the resources are imported, but Claims/Composites, observed and Managed resources are paused.
+	// In the finalization step, we will unpause Claims, Composites and observed resources but not Managed resources (i.e. not activate the control plane yet).
 	cm := category.NewAPICategoryModifier(im.dynamicClient, im.discoveryClient)
-	_, err = cm.ModifyResources(ctx, "composite", func(u *unstructured.Unstructured) error {
+	uctx, span := telemetry.StartSpan(ctx, "UnpauseComposites")
+	_, err := cm.ModifyResources(uctx, "composite", func(u *unstructured.Unstructured) error {
 		xpmeta.RemoveAnnotations(u, "crossplane.io/paused")
 		return nil
 	})
+	telemetry.EndSpan(span, err)
 	if err != nil {
 		return errors.Wrap(err, "cannot unpause composites")
 	}
 
-	_, err = cm.ModifyResources(ctx, "claim", func(u *unstructured.Unstructured) error {
+	uctx, span = telemetry.StartSpan(ctx, "UnpauseClaims")
+	_, err = cm.ModifyResources(uctx, "claim", func(u *unstructured.Unstructured) error {
 		xpmeta.RemoveAnnotations(u, "crossplane.io/paused")
 		return nil
 	})
+	telemetry.EndSpan(span, err)
 	if err != nil {
 		return errors.Wrap(err, "cannot unpause claims")
 	}
 
+	uctx, span = telemetry.StartSpan(ctx, "UnpauseObserved")
+	_, err = cm.ModifyResources(uctx, category.CategoryObserved, func(u *unstructured.Unstructured) error {
+		xpmeta.RemoveAnnotations(u, "crossplane.io/paused")
+		return nil
+	})
+	telemetry.EndSpan(span, err)
+	if err != nil {
+		return errors.Wrap(err, "cannot unpause observed resources")
+	}
+
 	if im.options.UnpauseAfterImport {
-		_, err = cm.ModifyResources(ctx, "managed", func(u *unstructured.Unstructured) error {
-			xpmeta.RemoveAnnotations(u, "crossplane.io/paused")
-			return nil
-		})
+		uctx, span = telemetry.StartSpan(ctx, "UnpauseManagedResources")
+		if im.options.ActivationBatchSize > 0 {
+			_, err = NewBatchActivator(im.dynamicClient, im.discoveryClient, im.options.ActivationBatchSize, im.options.ActivationBatchInterval).Activate(uctx)
+		} else {
+			_, err = cm.ModifyResources(uctx, "managed", func(u *unstructured.Unstructured) error {
+				xpmeta.RemoveAnnotations(u, "crossplane.io/paused")
+				return nil
+			})
+		}
+		telemetry.EndSpan(span, err)
 		if err != nil {
 			return errors.Wrap(err, "cannot unpause managed resources")
 		}
 	}
 	//////////////////////////////////////////
 
+	// Record the import, so that differential exports can be imported on top of it.
+	if err := history.RecordImportedExport(ctx, em); err != nil {
+		return errors.Wrap(err, "cannot record imported export")
+	}
+
+	if n := applier.Skipped(); n > 0 {
+		pterm.Printfln("\nSkipped %d resources that already existed.", n)
+	}
+	if n := applier.Unchanged(); n > 0 {
+		pterm.Printfln("\nSkipped %d resources that were already up to date.", n)
+	}
+	if len(skipped) > 0 {
+		pterm.Printfln("\nSkipped the excluded types %s.", strings.Join(skipped, ", "))
+	}
 	pterm.Println("\nSuccessfully imported control plane state!")
 	return nil
 }
 
+// rollback deletes the resources created by a failed import and returns the
+// import error together with any errors rolling back.
+func (im *ControlPlaneStateImporter) rollback(ctx context.Context, journal *ApplyJournal, importErr error) error {
+	// The import may have failed because its deadline was exceeded, which
+	// must not prevent the rollback.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), rollbackTimeout)
+	defer cancel()
+
+	pterm.Warning.Printfln("Import failed, deleting the %d resources created by it", len(journal.Entries()))
+	rctx, span := telemetry.StartSpan(ctx, "Rollback")
+	err := journal.Rollback(rctx, im.dynamicClient)
+	telemetry.EndSpan(span, err)
+	if err != nil {
+		return errors.Join(importErr, errors.Wrap(err, "cannot roll back import"))
+	}
+	return importErr
+}
+
+// PreflightChecks checks whether the control plane state can be imported into
+// the target control plane.
 func (im *ControlPlaneStateImporter) PreflightChecks(ctx context.Context) []error {
-	// Read Crossplane information from the target control plane.
+	ctx, cancel := im.withDeadline(ctx)
+	defer cancel()
+	errs := im.preflightChecks(ctx)
+	for i := range errs {
+		errs[i] = im.timeoutError(ctx, errs[i])
+	}
+	return errs
+}
+
+// TargetPreflightChecks checks whether the target control plane can receive
+// the state of a control plane running the supplied Crossplane instance,
+// without reading an export. It allows to fail before the source control
+// plane is exported.
+func (im *ControlPlaneStateImporter) TargetPreflightChecks(ctx context.Context, source *v1alpha1.CrossplaneInfo) []error {
+	ctx, cancel := im.withDeadline(ctx)
+	defer cancel()
 	observed, err := crossplane.CollectInfo(ctx, im.appsClient)
+	if err != nil {
+		return []error{im.timeoutError(ctx, errors.Wrap(err, "Cannot get Crossplane info"))}
+	}
+	return checkCrossplane(source, observed)
+}
+
+// checkCrossplane checks whether the observed Crossplane instance of the
+// target control plane is compatible with the exported one.
+func checkCrossplane(exported, observed *v1alpha1.CrossplaneInfo) []error {
+	errs := crossplane.CheckInstances(exported, observed)
+	for _, ff := range exported.FeatureFlags {
+		if !contains(observed.FeatureFlags, ff) {
+			errs = append(errs, errors.Errorf("Feature flag %q was set in the exported control plane but is not set in the target control plane for import.", ff))
+		}
+	}
+	return errs
+}
+
+// checkProviders checks whether the observed providers of the target control
+// plane have the versions of the exported ones with the same names.
+func checkProviders(exported, observed []v1alpha1.ProviderInfo) []error {
+	versions := make(map[string]string, len(observed))
+	for _, p := range observed {
+		versions[p.Name] = p.Version
+	}
+	var errs []error
+	for _, p := range exported {
+		if v, ok := versions[p.Name]; ok && v != p.Version {
+			errs = append(errs, errors.Errorf("Provider %q has version %q in the target control plane but version %q was exported.", p.Name, v, p.Version))
+		}
+	}
+	return errs
+}
+
+func (im *ControlPlaneStateImporter) preflightChecks(ctx context.Context) []error {
+	// Read Crossplane information from the target control plane.
+	var infoOpts []crossplane.InfoOption
+	if im.options.StrictProviderVersionCheck {
+		infoOpts = append(infoOpts, crossplane.WithProviders(im.dynamicClient))
+	}
+	observed, err := crossplane.CollectInfo(ctx, im.appsClient, infoOpts...)
 	if err != nil {
 		return []error{errors.Wrap(err, "Cannot get Crossplane info")}
 	}
 
 	// If the state archive not already unarchived, do it now, so that we can read the export metadata.
 	if im.fs == nil {
-		im.fs = &afero.Afero{Fs: afero.NewMemMapFs()}
+		if err := im.open(ctx); err != nil {
+			return []error{err}
+		}
+	}
+	if err := im.migrateFormat(); err != nil {
+		return []error{err}
+	}
+	em, err := im.exportMeta()
+	if err != nil {
+		return []error{errors.Wrap(err, "Cannot read export metadata")}
+	}
 
-		if err := im.unarchive(ctx, *im.fs); err != nil {
-			return []error{errors.Wrap(err, "Cannot unarchive export archive")}
+	var errs []error
+
+	if err := NewImportHistory(im.dynamicClient, observed.Namespace).CheckDifferential(ctx, em); err != nil {
+		errs = append(errs, errors.Wrap(err, "Cannot import differential export"))
+	}
+
+	errs = append(errs, checkCrossplane(&em.Crossplane, observed)...)
+	if im.options.StrictProviderVersionCheck {
+		errs = append(errs, checkProviders(em.Crossplane.Providers, observed.Providers)...)
+	}
+
+	for _, w := range em.Warnings {
+		errs = append(errs, errors.Errorf("Export warning: %s", w))
+	}
+
+	grs, err := im.exportedGroupResources()
+	if err != nil {
+		return append(errs, errors.Wrap(err, "Cannot read exported types"))
+	}
+	errs = append(errs, NewImportRBACChecker(im.dynamicClient).Check(ctx, grs)...)
+
+	errs = append(errs, NewCompositionFunctionValidator(NewFileSystemReader(*im.fs), im.dynamicClient, im.resourceMapper).Validate(ctx)...)
+
+	warnings, err := NewOwnerReferenceValidator(NewFileSystemReader(*im.fs), im.dynamicClient, im.resourceMapper).Validate(ctx, grs)
+	if err != nil {
+		errs = append(errs, errors.Wrap(err, "Cannot validate owner references"))
+	}
+	for _, w := range warnings {
+		pterm.Warning.Println(w)
+	}
+
+	// The registry reachability check runs a Job in the target control plane,
+	// which a dry run must not create.
+	if im.options.CheckRegistryReachability && !im.options.DryRun {
+		images, err := packageImages(NewFileSystemReader(*im.fs), "providers.pkg.crossplane.io")
+		if err != nil {
+			return append(errs, errors.Wrap(err, "Cannot read provider packages"))
 		}
+		for i := range images {
+			images[i] = transform.RewriteImage(im.options.ImageRewriteRules, images[i])
+		}
+		// Packages are pulled by the target Crossplane, from its default
+		// registry unless they name one.
+		ropts := []RegistryCheckOption{WithCheckImage(transform.RewriteImage(im.options.ImageRewriteRules, im.registryCheckImage()))}
+		if observed.Registry != "" {
+			ropts = append(ropts, WithDefaultRegistry(observed.Registry))
+		}
+		errs = append(errs, NewRegistryReachabilityCheck(NewDynamicJobRunner(im.dynamicClient), observed.Namespace, ropts...).Check(ctx, images)...)
+	}
+
+	return errs
+}
+
+// registryCheckImage returns the image of the registry reachability check.
+func (im *ControlPlaneStateImporter) registryCheckImage() string {
+	if im.options.RegistryCheckImage != "" {
+		return im.options.RegistryCheckImage
 	}
+	return DefaultRegistryCheckImage
+}
+
+// exportMeta reads the metadata of the unarchived export.
+func (im *ControlPlaneStateImporter) exportMeta() (*v1alpha1.ExportMeta, error) {
 	b, err := im.fs.ReadFile("export.yaml")
 	if err != nil {
-		return []error{errors.Wrap(err, "Cannot read export metadata")}
+		return nil, errors.Wrap(err, "cannot read export.yaml")
 	}
 	em := &v1alpha1.ExportMeta{}
 	if err = yaml.Unmarshal(b, em); err != nil {
-		return []error{errors.Wrap(err, "Cannot unmarshal export metadata")}
+		return nil, errors.Wrap(err, "cannot unmarshal export.yaml")
 	}
+	return em, nil
+}
 
-	var errs []error
-
-	if observed.Version != em.Crossplane.Version {
-		errs = append(errs, errors.Errorf("Crossplane version %q does not match exported version %q", observed.Version, em.Crossplane.Version))
+// migrateFormat migrates the unarchived export to the current format version,
+// if it is older. The export itself is left unchanged, as the migrated files
+// are only written to memory.
+func (im *ControlPlaneStateImporter) migrateFormat() error {
+	em, err := im.exportMeta()
+	if err != nil {
+		return errors.Wrap(err, "cannot read export metadata")
+	}
+	from := migrationmeta.FormatVersion(em)
+	if err := migrationmeta.CheckFormatVersion(from); err != nil {
+		return err
+	}
+	if from == migrationmeta.CurrentFormatVersion {
+		return nil
+	}
+	fs := &afero.Afero{Fs: afero.NewCopyOnWriteFs(im.fs.Fs, afero.NewMemMapFs())}
+	if err := migrationmeta.MigrateFormat(from, migrationmeta.CurrentFormatVersion, fs.Fs); err != nil {
+		return err
 	}
+	im.fs = fs
+	return nil
+}
 
-	for _, ff := range em.Crossplane.FeatureFlags {
-		if !contains(observed.FeatureFlags, ff) {
-			errs = append(errs, errors.Errorf("Feature flag %q was set in the exported control plane but is not set in the target control plane for import.", ff))
+// exportedGroupResources returns the group resources of all exported types
+// that are not excluded from the import.
+func (im *ControlPlaneStateImporter) exportedGroupResources() ([]schema.GroupResource, error) {
+	excluded, err := im.excludedResources()
+	if err != nil {
+		return nil, err
+	}
+	infos, err := im.fs.ReadDir("/")
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot list group resources")
+	}
+	grs := make([]schema.GroupResource, 0, len(infos))
+	for _, info := range infos {
+		if !info.IsDir() || isMetadataFile(info.Name()) || isExcludedResource(info.Name(), excluded) {
+			continue
 		}
+		grs = append(grs, schema.ParseGroupResource(info.Name()))
 	}
+	return grs, nil
+}
 
-	return errs
+// packageImages returns the package images of all exported resources of the
+// given package group resource.
+func packageImages(r ResourceReader, gr string) ([]string, error) {
+	pkgs, _, err := r.ReadResources(gr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot read %q resources", gr)
+	}
+	images := make([]string, 0, len(pkgs))
+	for _, p := range pkgs {
+		img, err := fieldpath.Pave(p.Object).GetString("spec.package")
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot get package image of %q", p.GetName())
+		}
+		images = append(images, img)
+	}
+	return images, nil
 }
 
 func contains(ss []string, s string) bool {
@@ -289,49 +884,156 @@ func contains(ss []string, s string) bool {
 	return false
 }
 
-func (im *ControlPlaneStateImporter) unarchive(ctx context.Context, fs afero.Afero) error {
-	g, err := os.Open(im.options.InputArchive)
-	if err != nil {
-		return errors.Wrap(err, "cannot open input archive")
+// withDeadline returns a context that expires at the global deadline, if a
+// timeout is configured.
+func (im *ControlPlaneStateImporter) withDeadline(ctx context.Context) (context.Context, context.CancelFunc) {
+	if im.options.Timeout <= 0 {
+		return context.WithCancel(ctx)
 	}
-	defer g.Close()
+	if im.deadline.IsZero() {
+		im.deadline = time.Now().Add(im.options.Timeout)
+	}
+	return context.WithDeadline(ctx, im.deadline)
+}
 
-	gr, err := gzip.NewReader(g)
-	if err != nil {
-		return errors.Wrap(err, "cannot create gzip reader")
+// timeoutError wraps err to make clear the global timeout was exceeded, if
+// that's why ctx is done.
+func (im *ControlPlaneStateImporter) timeoutError(ctx context.Context, err error) error {
+	if err == nil || im.deadline.IsZero() || !errors.Is(ctx.Err(), context.DeadlineExceeded) || time.Now().Before(im.deadline) {
+		return err
 	}
-	defer gr.Close()
+	return migration.WithCode(errors.Wrap(err, errGlobalTimeout), migration.ErrCodeTimeout)
+}
 
-	tr := tar.NewReader(gr)
+// open makes the exported state available in im.fs, either by unarchiving the
+// input archive or by reading the input directory. For exports segmented by
+// namespace, the cluster scoped segment is opened, which preflight checks
+// are run against.
+func (im *ControlPlaneStateImporter) open(ctx context.Context) error {
+	if im.options.InputOCIRef != "" {
+		return im.openArchive(ctx)
+	}
 
-	for {
-		hdr, err := tr.Next()
-		if err == io.EOF {
-			break // End of archive
-		}
-		if err != nil {
-			return errors.Wrap(err, "cannot read archive")
+	segments, err := im.segments()
+	if err != nil {
+		return err
+	}
+	if len(segments) > 0 {
+		sub := im.forSegment(segments[0])
+		if err := sub.open(ctx); err != nil {
+			return errors.Wrapf(err, "cannot open segment %q", segments[0].Archive)
 		}
+		im.fs, im.spill = sub.fs, sub.spill
+		return nil
+	}
 
-		if hdr.FileInfo().IsDir() {
-			if err = fs.Mkdir(hdr.Name, 0700); err != nil {
-				return errors.Wrapf(err, "cannot create directory %q", hdr.Name)
-			}
-			continue
+	if im.options.InputFormat == InputFormatDirectory {
+		in := im.inputFS()
+		if ok, err := in.DirExists(im.options.InputArchive); err != nil || !ok {
+			return errors.Errorf("input directory %q does not exist", im.options.InputArchive)
 		}
+		im.fs = &afero.Afero{Fs: afero.NewReadOnlyFs(afero.NewBasePathFs(in.Fs, im.options.InputArchive))}
+		return nil
+	}
 
-		nf, err := fs.OpenFile(hdr.Name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	return im.openArchive(ctx)
+}
+
+// openArchive unarchives the input archive to memory, spilling files to disk
+// if they exceed the memory limit.
+func (im *ControlPlaneStateImporter) openArchive(ctx context.Context) error {
+	mb := im.options.MaxMemoryMB
+	if mb <= 0 {
+		mb = defaultMaxMemoryMB
+	}
+	spill := newSpillFs(int64(mb)<<20, maxInMemoryFileSize)
+	if err := im.unarchive(ctx, afero.Afero{Fs: spill}); err != nil {
+		_ = spill.Close()
+		return errors.Wrap(err, "cannot unarchive export archive")
+	}
+	im.fs, im.spill = &afero.Afero{Fs: spill}, spill
+	return nil
+}
+
+// Close removes the temporary files of the unarchived export. Import, Verify,
+// and Inspect close the importer when they are done, so it only needs to be
+// closed if none of them was called after PreflightChecks.
+func (im *ControlPlaneStateImporter) Close() error {
+	if im.spill == nil {
+		return nil
+	}
+	return im.spill.Close()
+}
+
+// inputFS returns the file system the input is read from.
+func (im *ControlPlaneStateImporter) inputFS() afero.Afero {
+	if im.options.InputFS != nil {
+		return afero.Afero{Fs: im.options.InputFS}
+	}
+	return afero.Afero{Fs: afero.NewOsFs()}
+}
+
+func (im *ControlPlaneStateImporter) unarchive(ctx context.Context, fs afero.Afero) error {
+	var opts []archiver.UnarchiveOption
+	if im.options.EncryptionKey != "" {
+		key, err := archiver.ParseEncryptionKey(im.options.EncryptionKey)
 		if err != nil {
-			return errors.Wrapf(err, "cannot create file %q", hdr.Name)
+			return err
 		}
-		defer nf.Close()
-
-		if _, err := io.Copy(nf, tr); err != nil {
-			return errors.Wrapf(err, "cannot write file %q", hdr.Name)
+		opts = append(opts, archiver.WithDecryptionKey(key))
+	}
+	if im.options.InputOCIRef != "" {
+		rc, err := archiver.PullOCI(ctx, im.options.InputOCIRef)
+		if err != nil {
+			return err
 		}
+		defer rc.Close() //nolint:errcheck // Read only.
+		return archiver.Unarchive(ctx, rc, fs, opts...)
+	}
+	if im.options.InputArchive != "-" {
+		return archiver.UnarchiveFile(ctx, im.options.InputArchive, fs, opts...)
 	}
+	r := im.options.InputReader
+	if r == nil {
+		r = os.Stdin
+	}
+	return archiver.Unarchive(ctx, r, fs, opts...)
+}
 
-	return nil
+// resourceImporterOptions returns the options of the PausingResourceImporter.
+func (im *ControlPlaneStateImporter) resourceImporterOptions() []PausingResourceImporterOption {
+	var opts []PausingResourceImporterOption
+	if len(im.options.EndpointRewrites) > 0 {
+		opts = append(opts, WithResourceTransformers(transform.NewProviderConfigRewriter(im.options.EndpointRewrites)))
+	}
+	if len(im.options.ImageRewriteRules) > 0 {
+		opts = append(opts, WithResourceTransformers(transform.NewImageRewriter(im.options.ImageRewriteRules)))
+	}
+	if len(im.options.APIVersionConversions) > 0 {
+		opts = append(opts, WithResourceTransformers(transform.NewAPIVersionConverter(im.options.APIVersionConversions)))
+	}
+	if im.options.AmbiguousGVRResolution != nil {
+		opts = append(opts, WithGVRResolver(NewGVRResolver(im.resourceMapper, im.options.AmbiguousGVRResolution)))
+	}
+	if im.options.PreserveUIDs {
+		opts = append(opts, WithPreservedUIDs())
+	}
+	if im.options.PreserveResourceVersion {
+		opts = append(opts, WithPreservedResourceVersions())
+	}
+	if len(im.options.NamespaceMapping) > 0 {
+		opts = append(opts, WithNamespaceMapping(im.options.NamespaceMapping))
+	}
+	if im.options.SkipRedactedSecrets {
+		opts = append(opts, WithSkippedRedactedSecrets())
+	}
+	return opts
+}
+
+// isMetadataFile returns true if name is a top level metadata file of an
+// export.
+func isMetadataFile(name string) bool {
+	return name == "export.yaml" || name == "audit-history.yaml" || name == "dependency-graph.json" || name == archiver.ChecksumsFile
 }
 
 func isBaseResource(gr string) bool {
@@ -343,51 +1045,116 @@ func isBaseResource(gr string) bool {
 	return false
 }
 
-func (im *ControlPlaneStateImporter) waitForConditions(ctx context.Context, gk schema.GroupKind, conditions []xpv1.ConditionType) error {
-	rm, err := im.resourceMapper.RESTMapping(gk)
+// waitPolicy configures how long and how often to poll while waiting for
+// conditions.
+type waitPolicy struct {
+	// timeout is how long to wait in total.
+	timeout time.Duration
+	// pollInterval is the interval between the first polls.
+	pollInterval time.Duration
+	// maxPollInterval caps the poll interval, which is doubled after every
+	// poll up to it. The interval is constant if it is not larger than
+	// pollInterval.
+	maxPollInterval time.Duration
+}
+
+// next returns the poll interval following the supplied one.
+func (p waitPolicy) next(interval time.Duration) time.Duration {
+	if p.maxPollInterval <= interval {
+		return interval
+	}
+	return min(2*interval, p.maxPollInterval)
+}
+
+// waitPolicy returns the wait policy configured by the options.
+func (im *ControlPlaneStateImporter) waitPolicy() waitPolicy {
+	p := waitPolicy{
+		timeout:         im.options.WaitTimeout,
+		pollInterval:    im.options.WaitPollInterval,
+		maxPollInterval: im.options.WaitMaxPollInterval,
+	}
+	if p.timeout <= 0 {
+		p.timeout = defaultWaitTimeout
+	}
+	if p.pollInterval <= 0 {
+		p.pollInterval = defaultWaitPollInterval
+	}
+	return p
+}
+
+// waitForConditions waits until all resources of the supplied kind satisfy
+// all conditions, polling according to the wait policy. While waiting, a
+// spinner listing the resources that do not satisfy them yet is written to w.
+func waitForConditions(ctx context.Context, w io.Writer, dyn dynamic.Interface, mapper meta.RESTMapper, gk schema.GroupKind, conditions []xpv1.ConditionType, p waitPolicy) (err error) {
+	ctx, span := telemetry.StartSpan(ctx, "WaitForConditions", telemetry.GroupKindKey.String(gk.String()))
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	rm, err := mapper.RESTMapping(gk)
 	if err != nil {
 		return errors.Wrapf(err, "cannot get REST mapping for %q", gk)
 	}
 
-	success := false
-	timeout := 10 * time.Minute
-	ctx, cancel := context.WithTimeout(ctx, timeout)
-	wait.UntilWithContext(ctx, func(ctx context.Context) {
-		resourceList, err := im.dynamicClient.Resource(rm.Resource).List(ctx, v1.ListOptions{})
-		if err != nil {
-			pterm.Printf("cannot list packages with error: %v\n", err)
-			return
-		}
-		// total := len(resourceList.Items)
-		unmet := 0
-		for _, r := range resourceList.Items {
-			paved := fieldpath.Pave(r.Object)
-			status := xpv1.ConditionedStatus{}
-			if err = paved.GetValueInto("status", &status); err != nil && !fieldpath.IsNotFound(err) {
-				pterm.Printf("cannot get status for %q %q with error: %v\n", gk.Kind, r.GetName(), err)
-				return
-			}
-
-			for _, c := range conditions {
-				if status.GetCondition(c).Status != corev1.ConditionTrue {
-					unmet++
-					break // At least one condition is not met, so we should break and not count the same resource multiple times.
-				}
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	// The spinner is only started once there is something to wait for.
+	var spinner *pterm.SpinnerPrinter
+	defer func() {
+		if spinner != nil {
+			_ = spinner.Stop()
+		}
+	}()
+	interval := p.pollInterval
+	for {
+		var text string
+		unready, _, err := listUnready(ctx, dyn, rm.Resource, conditions)
+		switch {
+		case err != nil:
+			text = fmt.Sprintf("Cannot check conditions of %q: %v", gk.Kind, err)
+		case len(unready) == 0:
+			if spinner != nil {
+				spinner.Success(fmt.Sprintf("All %ss are %s", gk.Kind, printConditions(conditions)))
+				spinner = nil
 			}
+			return nil
+		default:
+			text = fmt.Sprintf("Waiting for %s: %s (%s)", gk.Kind, summarizeNames(unready, maxListedNames), strings.Join(conditionNames(conditions), ", "))
 		}
-		if unmet > 0 {
-			return
+		if spinner == nil {
+			spinner, _ = pterm.DefaultSpinner.WithWriter(w).Start(text)
+		} else {
+			spinner.UpdateText(text)
 		}
 
-		success = true
-		cancel()
-	}, 5*time.Second)
+		t := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			spinner.Fail(text)
+			spinner = nil
+			return errors.Errorf("timeout waiting for conditions %q to be satisfied for all %q", printConditions(conditions), gk.Kind)
+		case <-t.C:
+		}
+		interval = p.next(interval)
+	}
+}
+
+// maxListedNames is the maximum number of resources listed while waiting.
+const maxListedNames = 5
 
-	if !success {
-		return errors.Errorf("timeout waiting for conditions %q to be satisfied for all %q", printConditions(conditions), gk.Kind)
+// summarizeNames joins the first n names, followed by how many were left out.
+func summarizeNames(names []string, n int) string {
+	if len(names) <= n {
+		return strings.Join(names, ", ")
 	}
+	return fmt.Sprintf("%s and %d more", strings.Join(names[:n], ", "), len(names)-n)
+}
 
-	return nil
+func conditionNames(conditions []xpv1.ConditionType) []string {
+	cs := make([]string, len(conditions))
+	for i, c := range conditions {
+		cs[i] = string(c)
+	}
+	return cs
 }
 
 func printConditions(conditions []xpv1.ConditionType) string {
@@ -399,10 +1166,7 @@ func printConditions(conditions []xpv1.ConditionType) string {
 	case 2:
 		return fmt.Sprintf("%s and %s", conditions[0], conditions[1])
 	default:
-		cs := make([]string, len(conditions))
-		for i, c := range conditions {
-			cs[i] = string(c)
-		}
+		cs := conditionNames(conditions)
 		return fmt.Sprintf("%s, and %s", strings.Join(cs[:len(cs)-1], ", "), cs[len(cs)-1])
 	}
 }
@@ -15,11 +15,44 @@
 package importer
 
 import (
+	"bytes"
+	"context"
+	"io"
+	"log"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/pterm/pterm"
+	"github.com/spf13/afero"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apiextensionsfake "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/fake"
+	"k8s.io/apimachinery/pkg/api/meta"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/restmapper"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/upbound/up/pkg/migration/archiver"
+	"github.com/upbound/up/pkg/migration/exporter"
+	migrationmeta "github.com/upbound/up/pkg/migration/meta"
+	"github.com/upbound/up/pkg/migration/meta/v1alpha1"
 
 	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
 )
 
 func Test_printConditions(t *testing.T) {
@@ -85,3 +118,853 @@ func Test_printConditions(t *testing.T) {
 		})
 	}
 }
+
+func TestControlPlaneStateImporterTimeout(t *testing.T) {
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "xp-state.tar.gz")
+	fs := afero.Afero{Fs: afero.NewOsFs()}
+	if err := fs.MkdirAll(filepath.Join(dir, "state"), 0700); err != nil {
+		t.Fatalf("cannot create state directory: %v", err)
+	}
+	if err := fs.WriteFile(filepath.Join(dir, "state", "export.yaml"), []byte("version: v1alpha1\n"), 0600); err != nil {
+		t.Fatalf("cannot write export metadata: %v", err)
+	}
+	if err := archiver.ArchiveFile(context.Background(), fs, filepath.Join(dir, "state"), archive); err != nil {
+		t.Fatalf("cannot write archive: %v", err)
+	}
+
+	newImporter := func() *ControlPlaneStateImporter {
+		kube := kubefake.NewSimpleClientset()
+		return NewControlPlaneStateImporter(
+			dynamicfake.NewSimpleDynamicClient(runtime.NewScheme()),
+			kube.Discovery(),
+			kube.AppsV1(),
+			restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(kube.Discovery())),
+			Options{
+				InputArchive: archive,
+				Timeout:      time.Nanosecond,
+			})
+	}
+	check := func(t *testing.T, err error) {
+		t.Helper()
+		if err == nil || !strings.HasPrefix(err.Error(), errGlobalTimeout) {
+			t.Errorf("error = %v, want %q", err, errGlobalTimeout)
+		}
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("error = %v, want wrapped %v", err, context.DeadlineExceeded)
+		}
+	}
+
+	t.Run("PreflightChecks", func(t *testing.T) {
+		errs := newImporter().PreflightChecks(context.Background())
+		if len(errs) != 1 {
+			t.Fatalf("PreflightChecks() = %v, want a single error", errs)
+		}
+		check(t, errs[0])
+	})
+	t.Run("Import", func(t *testing.T) {
+		check(t, newImporter().Import(context.Background()))
+	})
+}
+
+func TestOptionsValidate(t *testing.T) {
+	cases := map[string]struct {
+		reason string
+		opts   Options
+		err    bool
+	}{
+		"PreserveUIDsWithDefaultStrategy": {
+			reason: "Preserving UIDs should be rejected with the default overwrite conflict strategy.",
+			opts:   Options{PreserveUIDs: true},
+			err:    true,
+		},
+		"PreserveUIDsWithOverwrite": {
+			reason: "Preserving UIDs should be rejected with the overwrite conflict strategy.",
+			opts:   Options{PreserveUIDs: true, ConflictStrategy: ConflictStrategyOverwrite},
+			err:    true,
+		},
+		"PreserveUIDsWithSkip": {
+			reason: "Preserving UIDs should be allowed with the skip conflict strategy.",
+			opts:   Options{PreserveUIDs: true, ConflictStrategy: ConflictStrategySkip},
+		},
+		"Overwrite": {
+			reason: "Overwriting should be allowed without preserving UIDs.",
+			opts:   Options{ConflictStrategy: ConflictStrategyOverwrite},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := tc.opts.validate()
+			if (err != nil) != tc.err {
+				t.Errorf("\n%s\nvalidate(...): error = %v, want error %t", tc.reason, err, tc.err)
+			}
+		})
+	}
+}
+
+// resettableMapper is a static RESTMapper with a no-op Reset.
+type resettableMapper struct {
+	*meta.DefaultRESTMapper
+}
+
+func (resettableMapper) Reset() {}
+
+func TestControlPlaneStateImporterTracing(t *testing.T) {
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "xp-state.tar.gz")
+	fs := afero.Afero{Fs: afero.NewOsFs()}
+	if err := fs.MkdirAll(filepath.Join(dir, "state"), 0700); err != nil {
+		t.Fatalf("cannot create state directory: %v", err)
+	}
+	if err := fs.WriteFile(filepath.Join(dir, "state", "export.yaml"), []byte("version: v1alpha1\n"), 0600); err != nil {
+		t.Fatalf("cannot write export metadata: %v", err)
+	}
+	if err := archiver.ArchiveFile(context.Background(), fs, filepath.Join(dir, "state"), archive); err != nil {
+		t.Fatalf("cannot write archive: %v", err)
+	}
+
+	xrd := schema.GroupVersion{Group: "apiextensions.crossplane.io", Version: "v1"}
+	pkg := schema.GroupVersion{Group: "pkg.crossplane.io", Version: "v1"}
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{xrd, pkg})
+	listKinds := map[schema.GroupVersionResource]string{}
+	for _, gvk := range []schema.GroupVersionKind{
+		xrd.WithKind("CompositeResourceDefinition"),
+		pkg.WithKind("Provider"),
+		pkg.WithKind("Function"),
+		pkg.WithKind("Configuration"),
+		pkg.WithKind("ProviderRevision"),
+		pkg.WithKind("FunctionRevision"),
+		pkg.WithKind("ConfigurationRevision"),
+	} {
+		mapper.Add(gvk, meta.RESTScopeRoot)
+		rm, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			t.Fatalf("cannot get REST mapping for %q: %v", gvk, err)
+		}
+		listKinds[rm.Resource] = gvk.Kind + "List"
+	}
+	waits := len(listKinds)
+	// Observed resources are found by listing CRDs.
+	listKinds[apiextensionsv1.SchemeGroupVersion.WithResource("customresourcedefinitions")] = "CustomResourceDefinitionList"
+
+	kube := kubefake.NewSimpleClientset()
+	spans := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(spans))
+
+	im := NewControlPlaneStateImporter(
+		dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds),
+		kube.Discovery(),
+		kube.AppsV1(),
+		resettableMapper{DefaultRESTMapper: mapper},
+		Options{
+			InputArchive:   archive,
+			TracerProvider: tp,
+		})
+	if err := im.Import(context.Background()); err != nil {
+		t.Fatalf("Import() unexpected error: %v", err)
+	}
+
+	got := spans.GetSpans()
+	counts := map[string]int{}
+	for _, s := range got {
+		counts[s.Name]++
+	}
+	want := map[string]int{
+		"Import":            1,
+		"Unarchive":         1,
+		"ApplyResources":    len(baseResources),
+		"WaitForConditions": waits,
+		"UnpauseComposites": 1,
+		"UnpauseClaims":     1,
+		"UnpauseObserved":   1,
+	}
+	if diff := cmp.Diff(want, counts); diff != "" {
+		t.Errorf("spans mismatch (-want +got):\n%s", diff)
+	}
+
+	root := got[len(got)-1]
+	if root.Name != "Import" {
+		t.Fatalf("last ended span is %q, want %q", root.Name, "Import")
+	}
+	for _, s := range got[:len(got)-1] {
+		if s.Parent.SpanID() != root.SpanContext.SpanID() {
+			t.Errorf("span %q is not a child of the %q span", s.Name, "Import")
+		}
+	}
+}
+
+func Test_isBaseResource(t *testing.T) {
+	cases := map[string]struct {
+		gr   string
+		want bool
+	}{
+		"Secrets": {
+			gr:   "secrets",
+			want: true,
+		},
+		"Compositions": {
+			gr:   "compositions.apiextensions.crossplane.io",
+			want: true,
+		},
+		"EnvironmentConfigs": {
+			gr:   "environmentconfigs.apiextensions.crossplane.io",
+			want: true,
+		},
+		"ImageConfigs": {
+			gr:   "imageconfigs.pkg.crossplane.io",
+			want: true,
+		},
+		"ManagedResources": {
+			gr:   "buckets.s3.aws.upbound.io",
+			want: false,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if got := isBaseResource(tc.gr); got != tc.want {
+				t.Errorf("isBaseResource(%q) = %t, want %t", tc.gr, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestControlPlaneStateImporterStream(t *testing.T) {
+	state := afero.Afero{Fs: afero.NewMemMapFs()}
+	if err := state.WriteFile("export.yaml", []byte("version: v1alpha1\n"), 0600); err != nil {
+		t.Fatalf("cannot write export metadata: %v", err)
+	}
+	in := &bytes.Buffer{}
+	if err := archiver.Archive(context.Background(), state, ".", in); err != nil {
+		t.Fatalf("cannot write archive: %v", err)
+	}
+
+	kube := kubefake.NewSimpleClientset()
+	im := NewControlPlaneStateImporter(
+		dynamicfake.NewSimpleDynamicClient(runtime.NewScheme()),
+		kube.Discovery(),
+		kube.AppsV1(),
+		restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(kube.Discovery())),
+		Options{
+			InputArchive: "-",
+			InputReader:  in,
+		})
+	if errs := im.PreflightChecks(context.Background()); len(errs) > 0 {
+		t.Fatalf("PreflightChecks() unexpected errors: %v", errs)
+	}
+	if ok, _ := im.fs.Exists("export.yaml"); !ok {
+		t.Errorf("streamed archive was not unarchived")
+	}
+}
+
+func TestBaseResourcesImageConfigsBeforePackages(t *testing.T) {
+	index := func(gr string) int {
+		for i, r := range baseResources {
+			if r == gr {
+				return i
+			}
+		}
+		t.Fatalf("%q is not a base resource", gr)
+		return -1
+	}
+	for _, pkg := range []string{"providers.pkg.crossplane.io", "functions.pkg.crossplane.io", "configurations.pkg.crossplane.io"} {
+		if index("imageconfigs.pkg.crossplane.io") > index(pkg) {
+			t.Errorf("imageconfigs.pkg.crossplane.io are imported after %s", pkg)
+		}
+	}
+}
+
+func TestControlPlaneStateImporterFormatVersion(t *testing.T) {
+	cases := map[string]struct {
+		exportMeta string
+		wantErr    bool
+	}{
+		"Legacy": {
+			exportMeta: "version: v1alpha1\ncrossplane:\n  distribution: uxp\n",
+		},
+		"Current": {
+			exportMeta: "version: v1alpha1\nformatVersion: " + migrationmeta.CurrentFormatVersion + "\n",
+		},
+		"Newer": {
+			exportMeta: "version: v1alpha1\nformatVersion: v9.0.0\n",
+			wantErr:    true,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			state := afero.Afero{Fs: afero.NewMemMapFs()}
+			if err := state.WriteFile("export.yaml", []byte(tc.exportMeta), 0600); err != nil {
+				t.Fatalf("cannot write export metadata: %v", err)
+			}
+			im := &ControlPlaneStateImporter{fs: &state}
+			err := im.migrateFormat()
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("\n%s\nmigrateFormat(): want error %t, got %v", name, tc.wantErr, err)
+			}
+			if tc.wantErr {
+				return
+			}
+			em, err := im.exportMeta()
+			if err != nil {
+				t.Fatalf("\n%s\nexportMeta(): %v", name, err)
+			}
+			if diff := cmp.Diff(migrationmeta.CurrentFormatVersion, em.FormatVersion); diff != "" {
+				t.Errorf("\n%s\nmigrateFormat(): -want format version, +got:\n%s", name, diff)
+			}
+			// The unarchived export itself is not changed.
+			if b, _ := state.ReadFile("export.yaml"); string(b) != tc.exportMeta {
+				t.Errorf("\n%s\nmigrateFormat(): changed the export", name)
+			}
+		})
+	}
+}
+
+// roundTripMapper returns a REST mapper for namespaces, config maps and the
+// types the importer waits for, and the list kinds of the latter.
+func roundTripMapper(t *testing.T) (*meta.DefaultRESTMapper, map[schema.GroupVersionResource]string) {
+	t.Helper()
+	core := schema.GroupVersion{Version: "v1"}
+	xrd := schema.GroupVersion{Group: "apiextensions.crossplane.io", Version: "v1"}
+	pkg := schema.GroupVersion{Group: "pkg.crossplane.io", Version: "v1"}
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{core, xrd, pkg})
+	mapper.Add(core.WithKind("Namespace"), meta.RESTScopeRoot)
+	mapper.Add(core.WithKind("ConfigMap"), meta.RESTScopeNamespace)
+	listKinds := map[schema.GroupVersionResource]string{}
+	for _, c := range HealthChecks {
+		gvk := c.GroupKind.WithVersion("v1")
+		mapper.Add(gvk, meta.RESTScopeRoot)
+		rm, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			t.Fatalf("cannot get REST mapping for %q: %v", gvk, err)
+		}
+		listKinds[rm.Resource] = gvk.Kind + "List"
+	}
+	// Observed resources are found by listing CRDs.
+	listKinds[apiextensionsv1.SchemeGroupVersion.WithResource("customresourcedefinitions")] = "CustomResourceDefinitionList"
+	return mapper, listKinds
+}
+
+func namespaceAndConfigMap(namespace string) []runtime.Object {
+	ns := &unstructured.Unstructured{}
+	ns.SetAPIVersion("v1")
+	ns.SetKind("Namespace")
+	ns.SetName(namespace)
+	cm := &unstructured.Unstructured{}
+	cm.SetAPIVersion("v1")
+	cm.SetKind("ConfigMap")
+	cm.SetNamespace(namespace)
+	cm.SetName("config")
+	return []runtime.Object{ns, cm}
+}
+
+func TestControlPlaneStateDirectoryRoundTrip(t *testing.T) {
+	mapper, listKinds := roundTripMapper(t)
+
+	dir := filepath.Join(t.TempDir(), "state")
+	kube := kubefake.NewSimpleClientset()
+	e := exporter.NewControlPlaneStateExporter(
+		apiextensionsfake.NewSimpleClientset(),
+		dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), namespaceAndConfigMap("default")...),
+		kube.Discovery(),
+		kube.AppsV1(),
+		mapper,
+		exporter.Options{
+			OutputArchive:         dir,
+			OutputFormat:          exporter.OutputFormatDirectory,
+			IncludeExtraResources: []string{"namespaces", "configmaps"},
+		})
+	if err := e.Export(context.Background()); err != nil {
+		t.Fatalf("Export() unexpected error: %v", err)
+	}
+
+	dyn := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds)
+	reviewAccess(dyn, nil)
+	target := &applyRecorder{Interface: dyn}
+	im := NewControlPlaneStateImporter(
+		target,
+		kube.Discovery(),
+		kube.AppsV1(),
+		resettableMapper{DefaultRESTMapper: mapper},
+		Options{
+			InputArchive: dir,
+			InputFormat:  InputFormatDirectory,
+		})
+	if errs := im.PreflightChecks(context.Background()); len(errs) > 0 {
+		t.Fatalf("PreflightChecks() unexpected errors: %v", errs)
+	}
+	if err := im.Import(context.Background()); err != nil {
+		t.Fatalf("Import() unexpected error: %v", err)
+	}
+
+	want := []string{"Namespace/default", "ConfigMap/config"}
+	if diff := cmp.Diff(want, target.applied); diff != "" {
+		t.Errorf("applied resources mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestControlPlaneStateMemoryRoundTrip(t *testing.T) {
+	mapper, listKinds := roundTripMapper(t)
+
+	fs := afero.NewMemMapFs()
+	kube := kubefake.NewSimpleClientset()
+	e := exporter.NewControlPlaneStateExporter(
+		apiextensionsfake.NewSimpleClientset(),
+		dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), namespaceAndConfigMap("default")...),
+		kube.Discovery(),
+		kube.AppsV1(),
+		mapper,
+		exporter.Options{
+			OutputArchive:         "/state",
+			OutputFormat:          exporter.OutputFormatDirectory,
+			OutputFS:              fs,
+			IncludeExtraResources: []string{"namespaces", "configmaps"},
+		})
+	if err := e.Export(context.Background()); err != nil {
+		t.Fatalf("Export() unexpected error: %v", err)
+	}
+	if ok, _ := afero.Exists(afero.NewOsFs(), "/state"); ok {
+		t.Fatal("Export() wrote to the local file system")
+	}
+
+	dyn := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds)
+	reviewAccess(dyn, nil)
+	target := &applyRecorder{Interface: dyn}
+	im := NewControlPlaneStateImporter(
+		target,
+		kube.Discovery(),
+		kube.AppsV1(),
+		resettableMapper{DefaultRESTMapper: mapper},
+		Options{
+			InputArchive: "/state",
+			InputFormat:  InputFormatDirectory,
+			InputFS:      fs,
+		})
+	if errs := im.TargetPreflightChecks(context.Background(), &v1alpha1.CrossplaneInfo{}); len(errs) > 0 {
+		t.Fatalf("TargetPreflightChecks() unexpected errors: %v", errs)
+	}
+	if errs := im.PreflightChecks(context.Background()); len(errs) > 0 {
+		t.Fatalf("PreflightChecks() unexpected errors: %v", errs)
+	}
+	if err := im.Import(context.Background()); err != nil {
+		t.Fatalf("Import() unexpected error: %v", err)
+	}
+
+	want := []string{"Namespace/default", "ConfigMap/config"}
+	if diff := cmp.Diff(want, target.applied); diff != "" {
+		t.Errorf("applied resources mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestControlPlaneStateOCIRoundTrip(t *testing.T) {
+	mapper, listKinds := roundTripMapper(t)
+
+	srv := httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+	defer srv.Close()
+	ref := strings.TrimPrefix(srv.URL, "http://") + "/exports/prod:v1"
+
+	kube := kubefake.NewSimpleClientset()
+	e := exporter.NewControlPlaneStateExporter(
+		apiextensionsfake.NewSimpleClientset(),
+		dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), namespaceAndConfigMap("default")...),
+		kube.Discovery(),
+		kube.AppsV1(),
+		mapper,
+		exporter.Options{
+			OutputOCIRef:          ref,
+			IncludeExtraResources: []string{"namespaces", "configmaps"},
+		})
+	if err := e.Export(context.Background()); err != nil {
+		t.Fatalf("Export() unexpected error: %v", err)
+	}
+
+	dyn := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds)
+	reviewAccess(dyn, nil)
+	target := &applyRecorder{Interface: dyn}
+	im := NewControlPlaneStateImporter(
+		target,
+		kube.Discovery(),
+		kube.AppsV1(),
+		resettableMapper{DefaultRESTMapper: mapper},
+		Options{
+			InputOCIRef: ref,
+		})
+	if errs := im.PreflightChecks(context.Background()); len(errs) > 0 {
+		t.Fatalf("PreflightChecks() unexpected errors: %v", errs)
+	}
+	if err := im.Import(context.Background()); err != nil {
+		t.Fatalf("Import() unexpected error: %v", err)
+	}
+
+	want := []string{"Namespace/default", "ConfigMap/config"}
+	if diff := cmp.Diff(want, target.applied); diff != "" {
+		t.Errorf("applied resources mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestControlPlaneStateSegmentedRoundTrip(t *testing.T) {
+	mapper, listKinds := roundTripMapper(t)
+
+	dir := filepath.Join(t.TempDir(), "segments")
+	kube := kubefake.NewSimpleClientset()
+	e := exporter.NewControlPlaneStateExporter(
+		apiextensionsfake.NewSimpleClientset(),
+		dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), append(append(namespaceAndConfigMap("tenant-b"), namespaceAndConfigMap("tenant-a")...), namespaceAndConfigMap("excluded")...)...),
+		kube.Discovery(),
+		kube.AppsV1(),
+		mapper,
+		exporter.Options{
+			OutputArchive:         dir,
+			SegmentByNamespace:    true,
+			ExcludeNamespaces:     []string{"excluded"},
+			IncludeExtraResources: []string{"namespaces", "configmaps"},
+		})
+	if err := e.Export(context.Background()); err != nil {
+		t.Fatalf("Export() unexpected error: %v", err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*"))
+	if err != nil {
+		t.Fatalf("cannot list segments: %v", err)
+	}
+	for i := range files {
+		files[i] = filepath.Base(files[i])
+	}
+	if diff := cmp.Diff([]string{"_cluster.tar.gz", "segments.yaml", "tenant-a.tar.gz", "tenant-b.tar.gz"}, files); diff != "" {
+		t.Errorf("segment files mismatch (-want +got):\n%s", diff)
+	}
+
+	dyn := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds)
+	reviewAccess(dyn, nil)
+	target := &applyRecorder{Interface: dyn}
+	im := NewControlPlaneStateImporter(
+		target,
+		kube.Discovery(),
+		kube.AppsV1(),
+		resettableMapper{DefaultRESTMapper: mapper},
+		Options{
+			InputArchive: dir,
+		})
+	mismatches, err := im.Verify(context.Background())
+	if err != nil {
+		t.Fatalf("Verify() unexpected error: %v", err)
+	}
+	if len(mismatches) > 0 {
+		t.Errorf("Verify() unexpected checksum mismatches: %v", mismatches)
+	}
+	if errs := im.PreflightChecks(context.Background()); len(errs) > 0 {
+		t.Fatalf("PreflightChecks() unexpected errors: %v", errs)
+	}
+	if err := im.Import(context.Background()); err != nil {
+		t.Fatalf("Import() unexpected error: %v", err)
+	}
+
+	// Segments are imported cluster scope first, then by namespace.
+	want := []string{"Namespace/tenant-a", "ConfigMap/config", "Namespace/tenant-b", "ConfigMap/config"}
+	if diff := cmp.Diff(want, target.applied); diff != "" {
+		t.Errorf("applied resources mismatch (-want +got):\n%s", diff)
+	}
+}
+
+// failingApplier creates applied objects, which the fake dynamic client does
+// not support applying, and fails applying objects in the supplied namespace.
+type failingApplier struct {
+	dynamic.Interface
+	namespace string
+}
+
+func (a *failingApplier) Resource(gvr schema.GroupVersionResource) dynamic.NamespaceableResourceInterface {
+	return &failingApplierResource{NamespaceableResourceInterface: a.Interface.Resource(gvr), applier: a}
+}
+
+type failingApplierResource struct {
+	dynamic.NamespaceableResourceInterface
+	applier *failingApplier
+}
+
+func (r *failingApplierResource) Namespace(ns string) dynamic.ResourceInterface {
+	return &failingApplierNamespacedResource{ResourceInterface: r.NamespaceableResourceInterface.Namespace(ns), applier: r.applier}
+}
+
+type failingApplierNamespacedResource struct {
+	dynamic.ResourceInterface
+	applier *failingApplier
+}
+
+func (r *failingApplierNamespacedResource) Apply(ctx context.Context, _ string, obj *unstructured.Unstructured, _ v1.ApplyOptions, subresources ...string) (*unstructured.Unstructured, error) {
+	if obj.GetNamespace() == r.applier.namespace {
+		return nil, errors.New("boom")
+	}
+	if len(subresources) > 0 {
+		return obj, nil
+	}
+	return r.Create(ctx, obj, v1.CreateOptions{})
+}
+
+func TestControlPlaneStateSegmentedRollback(t *testing.T) {
+	mapper, listKinds := roundTripMapper(t)
+	listKinds[schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}] = "NamespaceList"
+	listKinds[schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}] = "ConfigMapList"
+
+	dir := filepath.Join(t.TempDir(), "segments")
+	kube := kubefake.NewSimpleClientset()
+	e := exporter.NewControlPlaneStateExporter(
+		apiextensionsfake.NewSimpleClientset(),
+		dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), append(namespaceAndConfigMap("tenant-a"), namespaceAndConfigMap("tenant-b")...)...),
+		kube.Discovery(),
+		kube.AppsV1(),
+		mapper,
+		exporter.Options{
+			OutputArchive:         dir,
+			SegmentByNamespace:    true,
+			IncludeExtraResources: []string{"namespaces", "configmaps"},
+		})
+	if err := e.Export(context.Background()); err != nil {
+		t.Fatalf("Export() unexpected error: %v", err)
+	}
+
+	dyn := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds)
+	reviewAccess(dyn, nil)
+	im := NewControlPlaneStateImporter(
+		&failingApplier{Interface: dyn, namespace: "tenant-b"},
+		kube.Discovery(),
+		kube.AppsV1(),
+		resettableMapper{DefaultRESTMapper: mapper},
+		Options{
+			InputArchive:      dir,
+			RollbackOnFailure: true,
+		})
+	if err := im.Import(context.Background()); err == nil {
+		t.Fatal("Import() expected error, got nil")
+	}
+
+	// Resources created by segments imported before the failing one are
+	// rolled back too.
+	for _, gvr := range []schema.GroupVersionResource{
+		{Version: "v1", Resource: "namespaces"},
+		{Version: "v1", Resource: "configmaps"},
+	} {
+		l, err := dyn.Resource(gvr).List(context.Background(), v1.ListOptions{})
+		if err != nil {
+			t.Fatalf("cannot list %s: %v", gvr.Resource, err)
+		}
+		var names []string
+		for _, u := range l.Items {
+			names = append(names, namespacedName(u.GetNamespace(), u.GetName()))
+		}
+		if len(names) > 0 {
+			t.Errorf("Import(): %s not rolled back: %v", gvr.Resource, names)
+		}
+	}
+}
+
+func TestWaitPolicyNext(t *testing.T) {
+	cases := map[string]struct {
+		policy   waitPolicy
+		interval time.Duration
+		want     time.Duration
+	}{
+		"NoBackoff": {
+			policy:   waitPolicy{pollInterval: 5 * time.Second},
+			interval: 5 * time.Second,
+			want:     5 * time.Second,
+		},
+		"Doubled": {
+			policy:   waitPolicy{pollInterval: 5 * time.Second, maxPollInterval: time.Minute},
+			interval: 10 * time.Second,
+			want:     20 * time.Second,
+		},
+		"Capped": {
+			policy:   waitPolicy{pollInterval: 5 * time.Second, maxPollInterval: time.Minute},
+			interval: 40 * time.Second,
+			want:     time.Minute,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if got := tc.policy.next(tc.interval); got != tc.want {
+				t.Errorf("next(%s) = %s, want %s", tc.interval, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestWaitForConditions(t *testing.T) {
+	gvk := schema.GroupVersionKind{Group: "pkg.crossplane.io", Version: "v1", Kind: "Provider"}
+	gvr := schema.GroupVersionResource{Group: "pkg.crossplane.io", Version: "v1", Resource: "providers"}
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{gvk.GroupVersion()})
+	mapper.Add(gvk, meta.RESTScopeRoot)
+
+	type args struct {
+		policy waitPolicy
+		// readyAfter is the number of polls after which the provider is
+		// healthy. It never becomes healthy if negative.
+		readyAfter int
+	}
+	type want struct {
+		err bool
+		// maxPolls is the maximum number of expected polls.
+		maxPolls int
+		// output are lines expected in the output.
+		output []string
+	}
+
+	cases := map[string]struct {
+		args args
+		want want
+	}{
+		"Ready": {
+			args: args{
+				policy:     waitPolicy{timeout: time.Second, pollInterval: time.Millisecond},
+				readyAfter: 3,
+			},
+			want: want{
+				maxPolls: 3,
+				output: []string{
+					"Waiting for Provider: provider-aws (Healthy)",
+					"All Providers are Healthy",
+				},
+			},
+		},
+		"Timeout": {
+			args: args{
+				policy:     waitPolicy{timeout: 20 * time.Millisecond, pollInterval: time.Millisecond},
+				readyAfter: -1,
+			},
+			want: want{
+				err: true,
+			},
+		},
+		"Backoff": {
+			args: args{
+				// Polls after 0, 1, 3, 7, 15, 31 and 47ms at the earliest.
+				policy:     waitPolicy{timeout: 50 * time.Millisecond, pollInterval: time.Millisecond, maxPollInterval: 16 * time.Millisecond},
+				readyAfter: -1,
+			},
+			want: want{
+				err:      true,
+				maxPolls: 7,
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			p := &unstructured.Unstructured{}
+			p.SetGroupVersionKind(gvk)
+			p.SetName("provider-aws")
+			dyn := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{gvr: "ProviderList"}, p)
+			polls := 0
+			dyn.PrependReactor("list", "providers", func(_ k8stesting.Action) (bool, runtime.Object, error) {
+				polls++
+				healthy := p.DeepCopy()
+				if tc.args.readyAfter >= 0 && polls >= tc.args.readyAfter {
+					_ = unstructured.SetNestedSlice(healthy.Object, []any{map[string]any{"type": "Healthy", "status": "True"}}, "status", "conditions")
+				}
+				return true, &unstructured.UnstructuredList{Items: []unstructured.Unstructured{*healthy}}, nil
+			})
+
+			w := &syncWriter{}
+			err := waitForConditions(context.Background(), w, dyn, mapper, gvk.GroupKind(), []xpv1.ConditionType{"Healthy"}, tc.args.policy)
+			if (err != nil) != tc.want.err {
+				t.Fatalf("\n%s\nwaitForConditions(...): want error %t, got %v", name, tc.want.err, err)
+			}
+			out := pterm.RemoveColorFromString(w.String())
+			for _, l := range tc.want.output {
+				if !strings.Contains(out, l) {
+					t.Errorf("\n%s\nwaitForConditions(...): want output to contain %q, got %q", name, l, out)
+				}
+			}
+			if tc.want.maxPolls > 0 && polls > tc.want.maxPolls {
+				t.Errorf("\n%s\nwaitForConditions(...): want at most %d polls, got %d", name, tc.want.maxPolls, polls)
+			}
+		})
+	}
+}
+
+// syncWriter is a buffer that can be written to by a spinner concurrently.
+type syncWriter struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (w *syncWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+func (w *syncWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.String()
+}
+
+func TestControlPlaneStateImporterExportWarnings(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	meta := "version: v1alpha1\nwarnings:\n- CRD \"buckets.s3.aws.upbound.io\" is converted by a webhook\n"
+	if err := afero.WriteFile(fs, "/state/export.yaml", []byte(meta), 0600); err != nil {
+		t.Fatalf("cannot write export metadata: %v", err)
+	}
+	kube := kubefake.NewSimpleClientset()
+	dyn := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+	reviewAccess(dyn, nil)
+	im := NewControlPlaneStateImporter(
+		dyn,
+		kube.Discovery(),
+		kube.AppsV1(),
+		restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(kube.Discovery())),
+		Options{
+			InputArchive: "/state",
+			InputFormat:  InputFormatDirectory,
+			InputFS:      fs,
+		})
+	defer im.Close() //nolint:errcheck // Nothing to clean up.
+
+	want := []string{`Export warning: CRD "buckets.s3.aws.upbound.io" is converted by a webhook`}
+	var got []string
+	for _, err := range im.PreflightChecks(context.Background()) {
+		got = append(got, err.Error())
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("PreflightChecks(...): -want, +got:\n%s", diff)
+	}
+}
+
+func TestCheckProviders(t *testing.T) {
+	cases := map[string]struct {
+		reason   string
+		exported []v1alpha1.ProviderInfo
+		observed []v1alpha1.ProviderInfo
+		want     int
+	}{
+		"SameVersions": {
+			reason:   "Providers with the exported versions should pass.",
+			exported: []v1alpha1.ProviderInfo{{Name: "provider-aws", Version: "v1.0.0"}},
+			observed: []v1alpha1.ProviderInfo{{Name: "provider-aws", Version: "v1.0.0"}},
+		},
+		"NotInstalled": {
+			reason:   "Providers not installed in the target control plane should pass, as they are imported.",
+			exported: []v1alpha1.ProviderInfo{{Name: "provider-aws", Version: "v1.0.0"}},
+		},
+		"DifferentVersions": {
+			reason: "Every provider with a version other than the exported one should fail.",
+			exported: []v1alpha1.ProviderInfo{
+				{Name: "provider-aws", Version: "v1.0.0"},
+				{Name: "provider-gcp", Version: "v1.0.0"},
+				{Name: "provider-helm", Version: "v0.17.0"},
+			},
+			observed: []v1alpha1.ProviderInfo{
+				{Name: "provider-aws", Version: "v1.1.0"},
+				{Name: "provider-gcp", Version: "v1.0.0"},
+				{Name: "provider-helm", Version: "v0.16.0"},
+			},
+			want: 2,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			errs := checkProviders(tc.exported, tc.observed)
+			if diff := cmp.Diff(tc.want, len(errs)); diff != "" {
+				t.Errorf("\n%s\ncheckProviders(...): -want errors, +got errors:\n%s\n%v", tc.reason, diff, errs)
+			}
+		})
+	}
+}
@@ -0,0 +1,238 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package importer
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/upbound/up/pkg/migration/meta/v1alpha1"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+)
+
+const (
+	// CategoryBase is the category of types imported before all others,
+	// e.g. packages, CompositeResourceDefinitions, and Secrets.
+	CategoryBase = "base"
+	// CategoryManaged is the category of managed resource types.
+	CategoryManaged = "managed"
+	// CategoryComposite is the category of composite resource types.
+	CategoryComposite = "composite"
+	// CategoryClaim is the category of claim types.
+	CategoryClaim = "claim"
+)
+
+// TypeSummary summarizes the exported resources of a type.
+type TypeSummary struct {
+	// GroupResource is the group resource of the type, e.g.
+	// "buckets.s3.aws.upbound.io".
+	GroupResource string
+	// Count is the number of exported resources of the type.
+	Count int
+	// Category is how the type is imported, i.e. one of CategoryBase,
+	// CategoryManaged, CategoryComposite, or CategoryClaim, or empty for
+	// other types.
+	Category string
+}
+
+// ExportSummary summarizes an export.
+type ExportSummary struct {
+	// Meta is the export metadata.
+	Meta *v1alpha1.ExportMeta
+	// Types are the exported types, sorted by group resource.
+	Types []TypeSummary
+}
+
+// Inspect summarizes the export without accessing a control plane.
+func (im *ControlPlaneStateImporter) Inspect(ctx context.Context) (*ExportSummary, error) {
+	defer im.Close() //nolint:errcheck // Only temporary files are left behind.
+	segments, err := im.segments()
+	if err != nil {
+		return nil, err
+	}
+	if len(segments) == 0 {
+		if im.fs == nil {
+			if err := im.open(ctx); err != nil {
+				return nil, err
+			}
+		}
+		return im.inspect()
+	}
+
+	// The metadata is the same in all segments, the resources of a type are
+	// spread across them.
+	var summary *ExportSummary
+	counts := map[string]*TypeSummary{}
+	for _, s := range segments {
+		sub := im.forSegment(s)
+		if err := sub.open(ctx); err != nil {
+			return nil, errors.Wrapf(err, "cannot open segment %q", s.Archive)
+		}
+		ss, err := sub.inspect()
+		_ = sub.Close()
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot inspect segment %q", s.Archive)
+		}
+		if summary == nil {
+			summary = &ExportSummary{Meta: ss.Meta}
+		}
+		for _, t := range ss.Types {
+			if c, ok := counts[t.GroupResource]; ok {
+				c.Count += t.Count
+				continue
+			}
+			t := t
+			counts[t.GroupResource] = &t
+		}
+	}
+	for _, t := range counts {
+		summary.Types = append(summary.Types, *t)
+	}
+	sort.Slice(summary.Types, func(i, j int) bool {
+		return summary.Types[i].GroupResource < summary.Types[j].GroupResource
+	})
+	return summary, nil
+}
+
+// inspect summarizes the opened export.
+func (im *ControlPlaneStateImporter) inspect() (*ExportSummary, error) {
+	em, err := im.exportMeta()
+	if err != nil {
+		return nil, err
+	}
+	infos, err := im.fs.ReadDir("/")
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot list group resources")
+	}
+	r := NewFileSystemReader(*im.fs)
+	summary := &ExportSummary{Meta: em}
+	for _, info := range infos {
+		if !info.IsDir() {
+			continue
+		}
+		gr := info.Name()
+		resources, tm, err := r.ReadResources(gr)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot read %q resources", gr)
+		}
+		summary.Types = append(summary.Types, TypeSummary{
+			GroupResource: gr,
+			Count:         len(resources),
+			Category:      typeCategory(gr, tm),
+		})
+	}
+	// Directories are read in lexicographic order.
+	return summary, nil
+}
+
+// typeCategory returns how resources of the group resource with the supplied
+// type metadata are imported.
+func typeCategory(gr string, tm *v1alpha1.TypeMeta) string {
+	if isBaseResource(gr) {
+		return CategoryBase
+	}
+	if tm == nil {
+		return ""
+	}
+	for _, c := range tm.Categories {
+		switch c {
+		case CategoryManaged, CategoryComposite, CategoryClaim:
+			return c
+		}
+	}
+	return ""
+}
+
+// ReadResource returns the YAML file of the exported resource of the supplied
+// group resource, e.g. "buckets.s3.aws.upbound.io", with the supplied name and
+// namespace, which is empty for cluster scoped resources, without accessing a
+// control plane.
+func (im *ControlPlaneStateImporter) ReadResource(ctx context.Context, groupResource, namespace, name string) ([]byte, error) {
+	defer im.Close() //nolint:errcheck // Only temporary files are left behind.
+	segments, err := im.segments()
+	if err != nil {
+		return nil, err
+	}
+	if len(segments) == 0 {
+		if im.fs == nil {
+			if err := im.open(ctx); err != nil {
+				return nil, err
+			}
+		}
+		b, err := im.readResource(groupResource, namespace, name)
+		if err == nil && b == nil {
+			err = errNotExported(groupResource, namespace, name)
+		}
+		return b, err
+	}
+
+	// Namespaces are exported to their own segment, so search all of them.
+	for _, s := range segments {
+		sub := im.forSegment(s)
+		if err := sub.open(ctx); err != nil {
+			return nil, errors.Wrapf(err, "cannot open segment %q", s.Archive)
+		}
+		b, err := sub.readResource(groupResource, namespace, name)
+		_ = sub.Close()
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot read segment %q", s.Archive)
+		}
+		if b != nil {
+			return b, nil
+		}
+	}
+	return nil, errNotExported(groupResource, namespace, name)
+}
+
+// readResource returns the YAML file of the supplied resource in the opened
+// export, or nil if it was not exported.
+func (im *ControlPlaneStateImporter) readResource(groupResource, namespace, name string) ([]byte, error) {
+	id := filepath.Join("cluster", name)
+	if namespace != "" {
+		id = filepath.Join("namespaces", namespace, name)
+	}
+
+	path := filepath.Join(groupResource, id+".yaml")
+	mf := filepath.Join(groupResource, "manifest.yaml")
+	if ok, _ := im.fs.Exists(mf); ok {
+		b, err := im.fs.ReadFile(mf)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot read manifest %q", mf)
+		}
+		manifest := &v1alpha1.ContentManifest{}
+		if err := yaml.Unmarshal(b, manifest); err != nil {
+			return nil, errors.Wrapf(err, "cannot unmarshal manifest %q", mf)
+		}
+		h, ok := manifest.Resources[id]
+		if !ok {
+			return nil, nil
+		}
+		path = filepath.Join(groupResource, "objects", h+".yaml")
+	}
+
+	if ok, err := im.fs.Exists(path); err != nil || !ok {
+		return nil, errors.Wrapf(err, "cannot read file %q", path)
+	}
+	b, err := im.fs.ReadFile(path)
+	return b, errors.Wrapf(err, "cannot read file %q", path)
+}
+
+func errNotExported(groupResource, namespace, name string) error {
+	return errors.Errorf("%s %q was not exported", groupResource, namespacedName(namespace, name))
+}
@@ -0,0 +1,171 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package importer
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/upbound/up/pkg/migration/archiver"
+	"github.com/upbound/up/pkg/migration/meta/v1alpha1"
+)
+
+func TestControlPlaneStateImporterInspect(t *testing.T) {
+	type want struct {
+		summary *ExportSummary
+		err     bool
+	}
+
+	cases := map[string]struct {
+		files map[string]string
+		want  want
+	}{
+		"Categorized": {
+			files: map[string]string{
+				"export.yaml": "version: v1alpha1\ncrossplane:\n  version: v1.16.0\n  featureFlags: [--enable-usages]\n",
+				"configmaps/namespaces/default/config.yaml":        configMapYAML,
+				"configmaps/namespaces/default/config2.yaml":       configMapYAML,
+				"things.example.org/cluster/thing.yaml":            "apiVersion: example.org/v1\nkind: Thing\nmetadata:\n  name: thing\n",
+				"things.example.org/metadata.yaml":                 "categories: [crossplane, managed]\n",
+				"claims.example.org/namespaces/default/claim.yaml": "apiVersion: example.org/v1\nkind: Claim\nmetadata:\n  name: claim\n  namespace: default\n",
+				"claims.example.org/metadata.yaml":                 "categories: [claim]\n",
+				"others.example.org/cluster/other.yaml":            "apiVersion: example.org/v1\nkind: Other\nmetadata:\n  name: other\n",
+			},
+			want: want{
+				summary: &ExportSummary{
+					Meta: &v1alpha1.ExportMeta{
+						Version: "v1alpha1",
+						Crossplane: v1alpha1.CrossplaneInfo{
+							Version:      "v1.16.0",
+							FeatureFlags: []string{"--enable-usages"},
+						},
+					},
+					Types: []TypeSummary{
+						{GroupResource: "claims.example.org", Count: 1, Category: CategoryClaim},
+						{GroupResource: "configmaps", Count: 2, Category: CategoryBase},
+						{GroupResource: "others.example.org", Count: 1},
+						{GroupResource: "things.example.org", Count: 1, Category: CategoryManaged},
+					},
+				},
+			},
+		},
+		"MissingMetadata": {
+			files: map[string]string{
+				"configmaps/namespaces/default/config.yaml": configMapYAML,
+			},
+			want: want{
+				err: true,
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			fs := exportedState(t, tc.files)
+			in := &bytes.Buffer{}
+			if err := archiver.Archive(context.Background(), fs, ".", in); err != nil {
+				t.Fatalf("cannot write archive: %v", err)
+			}
+
+			im := NewControlPlaneStateImporter(nil, nil, nil, nil, Options{InputArchive: "-", InputReader: in})
+			summary, err := im.Inspect(context.Background())
+			if (err != nil) != tc.want.err {
+				t.Fatalf("\n%s\nInspect(...): want error %t, got %v", name, tc.want.err, err)
+			}
+			if diff := cmp.Diff(tc.want.summary, summary); diff != "" {
+				t.Errorf("\n%s\nInspect(...): -want, +got:\n%s", name, diff)
+			}
+		})
+	}
+}
+
+func TestControlPlaneStateImporterReadResource(t *testing.T) {
+	const thing = "apiVersion: example.org/v1\nkind: Thing\nmetadata:\n  name: thing\n"
+
+	type args struct {
+		groupResource string
+		namespace     string
+		name          string
+	}
+	type want struct {
+		yaml string
+		err  bool
+	}
+
+	cases := map[string]struct {
+		reason string
+		files  map[string]string
+		args   args
+		want   want
+	}{
+		"Namespaced": {
+			reason: "A namespaced resource should be read from its namespace directory.",
+			files: map[string]string{
+				"export.yaml": "version: v1alpha1\n",
+				"configmaps/namespaces/default/config.yaml": configMapYAML,
+			},
+			args: args{groupResource: "configmaps", namespace: "default", name: "config"},
+			want: want{yaml: configMapYAML},
+		},
+		"ClusterScoped": {
+			reason: "A cluster scoped resource should be read from the cluster directory.",
+			files: map[string]string{
+				"export.yaml":                           "version: v1alpha1\n",
+				"things.example.org/cluster/thing.yaml": thing,
+			},
+			args: args{groupResource: "things.example.org", name: "thing"},
+			want: want{yaml: thing},
+		},
+		"ContentAddressable": {
+			reason: "A resource of a content addressable export should be read by its hash.",
+			files: map[string]string{
+				"export.yaml":                         "version: v1alpha1\n",
+				"things.example.org/manifest.yaml":    "resources:\n  cluster/thing: abc\n",
+				"things.example.org/objects/abc.yaml": thing,
+			},
+			args: args{groupResource: "things.example.org", name: "thing"},
+			want: want{yaml: thing},
+		},
+		"NotExported": {
+			reason: "Reading a resource that was not exported should fail.",
+			files: map[string]string{
+				"export.yaml": "version: v1alpha1\n",
+				"configmaps/namespaces/default/config.yaml": configMapYAML,
+			},
+			args: args{groupResource: "configmaps", namespace: "other", name: "config"},
+			want: want{err: true},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			fs := exportedState(t, tc.files)
+			in := &bytes.Buffer{}
+			if err := archiver.Archive(context.Background(), fs, ".", in); err != nil {
+				t.Fatalf("cannot write archive: %v", err)
+			}
+
+			im := NewControlPlaneStateImporter(nil, nil, nil, nil, Options{InputArchive: "-", InputReader: in})
+			b, err := im.ReadResource(context.Background(), tc.args.groupResource, tc.args.namespace, tc.args.name)
+			if (err != nil) != tc.want.err {
+				t.Fatalf("\n%s\nReadResource(...): want error %t, got %v", tc.reason, tc.want.err, err)
+			}
+			if diff := cmp.Diff(tc.want.yaml, string(b)); diff != "" {
+				t.Errorf("\n%s\nReadResource(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package importer
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pterm/pterm"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+)
+
+// JournalEntry identifies a resource created by an import.
+type JournalEntry struct {
+	GVR       schema.GroupVersionResource
+	Namespace string
+	Name      string
+}
+
+// ApplyJournal records the resources created by an import, so that they can
+// be rolled back if the import fails. Resources that already existed before
+// they were applied are not recorded, so that a rollback never deletes them.
+type ApplyJournal struct {
+	mu      sync.Mutex
+	entries []JournalEntry
+}
+
+// NewApplyJournal returns a new, empty ApplyJournal.
+func NewApplyJournal() *ApplyJournal {
+	return &ApplyJournal{}
+}
+
+// Record records that the supplied resource was created.
+func (j *ApplyJournal) Record(e JournalEntry) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.entries = append(j.entries, e)
+}
+
+// Entries returns the recorded resources in the order they were created.
+func (j *ApplyJournal) Entries() []JournalEntry {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return append([]JournalEntry(nil), j.entries...)
+}
+
+// Rollback deletes all recorded resources in the reverse order of their
+// creation. Finalizers are removed first and dependents are orphaned, so
+// that deleting imported managed resources never deletes the external
+// resources they represent. Rollback is best effort: it warns about and
+// skips resources that cannot be deleted, and returns all such errors.
+func (j *ApplyJournal) Rollback(ctx context.Context, dyn dynamic.Interface) error {
+	entries := j.Entries()
+	var errs []error
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		if err := deleteOrphaning(ctx, dyn.Resource(e.GVR).Namespace(e.Namespace), e.Name); err != nil {
+			err = errors.Wrapf(err, "cannot delete %s %q", e.GVR.GroupResource(), namespacedName(e.Namespace, e.Name))
+			pterm.Warning.Println(err.Error())
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// deleteOrphaning removes the finalizers of the named resource and deletes it,
+// orphaning its dependents.
+func deleteOrphaning(ctx context.Context, ri dynamic.ResourceInterface, name string) error {
+	u, err := ri.Get(ctx, name, v1.GetOptions{})
+	if kerrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if len(u.GetFinalizers()) > 0 {
+		u.SetFinalizers(nil)
+		if _, err := ri.Update(ctx, u, v1.UpdateOptions{}); err != nil && !kerrors.IsNotFound(err) {
+			return errors.Wrap(err, "cannot remove finalizers")
+		}
+	}
+	orphan := v1.DeletePropagationOrphan
+	if err := ri.Delete(ctx, name, v1.DeleteOptions{PropagationPolicy: &orphan}); err != nil && !kerrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+func namespacedName(namespace, name string) string {
+	if namespace == "" {
+		return name
+	}
+	return namespace + "/" + name
+}
@@ -0,0 +1,130 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package importer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+var (
+	journalGVK = schema.GroupVersionKind{Group: "example.org", Version: "v1", Kind: "Thing"}
+	journalGVR = journalGVK.GroupVersion().WithResource("things")
+)
+
+func journalThing(name string, finalizers ...string) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(journalGVK)
+	u.SetNamespace("default")
+	u.SetName(name)
+	u.SetFinalizers(finalizers)
+	return u
+}
+
+func TestUnstructuredResourceApplierJournal(t *testing.T) {
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{journalGVK.GroupVersion()})
+	mapper.Add(journalGVK, meta.RESTScopeNamespace)
+	rec := &applyRecorder{Interface: fake.NewSimpleDynamicClient(runtime.NewScheme(), journalThing("existing"))}
+
+	j := NewApplyJournal()
+	a := NewUnstructuredResourceApplier(rec, mapper, WithJournal(j))
+	if err := a.ApplyResources(context.Background(), []unstructured.Unstructured{*journalThing("existing"), *journalThing("new")}, false); err != nil {
+		t.Fatalf("ApplyResources() unexpected error: %v", err)
+	}
+
+	want := []JournalEntry{{GVR: journalGVR, Namespace: "default", Name: "new"}}
+	if diff := cmp.Diff(want, j.Entries()); diff != "" {
+		t.Errorf("journal entries: -want, +got:\n%s", diff)
+	}
+}
+
+func TestApplyJournalRollback(t *testing.T) {
+	type want struct {
+		deleted   []string
+		remaining []string
+		err       bool
+	}
+	cases := map[string]struct {
+		failDelete string
+		want       want
+	}{
+		"ReverseOrder": {
+			want: want{
+				deleted:   []string{"c", "b", "a"},
+				remaining: []string{"untouched"},
+			},
+		},
+		"BestEffort": {
+			failDelete: "b",
+			want: want{
+				deleted:   []string{"c", "a"},
+				remaining: []string{"b", "untouched"},
+				err:       true,
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			dyn := fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{journalGVR: "ThingList"},
+				journalThing("a", "finalizer.crossplane.io"), journalThing("b"), journalThing("c"), journalThing("untouched"))
+			var deleted []string
+			dyn.PrependReactor("delete", "things", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				name := action.(k8stesting.DeleteAction).GetName()
+				if name == tc.failDelete {
+					return true, nil, kerrors.NewForbidden(journalGVR.GroupResource(), name, nil)
+				}
+				deleted = append(deleted, name)
+				return false, nil, nil
+			})
+
+			j := NewApplyJournal()
+			for _, n := range []string{"a", "b", "c"} {
+				j.Record(JournalEntry{GVR: journalGVR, Namespace: "default", Name: n})
+			}
+			// Resources that were already deleted are skipped.
+			j.Record(JournalEntry{GVR: journalGVR, Namespace: "default", Name: "gone"})
+
+			err := j.Rollback(context.Background(), dyn)
+			if (err != nil) != tc.want.err {
+				t.Fatalf("Rollback(...): error = %v, want error %t", err, tc.want.err)
+			}
+			if diff := cmp.Diff(tc.want.deleted, deleted); diff != "" {
+				t.Errorf("Rollback(...): deleted: -want, +got:\n%s", diff)
+			}
+
+			l, err := dyn.Resource(journalGVR).Namespace("default").List(context.Background(), v1.ListOptions{})
+			if err != nil {
+				t.Fatalf("cannot list remaining resources: %v", err)
+			}
+			remaining := make([]string, 0, len(l.Items))
+			for _, u := range l.Items {
+				remaining = append(remaining, u.GetName())
+			}
+			if diff := cmp.Diff(tc.want.remaining, remaining); diff != "" {
+				t.Errorf("Rollback(...): remaining: -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
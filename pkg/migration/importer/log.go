@@ -0,0 +1,98 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package importer
+
+import (
+	"encoding/json"
+	"io"
+	"path"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	// LogOpApply is the operation of applying a resource.
+	LogOpApply = "apply"
+	// LogOpRestoreStatus is the operation of restoring the status of a
+	// resource.
+	LogOpRestoreStatus = "restore-status"
+
+	// LogResultOK is the result of a successful operation.
+	LogResultOK = "ok"
+	// LogResultError is the result of a failed operation.
+	LogResultError = "error"
+	// LogResultSkipped is the result of an operation that was skipped.
+	LogResultSkipped = "skipped"
+	// LogResultUnchanged is the result of an operation that was skipped as
+	// the resource was identical already.
+	LogResultUnchanged = "unchanged"
+)
+
+// LogEntry is a line of the structured import log, recording the outcome of
+// an operation on a single resource.
+type LogEntry struct {
+	Timestamp time.Time `json:"ts"`
+	Op        string    `json:"op"`
+	GVR       string    `json:"gvr"`
+	Namespace string    `json:"namespace"`
+	Name      string    `json:"name"`
+	Result    string    `json:"result"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// structuredLog writes LogEntries as JSON lines. It is safe for concurrent
+// use.
+type structuredLog struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+func newStructuredLog(w io.Writer) *structuredLog {
+	return &structuredLog{enc: json.NewEncoder(w)}
+}
+
+// record writes the outcome of op on the named resource. Failing to write the
+// log does not fail the import, so write errors are ignored.
+func (l *structuredLog) record(op string, gvr schema.GroupVersionResource, namespace, name string, err error) {
+	result := LogResultOK
+	if err != nil {
+		result = LogResultError
+	}
+	l.recordResult(op, gvr, namespace, name, result, err)
+}
+
+// recordResult writes the supplied result of op on the named resource.
+func (l *structuredLog) recordResult(op string, gvr schema.GroupVersionResource, namespace, name, result string, err error) {
+	if l == nil {
+		return
+	}
+	e := LogEntry{
+		Timestamp: time.Now().UTC(),
+		Op:        op,
+		GVR:       path.Join(gvr.Group, gvr.Version, gvr.Resource),
+		Namespace: namespace,
+		Name:      name,
+		Result:    result,
+	}
+	if err != nil {
+		e.Error = err.Error()
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_ = l.enc.Encode(e)
+}
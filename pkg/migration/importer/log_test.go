@@ -0,0 +1,101 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package importer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+)
+
+func TestUnstructuredResourceApplierStructuredLog(t *testing.T) {
+	known := schema.GroupVersionKind{Group: "example.org", Version: "v1", Kind: "Thing"}
+	unknown := schema.GroupVersionKind{Group: "example.org", Version: "v1", Kind: "Unknown"}
+	object := func(gvk schema.GroupVersionKind, name string) unstructured.Unstructured {
+		u := unstructured.Unstructured{}
+		u.SetGroupVersionKind(gvk)
+		u.SetNamespace("default")
+		u.SetName(name)
+		return u
+	}
+
+	type want struct {
+		entries []LogEntry
+		err     bool
+	}
+	cases := map[string]struct {
+		resources []unstructured.Unstructured
+		want      want
+	}{
+		"Applied": {
+			resources: []unstructured.Unstructured{object(known, "a"), object(known, "b")},
+			want: want{
+				entries: []LogEntry{
+					{Op: LogOpApply, GVR: "example.org/v1/things", Namespace: "default", Name: "a", Result: LogResultOK},
+					{Op: LogOpApply, GVR: "example.org/v1/things", Namespace: "default", Name: "b", Result: LogResultOK},
+				},
+			},
+		},
+		"Failed": {
+			resources: []unstructured.Unstructured{object(known, "a"), object(unknown, "b")},
+			want: want{
+				entries: []LogEntry{
+					{Op: LogOpApply, GVR: "example.org/v1/things", Namespace: "default", Name: "a", Result: LogResultOK},
+					{Op: LogOpApply, GVR: "example.org/v1", Namespace: "default", Name: "b", Result: LogResultError, Error: `no matches for kind "Unknown" in version "example.org/v1"`},
+				},
+				err: true,
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{known.GroupVersion()})
+			mapper.Add(known, meta.RESTScopeNamespace)
+			rec := &applyRecorder{Interface: fake.NewSimpleDynamicClient(runtime.NewScheme())}
+
+			buf := &bytes.Buffer{}
+			a := NewUnstructuredResourceApplier(rec, mapper, WithStructuredLog(buf))
+			err := a.ApplyResources(context.Background(), tc.resources, false)
+			if (err != nil) != tc.want.err {
+				t.Fatalf("ApplyResources(...): error = %v, want error %t", err, tc.want.err)
+			}
+
+			var got []LogEntry
+			dec := json.NewDecoder(buf)
+			for dec.More() {
+				e := LogEntry{}
+				if err := dec.Decode(&e); err != nil {
+					t.Fatalf("cannot decode log entry: %v", err)
+				}
+				if e.Timestamp.IsZero() {
+					t.Errorf("log entry of %q has no timestamp", e.Name)
+				}
+				got = append(got, e)
+			}
+			if diff := cmp.Diff(tc.want.entries, got, cmpopts.IgnoreFields(LogEntry{}, "Timestamp")); diff != "" {
+				t.Errorf("ApplyResources(...): log entries: -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
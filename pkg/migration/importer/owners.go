@@ -0,0 +1,128 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package importer
+
+import (
+	"context"
+	"fmt"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+)
+
+// OwnerReferenceValidator finds resources of an export whose owners will
+// neither be imported nor exist in the target control plane, so that they
+// would be orphaned. Exports of 'migration export' have no owner references,
+// which are removed during the export, but exports written otherwise may.
+type OwnerReferenceValidator struct {
+	reader         ResourceReader
+	dynamicClient  dynamic.Interface
+	resourceMapper meta.RESTMapper
+}
+
+// NewOwnerReferenceValidator returns a new OwnerReferenceValidator.
+func NewOwnerReferenceValidator(r ResourceReader, dynamicClient dynamic.Interface, mapper meta.RESTMapper) *OwnerReferenceValidator {
+	return &OwnerReferenceValidator{
+		reader:         r,
+		dynamicClient:  dynamicClient,
+		resourceMapper: mapper,
+	}
+}
+
+// ownedResource is an exported resource with an owner reference.
+type ownedResource struct {
+	groupResource string
+	namespace     string
+	name          string
+	owner         v1.OwnerReference
+}
+
+// Validate returns a warning for every owner reference of the resources of
+// the supplied group resources to a resource that is neither among them nor
+// exists in the target control plane. Orphans are only warned about, as the
+// garbage collector may delete them anyway.
+func (v *OwnerReferenceValidator) Validate(ctx context.Context, grs []schema.GroupResource) ([]string, error) {
+	uids := map[types.UID]struct{}{}
+	var owned []ownedResource
+	for _, gr := range grs {
+		resources, _, err := v.reader.ReadResources(gr.String())
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range resources {
+			if uid := r.GetUID(); uid != "" {
+				uids[uid] = struct{}{}
+			}
+			for _, ref := range r.GetOwnerReferences() {
+				owned = append(owned, ownedResource{groupResource: gr.String(), namespace: r.GetNamespace(), name: r.GetName(), owner: ref})
+			}
+		}
+	}
+
+	var warnings []string
+	for _, o := range owned {
+		if _, ok := uids[o.owner.UID]; ok {
+			continue
+		}
+		exists, err := v.ownerExists(ctx, o)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("Cannot check whether owner %s %q of %s %q exists in the target control plane: %s", o.owner.Kind, o.owner.Name, o.groupResource, namespacedName(o.namespace, o.name), err))
+			continue
+		}
+		if !exists {
+			warnings = append(warnings, fmt.Sprintf("Owner %s %q of %s %q is neither imported nor exists in the target control plane.", o.owner.Kind, o.owner.Name, o.groupResource, namespacedName(o.namespace, o.name)))
+		}
+	}
+	return warnings, nil
+}
+
+// ownerExists returns whether the owner of the resource exists in the target
+// control plane. Namespaced owners are in the namespace of the resource. An
+// object with the name of the owner but a different UID is not the owner,
+// e.g. because it was recreated, and the garbage collector would delete the
+// resource.
+func (v *OwnerReferenceValidator) ownerExists(ctx context.Context, o ownedResource) (bool, error) {
+	gv, err := schema.ParseGroupVersion(o.owner.APIVersion)
+	if err != nil {
+		return false, err
+	}
+	rm, err := v.resourceMapper.RESTMapping(schema.GroupKind{Group: gv.Group, Kind: o.owner.Kind}, gv.Version)
+	if meta.IsNoMatchError(err) {
+		// The type of the owner does not exist in the target control plane.
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	ri := v.dynamicClient.Resource(rm.Resource)
+	var u *unstructured.Unstructured
+	if rm.Scope.Name() == meta.RESTScopeNameNamespace {
+		u, err = ri.Namespace(o.namespace).Get(ctx, o.owner.Name, v1.GetOptions{})
+	} else {
+		u, err = ri.Get(ctx, o.owner.Name, v1.GetOptions{})
+	}
+	if kerrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return o.owner.UID == "" || u.GetUID() == o.owner.UID, nil
+}
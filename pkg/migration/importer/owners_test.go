@@ -0,0 +1,146 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package importer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/afero"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic/fake"
+)
+
+const ownedChild = `
+apiVersion: example.org/v1
+kind: Child
+metadata:
+  name: child
+  namespace: default
+  uid: child-uid
+  ownerReferences:
+  - apiVersion: example.org/v1
+    kind: Owner
+    name: owner
+    uid: owner-uid
+`
+
+const exportedOwner = `
+apiVersion: example.org/v1
+kind: Owner
+metadata:
+  name: owner
+  uid: owner-uid
+`
+
+func TestOwnerReferenceValidator(t *testing.T) {
+	ownerGVK := schema.GroupVersionKind{Group: "example.org", Version: "v1", Kind: "Owner"}
+	owner := func(name string, uid types.UID) runtime.Object {
+		u := &unstructured.Unstructured{}
+		u.SetGroupVersionKind(ownerGVK)
+		u.SetName(name)
+		u.SetUID(uid)
+		return u
+	}
+	grs := []schema.GroupResource{{Group: "example.org", Resource: "children"}, {Group: "example.org", Resource: "owners"}}
+
+	type args struct {
+		files     map[string]string
+		installed []runtime.Object
+	}
+	type want struct {
+		warnings []string
+		err      error
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"ExportedOwner": {
+			reason: "No warning should be returned if the owner is part of the export.",
+			args: args{
+				files: map[string]string{
+					"children.example.org/namespaces/default/child.yaml": ownedChild,
+					"owners.example.org/cluster/owner.yaml":              exportedOwner,
+				},
+			},
+			want: want{},
+		},
+		"ExistingOwner": {
+			reason: "No warning should be returned if the owner exists in the target control plane.",
+			args: args{
+				files: map[string]string{
+					"children.example.org/namespaces/default/child.yaml": ownedChild,
+				},
+				installed: []runtime.Object{owner("owner", "owner-uid")},
+			},
+			want: want{},
+		},
+		"RecreatedOwner": {
+			reason: "A warning should be returned if an object with the name of the owner exists in the target control plane, but has a different UID.",
+			args: args{
+				files: map[string]string{
+					"children.example.org/namespaces/default/child.yaml": ownedChild,
+				},
+				installed: []runtime.Object{owner("owner", "other-uid")},
+			},
+			want: want{
+				warnings: []string{`Owner Owner "owner" of children.example.org "default/child" is neither imported nor exists in the target control plane.`},
+			},
+		},
+		"MissingOwner": {
+			reason: "A warning should be returned if the owner is neither exported nor exists in the target control plane.",
+			args: args{
+				files: map[string]string{
+					"children.example.org/namespaces/default/child.yaml": ownedChild,
+				},
+				installed: []runtime.Object{owner("other", "owner-uid")},
+			},
+			want: want{
+				warnings: []string{`Owner Owner "owner" of children.example.org "default/child" is neither imported nor exists in the target control plane.`},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			fs := afero.Afero{Fs: afero.NewMemMapFs()}
+			for f, c := range tc.args.files {
+				if err := fs.WriteFile(f, []byte(c), 0600); err != nil {
+					t.Fatalf("cannot write file %q: %v", f, err)
+				}
+			}
+			dyn := fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+				ownerGVK.GroupVersion().WithResource("owners"): "OwnerList",
+			}, tc.args.installed...)
+			mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{ownerGVK.GroupVersion()})
+			mapper.Add(ownerGVK, meta.RESTScopeRoot)
+
+			got, err := NewOwnerReferenceValidator(NewFileSystemReader(fs), dyn, mapper).Validate(context.Background(), grs)
+			if diff := cmp.Diff(tc.want.err, err); diff != "" {
+				t.Errorf("\n%s\nValidate(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.warnings, got); diff != "" {
+				t.Errorf("\n%s\nValidate(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
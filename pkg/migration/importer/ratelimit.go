@@ -0,0 +1,107 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package importer
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+const (
+	// minThrottleDelay is the delay between requests after the first
+	// throttled request.
+	minThrottleDelay = 100 * time.Millisecond
+	// maxThrottleDelay is the maximum delay between requests.
+	maxThrottleDelay = 30 * time.Second
+)
+
+// AdaptiveRateLimiter delays requests to the target cluster once it starts
+// throttling them with HTTP 429 responses. The delay doubles with every
+// throttled request and halves with every successful one, until requests are
+// no longer delayed.
+type AdaptiveRateLimiter struct {
+	mu    sync.Mutex
+	delay time.Duration
+
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// NewAdaptiveRateLimiter returns a new AdaptiveRateLimiter.
+func NewAdaptiveRateLimiter() *AdaptiveRateLimiter {
+	return &AdaptiveRateLimiter{
+		sleep: sleep,
+	}
+}
+
+// Wait blocks for the current delay, or until ctx is done.
+func (l *AdaptiveRateLimiter) Wait(ctx context.Context) error {
+	l.mu.Lock()
+	d := l.delay
+	l.mu.Unlock()
+	if d == 0 {
+		return nil
+	}
+	return l.sleep(ctx, d)
+}
+
+// Observe adjusts the delay to the result of a request.
+func (l *AdaptiveRateLimiter) Observe(err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !kerrors.IsTooManyRequests(err) {
+		l.delay /= 2
+		if l.delay < minThrottleDelay {
+			l.delay = 0
+		}
+		return
+	}
+
+	l.delay *= 2
+	if l.delay < minThrottleDelay {
+		l.delay = minThrottleDelay
+	}
+	// Respect the delay suggested by the server, if any.
+	if s, ok := kerrors.SuggestsClientDelay(err); ok && time.Duration(s)*time.Second > l.delay {
+		l.delay = time.Duration(s) * time.Second
+	}
+	if l.delay > maxThrottleDelay {
+		l.delay = maxThrottleDelay
+	}
+}
+
+// Do waits for the current delay, calls fn and observes its result.
+func (l *AdaptiveRateLimiter) Do(ctx context.Context, fn func() error) error {
+	if err := l.Wait(ctx); err != nil {
+		return err
+	}
+	err := fn()
+	l.Observe(err)
+	return err
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package importer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+	ktesting "k8s.io/client-go/testing"
+)
+
+func TestAdaptiveRateLimiter(t *testing.T) {
+	gvk := schema.GroupVersionKind{Group: "example.org", Version: "v1", Kind: "Thing"}
+
+	type args struct {
+		throttled  int
+		retryAfter int
+	}
+	type want struct {
+		calls int
+		waits []time.Duration
+		delay time.Duration
+	}
+
+	cases := map[string]struct {
+		args args
+		want want
+	}{
+		"NotThrottled": {
+			args: args{},
+			want: want{
+				calls: 1,
+			},
+		},
+		"BacksOffAndRetries": {
+			args: args{
+				throttled: 3,
+			},
+			want: want{
+				calls: 4,
+				waits: []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond},
+				delay: 200 * time.Millisecond,
+			},
+		},
+		"RespectsSuggestedDelay": {
+			args: args{
+				throttled:  1,
+				retryAfter: 2,
+			},
+			want: want{
+				calls: 2,
+				waits: []time.Duration{2 * time.Second},
+				delay: time.Second,
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			calls := 0
+			dyn := fake.NewSimpleDynamicClient(runtime.NewScheme())
+			dyn.PrependReactor("patch", "things", func(action ktesting.Action) (bool, runtime.Object, error) {
+				calls++
+				if calls <= tc.args.throttled {
+					return true, nil, kerrors.NewTooManyRequests("slow down", tc.args.retryAfter)
+				}
+				u := &unstructured.Unstructured{}
+				u.SetGroupVersionKind(gvk)
+				u.SetName(action.(ktesting.PatchAction).GetName())
+				return true, u, nil
+			})
+
+			var waits []time.Duration
+			l := NewAdaptiveRateLimiter()
+			l.sleep = func(_ context.Context, d time.Duration) error {
+				waits = append(waits, d)
+				return nil
+			}
+
+			mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{gvk.GroupVersion()})
+			mapper.Add(gvk, meta.RESTScopeRoot)
+			u := unstructured.Unstructured{}
+			u.SetGroupVersionKind(gvk)
+			u.SetName("a")
+
+			a := NewUnstructuredResourceApplier(dyn, mapper, WithRateLimiter(l))
+			if err := a.ApplyResources(context.Background(), []unstructured.Unstructured{u}, false); err != nil {
+				t.Fatalf("ApplyResources() unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.want.calls, calls); diff != "" {
+				t.Errorf("calls mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.want.waits, waits); diff != "" {
+				t.Errorf("waits mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.want.delay, l.delay); diff != "" {
+				t.Errorf("delay after recovery mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package importer
+
+import (
+	"context"
+	"strings"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+)
+
+// importVerbs are the verbs the importer needs on every imported type.
+var importVerbs = []string{"create", "patch"}
+
+var selfSubjectAccessReviewsGVR = authorizationv1.SchemeGroupVersion.WithResource("selfsubjectaccessreviews")
+
+// ImportRBACChecker checks that the importer is allowed to write all imported
+// types across all namespaces, so that an import does not fail midway due to
+// missing permissions.
+type ImportRBACChecker struct {
+	dynamicClient dynamic.Interface
+}
+
+// NewImportRBACChecker returns a new ImportRBACChecker.
+func NewImportRBACChecker(dynamicClient dynamic.Interface) *ImportRBACChecker {
+	return &ImportRBACChecker{
+		dynamicClient: dynamicClient,
+	}
+}
+
+// Check reviews the access of the importer to the supplied types. It returns
+// an error for every type with missing permissions, listing all verbs denied
+// on it. Versions are not needed to review access, so types provided by
+// packages that are not installed yet can be checked, too.
+func (c *ImportRBACChecker) Check(ctx context.Context, grs []schema.GroupResource) []error {
+	var errs []error
+	for _, gr := range grs {
+		var denied []string
+		for _, verb := range importVerbs {
+			status, err := c.review(ctx, gr, verb)
+			if err != nil {
+				errs = append(errs, errors.Wrapf(err, "Cannot review permission to %s %q", verb, gr))
+				continue
+			}
+			if !status.Allowed {
+				denied = append(denied, verb)
+			}
+		}
+		if len(denied) > 0 {
+			errs = append(errs, errors.Errorf("Missing permissions to %s %q in all namespaces", strings.Join(denied, ", "), gr))
+		}
+	}
+	return errs
+}
+
+// review creates a SelfSubjectAccessReview for the verb on the type in all
+// namespaces.
+func (c *ImportRBACChecker) review(ctx context.Context, gr schema.GroupResource, verb string) (authorizationv1.SubjectAccessReviewStatus, error) {
+	ssar := &authorizationv1.SelfSubjectAccessReview{
+		TypeMeta: v1.TypeMeta{
+			APIVersion: authorizationv1.SchemeGroupVersion.String(),
+			Kind:       "SelfSubjectAccessReview",
+		},
+		Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Verb:     verb,
+				Group:    gr.Group,
+				Resource: gr.Resource,
+			},
+		},
+	}
+	obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(ssar)
+	if err != nil {
+		return authorizationv1.SubjectAccessReviewStatus{}, errors.Wrap(err, "cannot convert SelfSubjectAccessReview")
+	}
+	res, err := c.dynamicClient.Resource(selfSubjectAccessReviewsGVR).Create(ctx, &unstructured.Unstructured{Object: obj}, v1.CreateOptions{})
+	if err != nil {
+		return authorizationv1.SubjectAccessReviewStatus{}, errors.Wrap(err, "cannot create SelfSubjectAccessReview")
+	}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(res.Object, ssar); err != nil {
+		return authorizationv1.SubjectAccessReviewStatus{}, errors.Wrap(err, "cannot convert SelfSubjectAccessReview")
+	}
+	return ssar.Status, nil
+}
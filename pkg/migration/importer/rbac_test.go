@@ -0,0 +1,127 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package importer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+)
+
+// reviewAccess makes the fake client answer SelfSubjectAccessReviews in the
+// status of the created review, like the API server. Verbs listed in denied
+// for a resource are denied, all others are allowed.
+func reviewAccess(dyn *fake.FakeDynamicClient, denied map[string][]string) {
+	dyn.PrependReactor("create", "selfsubjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		u := action.(k8stesting.CreateAction).GetObject().(*unstructured.Unstructured).DeepCopy()
+		verb, _, _ := unstructured.NestedString(u.Object, "spec", "resourceAttributes", "verb")
+		group, _, _ := unstructured.NestedString(u.Object, "spec", "resourceAttributes", "group")
+		resource, _, _ := unstructured.NestedString(u.Object, "spec", "resourceAttributes", "resource")
+		allowed := true
+		for _, v := range denied[schema.GroupResource{Group: group, Resource: resource}.String()] {
+			if v == verb {
+				allowed = false
+			}
+		}
+		_ = unstructured.SetNestedField(u.Object, allowed, "status", "allowed")
+		return true, u, nil
+	})
+}
+
+// isAccessReview returns true if the action reviews access, which does not
+// change the state of the control plane.
+func isAccessReview(action k8stesting.Action) bool {
+	return action.GetVerb() == "create" && action.GetResource() == selfSubjectAccessReviewsGVR
+}
+
+func TestImportRBACCheckerCheck(t *testing.T) {
+	errBoom := errors.New("boom")
+	buckets := schema.GroupResource{Group: "s3.aws.upbound.io", Resource: "buckets"}
+	configmaps := schema.GroupResource{Resource: "configmaps"}
+	secrets := schema.GroupResource{Resource: "secrets"}
+
+	type args struct {
+		grs    []schema.GroupResource
+		denied map[string][]string
+		err    error
+	}
+	type want struct {
+		errs []error
+	}
+
+	cases := map[string]struct {
+		args args
+		want want
+	}{
+		"AllAllowed": {
+			args: args{
+				grs: []schema.GroupResource{buckets, configmaps},
+			},
+			want: want{},
+		},
+		"MissingPermissions": {
+			args: args{
+				grs: []schema.GroupResource{buckets, configmaps, secrets},
+				denied: map[string][]string{
+					"buckets.s3.aws.upbound.io": {"create", "patch"},
+					"secrets":                   {"patch"},
+				},
+			},
+			want: want{
+				errs: []error{
+					errors.New(`Missing permissions to create, patch "buckets.s3.aws.upbound.io" in all namespaces`),
+					errors.New(`Missing permissions to patch "secrets" in all namespaces`),
+				},
+			},
+		},
+		"ReviewFailed": {
+			args: args{
+				grs: []schema.GroupResource{configmaps},
+				err: errBoom,
+			},
+			want: want{
+				errs: []error{
+					errors.Wrap(errors.Wrap(errBoom, "cannot create SelfSubjectAccessReview"), `Cannot review permission to create "configmaps"`),
+					errors.Wrap(errors.Wrap(errBoom, "cannot create SelfSubjectAccessReview"), `Cannot review permission to patch "configmaps"`),
+				},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			dyn := fake.NewSimpleDynamicClient(runtime.NewScheme())
+			reviewAccess(dyn, tc.args.denied)
+			if tc.args.err != nil {
+				dyn.PrependReactor("create", "selfsubjectaccessreviews", func(_ k8stesting.Action) (bool, runtime.Object, error) {
+					return true, nil, tc.args.err
+				})
+			}
+
+			errs := NewImportRBACChecker(dyn).Check(context.Background(), tc.args.grs)
+			if diff := cmp.Diff(tc.want.errs, errs, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nCheck(...): -want, +got:\n%s", name, diff)
+			}
+		})
+	}
+}
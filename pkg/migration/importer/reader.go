@@ -17,9 +17,13 @@ package importer
 import (
 	"io/fs"
 	"os"
+	"path"
+	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 
+	"github.com/pterm/pterm"
 	"github.com/spf13/afero"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"sigs.k8s.io/yaml"
@@ -29,6 +33,9 @@ import (
 	"github.com/crossplane/crossplane-runtime/pkg/errors"
 )
 
+// resourceOrderFile is the order manifest of a group resource.
+const resourceOrderFile = "order.yaml"
+
 const yamlPathPattern = `^(cluster|namespaces\/[a-z0-9]([-a-z0-9]*[a-z0-9])?)\/[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*\.yaml$`
 
 var (
@@ -54,12 +61,20 @@ func NewFileSystemReader(fs afero.Afero) *FileSystemReader {
 }
 
 func (g *FileSystemReader) ReadResources(groupResource string) (resources []unstructured.Unstructured, meta *v1alpha1.TypeMeta, rErr error) {
+	if ok, _ := g.fs.Exists(filepath.Join(groupResource, "manifest.yaml")); ok {
+		return g.readContentAddressable(groupResource)
+	}
+
 	rErr = g.fs.Walk(groupResource, func(path string, info fs.FileInfo, _ error) error {
 		if info == nil || info.IsDir() {
 			return nil
 		}
 
 		groupPath := strings.TrimPrefix(path, groupResource+string(os.PathSeparator))
+		if groupPath == resourceOrderFile {
+			// Read after all resources below.
+			return nil
+		}
 		if groupPath == "metadata.yaml" {
 			b, err := g.fs.ReadFile(path)
 			if err != nil {
@@ -86,6 +101,10 @@ func (g *FileSystemReader) ReadResources(groupResource string) (resources []unst
 			return errors.Wrapf(err, "cannot unmarshal file %q", path)
 		}
 
+		if g.shouldSkip(r) {
+			return nil
+		}
+
 		resources = append(resources, r)
 		return nil
 	})
@@ -93,5 +112,107 @@ func (g *FileSystemReader) ReadResources(groupResource string) (resources []unst
 		return nil, nil, errors.Wrapf(rErr, "cannot walk directory for resource group %q", groupResource)
 	}
 
-	return resources, meta, nil
+	resources, rErr = g.ordered(groupResource, resources)
+	return resources, meta, rErr
+}
+
+func (g *FileSystemReader) readContentAddressable(groupResource string) ([]unstructured.Unstructured, *v1alpha1.TypeMeta, error) {
+	var meta *v1alpha1.TypeMeta
+	mp := filepath.Join(groupResource, "metadata.yaml")
+	if ok, _ := g.fs.Exists(mp); ok {
+		b, err := g.fs.ReadFile(mp)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "cannot read file %q", mp)
+		}
+		meta = &v1alpha1.TypeMeta{}
+		if err := yaml.Unmarshal(b, meta); err != nil {
+			return nil, nil, errors.Wrapf(err, "cannot unmarshal metadata file %q", mp)
+		}
+	}
+
+	mf := filepath.Join(groupResource, "manifest.yaml")
+	b, err := g.fs.ReadFile(mf)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "cannot read manifest %q", mf)
+	}
+	manifest := &v1alpha1.ContentManifest{}
+	if err := yaml.Unmarshal(b, manifest); err != nil {
+		return nil, nil, errors.Wrapf(err, "cannot unmarshal manifest %q", mf)
+	}
+
+	ids := make([]string, 0, len(manifest.Resources))
+	for id := range manifest.Resources {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	resources := make([]unstructured.Unstructured, 0, len(ids))
+	for _, id := range ids {
+		p := filepath.Join(groupResource, "objects", manifest.Resources[id]+".yaml")
+		b, err := g.fs.ReadFile(p)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "cannot read file %q for resource %q", p, id)
+		}
+		var r unstructured.Unstructured
+		if err := yaml.Unmarshal(b, &r); err != nil {
+			return nil, nil, errors.Wrapf(err, "cannot unmarshal file %q", p)
+		}
+		if g.shouldSkip(r) {
+			continue
+		}
+		resources = append(resources, r)
+	}
+
+	resources, err = g.ordered(groupResource, resources)
+	return resources, meta, err
+}
+
+// ordered returns the resources in the order of the order manifest of the
+// group resource, if it has one. Resources not in the manifest keep their
+// order after the ones in it.
+func (g *FileSystemReader) ordered(groupResource string, resources []unstructured.Unstructured) ([]unstructured.Unstructured, error) {
+	of := filepath.Join(groupResource, resourceOrderFile)
+	if ok, _ := g.fs.Exists(of); !ok {
+		return resources, nil
+	}
+	b, err := g.fs.ReadFile(of)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot read order manifest %q", of)
+	}
+	order := &v1alpha1.ResourceOrder{}
+	if err := yaml.Unmarshal(b, order); err != nil {
+		return nil, errors.Wrapf(err, "cannot unmarshal order manifest %q", of)
+	}
+
+	rank := make(map[string]int, len(order.Resources))
+	for i, id := range order.Resources {
+		if _, ok := rank[id]; !ok {
+			rank[id] = i
+		}
+	}
+	position := func(u unstructured.Unstructured) int {
+		id := path.Join("cluster", u.GetName())
+		if u.GetNamespace() != "" {
+			id = path.Join("namespaces", u.GetNamespace(), u.GetName())
+		}
+		if r, ok := rank[id]; ok {
+			return r
+		}
+		return len(order.Resources)
+	}
+	sort.SliceStable(resources, func(i, j int) bool {
+		return position(resources[i]) < position(resources[j])
+	})
+	return resources, nil
+}
+
+func (g *FileSystemReader) shouldSkip(r unstructured.Unstructured) bool {
+	if r.GetDeletionTimestamp() != nil && !r.GetDeletionTimestamp().IsZero() {
+		// The resource was being deleted at the time of the export, so there
+		// is no point in importing it.
+		pterm.Warning.Printfln("Skipping %s/%s as it was terminating at the time of the export", r.GetKind(), r.GetName())
+		return true
+	}
+
+	return false
 }
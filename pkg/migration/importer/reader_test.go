@@ -0,0 +1,135 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package importer
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/afero"
+)
+
+func TestFileSystemReaderReadResources(t *testing.T) {
+	type args struct {
+		files map[string]string
+	}
+	type want struct {
+		names []string
+	}
+
+	cases := map[string]struct {
+		args args
+		want want
+	}{
+		"ReadAll": {
+			args: args{
+				files: map[string]string{
+					"things.example.org/cluster/a.yaml": `
+apiVersion: example.org/v1
+kind: Thing
+metadata:
+  name: a
+`,
+					"things.example.org/cluster/b.yaml": `
+apiVersion: example.org/v1
+kind: Thing
+metadata:
+  name: b
+`,
+				},
+			},
+			want: want{
+				names: []string{"a", "b"},
+			},
+		},
+		"SkipTerminating": {
+			args: args{
+				files: map[string]string{
+					"things.example.org/cluster/a.yaml": `
+apiVersion: example.org/v1
+kind: Thing
+metadata:
+  name: a
+`,
+					"things.example.org/cluster/b.yaml": `
+apiVersion: example.org/v1
+kind: Thing
+metadata:
+  name: b
+  deletionTimestamp: "2024-01-01T00:00:00Z"
+`,
+				},
+			},
+			want: want{
+				names: []string{"a"},
+			},
+		},
+		"OrderManifest": {
+			args: args{
+				files: map[string]string{
+					"things.example.org/cluster/a.yaml": `
+apiVersion: example.org/v1
+kind: Thing
+metadata:
+  name: a
+`,
+					"things.example.org/cluster/b.yaml": `
+apiVersion: example.org/v1
+kind: Thing
+metadata:
+  name: b
+`,
+					"things.example.org/cluster/c.yaml": `
+apiVersion: example.org/v1
+kind: Thing
+metadata:
+  name: c
+`,
+					"things.example.org/order.yaml": `
+resources:
+- cluster/c
+- cluster/a
+`,
+				},
+			},
+			want: want{
+				names: []string{"c", "a", "b"},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			fs := afero.Afero{Fs: afero.NewMemMapFs()}
+			for f, c := range tc.args.files {
+				if err := fs.WriteFile(f, []byte(c), 0600); err != nil {
+					t.Fatalf("cannot write file %q: %v", f, err)
+				}
+			}
+
+			r := NewFileSystemReader(fs)
+			got, _, err := r.ReadResources("things.example.org")
+			if err != nil {
+				t.Fatalf("ReadResources() unexpected error: %v", err)
+			}
+			names := make([]string, 0, len(got))
+			for _, u := range got {
+				names = append(names, u.GetName())
+			}
+			if diff := cmp.Diff(tc.want.names, names); diff != "" {
+				t.Errorf("ReadResources() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
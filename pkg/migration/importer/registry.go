@@ -0,0 +1,222 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package importer
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/utils/ptr"
+
+	"github.com/upbound/up/pkg/migration/crossplane"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
+)
+
+// DefaultRegistryCheckImage is the image of the Jobs checking whether
+// registries are reachable. It must contain curl as entrypoint.
+const DefaultRegistryCheckImage = "curlimages/curl:8.6.0"
+
+var jobsGVR = batchv1.SchemeGroupVersion.WithResource("jobs")
+
+// JobRunner runs a Job in the target cluster until it completes.
+type JobRunner interface {
+	// RunJob creates the Job, waits for it to finish and cleans it up. It
+	// returns whether the Job succeeded.
+	RunJob(ctx context.Context, job *batchv1.Job) (bool, error)
+}
+
+// DynamicJobRunner runs Jobs using a dynamic client.
+type DynamicJobRunner struct {
+	dynamicClient dynamic.Interface
+	pollInterval  time.Duration
+	timeout       time.Duration
+}
+
+// NewDynamicJobRunner returns a new DynamicJobRunner.
+func NewDynamicJobRunner(dynamicClient dynamic.Interface) *DynamicJobRunner {
+	return &DynamicJobRunner{
+		dynamicClient: dynamicClient,
+		pollInterval:  2 * time.Second,
+		timeout:       2 * time.Minute,
+	}
+}
+
+// RunJob creates the Job, waits for it to finish and deletes it afterwards.
+func (r *DynamicJobRunner) RunJob(ctx context.Context, job *batchv1.Job) (bool, error) {
+	job.SetGroupVersionKind(batchv1.SchemeGroupVersion.WithKind("Job"))
+	o, err := runtime.DefaultUnstructuredConverter.ToUnstructured(job)
+	if err != nil {
+		return false, errors.Wrap(err, "cannot convert job to unstructured")
+	}
+
+	jobs := r.dynamicClient.Resource(jobsGVR).Namespace(job.GetNamespace())
+	created, err := jobs.Create(ctx, &unstructured.Unstructured{Object: o}, v1.CreateOptions{})
+	if err != nil {
+		return false, errors.Wrap(err, "cannot create job")
+	}
+	defer func() {
+		// Use a fresh context so that we still clean up if ctx is done.
+		_ = jobs.Delete(context.Background(), created.GetName(), v1.DeleteOptions{PropagationPolicy: ptr.To(v1.DeletePropagationBackground)})
+	}()
+
+	succeeded := false
+	err = wait.PollUntilContextTimeout(ctx, r.pollInterval, r.timeout, true, func(ctx context.Context) (bool, error) {
+		u, err := jobs.Get(ctx, created.GetName(), v1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		paved := fieldpath.Pave(u.Object)
+		if s, _ := paved.GetInteger("status.succeeded"); s > 0 {
+			succeeded = true
+			return true, nil
+		}
+		if f, _ := paved.GetInteger("status.failed"); f > 0 {
+			return true, nil
+		}
+		return false, nil
+	})
+	if err != nil {
+		return false, errors.Wrap(err, "cannot wait for job to complete")
+	}
+	return succeeded, nil
+}
+
+// RegistryReachabilityCheck checks that the registries of package images are
+// reachable from within the target cluster. Only network reachability is
+// checked, the registries are not authenticated against, e.g. with the
+// package pull secrets.
+type RegistryReachabilityCheck struct {
+	runner          JobRunner
+	namespace       string
+	image           string
+	defaultRegistry string
+}
+
+// RegistryCheckOption configures a RegistryReachabilityCheck.
+type RegistryCheckOption func(*RegistryReachabilityCheck)
+
+// WithCheckImage configures the image of the Jobs checking the registries,
+// e.g. a mirror of DefaultRegistryCheckImage in air-gapped environments.
+func WithCheckImage(image string) RegistryCheckOption {
+	return func(c *RegistryReachabilityCheck) {
+		c.image = image
+	}
+}
+
+// WithDefaultRegistry configures the registry of package images without a
+// registry, i.e. the default registry of the target Crossplane.
+func WithDefaultRegistry(registry string) RegistryCheckOption {
+	return func(c *RegistryReachabilityCheck) {
+		c.defaultRegistry = registry
+	}
+}
+
+// NewRegistryReachabilityCheck returns a new RegistryReachabilityCheck that
+// runs its Jobs in the given namespace.
+func NewRegistryReachabilityCheck(runner JobRunner, namespace string, opts ...RegistryCheckOption) *RegistryReachabilityCheck {
+	c := &RegistryReachabilityCheck{
+		runner:          runner,
+		namespace:       namespace,
+		image:           DefaultRegistryCheckImage,
+		defaultRegistry: crossplane.DefaultRegistry,
+	}
+	for _, o := range opts {
+		o(c)
+	}
+	return c
+}
+
+// Check runs a Job per registry referenced by the given package images, which
+// sends a HEAD request to the registry API from within the cluster. Any HTTP
+// response, including 401 Unauthorized, means the registry is reachable; a
+// network or TLS error means it is not.
+func (c *RegistryReachabilityCheck) Check(ctx context.Context, images []string) []error {
+	registries := make(map[string]struct{}, len(images))
+	for _, img := range images {
+		registries[registryOf(img, c.defaultRegistry)] = struct{}{}
+	}
+	sorted := make([]string, 0, len(registries))
+	for r := range registries {
+		sorted = append(sorted, r)
+	}
+	sort.Strings(sorted)
+
+	var errs []error
+	for _, r := range sorted {
+		ok, err := c.runner.RunJob(ctx, c.jobFor(r))
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "Cannot check whether registry %q is reachable from the target control plane", r))
+			continue
+		}
+		if !ok {
+			errs = append(errs, errors.Errorf("Registry %q is not reachable from the target control plane", r))
+		}
+	}
+	return errs
+}
+
+func (c *RegistryReachabilityCheck) jobFor(registry string) *batchv1.Job {
+	return &batchv1.Job{
+		ObjectMeta: v1.ObjectMeta{
+			GenerateName: "up-registry-check-",
+			Namespace:    c.namespace,
+			Labels: map[string]string{
+				"app.kubernetes.io/managed-by": "up",
+			},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: ptr.To[int32](0),
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyNever,
+					Containers: []corev1.Container{
+						{
+							Name:  "check",
+							Image: c.image,
+							Args:  []string{"--silent", "--head", "--output", "/dev/null", "--max-time", "30", fmt.Sprintf("https://%s/v2/", registry)},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// registryOf returns the registry host of a package reference, following the
+// same defaulting rules as Crossplane, which pulls packages without a registry
+// from defaultRegistry.
+func registryOf(image, defaultRegistry string) string {
+	i := strings.IndexRune(image, '/')
+	if i < 0 {
+		return defaultRegistry
+	}
+	host := image[:i]
+	if !strings.ContainsAny(host, ".:") && host != "localhost" {
+		return defaultRegistry
+	}
+	return host
+}
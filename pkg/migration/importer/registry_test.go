@@ -0,0 +1,125 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package importer
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	batchv1 "k8s.io/api/batch/v1"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+)
+
+type mockJobRunner struct {
+	// reachable maps registries to whether they are reachable.
+	reachable map[string]bool
+	err       error
+}
+
+func (m *mockJobRunner) RunJob(_ context.Context, job *batchv1.Job) (bool, error) {
+	if m.err != nil {
+		return false, m.err
+	}
+	args := job.Spec.Template.Spec.Containers[0].Args
+	u := args[len(args)-1]
+	r := strings.TrimSuffix(strings.TrimPrefix(u, "https://"), "/v2/")
+	return m.reachable[r], nil
+}
+
+func TestRegistryReachabilityCheck(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	type args struct {
+		runner JobRunner
+		images []string
+		opts   []RegistryCheckOption
+	}
+	type want struct {
+		errs []error
+	}
+
+	cases := map[string]struct {
+		args args
+		want want
+	}{
+		"AllReachable": {
+			args: args{
+				runner: &mockJobRunner{reachable: map[string]bool{
+					"xpkg.upbound.io": true,
+				}},
+				images: []string{
+					"xpkg.upbound.io/upbound/provider-aws-s3:v1.0.0",
+					"xpkg.upbound.io/upbound/provider-aws-ec2:v1.0.0",
+					"crossplane/provider-helm:v0.15.0",
+				},
+			},
+			want: want{},
+		},
+		"Unreachable": {
+			args: args{
+				runner: &mockJobRunner{reachable: map[string]bool{
+					"xpkg.upbound.io": true,
+				}},
+				images: []string{
+					"xpkg.upbound.io/upbound/provider-aws-s3:v1.0.0",
+					"registry.example.org:5000/provider-internal:v1",
+				},
+			},
+			want: want{
+				errs: []error{
+					errors.New(`Registry "registry.example.org:5000" is not reachable from the target control plane`),
+				},
+			},
+		},
+		"CustomDefaultRegistry": {
+			args: args{
+				runner: &mockJobRunner{reachable: map[string]bool{
+					"xpkg.upbound.io": true,
+				}},
+				images: []string{"crossplane/provider-helm:v0.15.0"},
+				opts:   []RegistryCheckOption{WithDefaultRegistry("registry.example.org")},
+			},
+			want: want{
+				errs: []error{
+					errors.New(`Registry "registry.example.org" is not reachable from the target control plane`),
+				},
+			},
+		},
+		"JobError": {
+			args: args{
+				runner: &mockJobRunner{err: errBoom},
+				images: []string{"xpkg.upbound.io/upbound/provider-aws-s3:v1.0.0"},
+			},
+			want: want{
+				errs: []error{
+					errors.Wrap(errBoom, `Cannot check whether registry "xpkg.upbound.io" is reachable from the target control plane`),
+				},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			c := NewRegistryReachabilityCheck(tc.args.runner, "crossplane-system", tc.args.opts...)
+			errs := c.Check(context.Background(), tc.args.images)
+			if diff := cmp.Diff(tc.want.errs, errs, test.EquateErrors()); diff != "" {
+				t.Errorf("Check() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package importer
+
+import (
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+)
+
+// AmbiguousGVRResolution picks one of several GVRs matching the same group
+// resource in different API groups. Candidates are ordered by preference of
+// the resource mapper and contain one GVR per API group.
+type AmbiguousGVRResolution func(gr schema.GroupResource, candidates []schema.GroupVersionResource) (schema.GroupVersionResource, error)
+
+// UseFirst resolves ambiguous GVRs to the one preferred by the resource
+// mapper.
+func UseFirst() AmbiguousGVRResolution {
+	return func(_ schema.GroupResource, candidates []schema.GroupVersionResource) (schema.GroupVersionResource, error) {
+		return candidates[0], nil
+	}
+}
+
+// UseLast resolves ambiguous GVRs to the one least preferred by the resource
+// mapper.
+func UseLast() AmbiguousGVRResolution {
+	return func(_ schema.GroupResource, candidates []schema.GroupVersionResource) (schema.GroupVersionResource, error) {
+		return candidates[len(candidates)-1], nil
+	}
+}
+
+// UseGroup resolves ambiguous GVRs to the one in the supplied API group.
+func UseGroup(group string) AmbiguousGVRResolution {
+	return func(gr schema.GroupResource, candidates []schema.GroupVersionResource) (schema.GroupVersionResource, error) {
+		for _, c := range candidates {
+			if c.Group == group {
+				return c, nil
+			}
+		}
+		return schema.GroupVersionResource{}, errors.Errorf("none of the types matching %q is in group %q: %s", gr, group, groupResources(candidates))
+	}
+}
+
+// Error refuses to resolve ambiguous GVRs.
+func Error() AmbiguousGVRResolution {
+	return func(gr schema.GroupResource, candidates []schema.GroupVersionResource) (schema.GroupVersionResource, error) {
+		return schema.GroupVersionResource{}, errors.Errorf("%q is ambiguous, it matches %s", gr, groupResources(candidates))
+	}
+}
+
+// GVRResolver resolves group resources to GVRs, resolving group resources
+// matching types in several API groups with an AmbiguousGVRResolution.
+type GVRResolver struct {
+	mapper     meta.RESTMapper
+	resolution AmbiguousGVRResolution
+}
+
+// NewGVRResolver returns a new GVRResolver.
+func NewGVRResolver(mapper meta.RESTMapper, resolution AmbiguousGVRResolution) *GVRResolver {
+	return &GVRResolver{
+		mapper:     mapper,
+		resolution: resolution,
+	}
+}
+
+// ResourceFor returns the GVR of the supplied group resource.
+func (r *GVRResolver) ResourceFor(groupResource string) (schema.GroupVersionResource, error) {
+	gr := schema.ParseGroupResource(groupResource)
+	gvrs, err := r.mapper.ResourcesFor(gr.WithVersion(""))
+	if err != nil {
+		return schema.GroupVersionResource{}, errors.Wrapf(err, "cannot get GVR for %q", groupResource)
+	}
+
+	// Keep the preferred version of every matching API group.
+	candidates := make([]schema.GroupVersionResource, 0, len(gvrs))
+	seen := make(map[schema.GroupResource]bool, len(gvrs))
+	for _, gvr := range gvrs {
+		if seen[gvr.GroupResource()] {
+			continue
+		}
+		seen[gvr.GroupResource()] = true
+		candidates = append(candidates, gvr)
+	}
+
+	if len(candidates) == 0 {
+		return schema.GroupVersionResource{}, errors.Errorf("no GVR found for %q", groupResource)
+	}
+	if len(candidates) == 1 || r.resolution == nil {
+		return candidates[0], nil
+	}
+	return r.resolution(gr, candidates)
+}
+
+func groupResources(gvrs []schema.GroupVersionResource) string {
+	s := make([]string, len(gvrs))
+	for i, gvr := range gvrs {
+		s[i] = gvr.GroupResource().String()
+	}
+	return strings.Join(s, ", ")
+}
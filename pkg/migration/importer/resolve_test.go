@@ -0,0 +1,114 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package importer
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+)
+
+func TestGVRResolverResourceFor(t *testing.T) {
+	pkgLocks := schema.GroupVersion{Group: "pkg.crossplane.io", Version: "v1beta1"}
+	customLocks := schema.GroupVersion{Group: "custom.example.com", Version: "v1"}
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{pkgLocks, customLocks})
+	mapper.Add(pkgLocks.WithKind("Lock"), meta.RESTScopeRoot)
+	mapper.Add(customLocks.WithKind("Lock"), meta.RESTScopeRoot)
+
+	type args struct {
+		resolution    AmbiguousGVRResolution
+		groupResource string
+	}
+	type want struct {
+		gvr schema.GroupVersionResource
+		err error
+	}
+
+	cases := map[string]struct {
+		args args
+		want want
+	}{
+		"UseFirst": {
+			args: args{
+				resolution:    UseFirst(),
+				groupResource: "locks",
+			},
+			want: want{
+				gvr: pkgLocks.WithResource("locks"),
+			},
+		},
+		"UseLast": {
+			args: args{
+				resolution:    UseLast(),
+				groupResource: "locks",
+			},
+			want: want{
+				gvr: customLocks.WithResource("locks"),
+			},
+		},
+		"UseGroup": {
+			args: args{
+				resolution:    UseGroup("custom.example.com"),
+				groupResource: "locks",
+			},
+			want: want{
+				gvr: customLocks.WithResource("locks"),
+			},
+		},
+		"UseUnknownGroup": {
+			args: args{
+				resolution:    UseGroup("other.example.com"),
+				groupResource: "locks",
+			},
+			want: want{
+				err: errors.New(`none of the types matching "locks" is in group "other.example.com": locks.pkg.crossplane.io, locks.custom.example.com`),
+			},
+		},
+		"Error": {
+			args: args{
+				resolution:    Error(),
+				groupResource: "locks",
+			},
+			want: want{
+				err: errors.New(`"locks" is ambiguous, it matches locks.pkg.crossplane.io, locks.custom.example.com`),
+			},
+		},
+		"Unambiguous": {
+			args: args{
+				resolution:    Error(),
+				groupResource: "locks.custom.example.com",
+			},
+			want: want{
+				gvr: customLocks.WithResource("locks"),
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			gvr, err := NewGVRResolver(mapper, tc.args.resolution).ResourceFor(tc.args.groupResource)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nResourceFor(...): -want err, +got err:\n%s", name, diff)
+			}
+			if diff := cmp.Diff(tc.want.gvr, gvr); diff != "" {
+				t.Errorf("\n%s\nResourceFor(...): -want, +got:\n%s", name, diff)
+			}
+		})
+	}
+}
@@ -17,6 +17,15 @@ package importer
 import (
 	"context"
 
+	"github.com/pterm/pterm"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/upbound/up/pkg/migration/category"
+	"github.com/upbound/up/pkg/migration/meta/v1alpha1"
+	"github.com/upbound/up/pkg/migration/telemetry"
+	"github.com/upbound/up/pkg/migration/transform"
+
 	"github.com/crossplane/crossplane-runtime/pkg/errors"
 	"github.com/crossplane/crossplane-runtime/pkg/meta"
 )
@@ -26,15 +35,91 @@ type ResourceImporter interface {
 }
 
 type PausingResourceImporter struct {
-	reader  ResourceReader
-	applier ResourceApplier
+	reader       ResourceReader
+	applier      ResourceApplier
+	transformers []transform.ResourceTransformer
+	resolver     *GVRResolver
+	preserveUIDs bool
+	// preserveResourceVersions keeps the resourceVersions of exported
+	// resources, which are removed otherwise.
+	preserveResourceVersions bool
+	// skipRedactedSecrets skips Secrets whose data was redacted during the
+	// export instead of importing them without data.
+	skipRedactedSecrets bool
+	// namespaceMapping maps exported namespaces to the namespaces they are
+	// imported into.
+	namespaceMapping map[string]string
 }
 
-func NewPausingResourceImporter(r ResourceReader, a ResourceApplier) *PausingResourceImporter {
-	return &PausingResourceImporter{
+// PausingResourceImporterOption configures a PausingResourceImporter.
+type PausingResourceImporterOption func(*PausingResourceImporter)
+
+// WithResourceTransformers transforms resources with the supplied transformers
+// before they are applied.
+func WithResourceTransformers(t ...transform.ResourceTransformer) PausingResourceImporterOption {
+	return func(im *PausingResourceImporter) {
+		im.transformers = append(im.transformers, t...)
+	}
+}
+
+// WithGVRResolver resolves the API group of imported resources with the
+// supplied resolver, moving resources to the resolved API group if it differs
+// from the exported one.
+func WithGVRResolver(r *GVRResolver) PausingResourceImporterOption {
+	return func(im *PausingResourceImporter) {
+		im.resolver = r
+	}
+}
+
+// WithPreservedUIDs keeps the UIDs of exported resources when they are
+// applied. The API server assigns new UIDs to created resources regardless,
+// so a UID only acts as a precondition when re-applying a resource to the
+// cluster it was exported from: applying it fails if the existing resource
+// has a different UID. UIDs are removed otherwise.
+func WithPreservedUIDs() PausingResourceImporterOption {
+	return func(im *PausingResourceImporter) {
+		im.preserveUIDs = true
+	}
+}
+
+// WithPreservedResourceVersions keeps the resourceVersions of exported
+// resources when they are applied, so that applying a resource fails if it
+// changed since it was exported. This is only meaningful if the target
+// cluster shares the etcd of the exported one, e.g. when restoring resources
+// into the same cluster. ResourceVersions are removed otherwise, as they refer
+// to the etcd revisions of the exported cluster.
+func WithPreservedResourceVersions() PausingResourceImporterOption {
+	return func(im *PausingResourceImporter) {
+		im.preserveResourceVersions = true
+	}
+}
+
+// WithNamespaceMapping imports the resources of the exported namespaces that
+// are keys of the mapping into the namespaces they are mapped to. Exported
+// Namespaces are renamed accordingly.
+func WithNamespaceMapping(m map[string]string) PausingResourceImporterOption {
+	return func(im *PausingResourceImporter) {
+		im.namespaceMapping = m
+	}
+}
+
+// WithSkippedRedactedSecrets skips Secrets whose data was redacted during the
+// export. They are imported without data otherwise.
+func WithSkippedRedactedSecrets() PausingResourceImporterOption {
+	return func(im *PausingResourceImporter) {
+		im.skipRedactedSecrets = true
+	}
+}
+
+func NewPausingResourceImporter(r ResourceReader, a ResourceApplier, opts ...PausingResourceImporterOption) *PausingResourceImporter {
+	im := &PausingResourceImporter{
 		reader:  r,
 		applier: a,
 	}
+	for _, o := range opts {
+		o(im)
+	}
+	return im
 }
 
 func (im *PausingResourceImporter) ImportResources(ctx context.Context, gr string, restoreStatus bool) (int, error) {
@@ -42,15 +127,30 @@ func (im *PausingResourceImporter) ImportResources(ctx context.Context, gr strin
 	if err != nil {
 		return 0, errors.Wrapf(err, "cannot get %q resources", gr)
 	}
+	resources = withoutSkipped(gr, resources)
+	resources = withoutRedactedData(gr, resources, im.skipRedactedSecrets)
+
+	if im.resolver != nil && len(resources) > 0 {
+		gvr, err := im.resolver.ResourceFor(gr)
+		if err != nil {
+			return 0, errors.Wrapf(err, "cannot resolve %q", gr)
+		}
+		for i := range resources {
+			if resources[i].GroupVersionKind().Group != gvr.Group {
+				resources[i].SetAPIVersion(gvr.GroupVersion().String())
+			}
+		}
+	}
 
 	hasSubresource := false
 	if typeMeta != nil {
 		hasSubresource = typeMeta.WithStatusSubresource
 		for _, c := range typeMeta.Categories {
-			// We pause all resources that are managed, claim, or composite.
+			// We pause all resources that are managed, claim, composite, or observed.
 			// - Claim/Composite: We don't want Crossplane controllers to create new resources before we import all.
 			// - Managed: Same reason as above, but also don't want to take control of cloud resources yet.
-			if c == "managed" || c == "claim" || c == "composite" {
+			// - Observed: The intermediate state of Composition Functions must not be acted on before its composites are imported.
+			if c == "managed" || c == "claim" || c == "composite" || c == category.CategoryObserved {
 				for i := range resources {
 					meta.AddAnnotations(&resources[i], map[string]string{
 						"crossplane.io/paused": "true",
@@ -61,9 +161,82 @@ func (im *PausingResourceImporter) ImportResources(ctx context.Context, gr strin
 		}
 	}
 
-	if err = im.applier.ApplyResources(ctx, resources, restoreStatus && hasSubresource); err != nil {
+	for i := range resources {
+		if !im.preserveUIDs {
+			resources[i].SetUID("")
+		}
+		if !im.preserveResourceVersions {
+			resources[i].SetResourceVersion("")
+		}
+		im.mapNamespace(&resources[i])
+		for _, t := range im.transformers {
+			if err := t.Transform(&resources[i]); err != nil {
+				return 0, errors.Wrapf(err, "cannot transform %q resource %q", gr, resources[i].GetName())
+			}
+		}
+	}
+
+	actx, span := telemetry.StartSpan(ctx, "ApplyResources", telemetry.GroupResourceKey.String(gr))
+	err = im.applier.ApplyResources(actx, resources, restoreStatus && hasSubresource && !isPackageResource(gr))
+	telemetry.EndSpan(span, err)
+	if err != nil {
 		return 0, errors.Wrapf(err, "cannot apply %q resources", gr)
 	}
 
 	return len(resources), nil
 }
+
+// isPackageResource returns whether gr is a type of the package manager,
+// whose status is reported by the package manager of the target control plane
+// rather than restored from the export.
+func isPackageResource(gr string) bool {
+	return schema.ParseGroupResource(gr).Group == "pkg.crossplane.io"
+}
+
+// mapNamespace moves the resource to the namespace its exported namespace is
+// mapped to, or renames it if it is a mapped Namespace.
+func (im *PausingResourceImporter) mapNamespace(u *unstructured.Unstructured) {
+	if ns, ok := im.namespaceMapping[u.GetNamespace()]; ok && u.GetNamespace() != "" {
+		u.SetNamespace(ns)
+	}
+	if u.GetNamespace() == "" && u.GetKind() == "Namespace" && u.GroupVersionKind().Group == "" {
+		if ns, ok := im.namespaceMapping[u.GetName()]; ok {
+			u.SetName(ns)
+		}
+	}
+}
+
+// withoutSkipped returns the resources that are not annotated as not to be
+// migrated.
+func withoutSkipped(gr string, resources []unstructured.Unstructured) []unstructured.Unstructured {
+	kept := resources[:0]
+	for _, r := range resources {
+		if r.GetAnnotations()[v1alpha1.AnnotationSkip] == "true" {
+			pterm.Info.Printfln("Skipping %s %q as it is annotated with %s", gr, namespacedName(r.GetNamespace(), r.GetName()), v1alpha1.AnnotationSkip)
+			continue
+		}
+		kept = append(kept, r)
+	}
+	return kept
+}
+
+// withoutRedactedData returns the resources with the data of Secrets that were
+// redacted during the export removed, so that they are imported as
+// placeholders, or without them if skip is true.
+func withoutRedactedData(gr string, resources []unstructured.Unstructured, skip bool) []unstructured.Unstructured {
+	kept := resources[:0]
+	for _, r := range resources {
+		if r.GetAnnotations()[v1alpha1.AnnotationSecretsRedacted] != "true" {
+			kept = append(kept, r)
+			continue
+		}
+		if skip {
+			pterm.Warning.Printfln("Skipping %s %q as its data was redacted during the export", gr, namespacedName(r.GetNamespace(), r.GetName()))
+			continue
+		}
+		pterm.Warning.Printfln("Importing %s %q without data as its data was redacted during the export", gr, namespacedName(r.GetNamespace(), r.GetName()))
+		r.Object["data"] = map[string]any{}
+		kept = append(kept, r)
+	}
+	return kept
+}
@@ -0,0 +1,395 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package importer
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/upbound/up/pkg/migration/transform"
+)
+
+func TestPausingResourceImporterAPIVersionConversion(t *testing.T) {
+	networking := schema.GroupVersion{Group: "networking.k8s.io", Version: "v1"}
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{networking})
+	mapper.Add(networking.WithKind("Ingress"), meta.RESTScopeNamespace)
+
+	fs := exportedState(t, map[string]string{
+		"ingresses.extensions/namespaces/default/web.yaml": `apiVersion: extensions/v1beta1
+kind: Ingress
+metadata:
+  name: web
+  namespace: default
+`,
+	})
+
+	cases := map[string]struct {
+		opts []PausingResourceImporterOption
+		want []string
+		err  bool
+	}{
+		"Converted": {
+			opts: []PausingResourceImporterOption{WithResourceTransformers(transform.NewAPIVersionConverter(transform.DefaultConversionTable))},
+			want: []string{"networking.k8s.io/v1"},
+		},
+		"NotConverted": {
+			err: true,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			a := NewDryRunResourceApplier(mapper)
+			_, err := NewPausingResourceImporter(NewFileSystemReader(fs), a, tc.opts...).ImportResources(context.Background(), "ingresses.extensions", false)
+			if (err != nil) != tc.err {
+				t.Fatalf("ImportResources(...): error = %v, want error %t", err, tc.err)
+			}
+			var got []string
+			for _, u := range a.Applied {
+				got = append(got, u.GetAPIVersion())
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("ImportResources(...): applied API versions: -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestPausingResourceImporterPreservedUIDs(t *testing.T) {
+	core := schema.GroupVersion{Version: "v1"}
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{core})
+	mapper.Add(core.WithKind("ConfigMap"), meta.RESTScopeNamespace)
+
+	fs := exportedState(t, map[string]string{
+		"configmaps/namespaces/default/settings.yaml": `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+  namespace: default
+  uid: 0b7a1f3e-5c2d-4e8f-9a6b-1c3d5e7f9a0b
+`,
+	})
+
+	cases := map[string]struct {
+		opts []PausingResourceImporterOption
+		want []types.UID
+	}{
+		"Preserved": {
+			opts: []PausingResourceImporterOption{WithPreservedUIDs()},
+			want: []types.UID{"0b7a1f3e-5c2d-4e8f-9a6b-1c3d5e7f9a0b"},
+		},
+		"Removed": {
+			want: []types.UID{""},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			a := NewDryRunResourceApplier(mapper)
+			if _, err := NewPausingResourceImporter(NewFileSystemReader(fs), a, tc.opts...).ImportResources(context.Background(), "configmaps", false); err != nil {
+				t.Fatalf("ImportResources(...): unexpected error: %v", err)
+			}
+			var got []types.UID
+			for _, u := range a.Applied {
+				got = append(got, u.GetUID())
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("ImportResources(...): applied UIDs: -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestPausingResourceImporterPreservedResourceVersions(t *testing.T) {
+	core := schema.GroupVersion{Version: "v1"}
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{core})
+	mapper.Add(core.WithKind("ConfigMap"), meta.RESTScopeNamespace)
+
+	fs := exportedState(t, map[string]string{
+		"configmaps/namespaces/default/settings.yaml": `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+  namespace: default
+  resourceVersion: "4242"
+`,
+	})
+
+	cases := map[string]struct {
+		reason string
+		opts   []PausingResourceImporterOption
+		want   []string
+	}{
+		"Removed": {
+			reason: "ResourceVersions should be removed by default, as they refer to the etcd of the exported cluster.",
+			want:   []string{""},
+		},
+		"Preserved": {
+			reason: "ResourceVersions should be kept if requested.",
+			opts:   []PausingResourceImporterOption{WithPreservedResourceVersions()},
+			want:   []string{"4242"},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			a := NewDryRunResourceApplier(mapper)
+			if _, err := NewPausingResourceImporter(NewFileSystemReader(fs), a, tc.opts...).ImportResources(context.Background(), "configmaps", false); err != nil {
+				t.Fatalf("\n%s\nImportResources(...): unexpected error: %v", tc.reason, err)
+			}
+			var got []string
+			for _, u := range a.Applied {
+				got = append(got, u.GetResourceVersion())
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\nImportResources(...): applied resourceVersions: -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestPausingResourceImporterSkipAnnotation(t *testing.T) {
+	core := schema.GroupVersion{Version: "v1"}
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{core})
+	mapper.Add(core.WithKind("ConfigMap"), meta.RESTScopeNamespace)
+
+	fs := exportedState(t, map[string]string{
+		"configmaps/namespaces/default/settings.yaml": `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+  namespace: default
+`,
+		"configmaps/namespaces/default/scratch.yaml": `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: scratch
+  namespace: default
+  annotations:
+    migration.upbound.io/skip: "true"
+`,
+	})
+
+	a := NewDryRunResourceApplier(mapper)
+	n, err := NewPausingResourceImporter(NewFileSystemReader(fs), a).ImportResources(context.Background(), "configmaps", false)
+	if err != nil {
+		t.Fatalf("ImportResources(...): unexpected error: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("ImportResources(...): imported %d resources, want 1", n)
+	}
+	var got []string
+	for _, u := range a.Applied {
+		got = append(got, u.GetName())
+	}
+	if diff := cmp.Diff([]string{"settings"}, got); diff != "" {
+		t.Errorf("ImportResources(...): applied resources: -want, +got:\n%s", diff)
+	}
+}
+
+func TestPausingResourceImporterNamespaceMapping(t *testing.T) {
+	core := schema.GroupVersion{Version: "v1"}
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{core})
+	mapper.Add(core.WithKind("ConfigMap"), meta.RESTScopeNamespace)
+	mapper.Add(core.WithKind("Namespace"), meta.RESTScopeRoot)
+
+	configMap := func(ns string) string {
+		return fmt.Sprintf("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: settings\n  namespace: %s\n", ns)
+	}
+	namespace := func(name string) string {
+		return fmt.Sprintf("apiVersion: v1\nkind: Namespace\nmetadata:\n  name: %s\n", name)
+	}
+	fs := exportedState(t, map[string]string{
+		"configmaps/namespaces/team-a/settings.yaml":   configMap("team-a"),
+		"configmaps/namespaces/team-b/settings.yaml":   configMap("team-b"),
+		"configmaps/namespaces/platform/settings.yaml": configMap("platform"),
+		"namespaces/cluster/team-a.yaml":               namespace("team-a"),
+		"namespaces/cluster/team-b.yaml":               namespace("team-b"),
+		"namespaces/cluster/platform.yaml":             namespace("platform"),
+	})
+
+	type want struct {
+		configMaps []string
+		namespaces []string
+	}
+	cases := map[string]struct {
+		reason  string
+		mapping map[string]string
+		want    want
+	}{
+		"NoMapping": {
+			reason: "Resources should be imported into their exported namespaces without a mapping.",
+			want: want{
+				configMaps: []string{"platform/settings", "team-a/settings", "team-b/settings"},
+				namespaces: []string{"platform", "team-a", "team-b"},
+			},
+		},
+		"Mapped": {
+			reason:  "Resources of mapped namespaces should be imported into the namespaces they are mapped to.",
+			mapping: map[string]string{"team-a": "team-a-prod"},
+			want: want{
+				configMaps: []string{"platform/settings", "team-a-prod/settings", "team-b/settings"},
+				namespaces: []string{"platform", "team-a-prod", "team-b"},
+			},
+		},
+		"KeyAndValue": {
+			reason:  "A namespace that is both mapped and the target of a mapping should be moved once, and self-mappings should be no-ops.",
+			mapping: map[string]string{"team-a": "team-b", "team-b": "team-c", "platform": "platform"},
+			want: want{
+				configMaps: []string{"platform/settings", "team-b/settings", "team-c/settings"},
+				namespaces: []string{"platform", "team-b", "team-c"},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := want{}
+			for gr, names := range map[string]*[]string{"configmaps": &got.configMaps, "namespaces": &got.namespaces} {
+				a := NewDryRunResourceApplier(mapper)
+				if _, err := NewPausingResourceImporter(NewFileSystemReader(fs), a, WithNamespaceMapping(tc.mapping)).ImportResources(context.Background(), gr, false); err != nil {
+					t.Fatalf("\n%s\nImportResources(...): unexpected error: %v", tc.reason, err)
+				}
+				for _, u := range a.Applied {
+					*names = append(*names, path.Join(u.GetNamespace(), u.GetName()))
+				}
+				sort.Strings(*names)
+			}
+			if diff := cmp.Diff(tc.want, got, cmp.AllowUnexported(want{})); diff != "" {
+				t.Errorf("\n%s\nImportResources(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestPausingResourceImporterRedactedSecrets(t *testing.T) {
+	core := schema.GroupVersion{Version: "v1"}
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{core})
+	mapper.Add(core.WithKind("Secret"), meta.RESTScopeNamespace)
+
+	state := map[string]string{
+		"secrets/namespaces/default/plain.yaml": `apiVersion: v1
+kind: Secret
+metadata:
+  name: plain
+  namespace: default
+data:
+  key: dmFsdWU=
+`,
+		"secrets/namespaces/default/redacted.yaml": `apiVersion: v1
+kind: Secret
+metadata:
+  name: redacted
+  namespace: default
+  annotations:
+    migration.upbound.io/secrets-redacted: "true"
+data:
+  key: <redacted>
+`,
+	}
+
+	cases := map[string]struct {
+		reason string
+		opts   []PausingResourceImporterOption
+		want   map[string]any
+	}{
+		"Placeholder": {
+			reason: "Redacted secrets should be imported without data.",
+			want: map[string]any{
+				"plain":    map[string]any{"key": "dmFsdWU="},
+				"redacted": map[string]any{},
+			},
+		},
+		"Skip": {
+			reason: "Redacted secrets should not be imported if they are skipped.",
+			opts:   []PausingResourceImporterOption{WithSkippedRedactedSecrets()},
+			want: map[string]any{
+				"plain": map[string]any{"key": "dmFsdWU="},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			a := NewDryRunResourceApplier(mapper)
+			_, err := NewPausingResourceImporter(NewFileSystemReader(exportedState(t, state)), a, tc.opts...).ImportResources(context.Background(), "secrets", false)
+			if err != nil {
+				t.Fatalf("\n%s\nImportResources(...): unexpected error: %v", tc.reason, err)
+			}
+			got := map[string]any{}
+			for _, u := range a.Applied {
+				got[u.GetName()] = u.Object["data"]
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\nImportResources(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+// statusRecorder records whether the status of applied resources is restored.
+type statusRecorder struct {
+	applyStatus []bool
+}
+
+func (r *statusRecorder) ApplyResources(_ context.Context, _ []unstructured.Unstructured, applyStatus bool) error {
+	r.applyStatus = append(r.applyStatus, applyStatus)
+	return nil
+}
+
+func (r *statusRecorder) ModifyResources(_ context.Context, _ []unstructured.Unstructured, _ func(*unstructured.Unstructured) error) error {
+	return nil
+}
+
+func TestPausingResourceImporterRestoreStatus(t *testing.T) {
+	fs := exportedState(t, map[string]string{
+		"things.example.org/metadata.yaml":                   "withStatusSubresource: true\n",
+		"things.example.org/cluster/a.yaml":                  "apiVersion: example.org/v1\nkind: Thing\nmetadata:\n  name: a\nstatus:\n  ready: true\n",
+		"providerrevisions.pkg.crossplane.io/metadata.yaml":  "withStatusSubresource: true\n",
+		"providerrevisions.pkg.crossplane.io/cluster/a.yaml": "apiVersion: pkg.crossplane.io/v1\nkind: ProviderRevision\nmetadata:\n  name: a\nstatus:\n  foundDependencies: 1\n",
+	})
+
+	cases := map[string]struct {
+		reason string
+		gr     string
+		want   []bool
+	}{
+		"Restored": {
+			reason: "The status of types with a status subresource should be restored.",
+			gr:     "things.example.org",
+			want:   []bool{true},
+		},
+		"PackageManagerType": {
+			reason: "The status of package manager types should be left to the package manager of the target control plane.",
+			gr:     "providerrevisions.pkg.crossplane.io",
+			want:   []bool{false},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			r := &statusRecorder{}
+			if _, err := NewPausingResourceImporter(NewFileSystemReader(fs), r).ImportResources(context.Background(), tc.gr, true); err != nil {
+				t.Fatalf("\n%s\nImportResources(...): unexpected error: %v", tc.reason, err)
+			}
+			if diff := cmp.Diff(tc.want, r.applyStatus); diff != "" {
+				t.Errorf("\n%s\nImportResources(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
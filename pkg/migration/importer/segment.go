@@ -0,0 +1,139 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package importer
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"sort"
+
+	"github.com/pterm/pterm"
+	"gopkg.in/yaml.v3"
+
+	"github.com/upbound/up/pkg/migration/meta/v1alpha1"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+)
+
+const segmentManifestFile = "segments.yaml"
+
+// segments returns the segments of an export segmented by namespace, in the
+// order they are imported: the cluster scoped segment first, then namespaces
+// in lexicographic order. It returns no segments if the input is not a
+// directory with a segment manifest.
+func (im *ControlPlaneStateImporter) segments() ([]v1alpha1.Segment, error) {
+	if im.options.InputArchive == "-" || im.options.InputOCIRef != "" {
+		return nil, nil
+	}
+	fs := im.inputFS()
+	if ok, err := fs.IsDir(im.options.InputArchive); err != nil || !ok {
+		return nil, nil //nolint:nilerr // Missing inputs are reported when opening them.
+	}
+	mf := filepath.Join(im.options.InputArchive, segmentManifestFile)
+	if ok, err := fs.Exists(mf); err != nil || !ok {
+		return nil, errors.Wrapf(err, "cannot read segment manifest %q", mf)
+	}
+	b, err := fs.ReadFile(mf)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot read segment manifest %q", mf)
+	}
+	m := &v1alpha1.SegmentManifest{}
+	if err := yaml.Unmarshal(b, m); err != nil {
+		return nil, errors.Wrapf(err, "cannot unmarshal segment manifest %q", mf)
+	}
+	if len(m.Segments) == 0 {
+		return nil, errors.Errorf("segment manifest %q lists no segments", mf)
+	}
+	sort.SliceStable(m.Segments, func(i, j int) bool {
+		return m.Segments[i].Namespace < m.Segments[j].Namespace
+	})
+	return m.Segments, nil
+}
+
+// forSegment returns an importer for the archive of the supplied segment,
+// sharing the clients and global deadline of this importer.
+func (im *ControlPlaneStateImporter) forSegment(s v1alpha1.Segment) *ControlPlaneStateImporter {
+	sub := *im
+	sub.fs, sub.spill = nil, nil
+	sub.options.InputArchive = filepath.Join(im.options.InputArchive, s.Archive)
+	sub.options.InputFormat = InputFormatArchive
+	return &sub
+}
+
+// importSegments imports the segments of an export segmented by namespace
+// one after the other. Resources stay paused until all segments are imported,
+// so that Composites are not reconciled before the Claims of later segments
+// exist, and a failing segment stops the import. If the import is rolled back
+// on failure, the resources created by all segments are deleted.
+func (im *ControlPlaneStateImporter) importSegments(ctx context.Context, segments []v1alpha1.Segment) (err error) {
+	if im.options.DryRun {
+		for _, s := range segments {
+			printSegment(s)
+			sub := im.forSegment(s)
+			err := sub.dryRun(ctx)
+			_ = sub.Close()
+			if err != nil {
+				return errors.Wrapf(err, "cannot import segment %q", s.Archive)
+			}
+		}
+		return nil
+	}
+
+	applier, journal, closeLog, err := im.newApplier()
+	if err != nil {
+		return err
+	}
+	defer closeLog()
+	if journal != nil {
+		defer func() {
+			if err != nil {
+				err = im.rollback(ctx, journal, err)
+			}
+		}()
+	}
+
+	var history *ImportHistory
+	var em *v1alpha1.ExportMeta
+	var skipped []string
+	for _, s := range segments {
+		printSegment(s)
+		sub := im.forSegment(s)
+		var sk []string
+		history, em, err = sub.prepare(ctx)
+		if err == nil {
+			sk, err = sub.importResources(ctx, applier)
+		}
+		_ = sub.Close()
+		if err != nil {
+			return errors.Wrapf(err, "cannot import segment %q", s.Archive)
+		}
+		for _, gr := range sk {
+			if !slices.Contains(skipped, gr) {
+				skipped = append(skipped, gr)
+			}
+		}
+	}
+	// All segments share the metadata of the export they were split from.
+	return im.finalize(ctx, applier, history, em, skipped)
+}
+
+func printSegment(s v1alpha1.Segment) {
+	name := s.Namespace
+	if name == "" {
+		name = "cluster scoped resources"
+	}
+	pterm.Info.Printfln("Importing segment %q (%s)", s.Archive, name)
+}
@@ -0,0 +1,244 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package importer
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/pterm/pterm"
+	"github.com/spf13/afero"
+)
+
+const (
+	// defaultMaxMemoryMB is how many megabytes of unarchived files are kept
+	// in memory by default.
+	defaultMaxMemoryMB = 512
+	// maxInMemoryFileSize is the size in bytes above which unarchived files
+	// are always written to disk.
+	maxInMemoryFileSize = 1 << 20
+)
+
+// spillFs is a file system keeping the files written to it in memory, as
+// long as they are small and all of them fit into a memory budget. Other
+// files are spilled to a temporary directory on disk. Reads see the files in
+// memory and on disk as one file system. It is meant to unarchive an export
+// into, and to be read afterwards.
+type spillFs struct {
+	// Fs is the file system the files are read from, i.e. mem as long as no
+	// file was spilled, and the union of mem and disk afterwards.
+	afero.Fs
+
+	mem afero.Fs
+	// disk is created on demand by newDisk, as small exports are kept in
+	// memory entirely.
+	disk    afero.Fs
+	newDisk func() (fs afero.Fs, cleanup func() error, err error)
+	cleanup func() error
+
+	maxFileSize int64
+	maxMemory   int64
+
+	mu sync.Mutex
+	// inMemory is the total size of the files in mem.
+	inMemory int64
+}
+
+// newSpillFs returns a file system keeping files of at most maxFileSize bytes
+// in memory, up to maxMemory bytes in total.
+func newSpillFs(maxMemory, maxFileSize int64) *spillFs {
+	mem := afero.NewMemMapFs()
+	return &spillFs{
+		Fs:          mem,
+		mem:         mem,
+		newDisk:     tempDirFs,
+		maxFileSize: maxFileSize,
+		maxMemory:   maxMemory,
+	}
+}
+
+// tempDirFs returns a file system rooted at a new temporary directory, and a
+// function removing it.
+func tempDirFs() (afero.Fs, func() error, error) {
+	dir, err := os.MkdirTemp("", "up-migration-import-")
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "cannot create temporary directory")
+	}
+	pterm.Debug.Printfln("Spilling unarchived files exceeding the memory limit to %s", dir)
+	return afero.NewBasePathFs(afero.NewOsFs(), dir), func() error { return os.RemoveAll(dir) }, nil
+}
+
+// Create creates a file that is kept in memory until it is spilled to disk.
+func (s *spillFs) Create(name string) (afero.File, error) {
+	return s.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
+}
+
+// OpenFile opens a file. Files opened for writing are created in memory
+// until they are spilled to disk.
+func (s *spillFs) OpenFile(name string, flag int, perm os.FileMode) (afero.File, error) {
+	if flag&(os.O_WRONLY|os.O_RDWR|os.O_CREATE|os.O_TRUNC|os.O_APPEND) == 0 {
+		return s.Fs.OpenFile(name, flag, perm)
+	}
+	if flag&os.O_TRUNC == 0 {
+		return nil, errors.Errorf("cannot open %q: only new files can be written", name)
+	}
+	f, err := s.mem.OpenFile(name, flag, perm)
+	if err != nil {
+		return nil, err
+	}
+	return &spillFile{File: f, fs: s, name: name}, nil
+}
+
+// Mkdir creates a directory in memory.
+func (s *spillFs) Mkdir(name string, perm os.FileMode) error {
+	return s.mem.Mkdir(name, perm)
+}
+
+// MkdirAll creates a directory and its parents in memory.
+func (s *spillFs) MkdirAll(name string, perm os.FileMode) error {
+	return s.mem.MkdirAll(name, perm)
+}
+
+// Name returns the name of the file system.
+func (s *spillFs) Name() string {
+	return "SpillFs"
+}
+
+// Close removes the files spilled to disk.
+func (s *spillFs) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cleanup == nil {
+		return nil
+	}
+	err := s.cleanup()
+	s.cleanup = nil
+	return errors.Wrap(err, "cannot remove spilled files")
+}
+
+// reserve returns true if n more bytes of a file of size bytes fit into
+// memory, and reserves them if so.
+func (s *spillFs) reserve(size, n int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if size+n > s.maxFileSize || s.inMemory+n > s.maxMemory {
+		return false
+	}
+	s.inMemory += n
+	return true
+}
+
+// release releases n bytes reserved in memory.
+func (s *spillFs) release(n int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.inMemory -= n
+}
+
+// diskFs returns the file system files are spilled to, creating it if
+// necessary.
+func (s *spillFs) diskFs() (afero.Fs, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.disk != nil {
+		return s.disk, nil
+	}
+	disk, cleanup, err := s.newDisk()
+	if err != nil {
+		return nil, err
+	}
+	s.disk, s.cleanup = disk, cleanup
+	// Files in memory take precedence, but spilled files are removed from
+	// memory.
+	s.Fs = afero.NewCopyOnWriteFs(disk, s.mem)
+	return disk, nil
+}
+
+// spillFile is a file written to a spillFs. It is moved to disk once it does
+// not fit into memory anymore.
+type spillFile struct {
+	afero.File
+	fs     *spillFs
+	name   string
+	size   int64
+	onDisk bool
+}
+
+// Write writes p to the file, spilling it to disk first if p does not fit
+// into memory.
+func (f *spillFile) Write(p []byte) (int, error) {
+	n := int64(len(p))
+	if !f.onDisk && !f.fs.reserve(f.size, n) {
+		if err := f.spill(); err != nil {
+			return 0, err
+		}
+	}
+	w, err := f.File.Write(p)
+	f.size += int64(w)
+	if !f.onDisk && int64(w) < n {
+		f.fs.release(n - int64(w))
+	}
+	return w, err
+}
+
+// WriteString writes s to the file.
+func (f *spillFile) WriteString(s string) (int, error) {
+	return f.Write([]byte(s))
+}
+
+// WriteAt is not supported, as files are written sequentially.
+func (f *spillFile) WriteAt(_ []byte, _ int64) (int, error) {
+	return 0, errors.Errorf("cannot write %q at an offset", f.name)
+}
+
+// spill moves the file written so far from memory to disk.
+func (f *spillFile) spill() error {
+	disk, err := f.fs.diskFs()
+	if err != nil {
+		return err
+	}
+	if err := f.File.Close(); err != nil {
+		return errors.Wrapf(err, "cannot close %q in memory", f.name)
+	}
+	if err := disk.MkdirAll(filepath.Dir(f.name), 0700); err != nil {
+		return errors.Wrapf(err, "cannot create directory for %q on disk", f.name)
+	}
+	df, err := disk.OpenFile(f.name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return errors.Wrapf(err, "cannot create %q on disk", f.name)
+	}
+	mf, err := f.fs.mem.Open(f.name)
+	if err != nil {
+		_ = df.Close()
+		return errors.Wrapf(err, "cannot open %q in memory", f.name)
+	}
+	_, err = io.Copy(df, mf)
+	_ = mf.Close()
+	if err != nil {
+		_ = df.Close()
+		return errors.Wrapf(err, "cannot spill %q to disk", f.name)
+	}
+	if err := f.fs.mem.Remove(f.name); err != nil {
+		_ = df.Close()
+		return errors.Wrapf(err, "cannot remove %q from memory", f.name)
+	}
+	f.fs.release(f.size)
+	f.File = df
+	f.onDisk = true
+	return nil
+}
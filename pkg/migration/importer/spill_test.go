@@ -0,0 +1,132 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package importer
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/afero"
+
+	"github.com/upbound/up/pkg/migration/archiver"
+)
+
+func TestSpillFsUnarchive(t *testing.T) {
+	files := map[string]string{
+		"export.yaml": "version: v1alpha1\n",
+		"configmaps/namespaces/default/small.yaml": strings.Repeat("a", 10),
+		"configmaps/namespaces/default/large.yaml": strings.Repeat("b", 30),
+		"secrets/namespaces/default/small.yaml":    strings.Repeat("c", 10),
+		"secrets/namespaces/default/other.yaml":    strings.Repeat("d", 10),
+	}
+
+	type args struct {
+		maxMemory   int64
+		maxFileSize int64
+	}
+	type want struct {
+		spilled []string
+	}
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"InMemory": {
+			reason: "All files should be kept in memory if they fit.",
+			args: args{
+				maxMemory:   1 << 10,
+				maxFileSize: 1 << 10,
+			},
+		},
+		"LargeFile": {
+			reason: "Files larger than the maximum file size should be spilled to disk.",
+			args: args{
+				maxMemory:   1 << 10,
+				maxFileSize: 20,
+			},
+			want: want{
+				spilled: []string{"configmaps/namespaces/default/large.yaml"},
+			},
+		},
+		"MemoryExceeded": {
+			reason: "Files exceeding the memory limit should be spilled to disk.",
+			args: args{
+				maxMemory:   60,
+				maxFileSize: 40,
+			},
+			want: want{
+				// Archives are written in lexical order.
+				spilled: []string{"secrets/namespaces/default/other.yaml", "secrets/namespaces/default/small.yaml"},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			src := exportedState(t, files)
+			buf := &bytes.Buffer{}
+			if err := archiver.Archive(context.Background(), src, ".", buf); err != nil {
+				t.Fatalf("cannot write archive: %v", err)
+			}
+
+			disk := afero.NewMemMapFs()
+			removed := false
+			s := newSpillFs(tc.args.maxMemory, tc.args.maxFileSize)
+			s.newDisk = func() (afero.Fs, func() error, error) {
+				return disk, func() error { removed = true; return nil }, nil
+			}
+			fs := afero.Afero{Fs: s}
+			if err := archiver.Unarchive(context.Background(), buf, fs); err != nil {
+				t.Fatalf("\n%s\nUnarchive(...): unexpected error: %v", tc.reason, err)
+			}
+
+			got := map[string]string{}
+			var spilled []string
+			err := fs.Walk(".", func(path string, info os.FileInfo, err error) error {
+				if err != nil || info.IsDir() {
+					return err
+				}
+				b, err := fs.ReadFile(path)
+				if err != nil {
+					return err
+				}
+				got[path] = string(b)
+				if ok, _ := afero.Exists(disk, path); ok {
+					spilled = append(spilled, path)
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("\n%s\nWalk(...): unexpected error: %v", tc.reason, err)
+			}
+			if diff := cmp.Diff(files, got); diff != "" {
+				t.Errorf("\n%s\nReadFile(...): -want, +got:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.spilled, spilled); diff != "" {
+				t.Errorf("\n%s\nspilled files: -want, +got:\n%s", tc.reason, diff)
+			}
+			if err := s.Close(); err != nil {
+				t.Fatalf("\n%s\nClose(): unexpected error: %v", tc.reason, err)
+			}
+			if diff := cmp.Diff(tc.want.spilled != nil, removed); diff != "" {
+				t.Errorf("\n%s\nClose(): removed disk: -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
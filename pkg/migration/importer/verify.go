@@ -0,0 +1,63 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package importer
+
+import (
+	"context"
+	"path"
+
+	"github.com/upbound/up/pkg/migration/archiver"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+)
+
+// Verify compares the checksums of all exported files to the checksum
+// manifest embedded by the exporter, without connecting to the control
+// plane. It returns all files that do not match. The paths of files of an
+// export segmented by namespace are prefixed with the archive of their
+// segment.
+func (im *ControlPlaneStateImporter) Verify(ctx context.Context) ([]archiver.ChecksumMismatch, error) {
+	defer im.Close() //nolint:errcheck // Only temporary files are left behind.
+	segments, err := im.segments()
+	if err != nil {
+		return nil, err
+	}
+	if len(segments) == 0 {
+		if im.fs == nil {
+			if err := im.open(ctx); err != nil {
+				return nil, err
+			}
+		}
+		return archiver.VerifyChecksums(*im.fs, ".")
+	}
+
+	var mismatches []archiver.ChecksumMismatch
+	for _, s := range segments {
+		sub := im.forSegment(s)
+		if err := sub.open(ctx); err != nil {
+			return nil, errors.Wrapf(err, "cannot open segment %q", s.Archive)
+		}
+		mm, err := archiver.VerifyChecksums(*sub.fs, ".")
+		_ = sub.Close()
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot verify segment %q", s.Archive)
+		}
+		for _, m := range mm {
+			m.Path = path.Join(s.Archive, m.Path)
+			mismatches = append(mismatches, m)
+		}
+	}
+	return mismatches, nil
+}
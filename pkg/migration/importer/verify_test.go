@@ -0,0 +1,99 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package importer
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	"github.com/upbound/up/pkg/migration/archiver"
+)
+
+func TestControlPlaneStateImporterVerify(t *testing.T) {
+	type args struct {
+		// corrupt is written to the config map after the checksums were
+		// written, if set.
+		corrupt string
+		// key encrypts the archive, if set.
+		key []byte
+	}
+	type want struct {
+		mismatches []archiver.ChecksumMismatch
+	}
+
+	cases := map[string]struct {
+		args args
+		want want
+	}{
+		"Intact": {
+			want: want{},
+		},
+		"Encrypted": {
+			args: args{
+				key: bytes.Repeat([]byte{0x2a}, archiver.EncryptionKeySize),
+			},
+			want: want{},
+		},
+		"Corrupted": {
+			args: args{
+				corrupt: "kind: Secret\n",
+			},
+			want: want{
+				mismatches: []archiver.ChecksumMismatch{{Path: "configmaps/namespaces/default/config.yaml"}},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			fs := exportedState(t, map[string]string{
+				"export.yaml": "version: v1alpha1\n",
+				"configmaps/namespaces/default/config.yaml": configMapYAML,
+			})
+			if err := archiver.WriteChecksums(fs, "."); err != nil {
+				t.Fatalf("cannot write checksums: %v", err)
+			}
+			if tc.args.corrupt != "" {
+				if err := fs.WriteFile("configmaps/namespaces/default/config.yaml", []byte(tc.args.corrupt), 0600); err != nil {
+					t.Fatalf("cannot corrupt config map: %v", err)
+				}
+			}
+			in := &bytes.Buffer{}
+			opts := Options{InputArchive: "-", InputReader: in}
+			var aopts []archiver.ArchiveOption
+			if tc.args.key != nil {
+				aopts = append(aopts, archiver.WithEncryption(tc.args.key))
+				opts.EncryptionKey = base64.StdEncoding.EncodeToString(tc.args.key)
+			}
+			if err := archiver.Archive(context.Background(), fs, ".", in, aopts...); err != nil {
+				t.Fatalf("cannot write archive: %v", err)
+			}
+
+			im := NewControlPlaneStateImporter(nil, nil, nil, nil, opts)
+			mismatches, err := im.Verify(context.Background())
+			if err != nil {
+				t.Fatalf("Verify(...): unexpected error: %v", err)
+			}
+			// The digests are covered by the archiver tests.
+			if diff := cmp.Diff(tc.want.mismatches, mismatches, cmpopts.IgnoreFields(archiver.ChecksumMismatch{}, "Expected", "Actual")); diff != "" {
+				t.Errorf("\n%s\nVerify(...): -want, +got:\n%s", name, diff)
+			}
+		})
+	}
+}
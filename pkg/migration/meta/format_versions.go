@@ -0,0 +1,151 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package meta contains the versioning of the format of exports.
+package meta
+
+import (
+	"github.com/spf13/afero"
+	"k8s.io/apimachinery/pkg/util/version"
+	"sigs.k8s.io/yaml"
+
+	"github.com/upbound/up/pkg/migration/meta/v1alpha1"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+)
+
+const (
+	// ExportMetaFile is the name of the file with the export metadata.
+	ExportMetaFile = "export.yaml"
+
+	// FormatVersionV1_0_0 is the format of exports that did not record their
+	// format version.
+	FormatVersionV1_0_0 = "v1.0.0"
+	// FormatVersionV1_1_0 records the distribution of Crossplane as either
+	// "crossplane" or "universal-crossplane" instead of the name of its Helm
+	// release.
+	FormatVersionV1_1_0 = "v1.1.0"
+	// FormatVersionV1_2_0 keeps the UIDs of exported resources, so that they
+	// can optionally be preserved on import.
+	FormatVersionV1_2_0 = "v1.2.0"
+
+	// CurrentFormatVersion is the format version of new exports.
+	CurrentFormatVersion = FormatVersionV1_2_0
+)
+
+// A formatMigration migrates an export from the previous format version to
+// its format version. Format versions that only add information to exports
+// have no migrate function.
+type formatMigration struct {
+	version string
+	migrate func(em *v1alpha1.ExportMeta, fs afero.Fs) error
+}
+
+// formatMigrations are all format versions after v1.0.0, oldest first.
+var formatMigrations = []formatMigration{
+	{version: FormatVersionV1_1_0, migrate: normalizeDistribution},
+	{version: FormatVersionV1_2_0},
+}
+
+// SupportedFormatVersions returns the format versions that can be imported,
+// oldest first.
+func SupportedFormatVersions() []string {
+	versions := []string{FormatVersionV1_0_0}
+	for _, m := range formatMigrations {
+		versions = append(versions, m.version)
+	}
+	return versions
+}
+
+// FormatVersion returns the format version of the export with the supplied
+// metadata.
+func FormatVersion(em *v1alpha1.ExportMeta) string {
+	if em.FormatVersion == "" {
+		return FormatVersionV1_0_0
+	}
+	return em.FormatVersion
+}
+
+// CheckFormatVersion returns an error if exports of format version v cannot be
+// imported, e.g. because they were created by a newer version of up.
+func CheckFormatVersion(v string) error {
+	fv, err := version.ParseSemantic(v)
+	if err != nil {
+		return errors.Wrapf(err, "cannot parse export format version %q", v)
+	}
+	if version.MustParseSemantic(CurrentFormatVersion).LessThan(fv) {
+		return errors.Errorf("export format version %s is newer than the supported version %s, please upgrade up", v, CurrentFormatVersion)
+	}
+	for _, s := range SupportedFormatVersions() {
+		if c, err := fv.Compare(s); err == nil && c == 0 {
+			return nil
+		}
+	}
+	return errors.Errorf("unknown export format version %s", v)
+}
+
+// MigrateFormat migrates the export in fs from format version from to the
+// newer format version to, and records the new format version in its
+// metadata.
+func MigrateFormat(from, to string, fs afero.Fs) error {
+	for _, v := range []string{from, to} {
+		if err := CheckFormatVersion(v); err != nil {
+			return err
+		}
+	}
+	fv, tv := version.MustParseSemantic(from), version.MustParseSemantic(to)
+	if tv.LessThan(fv) {
+		return errors.Errorf("cannot migrate export format version %s to older version %s", from, to)
+	}
+
+	afs := afero.Afero{Fs: fs}
+	b, err := afs.ReadFile(ExportMetaFile)
+	if err != nil {
+		return errors.Wrap(err, "cannot read export metadata")
+	}
+	em := &v1alpha1.ExportMeta{}
+	if err := yaml.Unmarshal(b, em); err != nil {
+		return errors.Wrap(err, "cannot unmarshal export metadata")
+	}
+	for _, m := range formatMigrations {
+		mv := version.MustParseSemantic(m.version)
+		if m.migrate == nil || !fv.LessThan(mv) || tv.LessThan(mv) {
+			continue
+		}
+		if err := m.migrate(em, fs); err != nil {
+			return errors.Wrapf(err, "cannot migrate export to format version %s", m.version)
+		}
+	}
+
+	em.FormatVersion = to
+	b, err = yaml.Marshal(em)
+	if err != nil {
+		return errors.Wrap(err, "cannot marshal export metadata")
+	}
+	return errors.Wrap(afs.WriteFile(ExportMetaFile, b, 0600), "cannot write export metadata")
+}
+
+// normalizeDistribution replaces the name of the Helm release Crossplane was
+// installed with by its distribution.
+func normalizeDistribution(em *v1alpha1.ExportMeta, _ afero.Fs) error {
+	if em.Crossplane.Version == "" && em.Crossplane.Distribution == "" {
+		// Crossplane was not found on the exported control plane.
+		return nil
+	}
+	em.Crossplane.Distribution = v1alpha1.DistributionCrossplane
+	if em.Crossplane.IsUniversalCrossplane() {
+		em.Crossplane.Distribution = v1alpha1.DistributionUniversalCrossplane
+	}
+	return nil
+}
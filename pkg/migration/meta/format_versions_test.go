@@ -0,0 +1,170 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package meta
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/afero"
+	"sigs.k8s.io/yaml"
+
+	"github.com/upbound/up/pkg/migration/meta/v1alpha1"
+)
+
+func TestCheckFormatVersion(t *testing.T) {
+	cases := map[string]struct {
+		version string
+		wantErr bool
+	}{
+		"Legacy": {
+			version: FormatVersionV1_0_0,
+		},
+		"Current": {
+			version: CurrentFormatVersion,
+		},
+		"Newer": {
+			version: "v1.3.0",
+			wantErr: true,
+		},
+		"Unknown": {
+			version: "v1.0.1",
+			wantErr: true,
+		},
+		"Invalid": {
+			version: "latest",
+			wantErr: true,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := CheckFormatVersion(tc.version)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("\n%s\nCheckFormatVersion(%q): want error %t, got %v", name, tc.version, tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestMigrateFormat(t *testing.T) {
+	type args struct {
+		from string
+		to   string
+		meta *v1alpha1.ExportMeta
+	}
+	type want struct {
+		meta *v1alpha1.ExportMeta
+		err  bool
+	}
+	cases := map[string]struct {
+		args args
+		want want
+	}{
+		"CrossplaneV1_0_0ToV1_1_0": {
+			args: args{
+				from: FormatVersionV1_0_0,
+				to:   FormatVersionV1_1_0,
+				meta: &v1alpha1.ExportMeta{
+					Version:    "v1alpha1",
+					Crossplane: v1alpha1.CrossplaneInfo{Distribution: "my-crossplane", Version: "1.14.5"},
+				},
+			},
+			want: want{
+				meta: &v1alpha1.ExportMeta{
+					Version:       "v1alpha1",
+					FormatVersion: FormatVersionV1_1_0,
+					Crossplane:    v1alpha1.CrossplaneInfo{Distribution: v1alpha1.DistributionCrossplane, Version: "1.14.5"},
+				},
+			},
+		},
+		"UniversalCrossplaneV1_0_0ToV1_1_0": {
+			args: args{
+				from: FormatVersionV1_0_0,
+				to:   FormatVersionV1_1_0,
+				meta: &v1alpha1.ExportMeta{
+					Version:    "v1alpha1",
+					Crossplane: v1alpha1.CrossplaneInfo{Distribution: "uxp", Version: "1.14.5-up.1"},
+				},
+			},
+			want: want{
+				meta: &v1alpha1.ExportMeta{
+					Version:       "v1alpha1",
+					FormatVersion: FormatVersionV1_1_0,
+					Crossplane:    v1alpha1.CrossplaneInfo{Distribution: v1alpha1.DistributionUniversalCrossplane, Version: "1.14.5-up.1"},
+				},
+			},
+		},
+		"NoCrossplane": {
+			args: args{
+				from: FormatVersionV1_0_0,
+				to:   FormatVersionV1_1_0,
+				meta: &v1alpha1.ExportMeta{Version: "v1alpha1"},
+			},
+			want: want{
+				meta: &v1alpha1.ExportMeta{Version: "v1alpha1", FormatVersion: FormatVersionV1_1_0},
+			},
+		},
+		"NoUIDsV1_1_0ToV1_2_0": {
+			args: args{
+				from: FormatVersionV1_1_0,
+				to:   FormatVersionV1_2_0,
+				meta: &v1alpha1.ExportMeta{Version: "v1alpha1", FormatVersion: FormatVersionV1_1_0},
+			},
+			want: want{
+				meta: &v1alpha1.ExportMeta{Version: "v1alpha1", FormatVersion: FormatVersionV1_2_0},
+			},
+		},
+		"Downgrade": {
+			args: args{
+				from: FormatVersionV1_1_0,
+				to:   FormatVersionV1_0_0,
+				meta: &v1alpha1.ExportMeta{Version: "v1alpha1", FormatVersion: FormatVersionV1_1_0},
+			},
+			want: want{
+				meta: &v1alpha1.ExportMeta{Version: "v1alpha1", FormatVersion: FormatVersionV1_1_0},
+				err:  true,
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			fs := afero.Afero{Fs: afero.NewMemMapFs()}
+			b, err := yaml.Marshal(tc.args.meta)
+			if err != nil {
+				t.Fatalf("cannot marshal export metadata: %v", err)
+			}
+			if err := fs.WriteFile(ExportMetaFile, b, 0600); err != nil {
+				t.Fatalf("cannot write export metadata: %v", err)
+			}
+
+			err = MigrateFormat(tc.args.from, tc.args.to, fs.Fs)
+			if (err != nil) != tc.want.err {
+				t.Fatalf("\n%s\nMigrateFormat(...): want error %t, got %v", name, tc.want.err, err)
+			}
+
+			b, err = fs.ReadFile(ExportMetaFile)
+			if err != nil {
+				t.Fatalf("cannot read export metadata: %v", err)
+			}
+			got := &v1alpha1.ExportMeta{}
+			if err := yaml.Unmarshal(b, got); err != nil {
+				t.Fatalf("cannot unmarshal export metadata: %v", err)
+			}
+			if diff := cmp.Diff(tc.want.meta, got); diff != "" {
+				t.Errorf("\n%s\nMigrateFormat(...): -want, +got:\n%s", name, diff)
+			}
+		})
+	}
+}
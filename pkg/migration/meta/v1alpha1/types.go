@@ -15,13 +15,38 @@
 package v1alpha1
 
 import (
+	"strings"
 	"time"
 )
 
 // Directory structure for export:
 // export.yaml (with ExportMeta below)
+// audit-history.yaml (optional, with AuditHistory below)
+// dependency-graph.json (with DependencyGraph below)
 // <groupResource>/<cluster or namespace>/<?namespace>/<name>.yaml
 // <groupResource>/metadata.yaml (with TypeMeta below)
+// <groupResource>/order.yaml (optional, with ResourceOrder below)
+//
+// For content addressable exports, resource files are stored by hash instead:
+// <groupResource>/objects/<sha256>.yaml
+// <groupResource>/manifest.yaml (with ContentManifest below)
+//
+// Exports segmented by namespace are a directory of archives instead, each
+// with the structure above:
+// segments.yaml (with SegmentManifest below)
+// _cluster.tar.gz (cluster scoped resources)
+// <namespace>.tar.gz (resources in the namespace and the namespace itself)
+
+// AnnotationSkip marks a resource as not to be migrated if set to "true".
+// Resources annotated in the source control plane are not exported.
+// Resources annotated in an export, e.g. by editing the YAML file of an export
+// directory, keep the annotation in their file for traceability, but are not
+// imported.
+const AnnotationSkip = "migration.upbound.io/skip"
+
+// AnnotationSecretsRedacted marks a Secret whose data was redacted during the
+// export if set to "true".
+const AnnotationSecretsRedacted = "migration.upbound.io/secrets-redacted"
 
 // TypeMeta is the metadata for a given resource type.
 type TypeMeta struct {
@@ -31,6 +56,80 @@ type TypeMeta struct {
 	WithStatusSubresource bool `json:"withStatusSubresource,omitempty" yaml:"withStatusSubresource,omitempty"`
 }
 
+// ContentManifest maps resource identities to the hashes of their content in a
+// content addressable export.
+type ContentManifest struct {
+	// Resources maps the identity of a resource, i.e. "cluster/<name>" or
+	// "namespaces/<namespace>/<name>", to the SHA-256 hash of its content.
+	Resources map[string]string `json:"resources,omitempty" yaml:"resources,omitempty"`
+}
+
+// ResourceOrder is the order the resources of a group resource should be
+// imported in, e.g. owners before the resources they own.
+type ResourceOrder struct {
+	// Resources are the identities of the resources, i.e. "cluster/<name>"
+	// or "namespaces/<namespace>/<name>", in import order.
+	Resources []string `json:"resources,omitempty" yaml:"resources,omitempty"`
+}
+
+// Segment is a single archive of an export segmented by namespace.
+type Segment struct {
+	// Namespace is the namespace of the resources in the segment. It is empty
+	// for the segment with the cluster scoped resources.
+	Namespace string `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	// Archive is the name of the archive file of the segment, relative to the
+	// segment manifest.
+	Archive string `json:"archive" yaml:"archive"`
+}
+
+// SegmentManifest lists the segments of an export segmented by namespace.
+type SegmentManifest struct {
+	// Segments are the segments of the export, the cluster scoped segment
+	// first and namespaces in lexicographic order.
+	Segments []Segment `json:"segments,omitempty" yaml:"segments,omitempty"`
+}
+
+// Mutation is a single mutation of a resource recorded in the audit log.
+type Mutation struct {
+	// Timestamp is the time at which the mutation was completed.
+	Timestamp time.Time `json:"timestamp,omitempty" yaml:"timestamp,omitempty"`
+	// Verb is the verb of the request, e.g. "create", "update" or "patch".
+	Verb string `json:"verb,omitempty" yaml:"verb,omitempty"`
+	// User is the user that made the request.
+	User string `json:"user,omitempty" yaml:"user,omitempty"`
+	// Subresource is the subresource that was mutated, if any.
+	Subresource string `json:"subresource,omitempty" yaml:"subresource,omitempty"`
+	// Code is the HTTP status code of the response.
+	Code int32 `json:"code,omitempty" yaml:"code,omitempty"`
+}
+
+// AuditHistory is the condensed mutation history of the exported resources.
+type AuditHistory struct {
+	// Resources maps "<groupResource>/<cluster or namespaces/namespace>/<name>"
+	// to the most recent mutations of that resource, oldest first.
+	Resources map[string][]Mutation `json:"resources,omitempty" yaml:"resources,omitempty"`
+}
+
+// Dependency is a dependency of one exported resource type on another.
+type Dependency struct {
+	// From is the group resource of the dependent type.
+	From string `json:"from" yaml:"from"`
+	// To is the group resource of the type From depends on.
+	To string `json:"to" yaml:"to"`
+	// Reason is why From depends on To, e.g. "ProviderConfig" or
+	// "OwnerReference".
+	Reason string `json:"reason" yaml:"reason"`
+}
+
+// DependencyGraph describes which exported resource types depend on which
+// other types, for use by external tooling.
+type DependencyGraph struct {
+	// Types are the group resources of all exported types.
+	Types []string `json:"types,omitempty" yaml:"types,omitempty"`
+	// Dependencies are the edges of the graph.
+	Dependencies []Dependency `json:"dependencies,omitempty" yaml:"dependencies,omitempty"`
+}
+
 // ExportStats are the statistics about the exported resources.
 type ExportStats struct {
 	// Total is the total number of resources exported.
@@ -41,6 +140,13 @@ type ExportStats struct {
 	CustomResources map[string]int `json:"customResources,omitempty" yaml:"customResources,omitempty"`
 }
 
+const (
+	// DistributionCrossplane is the open source distribution of Crossplane.
+	DistributionCrossplane = "crossplane"
+	// DistributionUniversalCrossplane is Upbound Universal Crossplane (UXP).
+	DistributionUniversalCrossplane = "universal-crossplane"
+)
+
 // CrossplaneInfo is the information about the Crossplane instance on the exported control plane.
 type CrossplaneInfo struct {
 	// Distribution is the distribution of Crossplane, e.g. "crossplane" or "universal-crossplane".
@@ -51,6 +157,29 @@ type CrossplaneInfo struct {
 	Version string `json:"version,omitempty" yaml:"version,omitempty"`
 	// FeatureFlags are the feature flags enabled in Crossplane.
 	FeatureFlags []string `json:"featureFlags,omitempty" yaml:"featureFlags,omitempty"`
+	// Registry is the default registry Crossplane pulls packages without a
+	// registry from, if it is not the default of Crossplane.
+	Registry string `json:"registry,omitempty" yaml:"registry,omitempty"`
+	// Providers are the providers installed in the control plane.
+	Providers []ProviderInfo `json:"providers,omitempty" yaml:"providers,omitempty"`
+}
+
+// ProviderInfo is the information about a provider installed in the exported
+// control plane.
+type ProviderInfo struct {
+	// Name is the name of the Provider.
+	Name string `json:"name" yaml:"name"`
+	// Version is the version of the provider package, i.e. its tag or digest.
+	Version string `json:"version,omitempty" yaml:"version,omitempty"`
+	// Healthy is true if the provider was healthy.
+	Healthy bool `json:"healthy" yaml:"healthy"`
+}
+
+// IsUniversalCrossplane returns true if the Crossplane instance is Upbound
+// Universal Crossplane. Exports that predate recording the distribution are
+// recognized by the "-up" suffix of the version.
+func (i *CrossplaneInfo) IsUniversalCrossplane() bool {
+	return i.Distribution == DistributionUniversalCrossplane || strings.Contains(i.Version, "-up.")
 }
 
 // ExportOptions are the options used to create the export.
@@ -59,12 +188,30 @@ type ExportOptions struct {
 	IncludedNamespaces []string `json:"includedNamespaces,omitempty" yaml:"includedNamespaces,omitempty"`
 	// ExcludedNamespaces are the namespaces excluded from the export.
 	ExcludedNamespaces []string `json:"excludedNamespaces,omitempty" yaml:"excludedNamespaces,omitempty"`
+	// ExcludedNamespacePatterns are the glob patterns of namespaces excluded
+	// from the export.
+	ExcludedNamespacePatterns []string `json:"excludedNamespacePatterns,omitempty" yaml:"excludedNamespacePatterns,omitempty"`
+	// IncludedHelmReleases are the Helm releases whose resources are included
+	// in the export.
+	IncludedHelmReleases []string `json:"includedHelmReleases,omitempty" yaml:"includedHelmReleases,omitempty"`
+	// CompositionLabelSelector is the label selector of the
+	// CompositeResourceDefinitions whose types were exported.
+	CompositionLabelSelector string `json:"compositionLabelSelector,omitempty" yaml:"compositionLabelSelector,omitempty"`
+	// FieldSelector is the field selector the exported resources matched.
+	FieldSelector string `json:"fieldSelector,omitempty" yaml:"fieldSelector,omitempty"`
+	// LabelSelector is the label selector the exported resources matched.
+	LabelSelector string `json:"labelSelector,omitempty" yaml:"labelSelector,omitempty"`
 	// IncludedExtraResources are the resources included in the export.
 	IncludedExtraResources []string `json:"includedExtraResources,omitempty" yaml:"includedResources,omitempty"`
 	// ExcludedResources are the resources excluded from the export.
 	ExcludedResources []string `json:"excludedResources,omitempty" yaml:"excludedResources,omitempty"`
 	// PausedBeforeExport stores whether the resources were paused before the export.
 	PausedBeforeExport bool `json:"pausedBeforeExport,omitempty" yaml:"pausedBeforeExport,omitempty"`
+	// ContentAddressable stores whether resources were stored by the hash of their content.
+	ContentAddressable bool `json:"contentAddressable,omitempty" yaml:"contentAddressable,omitempty"`
+	// SegmentedByNamespace stores whether the export was split into one
+	// archive per namespace.
+	SegmentedByNamespace bool `json:"segmentedByNamespace,omitempty" yaml:"segmentedByNamespace,omitempty"`
 }
 
 // ExportMeta is the top level metadata for an export.
@@ -72,12 +219,34 @@ type ExportMeta struct {
 	// Version is the API version of the export. This will be used to determine
 	// compatibility with the importer once we evolve the export format.
 	Version string `json:"version,omitempty" yaml:"version,omitempty"`
+	// FormatVersion is the semantic version of the layout of the export,
+	// which is migrated by the importer if it is older than the format
+	// version of the importer. Exports without a format version have format
+	// version v1.0.0.
+	FormatVersion string `json:"formatVersion,omitempty" yaml:"formatVersion,omitempty"`
 	// ExportedAt is the time at which the export was created.
 	ExportedAt time.Time `json:"exportedAt,omitempty" yaml:"exportedAt,omitempty"`
+	// ChangedSince is set for differential exports, which only contain the
+	// resources changed since this time. They can only be imported into
+	// control planes that already received an export taken at or after it.
+	ChangedSince *time.Time `json:"changedSince,omitempty" yaml:"changedSince,omitempty"`
 	// Options are the options used to create the export.
 	Options ExportOptions `json:"options,omitempty" yaml:"options,omitempty"`
 	// Crossplane is the information about the Crossplane instance on the exported control plane.
 	Crossplane CrossplaneInfo `json:"crossplane,omitempty" yaml:"crossplane,omitempty"`
 	// Stats are the statistics about the exported resources.
 	Stats ExportStats `json:"stats,omitempty" yaml:"stats,omitempty"`
+	// ResourceTypeDurations are how long exporting the resources of each
+	// type took, keyed by group resource, e.g. "1.5s". They are informational
+	// only, to find the types slowing down an export.
+	ResourceTypeDurations map[string]string `json:"resourceTypeDurations,omitempty" yaml:"resourceTypeDurations,omitempty"`
+	// OversizedResources are the resources that were not exported because
+	// they exceeded the maximum resource size, as
+	// "<groupResource>/<namespace>/<name>", or "<groupResource>/<name>" for
+	// cluster scoped resources.
+	OversizedResources []string `json:"oversizedResources,omitempty" yaml:"oversizedResources,omitempty"`
+	// Warnings are problems detected during the export that may affect the
+	// import, e.g. CRDs with conversion webhooks. They are reported by the
+	// preflight checks of the importer.
+	Warnings []string `json:"warnings,omitempty" yaml:"warnings,omitempty"`
 }
@@ -0,0 +1,293 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package namespacemove moves Crossplane resources between the namespaces of
+// a control plane.
+package namespacemove
+
+import (
+	"context"
+	"fmt"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
+)
+
+const (
+	// labelClaimNamespace is the label of composite resources holding the
+	// namespace of their claim.
+	labelClaimNamespace = "crossplane.io/claim-namespace"
+)
+
+var (
+	namespacesGVR = schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}
+	secretsGVR    = schema.GroupVersionResource{Version: "v1", Resource: "secrets"}
+)
+
+// object is a resource together with its GVR.
+type object struct {
+	gvr schema.GroupVersionResource
+	u   unstructured.Unstructured
+}
+
+// Mover moves Crossplane resources between namespaces.
+type Mover struct {
+	dynamicClient   dynamic.Interface
+	discoveryClient discovery.DiscoveryInterface
+
+	deleteOriginals bool
+}
+
+// Option configures a Mover.
+type Option func(*Mover)
+
+// WithDeleteOriginals deletes the moved resources from their original
+// namespace, without triggering their deletion logic.
+func WithDeleteOriginals() Option {
+	return func(m *Mover) {
+		m.deleteOriginals = true
+	}
+}
+
+// NewMover returns a new Mover.
+func NewMover(dyn dynamic.Interface, dis discovery.DiscoveryInterface, opts ...Option) *Mover {
+	m := &Mover{
+		dynamicClient:   dyn,
+		discoveryClient: dis,
+	}
+	for _, o := range opts {
+		o(m)
+	}
+	return m
+}
+
+// MoveResources moves all claims in namespace from to namespace to, together
+// with the Secrets referenced by them and by cluster scoped Crossplane
+// resources, e.g. connection secrets and provider credentials. References of
+// cluster scoped Crossplane resources to the moved resources are updated.
+func (m *Mover) MoveResources(ctx context.Context, from, to string) error { // nolint:gocyclo // Sequential steps, easier to follow in one place.
+	if from == to {
+		return errors.Errorf("cannot move resources from namespace %q to itself", from)
+	}
+	claimTypes, clusterTypes, err := m.crossplaneTypes()
+	if err != nil {
+		return err
+	}
+
+	var claims []object
+	secrets := map[string]bool{}
+	for _, gvr := range claimTypes {
+		l, err := m.dynamicClient.Resource(gvr).Namespace(from).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return errors.Wrapf(err, "cannot list %q", gvr.GroupResource())
+		}
+		for _, c := range l.Items {
+			if name, err := fieldpath.Pave(c.Object).GetString("spec.writeConnectionSecretToRef.name"); err == nil {
+				secrets[name] = true
+			}
+			claims = append(claims, object{gvr: gvr, u: c})
+		}
+	}
+
+	// Rewrite the references of cluster scoped resources, to be updated once
+	// the referenced resources were copied.
+	var referencing []object
+	for _, gvr := range clusterTypes {
+		l, err := m.dynamicClient.Resource(gvr).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return errors.Wrapf(err, "cannot list %q", gvr.GroupResource())
+		}
+		for _, r := range l.Items {
+			changed := rewriteReferences(r.Object["spec"], from, to, func(ref map[string]any) {
+				if kind, ok := ref["kind"].(string); !ok || kind == "Secret" {
+					secrets[ref["name"].(string)] = true
+				}
+			})
+			if r.GetLabels()[labelClaimNamespace] == from {
+				labels := r.GetLabels()
+				labels[labelClaimNamespace] = to
+				r.SetLabels(labels)
+				changed = true
+			}
+			if changed {
+				referencing = append(referencing, object{gvr: gvr, u: r})
+			}
+		}
+	}
+
+	var moved []object
+	for name := range secrets {
+		s, err := m.dynamicClient.Resource(secretsGVR).Namespace(from).Get(ctx, name, metav1.GetOptions{})
+		if kerrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return errors.Wrapf(err, "cannot get secret %q", name)
+		}
+		moved = append(moved, object{gvr: secretsGVR, u: *s})
+	}
+	moved = append(moved, claims...)
+
+	if err := m.ensureNamespace(ctx, to); err != nil {
+		return err
+	}
+	for i := range moved {
+		if err := m.copyTo(ctx, moved[i], to); err != nil {
+			return err
+		}
+	}
+	for _, r := range referencing {
+		if _, err := m.dynamicClient.Resource(r.gvr).Update(ctx, &r.u, metav1.UpdateOptions{}); err != nil {
+			return errors.Wrapf(err, "cannot update references of %s", resourceName(r.u))
+		}
+	}
+
+	if !m.deleteOriginals {
+		return nil
+	}
+	for i := range moved {
+		if err := m.orphanDelete(ctx, moved[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// crossplaneTypes returns the namespaced claim types and the cluster scoped
+// Crossplane types served by the control plane.
+func (m *Mover) crossplaneTypes() ([]schema.GroupVersionResource, []schema.GroupVersionResource, error) {
+	_, lists, err := m.discoveryClient.ServerGroupsAndResources()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "cannot discover served types")
+	}
+	seen := map[schema.GroupResource]bool{}
+	var claimTypes, clusterTypes []schema.GroupVersionResource
+	for _, l := range lists {
+		gv, err := schema.ParseGroupVersion(l.GroupVersion)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "cannot parse group version %q", l.GroupVersion)
+		}
+		for _, r := range l.APIResources {
+			gvr := gv.WithResource(r.Name)
+			if seen[gvr.GroupResource()] || len(r.Verbs) > 0 && !contains(r.Verbs, "list") {
+				continue
+			}
+			seen[gvr.GroupResource()] = true
+			switch {
+			case r.Namespaced && contains(r.Categories, "claim"):
+				claimTypes = append(claimTypes, gvr)
+			case !r.Namespaced && (contains(r.Categories, "crossplane") || contains(r.Categories, "composite")):
+				clusterTypes = append(clusterTypes, gvr)
+			}
+		}
+	}
+	return claimTypes, clusterTypes, nil
+}
+
+func (m *Mover) ensureNamespace(ctx context.Context, name string) error {
+	ns := &unstructured.Unstructured{}
+	ns.SetAPIVersion("v1")
+	ns.SetKind("Namespace")
+	ns.SetName(name)
+	_, err := m.dynamicClient.Resource(namespacesGVR).Create(ctx, ns, metav1.CreateOptions{})
+	if err != nil && !kerrors.IsAlreadyExists(err) {
+		return errors.Wrapf(err, "cannot create namespace %q", name)
+	}
+	return nil
+}
+
+func (m *Mover) copyTo(ctx context.Context, o object, namespace string) error {
+	c := o.u.DeepCopy()
+	c.SetNamespace(namespace)
+	c.SetUID("")
+	c.SetResourceVersion("")
+	c.SetGeneration(0)
+	c.SetCreationTimestamp(metav1.Time{})
+	c.SetManagedFields(nil)
+	c.SetOwnerReferences(nil)
+	c.SetFinalizers(nil)
+	c.SetSelfLink("")
+	if _, err := m.dynamicClient.Resource(o.gvr).Namespace(namespace).Create(ctx, c, metav1.CreateOptions{}); err != nil {
+		return errors.Wrapf(err, "cannot copy %s to namespace %q", resourceName(o.u), namespace)
+	}
+	return nil
+}
+
+// orphanDelete removes the finalizers of a resource before deleting it, so
+// that its controller does not delete the resources it composed or created.
+func (m *Mover) orphanDelete(ctx context.Context, o object) error {
+	u := o.u
+	ri := m.dynamicClient.Resource(o.gvr).Namespace(u.GetNamespace())
+	if len(u.GetFinalizers()) > 0 {
+		u.SetFinalizers(nil)
+		if _, err := ri.Update(ctx, &u, metav1.UpdateOptions{}); err != nil {
+			return errors.Wrapf(err, "cannot remove finalizers of %s", resourceName(u))
+		}
+	}
+	if err := ri.Delete(ctx, u.GetName(), metav1.DeleteOptions{}); err != nil && !kerrors.IsNotFound(err) {
+		return errors.Wrapf(err, "cannot delete %s", resourceName(u))
+	}
+	return nil
+}
+
+// rewriteReferences rewrites all references to objects in namespace from to
+// point to namespace to, calling fn for every rewritten reference. References
+// are objects with a name and a namespace.
+func rewriteReferences(v any, from, to string, fn func(ref map[string]any)) bool {
+	changed := false
+	switch t := v.(type) {
+	case map[string]any:
+		_, named := t["name"].(string)
+		if ns, ok := t["namespace"].(string); ok && named && ns == from {
+			t["namespace"] = to
+			fn(t)
+			changed = true
+		}
+		for _, e := range t {
+			if rewriteReferences(e, from, to, fn) {
+				changed = true
+			}
+		}
+	case []any:
+		for _, e := range t {
+			if rewriteReferences(e, from, to, fn) {
+				changed = true
+			}
+		}
+	}
+	return changed
+}
+
+func resourceName(u unstructured.Unstructured) string {
+	if u.GetNamespace() != "" {
+		return fmt.Sprintf("%s %s/%s", u.GetKind(), u.GetNamespace(), u.GetName())
+	}
+	return fmt.Sprintf("%s %s", u.GetKind(), u.GetName())
+}
+
+func contains(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
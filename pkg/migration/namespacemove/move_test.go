@@ -0,0 +1,193 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package namespacemove
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
+)
+
+var (
+	claimsGVR          = schema.GroupVersionResource{Group: "example.org", Version: "v1", Resource: "clusters"}
+	compositesGVR      = schema.GroupVersionResource{Group: "example.org", Version: "v1", Resource: "xclusters"}
+	bucketsGVR         = schema.GroupVersionResource{Group: "s3.aws.upbound.io", Version: "v1beta1", Resource: "buckets"}
+	providerConfigsGVR = schema.GroupVersionResource{Group: "aws.upbound.io", Version: "v1beta1", Resource: "providerconfigs"}
+)
+
+func newObject(gvr schema.GroupVersionResource, kind, namespace, name string, spec map[string]any) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{Object: map[string]any{"spec": spec}}
+	u.SetAPIVersion(gvr.GroupVersion().String())
+	u.SetKind(kind)
+	u.SetNamespace(namespace)
+	u.SetName(name)
+	return u
+}
+
+func TestMoverMoveResources(t *testing.T) {
+	list := []string{"get", "list", "create", "update", "delete"}
+	resources := []*metav1.APIResourceList{
+		{GroupVersion: "example.org/v1", APIResources: []metav1.APIResource{
+			{Name: "clusters", Kind: "Cluster", Namespaced: true, Categories: []string{"claim"}, Verbs: list},
+			{Name: "xclusters", Kind: "XCluster", Categories: []string{"composite"}, Verbs: list},
+		}},
+		{GroupVersion: "s3.aws.upbound.io/v1beta1", APIResources: []metav1.APIResource{
+			{Name: "buckets", Kind: "Bucket", Categories: []string{"crossplane", "managed", "aws"}, Verbs: list},
+		}},
+		{GroupVersion: "aws.upbound.io/v1beta1", APIResources: []metav1.APIResource{
+			{Name: "providerconfigs", Kind: "ProviderConfig", Categories: []string{"crossplane", "provider", "aws"}, Verbs: list},
+		}},
+	}
+	listKinds := map[schema.GroupVersionResource]string{
+		claimsGVR:          "ClusterList",
+		compositesGVR:      "XClusterList",
+		bucketsGVR:         "BucketList",
+		providerConfigsGVR: "ProviderConfigList",
+		secretsGVR:         "SecretList",
+		namespacesGVR:      "NamespaceList",
+	}
+
+	objects := func() []runtime.Object {
+		claim := newObject(claimsGVR, "Cluster", "team-a", "db", map[string]any{
+			"writeConnectionSecretToRef": map[string]any{"name": "db-conn"},
+			"resourceRef":                map[string]any{"apiVersion": "example.org/v1", "kind": "XCluster", "name": "db-x1"},
+		})
+		claim.SetFinalizers([]string{"finalizer.apiextensions.crossplane.io"})
+		claim.SetUID("claim-uid")
+		xr := newObject(compositesGVR, "XCluster", "", "db-x1", map[string]any{
+			"claimRef": map[string]any{"apiVersion": "example.org/v1", "kind": "Cluster", "name": "db", "namespace": "team-a"},
+		})
+		xr.SetLabels(map[string]string{labelClaimNamespace: "team-a"})
+		return []runtime.Object{
+			claim,
+			xr,
+			newObject(bucketsGVR, "Bucket", "", "bucket", map[string]any{
+				"writeConnectionSecretToRef": map[string]any{"name": "bucket-conn", "namespace": "crossplane-system"},
+			}),
+			newObject(providerConfigsGVR, "ProviderConfig", "", "default", map[string]any{
+				"credentials": map[string]any{"secretRef": map[string]any{"name": "aws-creds", "namespace": "team-a", "key": "creds"}},
+			}),
+			newObject(secretsGVR, "Secret", "team-a", "db-conn", nil),
+			newObject(secretsGVR, "Secret", "team-a", "aws-creds", nil),
+			newObject(secretsGVR, "Secret", "team-a", "unrelated", nil),
+		}
+	}
+
+	type want struct {
+		inTarget   []string
+		inSource   []string
+		references map[string]string
+	}
+
+	cases := map[string]struct {
+		opts []Option
+		want want
+	}{
+		"Copy": {
+			want: want{
+				inTarget: []string{"clusters/db", "secrets/db-conn", "secrets/aws-creds"},
+				inSource: []string{"clusters/db", "secrets/db-conn", "secrets/aws-creds", "secrets/unrelated"},
+				references: map[string]string{
+					"xclusters/db-x1:spec.claimRef.namespace":                        "team-b",
+					"xclusters/db-x1:metadata.labels[crossplane.io/claim-namespace]": "team-b",
+					"providerconfigs/default:spec.credentials.secretRef.namespace":   "team-b",
+					"buckets/bucket:spec.writeConnectionSecretToRef.namespace":       "crossplane-system",
+				},
+			},
+		},
+		"DeleteOriginals": {
+			opts: []Option{WithDeleteOriginals()},
+			want: want{
+				inTarget: []string{"clusters/db", "secrets/db-conn", "secrets/aws-creds"},
+				inSource: []string{"secrets/unrelated"},
+				references: map[string]string{
+					"xclusters/db-x1:spec.claimRef.namespace":                        "team-b",
+					"xclusters/db-x1:metadata.labels[crossplane.io/claim-namespace]": "team-b",
+					"providerconfigs/default:spec.credentials.secretRef.namespace":   "team-b",
+					"buckets/bucket:spec.writeConnectionSecretToRef.namespace":       "crossplane-system",
+				},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			dyn := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds, objects()...)
+			dis := kubefake.NewSimpleClientset().Discovery().(*fakediscovery.FakeDiscovery)
+			dis.Resources = resources
+
+			if err := NewMover(dyn, dis, tc.opts...).MoveResources(context.Background(), "team-a", "team-b"); err != nil {
+				t.Fatalf("MoveResources(...): unexpected error: %v", err)
+			}
+
+			exists := func(namespace string) []string {
+				var got []string
+				for _, gvr := range []schema.GroupVersionResource{claimsGVR, secretsGVR} {
+					l, err := dyn.Resource(gvr).Namespace(namespace).List(context.Background(), metav1.ListOptions{})
+					if err != nil {
+						t.Fatalf("cannot list %q: %v", gvr.Resource, err)
+					}
+					for _, u := range l.Items {
+						got = append(got, gvr.Resource+"/"+u.GetName())
+					}
+				}
+				return got
+			}
+			sorted := cmpopts.SortSlices(func(a, b string) bool { return a < b })
+			if diff := cmp.Diff(tc.want.inTarget, exists("team-b"), sorted); diff != "" {
+				t.Errorf("\n%s\nMoveResources(...): -want in target namespace, +got:\n%s", name, diff)
+			}
+			if diff := cmp.Diff(tc.want.inSource, exists("team-a"), sorted); diff != "" {
+				t.Errorf("\n%s\nMoveResources(...): -want in source namespace, +got:\n%s", name, diff)
+			}
+
+			claim, err := dyn.Resource(claimsGVR).Namespace("team-b").Get(context.Background(), "db", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("cannot get moved claim: %v", err)
+			}
+			if claim.GetUID() != "" || len(claim.GetFinalizers()) > 0 {
+				t.Errorf("moved claim kept uid %q and finalizers %v", claim.GetUID(), claim.GetFinalizers())
+			}
+
+			field := func(gvr schema.GroupVersionResource, name, path string) string {
+				u, err := dyn.Resource(gvr).Get(context.Background(), name, metav1.GetOptions{})
+				if err != nil {
+					t.Fatalf("cannot get %q: %v", name, err)
+				}
+				v, _ := fieldpath.Pave(u.Object).GetString(path)
+				return v
+			}
+			got := map[string]string{
+				"xclusters/db-x1:spec.claimRef.namespace":                        field(compositesGVR, "db-x1", "spec.claimRef.namespace"),
+				"xclusters/db-x1:metadata.labels[crossplane.io/claim-namespace]": field(compositesGVR, "db-x1", "metadata.labels[crossplane.io/claim-namespace]"),
+				"providerconfigs/default:spec.credentials.secretRef.namespace":   field(providerConfigsGVR, "default", "spec.credentials.secretRef.namespace"),
+				"buckets/bucket:spec.writeConnectionSecretToRef.namespace":       field(bucketsGVR, "bucket", "spec.writeConnectionSecretToRef.namespace"),
+			}
+			if diff := cmp.Diff(tc.want.references, got); diff != "" {
+				t.Errorf("\n%s\nMoveResources(...): -want references, +got:\n%s", name, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package telemetry traces the export and import of control plane state with
+// OpenTelemetry.
+package telemetry
+
+import (
+	"context"
+	"net/url"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	appsv1 "k8s.io/client-go/kubernetes/typed/apps/v1"
+
+	"github.com/upbound/up/pkg/migration/crossplane"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+)
+
+// TracerName is the name of the tracer used by the migration tooling.
+const TracerName = "github.com/upbound/up/pkg/migration"
+
+// ServiceName is the name of the service reported to the telemetry backend.
+const ServiceName = "up-controlplane-migrator"
+
+// Resource attribute keys describing a migration.
+const (
+	ClusterIDKey         = attribute.Key("upbound.migration.cluster_id")
+	CrossplaneVersionKey = attribute.Key("upbound.migration.crossplane_version")
+	ArchivePathKey       = attribute.Key("upbound.migration.archive_path")
+)
+
+// Span attribute keys describing a phase of a migration.
+const (
+	GroupResourceKey = attribute.Key("upbound.migration.group_resource")
+	GroupKindKey     = attribute.Key("upbound.migration.group_kind")
+)
+
+// NewTracerProvider returns a tracer provider exporting spans to the OTLP gRPC
+// endpoint, with the supplied resource attributes. The endpoint is either a
+// host:port pair, which is connected to over TLS, or a URL with an http or
+// https scheme. Callers must shut the provider down to flush pending spans.
+func NewTracerProvider(ctx context.Context, endpoint string, attrs ...attribute.KeyValue) (*sdktrace.TracerProvider, error) {
+	opts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(endpoint)}
+	if u, err := url.Parse(endpoint); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+		opts = []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(u.Host)}
+		if u.Scheme == "http" {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+	}
+	exp, err := otlptracegrpc.New(ctx, opts...)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot create OTLP trace exporter")
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(append([]attribute.KeyValue{
+		attribute.String("service.name", ServiceName),
+	}, attrs...)...))
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot build telemetry resource")
+	}
+
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exp),
+		sdktrace.WithResource(res),
+	), nil
+}
+
+// ResourceAttributes returns the cluster ID, Crossplane version and archive
+// path of a migration as resource attributes. The cluster ID is the UID of the
+// kube-system namespace. Attributes that cannot be determined are omitted.
+func ResourceAttributes(ctx context.Context, dynamicClient dynamic.Interface, appsClient appsv1.DeploymentsGetter, archive string) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{ArchivePathKey.String(archive)}
+
+	ns, err := dynamicClient.Resource(schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}).Get(ctx, "kube-system", v1.GetOptions{})
+	if err == nil {
+		attrs = append(attrs, ClusterIDKey.String(string(ns.GetUID())))
+	}
+	if xp, err := crossplane.CollectInfo(ctx, appsClient); err == nil && xp.Version != "" {
+		attrs = append(attrs, CrossplaneVersionKey.String(xp.Version))
+	}
+	return attrs
+}
+
+// StartSpan starts a span as a child of the span in ctx, using the same
+// tracer provider. If ctx has no span, the returned span is a no-op.
+func StartSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return trace.SpanFromContext(ctx).TracerProvider().Tracer(TracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// EndSpan records err, if any, on span and ends it.
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,82 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transform
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// ConversionTable maps the deprecated API versions of kinds to their current
+// API versions.
+type ConversionTable map[schema.GroupVersionKind]schema.GroupVersion
+
+// DefaultConversionTable converts the API versions of native Kubernetes kinds
+// that were removed in recent Kubernetes releases.
+var DefaultConversionTable = ConversionTable{
+	{Group: "extensions", Version: "v1beta1", Kind: "Ingress"}:                                          {Group: "networking.k8s.io", Version: "v1"},
+	{Group: "networking.k8s.io", Version: "v1beta1", Kind: "Ingress"}:                                   {Group: "networking.k8s.io", Version: "v1"},
+	{Group: "networking.k8s.io", Version: "v1beta1", Kind: "IngressClass"}:                              {Group: "networking.k8s.io", Version: "v1"},
+	{Group: "extensions", Version: "v1beta1", Kind: "NetworkPolicy"}:                                    {Group: "networking.k8s.io", Version: "v1"},
+	{Group: "extensions", Version: "v1beta1", Kind: "Deployment"}:                                       {Group: "apps", Version: "v1"},
+	{Group: "extensions", Version: "v1beta1", Kind: "DaemonSet"}:                                        {Group: "apps", Version: "v1"},
+	{Group: "extensions", Version: "v1beta1", Kind: "ReplicaSet"}:                                       {Group: "apps", Version: "v1"},
+	{Group: "apps", Version: "v1beta1", Kind: "Deployment"}:                                             {Group: "apps", Version: "v1"},
+	{Group: "apps", Version: "v1beta1", Kind: "StatefulSet"}:                                            {Group: "apps", Version: "v1"},
+	{Group: "apps", Version: "v1beta2", Kind: "Deployment"}:                                             {Group: "apps", Version: "v1"},
+	{Group: "apps", Version: "v1beta2", Kind: "DaemonSet"}:                                              {Group: "apps", Version: "v1"},
+	{Group: "apps", Version: "v1beta2", Kind: "ReplicaSet"}:                                             {Group: "apps", Version: "v1"},
+	{Group: "apps", Version: "v1beta2", Kind: "StatefulSet"}:                                            {Group: "apps", Version: "v1"},
+	{Group: "batch", Version: "v1beta1", Kind: "CronJob"}:                                               {Group: "batch", Version: "v1"},
+	{Group: "policy", Version: "v1beta1", Kind: "PodDisruptionBudget"}:                                  {Group: "policy", Version: "v1"},
+	{Group: "autoscaling", Version: "v2beta1", Kind: "HorizontalPodAutoscaler"}:                         {Group: "autoscaling", Version: "v2"},
+	{Group: "autoscaling", Version: "v2beta2", Kind: "HorizontalPodAutoscaler"}:                         {Group: "autoscaling", Version: "v2"},
+	{Group: "rbac.authorization.k8s.io", Version: "v1beta1", Kind: "Role"}:                              {Group: "rbac.authorization.k8s.io", Version: "v1"},
+	{Group: "rbac.authorization.k8s.io", Version: "v1beta1", Kind: "RoleBinding"}:                       {Group: "rbac.authorization.k8s.io", Version: "v1"},
+	{Group: "rbac.authorization.k8s.io", Version: "v1beta1", Kind: "ClusterRole"}:                       {Group: "rbac.authorization.k8s.io", Version: "v1"},
+	{Group: "rbac.authorization.k8s.io", Version: "v1beta1", Kind: "ClusterRoleBinding"}:                {Group: "rbac.authorization.k8s.io", Version: "v1"},
+	{Group: "scheduling.k8s.io", Version: "v1beta1", Kind: "PriorityClass"}:                             {Group: "scheduling.k8s.io", Version: "v1"},
+	{Group: "storage.k8s.io", Version: "v1beta1", Kind: "StorageClass"}:                                 {Group: "storage.k8s.io", Version: "v1"},
+	{Group: "storage.k8s.io", Version: "v1beta1", Kind: "CSIDriver"}:                                    {Group: "storage.k8s.io", Version: "v1"},
+	{Group: "apiextensions.k8s.io", Version: "v1beta1", Kind: "CustomResourceDefinition"}:               {Group: "apiextensions.k8s.io", Version: "v1"},
+	{Group: "admissionregistration.k8s.io", Version: "v1beta1", Kind: "ValidatingWebhookConfiguration"}: {Group: "admissionregistration.k8s.io", Version: "v1"},
+	{Group: "admissionregistration.k8s.io", Version: "v1beta1", Kind: "MutatingWebhookConfiguration"}:   {Group: "admissionregistration.k8s.io", Version: "v1"},
+}
+
+// APIVersionConverter converts the deprecated API versions of resources to
+// their current API versions. Only the API version is changed, the resources
+// must be valid in the current API version otherwise.
+type APIVersionConverter struct {
+	table ConversionTable
+}
+
+// NewAPIVersionConverter returns a new APIVersionConverter converting API
+// versions according to the supplied table.
+func NewAPIVersionConverter(table ConversionTable) *APIVersionConverter {
+	return &APIVersionConverter{
+		table: table,
+	}
+}
+
+// Transform sets the API version of u to its current API version, if its API
+// version is in the conversion table.
+func (c *APIVersionConverter) Transform(u *unstructured.Unstructured) error {
+	gv, ok := c.table[u.GroupVersionKind()]
+	if !ok {
+		return nil
+	}
+	u.SetAPIVersion(gv.String())
+	return nil
+}
@@ -0,0 +1,63 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transform
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestAPIVersionConverter(t *testing.T) {
+	cases := map[string]struct {
+		apiVersion string
+		kind       string
+		want       string
+	}{
+		"Deprecated": {
+			apiVersion: "extensions/v1beta1",
+			kind:       "Ingress",
+			want:       "networking.k8s.io/v1",
+		},
+		"DeprecatedInGroup": {
+			apiVersion: "batch/v1beta1",
+			kind:       "CronJob",
+			want:       "batch/v1",
+		},
+		"OtherKindOfDeprecatedVersion": {
+			apiVersion: "extensions/v1beta1",
+			kind:       "PodSecurityPolicy",
+			want:       "extensions/v1beta1",
+		},
+		"Current": {
+			apiVersion: "networking.k8s.io/v1",
+			kind:       "Ingress",
+			want:       "networking.k8s.io/v1",
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			u := &unstructured.Unstructured{}
+			u.SetAPIVersion(tc.apiVersion)
+			u.SetKind(tc.kind)
+			if err := NewAPIVersionConverter(DefaultConversionTable).Transform(u); err != nil {
+				t.Fatalf("Transform(...): unexpected error: %v", err)
+			}
+			if got := u.GetAPIVersion(); got != tc.want {
+				t.Errorf("Transform(...): apiVersion = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
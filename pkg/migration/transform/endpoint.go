@@ -0,0 +1,139 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transform
+
+import (
+	"regexp"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+)
+
+// schemeSeparator matches the separator between the old and the new endpoint
+// of a rewrite, if the new endpoint is a URL with a scheme.
+var schemeSeparator = regexp.MustCompile(`:[a-zA-Z][a-zA-Z0-9+.-]*://`)
+
+// EndpointRewrite replaces the endpoint Old with New in the ProviderConfigs of
+// the provider Provider.
+type EndpointRewrite struct {
+	// Provider is the provider type, e.g. provider-aws. It matches the
+	// ProviderConfigs of API groups whose first label is the provider name
+	// without the "provider-" prefix, e.g. aws.upbound.io.
+	Provider string
+	// Old is the endpoint to replace.
+	Old string
+	// New is the replacement endpoint.
+	New string
+}
+
+// ParseEndpointRewrite parses a rewrite in the "provider:old-url:new-url"
+// format.
+func ParseEndpointRewrite(s string) (EndpointRewrite, error) {
+	provider, endpoints, ok := strings.Cut(s, ":")
+	if !ok || provider == "" {
+		return EndpointRewrite{}, errors.Errorf("invalid endpoint rewrite %q, expected format is provider:old-url:new-url", s)
+	}
+
+	// Endpoints are usually URLs, which contain colons themselves. Split
+	// before the scheme of the new endpoint if there is one, otherwise the
+	// colon must be unambiguous.
+	sep := -1
+	start := strings.Index(endpoints, "://")
+	if start >= 0 {
+		start += len("://")
+	} else {
+		start = 0
+	}
+	rest := endpoints[start:]
+	if loc := schemeSeparator.FindStringIndex(rest); loc != nil {
+		sep = start + loc[0]
+	} else if strings.Count(rest, ":") == 1 {
+		sep = start + strings.Index(rest, ":")
+	}
+	if sep <= 0 || sep == len(endpoints)-1 {
+		return EndpointRewrite{}, errors.Errorf("invalid endpoint rewrite %q, expected format is provider:old-url:new-url", s)
+	}
+
+	return EndpointRewrite{
+		Provider: provider,
+		Old:      endpoints[:sep],
+		New:      endpoints[sep+1:],
+	}, nil
+}
+
+// ProviderConfigRewriter rewrites endpoints in the spec of ProviderConfigs.
+type ProviderConfigRewriter struct {
+	rewrites []EndpointRewrite
+}
+
+// NewProviderConfigRewriter returns a new ProviderConfigRewriter.
+func NewProviderConfigRewriter(rewrites []EndpointRewrite) *ProviderConfigRewriter {
+	return &ProviderConfigRewriter{
+		rewrites: rewrites,
+	}
+}
+
+// Transform replaces all matching endpoints in the spec of u, if it is a
+// ProviderConfig of a provider with configured rewrites. Endpoints match if
+// they are equal to the old endpoint or have it as a path prefix.
+func (r *ProviderConfigRewriter) Transform(u *unstructured.Unstructured) error {
+	gvk := u.GroupVersionKind()
+	if gvk.Kind != "ProviderConfig" {
+		return nil
+	}
+	name, _, _ := strings.Cut(gvk.Group, ".")
+
+	spec, ok := u.Object["spec"]
+	if !ok {
+		return nil
+	}
+	for _, rw := range r.rewrites {
+		if strings.TrimPrefix(rw.Provider, "provider-") != name {
+			continue
+		}
+		spec = rewrite(spec, rw.Old, rw.New)
+	}
+	u.Object["spec"] = spec
+	return nil
+}
+
+// rewrite replaces the endpoint from with to in all string values below v.
+func rewrite(v any, from, to string) any {
+	switch t := v.(type) {
+	case map[string]any:
+		for k, e := range t {
+			t[k] = rewrite(e, from, to)
+		}
+		return t
+	case []any:
+		for i, e := range t {
+			t[i] = rewrite(e, from, to)
+		}
+		return t
+	case string:
+		if t == from {
+			return to
+		}
+		base := strings.TrimSuffix(from, "/")
+		if strings.HasPrefix(t, base+"/") {
+			return strings.TrimSuffix(to, "/") + strings.TrimPrefix(t, base)
+		}
+		return t
+	default:
+		return v
+	}
+}
@@ -0,0 +1,185 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transform
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+func TestParseEndpointRewrite(t *testing.T) {
+	type want struct {
+		rw  EndpointRewrite
+		err bool
+	}
+
+	cases := map[string]struct {
+		in   string
+		want want
+	}{
+		"URLs": {
+			in: "provider-aws:https://s3.prod.example.com:443/api:https://s3.staging.example.com",
+			want: want{
+				rw: EndpointRewrite{Provider: "provider-aws", Old: "https://s3.prod.example.com:443/api", New: "https://s3.staging.example.com"},
+			},
+		},
+		"Hosts": {
+			in: "provider-gcp:prod.example.com:staging.example.com",
+			want: want{
+				rw: EndpointRewrite{Provider: "provider-gcp", Old: "prod.example.com", New: "staging.example.com"},
+			},
+		},
+		"Ambiguous": {
+			in:   "provider-gcp:prod.example.com:443:staging.example.com:443",
+			want: want{err: true},
+		},
+		"MissingNew": {
+			in:   "provider-aws:https://prod.example.com",
+			want: want{err: true},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			rw, err := ParseEndpointRewrite(tc.in)
+			if (err != nil) != tc.want.err {
+				t.Fatalf("ParseEndpointRewrite() error = %v, want error %t", err, tc.want.err)
+			}
+			if diff := cmp.Diff(tc.want.rw, rw, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("ParseEndpointRewrite() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestProviderConfigRewriterTransform(t *testing.T) {
+	rewrites := []EndpointRewrite{
+		{Provider: "provider-aws", Old: "https://prod.example.com", New: "https://staging.example.com"},
+	}
+
+	type want struct {
+		out string
+	}
+
+	cases := map[string]struct {
+		in   string
+		want want
+	}{
+		"RewriteEndpoints": {
+			in: `
+apiVersion: aws.upbound.io/v1beta1
+kind: ProviderConfig
+metadata:
+  name: default
+spec:
+  endpoint:
+    url:
+      type: Static
+      static: https://prod.example.com
+    services:
+    - s3
+  credentials:
+    source: Secret
+  extra:
+  - https://prod.example.com/sts
+  - https://prod.example.com.other.org
+`,
+			want: want{
+				out: `
+apiVersion: aws.upbound.io/v1beta1
+kind: ProviderConfig
+metadata:
+  name: default
+spec:
+  endpoint:
+    url:
+      type: Static
+      static: https://staging.example.com
+    services:
+    - s3
+  credentials:
+    source: Secret
+  extra:
+  - https://staging.example.com/sts
+  - https://prod.example.com.other.org
+`,
+			},
+		},
+		"OtherProvider": {
+			in: `
+apiVersion: gcp.upbound.io/v1beta1
+kind: ProviderConfig
+metadata:
+  name: default
+spec:
+  endpoint: https://prod.example.com
+`,
+			want: want{
+				out: `
+apiVersion: gcp.upbound.io/v1beta1
+kind: ProviderConfig
+metadata:
+  name: default
+spec:
+  endpoint: https://prod.example.com
+`,
+			},
+		},
+		"NotAProviderConfig": {
+			in: `
+apiVersion: s3.aws.upbound.io/v1beta1
+kind: Bucket
+metadata:
+  name: bucket
+spec:
+  forProvider:
+    endpoint: https://prod.example.com
+`,
+			want: want{
+				out: `
+apiVersion: s3.aws.upbound.io/v1beta1
+kind: Bucket
+metadata:
+  name: bucket
+spec:
+  forProvider:
+    endpoint: https://prod.example.com
+`,
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			u := &unstructured.Unstructured{}
+			if err := yaml.Unmarshal([]byte(tc.in), &u.Object); err != nil {
+				t.Fatalf("cannot unmarshal input: %v", err)
+			}
+			want := &unstructured.Unstructured{}
+			if err := yaml.Unmarshal([]byte(tc.want.out), &want.Object); err != nil {
+				t.Fatalf("cannot unmarshal output: %v", err)
+			}
+
+			if err := NewProviderConfigRewriter(rewrites).Transform(u); err != nil {
+				t.Fatalf("Transform() unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(want, u); diff != "" {
+				t.Errorf("Transform() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transform
+
+import (
+	"path"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+)
+
+const providerGroup = "pkg.crossplane.io"
+
+// ImageRewriteRule replaces the prefix From of package images with To, e.g.
+// to pull provider packages from a mirror registry.
+type ImageRewriteRule struct {
+	// From is the prefix to replace. It may be a glob pattern in path.Match
+	// syntax, e.g. "xpkg.upbound.io/*/", in which case the longest prefix
+	// matching it is replaced.
+	From string
+	// To is the replacement prefix.
+	To string
+}
+
+// ParseImageRewriteRule parses a rule in the "from=to" format.
+func ParseImageRewriteRule(s string) (ImageRewriteRule, error) {
+	from, to, ok := strings.Cut(s, "=")
+	if !ok || from == "" {
+		return ImageRewriteRule{}, errors.Errorf("invalid image rewrite %q, expected format is from=to", s)
+	}
+	if _, err := path.Match(from, ""); err != nil {
+		return ImageRewriteRule{}, errors.Wrapf(err, "invalid image rewrite pattern %q", from)
+	}
+	return ImageRewriteRule{From: from, To: to}, nil
+}
+
+// RewriteImage applies the first of the rules whose From pattern matches a
+// prefix of image, and returns image as is if none does.
+func RewriteImage(rules []ImageRewriteRule, image string) string {
+	for _, r := range rules {
+		for i := len(image); i > 0; i-- {
+			if ok, _ := path.Match(r.From, image[:i]); ok {
+				return r.To + image[i:]
+			}
+		}
+	}
+	return image
+}
+
+// ImageRewriter rewrites the package images of Providers.
+type ImageRewriter struct {
+	rules []ImageRewriteRule
+}
+
+// NewImageRewriter returns a new ImageRewriter.
+func NewImageRewriter(rules []ImageRewriteRule) *ImageRewriter {
+	return &ImageRewriter{
+		rules: rules,
+	}
+}
+
+// Transform rewrites spec.package of u, if it is a Provider.
+func (r *ImageRewriter) Transform(u *unstructured.Unstructured) error {
+	gvk := u.GroupVersionKind()
+	if gvk.Group != providerGroup || gvk.Kind != "Provider" {
+		return nil
+	}
+	image, ok, err := unstructured.NestedString(u.Object, "spec", "package")
+	if err != nil || !ok {
+		return errors.Wrapf(err, "cannot get package of provider %q", u.GetName())
+	}
+	return errors.Wrapf(unstructured.SetNestedField(u.Object, RewriteImage(r.rules, image), "spec", "package"), "cannot set package of provider %q", u.GetName())
+}
@@ -0,0 +1,131 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transform
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestParseImageRewriteRule(t *testing.T) {
+	type want struct {
+		rule ImageRewriteRule
+		err  bool
+	}
+
+	cases := map[string]struct {
+		in   string
+		want want
+	}{
+		"Prefix": {
+			in:   "xpkg.upbound.io/upbound/=my-registry.corp/",
+			want: want{rule: ImageRewriteRule{From: "xpkg.upbound.io/upbound/", To: "my-registry.corp/"}},
+		},
+		"MissingSeparator": {
+			in:   "xpkg.upbound.io/upbound/",
+			want: want{err: true},
+		},
+		"InvalidPattern": {
+			in:   "xpkg.upbound.io/[/=my-registry.corp/",
+			want: want{err: true},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			rule, err := ParseImageRewriteRule(tc.in)
+			if (err != nil) != tc.want.err {
+				t.Fatalf("ParseImageRewriteRule() error = %v, want error %t", err, tc.want.err)
+			}
+			if diff := cmp.Diff(tc.want.rule, rule); diff != "" {
+				t.Errorf("ParseImageRewriteRule() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRewriteImage(t *testing.T) {
+	const image = "xpkg.upbound.io/upbound/provider-aws:v0.43.0"
+
+	cases := map[string]struct {
+		rules []ImageRewriteRule
+		want  string
+	}{
+		"Prefix": {
+			rules: []ImageRewriteRule{{From: "xpkg.upbound.io/upbound/", To: "my-registry.corp/"}},
+			want:  "my-registry.corp/provider-aws:v0.43.0",
+		},
+		"Glob": {
+			rules: []ImageRewriteRule{{From: "xpkg.upbound.io/*/", To: "my-registry.corp/"}},
+			want:  "my-registry.corp/provider-aws:v0.43.0",
+		},
+		"FirstMatch": {
+			rules: []ImageRewriteRule{
+				{From: "index.docker.io/", To: "docker.corp/"},
+				{From: "xpkg.upbound.io/", To: "my-registry.corp/"},
+				{From: "xpkg.upbound.io/upbound/", To: "other-registry.corp/"},
+			},
+			want: "my-registry.corp/upbound/provider-aws:v0.43.0",
+		},
+		"NoMatch": {
+			rules: []ImageRewriteRule{{From: "index.docker.io/", To: "docker.corp/"}},
+			want:  image,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.want, RewriteImage(tc.rules, image)); diff != "" {
+				t.Errorf("RewriteImage() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestImageRewriterTransform(t *testing.T) {
+	rules := []ImageRewriteRule{{From: "xpkg.upbound.io/upbound/", To: "my-registry.corp/"}}
+	pkg := func(kind, image string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]any{
+			"apiVersion": "pkg.crossplane.io/v1",
+			"kind":       kind,
+			"metadata":   map[string]any{"name": "provider-aws"},
+			"spec":       map[string]any{"package": image},
+		}}
+	}
+
+	cases := map[string]struct {
+		in   *unstructured.Unstructured
+		want *unstructured.Unstructured
+	}{
+		"Provider": {
+			in:   pkg("Provider", "xpkg.upbound.io/upbound/provider-aws:v0.43.0"),
+			want: pkg("Provider", "my-registry.corp/provider-aws:v0.43.0"),
+		},
+		"NotAProvider": {
+			in:   pkg("Configuration", "xpkg.upbound.io/upbound/platform-ref-aws:v0.9.0"),
+			want: pkg("Configuration", "xpkg.upbound.io/upbound/platform-ref-aws:v0.9.0"),
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if err := NewImageRewriter(rules).Transform(tc.in); err != nil {
+				t.Fatalf("Transform() error = %v", err)
+			}
+			if diff := cmp.Diff(tc.want, tc.in); diff != "" {
+				t.Errorf("Transform() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}